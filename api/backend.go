@@ -1,6 +1,9 @@
 package api
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 type Backend interface {
 	Client
@@ -10,3 +13,18 @@ type Backend interface {
 
 	GraceTime(Container) time.Duration
 }
+
+// A Renamer is a backend which can change a container's handle, keeping the
+// container itself. Servers whose backend is not one fail renames with
+// ErrUnsupportedOperation.
+type Renamer interface {
+	Rename(oldHandle, newHandle string) error
+}
+
+// A Snapshotter is a backend which can snapshot a container's state, and
+// recreate the container from it later. Servers whose backend is not one fail
+// snapshots and restores with ErrUnsupportedOperation.
+type Snapshotter interface {
+	Snapshot(handle string) (io.ReadCloser, error)
+	Restore(snapshot io.Reader) (Container, error)
+}
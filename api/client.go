@@ -1,8 +1,6 @@
 package api
 
-import (
-	"time"
-)
+import "time"
 
 type Client interface {
 	Ping() error
@@ -24,8 +22,38 @@ type ContainerSpec struct {
 	Properties Properties
 	Env        []string
 	Privileged bool
+
+	// DefaultProcessUser is the user that processes run as when Run does not
+	// name one. Env is likewise the default environment of every process.
+	DefaultProcessUser string
+
+	// Image, if given, is an image for the backend to pull the container's
+	// root filesystem from, rather than RootFSPath.
+	Image ImageRef
+
+	// ExternalIP and ExternalInterface, if given, choose the host's address,
+	// or its network interface, that the container's outbound traffic leaves
+	// from, on hosts with more than one uplink. Backends which cannot place
+	// it there fail to create the container.
+	ExternalIP        string
+	ExternalInterface string
 }
 
+// ImageRef refers to an image in a registry, e.g. docker:///busybox or
+// oci://registry.example.com/some/image:tag, along with the credentials to
+// pull it with, if the registry needs them.
+type ImageRef struct {
+	URI      string
+	Username string
+	Password string
+}
+
+// The URI schemes of images which clients may create containers from.
+const (
+	ImageSchemeDocker = "docker"
+	ImageSchemeOCI    = "oci"
+)
+
 type BindMount struct {
 	SrcPath string
 	DstPath string
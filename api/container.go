@@ -2,6 +2,8 @@ package api
 
 import (
 	"io"
+	"net"
+	"time"
 )
 
 type Container interface {
@@ -9,10 +11,28 @@ type Container interface {
 
 	Stop(kill bool) error
 
+	// SignalAll sends a signal to every process in the container, without
+	// stopping the container itself.
+	SignalAll(Signal) error
+
+	// Pause freezes every process in the container, leaving its state intact
+	// until Resume thaws them.
+	Pause() error
+	Resume() error
+
+	// SetGraceTime changes how long the container may be idle before it is
+	// destroyed. Zero means it is never destroyed for being idle.
+	SetGraceTime(graceTime time.Duration) error
+
 	Info() (ContainerInfo, error)
 
-	StreamIn(dstPath string, tarStream io.Reader) error
-	StreamOut(srcPath string) (io.ReadCloser, error)
+	// InfoFields is like Info, but need only fill in the given fields, so
+	// that backends may skip collecting expensive stats nobody asked for.
+	InfoFields(fields []InfoField) (ContainerInfo, error)
+
+	StreamIn(spec StreamInSpec) error
+	VerifyStreamIn(dstPath string, tarStream io.Reader) (StreamInReport, error)
+	StreamOut(spec StreamOutSpec) (io.ReadCloser, error)
 
 	LimitBandwidth(limits BandwidthLimits) error
 	CurrentBandwidthLimits() (BandwidthLimits, error)
@@ -22,18 +42,32 @@ type Container interface {
 
 	LimitDisk(limits DiskLimits) error
 	CurrentDiskLimits() (DiskLimits, error)
+	DiskUsage() (ContainerDiskUsage, error)
 
 	LimitMemory(limits MemoryLimits) error
 	CurrentMemoryLimits() (MemoryLimits, error)
 
-	NetIn(hostPort, containerPort uint32) (uint32, uint32, error)
-	NetOut(network string, port uint32, portRange string, protocol Protocol) error
+	// NetInSpec maps a port on the host to a port in the container, returning
+	// both. A zero host port is chosen by the backend, and a zero container
+	// port is the same as the host port.
+	NetInSpec(spec NetInSpec) (uint32, uint32, error)
+	MappedPorts() ([]PortMapping, error)
+	NetOutRule(rule NetOutRule) error
 
 	Run(ProcessSpec, ProcessIO) (Process, error)
 	Attach(uint32, ProcessIO) (Process, error)
+	AttachAll(func(uint32) ProcessIO) ([]Process, error)
+
+	// ProcessInfo reports the state and resource usage of one of the
+	// container's processes, e.g. to find which is using the most CPU. It
+	// fails with ErrProcessNotFound if the container has no such process.
+	ProcessInfo(processID uint32) (ProcessInfo, error)
 
+	Properties() (Properties, error)
 	GetProperty(name string) (string, error)
 	SetProperty(name string, value string) error
+	SetProperties(properties Properties) error
+	CompareAndSwapProperty(name string, oldValue string, newValue string) (bool, error)
 	RemoveProperty(name string) error
 }
 
@@ -55,8 +89,18 @@ type ProcessSpec struct {
 	Privileged bool
 	User       string
 
+	// UID and GID run the process as a numeric user and group, whether or
+	// not the container knows them by name. UID may not be given with User;
+	// GID may, to override the user's group.
+	UID *uint32
+	GID *uint32
+
 	Limits ResourceLimits
 	TTY    *TTYSpec
+
+	// Labels are kept by the server for finding the process with
+	// FindProcesses; they have no effect on how it is run.
+	Labels map[string]string
 }
 
 type TTYSpec struct {
@@ -68,21 +112,187 @@ type WindowSize struct {
 	Rows    int
 }
 
+// ProcessIO connects a process to its caller. A nil Stdout or Stderr discards
+// that output; clients ask the server not to stream it at all.
 type ProcessIO struct {
 	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
+
+	// PassFiles has clients connected to the server over a unix socket pass
+	// those of Stdin, Stdout and Stderr which are *os.Files, e.g. pipes, to
+	// the server, for the process to read and write directly rather than
+	// having them copied over the connection. Other clients copy them.
+	PassFiles bool
+}
+
+// ReconnectSpec resumes the streams of a run process, e.g. after losing them
+// to its server restarting, from where they left off.
+type ReconnectSpec struct {
+	// Token is the one the server gave out when the process was run.
+	Token string
+
+	// StdoutOffset and StderrOffset are the bytes of each stream already
+	// received, which the server does not send again.
+	StdoutOffset uint64
+	StderrOffset uint64
+}
+
+// ExecSpec is a process for Exec to run, with its input.
+type ExecSpec struct {
+	ProcessSpec
+
+	Stdin io.Reader
+
+	// Timeout, if non-zero, bounds how long Exec waits for the process to
+	// exit.
+	Timeout time.Duration
 }
 
+type Signal uint8
+
+const (
+	SignalTerminate Signal = iota
+	SignalKill
+	SignalHangup
+	SignalInterrupt
+	SignalQuit
+	SignalUser1
+	SignalUser2
+)
+
 type Process interface {
 	ID() uint32
 	Wait() (int, error)
 	SetTTY(TTYSpec) error
+
+	// SetRlimits changes the resource limits of the running process. Backends
+	// which cannot change them after it has started return an error.
+	SetRlimits(ResourceLimits) error
+
+	// AllocateTTY gives a process started without a TTY one, in place of its
+	// stdin, stdout and stderr pipes, e.g. so that a debugger can attach an
+	// interactive terminal to it. Backends which cannot return
+	// ErrUnsupportedOperation.
+	AllocateTTY(TTYSpec) error
+}
+
+// A LogStream yields a container's retained process output, entry by entry.
+type LogStream interface {
+	// Next returns the next entry, or io.EOF once there are no more. When
+	// following, it blocks until an entry is written.
+	Next() (LogEntry, error)
+
+	Close() error
+}
+
+// LogEntry is a chunk of a process's output, as it was written.
+type LogEntry struct {
+	ProcessID uint32
+	Source    LogSource
+	Data      []byte
+	Time      time.Time
+}
+
+type LogSource string
+
+const (
+	LogSourceStdout LogSource = "stdout"
+	LogSourceStderr LogSource = "stderr"
+)
+
+// Compression is the compression of a tar stream. The server compresses and
+// decompresses streams itself, so backends are only given uncompressed ones.
+type Compression uint8
+
+const CompressionNone Compression = 0
+const CompressionGzip Compression = 1
+
+type StreamInSpec struct {
+	Path      string
+	TarStream io.Reader
+
+	// User is the container user to extract the files as; if empty, the
+	// backend's default is used.
+	User string
+
+	Compression Compression
+
+	// Atomic extracts the stream into a staging directory, and renames it
+	// into place only once extraction succeeds.
+	Atomic bool
+
+	// ExpectedSize, if non-zero, is the size of the tar stream, before any
+	// compression, so that servers may refuse streams which would not fit
+	// before reading them.
+	ExpectedSize uint64
+}
+
+type StreamOutSpec struct {
+	Path string
+
+	// User is the container user to read the files as; if empty, the
+	// backend's default is used.
+	User string
+
+	Compression Compression
+
+	// Offset, if non-zero, resumes a stream which was interrupted, skipping
+	// that many bytes of the archive, before any compression. Servers skip
+	// them, so backends are asked for the whole archive: resuming costs the
+	// backend producing, and the server reading, everything before the
+	// offset again, though none of it is sent. It may not be given for
+	// gzipped streams.
+	Offset uint64
+
+	// Cancel, if not nil, is closed once the files are no longer wanted,
+	// e.g. as the client went away mid-transfer, even while the stream is
+	// not being read. Backends should then stop producing the archive
+	// promptly; the stream is closed too, but perhaps only once a read of it
+	// has returned.
+	Cancel <-chan struct{}
+}
+
+// StreamInReport describes what streaming an archive in would do, without
+// having extracted it.
+type StreamInReport struct {
+	Entries uint64
+	Bytes   uint64
+
+	Problems []StreamInProblem
+}
+
+// Valid reports whether the archive could be streamed in.
+func (r StreamInReport) Valid() bool {
+	return len(r.Problems) == 0
+}
+
+type StreamInProblem struct {
+	Path   string
+	Reason string
+}
+
+type NetInSpec struct {
+	HostPort      uint32
+	ContainerPort uint32
+
+	// Protocol is ProtocolTCP or ProtocolUDP. Servers pass backends
+	// ProtocolTCP if it is not given.
+	Protocol Protocol
+
+	// HostIP is the host address to bind the host port on; if nil, it is
+	// bound on all of the host's addresses.
+	HostIP net.IP
 }
 
 type PortMapping struct {
 	HostPort      uint32
 	ContainerPort uint32
+
+	// Protocol and HostIP are as the mapping's NetInSpec gave them. Clients
+	// take mappings without a protocol, e.g. from older servers, to be TCP.
+	Protocol Protocol
+	HostIP   net.IP
 }
 
 type ContainerInfo struct {
@@ -99,6 +309,81 @@ type ContainerInfo struct {
 	BandwidthStat ContainerBandwidthStat
 	Properties    Properties
 	MappedPorts   []PortMapping
+	Held          bool
+	GraceTime     time.Duration
+
+	// CreatedAt is when the container was created, or zero if the backend
+	// does not know.
+	CreatedAt time.Time
+
+	// ExternalInterface is the host's network interface that the container's
+	// outbound traffic leaves from, if the backend chose one.
+	ExternalInterface string
+
+	// TimeToGraceExpiry is how long was left of the container's grace time,
+	// before it is destroyed for being idle, when the server was asked for
+	// its info. It is zero if the container was not counting down: it was in
+	// use or held, or has no grace time. Like any other request, asking for
+	// the container's info restarts the countdown.
+	TimeToGraceExpiry time.Duration
+
+	// LastActivityAt is when a request last used the container, before the
+	// one asking for its info, or zero if the server does not know.
+	LastActivityAt time.Time
+
+	// Limits are those applied to the container when the server was asked
+	// for its info, as its Current*Limits report them.
+	Limits ContainerLimits
+}
+
+// ContainerLimits are the limits applied to a container, each nil if the
+// backend could not report it, e.g. as it does not support that limit.
+type ContainerLimits struct {
+	Bandwidth *BandwidthLimits
+	CPU       *CPULimits
+	Disk      *DiskLimits
+	Memory    *MemoryLimits
+}
+
+// ProcessMatcher picks out the processes WaitForProcess waits for. The zero
+// matcher matches any process.
+type ProcessMatcher struct {
+	// ID, if given, matches only the process with that ID.
+	ID uint32
+
+	// Labels, if given, match only processes run with every one of them,
+	// which, like FindProcesses, are only known for processes run through
+	// the server.
+	Labels map[string]string
+}
+
+// ProcessInfo is a snapshot of a process's state and resource usage.
+type ProcessInfo struct {
+	ID    uint32
+	State ProcessState
+
+	// CPUTime is the CPU time the process has used, in user and system mode.
+	CPUTime time.Duration
+
+	// RSSInBytes is the process's resident set size.
+	RSSInBytes uint64
+
+	// StartedAt is when the process started, or zero if the backend does not
+	// know.
+	StartedAt time.Time
+}
+
+type ProcessState string
+
+const (
+	ProcessStateRunning ProcessState = "running"
+	ProcessStateExited  ProcessState = "exited"
+)
+
+type ContainerSummary struct {
+	Handle     string
+	State      string
+	Properties Properties
 }
 
 type ContainerMemoryStat struct {
@@ -155,6 +440,21 @@ type BandwidthLimits struct {
 	BurstRateInBytesPerSecond uint64
 }
 
+// ContainerDiskUsage breaks down the disk used by a container.
+type ContainerDiskUsage struct {
+	RootFSLayers []DiskUsageEntry
+	Scratch      DiskUsageEntry
+	BindMounts   []DiskUsageEntry
+}
+
+// DiskUsageEntry is the disk used by a rootfs layer (named by its id), the
+// container's scratch space, or a bind mount (named by its destination).
+type DiskUsageEntry struct {
+	Name       string
+	BytesUsed  uint64
+	InodesUsed uint64
+}
+
 type DiskLimits struct {
 	BlockSoft uint64
 	BlockHard uint64
@@ -172,6 +472,14 @@ type MemoryLimits struct {
 
 type CPULimits struct {
 	LimitInShares uint64
+
+	// Quota caps the CPU time, in microseconds, that the container may use in
+	// each Period, also in microseconds. A Quota of zero leaves it uncapped.
+	Quota  uint64
+	Period uint64
+
+	// Cpuset pins the container to the given cores, e.g. "0-2,4".
+	Cpuset string
 }
 
 type ResourceLimits struct {
@@ -0,0 +1,21 @@
+package api
+
+// A DestroyState is how far a container's destroy has got.
+type DestroyState string
+
+const (
+	DestroyStateDestroying DestroyState = "destroying"
+	DestroyStateDestroyed  DestroyState = "destroyed"
+	DestroyStateFailed     DestroyState = "failed"
+)
+
+// DestroyOperation is a destroy of a container, which clients that destroy
+// asynchronously look up to learn its outcome.
+type DestroyOperation struct {
+	ID     string
+	Handle string
+	State  DestroyState
+
+	// Error is why the destroy failed, if it did.
+	Error error
+}
@@ -0,0 +1,82 @@
+package api
+
+// An ErrorClass says how clients should treat an error, whatever the error
+// itself is, e.g. whether the request which failed may be retried.
+type ErrorClass string
+
+const (
+	// ErrorClassRetryable errors are transient; the request may succeed if it
+	// is retried later.
+	ErrorClassRetryable ErrorClass = "retryable"
+
+	// ErrorClassTerminal errors recur however often the request is retried.
+	ErrorClassTerminal ErrorClass = "terminal"
+
+	// ErrorClassNotFound errors are for requests of containers, processes or
+	// other things which do not exist.
+	ErrorClassNotFound ErrorClass = "not-found"
+
+	// ErrorClassQuota errors are for requests which would exceed a limit,
+	// e.g. on disk or on the number of containers.
+	ErrorClassQuota ErrorClass = "quota"
+
+	// ErrorClassUnsupported errors are for requests the backend or server
+	// cannot carry out at all.
+	ErrorClassUnsupported ErrorClass = "unsupported"
+)
+
+// A ClassifiedError knows its own class. Backends return them, e.g. made with
+// Classify, for failures which are not one of the api package's errors, so
+// that the server can tell clients how to treat them.
+type ClassifiedError interface {
+	error
+	ErrorClass() ErrorClass
+}
+
+// Classify returns an error with the same message as err, of the class.
+func Classify(class ErrorClass, err error) error {
+	return classifiedError{err: err, class: class}
+}
+
+type classifiedError struct {
+	err   error
+	class ErrorClass
+}
+
+func (e classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e classifiedError) ErrorClass() ErrorClass {
+	return e.class
+}
+
+// ClassOf returns the class of the error: its own, if it is a ClassifiedError,
+// or that of the api package's error. Any other error is terminal, as a
+// request which failed for an unknown reason is not safe to retry.
+func ClassOf(err error) ErrorClass {
+	if classified, ok := err.(ClassifiedError); ok && classified.ErrorClass() != "" {
+		return classified.ErrorClass()
+	}
+
+	switch err.(type) {
+	case DiskQuotaExceededError, StdinLimitExceededError, StreamInLimitExceededError, MessageTooLargeError:
+		return ErrorClassQuota
+	}
+
+	switch err {
+	case ErrContainerNotFound, ErrProcessNotFound, ErrOperationNotFound:
+		return ErrorClassNotFound
+
+	case ErrUnsupportedOperation:
+		return ErrorClassUnsupported
+
+	case ErrCapacityExceeded, ErrProcessLimitExceeded:
+		return ErrorClassQuota
+
+	case ErrRateLimited, ErrDestroyInProgress:
+		return ErrorClassRetryable
+	}
+
+	return ErrorClassTerminal
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Backends return these errors, rather than errors of their own, so that
+// clients can tell them apart without matching on the message.
+var (
+	ErrContainerNotFound    = errors.New("container not found")
+	ErrProcessNotFound      = errors.New("process not found")
+	ErrUnsupportedOperation = errors.New("operation not supported by this backend")
+)
+
+// ErrUserNotFound is returned by Run when the user a process is to run as
+// does not exist in the container.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrCapacityExceeded is returned by Create when the server enforces its
+// capacity and the backend already has MaxContainers containers.
+var ErrCapacityExceeded = errors.New("container capacity exceeded")
+
+// ErrProcessLimitExceeded is returned by Run when the server limits how many
+// processes each container may run at once, and the container already runs
+// that many.
+var ErrProcessLimitExceeded = errors.New("container process limit exceeded")
+
+// ErrRateLimited is returned when the server rejects a request because the
+// client has made too many recently, or has too many streams open. The
+// request may be retried later.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// ErrDestroyInProgress is returned by Destroy when the container is already
+// being destroyed, e.g. by another client. It has not failed; the other
+// destroy's outcome is unknown.
+var ErrDestroyInProgress = errors.New("container already being destroyed")
+
+// ErrDestroyNotConfirmed is returned by Destroy, and when scheduling a
+// container's destruction, when the server requires destroys to be confirmed
+// and the client did not confirm it.
+var ErrDestroyNotConfirmed = errors.New("destroy not confirmed")
+
+// ErrPermissionDenied is returned when the server's policy forbids the client
+// from making the request, e.g. from creating a privileged container.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// ErrOperationNotFound is returned when looking up a destroy operation the
+// server does not know, e.g. because it finished too long ago.
+var ErrOperationNotFound = errors.New("operation not found")
+
+// ErrMultipleContainersMatch is returned by LookupBy when more than one
+// container has the properties looked up.
+var ErrMultipleContainersMatch = errors.New("multiple containers match the properties")
+
+// StdinLimitExceededError is returned by a process's Wait, along with its
+// exit status, if the server cut off its stdin for exceeding the server's
+// limit on the bytes written to each process's stdin.
+type StdinLimitExceededError struct {
+	LimitInBytes uint64
+}
+
+func (e StdinLimitExceededError) Error() string {
+	return fmt.Sprintf("stdin limit exceeded: processes may be sent at most %d bytes", e.LimitInBytes)
+}
+
+// StreamInLimitExceededError is returned when files streamed in to a
+// container exceed the server's limit on the bytes, or on the entries, of each
+// stream, which is then aborted. Only the limit which was exceeded is set.
+type StreamInLimitExceededError struct {
+	LimitInBytes   uint64
+	LimitInEntries uint64
+}
+
+func (e StreamInLimitExceededError) Error() string {
+	if e.LimitInEntries != 0 {
+		return fmt.Sprintf("stream in limit exceeded: streams may have at most %d entries", e.LimitInEntries)
+	}
+
+	return fmt.Sprintf("stream in limit exceeded: streams may be at most %d bytes", e.LimitInBytes)
+}
+
+// MessageTooLargeError is returned when a request or response message is
+// bigger than the most either end will decode, so that a corrupt or huge
+// message cannot exhaust the memory of the end decoding it.
+type MessageTooLargeError struct {
+	LimitInBytes uint64
+}
+
+func (e MessageTooLargeError) Error() string {
+	return fmt.Sprintf("message too large: messages may be at most %d bytes", e.LimitInBytes)
+}
+
+// A SpecField names the field of a spec that failed validation.
+type SpecField string
+
+const (
+	SpecFieldPath SpecField = "path"
+	SpecFieldDir  SpecField = "dir"
+	SpecFieldUser SpecField = "user"
+	SpecFieldUID  SpecField = "uid"
+
+	SpecFieldEnv SpecField = "env"
+
+	SpecFieldImageURI SpecField = "image.uri"
+
+	SpecFieldBindMountSrcPath SpecField = "bind_mounts.src_path"
+	SpecFieldBindMountDstPath SpecField = "bind_mounts.dst_path"
+	SpecFieldBindMountMode    SpecField = "bind_mounts.mode"
+	SpecFieldBindMountOrigin  SpecField = "bind_mounts.origin"
+
+	SpecFieldGraceTime SpecField = "grace_time"
+)
+
+// ValidationError is returned when a spec is malformed, before it is passed
+// to the backend.
+type ValidationError struct {
+	Field  SpecField
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+// InvalidRequestError is returned when the server rejects a request because
+// some of its fields are invalid. It lists every such field.
+type InvalidRequestError struct {
+	Violations []ValidationError
+}
+
+func (e InvalidRequestError) Error() string {
+	reasons := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		reasons[i] = violation.Error()
+	}
+
+	return "invalid request: " + strings.Join(reasons, "; ")
+}
+
+// DiskQuotaExceededError is returned when streaming files in to a container
+// would exceed its disk quota.
+type DiskQuotaExceededError struct {
+	ExpectedBytes  uint64
+	RemainingBytes uint64
+}
+
+func (e DiskQuotaExceededError) Error() string {
+	return fmt.Sprintf(
+		"disk quota exceeded: expected %d bytes, but only %d remain",
+		e.ExpectedBytes,
+		e.RemainingBytes,
+	)
+}
+
+// StreamOutInterruptedError is read from an uncompressed stream of files
+// which was cut short, e.g. as the backend failed part way through the
+// archive. The stream may be resumed from the Offset it reached; Message is
+// how reading it failed, as the backend's error is only logged by the server.
+type StreamOutInterruptedError struct {
+	Offset  uint64
+	Message string
+}
+
+func (e StreamOutInterruptedError) Error() string {
+	return fmt.Sprintf("stream out interrupted at offset %d: %s", e.Offset, e.Message)
+}
@@ -4,6 +4,7 @@ package fakes
 import (
 	"io"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden/api"
 )
@@ -23,6 +24,40 @@ type FakeContainer struct {
 	stopReturns struct {
 		result1 error
 	}
+	SignalAllStub        func(api.Signal) error
+	signalAllMutex       sync.RWMutex
+	signalAllArgsForCall []struct {
+		arg1 api.Signal
+	}
+	signalAllReturns struct {
+		result1 error
+	}
+	PauseStub        func() error
+	pauseMutex       sync.RWMutex
+	pauseArgsForCall []struct{}
+	pauseReturns     struct {
+		result1 error
+	}
+	ResumeStub        func() error
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct{}
+	resumeReturns     struct {
+		result1 error
+	}
+	CancelScheduledDestroyStub        func() error
+	cancelScheduledDestroyMutex       sync.RWMutex
+	cancelScheduledDestroyArgsForCall []struct{}
+	cancelScheduledDestroyReturns     struct {
+		result1 error
+	}
+	SetGraceTimeStub        func(graceTime time.Duration) error
+	setGraceTimeMutex       sync.RWMutex
+	setGraceTimeArgsForCall []struct {
+		graceTime time.Duration
+	}
+	setGraceTimeReturns struct {
+		result1 error
+	}
 	InfoStub        func() (api.ContainerInfo, error)
 	infoMutex       sync.RWMutex
 	infoArgsForCall []struct{}
@@ -30,24 +65,49 @@ type FakeContainer struct {
 		result1 api.ContainerInfo
 		result2 error
 	}
-	StreamInStub        func(dstPath string, tarStream io.Reader) error
+	InfoFieldsStub        func(fields []api.InfoField) (api.ContainerInfo, error)
+	infoFieldsMutex       sync.RWMutex
+	infoFieldsArgsForCall []struct {
+		fields []api.InfoField
+	}
+	infoFieldsReturns struct {
+		result1 api.ContainerInfo
+		result2 error
+	}
+	PropertiesStub        func() (api.Properties, error)
+	propertiesMutex       sync.RWMutex
+	propertiesArgsForCall []struct{}
+	propertiesReturns     struct {
+		result1 api.Properties
+		result2 error
+	}
+	StreamInStub        func(spec api.StreamInSpec) error
 	streamInMutex       sync.RWMutex
 	streamInArgsForCall []struct {
-		dstPath   string
-		tarStream io.Reader
+		spec api.StreamInSpec
 	}
 	streamInReturns struct {
 		result1 error
 	}
-	StreamOutStub        func(srcPath string) (io.ReadCloser, error)
+	StreamOutStub        func(spec api.StreamOutSpec) (io.ReadCloser, error)
 	streamOutMutex       sync.RWMutex
 	streamOutArgsForCall []struct {
-		srcPath string
+		spec api.StreamOutSpec
 	}
 	streamOutReturns struct {
 		result1 io.ReadCloser
 		result2 error
 	}
+	VerifyStreamInStub        func(dstPath string, tarStream io.Reader) (api.StreamInReport, error)
+	verifyStreamInMutex       sync.RWMutex
+	verifyStreamInArgsForCall []struct {
+		dstPath   string
+		tarStream io.Reader
+	}
+	verifyStreamInReturns struct {
+		result1 api.StreamInReport
+		result2 error
+	}
 	LimitBandwidthStub        func(limits api.BandwidthLimits) error
 	limitBandwidthMutex       sync.RWMutex
 	limitBandwidthArgsForCall []struct {
@@ -93,6 +153,13 @@ type FakeContainer struct {
 		result1 api.DiskLimits
 		result2 error
 	}
+	DiskUsageStub        func() (api.ContainerDiskUsage, error)
+	diskUsageMutex       sync.RWMutex
+	diskUsageArgsForCall []struct{}
+	diskUsageReturns     struct {
+		result1 api.ContainerDiskUsage
+		result2 error
+	}
 	LimitMemoryStub        func(limits api.MemoryLimits) error
 	limitMemoryMutex       sync.RWMutex
 	limitMemoryArgsForCall []struct {
@@ -108,26 +175,29 @@ type FakeContainer struct {
 		result1 api.MemoryLimits
 		result2 error
 	}
-	NetInStub        func(hostPort, containerPort uint32) (uint32, uint32, error)
-	netInMutex       sync.RWMutex
-	netInArgsForCall []struct {
-		hostPort      uint32
-		containerPort uint32
+	NetInSpecStub        func(spec api.NetInSpec) (uint32, uint32, error)
+	netInSpecMutex       sync.RWMutex
+	netInSpecArgsForCall []struct {
+		spec api.NetInSpec
 	}
-	netInReturns struct {
+	netInSpecReturns struct {
 		result1 uint32
 		result2 uint32
 		result3 error
 	}
-	NetOutStub        func(network string, port uint32, portRange string, protocol api.Protocol) error
-	netOutMutex       sync.RWMutex
-	netOutArgsForCall []struct {
-		network   string
-		port      uint32
-		portRange string
-		protocol  api.Protocol
+	MappedPortsStub        func() ([]api.PortMapping, error)
+	mappedPortsMutex       sync.RWMutex
+	mappedPortsArgsForCall []struct{}
+	mappedPortsReturns     struct {
+		result1 []api.PortMapping
+		result2 error
+	}
+	NetOutRuleStub        func(rule api.NetOutRule) error
+	netOutRuleMutex       sync.RWMutex
+	netOutRuleArgsForCall []struct {
+		rule api.NetOutRule
 	}
-	netOutReturns struct {
+	netOutRuleReturns struct {
 		result1 error
 	}
 	RunStub        func(api.ProcessSpec, api.ProcessIO) (api.Process, error)
@@ -150,6 +220,24 @@ type FakeContainer struct {
 		result1 api.Process
 		result2 error
 	}
+	AttachAllStub        func(func(uint32) api.ProcessIO) ([]api.Process, error)
+	attachAllMutex       sync.RWMutex
+	attachAllArgsForCall []struct {
+		arg1 func(uint32) api.ProcessIO
+	}
+	attachAllReturns struct {
+		result1 []api.Process
+		result2 error
+	}
+	ProcessInfoStub        func(processID uint32) (api.ProcessInfo, error)
+	processInfoMutex       sync.RWMutex
+	processInfoArgsForCall []struct {
+		processID uint32
+	}
+	processInfoReturns struct {
+		result1 api.ProcessInfo
+		result2 error
+	}
 	GetPropertyStub        func(name string) (string, error)
 	getPropertyMutex       sync.RWMutex
 	getPropertyArgsForCall []struct {
@@ -168,6 +256,25 @@ type FakeContainer struct {
 	setPropertyReturns struct {
 		result1 error
 	}
+	SetPropertiesStub        func(properties api.Properties) error
+	setPropertiesMutex       sync.RWMutex
+	setPropertiesArgsForCall []struct {
+		properties api.Properties
+	}
+	setPropertiesReturns struct {
+		result1 error
+	}
+	CompareAndSwapPropertyStub        func(name string, oldValue string, newValue string) (bool, error)
+	compareAndSwapPropertyMutex       sync.RWMutex
+	compareAndSwapPropertyArgsForCall []struct {
+		name     string
+		oldValue string
+		newValue string
+	}
+	compareAndSwapPropertyReturns struct {
+		result1 bool
+		result2 error
+	}
 	RemovePropertyStub        func(name string) error
 	removePropertyMutex       sync.RWMutex
 	removePropertyArgsForCall []struct {
@@ -234,6 +341,118 @@ func (fake *FakeContainer) StopReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) SignalAll(arg1 api.Signal) error {
+	fake.signalAllMutex.Lock()
+	fake.signalAllArgsForCall = append(fake.signalAllArgsForCall, struct {
+		arg1 api.Signal
+	}{arg1})
+	fake.signalAllMutex.Unlock()
+	if fake.SignalAllStub != nil {
+		return fake.SignalAllStub(arg1)
+	} else {
+		return fake.signalAllReturns.result1
+	}
+}
+
+func (fake *FakeContainer) SignalAllCallCount() int {
+	fake.signalAllMutex.RLock()
+	defer fake.signalAllMutex.RUnlock()
+	return len(fake.signalAllArgsForCall)
+}
+
+func (fake *FakeContainer) SignalAllArgsForCall(i int) api.Signal {
+	fake.signalAllMutex.RLock()
+	defer fake.signalAllMutex.RUnlock()
+	return fake.signalAllArgsForCall[i].arg1
+}
+
+func (fake *FakeContainer) SignalAllReturns(result1 error) {
+	fake.SignalAllStub = nil
+	fake.signalAllReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) Pause() error {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct{}{})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		return fake.PauseStub()
+	} else {
+		return fake.pauseReturns.result1
+	}
+}
+
+func (fake *FakeContainer) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeContainer) PauseReturns(result1 error) {
+	fake.PauseStub = nil
+	fake.pauseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) Resume() error {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct{}{})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		return fake.ResumeStub()
+	} else {
+		return fake.resumeReturns.result1
+	}
+}
+
+func (fake *FakeContainer) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+func (fake *FakeContainer) ResumeReturns(result1 error) {
+	fake.ResumeStub = nil
+	fake.resumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) SetGraceTime(graceTime time.Duration) error {
+	fake.setGraceTimeMutex.Lock()
+	fake.setGraceTimeArgsForCall = append(fake.setGraceTimeArgsForCall, struct {
+		graceTime time.Duration
+	}{graceTime})
+	fake.setGraceTimeMutex.Unlock()
+	if fake.SetGraceTimeStub != nil {
+		return fake.SetGraceTimeStub(graceTime)
+	} else {
+		return fake.setGraceTimeReturns.result1
+	}
+}
+
+func (fake *FakeContainer) SetGraceTimeCallCount() int {
+	fake.setGraceTimeMutex.RLock()
+	defer fake.setGraceTimeMutex.RUnlock()
+	return len(fake.setGraceTimeArgsForCall)
+}
+
+func (fake *FakeContainer) SetGraceTimeArgsForCall(i int) time.Duration {
+	fake.setGraceTimeMutex.RLock()
+	defer fake.setGraceTimeMutex.RUnlock()
+	return fake.setGraceTimeArgsForCall[i].graceTime
+}
+
+func (fake *FakeContainer) SetGraceTimeReturns(result1 error) {
+	fake.SetGraceTimeStub = nil
+	fake.setGraceTimeReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeContainer) Info() (api.ContainerInfo, error) {
 	fake.infoMutex.Lock()
 	fake.infoArgsForCall = append(fake.infoArgsForCall, struct{}{})
@@ -259,15 +478,72 @@ func (fake *FakeContainer) InfoReturns(result1 api.ContainerInfo, result2 error)
 	}{result1, result2}
 }
 
-func (fake *FakeContainer) StreamIn(dstPath string, tarStream io.Reader) error {
+func (fake *FakeContainer) InfoFields(fields []api.InfoField) (api.ContainerInfo, error) {
+	fake.infoFieldsMutex.Lock()
+	fake.infoFieldsArgsForCall = append(fake.infoFieldsArgsForCall, struct {
+		fields []api.InfoField
+	}{fields})
+	fake.infoFieldsMutex.Unlock()
+	if fake.InfoFieldsStub != nil {
+		return fake.InfoFieldsStub(fields)
+	} else {
+		return fake.infoFieldsReturns.result1, fake.infoFieldsReturns.result2
+	}
+}
+
+func (fake *FakeContainer) InfoFieldsCallCount() int {
+	fake.infoFieldsMutex.RLock()
+	defer fake.infoFieldsMutex.RUnlock()
+	return len(fake.infoFieldsArgsForCall)
+}
+
+func (fake *FakeContainer) InfoFieldsArgsForCall(i int) []api.InfoField {
+	fake.infoFieldsMutex.RLock()
+	defer fake.infoFieldsMutex.RUnlock()
+	return fake.infoFieldsArgsForCall[i].fields
+}
+
+func (fake *FakeContainer) InfoFieldsReturns(result1 api.ContainerInfo, result2 error) {
+	fake.InfoFieldsStub = nil
+	fake.infoFieldsReturns = struct {
+		result1 api.ContainerInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) Properties() (api.Properties, error) {
+	fake.propertiesMutex.Lock()
+	fake.propertiesArgsForCall = append(fake.propertiesArgsForCall, struct{}{})
+	fake.propertiesMutex.Unlock()
+	if fake.PropertiesStub != nil {
+		return fake.PropertiesStub()
+	} else {
+		return fake.propertiesReturns.result1, fake.propertiesReturns.result2
+	}
+}
+
+func (fake *FakeContainer) PropertiesCallCount() int {
+	fake.propertiesMutex.RLock()
+	defer fake.propertiesMutex.RUnlock()
+	return len(fake.propertiesArgsForCall)
+}
+
+func (fake *FakeContainer) PropertiesReturns(result1 api.Properties, result2 error) {
+	fake.PropertiesStub = nil
+	fake.propertiesReturns = struct {
+		result1 api.Properties
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) StreamIn(spec api.StreamInSpec) error {
 	fake.streamInMutex.Lock()
 	fake.streamInArgsForCall = append(fake.streamInArgsForCall, struct {
-		dstPath   string
-		tarStream io.Reader
-	}{dstPath, tarStream})
+		spec api.StreamInSpec
+	}{spec})
 	fake.streamInMutex.Unlock()
 	if fake.StreamInStub != nil {
-		return fake.StreamInStub(dstPath, tarStream)
+		return fake.StreamInStub(spec)
 	} else {
 		return fake.streamInReturns.result1
 	}
@@ -279,10 +555,10 @@ func (fake *FakeContainer) StreamInCallCount() int {
 	return len(fake.streamInArgsForCall)
 }
 
-func (fake *FakeContainer) StreamInArgsForCall(i int) (string, io.Reader) {
+func (fake *FakeContainer) StreamInArgsForCall(i int) api.StreamInSpec {
 	fake.streamInMutex.RLock()
 	defer fake.streamInMutex.RUnlock()
-	return fake.streamInArgsForCall[i].dstPath, fake.streamInArgsForCall[i].tarStream
+	return fake.streamInArgsForCall[i].spec
 }
 
 func (fake *FakeContainer) StreamInReturns(result1 error) {
@@ -292,14 +568,14 @@ func (fake *FakeContainer) StreamInReturns(result1 error) {
 	}{result1}
 }
 
-func (fake *FakeContainer) StreamOut(srcPath string) (io.ReadCloser, error) {
+func (fake *FakeContainer) StreamOut(spec api.StreamOutSpec) (io.ReadCloser, error) {
 	fake.streamOutMutex.Lock()
 	fake.streamOutArgsForCall = append(fake.streamOutArgsForCall, struct {
-		srcPath string
-	}{srcPath})
+		spec api.StreamOutSpec
+	}{spec})
 	fake.streamOutMutex.Unlock()
 	if fake.StreamOutStub != nil {
-		return fake.StreamOutStub(srcPath)
+		return fake.StreamOutStub(spec)
 	} else {
 		return fake.streamOutReturns.result1, fake.streamOutReturns.result2
 	}
@@ -311,10 +587,10 @@ func (fake *FakeContainer) StreamOutCallCount() int {
 	return len(fake.streamOutArgsForCall)
 }
 
-func (fake *FakeContainer) StreamOutArgsForCall(i int) string {
+func (fake *FakeContainer) StreamOutArgsForCall(i int) api.StreamOutSpec {
 	fake.streamOutMutex.RLock()
 	defer fake.streamOutMutex.RUnlock()
-	return fake.streamOutArgsForCall[i].srcPath
+	return fake.streamOutArgsForCall[i].spec
 }
 
 func (fake *FakeContainer) StreamOutReturns(result1 io.ReadCloser, result2 error) {
@@ -325,6 +601,40 @@ func (fake *FakeContainer) StreamOutReturns(result1 io.ReadCloser, result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) VerifyStreamIn(dstPath string, tarStream io.Reader) (api.StreamInReport, error) {
+	fake.verifyStreamInMutex.Lock()
+	fake.verifyStreamInArgsForCall = append(fake.verifyStreamInArgsForCall, struct {
+		dstPath   string
+		tarStream io.Reader
+	}{dstPath, tarStream})
+	fake.verifyStreamInMutex.Unlock()
+	if fake.VerifyStreamInStub != nil {
+		return fake.VerifyStreamInStub(dstPath, tarStream)
+	} else {
+		return fake.verifyStreamInReturns.result1, fake.verifyStreamInReturns.result2
+	}
+}
+
+func (fake *FakeContainer) VerifyStreamInCallCount() int {
+	fake.verifyStreamInMutex.RLock()
+	defer fake.verifyStreamInMutex.RUnlock()
+	return len(fake.verifyStreamInArgsForCall)
+}
+
+func (fake *FakeContainer) VerifyStreamInArgsForCall(i int) (string, io.Reader) {
+	fake.verifyStreamInMutex.RLock()
+	defer fake.verifyStreamInMutex.RUnlock()
+	return fake.verifyStreamInArgsForCall[i].dstPath, fake.verifyStreamInArgsForCall[i].tarStream
+}
+
+func (fake *FakeContainer) VerifyStreamInReturns(result1 api.StreamInReport, result2 error) {
+	fake.VerifyStreamInStub = nil
+	fake.verifyStreamInReturns = struct {
+		result1 api.StreamInReport
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) LimitBandwidth(limits api.BandwidthLimits) error {
 	fake.limitBandwidthMutex.Lock()
 	fake.limitBandwidthArgsForCall = append(fake.limitBandwidthArgsForCall, struct {
@@ -496,6 +806,31 @@ func (fake *FakeContainer) CurrentDiskLimitsReturns(result1 api.DiskLimits, resu
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) DiskUsage() (api.ContainerDiskUsage, error) {
+	fake.diskUsageMutex.Lock()
+	fake.diskUsageArgsForCall = append(fake.diskUsageArgsForCall, struct{}{})
+	fake.diskUsageMutex.Unlock()
+	if fake.DiskUsageStub != nil {
+		return fake.DiskUsageStub()
+	} else {
+		return fake.diskUsageReturns.result1, fake.diskUsageReturns.result2
+	}
+}
+
+func (fake *FakeContainer) DiskUsageCallCount() int {
+	fake.diskUsageMutex.RLock()
+	defer fake.diskUsageMutex.RUnlock()
+	return len(fake.diskUsageArgsForCall)
+}
+
+func (fake *FakeContainer) DiskUsageReturns(result1 api.ContainerDiskUsage, result2 error) {
+	fake.DiskUsageStub = nil
+	fake.diskUsageReturns = struct {
+		result1 api.ContainerDiskUsage
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) LimitMemory(limits api.MemoryLimits) error {
 	fake.limitMemoryMutex.Lock()
 	fake.limitMemoryArgsForCall = append(fake.limitMemoryArgsForCall, struct {
@@ -553,72 +888,93 @@ func (fake *FakeContainer) CurrentMemoryLimitsReturns(result1 api.MemoryLimits,
 	}{result1, result2}
 }
 
-func (fake *FakeContainer) NetIn(hostPort uint32, containerPort uint32) (uint32, uint32, error) {
-	fake.netInMutex.Lock()
-	fake.netInArgsForCall = append(fake.netInArgsForCall, struct {
-		hostPort      uint32
-		containerPort uint32
-	}{hostPort, containerPort})
-	fake.netInMutex.Unlock()
-	if fake.NetInStub != nil {
-		return fake.NetInStub(hostPort, containerPort)
+func (fake *FakeContainer) NetInSpec(spec api.NetInSpec) (uint32, uint32, error) {
+	fake.netInSpecMutex.Lock()
+	fake.netInSpecArgsForCall = append(fake.netInSpecArgsForCall, struct {
+		spec api.NetInSpec
+	}{spec})
+	fake.netInSpecMutex.Unlock()
+	if fake.NetInSpecStub != nil {
+		return fake.NetInSpecStub(spec)
 	} else {
-		return fake.netInReturns.result1, fake.netInReturns.result2, fake.netInReturns.result3
+		return fake.netInSpecReturns.result1, fake.netInSpecReturns.result2, fake.netInSpecReturns.result3
 	}
 }
 
-func (fake *FakeContainer) NetInCallCount() int {
-	fake.netInMutex.RLock()
-	defer fake.netInMutex.RUnlock()
-	return len(fake.netInArgsForCall)
+func (fake *FakeContainer) NetInSpecCallCount() int {
+	fake.netInSpecMutex.RLock()
+	defer fake.netInSpecMutex.RUnlock()
+	return len(fake.netInSpecArgsForCall)
 }
 
-func (fake *FakeContainer) NetInArgsForCall(i int) (uint32, uint32) {
-	fake.netInMutex.RLock()
-	defer fake.netInMutex.RUnlock()
-	return fake.netInArgsForCall[i].hostPort, fake.netInArgsForCall[i].containerPort
+func (fake *FakeContainer) NetInSpecArgsForCall(i int) api.NetInSpec {
+	fake.netInSpecMutex.RLock()
+	defer fake.netInSpecMutex.RUnlock()
+	return fake.netInSpecArgsForCall[i].spec
 }
 
-func (fake *FakeContainer) NetInReturns(result1 uint32, result2 uint32, result3 error) {
-	fake.NetInStub = nil
-	fake.netInReturns = struct {
+func (fake *FakeContainer) NetInSpecReturns(result1 uint32, result2 uint32, result3 error) {
+	fake.NetInSpecStub = nil
+	fake.netInSpecReturns = struct {
 		result1 uint32
 		result2 uint32
 		result3 error
 	}{result1, result2, result3}
 }
 
-func (fake *FakeContainer) NetOut(network string, port uint32, portRange string, protocol api.Protocol) error {
-	fake.netOutMutex.Lock()
-	fake.netOutArgsForCall = append(fake.netOutArgsForCall, struct {
-		network   string
-		port      uint32
-		portRange string
-		protocol  api.Protocol
-	}{network, port, portRange, protocol})
-	fake.netOutMutex.Unlock()
-	if fake.NetOutStub != nil {
-		return fake.NetOutStub(network, port, portRange, protocol)
+func (fake *FakeContainer) MappedPorts() ([]api.PortMapping, error) {
+	fake.mappedPortsMutex.Lock()
+	fake.mappedPortsArgsForCall = append(fake.mappedPortsArgsForCall, struct{}{})
+	fake.mappedPortsMutex.Unlock()
+	if fake.MappedPortsStub != nil {
+		return fake.MappedPortsStub()
+	} else {
+		return fake.mappedPortsReturns.result1, fake.mappedPortsReturns.result2
+	}
+}
+
+func (fake *FakeContainer) MappedPortsCallCount() int {
+	fake.mappedPortsMutex.RLock()
+	defer fake.mappedPortsMutex.RUnlock()
+	return len(fake.mappedPortsArgsForCall)
+}
+
+func (fake *FakeContainer) MappedPortsReturns(result1 []api.PortMapping, result2 error) {
+	fake.MappedPortsStub = nil
+	fake.mappedPortsReturns = struct {
+		result1 []api.PortMapping
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) NetOutRule(rule api.NetOutRule) error {
+	fake.netOutRuleMutex.Lock()
+	fake.netOutRuleArgsForCall = append(fake.netOutRuleArgsForCall, struct {
+		rule api.NetOutRule
+	}{rule})
+	fake.netOutRuleMutex.Unlock()
+	if fake.NetOutRuleStub != nil {
+		return fake.NetOutRuleStub(rule)
 	} else {
-		return fake.netOutReturns.result1
+		return fake.netOutRuleReturns.result1
 	}
 }
 
-func (fake *FakeContainer) NetOutCallCount() int {
-	fake.netOutMutex.RLock()
-	defer fake.netOutMutex.RUnlock()
-	return len(fake.netOutArgsForCall)
+func (fake *FakeContainer) NetOutRuleCallCount() int {
+	fake.netOutRuleMutex.RLock()
+	defer fake.netOutRuleMutex.RUnlock()
+	return len(fake.netOutRuleArgsForCall)
 }
 
-func (fake *FakeContainer) NetOutArgsForCall(i int) (string, uint32, string, api.Protocol) {
-	fake.netOutMutex.RLock()
-	defer fake.netOutMutex.RUnlock()
-	return fake.netOutArgsForCall[i].network, fake.netOutArgsForCall[i].port, fake.netOutArgsForCall[i].portRange, fake.netOutArgsForCall[i].protocol
+func (fake *FakeContainer) NetOutRuleArgsForCall(i int) api.NetOutRule {
+	fake.netOutRuleMutex.RLock()
+	defer fake.netOutRuleMutex.RUnlock()
+	return fake.netOutRuleArgsForCall[i].rule
 }
 
-func (fake *FakeContainer) NetOutReturns(result1 error) {
-	fake.NetOutStub = nil
-	fake.netOutReturns = struct {
+func (fake *FakeContainer) NetOutRuleReturns(result1 error) {
+	fake.NetOutRuleStub = nil
+	fake.netOutRuleReturns = struct {
 		result1 error
 	}{result1}
 }
@@ -691,6 +1047,72 @@ func (fake *FakeContainer) AttachReturns(result1 api.Process, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) AttachAll(arg1 func(uint32) api.ProcessIO) ([]api.Process, error) {
+	fake.attachAllMutex.Lock()
+	fake.attachAllArgsForCall = append(fake.attachAllArgsForCall, struct {
+		arg1 func(uint32) api.ProcessIO
+	}{arg1})
+	fake.attachAllMutex.Unlock()
+	if fake.AttachAllStub != nil {
+		return fake.AttachAllStub(arg1)
+	} else {
+		return fake.attachAllReturns.result1, fake.attachAllReturns.result2
+	}
+}
+
+func (fake *FakeContainer) AttachAllCallCount() int {
+	fake.attachAllMutex.RLock()
+	defer fake.attachAllMutex.RUnlock()
+	return len(fake.attachAllArgsForCall)
+}
+
+func (fake *FakeContainer) AttachAllArgsForCall(i int) func(uint32) api.ProcessIO {
+	fake.attachAllMutex.RLock()
+	defer fake.attachAllMutex.RUnlock()
+	return fake.attachAllArgsForCall[i].arg1
+}
+
+func (fake *FakeContainer) AttachAllReturns(result1 []api.Process, result2 error) {
+	fake.AttachAllStub = nil
+	fake.attachAllReturns = struct {
+		result1 []api.Process
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) ProcessInfo(processID uint32) (api.ProcessInfo, error) {
+	fake.processInfoMutex.Lock()
+	fake.processInfoArgsForCall = append(fake.processInfoArgsForCall, struct {
+		processID uint32
+	}{processID})
+	fake.processInfoMutex.Unlock()
+	if fake.ProcessInfoStub != nil {
+		return fake.ProcessInfoStub(processID)
+	} else {
+		return fake.processInfoReturns.result1, fake.processInfoReturns.result2
+	}
+}
+
+func (fake *FakeContainer) ProcessInfoCallCount() int {
+	fake.processInfoMutex.RLock()
+	defer fake.processInfoMutex.RUnlock()
+	return len(fake.processInfoArgsForCall)
+}
+
+func (fake *FakeContainer) ProcessInfoArgsForCall(i int) uint32 {
+	fake.processInfoMutex.RLock()
+	defer fake.processInfoMutex.RUnlock()
+	return fake.processInfoArgsForCall[i].processID
+}
+
+func (fake *FakeContainer) ProcessInfoReturns(result1 api.ProcessInfo, result2 error) {
+	fake.ProcessInfoStub = nil
+	fake.processInfoReturns = struct {
+		result1 api.ProcessInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) GetProperty(name string) (string, error) {
 	fake.getPropertyMutex.Lock()
 	fake.getPropertyArgsForCall = append(fake.getPropertyArgsForCall, struct {
@@ -757,6 +1179,73 @@ func (fake *FakeContainer) SetPropertyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) SetProperties(properties api.Properties) error {
+	fake.setPropertiesMutex.Lock()
+	fake.setPropertiesArgsForCall = append(fake.setPropertiesArgsForCall, struct {
+		properties api.Properties
+	}{properties})
+	fake.setPropertiesMutex.Unlock()
+	if fake.SetPropertiesStub != nil {
+		return fake.SetPropertiesStub(properties)
+	} else {
+		return fake.setPropertiesReturns.result1
+	}
+}
+
+func (fake *FakeContainer) SetPropertiesCallCount() int {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return len(fake.setPropertiesArgsForCall)
+}
+
+func (fake *FakeContainer) SetPropertiesArgsForCall(i int) api.Properties {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return fake.setPropertiesArgsForCall[i].properties
+}
+
+func (fake *FakeContainer) SetPropertiesReturns(result1 error) {
+	fake.SetPropertiesStub = nil
+	fake.setPropertiesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) CompareAndSwapProperty(name string, oldValue string, newValue string) (bool, error) {
+	fake.compareAndSwapPropertyMutex.Lock()
+	fake.compareAndSwapPropertyArgsForCall = append(fake.compareAndSwapPropertyArgsForCall, struct {
+		name     string
+		oldValue string
+		newValue string
+	}{name, oldValue, newValue})
+	fake.compareAndSwapPropertyMutex.Unlock()
+	if fake.CompareAndSwapPropertyStub != nil {
+		return fake.CompareAndSwapPropertyStub(name, oldValue, newValue)
+	} else {
+		return fake.compareAndSwapPropertyReturns.result1, fake.compareAndSwapPropertyReturns.result2
+	}
+}
+
+func (fake *FakeContainer) CompareAndSwapPropertyCallCount() int {
+	fake.compareAndSwapPropertyMutex.RLock()
+	defer fake.compareAndSwapPropertyMutex.RUnlock()
+	return len(fake.compareAndSwapPropertyArgsForCall)
+}
+
+func (fake *FakeContainer) CompareAndSwapPropertyArgsForCall(i int) (string, string, string) {
+	fake.compareAndSwapPropertyMutex.RLock()
+	defer fake.compareAndSwapPropertyMutex.RUnlock()
+	return fake.compareAndSwapPropertyArgsForCall[i].name, fake.compareAndSwapPropertyArgsForCall[i].oldValue, fake.compareAndSwapPropertyArgsForCall[i].newValue
+}
+
+func (fake *FakeContainer) CompareAndSwapPropertyReturns(result1 bool, result2 error) {
+	fake.CompareAndSwapPropertyStub = nil
+	fake.compareAndSwapPropertyReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) RemoveProperty(name string) error {
 	fake.removePropertyMutex.Lock()
 	fake.removePropertyArgsForCall = append(fake.removePropertyArgsForCall, struct {
@@ -28,6 +28,29 @@ type FakeProcess struct {
 	setTTYReturns struct {
 		result1 error
 	}
+	SetRlimitsStub        func(api.ResourceLimits) error
+	setRlimitsMutex       sync.RWMutex
+	setRlimitsArgsForCall []struct {
+		arg1 api.ResourceLimits
+	}
+	setRlimitsReturns struct {
+		result1 error
+	}
+	AllocateTTYStub        func(api.TTYSpec) error
+	allocateTTYMutex       sync.RWMutex
+	allocateTTYArgsForCall []struct {
+		arg1 api.TTYSpec
+	}
+	allocateTTYReturns struct {
+		result1 error
+	}
+	ExitedStub        func() (bool, int)
+	exitedMutex       sync.RWMutex
+	exitedArgsForCall []struct{}
+	exitedReturns     struct {
+		result1 bool
+		result2 int
+	}
 }
 
 func (fake *FakeProcess) ID() uint32 {
@@ -111,4 +134,68 @@ func (fake *FakeProcess) SetTTYReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeProcess) SetRlimits(arg1 api.ResourceLimits) error {
+	fake.setRlimitsMutex.Lock()
+	fake.setRlimitsArgsForCall = append(fake.setRlimitsArgsForCall, struct {
+		arg1 api.ResourceLimits
+	}{arg1})
+	fake.setRlimitsMutex.Unlock()
+	if fake.SetRlimitsStub != nil {
+		return fake.SetRlimitsStub(arg1)
+	} else {
+		return fake.setRlimitsReturns.result1
+	}
+}
+
+func (fake *FakeProcess) SetRlimitsCallCount() int {
+	fake.setRlimitsMutex.RLock()
+	defer fake.setRlimitsMutex.RUnlock()
+	return len(fake.setRlimitsArgsForCall)
+}
+
+func (fake *FakeProcess) SetRlimitsArgsForCall(i int) api.ResourceLimits {
+	fake.setRlimitsMutex.RLock()
+	defer fake.setRlimitsMutex.RUnlock()
+	return fake.setRlimitsArgsForCall[i].arg1
+}
+
+func (fake *FakeProcess) SetRlimitsReturns(result1 error) {
+	fake.SetRlimitsStub = nil
+	fake.setRlimitsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProcess) AllocateTTY(arg1 api.TTYSpec) error {
+	fake.allocateTTYMutex.Lock()
+	fake.allocateTTYArgsForCall = append(fake.allocateTTYArgsForCall, struct {
+		arg1 api.TTYSpec
+	}{arg1})
+	fake.allocateTTYMutex.Unlock()
+	if fake.AllocateTTYStub != nil {
+		return fake.AllocateTTYStub(arg1)
+	} else {
+		return fake.allocateTTYReturns.result1
+	}
+}
+
+func (fake *FakeProcess) AllocateTTYCallCount() int {
+	fake.allocateTTYMutex.RLock()
+	defer fake.allocateTTYMutex.RUnlock()
+	return len(fake.allocateTTYArgsForCall)
+}
+
+func (fake *FakeProcess) AllocateTTYArgsForCall(i int) api.TTYSpec {
+	fake.allocateTTYMutex.RLock()
+	defer fake.allocateTTYMutex.RUnlock()
+	return fake.allocateTTYArgsForCall[i].arg1
+}
+
+func (fake *FakeProcess) AllocateTTYReturns(result1 error) {
+	fake.AllocateTTYStub = nil
+	fake.allocateTTYReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ api.Process = new(FakeProcess)
@@ -0,0 +1,55 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+type FakeRenamer struct {
+	RenameStub        func(oldHandle string, newHandle string) error
+	renameMutex       sync.RWMutex
+	renameArgsForCall []struct {
+		oldHandle string
+		newHandle string
+	}
+	renameReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeRenamer) Rename(oldHandle string, newHandle string) error {
+	fake.renameMutex.Lock()
+	fake.renameArgsForCall = append(fake.renameArgsForCall, struct {
+		oldHandle string
+		newHandle string
+	}{oldHandle, newHandle})
+	fake.renameMutex.Unlock()
+	if fake.RenameStub != nil {
+		return fake.RenameStub(oldHandle, newHandle)
+	} else {
+		return fake.renameReturns.result1
+	}
+}
+
+func (fake *FakeRenamer) RenameCallCount() int {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return len(fake.renameArgsForCall)
+}
+
+func (fake *FakeRenamer) RenameArgsForCall(i int) (string, string) {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return fake.renameArgsForCall[i].oldHandle, fake.renameArgsForCall[i].newHandle
+}
+
+func (fake *FakeRenamer) RenameReturns(result1 error) {
+	fake.RenameStub = nil
+	fake.renameReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ api.Renamer = new(FakeRenamer)
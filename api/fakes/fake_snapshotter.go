@@ -0,0 +1,98 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+type FakeSnapshotter struct {
+	SnapshotStub        func(handle string) (io.ReadCloser, error)
+	snapshotMutex       sync.RWMutex
+	snapshotArgsForCall []struct {
+		handle string
+	}
+	snapshotReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	RestoreStub        func(snapshot io.Reader) (api.Container, error)
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		snapshot io.Reader
+	}
+	restoreReturns struct {
+		result1 api.Container
+		result2 error
+	}
+}
+
+func (fake *FakeSnapshotter) Snapshot(handle string) (io.ReadCloser, error) {
+	fake.snapshotMutex.Lock()
+	fake.snapshotArgsForCall = append(fake.snapshotArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.snapshotMutex.Unlock()
+	if fake.SnapshotStub != nil {
+		return fake.SnapshotStub(handle)
+	} else {
+		return fake.snapshotReturns.result1, fake.snapshotReturns.result2
+	}
+}
+
+func (fake *FakeSnapshotter) SnapshotCallCount() int {
+	fake.snapshotMutex.RLock()
+	defer fake.snapshotMutex.RUnlock()
+	return len(fake.snapshotArgsForCall)
+}
+
+func (fake *FakeSnapshotter) SnapshotArgsForCall(i int) string {
+	fake.snapshotMutex.RLock()
+	defer fake.snapshotMutex.RUnlock()
+	return fake.snapshotArgsForCall[i].handle
+}
+
+func (fake *FakeSnapshotter) SnapshotReturns(result1 io.ReadCloser, result2 error) {
+	fake.SnapshotStub = nil
+	fake.snapshotReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSnapshotter) Restore(snapshot io.Reader) (api.Container, error) {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		snapshot io.Reader
+	}{snapshot})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(snapshot)
+	} else {
+		return fake.restoreReturns.result1, fake.restoreReturns.result2
+	}
+}
+
+func (fake *FakeSnapshotter) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeSnapshotter) RestoreArgsForCall(i int) io.Reader {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].snapshot
+}
+
+func (fake *FakeSnapshotter) RestoreReturns(result1 api.Container, result2 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 api.Container
+		result2 error
+	}{result1, result2}
+}
+
+var _ api.Snapshotter = new(FakeSnapshotter)
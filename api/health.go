@@ -0,0 +1,24 @@
+package api
+
+import "time"
+
+// HealthStatus is a server's report of its own health, for load balancers
+// and monitoring.
+type HealthStatus struct {
+	// Healthy is true if the server's backend is reachable.
+	Healthy bool
+
+	// BackendError is why the backend is unhealthy, if it is.
+	BackendError string
+
+	Containers    uint64
+	MaxContainers uint64
+
+	// ContainerHeadroom is how many more containers the backend has room
+	// for, or 0 if it does not report a maximum.
+	ContainerHeadroom uint64
+
+	Uptime time.Duration
+
+	ProtocolVersion uint32
+}
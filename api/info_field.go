@@ -0,0 +1,53 @@
+package api
+
+// InfoField names a part of ContainerInfo, so that callers may ask for only
+// the parts they need.
+type InfoField string
+
+const (
+	InfoFieldState         InfoField = "state"
+	InfoFieldEvents        InfoField = "events"
+	InfoFieldHostIP        InfoField = "host_ip"
+	InfoFieldContainerIP   InfoField = "container_ip"
+	InfoFieldExternalIP    InfoField = "external_ip"
+	InfoFieldContainerPath InfoField = "container_path"
+	InfoFieldProcessIDs    InfoField = "process_ids"
+	InfoFieldMemoryStat    InfoField = "memory_stat"
+	InfoFieldCPUStat       InfoField = "cpu_stat"
+	InfoFieldDiskStat      InfoField = "disk_stat"
+	InfoFieldBandwidthStat InfoField = "bandwidth_stat"
+	InfoFieldProperties    InfoField = "properties"
+	InfoFieldMappedPorts   InfoField = "mapped_ports"
+	InfoFieldHeld          InfoField = "held"
+	InfoFieldGraceTime     InfoField = "grace_time"
+	InfoFieldCreatedAt     InfoField = "created_at"
+
+	InfoFieldExternalInterface InfoField = "external_interface"
+	InfoFieldTimeToGraceExpiry InfoField = "time_to_grace_expiry"
+	InfoFieldLastActivityAt    InfoField = "last_activity_at"
+	InfoFieldLimits            InfoField = "limits"
+)
+
+// InfoFields are all of the fields of ContainerInfo.
+var InfoFields = []InfoField{
+	InfoFieldState,
+	InfoFieldEvents,
+	InfoFieldHostIP,
+	InfoFieldContainerIP,
+	InfoFieldExternalIP,
+	InfoFieldContainerPath,
+	InfoFieldProcessIDs,
+	InfoFieldMemoryStat,
+	InfoFieldCPUStat,
+	InfoFieldDiskStat,
+	InfoFieldBandwidthStat,
+	InfoFieldProperties,
+	InfoFieldMappedPorts,
+	InfoFieldHeld,
+	InfoFieldGraceTime,
+	InfoFieldCreatedAt,
+	InfoFieldExternalInterface,
+	InfoFieldTimeToGraceExpiry,
+	InfoFieldLastActivityAt,
+	InfoFieldLimits,
+}
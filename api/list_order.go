@@ -0,0 +1,14 @@
+package api
+
+// ListOrder is the order in which containers are listed. Ties are broken by
+// handle, so that listing the same containers twice gives the same order.
+type ListOrder string
+
+const (
+	// ListOrderHandle lists containers by handle. It is the default.
+	ListOrderHandle ListOrder = "handle"
+
+	// ListOrderCreated lists containers from the oldest to the newest, with
+	// any whose creation time the backend does not know first.
+	ListOrderCreated ListOrder = "created"
+)
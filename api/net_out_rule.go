@@ -0,0 +1,45 @@
+package api
+
+import "net"
+
+// A NetOutRule allows a container's processes to reach the given networks
+// and ports. Empty Networks or Ports allow any network or port.
+type NetOutRule struct {
+	Protocol Protocol
+	Networks []IPRange
+	Ports    []PortRange
+
+	// Log asks the backend to log the connections that the rule allows.
+	Log bool
+}
+
+// An IPRange includes every address from Start to End, inclusive.
+type IPRange struct {
+	Start net.IP
+	End   net.IP
+}
+
+// A PortRange includes every port from Start to End, inclusive.
+type PortRange struct {
+	Start uint16
+	End   uint16
+}
+
+func IPRangeFromIP(ip net.IP) IPRange {
+	return IPRange{Start: ip, End: ip}
+}
+
+func IPRangeFromIPNet(ipNet *net.IPNet) IPRange {
+	start := ipNet.IP.Mask(ipNet.Mask)
+
+	end := make(net.IP, len(start))
+	for i := range start {
+		end[i] = start[i] | ^ipNet.Mask[i]
+	}
+
+	return IPRange{Start: start, End: end}
+}
+
+func PortRangeFromPort(port uint16) PortRange {
+	return PortRange{Start: port, End: port}
+}
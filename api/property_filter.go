@@ -0,0 +1,62 @@
+package api
+
+import "strings"
+
+// PropertyOperator is how a PropertyFilter compares a container's property
+// with its value.
+type PropertyOperator string
+
+const (
+	// PropertyEquals matches containers whose property has the value.
+	PropertyEquals PropertyOperator = "eq"
+
+	// PropertyNotEquals matches containers whose property does not have the
+	// value, including those without the property.
+	PropertyNotEquals PropertyOperator = "ne"
+
+	// PropertyPrefix matches containers whose property starts with the value.
+	PropertyPrefix PropertyOperator = "prefix"
+
+	// PropertyExists matches containers with the property, whatever its
+	// value. The filter's value is ignored.
+	PropertyExists PropertyOperator = "exists"
+)
+
+// A PropertyFilter matches containers by one of their properties, for
+// listings.
+type PropertyFilter struct {
+	Name     string
+	Operator PropertyOperator
+	Value    string
+}
+
+// Matches reports whether a container with the properties matches the filter.
+// Filters with an unknown operator match nothing.
+func (f PropertyFilter) Matches(properties Properties) bool {
+	value, found := properties[f.Name]
+
+	switch f.Operator {
+	case PropertyEquals:
+		return found && value == f.Value
+	case PropertyNotEquals:
+		return !found || value != f.Value
+	case PropertyPrefix:
+		return found && strings.HasPrefix(value, f.Value)
+	case PropertyExists:
+		return found
+	}
+
+	return false
+}
+
+// MatchesAll reports whether a container with the properties matches every
+// filter.
+func MatchesAll(filters []PropertyFilter, properties Properties) bool {
+	for _, filter := range filters {
+		if !filter.Matches(properties) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,76 @@
+package api
+
+// ProtocolVersion is the version of the protocol spoken by this package's
+// client and server. It is only increased by changes that stop older clients
+// and servers from working together.
+const ProtocolVersion uint32 = 1
+
+// A Capability is an optional part of the protocol, added without increasing
+// its version, which a server may or may not support.
+type Capability string
+
+const (
+	CapabilityPauseResume          Capability = "pause-resume"
+	CapabilitySetGraceTime         Capability = "set-grace-time"
+	CapabilityRename               Capability = "rename"
+	CapabilityNetOutRules          Capability = "net-out-rules"
+	CapabilityHealthCheck          Capability = "health-check"
+	CapabilityBinaryProcessStreams Capability = "binary-process-streams"
+	CapabilityListOrder            Capability = "list-order"
+	CapabilityMultiplex            Capability = "multiplex"
+	CapabilityAllocateTTY          Capability = "allocate-tty"
+	CapabilityAsyncDestroy         Capability = "async-destroy"
+	CapabilityListStream           Capability = "list-stream"
+	CapabilityListFilterOperators  Capability = "list-filter-operators"
+	CapabilityNetInSpec            Capability = "net-in-spec"
+	CapabilityProcessLabels        Capability = "process-labels"
+	CapabilityPassFiles            Capability = "pass-files"
+	CapabilityStreamOutOffset      Capability = "stream-out-offset"
+	CapabilityExternalIP           Capability = "external-ip"
+	CapabilityWaitForProcess       Capability = "wait-for-process"
+	CapabilityBulkProperties       Capability = "bulk-properties"
+	CapabilityContainerLogs        Capability = "container-logs"
+	CapabilityReconnectTokens      Capability = "reconnect-tokens"
+)
+
+// Capabilities are those supported by this package's client and server.
+var Capabilities = []Capability{
+	CapabilityPauseResume,
+	CapabilitySetGraceTime,
+	CapabilityRename,
+	CapabilityNetOutRules,
+	CapabilityHealthCheck,
+	CapabilityBinaryProcessStreams,
+	CapabilityListOrder,
+	CapabilityMultiplex,
+	CapabilityAllocateTTY,
+	CapabilityAsyncDestroy,
+	CapabilityListStream,
+	CapabilityListFilterOperators,
+	CapabilityNetInSpec,
+	CapabilityProcessLabels,
+	CapabilityPassFiles,
+	CapabilityStreamOutOffset,
+	CapabilityExternalIP,
+	CapabilityWaitForProcess,
+	CapabilityBulkProperties,
+	CapabilityContainerLogs,
+	CapabilityReconnectTokens,
+}
+
+// ServerVersion describes the protocol a server speaks. Servers which predate
+// capabilities report version 0 and none.
+type ServerVersion struct {
+	ProtocolVersion uint32
+	Capabilities    []Capability
+}
+
+func (v ServerVersion) Supports(capability Capability) bool {
+	for _, supported := range v.Capabilities {
+		if supported == capability {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package apitypes
+
+type AttachRequest struct {
+	Handle        *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	ProcessId     *uint32 `protobuf:"varint,2,req,name=process_id" json:"process_id,omitempty"`
+	DiscardStdout *bool   `protobuf:"varint,3,opt,name=discard_stdout" json:"discard_stdout,omitempty"`
+	DiscardStderr *bool   `protobuf:"varint,4,opt,name=discard_stderr" json:"discard_stderr,omitempty"`
+
+	// ReconnectToken resumes the process's streams, replaying its retained
+	// output less the bytes of each stream already received.
+	ReconnectToken *string `protobuf:"bytes,5,opt,name=reconnect_token" json:"reconnect_token,omitempty"`
+	StdoutOffset   *uint64 `protobuf:"varint,6,opt,name=stdout_offset" json:"stdout_offset,omitempty"`
+	StderrOffset   *uint64 `protobuf:"varint,7,opt,name=stderr_offset" json:"stderr_offset,omitempty"`
+}
+
+func (m *AttachRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *AttachRequest) GetProcessId() uint32 {
+	if m != nil && m.ProcessId != nil {
+		return *m.ProcessId
+	}
+	return 0
+}
+
+func (m *AttachRequest) GetDiscardStdout() bool {
+	if m != nil && m.DiscardStdout != nil {
+		return *m.DiscardStdout
+	}
+	return false
+}
+
+func (m *AttachRequest) GetDiscardStderr() bool {
+	if m != nil && m.DiscardStderr != nil {
+		return *m.DiscardStderr
+	}
+	return false
+}
+
+func (m *AttachRequest) GetReconnectToken() string {
+	if m != nil && m.ReconnectToken != nil {
+		return *m.ReconnectToken
+	}
+	return ""
+}
+
+func (m *AttachRequest) GetStdoutOffset() uint64 {
+	if m != nil && m.StdoutOffset != nil {
+		return *m.StdoutOffset
+	}
+	return 0
+}
+
+func (m *AttachRequest) GetStderrOffset() uint64 {
+	if m != nil && m.StderrOffset != nil {
+		return *m.StderrOffset
+	}
+	return 0
+}
+
+type AttachAllResponse struct {
+	ProcessIds  []uint32 `protobuf:"varint,1,rep,name=process_ids" json:"process_ids,omitempty"`
+	StdinWindow *uint32  `protobuf:"varint,2,opt,name=stdin_window" json:"stdin_window,omitempty"`
+}
+
+func (m *AttachAllResponse) GetProcessIds() []uint32 {
+	if m != nil {
+		return m.ProcessIds
+	}
+	return nil
+}
+
+func (m *AttachAllResponse) GetStdinWindow() uint32 {
+	if m != nil && m.StdinWindow != nil {
+		return *m.StdinWindow
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package apitypes
+
+type BulkPropertiesRequest struct {
+	Handles []string `protobuf:"bytes,1,rep,name=handles" json:"handles,omitempty"`
+	Keys    []string `protobuf:"bytes,2,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *BulkPropertiesRequest) GetHandles() []string {
+	if m != nil {
+		return m.Handles
+	}
+	return nil
+}
+
+func (m *BulkPropertiesRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type BulkPropertiesResponse struct {
+	Containers []*BulkPropertiesResponse_Container `protobuf:"bytes,1,rep,name=containers" json:"containers,omitempty"`
+}
+
+func (m *BulkPropertiesResponse) GetContainers() []*BulkPropertiesResponse_Container {
+	if m != nil {
+		return m.Containers
+	}
+	return nil
+}
+
+type BulkPropertiesResponse_Container struct {
+	Handle     *string     `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Properties []*Property `protobuf:"bytes,2,rep,name=properties" json:"properties,omitempty"`
+}
+
+func (m *BulkPropertiesResponse_Container) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *BulkPropertiesResponse_Container) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package apitypes
+
+type CompareAndSwapPropertyRequest struct {
+	Handle   *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Key      *string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	OldValue *string `protobuf:"bytes,3,opt,name=old_value" json:"old_value,omitempty"`
+	NewValue *string `protobuf:"bytes,4,opt,name=new_value" json:"new_value,omitempty"`
+}
+
+func (m *CompareAndSwapPropertyRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *CompareAndSwapPropertyRequest) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *CompareAndSwapPropertyRequest) GetOldValue() string {
+	if m != nil && m.OldValue != nil {
+		return *m.OldValue
+	}
+	return ""
+}
+
+func (m *CompareAndSwapPropertyRequest) GetNewValue() string {
+	if m != nil && m.NewValue != nil {
+		return *m.NewValue
+	}
+	return ""
+}
+
+type CompareAndSwapPropertyResponse struct {
+	Swapped *bool `protobuf:"varint,1,opt,name=swapped" json:"swapped,omitempty"`
+}
+
+func (m *CompareAndSwapPropertyResponse) GetSwapped() bool {
+	if m != nil && m.Swapped != nil {
+		return *m.Swapped
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package apitypes
+
+type ContainerEnvResponse struct {
+	Env []*EnvironmentVariable `protobuf:"bytes,1,rep,name=env" json:"env,omitempty"`
+}
+
+func (m *ContainerEnvResponse) GetEnv() []*EnvironmentVariable {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package apitypes
+
+type CreateRequest_BindMount_Mode int32
+
+const (
+	CreateRequest_BindMount_RO CreateRequest_BindMount_Mode = 0
+	CreateRequest_BindMount_RW CreateRequest_BindMount_Mode = 1
+)
+
+var CreateRequest_BindMount_Mode_name = map[int32]string{
+	0: "RO",
+	1: "RW",
+}
+var CreateRequest_BindMount_Mode_value = map[string]int32{
+	"RO": 0,
+	"RW": 1,
+}
+
+func (x CreateRequest_BindMount_Mode) Enum() *CreateRequest_BindMount_Mode {
+	p := new(CreateRequest_BindMount_Mode)
+	*p = x
+	return p
+}
+func (x CreateRequest_BindMount_Mode) String() string {
+	return enumName(CreateRequest_BindMount_Mode_name, int32(x))
+}
+func (x *CreateRequest_BindMount_Mode) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalJSONEnum(CreateRequest_BindMount_Mode_value, data, "CreateRequest_BindMount_Mode")
+	if err != nil {
+		return err
+	}
+	*x = CreateRequest_BindMount_Mode(value)
+	return nil
+}
+
+type CreateRequest_BindMount_Origin int32
+
+const (
+	CreateRequest_BindMount_Host      CreateRequest_BindMount_Origin = 0
+	CreateRequest_BindMount_Container CreateRequest_BindMount_Origin = 1
+)
+
+var CreateRequest_BindMount_Origin_name = map[int32]string{
+	0: "Host",
+	1: "Container",
+}
+var CreateRequest_BindMount_Origin_value = map[string]int32{
+	"Host":      0,
+	"Container": 1,
+}
+
+func (x CreateRequest_BindMount_Origin) Enum() *CreateRequest_BindMount_Origin {
+	p := new(CreateRequest_BindMount_Origin)
+	*p = x
+	return p
+}
+func (x CreateRequest_BindMount_Origin) String() string {
+	return enumName(CreateRequest_BindMount_Origin_name, int32(x))
+}
+func (x *CreateRequest_BindMount_Origin) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalJSONEnum(CreateRequest_BindMount_Origin_value, data, "CreateRequest_BindMount_Origin")
+	if err != nil {
+		return err
+	}
+	*x = CreateRequest_BindMount_Origin(value)
+	return nil
+}
+
+type CreateRequest struct {
+	BindMounts []*CreateRequest_BindMount `protobuf:"bytes,1,rep,name=bind_mounts" json:"bind_mounts,omitempty"`
+	GraceTime  *uint32                    `protobuf:"varint,2,opt,name=grace_time" json:"grace_time,omitempty"`
+	Handle     *string                    `protobuf:"bytes,3,opt,name=handle" json:"handle,omitempty"`
+	Network    *string                    `protobuf:"bytes,4,opt,name=network" json:"network,omitempty"`
+	Rootfs     *string                    `protobuf:"bytes,5,opt,name=rootfs" json:"rootfs,omitempty"`
+	Properties []*Property                `protobuf:"bytes,6,rep,name=properties" json:"properties,omitempty"`
+	Env        []*EnvironmentVariable     `protobuf:"bytes,7,rep,name=env" json:"env,omitempty"`
+	Privileged *bool                      `protobuf:"varint,8,opt,name=privileged" json:"privileged,omitempty"`
+
+	DefaultProcessUser *string `protobuf:"bytes,9,opt,name=default_process_user" json:"default_process_user,omitempty"`
+
+	Image *CreateRequest_Image `protobuf:"bytes,10,opt,name=image" json:"image,omitempty"`
+
+	ExternalIp        *string `protobuf:"bytes,11,opt,name=external_ip" json:"external_ip,omitempty"`
+	ExternalInterface *string `protobuf:"bytes,12,opt,name=external_interface" json:"external_interface,omitempty"`
+}
+
+func (m *CreateRequest) GetBindMounts() []*CreateRequest_BindMount {
+	if m != nil {
+		return m.BindMounts
+	}
+	return nil
+}
+
+func (m *CreateRequest) GetGraceTime() uint32 {
+	if m != nil && m.GraceTime != nil {
+		return *m.GraceTime
+	}
+	return 0
+}
+
+func (m *CreateRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *CreateRequest) GetNetwork() string {
+	if m != nil && m.Network != nil {
+		return *m.Network
+	}
+	return ""
+}
+
+func (m *CreateRequest) GetRootfs() string {
+	if m != nil && m.Rootfs != nil {
+		return *m.Rootfs
+	}
+	return ""
+}
+
+func (m *CreateRequest) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *CreateRequest) GetEnv() []*EnvironmentVariable {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
+
+func (m *CreateRequest) GetPrivileged() bool {
+	if m != nil && m.Privileged != nil {
+		return *m.Privileged
+	}
+	return false
+}
+
+func (m *CreateRequest) GetDefaultProcessUser() string {
+	if m != nil && m.DefaultProcessUser != nil {
+		return *m.DefaultProcessUser
+	}
+	return ""
+}
+
+func (m *CreateRequest) GetImage() *CreateRequest_Image {
+	if m != nil {
+		return m.Image
+	}
+	return nil
+}
+
+func (m *CreateRequest) GetExternalIp() string {
+	if m != nil && m.ExternalIp != nil {
+		return *m.ExternalIp
+	}
+	return ""
+}
+
+func (m *CreateRequest) GetExternalInterface() string {
+	if m != nil && m.ExternalInterface != nil {
+		return *m.ExternalInterface
+	}
+	return ""
+}
+
+type CreateRequest_Image struct {
+	Uri      *string `protobuf:"bytes,1,opt,name=uri" json:"uri,omitempty"`
+	Username *string `protobuf:"bytes,2,opt,name=username" json:"username,omitempty"`
+	Password *string `protobuf:"bytes,3,opt,name=password" json:"password,omitempty"`
+}
+
+func (m *CreateRequest_Image) GetUri() string {
+	if m != nil && m.Uri != nil {
+		return *m.Uri
+	}
+	return ""
+}
+
+func (m *CreateRequest_Image) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *CreateRequest_Image) GetPassword() string {
+	if m != nil && m.Password != nil {
+		return *m.Password
+	}
+	return ""
+}
+
+type CreateRequest_BindMount struct {
+	SrcPath *string                         `protobuf:"bytes,1,req,name=src_path" json:"src_path,omitempty"`
+	DstPath *string                         `protobuf:"bytes,2,req,name=dst_path" json:"dst_path,omitempty"`
+	Mode    *CreateRequest_BindMount_Mode   `protobuf:"varint,3,req,name=mode,enum=garden.CreateRequest_BindMount_Mode" json:"mode,omitempty"`
+	Origin  *CreateRequest_BindMount_Origin `protobuf:"varint,4,opt,name=origin,enum=garden.CreateRequest_BindMount_Origin" json:"origin,omitempty"`
+}
+
+func (m *CreateRequest_BindMount) GetSrcPath() string {
+	if m != nil && m.SrcPath != nil {
+		return *m.SrcPath
+	}
+	return ""
+}
+
+func (m *CreateRequest_BindMount) GetDstPath() string {
+	if m != nil && m.DstPath != nil {
+		return *m.DstPath
+	}
+	return ""
+}
+
+func (m *CreateRequest_BindMount) GetMode() CreateRequest_BindMount_Mode {
+	if m != nil && m.Mode != nil {
+		return *m.Mode
+	}
+	return CreateRequest_BindMount_RO
+}
+
+func (m *CreateRequest_BindMount) GetOrigin() CreateRequest_BindMount_Origin {
+	if m != nil && m.Origin != nil {
+		return *m.Origin
+	}
+	return CreateRequest_BindMount_Host
+}
+
+type CreateResponse struct {
+	Handle *string       `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	Info   *InfoResponse `protobuf:"bytes,2,opt,name=info" json:"info,omitempty"`
+}
+
+func (m *CreateResponse) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *CreateResponse) GetInfo() *InfoResponse {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package apitypes
+
+type DefaultsRequest struct {
+}
+
+type DefaultsResponse struct {
+	GraceTime                *uint32  `protobuf:"varint,1,opt,name=grace_time" json:"grace_time,omitempty"`
+	RequestsPerSecond        *float64 `protobuf:"fixed64,2,opt,name=requests_per_second" json:"requests_per_second,omitempty"`
+	Burst                    *uint32  `protobuf:"varint,3,opt,name=burst" json:"burst,omitempty"`
+	MaxConcurrentStreams     *uint32  `protobuf:"varint,4,opt,name=max_concurrent_streams" json:"max_concurrent_streams,omitempty"`
+	StdinLimit               *uint64  `protobuf:"varint,5,opt,name=stdin_limit" json:"stdin_limit,omitempty"`
+	MaxProcessesPerContainer *uint32  `protobuf:"varint,6,opt,name=max_processes_per_container" json:"max_processes_per_container,omitempty"`
+}
+
+func (m *DefaultsResponse) GetGraceTime() uint32 {
+	if m != nil && m.GraceTime != nil {
+		return *m.GraceTime
+	}
+	return 0
+}
+
+func (m *DefaultsResponse) GetRequestsPerSecond() float64 {
+	if m != nil && m.RequestsPerSecond != nil {
+		return *m.RequestsPerSecond
+	}
+	return 0
+}
+
+func (m *DefaultsResponse) GetBurst() uint32 {
+	if m != nil && m.Burst != nil {
+		return *m.Burst
+	}
+	return 0
+}
+
+func (m *DefaultsResponse) GetMaxConcurrentStreams() uint32 {
+	if m != nil && m.MaxConcurrentStreams != nil {
+		return *m.MaxConcurrentStreams
+	}
+	return 0
+}
+
+func (m *DefaultsResponse) GetStdinLimit() uint64 {
+	if m != nil && m.StdinLimit != nil {
+		return *m.StdinLimit
+	}
+	return 0
+}
+
+func (m *DefaultsResponse) GetMaxProcessesPerContainer() uint32 {
+	if m != nil && m.MaxProcessesPerContainer != nil {
+		return *m.MaxProcessesPerContainer
+	}
+	return 0
+}
+
+type SetDefaultsRequest struct {
+	GraceTime                *uint32  `protobuf:"varint,1,opt,name=grace_time" json:"grace_time,omitempty"`
+	RequestsPerSecond        *float64 `protobuf:"fixed64,2,opt,name=requests_per_second" json:"requests_per_second,omitempty"`
+	Burst                    *uint32  `protobuf:"varint,3,opt,name=burst" json:"burst,omitempty"`
+	MaxConcurrentStreams     *uint32  `protobuf:"varint,4,opt,name=max_concurrent_streams" json:"max_concurrent_streams,omitempty"`
+	StdinLimit               *uint64  `protobuf:"varint,5,opt,name=stdin_limit" json:"stdin_limit,omitempty"`
+	MaxProcessesPerContainer *uint32  `protobuf:"varint,6,opt,name=max_processes_per_container" json:"max_processes_per_container,omitempty"`
+}
+
+func (m *SetDefaultsRequest) GetGraceTime() uint32 {
+	if m != nil && m.GraceTime != nil {
+		return *m.GraceTime
+	}
+	return 0
+}
+
+func (m *SetDefaultsRequest) GetRequestsPerSecond() float64 {
+	if m != nil && m.RequestsPerSecond != nil {
+		return *m.RequestsPerSecond
+	}
+	return 0
+}
+
+func (m *SetDefaultsRequest) GetBurst() uint32 {
+	if m != nil && m.Burst != nil {
+		return *m.Burst
+	}
+	return 0
+}
+
+func (m *SetDefaultsRequest) GetMaxConcurrentStreams() uint32 {
+	if m != nil && m.MaxConcurrentStreams != nil {
+		return *m.MaxConcurrentStreams
+	}
+	return 0
+}
+
+func (m *SetDefaultsRequest) GetStdinLimit() uint64 {
+	if m != nil && m.StdinLimit != nil {
+		return *m.StdinLimit
+	}
+	return 0
+}
+
+func (m *SetDefaultsRequest) GetMaxProcessesPerContainer() uint32 {
+	if m != nil && m.MaxProcessesPerContainer != nil {
+		return *m.MaxProcessesPerContainer
+	}
+	return 0
+}
+
+type SetDefaultsResponse struct {
+}
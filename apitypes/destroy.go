@@ -0,0 +1,23 @@
+package apitypes
+
+type DestroyRequest struct {
+	Handle *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+}
+
+func (m *DestroyRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+type DestroyResponse struct {
+	OperationId *string `protobuf:"bytes,1,opt,name=operation_id" json:"operation_id,omitempty"`
+}
+
+func (m *DestroyResponse) GetOperationId() string {
+	if m != nil && m.OperationId != nil {
+		return *m.OperationId
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package apitypes
+
+type DestroyOperationResponse struct {
+	Id     *string        `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Handle *string        `protobuf:"bytes,2,opt,name=handle" json:"handle,omitempty"`
+	State  *string        `protobuf:"bytes,3,opt,name=state" json:"state,omitempty"`
+	Error  *ErrorResponse `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *DestroyOperationResponse) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *DestroyOperationResponse) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *DestroyOperationResponse) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
+
+func (m *DestroyOperationResponse) GetError() *ErrorResponse {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
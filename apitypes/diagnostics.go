@@ -0,0 +1,55 @@
+package apitypes
+
+type DiagnosticsRequest struct {
+}
+
+type DiagnosticsResponse struct {
+	Goroutines          *uint32 `protobuf:"varint,1,opt,name=goroutines" json:"goroutines,omitempty"`
+	OpenFiles           *uint32 `protobuf:"varint,2,opt,name=open_files" json:"open_files,omitempty"`
+	OpenConnections     *uint32 `protobuf:"varint,3,opt,name=open_connections" json:"open_connections,omitempty"`
+	HijackedConnections *uint32 `protobuf:"varint,4,opt,name=hijacked_connections" json:"hijacked_connections,omitempty"`
+	HeapBytes           *uint64 `protobuf:"varint,5,opt,name=heap_bytes" json:"heap_bytes,omitempty"`
+	SysBytes            *uint64 `protobuf:"varint,6,opt,name=sys_bytes" json:"sys_bytes,omitempty"`
+}
+
+func (m *DiagnosticsResponse) GetGoroutines() uint32 {
+	if m != nil && m.Goroutines != nil {
+		return *m.Goroutines
+	}
+	return 0
+}
+
+func (m *DiagnosticsResponse) GetOpenFiles() uint32 {
+	if m != nil && m.OpenFiles != nil {
+		return *m.OpenFiles
+	}
+	return 0
+}
+
+func (m *DiagnosticsResponse) GetOpenConnections() uint32 {
+	if m != nil && m.OpenConnections != nil {
+		return *m.OpenConnections
+	}
+	return 0
+}
+
+func (m *DiagnosticsResponse) GetHijackedConnections() uint32 {
+	if m != nil && m.HijackedConnections != nil {
+		return *m.HijackedConnections
+	}
+	return 0
+}
+
+func (m *DiagnosticsResponse) GetHeapBytes() uint64 {
+	if m != nil && m.HeapBytes != nil {
+		return *m.HeapBytes
+	}
+	return 0
+}
+
+func (m *DiagnosticsResponse) GetSysBytes() uint64 {
+	if m != nil && m.SysBytes != nil {
+		return *m.SysBytes
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package apitypes
+
+type DiskUsageResponse struct {
+	RootfsLayers []*DiskUsageResponse_Entry `protobuf:"bytes,1,rep,name=rootfs_layers" json:"rootfs_layers,omitempty"`
+	Scratch      *DiskUsageResponse_Entry   `protobuf:"bytes,2,opt,name=scratch" json:"scratch,omitempty"`
+	BindMounts   []*DiskUsageResponse_Entry `protobuf:"bytes,3,rep,name=bind_mounts" json:"bind_mounts,omitempty"`
+}
+
+func (m *DiskUsageResponse) GetRootfsLayers() []*DiskUsageResponse_Entry {
+	if m != nil {
+		return m.RootfsLayers
+	}
+	return nil
+}
+
+func (m *DiskUsageResponse) GetScratch() *DiskUsageResponse_Entry {
+	if m != nil {
+		return m.Scratch
+	}
+	return nil
+}
+
+func (m *DiskUsageResponse) GetBindMounts() []*DiskUsageResponse_Entry {
+	if m != nil {
+		return m.BindMounts
+	}
+	return nil
+}
+
+type DiskUsageResponse_Entry struct {
+	Name       *string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	BytesUsed  *uint64 `protobuf:"varint,2,opt,name=bytes_used" json:"bytes_used,omitempty"`
+	InodesUsed *uint64 `protobuf:"varint,3,opt,name=inodes_used" json:"inodes_used,omitempty"`
+}
+
+func (m *DiskUsageResponse_Entry) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *DiskUsageResponse_Entry) GetBytesUsed() uint64 {
+	if m != nil && m.BytesUsed != nil {
+		return *m.BytesUsed
+	}
+	return 0
+}
+
+func (m *DiskUsageResponse_Entry) GetInodesUsed() uint64 {
+	if m != nil && m.InodesUsed != nil {
+		return *m.InodesUsed
+	}
+	return 0
+}
@@ -0,0 +1,8 @@
+// Package apitypes defines the request and response bodies of the Garden HTTP
+// API, as sent over the wire in JSON or as protocol buffers.
+//
+// They are plain structs, which the transport package encodes as protocol
+// buffers, so that tools speaking the API need not import generated code.
+// Optional fields are pointers so that an absent value can be told apart from
+// a zero one.
+package apitypes
@@ -0,0 +1,42 @@
+package apitypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+func enumName(names map[int32]string, value int32) string {
+	if name, found := names[value]; found {
+		return name
+	}
+
+	return strconv.Itoa(int(value))
+}
+
+// unmarshalJSONEnum accepts an enum given either by name or by number, as
+// older clients send the former.
+func unmarshalJSONEnum(values map[string]int32, data []byte, enumName string) (int32, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		err := json.Unmarshal(data, &name)
+		if err != nil {
+			return 0, err
+		}
+
+		value, found := values[name]
+		if !found {
+			return 0, fmt.Errorf("unrecognized enum %s value %q", enumName, name)
+		}
+
+		return value, nil
+	}
+
+	var value int32
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return 0, fmt.Errorf("cannot unmarshal %#q into enum %s", data, enumName)
+	}
+
+	return value, nil
+}
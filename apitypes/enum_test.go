@@ -0,0 +1,48 @@
+package apitypes_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+var _ = Describe("Enums", func() {
+	It("are sent as numbers", func() {
+		payload, err := json.Marshal(&apitypes.ProcessPayload{
+			Source: apitypes.ProcessPayload_stderr.Enum(),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(string(payload)).Should(Equal(`{"source":2}`))
+	})
+
+	It("can be received as numbers", func() {
+		var payload apitypes.ProcessPayload
+		err := json.Unmarshal([]byte(`{"source":2}`), &payload)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(payload.GetSource()).Should(Equal(apitypes.ProcessPayload_stderr))
+	})
+
+	It("can be received as names", func() {
+		var payload apitypes.ProcessPayload
+		err := json.Unmarshal([]byte(`{"source":"stderr"}`), &payload)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(payload.GetSource()).Should(Equal(apitypes.ProcessPayload_stderr))
+	})
+
+	It("reject unknown names", func() {
+		var payload apitypes.ProcessPayload
+		err := json.Unmarshal([]byte(`{"source":"stdsideways"}`), &payload)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("are named by their String method", func() {
+		Ω(apitypes.ProcessPayload_stderr.String()).Should(Equal("stderr"))
+		Ω(apitypes.ProcessPayload_Source(42).String()).Should(Equal("42"))
+	})
+})
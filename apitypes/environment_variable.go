@@ -0,0 +1,20 @@
+package apitypes
+
+type EnvironmentVariable struct {
+	Key   *string `protobuf:"bytes,1,req" json:"Key,omitempty"`
+	Value *string `protobuf:"bytes,2,req" json:"Value,omitempty"`
+}
+
+func (m *EnvironmentVariable) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *EnvironmentVariable) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package apitypes
+
+type ErrorResponse struct {
+	Message     *string                     `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+	Data        *string                     `protobuf:"bytes,4,opt,name=data" json:"data,omitempty"`
+	Backtrace   []string                    `protobuf:"bytes,3,rep,name=backtrace" json:"backtrace,omitempty"`
+	Annotations []*Property                 `protobuf:"bytes,5,rep,name=annotations" json:"annotations,omitempty"`
+	Type        *string                     `protobuf:"bytes,6,opt,name=type" json:"type,omitempty"`
+	DiskQuota   *ErrorResponse_DiskQuota    `protobuf:"bytes,7,opt,name=disk_quota" json:"disk_quota,omitempty"`
+	Validation  *ErrorResponse_Validation   `protobuf:"bytes,8,opt,name=validation" json:"validation,omitempty"`
+	Violations  []*ErrorResponse_Validation `protobuf:"bytes,9,rep,name=violations" json:"violations,omitempty"`
+	StdinLimit  *uint64                     `protobuf:"varint,10,opt,name=stdin_limit" json:"stdin_limit,omitempty"`
+	Class       *string                     `protobuf:"bytes,11,opt,name=class" json:"class,omitempty"`
+	TraceId     *string                     `protobuf:"bytes,12,opt,name=trace_id" json:"trace_id,omitempty"`
+
+	StreamInLimit *ErrorResponse_StreamInLimit `protobuf:"bytes,13,opt,name=stream_in_limit" json:"stream_in_limit,omitempty"`
+
+	MessageSizeLimit *uint64 `protobuf:"varint,14,opt,name=message_size_limit" json:"message_size_limit,omitempty"`
+}
+
+// An ErrorResponse's Type identifies which of the errors defined by the api
+// package it carries; it is empty for any other error.
+const (
+	ErrorTypeContainerNotFound       = "ContainerNotFound"
+	ErrorTypeProcessNotFound         = "ProcessNotFound"
+	ErrorTypeUnsupportedOperation    = "UnsupportedOperation"
+	ErrorTypeCapacityExceeded        = "CapacityExceeded"
+	ErrorTypeUserNotFound            = "UserNotFound"
+	ErrorTypeRateLimited             = "RateLimited"
+	ErrorTypeProcessLimitExceeded    = "ProcessLimitExceeded"
+	ErrorTypeDestroyInProgress       = "DestroyInProgress"
+	ErrorTypeOperationNotFound       = "OperationNotFound"
+	ErrorTypePermissionDenied        = "PermissionDenied"
+	ErrorTypeDestroyNotConfirmed     = "DestroyNotConfirmed"
+	ErrorTypeMultipleContainersMatch = "MultipleContainersMatch"
+
+	// ErrorTypeDiskQuotaExceeded's DiskQuota field describes the quota.
+	ErrorTypeDiskQuotaExceeded = "DiskQuotaExceeded"
+
+	// ErrorTypeStdinLimitExceeded's StdinLimit field is the limit in bytes.
+	ErrorTypeStdinLimitExceeded = "StdinLimitExceeded"
+
+	// ErrorTypeStreamInLimitExceeded's StreamInLimit field is the limit.
+	ErrorTypeStreamInLimitExceeded = "StreamInLimitExceeded"
+
+	// ErrorTypeMessageTooLarge's MessageSizeLimit field is the limit in bytes.
+	ErrorTypeMessageTooLarge = "MessageTooLarge"
+
+	// ErrorTypeValidation's Validation field names the invalid field.
+	ErrorTypeValidation = "Validation"
+
+	// ErrorTypeInvalidRequest's Violations field lists the invalid fields.
+	ErrorTypeInvalidRequest = "InvalidRequest"
+)
+
+func (m *ErrorResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+func (m *ErrorResponse) GetData() string {
+	if m != nil && m.Data != nil {
+		return *m.Data
+	}
+	return ""
+}
+
+func (m *ErrorResponse) GetBacktrace() []string {
+	if m != nil {
+		return m.Backtrace
+	}
+	return nil
+}
+
+func (m *ErrorResponse) GetAnnotations() []*Property {
+	if m != nil {
+		return m.Annotations
+	}
+	return nil
+}
+
+func (m *ErrorResponse) GetType() string {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return ""
+}
+
+func (m *ErrorResponse) GetDiskQuota() *ErrorResponse_DiskQuota {
+	if m != nil {
+		return m.DiskQuota
+	}
+	return nil
+}
+
+func (m *ErrorResponse) GetValidation() *ErrorResponse_Validation {
+	if m != nil {
+		return m.Validation
+	}
+	return nil
+}
+
+func (m *ErrorResponse) GetViolations() []*ErrorResponse_Validation {
+	if m != nil {
+		return m.Violations
+	}
+	return nil
+}
+
+func (m *ErrorResponse) GetStdinLimit() uint64 {
+	if m != nil && m.StdinLimit != nil {
+		return *m.StdinLimit
+	}
+	return 0
+}
+
+type ErrorResponse_DiskQuota struct {
+	ExpectedBytes  *uint64 `protobuf:"varint,1,opt,name=expected_bytes" json:"expected_bytes,omitempty"`
+	RemainingBytes *uint64 `protobuf:"varint,2,opt,name=remaining_bytes" json:"remaining_bytes,omitempty"`
+}
+
+func (m *ErrorResponse) GetClass() string {
+	if m != nil && m.Class != nil {
+		return *m.Class
+	}
+	return ""
+}
+
+func (m *ErrorResponse) GetTraceId() string {
+	if m != nil && m.TraceId != nil {
+		return *m.TraceId
+	}
+	return ""
+}
+
+func (m *ErrorResponse_DiskQuota) GetExpectedBytes() uint64 {
+	if m != nil && m.ExpectedBytes != nil {
+		return *m.ExpectedBytes
+	}
+	return 0
+}
+
+func (m *ErrorResponse_DiskQuota) GetRemainingBytes() uint64 {
+	if m != nil && m.RemainingBytes != nil {
+		return *m.RemainingBytes
+	}
+	return 0
+}
+
+func (m *ErrorResponse) GetStreamInLimit() *ErrorResponse_StreamInLimit {
+	if m != nil {
+		return m.StreamInLimit
+	}
+	return nil
+}
+
+func (m *ErrorResponse) GetMessageSizeLimit() uint64 {
+	if m != nil && m.MessageSizeLimit != nil {
+		return *m.MessageSizeLimit
+	}
+	return 0
+}
+
+type ErrorResponse_StreamInLimit struct {
+	LimitInBytes   *uint64 `protobuf:"varint,1,opt,name=limit_in_bytes" json:"limit_in_bytes,omitempty"`
+	LimitInEntries *uint64 `protobuf:"varint,2,opt,name=limit_in_entries" json:"limit_in_entries,omitempty"`
+}
+
+func (m *ErrorResponse_StreamInLimit) GetLimitInBytes() uint64 {
+	if m != nil && m.LimitInBytes != nil {
+		return *m.LimitInBytes
+	}
+	return 0
+}
+
+func (m *ErrorResponse_StreamInLimit) GetLimitInEntries() uint64 {
+	if m != nil && m.LimitInEntries != nil {
+		return *m.LimitInEntries
+	}
+	return 0
+}
+
+type ErrorResponse_Validation struct {
+	Field  *string `protobuf:"bytes,1,opt,name=field" json:"field,omitempty"`
+	Reason *string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *ErrorResponse_Validation) GetField() string {
+	if m != nil && m.Field != nil {
+		return *m.Field
+	}
+	return ""
+}
+
+func (m *ErrorResponse_Validation) GetReason() string {
+	if m != nil && m.Reason != nil {
+		return *m.Reason
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+type FindProcessesResponse struct {
+	ProcessIds []uint32 `protobuf:"varint,1,rep,name=process_ids" json:"process_ids,omitempty"`
+}
+
+func (m *FindProcessesResponse) GetProcessIds() []uint32 {
+	if m != nil {
+		return m.ProcessIds
+	}
+	return nil
+}
+
+type WaitForProcessResponse struct {
+	ProcessId *uint32 `protobuf:"varint,1,opt,name=process_id" json:"process_id,omitempty"`
+}
+
+func (m *WaitForProcessResponse) GetProcessId() uint32 {
+	if m != nil && m.ProcessId != nil {
+		return *m.ProcessId
+	}
+	return 0
+}
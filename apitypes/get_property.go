@@ -0,0 +1,31 @@
+package apitypes
+
+type GetPropertyRequest struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Key    *string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *GetPropertyRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *GetPropertyRequest) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+type GetPropertyResponse struct {
+	Value *string `protobuf:"bytes,1,req,name=value" json:"value,omitempty"`
+}
+
+func (m *GetPropertyResponse) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+type SetGraceTimeRequest struct {
+	Handle    *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	GraceTime *uint32 `protobuf:"varint,2,opt,name=grace_time" json:"grace_time,omitempty"`
+}
+
+func (m *SetGraceTimeRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *SetGraceTimeRequest) GetGraceTime() uint32 {
+	if m != nil && m.GraceTime != nil {
+		return *m.GraceTime
+	}
+	return 0
+}
+
+type SetGraceTimeResponse struct {
+}
@@ -0,0 +1,60 @@
+package apitypes
+
+type HealthCheckResponse struct {
+	Healthy           *bool   `protobuf:"varint,1,opt,name=healthy" json:"healthy,omitempty"`
+	BackendError      *string `protobuf:"bytes,2,opt,name=backend_error" json:"backend_error,omitempty"`
+	Containers        *uint64 `protobuf:"varint,3,opt,name=containers" json:"containers,omitempty"`
+	MaxContainers     *uint64 `protobuf:"varint,4,opt,name=max_containers" json:"max_containers,omitempty"`
+	ContainerHeadroom *uint64 `protobuf:"varint,5,opt,name=container_headroom" json:"container_headroom,omitempty"`
+	Uptime            *uint64 `protobuf:"varint,6,opt,name=uptime" json:"uptime,omitempty"`
+	ProtocolVersion   *uint32 `protobuf:"varint,7,opt,name=protocol_version" json:"protocol_version,omitempty"`
+}
+
+func (m *HealthCheckResponse) GetHealthy() bool {
+	if m != nil && m.Healthy != nil {
+		return *m.Healthy
+	}
+	return false
+}
+
+func (m *HealthCheckResponse) GetBackendError() string {
+	if m != nil && m.BackendError != nil {
+		return *m.BackendError
+	}
+	return ""
+}
+
+func (m *HealthCheckResponse) GetContainers() uint64 {
+	if m != nil && m.Containers != nil {
+		return *m.Containers
+	}
+	return 0
+}
+
+func (m *HealthCheckResponse) GetMaxContainers() uint64 {
+	if m != nil && m.MaxContainers != nil {
+		return *m.MaxContainers
+	}
+	return 0
+}
+
+func (m *HealthCheckResponse) GetContainerHeadroom() uint64 {
+	if m != nil && m.ContainerHeadroom != nil {
+		return *m.ContainerHeadroom
+	}
+	return 0
+}
+
+func (m *HealthCheckResponse) GetUptime() uint64 {
+	if m != nil && m.Uptime != nil {
+		return *m.Uptime
+	}
+	return 0
+}
+
+func (m *HealthCheckResponse) GetProtocolVersion() uint32 {
+	if m != nil && m.ProtocolVersion != nil {
+		return *m.ProtocolVersion
+	}
+	return 0
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+type SetHoldRequest struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Held   *bool   `protobuf:"varint,2,opt,name=held" json:"held,omitempty"`
+}
+
+func (m *SetHoldRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *SetHoldRequest) GetHeld() bool {
+	if m != nil && m.Held != nil {
+		return *m.Held
+	}
+	return false
+}
+
+type SetHoldResponse struct {
+}
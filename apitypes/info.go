@@ -1,25 +1,9 @@
-// Code generated by protoc-gen-gogo.
-// source: info.proto
-// DO NOT EDIT!
-
-package garden
-
-import proto "github.com/gogo/protobuf/proto"
-import math "math"
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = math.Inf
+package apitypes
 
 type InfoRequest struct {
-	Handle           *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	Handle *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
 }
 
-func (m *InfoRequest) Reset()         { *m = InfoRequest{} }
-func (m *InfoRequest) String() string { return proto.CompactTextString(m) }
-func (*InfoRequest) ProtoMessage()    {}
-
 func (m *InfoRequest) GetHandle() string {
 	if m != nil && m.Handle != nil {
 		return *m.Handle
@@ -28,25 +12,29 @@ func (m *InfoRequest) GetHandle() string {
 }
 
 type InfoResponse struct {
-	State            *string                     `protobuf:"bytes,10,opt,name=state" json:"state,omitempty"`
-	Events           []string                    `protobuf:"bytes,20,rep,name=events" json:"events,omitempty"`
-	HostIp           *string                     `protobuf:"bytes,30,opt,name=host_ip" json:"host_ip,omitempty"`
-	ContainerIp      *string                     `protobuf:"bytes,31,opt,name=container_ip" json:"container_ip,omitempty"`
-	ContainerPath    *string                     `protobuf:"bytes,32,opt,name=container_path" json:"container_path,omitempty"`
-	ExternalIp       *string                     `protobuf:"bytes,33,opt,name=external_ip" json:"external_ip,omitempty"`
-	MemoryStat       *InfoResponse_MemoryStat    `protobuf:"bytes,40,opt,name=memory_stat" json:"memory_stat,omitempty"`
-	CpuStat          *InfoResponse_CpuStat       `protobuf:"bytes,41,opt,name=cpu_stat" json:"cpu_stat,omitempty"`
-	DiskStat         *InfoResponse_DiskStat      `protobuf:"bytes,42,opt,name=disk_stat" json:"disk_stat,omitempty"`
-	BandwidthStat    *InfoResponse_BandwidthStat `protobuf:"bytes,43,opt,name=bandwidth_stat" json:"bandwidth_stat,omitempty"`
-	ProcessIds       []uint64                    `protobuf:"varint,44,rep,name=process_ids" json:"process_ids,omitempty"`
-	Properties       []*Property                 `protobuf:"bytes,45,rep,name=properties" json:"properties,omitempty"`
-	MappedPorts      []*InfoResponse_PortMapping `protobuf:"bytes,46,rep,name=mapped_ports" json:"mapped_ports,omitempty"`
-	XXX_unrecognized []byte                      `json:"-"`
-}
-
-func (m *InfoResponse) Reset()         { *m = InfoResponse{} }
-func (m *InfoResponse) String() string { return proto.CompactTextString(m) }
-func (*InfoResponse) ProtoMessage()    {}
+	State         *string                     `protobuf:"bytes,10,opt,name=state" json:"state,omitempty"`
+	Events        []string                    `protobuf:"bytes,20,rep,name=events" json:"events,omitempty"`
+	HostIp        *string                     `protobuf:"bytes,30,opt,name=host_ip" json:"host_ip,omitempty"`
+	ContainerIp   *string                     `protobuf:"bytes,31,opt,name=container_ip" json:"container_ip,omitempty"`
+	ContainerPath *string                     `protobuf:"bytes,32,opt,name=container_path" json:"container_path,omitempty"`
+	ExternalIp    *string                     `protobuf:"bytes,33,opt,name=external_ip" json:"external_ip,omitempty"`
+	MemoryStat    *InfoResponse_MemoryStat    `protobuf:"bytes,40,opt,name=memory_stat" json:"memory_stat,omitempty"`
+	CpuStat       *InfoResponse_CpuStat       `protobuf:"bytes,41,opt,name=cpu_stat" json:"cpu_stat,omitempty"`
+	DiskStat      *InfoResponse_DiskStat      `protobuf:"bytes,42,opt,name=disk_stat" json:"disk_stat,omitempty"`
+	BandwidthStat *InfoResponse_BandwidthStat `protobuf:"bytes,43,opt,name=bandwidth_stat" json:"bandwidth_stat,omitempty"`
+	ProcessIds    []uint64                    `protobuf:"varint,44,rep,name=process_ids" json:"process_ids,omitempty"`
+	Properties    []*Property                 `protobuf:"bytes,45,rep,name=properties" json:"properties,omitempty"`
+	MappedPorts   []*InfoResponse_PortMapping `protobuf:"bytes,46,rep,name=mapped_ports" json:"mapped_ports,omitempty"`
+	Held          *bool                       `protobuf:"varint,47,opt,name=held" json:"held,omitempty"`
+	GraceTime     *uint32                     `protobuf:"varint,48,opt,name=grace_time" json:"grace_time,omitempty"`
+	CreatedAt     *int64                      `protobuf:"varint,49,opt,name=created_at" json:"created_at,omitempty"`
+
+	ExternalInterface *string `protobuf:"bytes,50,opt,name=external_interface" json:"external_interface,omitempty"`
+	TimeToGraceExpiry *uint64 `protobuf:"varint,51,opt,name=time_to_grace_expiry" json:"time_to_grace_expiry,omitempty"`
+	LastActivityAt    *int64  `protobuf:"varint,52,opt,name=last_activity_at" json:"last_activity_at,omitempty"`
+
+	Limits *InfoResponse_Limits `protobuf:"bytes,53,opt,name=limits" json:"limits,omitempty"`
+}
 
 func (m *InfoResponse) GetState() string {
 	if m != nil && m.State != nil {
@@ -139,6 +127,90 @@ func (m *InfoResponse) GetMappedPorts() []*InfoResponse_PortMapping {
 	return nil
 }
 
+func (m *InfoResponse) GetHeld() bool {
+	if m != nil && m.Held != nil {
+		return *m.Held
+	}
+	return false
+}
+
+func (m *InfoResponse) GetGraceTime() uint32 {
+	if m != nil && m.GraceTime != nil {
+		return *m.GraceTime
+	}
+	return 0
+}
+
+func (m *InfoResponse) GetCreatedAt() int64 {
+	if m != nil && m.CreatedAt != nil {
+		return *m.CreatedAt
+	}
+	return 0
+}
+
+func (m *InfoResponse) GetExternalInterface() string {
+	if m != nil && m.ExternalInterface != nil {
+		return *m.ExternalInterface
+	}
+	return ""
+}
+
+func (m *InfoResponse) GetTimeToGraceExpiry() uint64 {
+	if m != nil && m.TimeToGraceExpiry != nil {
+		return *m.TimeToGraceExpiry
+	}
+	return 0
+}
+
+func (m *InfoResponse) GetLastActivityAt() int64 {
+	if m != nil && m.LastActivityAt != nil {
+		return *m.LastActivityAt
+	}
+	return 0
+}
+
+func (m *InfoResponse) GetLimits() *InfoResponse_Limits {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+type InfoResponse_Limits struct {
+	Bandwidth *LimitBandwidthResponse `protobuf:"bytes,1,opt,name=bandwidth" json:"bandwidth,omitempty"`
+	Cpu       *LimitCpuResponse       `protobuf:"bytes,2,opt,name=cpu" json:"cpu,omitempty"`
+	Disk      *LimitDiskResponse      `protobuf:"bytes,3,opt,name=disk" json:"disk,omitempty"`
+	Memory    *LimitMemoryResponse    `protobuf:"bytes,4,opt,name=memory" json:"memory,omitempty"`
+}
+
+func (m *InfoResponse_Limits) GetBandwidth() *LimitBandwidthResponse {
+	if m != nil {
+		return m.Bandwidth
+	}
+	return nil
+}
+
+func (m *InfoResponse_Limits) GetCpu() *LimitCpuResponse {
+	if m != nil {
+		return m.Cpu
+	}
+	return nil
+}
+
+func (m *InfoResponse_Limits) GetDisk() *LimitDiskResponse {
+	if m != nil {
+		return m.Disk
+	}
+	return nil
+}
+
+func (m *InfoResponse_Limits) GetMemory() *LimitMemoryResponse {
+	if m != nil {
+		return m.Memory
+	}
+	return nil
+}
+
 type InfoResponse_MemoryStat struct {
 	Cache                   *uint64 `protobuf:"varint,1,opt,name=cache" json:"cache,omitempty"`
 	Rss                     *uint64 `protobuf:"varint,2,opt,name=rss" json:"rss,omitempty"`
@@ -168,13 +240,8 @@ type InfoResponse_MemoryStat struct {
 	TotalInactiveFile       *uint64 `protobuf:"varint,26,opt,name=total_inactive_file" json:"total_inactive_file,omitempty"`
 	TotalActiveFile         *uint64 `protobuf:"varint,27,opt,name=total_active_file" json:"total_active_file,omitempty"`
 	TotalUnevictable        *uint64 `protobuf:"varint,28,opt,name=total_unevictable" json:"total_unevictable,omitempty"`
-	XXX_unrecognized        []byte  `json:"-"`
 }
 
-func (m *InfoResponse_MemoryStat) Reset()         { *m = InfoResponse_MemoryStat{} }
-func (m *InfoResponse_MemoryStat) String() string { return proto.CompactTextString(m) }
-func (*InfoResponse_MemoryStat) ProtoMessage()    {}
-
 func (m *InfoResponse_MemoryStat) GetCache() uint64 {
 	if m != nil && m.Cache != nil {
 		return *m.Cache
@@ -372,16 +439,11 @@ func (m *InfoResponse_MemoryStat) GetTotalUnevictable() uint64 {
 }
 
 type InfoResponse_CpuStat struct {
-	Usage            *uint64 `protobuf:"varint,1,opt,name=usage" json:"usage,omitempty"`
-	User             *uint64 `protobuf:"varint,2,opt,name=user" json:"user,omitempty"`
-	System           *uint64 `protobuf:"varint,3,opt,name=system" json:"system,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	Usage  *uint64 `protobuf:"varint,1,opt,name=usage" json:"usage,omitempty"`
+	User   *uint64 `protobuf:"varint,2,opt,name=user" json:"user,omitempty"`
+	System *uint64 `protobuf:"varint,3,opt,name=system" json:"system,omitempty"`
 }
 
-func (m *InfoResponse_CpuStat) Reset()         { *m = InfoResponse_CpuStat{} }
-func (m *InfoResponse_CpuStat) String() string { return proto.CompactTextString(m) }
-func (*InfoResponse_CpuStat) ProtoMessage()    {}
-
 func (m *InfoResponse_CpuStat) GetUsage() uint64 {
 	if m != nil && m.Usage != nil {
 		return *m.Usage
@@ -404,15 +466,10 @@ func (m *InfoResponse_CpuStat) GetSystem() uint64 {
 }
 
 type InfoResponse_DiskStat struct {
-	BytesUsed        *uint64 `protobuf:"varint,1,opt,name=bytes_used" json:"bytes_used,omitempty"`
-	InodesUsed       *uint64 `protobuf:"varint,2,opt,name=inodes_used" json:"inodes_used,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	BytesUsed  *uint64 `protobuf:"varint,1,opt,name=bytes_used" json:"bytes_used,omitempty"`
+	InodesUsed *uint64 `protobuf:"varint,2,opt,name=inodes_used" json:"inodes_used,omitempty"`
 }
 
-func (m *InfoResponse_DiskStat) Reset()         { *m = InfoResponse_DiskStat{} }
-func (m *InfoResponse_DiskStat) String() string { return proto.CompactTextString(m) }
-func (*InfoResponse_DiskStat) ProtoMessage()    {}
-
 func (m *InfoResponse_DiskStat) GetBytesUsed() uint64 {
 	if m != nil && m.BytesUsed != nil {
 		return *m.BytesUsed
@@ -428,17 +485,12 @@ func (m *InfoResponse_DiskStat) GetInodesUsed() uint64 {
 }
 
 type InfoResponse_BandwidthStat struct {
-	InRate           *uint64 `protobuf:"varint,1,opt,name=in_rate" json:"in_rate,omitempty"`
-	InBurst          *uint64 `protobuf:"varint,2,opt,name=in_burst" json:"in_burst,omitempty"`
-	OutRate          *uint64 `protobuf:"varint,3,opt,name=out_rate" json:"out_rate,omitempty"`
-	OutBurst         *uint64 `protobuf:"varint,4,opt,name=out_burst" json:"out_burst,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	InRate   *uint64 `protobuf:"varint,1,opt,name=in_rate" json:"in_rate,omitempty"`
+	InBurst  *uint64 `protobuf:"varint,2,opt,name=in_burst" json:"in_burst,omitempty"`
+	OutRate  *uint64 `protobuf:"varint,3,opt,name=out_rate" json:"out_rate,omitempty"`
+	OutBurst *uint64 `protobuf:"varint,4,opt,name=out_burst" json:"out_burst,omitempty"`
 }
 
-func (m *InfoResponse_BandwidthStat) Reset()         { *m = InfoResponse_BandwidthStat{} }
-func (m *InfoResponse_BandwidthStat) String() string { return proto.CompactTextString(m) }
-func (*InfoResponse_BandwidthStat) ProtoMessage()    {}
-
 func (m *InfoResponse_BandwidthStat) GetInRate() uint64 {
 	if m != nil && m.InRate != nil {
 		return *m.InRate
@@ -468,15 +520,12 @@ func (m *InfoResponse_BandwidthStat) GetOutBurst() uint64 {
 }
 
 type InfoResponse_PortMapping struct {
-	HostPort         *uint32 `protobuf:"varint,1,req,name=host_port" json:"host_port,omitempty"`
-	ContainerPort    *uint32 `protobuf:"varint,2,req,name=container_port" json:"container_port,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	HostPort      *uint32                `protobuf:"varint,1,req,name=host_port" json:"host_port,omitempty"`
+	ContainerPort *uint32                `protobuf:"varint,2,req,name=container_port" json:"container_port,omitempty"`
+	Protocol      *NetInRequest_Protocol `protobuf:"varint,3,opt,name=protocol,enum=garden.NetInRequest_Protocol" json:"protocol,omitempty"`
+	HostIp        *string                `protobuf:"bytes,4,opt,name=host_ip" json:"host_ip,omitempty"`
 }
 
-func (m *InfoResponse_PortMapping) Reset()         { *m = InfoResponse_PortMapping{} }
-func (m *InfoResponse_PortMapping) String() string { return proto.CompactTextString(m) }
-func (*InfoResponse_PortMapping) ProtoMessage()    {}
-
 func (m *InfoResponse_PortMapping) GetHostPort() uint32 {
 	if m != nil && m.HostPort != nil {
 		return *m.HostPort
@@ -491,5 +540,16 @@ func (m *InfoResponse_PortMapping) GetContainerPort() uint32 {
 	return 0
 }
 
-func init() {
+func (m *InfoResponse_PortMapping) GetProtocol() NetInRequest_Protocol {
+	if m != nil && m.Protocol != nil {
+		return *m.Protocol
+	}
+	return NetInRequest_TCP
+}
+
+func (m *InfoResponse_PortMapping) GetHostIp() string {
+	if m != nil && m.HostIp != nil {
+		return *m.HostIp
+	}
+	return ""
 }
@@ -0,0 +1,47 @@
+package apitypes
+
+type LimitBandwidthRequest struct {
+	Handle *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	Rate   *uint64 `protobuf:"varint,2,req,name=rate" json:"rate,omitempty"`
+	Burst  *uint64 `protobuf:"varint,3,req,name=burst" json:"burst,omitempty"`
+}
+
+func (m *LimitBandwidthRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *LimitBandwidthRequest) GetRate() uint64 {
+	if m != nil && m.Rate != nil {
+		return *m.Rate
+	}
+	return 0
+}
+
+func (m *LimitBandwidthRequest) GetBurst() uint64 {
+	if m != nil && m.Burst != nil {
+		return *m.Burst
+	}
+	return 0
+}
+
+type LimitBandwidthResponse struct {
+	Rate  *uint64 `protobuf:"varint,1,req,name=rate" json:"rate,omitempty"`
+	Burst *uint64 `protobuf:"varint,2,req,name=burst" json:"burst,omitempty"`
+}
+
+func (m *LimitBandwidthResponse) GetRate() uint64 {
+	if m != nil && m.Rate != nil {
+		return *m.Rate
+	}
+	return 0
+}
+
+func (m *LimitBandwidthResponse) GetBurst() uint64 {
+	if m != nil && m.Burst != nil {
+		return *m.Burst
+	}
+	return 0
+}
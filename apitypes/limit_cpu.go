@@ -0,0 +1,79 @@
+package apitypes
+
+type LimitCpuRequest struct {
+	Handle        *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	LimitInShares *uint64 `protobuf:"varint,2,opt,name=limit_in_shares" json:"limit_in_shares,omitempty"`
+	Quota         *uint64 `protobuf:"varint,3,opt,name=quota" json:"quota,omitempty"`
+	Period        *uint64 `protobuf:"varint,4,opt,name=period" json:"period,omitempty"`
+	Cpuset        *string `protobuf:"bytes,5,opt,name=cpuset" json:"cpuset,omitempty"`
+}
+
+func (m *LimitCpuRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *LimitCpuRequest) GetLimitInShares() uint64 {
+	if m != nil && m.LimitInShares != nil {
+		return *m.LimitInShares
+	}
+	return 0
+}
+
+func (m *LimitCpuRequest) GetQuota() uint64 {
+	if m != nil && m.Quota != nil {
+		return *m.Quota
+	}
+	return 0
+}
+
+func (m *LimitCpuRequest) GetPeriod() uint64 {
+	if m != nil && m.Period != nil {
+		return *m.Period
+	}
+	return 0
+}
+
+func (m *LimitCpuRequest) GetCpuset() string {
+	if m != nil && m.Cpuset != nil {
+		return *m.Cpuset
+	}
+	return ""
+}
+
+type LimitCpuResponse struct {
+	LimitInShares *uint64 `protobuf:"varint,1,opt,name=limit_in_shares" json:"limit_in_shares,omitempty"`
+	Quota         *uint64 `protobuf:"varint,2,opt,name=quota" json:"quota,omitempty"`
+	Period        *uint64 `protobuf:"varint,3,opt,name=period" json:"period,omitempty"`
+	Cpuset        *string `protobuf:"bytes,4,opt,name=cpuset" json:"cpuset,omitempty"`
+}
+
+func (m *LimitCpuResponse) GetLimitInShares() uint64 {
+	if m != nil && m.LimitInShares != nil {
+		return *m.LimitInShares
+	}
+	return 0
+}
+
+func (m *LimitCpuResponse) GetQuota() uint64 {
+	if m != nil && m.Quota != nil {
+		return *m.Quota
+	}
+	return 0
+}
+
+func (m *LimitCpuResponse) GetPeriod() uint64 {
+	if m != nil && m.Period != nil {
+		return *m.Period
+	}
+	return 0
+}
+
+func (m *LimitCpuResponse) GetCpuset() string {
+	if m != nil && m.Cpuset != nil {
+		return *m.Cpuset
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package apitypes
+
+type LimitMemoryRequest struct {
+	Handle       *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	LimitInBytes *uint64 `protobuf:"varint,2,opt,name=limit_in_bytes" json:"limit_in_bytes,omitempty"`
+}
+
+func (m *LimitMemoryRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *LimitMemoryRequest) GetLimitInBytes() uint64 {
+	if m != nil && m.LimitInBytes != nil {
+		return *m.LimitInBytes
+	}
+	return 0
+}
+
+type LimitMemoryResponse struct {
+	LimitInBytes *uint64 `protobuf:"varint,1,opt,name=limit_in_bytes" json:"limit_in_bytes,omitempty"`
+}
+
+func (m *LimitMemoryResponse) GetLimitInBytes() uint64 {
+	if m != nil && m.LimitInBytes != nil {
+		return *m.LimitInBytes
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package apitypes
+
+type ListRequest struct {
+	Properties []*Property `protobuf:"bytes,1,rep,name=properties" json:"properties,omitempty"`
+}
+
+func (m *ListRequest) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+type ListResponse struct {
+	Handles    []string                  `protobuf:"bytes,1,rep,name=handles" json:"handles,omitempty"`
+	Containers []*ListResponse_Container `protobuf:"bytes,2,rep,name=containers" json:"containers,omitempty"`
+}
+
+func (m *ListResponse) GetHandles() []string {
+	if m != nil {
+		return m.Handles
+	}
+	return nil
+}
+
+func (m *ListResponse) GetContainers() []*ListResponse_Container {
+	if m != nil {
+		return m.Containers
+	}
+	return nil
+}
+
+type ListResponse_Container struct {
+	Handle     *string     `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	State      *string     `protobuf:"bytes,2,opt,name=state" json:"state,omitempty"`
+	Properties []*Property `protobuf:"bytes,3,rep,name=properties" json:"properties,omitempty"`
+}
+
+func (m *ListResponse_Container) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *ListResponse_Container) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
+
+func (m *ListResponse_Container) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
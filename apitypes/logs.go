@@ -0,0 +1,36 @@
+package apitypes
+
+type LogEntry struct {
+	ProcessId *uint32                `protobuf:"varint,1,opt,name=process_id" json:"process_id,omitempty"`
+	Source    *ProcessPayload_Source `protobuf:"varint,2,opt,name=source,enum=garden.ProcessPayload_Source" json:"source,omitempty"`
+	Data      *string                `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
+	Timestamp *int64                 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *LogEntry) GetProcessId() uint32 {
+	if m != nil && m.ProcessId != nil {
+		return *m.ProcessId
+	}
+	return 0
+}
+
+func (m *LogEntry) GetSource() ProcessPayload_Source {
+	if m != nil && m.Source != nil {
+		return *m.Source
+	}
+	return ProcessPayload_stdin
+}
+
+func (m *LogEntry) GetData() string {
+	if m != nil && m.Data != nil {
+		return *m.Data
+	}
+	return ""
+}
+
+func (m *LogEntry) GetTimestamp() int64 {
+	if m != nil && m.Timestamp != nil {
+		return *m.Timestamp
+	}
+	return 0
+}
@@ -0,0 +1,12 @@
+package apitypes
+
+type LookupResponse struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+}
+
+func (m *LookupResponse) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package apitypes
+
+type NetInRequest_Protocol int32
+
+const (
+	NetInRequest_TCP NetInRequest_Protocol = 0
+	NetInRequest_UDP NetInRequest_Protocol = 1
+)
+
+var NetInRequest_Protocol_name = map[int32]string{
+	0: "TCP",
+	1: "UDP",
+}
+var NetInRequest_Protocol_value = map[string]int32{
+	"TCP": 0,
+	"UDP": 1,
+}
+
+func (x NetInRequest_Protocol) Enum() *NetInRequest_Protocol {
+	p := new(NetInRequest_Protocol)
+	*p = x
+	return p
+}
+func (x NetInRequest_Protocol) String() string {
+	return enumName(NetInRequest_Protocol_name, int32(x))
+}
+func (x *NetInRequest_Protocol) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalJSONEnum(NetInRequest_Protocol_value, data, "NetInRequest_Protocol")
+	if err != nil {
+		return err
+	}
+	*x = NetInRequest_Protocol(value)
+	return nil
+}
+
+type NetInRequest struct {
+	Handle        *string                `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	HostPort      *uint32                `protobuf:"varint,3,opt,name=host_port" json:"host_port,omitempty"`
+	ContainerPort *uint32                `protobuf:"varint,2,opt,name=container_port" json:"container_port,omitempty"`
+	Protocol      *NetInRequest_Protocol `protobuf:"varint,4,opt,name=protocol,enum=garden.NetInRequest_Protocol" json:"protocol,omitempty"`
+	HostIp        *string                `protobuf:"bytes,5,opt,name=host_ip" json:"host_ip,omitempty"`
+}
+
+func (m *NetInRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *NetInRequest) GetHostPort() uint32 {
+	if m != nil && m.HostPort != nil {
+		return *m.HostPort
+	}
+	return 0
+}
+
+func (m *NetInRequest) GetContainerPort() uint32 {
+	if m != nil && m.ContainerPort != nil {
+		return *m.ContainerPort
+	}
+	return 0
+}
+
+func (m *NetInRequest) GetProtocol() NetInRequest_Protocol {
+	if m != nil && m.Protocol != nil {
+		return *m.Protocol
+	}
+	return NetInRequest_TCP
+}
+
+func (m *NetInRequest) GetHostIp() string {
+	if m != nil && m.HostIp != nil {
+		return *m.HostIp
+	}
+	return ""
+}
+
+type NetInResponse struct {
+	HostPort      *uint32 `protobuf:"varint,1,req,name=host_port" json:"host_port,omitempty"`
+	ContainerPort *uint32 `protobuf:"varint,2,req,name=container_port" json:"container_port,omitempty"`
+}
+
+func (m *NetInResponse) GetHostPort() uint32 {
+	if m != nil && m.HostPort != nil {
+		return *m.HostPort
+	}
+	return 0
+}
+
+func (m *NetInResponse) GetContainerPort() uint32 {
+	if m != nil && m.ContainerPort != nil {
+		return *m.ContainerPort
+	}
+	return 0
+}
+
+type MappedPortsResponse struct {
+	MappedPorts []*InfoResponse_PortMapping `protobuf:"bytes,1,rep,name=mapped_ports" json:"mapped_ports,omitempty"`
+}
+
+func (m *MappedPortsResponse) GetMappedPorts() []*InfoResponse_PortMapping {
+	if m != nil {
+		return m.MappedPorts
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package apitypes
+
+type NetOutRuleRequest_Protocol int32
+
+const (
+	NetOutRuleRequest_ALL  NetOutRuleRequest_Protocol = 0
+	NetOutRuleRequest_TCP  NetOutRuleRequest_Protocol = 1
+	NetOutRuleRequest_UDP  NetOutRuleRequest_Protocol = 2
+	NetOutRuleRequest_ICMP NetOutRuleRequest_Protocol = 3
+)
+
+var NetOutRuleRequest_Protocol_name = map[int32]string{
+	0: "ALL",
+	1: "TCP",
+	2: "UDP",
+	3: "ICMP",
+}
+var NetOutRuleRequest_Protocol_value = map[string]int32{
+	"ALL":  0,
+	"TCP":  1,
+	"UDP":  2,
+	"ICMP": 3,
+}
+
+func (x NetOutRuleRequest_Protocol) Enum() *NetOutRuleRequest_Protocol {
+	p := new(NetOutRuleRequest_Protocol)
+	*p = x
+	return p
+}
+func (x NetOutRuleRequest_Protocol) String() string {
+	return enumName(NetOutRuleRequest_Protocol_name, int32(x))
+}
+func (x *NetOutRuleRequest_Protocol) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalJSONEnum(NetOutRuleRequest_Protocol_value, data, "NetOutRuleRequest_Protocol")
+	if err != nil {
+		return err
+	}
+	*x = NetOutRuleRequest_Protocol(value)
+	return nil
+}
+
+type NetOutRuleRequest struct {
+	Handle   *string                        `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Protocol *NetOutRuleRequest_Protocol    `protobuf:"varint,2,opt,name=protocol,enum=garden.NetOutRuleRequest_Protocol" json:"protocol,omitempty"`
+	Networks []*NetOutRuleRequest_IPRange   `protobuf:"bytes,3,rep,name=networks" json:"networks,omitempty"`
+	Ports    []*NetOutRuleRequest_PortRange `protobuf:"bytes,4,rep,name=ports" json:"ports,omitempty"`
+	Log      *bool                          `protobuf:"varint,5,opt,name=log" json:"log,omitempty"`
+}
+
+func (m *NetOutRuleRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *NetOutRuleRequest) GetProtocol() NetOutRuleRequest_Protocol {
+	if m != nil && m.Protocol != nil {
+		return *m.Protocol
+	}
+	return NetOutRuleRequest_ALL
+}
+
+func (m *NetOutRuleRequest) GetNetworks() []*NetOutRuleRequest_IPRange {
+	if m != nil {
+		return m.Networks
+	}
+	return nil
+}
+
+func (m *NetOutRuleRequest) GetPorts() []*NetOutRuleRequest_PortRange {
+	if m != nil {
+		return m.Ports
+	}
+	return nil
+}
+
+func (m *NetOutRuleRequest) GetLog() bool {
+	if m != nil && m.Log != nil {
+		return *m.Log
+	}
+	return false
+}
+
+type NetOutRuleRequest_IPRange struct {
+	Start *string `protobuf:"bytes,1,opt,name=start" json:"start,omitempty"`
+	End   *string `protobuf:"bytes,2,opt,name=end" json:"end,omitempty"`
+}
+
+func (m *NetOutRuleRequest_IPRange) GetStart() string {
+	if m != nil && m.Start != nil {
+		return *m.Start
+	}
+	return ""
+}
+
+func (m *NetOutRuleRequest_IPRange) GetEnd() string {
+	if m != nil && m.End != nil {
+		return *m.End
+	}
+	return ""
+}
+
+type NetOutRuleRequest_PortRange struct {
+	Start *uint32 `protobuf:"varint,1,opt,name=start" json:"start,omitempty"`
+	End   *uint32 `protobuf:"varint,2,opt,name=end" json:"end,omitempty"`
+}
+
+func (m *NetOutRuleRequest_PortRange) GetStart() uint32 {
+	if m != nil && m.Start != nil {
+		return *m.Start
+	}
+	return 0
+}
+
+func (m *NetOutRuleRequest_PortRange) GetEnd() uint32 {
+	if m != nil && m.End != nil {
+		return *m.End
+	}
+	return 0
+}
+
+type NetOutRuleResponse struct {
+}
@@ -0,0 +1,29 @@
+package apitypes
+
+type PauseRequest struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+}
+
+func (m *PauseRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+type PauseResponse struct {
+}
+
+type ResumeRequest struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+}
+
+func (m *ResumeRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+type ResumeResponse struct {
+}
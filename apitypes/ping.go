@@ -0,0 +1,7 @@
+package apitypes
+
+type PingRequest struct {
+}
+
+type PingResponse struct {
+}
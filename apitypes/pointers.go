@@ -0,0 +1,27 @@
+package apitypes
+
+// Helpers for filling in optional fields.
+
+func Bool(v bool) *bool {
+	return &v
+}
+
+func String(v string) *string {
+	return &v
+}
+
+func Int64(v int64) *int64 {
+	return &v
+}
+
+func Uint32(v uint32) *uint32 {
+	return &v
+}
+
+func Uint64(v uint64) *uint64 {
+	return &v
+}
+
+func Float64(v float64) *float64 {
+	return &v
+}
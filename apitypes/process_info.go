@@ -0,0 +1,44 @@
+package apitypes
+
+type ProcessInfoResponse struct {
+	ProcessId *uint32 `protobuf:"varint,1,opt,name=process_id" json:"process_id,omitempty"`
+	State     *string `protobuf:"bytes,2,opt,name=state" json:"state,omitempty"`
+	CpuTime   *uint64 `protobuf:"varint,3,opt,name=cpu_time" json:"cpu_time,omitempty"`
+	Rss       *uint64 `protobuf:"varint,4,opt,name=rss" json:"rss,omitempty"`
+	StartedAt *int64  `protobuf:"varint,5,opt,name=started_at" json:"started_at,omitempty"`
+}
+
+func (m *ProcessInfoResponse) GetProcessId() uint32 {
+	if m != nil && m.ProcessId != nil {
+		return *m.ProcessId
+	}
+	return 0
+}
+
+func (m *ProcessInfoResponse) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
+
+func (m *ProcessInfoResponse) GetCpuTime() uint64 {
+	if m != nil && m.CpuTime != nil {
+		return *m.CpuTime
+	}
+	return 0
+}
+
+func (m *ProcessInfoResponse) GetRss() uint64 {
+	if m != nil && m.Rss != nil {
+		return *m.Rss
+	}
+	return 0
+}
+
+func (m *ProcessInfoResponse) GetStartedAt() int64 {
+	if m != nil && m.StartedAt != nil {
+		return *m.StartedAt
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package apitypes
+
+type ProcessPayload_Source int32
+
+const (
+	ProcessPayload_stdin  ProcessPayload_Source = 0
+	ProcessPayload_stdout ProcessPayload_Source = 1
+	ProcessPayload_stderr ProcessPayload_Source = 2
+)
+
+var ProcessPayload_Source_name = map[int32]string{
+	0: "stdin",
+	1: "stdout",
+	2: "stderr",
+}
+var ProcessPayload_Source_value = map[string]int32{
+	"stdin":  0,
+	"stdout": 1,
+	"stderr": 2,
+}
+
+func (x ProcessPayload_Source) Enum() *ProcessPayload_Source {
+	p := new(ProcessPayload_Source)
+	*p = x
+	return p
+}
+func (x ProcessPayload_Source) String() string {
+	return enumName(ProcessPayload_Source_name, int32(x))
+}
+func (x *ProcessPayload_Source) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalJSONEnum(ProcessPayload_Source_value, data, "ProcessPayload_Source")
+	if err != nil {
+		return err
+	}
+	*x = ProcessPayload_Source(value)
+	return nil
+}
+
+type ProcessPayload struct {
+	ProcessId   *uint32                `protobuf:"varint,1,req,name=process_id" json:"process_id,omitempty"`
+	Source      *ProcessPayload_Source `protobuf:"varint,2,opt,name=source,enum=garden.ProcessPayload_Source" json:"source,omitempty"`
+	Data        *string                `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
+	ExitStatus  *uint32                `protobuf:"varint,4,opt,name=exit_status" json:"exit_status,omitempty"`
+	Error       *string                `protobuf:"bytes,5,opt,name=error" json:"error,omitempty"`
+	Tty         *TTY                   `protobuf:"bytes,6,opt,name=tty" json:"tty,omitempty"`
+	StdinWindow *uint32                `protobuf:"varint,7,opt,name=stdin_window" json:"stdin_window,omitempty"`
+	StdinAck    *uint32                `protobuf:"varint,8,opt,name=stdin_ack" json:"stdin_ack,omitempty"`
+	Rlimits     *ResourceLimits        `protobuf:"bytes,9,opt,name=rlimits" json:"rlimits,omitempty"`
+
+	// StdinError is sent when the server stops accepting the process's
+	// stdin, e.g. for exceeding its limit. The process keeps running.
+	StdinError *ErrorResponse `protobuf:"bytes,10,opt,name=stdin_error" json:"stdin_error,omitempty"`
+
+	// AllocateTty asks the server to give the process a TTY. It replies with
+	// TtyAllocated once it has, or with TtyError if it could not.
+	AllocateTty  *TTY           `protobuf:"bytes,11,opt,name=allocate_tty" json:"allocate_tty,omitempty"`
+	TtyAllocated *bool          `protobuf:"varint,12,opt,name=tty_allocated" json:"tty_allocated,omitempty"`
+	TtyError     *ErrorResponse `protobuf:"bytes,13,opt,name=tty_error" json:"tty_error,omitempty"`
+
+	// ReconnectToken is sent with the first payload of a run process, if the
+	// server gives them out, for resuming its streams with an AttachRequest.
+	ReconnectToken *string `protobuf:"bytes,14,opt,name=reconnect_token" json:"reconnect_token,omitempty"`
+}
+
+func (m *ProcessPayload) GetProcessId() uint32 {
+	if m != nil && m.ProcessId != nil {
+		return *m.ProcessId
+	}
+	return 0
+}
+
+func (m *ProcessPayload) GetSource() ProcessPayload_Source {
+	if m != nil && m.Source != nil {
+		return *m.Source
+	}
+	return ProcessPayload_stdin
+}
+
+func (m *ProcessPayload) GetData() string {
+	if m != nil && m.Data != nil {
+		return *m.Data
+	}
+	return ""
+}
+
+func (m *ProcessPayload) GetExitStatus() uint32 {
+	if m != nil && m.ExitStatus != nil {
+		return *m.ExitStatus
+	}
+	return 0
+}
+
+func (m *ProcessPayload) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+func (m *ProcessPayload) GetTty() *TTY {
+	if m != nil {
+		return m.Tty
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetStdinWindow() uint32 {
+	if m != nil && m.StdinWindow != nil {
+		return *m.StdinWindow
+	}
+	return 0
+}
+
+func (m *ProcessPayload) GetStdinAck() uint32 {
+	if m != nil && m.StdinAck != nil {
+		return *m.StdinAck
+	}
+	return 0
+}
+
+func (m *ProcessPayload) GetRlimits() *ResourceLimits {
+	if m != nil {
+		return m.Rlimits
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetStdinError() *ErrorResponse {
+	if m != nil {
+		return m.StdinError
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetAllocateTty() *TTY {
+	if m != nil {
+		return m.AllocateTty
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetTtyAllocated() bool {
+	if m != nil && m.TtyAllocated != nil {
+		return *m.TtyAllocated
+	}
+	return false
+}
+
+func (m *ProcessPayload) GetTtyError() *ErrorResponse {
+	if m != nil {
+		return m.TtyError
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetReconnectToken() string {
+	if m != nil && m.ReconnectToken != nil {
+		return *m.ReconnectToken
+	}
+	return ""
+}
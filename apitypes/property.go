@@ -0,0 +1,20 @@
+package apitypes
+
+type Property struct {
+	Key   *string `protobuf:"bytes,1,req" json:"Key,omitempty"`
+	Value *string `protobuf:"bytes,2,req" json:"Value,omitempty"`
+}
+
+func (m *Property) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *Property) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+type RemovePropertyRequest struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Key    *string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *RemovePropertyRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *RemovePropertyRequest) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+type RemovePropertyResponse struct {
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+type RenameRequest struct {
+	Handle    *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	NewHandle *string `protobuf:"bytes,2,opt,name=new_handle" json:"new_handle,omitempty"`
+}
+
+func (m *RenameRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *RenameRequest) GetNewHandle() string {
+	if m != nil && m.NewHandle != nil {
+		return *m.NewHandle
+	}
+	return ""
+}
+
+type RenameResponse struct {
+}
@@ -0,0 +1,128 @@
+package apitypes
+
+type RunRequest struct {
+	Handle     *string                `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	Path       *string                `protobuf:"bytes,2,req,name=path" json:"path,omitempty"`
+	Privileged *bool                  `protobuf:"varint,3,opt,name=privileged,def=0" json:"privileged,omitempty"`
+	User       *string                `protobuf:"bytes,9,opt,name=user" json:"user,omitempty"`
+	Uid        *uint32                `protobuf:"varint,10,opt,name=uid" json:"uid,omitempty"`
+	Gid        *uint32                `protobuf:"varint,11,opt,name=gid" json:"gid,omitempty"`
+	Rlimits    *ResourceLimits        `protobuf:"bytes,4,opt,name=rlimits" json:"rlimits,omitempty"`
+	Env        []*EnvironmentVariable `protobuf:"bytes,5,rep,name=env" json:"env,omitempty"`
+	Args       []string               `protobuf:"bytes,6,rep,name=args" json:"args,omitempty"`
+	Dir        *string                `protobuf:"bytes,7,opt,name=dir" json:"dir,omitempty"`
+	Tty        *TTY                   `protobuf:"bytes,8,opt,name=tty" json:"tty,omitempty"`
+	Labels     []*Property            `protobuf:"bytes,12,rep,name=labels" json:"labels,omitempty"`
+
+	DiscardStdout *bool `protobuf:"varint,13,opt,name=discard_stdout" json:"discard_stdout,omitempty"`
+	DiscardStderr *bool `protobuf:"varint,14,opt,name=discard_stderr" json:"discard_stderr,omitempty"`
+
+	PassedFiles []ProcessPayload_Source `protobuf:"varint,15,rep,name=passed_files,enum=garden.ProcessPayload_Source" json:"passed_files,omitempty"`
+}
+
+const Default_RunRequest_Privileged bool = false
+
+func (m *RunRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *RunRequest) GetPath() string {
+	if m != nil && m.Path != nil {
+		return *m.Path
+	}
+	return ""
+}
+
+func (m *RunRequest) GetPrivileged() bool {
+	if m != nil && m.Privileged != nil {
+		return *m.Privileged
+	}
+	return Default_RunRequest_Privileged
+}
+
+func (m *RunRequest) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+func (m *RunRequest) GetUid() uint32 {
+	if m != nil && m.Uid != nil {
+		return *m.Uid
+	}
+	return 0
+}
+
+func (m *RunRequest) GetGid() uint32 {
+	if m != nil && m.Gid != nil {
+		return *m.Gid
+	}
+	return 0
+}
+
+func (m *RunRequest) GetDiscardStdout() bool {
+	if m != nil && m.DiscardStdout != nil {
+		return *m.DiscardStdout
+	}
+	return false
+}
+
+func (m *RunRequest) GetDiscardStderr() bool {
+	if m != nil && m.DiscardStderr != nil {
+		return *m.DiscardStderr
+	}
+	return false
+}
+
+func (m *RunRequest) GetRlimits() *ResourceLimits {
+	if m != nil {
+		return m.Rlimits
+	}
+	return nil
+}
+
+func (m *RunRequest) GetEnv() []*EnvironmentVariable {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
+
+func (m *RunRequest) GetArgs() []string {
+	if m != nil {
+		return m.Args
+	}
+	return nil
+}
+
+func (m *RunRequest) GetDir() string {
+	if m != nil && m.Dir != nil {
+		return *m.Dir
+	}
+	return ""
+}
+
+func (m *RunRequest) GetTty() *TTY {
+	if m != nil {
+		return m.Tty
+	}
+	return nil
+}
+
+func (m *RunRequest) GetLabels() []*Property {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *RunRequest) GetPassedFiles() []ProcessPayload_Source {
+	if m != nil {
+		return m.PassedFiles
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package apitypes
+
+type ScheduleDestroyRequest struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	At     *int64  `protobuf:"varint,2,opt,name=at" json:"at,omitempty"`
+	After  *uint32 `protobuf:"varint,3,opt,name=after" json:"after,omitempty"`
+}
+
+func (m *ScheduleDestroyRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *ScheduleDestroyRequest) GetAt() int64 {
+	if m != nil && m.At != nil {
+		return *m.At
+	}
+	return 0
+}
+
+func (m *ScheduleDestroyRequest) GetAfter() uint32 {
+	if m != nil && m.After != nil {
+		return *m.After
+	}
+	return 0
+}
+
+type ScheduleDestroyResponse struct {
+}
+
+type CancelScheduledDestroyResponse struct {
+}
@@ -0,0 +1,23 @@
+package apitypes
+
+type SetPropertiesRequest struct {
+	Handle     *string     `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Properties []*Property `protobuf:"bytes,2,rep,name=properties" json:"properties,omitempty"`
+}
+
+func (m *SetPropertiesRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *SetPropertiesRequest) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+type SetPropertiesResponse struct {
+}
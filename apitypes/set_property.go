@@ -0,0 +1,31 @@
+package apitypes
+
+type SetPropertyRequest struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Key    *string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	Value  *string `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *SetPropertyRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *SetPropertyRequest) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *SetPropertyRequest) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+type SetPropertyResponse struct {
+}
@@ -0,0 +1,71 @@
+package apitypes
+
+type SignalAllRequest_Signal int32
+
+const (
+	SignalAllRequest_TERM SignalAllRequest_Signal = 0
+	SignalAllRequest_KILL SignalAllRequest_Signal = 1
+	SignalAllRequest_HUP  SignalAllRequest_Signal = 2
+	SignalAllRequest_INT  SignalAllRequest_Signal = 3
+	SignalAllRequest_QUIT SignalAllRequest_Signal = 4
+	SignalAllRequest_USR1 SignalAllRequest_Signal = 5
+	SignalAllRequest_USR2 SignalAllRequest_Signal = 6
+)
+
+var SignalAllRequest_Signal_name = map[int32]string{
+	0: "TERM",
+	1: "KILL",
+	2: "HUP",
+	3: "INT",
+	4: "QUIT",
+	5: "USR1",
+	6: "USR2",
+}
+var SignalAllRequest_Signal_value = map[string]int32{
+	"TERM": 0,
+	"KILL": 1,
+	"HUP":  2,
+	"INT":  3,
+	"QUIT": 4,
+	"USR1": 5,
+	"USR2": 6,
+}
+
+func (x SignalAllRequest_Signal) Enum() *SignalAllRequest_Signal {
+	p := new(SignalAllRequest_Signal)
+	*p = x
+	return p
+}
+func (x SignalAllRequest_Signal) String() string {
+	return enumName(SignalAllRequest_Signal_name, int32(x))
+}
+func (x *SignalAllRequest_Signal) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalJSONEnum(SignalAllRequest_Signal_value, data, "SignalAllRequest_Signal")
+	if err != nil {
+		return err
+	}
+	*x = SignalAllRequest_Signal(value)
+	return nil
+}
+
+type SignalAllRequest struct {
+	Handle *string                  `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Signal *SignalAllRequest_Signal `protobuf:"varint,2,opt,name=signal,enum=garden.SignalAllRequest_Signal" json:"signal,omitempty"`
+}
+
+func (m *SignalAllRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *SignalAllRequest) GetSignal() SignalAllRequest_Signal {
+	if m != nil && m.Signal != nil {
+		return *m.Signal
+	}
+	return SignalAllRequest_TERM
+}
+
+type SignalAllResponse struct {
+}
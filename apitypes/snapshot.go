@@ -0,0 +1,12 @@
+package apitypes
+
+type RestoreResponse struct {
+	Handle *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+}
+
+func (m *RestoreResponse) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
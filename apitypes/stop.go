@@ -0,0 +1,33 @@
+package apitypes
+
+type StopRequest struct {
+	Handle *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	Kill   *bool   `protobuf:"varint,20,opt,name=kill,def=0" json:"kill,omitempty"`
+	Reason *string `protobuf:"bytes,21,opt,name=reason" json:"reason,omitempty"`
+}
+
+const Default_StopRequest_Kill bool = false
+
+func (m *StopRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *StopRequest) GetKill() bool {
+	if m != nil && m.Kill != nil {
+		return *m.Kill
+	}
+	return Default_StopRequest_Kill
+}
+
+func (m *StopRequest) GetReason() string {
+	if m != nil && m.Reason != nil {
+		return *m.Reason
+	}
+	return ""
+}
+
+type StopResponse struct {
+}
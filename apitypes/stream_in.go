@@ -0,0 +1,23 @@
+package apitypes
+
+type StreamInRequest struct {
+	Handle  *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	DstPath *string `protobuf:"bytes,2,req,name=dst_path" json:"dst_path,omitempty"`
+}
+
+func (m *StreamInRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *StreamInRequest) GetDstPath() string {
+	if m != nil && m.DstPath != nil {
+		return *m.DstPath
+	}
+	return ""
+}
+
+type StreamInResponse struct {
+}
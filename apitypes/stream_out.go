@@ -0,0 +1,23 @@
+package apitypes
+
+type StreamOutRequest struct {
+	Handle  *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	SrcPath *string `protobuf:"bytes,2,req,name=src_path" json:"src_path,omitempty"`
+}
+
+func (m *StreamOutRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *StreamOutRequest) GetSrcPath() string {
+	if m != nil && m.SrcPath != nil {
+		return *m.SrcPath
+	}
+	return ""
+}
+
+type StreamOutResponse struct {
+}
@@ -0,0 +1,31 @@
+package apitypes
+
+type TTY struct {
+	WindowSize *TTY_WindowSize `protobuf:"bytes,1,opt,name=window_size" json:"window_size,omitempty"`
+}
+
+func (m *TTY) GetWindowSize() *TTY_WindowSize {
+	if m != nil {
+		return m.WindowSize
+	}
+	return nil
+}
+
+type TTY_WindowSize struct {
+	Columns *uint32 `protobuf:"varint,1,req,name=columns" json:"columns,omitempty"`
+	Rows    *uint32 `protobuf:"varint,2,req,name=rows" json:"rows,omitempty"`
+}
+
+func (m *TTY_WindowSize) GetColumns() uint32 {
+	if m != nil && m.Columns != nil {
+		return *m.Columns
+	}
+	return 0
+}
+
+func (m *TTY_WindowSize) GetRows() uint32 {
+	if m != nil && m.Rows != nil {
+		return *m.Rows
+	}
+	return 0
+}
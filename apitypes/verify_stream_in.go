@@ -0,0 +1,47 @@
+package apitypes
+
+type VerifyStreamInResponse struct {
+	Entries  *uint64                           `protobuf:"varint,1,opt,name=entries" json:"entries,omitempty"`
+	Bytes    *uint64                           `protobuf:"varint,2,opt,name=bytes" json:"bytes,omitempty"`
+	Problems []*VerifyStreamInResponse_Problem `protobuf:"bytes,3,rep,name=problems" json:"problems,omitempty"`
+}
+
+func (m *VerifyStreamInResponse) GetEntries() uint64 {
+	if m != nil && m.Entries != nil {
+		return *m.Entries
+	}
+	return 0
+}
+
+func (m *VerifyStreamInResponse) GetBytes() uint64 {
+	if m != nil && m.Bytes != nil {
+		return *m.Bytes
+	}
+	return 0
+}
+
+func (m *VerifyStreamInResponse) GetProblems() []*VerifyStreamInResponse_Problem {
+	if m != nil {
+		return m.Problems
+	}
+	return nil
+}
+
+type VerifyStreamInResponse_Problem struct {
+	Path   *string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Reason *string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *VerifyStreamInResponse_Problem) GetPath() string {
+	if m != nil && m.Path != nil {
+		return *m.Path
+	}
+	return ""
+}
+
+func (m *VerifyStreamInResponse_Problem) GetReason() string {
+	if m != nil && m.Reason != nil {
+		return *m.Reason
+	}
+	return ""
+}
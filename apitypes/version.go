@@ -0,0 +1,20 @@
+package apitypes
+
+type VersionResponse struct {
+	ProtocolVersion *uint32  `protobuf:"varint,1,opt,name=protocol_version" json:"protocol_version,omitempty"`
+	Capabilities    []string `protobuf:"bytes,2,rep,name=capabilities" json:"capabilities,omitempty"`
+}
+
+func (m *VersionResponse) GetProtocolVersion() uint32 {
+	if m != nil && m.ProtocolVersion != nil {
+		return *m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *VersionResponse) GetCapabilities() []string {
+	if m != nil {
+		return m.Capabilities
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package client
 
 import (
-	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden/api"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
@@ -9,9 +11,97 @@ import (
 
 type Client interface {
 	api.Client
+
+	// Rename changes a container's handle, keeping the container itself. It
+	// fails with api.ErrUnsupportedOperation if the server's backend cannot
+	// rename containers.
+	Rename(oldHandle, newHandle string) error
+
+	// LookupBy returns the single container whose properties match the
+	// filter, failing with api.ErrContainerNotFound if none do, or
+	// api.ErrMultipleContainersMatch if several do.
+	LookupBy(filter api.Properties) (api.Container, error)
+
+	// Snapshot streams an opaque snapshot of the container's state, which
+	// Restore recreates the container from. Both fail with
+	// api.ErrUnsupportedOperation if the server's backend cannot snapshot
+	// containers.
+	Snapshot(handle string) (io.ReadCloser, error)
+	Restore(snapshot io.Reader) (api.Container, error)
+
+	// ContainersVerbose is like Containers, but has the server include each
+	// container's state and properties in the listing, which the listed
+	// containers answer Properties from rather than asking again. The server
+	// gets every listed container's info to do so.
+	ContainersVerbose(properties api.Properties) ([]api.Container, error)
+
+	// ContainersInOrder is like ContainersVerbose, but lists the containers
+	// in the given order. Listings in the same order are stable, so that
+	// clients paging through them see no duplicates or gaps from reordering.
+	ContainersInOrder(properties api.Properties, order api.ListOrder) ([]api.Container, error)
+
+	// EachContainer is like Containers, but calls each with every container
+	// as the server streams the listing, in handle order, rather than
+	// holding all of them, e.g. for hosts with tens of thousands. It stops at
+	// the first error each returns, returning it.
+	EachContainer(properties api.Properties, each func(api.Container) error) error
+
+	// ContainersWhere is like Containers, but lists the containers matching
+	// every filter, which may compare properties other than exactly.
+	ContainersWhere(filters []api.PropertyFilter) ([]api.Container, error)
+
+	// HealthCheck reports the server's health. Unlike Ping, it succeeds
+	// while the backend is unhealthy, describing why.
+	HealthCheck() (api.HealthStatus, error)
+
+	// FreshCapacity is like Capacity, but bypasses any capacity the server
+	// has cached.
+	FreshCapacity() (api.Capacity, error)
+
+	// ForceDestroy is like Destroy, but kills the container's processes
+	// first, rather than waiting for them to stop.
+	ForceDestroy(handle string) error
+
+	// DestroyWithReason is like Destroy, or ForceDestroy if force is set,
+	// but explains why the container was destroyed. Servers which predate
+	// reasons ignore them.
+	DestroyWithReason(handle string, force bool, reason string) error
+
+	// DestroyAsync starts destroying the container and returns at once, with
+	// the id of the destroy to pass to DestroyOperation. If the container is
+	// already being destroyed, it returns that destroy's id. force is as for
+	// ForceDestroy.
+	DestroyAsync(handle string, force bool) (string, error)
+
+	// DestroyOperation returns the state of a destroy, which fails with
+	// api.ErrOperationNotFound once the server has forgotten it.
+	DestroyOperation(id string) (api.DestroyOperation, error)
+
+	// WaitForDestroy waits until the container no longer exists, e.g. after
+	// Destroy fails with api.ErrDestroyInProgress because another client is
+	// destroying it. If that destroy fails, the container remains, and
+	// WaitForDestroy waits on, failing with a DestroyTimeoutError once the
+	// timeout has passed. A timeout of 0 waits for as long as it takes.
+	WaitForDestroy(handle string, timeout time.Duration) error
+
+	// BulkProperties returns the properties with the keys, or all of them if
+	// no keys are given, of each of the containers, by handle, in one
+	// request rather than one per container. Containers which do not exist
+	// are left out.
+	BulkProperties(handles []string, keys []string) (map[string]api.Properties, error)
+
+	// Reconnect resumes the streams of a process in the container after
+	// those received so far, with the spec its connection.Reconnectable
+	// gives, e.g. after losing them to the server restarting.
+	Reconnect(handle string, processID uint32, spec api.ReconnectSpec, io api.ProcessIO) (api.Process, error)
 }
 
-var ErrContainerNotFound = errors.New("container not found")
+// DestroyPollInterval is how often WaitForDestroy asks the server whether the
+// container still exists.
+var DestroyPollInterval = 100 * time.Millisecond
+
+// ErrContainerNotFound is api.ErrContainerNotFound, kept for existing callers.
+var ErrContainerNotFound = api.ErrContainerNotFound
 
 type client struct {
 	connection connection.Connection
@@ -23,21 +113,83 @@ func New(connection connection.Connection) Client {
 	}
 }
 
+// IncompatibleVersionError is returned by NewStrict when the server speaks a
+// different version of the protocol to the client.
+type IncompatibleVersionError struct {
+	ServerVersion uint32
+}
+
+func (e IncompatibleVersionError) Error() string {
+	return fmt.Sprintf(
+		"incompatible server: it speaks protocol version %d, but this client speaks version %d",
+		e.ServerVersion,
+		api.ProtocolVersion,
+	)
+}
+
+// DestroyTimeoutError is returned by WaitForDestroy when the container still
+// exists once the timeout has passed.
+type DestroyTimeoutError struct {
+	Handle  string
+	Timeout time.Duration
+}
+
+func (e DestroyTimeoutError) Error() string {
+	return fmt.Sprintf("container %s still exists after %s", e.Handle, e.Timeout)
+}
+
+// NewStrict is like New, but first checks that the server speaks the same
+// version of the protocol, rather than failing later on requests it does not
+// understand.
+func NewStrict(connection connection.Connection) (Client, error) {
+	serverVersion, err := connection.ProtocolVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if serverVersion != api.ProtocolVersion {
+		return nil, IncompatibleVersionError{ServerVersion: serverVersion}
+	}
+
+	return New(connection), nil
+}
+
+// NewNegotiated is like New, but first negotiates the capabilities the server
+// supports, so that calls it would not understand fail with
+// api.ErrUnsupportedOperation without being sent. Unlike NewStrict, it accepts
+// servers of any protocol version, for fleets being upgraded.
+func NewNegotiated(conn connection.Connection) (Client, error) {
+	negotiated, err := connection.NewNegotiated(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(negotiated), nil
+}
+
 func (client *client) Ping() error {
 	return client.connection.Ping()
 }
 
+func (client *client) HealthCheck() (api.HealthStatus, error) {
+	return client.connection.HealthCheck()
+}
+
 func (client *client) Capacity() (api.Capacity, error) {
 	return client.connection.Capacity()
 }
 
+func (client *client) FreshCapacity() (api.Capacity, error) {
+	return client.connection.FreshCapacity()
+}
+
 func (client *client) Create(spec api.ContainerSpec) (api.Container, error) {
-	handle, err := client.connection.Create(spec)
+	handle, info, err := client.connection.Create(spec)
 	if err != nil {
 		return nil, err
 	}
 
-	return newContainer(handle, client.connection), nil
+	return newCreatedContainer(handle, info, client.connection), nil
 }
 
 func (client *client) Containers(properties api.Properties) ([]api.Container, error) {
@@ -54,10 +206,106 @@ func (client *client) Containers(properties api.Properties) ([]api.Container, er
 	return containers, nil
 }
 
+func (client *client) ContainersVerbose(properties api.Properties) ([]api.Container, error) {
+	return client.ContainersInOrder(properties, "")
+}
+
+func (client *client) ContainersInOrder(properties api.Properties, order api.ListOrder) ([]api.Container, error) {
+	summaries, err := client.connection.ListVerbose(properties, order)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := []api.Container{}
+	for _, summary := range summaries {
+		containers = append(containers, newListedContainer(summary, client.connection))
+	}
+
+	return containers, nil
+}
+
+func (client *client) ContainersWhere(filters []api.PropertyFilter) ([]api.Container, error) {
+	handles, err := client.connection.ListWhere(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := []api.Container{}
+	for _, handle := range handles {
+		containers = append(containers, newContainer(handle, client.connection))
+	}
+
+	return containers, nil
+}
+
+func (client *client) EachContainer(properties api.Properties, each func(api.Container) error) error {
+	return client.connection.ListEach(properties, func(handle string) error {
+		return each(newContainer(handle, client.connection))
+	})
+}
+
 func (client *client) Destroy(handle string) error {
 	return client.connection.Destroy(handle)
 }
 
+func (client *client) ForceDestroy(handle string) error {
+	return client.connection.ForceDestroy(handle)
+}
+
+func (client *client) DestroyWithReason(handle string, force bool, reason string) error {
+	return client.connection.DestroyWithReason(handle, force, reason)
+}
+
+func (client *client) DestroyAsync(handle string, force bool) (string, error) {
+	return client.connection.DestroyAsync(handle, force)
+}
+
+func (client *client) DestroyOperation(id string) (api.DestroyOperation, error) {
+	return client.connection.DestroyOperation(id)
+}
+
+func (client *client) WaitForDestroy(handle string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		_, err := client.connection.InfoFields(handle, []api.InfoField{api.InfoFieldState})
+		if err == api.ErrContainerNotFound {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		wait := DestroyPollInterval
+
+		if timeout != 0 {
+			remaining := deadline.Sub(time.Now())
+			if remaining <= 0 {
+				return DestroyTimeoutError{Handle: handle, Timeout: timeout}
+			}
+
+			if remaining < wait {
+				wait = remaining
+			}
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+func (client *client) BulkProperties(handles []string, keys []string) (map[string]api.Properties, error) {
+	return client.connection.BulkProperties(handles, keys)
+}
+
+func (client *client) Reconnect(handle string, processID uint32, spec api.ReconnectSpec, io api.ProcessIO) (api.Process, error) {
+	return client.connection.Reconnect(handle, processID, spec, io)
+}
+
+func (client *client) Rename(oldHandle, newHandle string) error {
+	return client.connection.Rename(oldHandle, newHandle)
+}
+
 func (client *client) Lookup(handle string) (api.Container, error) {
 	handles, err := client.connection.List(nil)
 	if err != nil {
@@ -72,3 +320,25 @@ func (client *client) Lookup(handle string) (api.Container, error) {
 
 	return nil, ErrContainerNotFound
 }
+
+func (client *client) LookupBy(filter api.Properties) (api.Container, error) {
+	handle, err := client.connection.LookupBy(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return newContainer(handle, client.connection), nil
+}
+
+func (client *client) Snapshot(handle string) (io.ReadCloser, error) {
+	return client.connection.Snapshot(handle)
+}
+
+func (client *client) Restore(snapshot io.Reader) (api.Container, error) {
+	handle, err := client.connection.Restore(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return newContainer(handle, client.connection), nil
+}
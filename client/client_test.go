@@ -2,6 +2,10 @@ package client_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -24,6 +28,108 @@ var _ = Describe("Client", func() {
 		client = New(fakeConnection)
 	})
 
+	Describe("NewStrict", func() {
+		Context("when the server speaks the client's protocol version", func() {
+			BeforeEach(func() {
+				fakeConnection.ProtocolVersionReturns(api.ProtocolVersion, nil)
+			})
+
+			It("returns a client", func() {
+				strictClient, err := NewStrict(fakeConnection)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(strictClient).ShouldNot(BeNil())
+			})
+		})
+
+		Context("when the server speaks another protocol version", func() {
+			BeforeEach(func() {
+				fakeConnection.ProtocolVersionReturns(0, nil)
+			})
+
+			It("returns an IncompatibleVersionError", func() {
+				_, err := NewStrict(fakeConnection)
+				Ω(err).Should(Equal(IncompatibleVersionError{ServerVersion: 0}))
+				Ω(err.Error()).Should(ContainSubstring("protocol version 0"))
+			})
+		})
+
+		Context("when asking for the version fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ProtocolVersionReturns(0, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := NewStrict(fakeConnection)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("NewNegotiated", func() {
+		Context("when the server lacks a capability", func() {
+			BeforeEach(func() {
+				fakeConnection.ServerVersionReturns(api.ServerVersion{}, nil)
+			})
+
+			It("returns a client which fails calls needing it", func() {
+				negotiatedClient, err := NewNegotiated(fakeConnection)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = negotiatedClient.Rename("some-handle", "new-handle")
+				Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+				Ω(fakeConnection.RenameCallCount()).Should(BeZero())
+			})
+		})
+
+		Context("when asking for the version fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ServerVersionReturns(api.ServerVersion{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := NewNegotiated(fakeConnection)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("HealthCheck", func() {
+		BeforeEach(func() {
+			fakeConnection.HealthCheckReturns(api.HealthStatus{
+				Healthy:    true,
+				Containers: 3,
+			}, nil)
+		})
+
+		It("returns the server's health", func() {
+			status, err := client.HealthCheck()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(status).Should(Equal(api.HealthStatus{
+				Healthy:    true,
+				Containers: 3,
+			}))
+		})
+
+		Context("when checking the server's health fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.HealthCheckReturns(api.HealthStatus{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := client.HealthCheck()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Capacity", func() {
 		BeforeEach(func() {
 			fakeConnection.CapacityReturns(
@@ -57,13 +163,28 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("FreshCapacity", func() {
+		BeforeEach(func() {
+			fakeConnection.FreshCapacityReturns(api.Capacity{MaxContainers: 42}, nil)
+		})
+
+		It("asks the connection for capacity bypassing the server's cache", func() {
+			capacity, err := client.FreshCapacity()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity.MaxContainers).Should(Equal(uint64(42)))
+
+			Ω(fakeConnection.FreshCapacityCallCount()).Should(Equal(1))
+			Ω(fakeConnection.CapacityCallCount()).Should(BeZero())
+		})
+	})
+
 	Describe("Create", func() {
 		It("sends a create request and returns a container", func() {
 			spec := api.ContainerSpec{
 				RootFSPath: "/some/roofs",
 			}
 
-			fakeConnection.CreateReturns("some-handle", nil)
+			fakeConnection.CreateReturns("some-handle", nil, nil)
 
 			container, err := client.Create(spec)
 			Ω(err).ShouldNot(HaveOccurred())
@@ -78,7 +199,7 @@ var _ = Describe("Client", func() {
 			disaster := errors.New("oh no!")
 
 			BeforeEach(func() {
-				fakeConnection.CreateReturns("", disaster)
+				fakeConnection.CreateReturns("", nil, disaster)
 			})
 
 			It("returns it", func() {
@@ -98,6 +219,7 @@ var _ = Describe("Client", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 
 			Ω(fakeConnection.ListArgsForCall(0)).Should(Equal(props))
+			Ω(fakeConnection.ListVerboseCallCount()).Should(BeZero())
 
 			Ω(containers).Should(HaveLen(2))
 			Ω(containers[0].Handle()).Should(Equal("handle-a"))
@@ -118,6 +240,169 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("ContainersVerbose", func() {
+		It("sends a verbose list request and returns all containers", func() {
+			fakeConnection.ListVerboseReturns([]api.ContainerSummary{
+				{Handle: "handle-a"},
+				{Handle: "handle-b"},
+			}, nil)
+
+			props := api.Properties{"foo": "bar"}
+
+			containers, err := client.ContainersVerbose(props)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			listedProps, order := fakeConnection.ListVerboseArgsForCall(0)
+			Ω(listedProps).Should(Equal(props))
+			Ω(order).Should(BeEmpty())
+
+			Ω(containers).Should(HaveLen(2))
+			Ω(containers[0].Handle()).Should(Equal("handle-a"))
+			Ω(containers[1].Handle()).Should(Equal("handle-b"))
+		})
+
+		It("lists them in the order asked for", func() {
+			_, err := client.ContainersInOrder(nil, api.ListOrderCreated)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, order := fakeConnection.ListVerboseArgsForCall(0)
+			Ω(order).Should(Equal(api.ListOrderCreated))
+		})
+
+		It("returns containers whose properties come from the listing", func() {
+			fakeConnection.ListVerboseReturns([]api.ContainerSummary{
+				{Handle: "handle-a", Properties: api.Properties{"foo": "bar"}},
+			}, nil)
+
+			containers, err := client.ContainersVerbose(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			properties, err := containers[0].Properties()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(properties).Should(Equal(api.Properties{"foo": "bar"}))
+
+			Ω(fakeConnection.InfoCallCount()).Should(BeZero())
+		})
+
+		It("returns containers which fetch their properties again when refreshed", func() {
+			fakeConnection.ListVerboseReturns([]api.ContainerSummary{
+				{Handle: "handle-a", Properties: api.Properties{"foo": "bar"}},
+			}, nil)
+
+			fakeConnection.InfoReturns(api.ContainerInfo{
+				Properties: api.Properties{"foo": "baz"},
+			}, nil)
+
+			containers, err := client.ContainersVerbose(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = containers[0].(Container).Refresh()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			properties, err := containers[0].Properties()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(properties).Should(Equal(api.Properties{"foo": "baz"}))
+		})
+
+		Context("when the listing does not include properties", func() {
+			BeforeEach(func() {
+				fakeConnection.ListVerboseReturns([]api.ContainerSummary{
+					{Handle: "handle-a"},
+				}, nil)
+
+				fakeConnection.InfoReturns(api.ContainerInfo{
+					Properties: api.Properties{"foo": "bar"},
+				}, nil)
+			})
+
+			It("fetches them when they are asked for", func() {
+				containers, err := client.ContainersVerbose(nil)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeConnection.InfoCallCount()).Should(BeZero())
+
+				properties, err := containers[0].Properties()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(properties).Should(Equal(api.Properties{"foo": "bar"}))
+
+				Ω(fakeConnection.InfoArgsForCall(0)).Should(Equal("handle-a"))
+			})
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ListVerboseReturns(nil, disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.ContainersVerbose(nil)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("ContainersWhere", func() {
+		It("lists the containers matching the filters", func() {
+			filters := []api.PropertyFilter{
+				{Name: "owner", Operator: api.PropertyPrefix, Value: "team-"},
+			}
+
+			fakeConnection.ListWhereReturns([]string{"handle-a", "handle-b"}, nil)
+
+			containers, err := client.ContainersWhere(filters)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ListWhereArgsForCall(0)).Should(Equal(filters))
+
+			Ω(containers).Should(HaveLen(2))
+			Ω(containers[0].Handle()).Should(Equal("handle-a"))
+			Ω(containers[1].Handle()).Should(Equal("handle-b"))
+		})
+	})
+
+	Describe("EachContainer", func() {
+		BeforeEach(func() {
+			fakeConnection.ListEachStub = func(properties api.Properties, each func(string) error) error {
+				for _, handle := range []string{"handle-a", "handle-b"} {
+					err := each(handle)
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			}
+		})
+
+		It("streams the listing, calling back with each container", func() {
+			props := api.Properties{"foo": "bar"}
+
+			handles := []string{}
+
+			err := client.EachContainer(props, func(container api.Container) error {
+				handles = append(handles, container.Handle())
+				return nil
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			listedProps, _ := fakeConnection.ListEachArgsForCall(0)
+			Ω(listedProps).Should(Equal(props))
+
+			Ω(handles).Should(Equal([]string{"handle-a", "handle-b"}))
+		})
+
+		It("returns the callback's error", func() {
+			disaster := errors.New("oh no!")
+
+			err := client.EachContainer(nil, func(api.Container) error {
+				return disaster
+			})
+			Ω(err).Should(Equal(disaster))
+		})
+	})
+
 	Describe("Destroy", func() {
 		It("sends a destroy request", func() {
 			err := client.Destroy("some-handle")
@@ -140,6 +425,222 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("ForceDestroy", func() {
+		It("sends a forced destroy request", func() {
+			err := client.ForceDestroy("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ForceDestroyArgsForCall(0)).Should(Equal("some-handle"))
+		})
+	})
+
+	Describe("DestroyWithReason", func() {
+		It("sends a destroy request with the reason", func() {
+			err := client.DestroyWithReason("some-handle", true, "tenant deleted")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, force, reason := fakeConnection.DestroyWithReasonArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(force).Should(BeTrue())
+			Ω(reason).Should(Equal("tenant deleted"))
+		})
+	})
+
+	Describe("DestroyAsync", func() {
+		It("returns the id of the destroy", func() {
+			fakeConnection.DestroyAsyncReturns("some-operation", nil)
+
+			id, err := client.DestroyAsync("some-handle", true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(id).Should(Equal("some-operation"))
+
+			handle, force := fakeConnection.DestroyAsyncArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(force).Should(BeTrue())
+		})
+	})
+
+	Describe("DestroyOperation", func() {
+		It("returns the destroy", func() {
+			operation := api.DestroyOperation{
+				ID:     "some-operation",
+				Handle: "some-handle",
+				State:  api.DestroyStateDestroyed,
+			}
+
+			fakeConnection.DestroyOperationReturns(operation, nil)
+
+			Ω(client.DestroyOperation("some-operation")).Should(Equal(operation))
+
+			Ω(fakeConnection.DestroyOperationArgsForCall(0)).Should(Equal("some-operation"))
+		})
+	})
+
+	Describe("WaitForDestroy", func() {
+		BeforeEach(func() {
+			DestroyPollInterval = time.Millisecond
+		})
+
+		Context("once the container is gone", func() {
+			BeforeEach(func() {
+				fakeConnection.InfoFieldsStub = func(handle string, fields []api.InfoField) (api.ContainerInfo, error) {
+					if fakeConnection.InfoFieldsCallCount() < 3 {
+						return api.ContainerInfo{State: "active"}, nil
+					}
+
+					return api.ContainerInfo{}, api.ErrContainerNotFound
+				}
+			})
+
+			It("returns", func() {
+				err := client.WaitForDestroy("some-handle", time.Minute)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeConnection.InfoFieldsCallCount()).Should(Equal(3))
+
+				handle, fields := fakeConnection.InfoFieldsArgsForCall(0)
+				Ω(handle).Should(Equal("some-handle"))
+				Ω(fields).Should(Equal([]api.InfoField{api.InfoFieldState}))
+			})
+		})
+
+		Context("when asking for the container fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.InfoFieldsReturns(api.ContainerInfo{}, disaster)
+			})
+
+			It("returns the error", func() {
+				err := client.WaitForDestroy("some-handle", time.Minute)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+
+		Context("when the container outlasts the timeout", func() {
+			BeforeEach(func() {
+				fakeConnection.InfoFieldsReturns(api.ContainerInfo{State: "active"}, nil)
+			})
+
+			It("fails with a DestroyTimeoutError", func() {
+				before := time.Now()
+
+				err := client.WaitForDestroy("some-handle", 100*time.Millisecond)
+				Ω(err).Should(Equal(DestroyTimeoutError{
+					Handle:  "some-handle",
+					Timeout: 100 * time.Millisecond,
+				}))
+
+				Ω(time.Since(before)).Should(BeNumerically("~", 100*time.Millisecond, 50*time.Millisecond))
+			})
+
+			Context("when the timeout is 0", func() {
+				It("waits on", func() {
+					var gone int32
+
+					fakeConnection.InfoFieldsStub = func(string, []api.InfoField) (api.ContainerInfo, error) {
+						if atomic.LoadInt32(&gone) == 1 {
+							return api.ContainerInfo{}, api.ErrContainerNotFound
+						}
+
+						return api.ContainerInfo{State: "active"}, nil
+					}
+
+					errs := make(chan error, 1)
+
+					go func() {
+						errs <- client.WaitForDestroy("some-handle", 0)
+					}()
+
+					Consistently(errs, 200*time.Millisecond).ShouldNot(Receive())
+
+					atomic.StoreInt32(&gone, 1)
+
+					Eventually(errs).Should(Receive(BeNil()))
+				})
+			})
+		})
+	})
+
+	Describe("Rename", func() {
+		It("sends a rename request", func() {
+			err := client.Rename("some-handle", "new-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			oldHandle, newHandle := fakeConnection.RenameArgsForCall(0)
+			Ω(oldHandle).Should(Equal("some-handle"))
+			Ω(newHandle).Should(Equal("new-handle"))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.RenameReturns(disaster)
+			})
+
+			It("returns it", func() {
+				err := client.Rename("some-handle", "new-handle")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Snapshot", func() {
+		It("sends a snapshot request", func() {
+			fakeConnection.SnapshotReturns(ioutil.NopCloser(strings.NewReader("some-snapshot")), nil)
+
+			reader, err := client.Snapshot("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(ioutil.ReadAll(reader)).Should(Equal([]byte("some-snapshot")))
+
+			Ω(fakeConnection.SnapshotArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.SnapshotReturns(nil, disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.Snapshot("some-handle")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		It("sends a restore request and returns the restored container", func() {
+			fakeConnection.RestoreReturns("restored-handle", nil)
+
+			snapshot := strings.NewReader("some-snapshot")
+
+			container, err := client.Restore(snapshot)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.Handle()).Should(Equal("restored-handle"))
+
+			Ω(fakeConnection.RestoreArgsForCall(0)).Should(Equal(snapshot))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.RestoreReturns("", disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.Restore(strings.NewReader("some-snapshot"))
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Lookup", func() {
 		It("sends a list request", func() {
 			fakeConnection.ListReturns([]string{"some-handle", "some-other-handle"}, nil)
@@ -174,4 +675,30 @@ var _ = Describe("Client", func() {
 			})
 		})
 	})
+
+	Describe("LookupBy", func() {
+		It("sends a lookup request", func() {
+			fakeConnection.LookupByReturns("some-handle", nil)
+
+			container, err := client.LookupBy(api.Properties{"foo": "bar"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.Handle()).Should(Equal("some-handle"))
+
+			Ω(fakeConnection.LookupByArgsForCall(0)).Should(Equal(api.Properties{"foo": "bar"}))
+		})
+
+		Context("when the lookup fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.LookupByReturns("", disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.LookupBy(api.Properties{"foo": "bar"})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
 })
@@ -0,0 +1,61 @@
+package connection
+
+import (
+	"path"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// validateBindMounts checks that bind mounts' paths are absolute and stay
+// within the directories they name, and that their mode and origin are
+// known, before the server is asked to create a container with them.
+func validateBindMounts(mounts []api.BindMount) error {
+	for _, mount := range mounts {
+		err := validateMountPath(api.SpecFieldBindMountSrcPath, mount.SrcPath)
+		if err != nil {
+			return err
+		}
+
+		err = validateMountPath(api.SpecFieldBindMountDstPath, mount.DstPath)
+		if err != nil {
+			return err
+		}
+
+		if mount.Mode != api.BindMountModeRO && mount.Mode != api.BindMountModeRW {
+			return api.ValidationError{
+				Field:  api.SpecFieldBindMountMode,
+				Reason: "must be RO or RW",
+			}
+		}
+
+		if mount.Origin != api.BindMountOriginHost && mount.Origin != api.BindMountOriginContainer {
+			return api.ValidationError{
+				Field:  api.SpecFieldBindMountOrigin,
+				Reason: "must be Host or Container",
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateMountPath(field api.SpecField, mountPath string) error {
+	if !path.IsAbs(mountPath) {
+		return api.ValidationError{
+			Field:  field,
+			Reason: "must be an absolute path",
+		}
+	}
+
+	for _, element := range strings.Split(mountPath, "/") {
+		if element == ".." {
+			return api.ValidationError{
+				Field:  field,
+				Reason: "must not contain ..",
+			}
+		}
+	}
+
+	return nil
+}
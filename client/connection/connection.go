@@ -1,7 +1,6 @@
 package connection
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -12,35 +11,78 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden/api"
-	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
 	"github.com/cloudfoundry-incubator/garden/routes"
 	"github.com/cloudfoundry-incubator/garden/transport"
-	"github.com/gogo/protobuf/proto"
 	"github.com/tedsuo/rata"
 )
 
 var ErrDisconnected = errors.New("disconnected")
 var ErrInvalidMessage = errors.New("invalid message payload")
+var ErrWaitTimedOut = errors.New("timed out waiting for process to exit")
 
 type Connection interface {
 	Ping() error
 
+	// ProtocolVersion returns the version of the protocol the server speaks,
+	// or 0 for servers which predate the version endpoint.
+	ProtocolVersion() (uint32, error)
+	ServerVersion() (api.ServerVersion, error)
+	HealthCheck() (api.HealthStatus, error)
+
 	Capacity() (api.Capacity, error)
+	FreshCapacity() (api.Capacity, error)
 
-	Create(spec api.ContainerSpec) (string, error)
+	// Create returns the new container's handle and, if the server reports
+	// it, the container's info as of its creation.
+	Create(spec api.ContainerSpec) (string, *api.ContainerInfo, error)
 	List(properties api.Properties) ([]string, error)
+	ListWhere(filters []api.PropertyFilter) ([]string, error)
+	ListVerbose(properties api.Properties, order api.ListOrder) ([]api.ContainerSummary, error)
+
+	// ListEach calls each with the handle of every container matching the
+	// properties, in handle order, as the server streams them. It stops at
+	// the first error each returns, returning it.
+	ListEach(properties api.Properties, each func(handle string) error) error
+
+	LookupBy(properties api.Properties) (string, error)
 	Destroy(handle string) error
+	ForceDestroy(handle string) error
+	DestroyWithReason(handle string, force bool, reason string) error
+	DestroyAsync(handle string, force bool) (string, error)
+	DestroyOperation(id string) (api.DestroyOperation, error)
+	Rename(oldHandle, newHandle string) error
+
+	Snapshot(handle string) (io.ReadCloser, error)
+	Restore(snapshot io.Reader) (string, error)
 
 	Stop(handle string, kill bool) error
+	StopWithReason(handle string, kill bool, reason string) error
+	SignalAll(handle string, signal api.Signal) error
+	Pause(handle string) error
+	Resume(handle string) error
+
+	DestroyAt(handle string, at time.Time) error
+	DestroyAfter(handle string, delay time.Duration) error
+	CancelScheduledDestroy(handle string) error
+
+	SetHold(handle string, held bool) error
+	SetGraceTime(handle string, graceTime time.Duration) error
 
 	Info(handle string) (api.ContainerInfo, error)
+	InfoFields(handle string, fields []api.InfoField) (api.ContainerInfo, error)
 
-	StreamIn(handle string, dstPath string, reader io.Reader) error
-	StreamOut(handle string, srcPath string) (io.ReadCloser, error)
+	StreamIn(handle string, spec api.StreamInSpec) error
+	StreamOut(handle string, spec api.StreamOutSpec) (io.ReadCloser, error)
+	VerifyStreamIn(handle string, dstPath string, reader io.Reader) (api.StreamInReport, error)
 
 	LimitBandwidth(handle string, limits api.BandwidthLimits) (api.BandwidthLimits, error)
 	LimitCPU(handle string, limits api.CPULimits) (api.CPULimits, error)
@@ -50,17 +92,35 @@ type Connection interface {
 	CurrentBandwidthLimits(handle string) (api.BandwidthLimits, error)
 	CurrentCPULimits(handle string) (api.CPULimits, error)
 	CurrentDiskLimits(handle string) (api.DiskLimits, error)
+	DiskUsage(handle string) (api.ContainerDiskUsage, error)
+	Env(handle string) ([]string, error)
 	CurrentMemoryLimits(handle string) (api.MemoryLimits, error)
 
 	Run(handle string, spec api.ProcessSpec, io api.ProcessIO) (api.Process, error)
 	Attach(handle string, processID uint32, io api.ProcessIO) (api.Process, error)
+	Reconnect(handle string, processID uint32, spec api.ReconnectSpec, io api.ProcessIO) (api.Process, error)
+	AttachAll(handle string, io func(uint32) api.ProcessIO) ([]api.Process, error)
+	ProcessInfo(handle string, processID uint32) (api.ProcessInfo, error)
+	FindProcesses(handle string, labels map[string]string) ([]uint32, error)
+	WaitForProcess(handle string, matcher api.ProcessMatcher, timeout time.Duration) (uint32, error)
+	Logs(handle string, tail int, follow bool) (api.LogStream, error)
 
 	NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error)
+	NetInSpec(handle string, spec api.NetInSpec) (uint32, uint32, error)
+	MappedPorts(handle string) ([]api.PortMapping, error)
 	NetOut(handle string, network string, port uint32, portRange string, protocol api.Protocol) error
+	NetOutRule(handle string, rule api.NetOutRule) error
 
 	GetProperty(handle string, name string) (string, error)
 	SetProperty(handle string, name string, value string) error
+	SetProperties(handle string, properties api.Properties) error
+	CompareAndSwapProperty(handle string, name string, oldValue string, newValue string) (bool, error)
 	RemoveProperty(handle string, name string) error
+
+	// BulkProperties returns the properties with the keys, or all of them if
+	// no keys are given, of each of the containers, by handle. Containers
+	// which do not exist are left out.
+	BulkProperties(handles []string, keys []string) (map[string]api.Properties, error)
 }
 
 type connection struct {
@@ -68,52 +128,289 @@ type connection struct {
 
 	dialer func(string, string) (net.Conn, error)
 
+	header http.Header
+
+	codec transport.Codec
+
+	onWarning func(string)
+
+	confirmDestroys bool
+
+	// passFiles is whether files may be passed to the server, i.e. it is
+	// dialed directly over a unix socket
+	passFiles bool
+
+	hooks Hooks
+
 	httpClient        *http.Client
 	noKeepaliveClient *http.Client
+	streamClient      *http.Client
+}
+
+// Config tunes a Connection. Zero values take the defaults.
+type Config struct {
+	// DialTimeout bounds connecting to the server; it defaults to one second.
+	DialTimeout time.Duration
+
+	// RequestTimeout bounds each request, including reading its response.
+	// Zero means no timeout.
+	RequestTimeout time.Duration
+
+	// StreamTimeout bounds requests which stream files or snapshots in or out
+	// of a container, including reading their response. Zero means no
+	// timeout. Process streams are never timed out.
+	StreamTimeout time.Duration
+
+	// Header is sent with every request, e.g. an Authorization credential.
+	Header http.Header
+
+	// Codec encodes requests, e.g. transport.Protobuf for compact ones, and
+	// must be registered with the server's transport package too; it
+	// defaults to transport.JSON. The server is asked to encode responses
+	// with it as well, but they are decoded by the codec registered for their
+	// content type.
+	Codec transport.Codec
+
+	// Multiplex sends every request, including process streams, over a
+	// single connection to the server rather than dialing one for each.
+	// Servers which do not support it are dialed directly.
+	Multiplex bool
+
+	// OnWarning, if set, is called with each warning the server responds
+	// with, e.g. that it clamped a container's grace time to its maximum.
+	OnWarning func(warning string)
+
+	// Hooks are called around each request, e.g. to trace or time them.
+	Hooks Hooks
+
+	// ConfirmDestroys confirms each destroy, and each scheduled destroy, for
+	// servers which refuse those not confirmed, by naming the container in
+	// the transport.DestroyConfirmationHeader. Tools which are not meant to
+	// destroy containers should leave it unset.
+	ConfirmDestroys bool
 }
 
+const DefaultDialTimeout = time.Second
+
 type GardenError struct {
-	Message   string
-	Data      string
-	Backtrace []string
+	Message     string
+	Data        string
+	Backtrace   []string
+	Annotations map[string]string
+
+	// Class is how the server classified the error; it is empty for servers
+	// which predate classes, and api.ClassOf treats such errors as terminal.
+	Class api.ErrorClass
+
+	// TraceID is that of the trace the failed request was part of, as given
+	// in its transport.TraceHeader.
+	TraceID string
 }
 
 func (e *GardenError) Error() string {
 	return e.Message
 }
 
+func (e *GardenError) ErrorClass() api.ErrorClass {
+	return e.Class
+}
+
+var typedErrors = map[string]error{
+	apitypes.ErrorTypeContainerNotFound:       api.ErrContainerNotFound,
+	apitypes.ErrorTypeProcessNotFound:         api.ErrProcessNotFound,
+	apitypes.ErrorTypeUnsupportedOperation:    api.ErrUnsupportedOperation,
+	apitypes.ErrorTypeCapacityExceeded:        api.ErrCapacityExceeded,
+	apitypes.ErrorTypeUserNotFound:            api.ErrUserNotFound,
+	apitypes.ErrorTypeRateLimited:             api.ErrRateLimited,
+	apitypes.ErrorTypeProcessLimitExceeded:    api.ErrProcessLimitExceeded,
+	apitypes.ErrorTypeDestroyInProgress:       api.ErrDestroyInProgress,
+	apitypes.ErrorTypeOperationNotFound:       api.ErrOperationNotFound,
+	apitypes.ErrorTypePermissionDenied:        api.ErrPermissionDenied,
+	apitypes.ErrorTypeDestroyNotConfirmed:     api.ErrDestroyNotConfirmed,
+	apitypes.ErrorTypeMultipleContainersMatch: api.ErrMultipleContainersMatch,
+}
+
+// New returns a Connection to a server listening on the network and address,
+// e.g. "unix" and a socket path, or a network registered with the transport
+// package.
 func New(network, address string) Connection {
+	return NewWithHeader(network, address, nil)
+}
+
+// NewWithHeader returns a Connection that sends the given header, e.g. an
+// Authorization credential, with every request.
+func NewWithHeader(network, address string, header http.Header) Connection {
+	return NewWithConfig(network, address, Config{Header: header})
+}
+
+func NewWithConfig(network, address string, config Config) Connection {
+	dialTimeout := config.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = DefaultDialTimeout
+	}
+
+	req := rata.NewRequestGenerator("http://api", routes.Routes)
+
 	dialer := func(string, string) (net.Conn, error) {
-		return net.DialTimeout(network, address, time.Second)
+		return transport.Dial(network, address, dialTimeout)
+	}
+
+	if config.Multiplex {
+		dialer = (&muxDialer{
+			dial: func() (net.Conn, error) {
+				return transport.Dial(network, address, dialTimeout)
+			},
+			req:    req,
+			header: config.Header,
+		}).Dial
+	}
+
+	codec := config.Codec
+	if codec == nil {
+		codec = transport.JSON
 	}
 
 	return &connection{
-		req: rata.NewRequestGenerator("http://api", routes.Routes),
+		req: req,
 
 		dialer: dialer,
 
+		header: config.Header,
+
+		codec: codec,
+
+		onWarning: config.OnWarning,
+
+		confirmDestroys: config.ConfirmDestroys,
+
+		passFiles: network == "unix" && !config.Multiplex,
+
+		hooks: config.Hooks,
+
 		httpClient: &http.Client{
-			Transport: &http.Transport{
+			Transport: hookTransport(&http.Transport{
 				Dial: dialer,
-			},
+			}, config.Hooks),
 		},
 		noKeepaliveClient: &http.Client{
-			Transport: &http.Transport{
+			Transport: hookTransport(&http.Transport{
 				Dial:              dialer,
 				DisableKeepAlives: true,
-			},
+			}, config.Hooks),
+			Timeout: config.RequestTimeout,
+		},
+		streamClient: &http.Client{
+			Transport: hookTransport(&http.Transport{
+				Dial:              dialer,
+				DisableKeepAlives: true,
+			}, config.Hooks),
+			Timeout: config.StreamTimeout,
 		},
 	}
 }
 
 func (c *connection) Ping() error {
-	return c.do(routes.Ping, nil, &protocol.PingResponse{}, nil, nil)
+	return c.do(routes.Ping, nil, &apitypes.PingResponse{}, nil, nil)
+}
+
+func (c *connection) ProtocolVersion() (uint32, error) {
+	version, err := c.ServerVersion()
+	if err != nil {
+		return 0, err
+	}
+
+	return version.ProtocolVersion, nil
+}
+
+func (c *connection) ServerVersion() (api.ServerVersion, error) {
+	request, err := c.newRequest(routes.Version, nil, nil, nil, "")
+	if err != nil {
+		return api.ServerVersion{}, err
+	}
+
+	httpResp, err := c.noKeepaliveClient.Do(request)
+	if err != nil {
+		return api.ServerVersion{}, err
+	}
+
+	defer httpResp.Body.Close()
+
+	// the router of a server without the route answers 404
+	if httpResp.StatusCode == http.StatusNotFound {
+		return api.ServerVersion{}, nil
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return api.ServerVersion{}, responseError(httpResp)
+	}
+
+	var res apitypes.VersionResponse
+	err = c.responseCodec(httpResp).Decode(httpResp.Body, &res)
+	if err != nil {
+		return api.ServerVersion{}, err
+	}
+
+	var capabilities []api.Capability
+	for _, capability := range res.GetCapabilities() {
+		capabilities = append(capabilities, api.Capability(capability))
+	}
+
+	return api.ServerVersion{
+		ProtocolVersion: res.GetProtocolVersion(),
+		Capabilities:    capabilities,
+	}, nil
+}
+
+// HealthCheck returns the server's health, which is reported with a 503 when
+// its backend is unhealthy.
+func (c *connection) HealthCheck() (api.HealthStatus, error) {
+	request, err := c.newRequest(routes.HealthCheck, nil, nil, nil, "")
+	if err != nil {
+		return api.HealthStatus{}, err
+	}
+
+	httpResp, err := c.noKeepaliveClient.Do(request)
+	if err != nil {
+		return api.HealthStatus{}, err
+	}
+
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK && httpResp.StatusCode != http.StatusServiceUnavailable {
+		return api.HealthStatus{}, responseError(httpResp)
+	}
+
+	var res apitypes.HealthCheckResponse
+	err = c.responseCodec(httpResp).Decode(httpResp.Body, &res)
+	if err != nil {
+		return api.HealthStatus{}, err
+	}
+
+	return api.HealthStatus{
+		Healthy:           res.GetHealthy(),
+		BackendError:      res.GetBackendError(),
+		Containers:        res.GetContainers(),
+		MaxContainers:     res.GetMaxContainers(),
+		ContainerHeadroom: res.GetContainerHeadroom(),
+		Uptime:            time.Duration(res.GetUptime()) * time.Second,
+		ProtocolVersion:   res.GetProtocolVersion(),
+	}, nil
 }
 
 func (c *connection) Capacity() (api.Capacity, error) {
-	capacity := &protocol.CapacityResponse{}
+	return c.capacity(nil)
+}
+
+// FreshCapacity is like Capacity, but asks the server to bypass any capacity
+// it has cached.
+func (c *connection) FreshCapacity() (api.Capacity, error) {
+	return c.capacity(url.Values{"fresh": []string{"true"}})
+}
 
-	err := c.do(routes.Capacity, nil, capacity, nil, nil)
+func (c *connection) capacity(query url.Values) (api.Capacity, error) {
+	capacity := &apitypes.CapacityResponse{}
+
+	err := c.do(routes.Capacity, nil, capacity, nil, query)
 	if err != nil {
 		return api.Capacity{}, err
 	}
@@ -125,84 +422,256 @@ func (c *connection) Capacity() (api.Capacity, error) {
 	}, nil
 }
 
-func (c *connection) Create(spec api.ContainerSpec) (string, error) {
-	req := &protocol.CreateRequest{}
+func (c *connection) Create(spec api.ContainerSpec) (string, *api.ContainerInfo, error) {
+	err := validateImage(spec.Image)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = validateBindMounts(spec.BindMounts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = validateEnv(spec.Env)
+	if err != nil {
+		return "", nil, err
+	}
+
+	req := &apitypes.CreateRequest{}
 
 	if spec.Handle != "" {
-		req.Handle = proto.String(spec.Handle)
+		req.Handle = apitypes.String(spec.Handle)
 	}
 
 	if spec.RootFSPath != "" {
-		req.Rootfs = proto.String(spec.RootFSPath)
+		req.Rootfs = apitypes.String(spec.RootFSPath)
 	}
 
 	if spec.GraceTime != 0 {
-		req.GraceTime = proto.Uint32(uint32(spec.GraceTime.Seconds()))
+		req.GraceTime = apitypes.Uint32(uint32(spec.GraceTime.Seconds()))
 	}
 
 	if spec.Network != "" {
-		req.Network = proto.String(spec.Network)
+		req.Network = apitypes.String(spec.Network)
 	}
 
 	if spec.Env != nil {
 		req.Env = convertEnvironmentVariables(spec.Env)
 	}
 
-	req.Privileged = proto.Bool(spec.Privileged)
+	req.Privileged = apitypes.Bool(spec.Privileged)
+
+	if spec.DefaultProcessUser != "" {
+		req.DefaultProcessUser = apitypes.String(spec.DefaultProcessUser)
+	}
+
+	if spec.ExternalIP != "" {
+		req.ExternalIp = apitypes.String(spec.ExternalIP)
+	}
+
+	if spec.ExternalInterface != "" {
+		req.ExternalInterface = apitypes.String(spec.ExternalInterface)
+	}
+
+	if spec.Image.URI != "" {
+		req.Image = &apitypes.CreateRequest_Image{
+			Uri: apitypes.String(spec.Image.URI),
+		}
+
+		if spec.Image.Username != "" {
+			req.Image.Username = apitypes.String(spec.Image.Username)
+		}
+
+		if spec.Image.Password != "" {
+			req.Image.Password = apitypes.String(spec.Image.Password)
+		}
+	}
 
 	for _, bm := range spec.BindMounts {
-		var mode protocol.CreateRequest_BindMount_Mode
-		var origin protocol.CreateRequest_BindMount_Origin
+		var mode apitypes.CreateRequest_BindMount_Mode
+		var origin apitypes.CreateRequest_BindMount_Origin
 
 		switch bm.Mode {
 		case api.BindMountModeRO:
-			mode = protocol.CreateRequest_BindMount_RO
+			mode = apitypes.CreateRequest_BindMount_RO
 		case api.BindMountModeRW:
-			mode = protocol.CreateRequest_BindMount_RW
+			mode = apitypes.CreateRequest_BindMount_RW
 		}
 
 		switch bm.Origin {
 		case api.BindMountOriginHost:
-			origin = protocol.CreateRequest_BindMount_Host
+			origin = apitypes.CreateRequest_BindMount_Host
 		case api.BindMountOriginContainer:
-			origin = protocol.CreateRequest_BindMount_Container
+			origin = apitypes.CreateRequest_BindMount_Container
 		}
 
-		req.BindMounts = append(req.BindMounts, &protocol.CreateRequest_BindMount{
-			SrcPath: proto.String(bm.SrcPath),
-			DstPath: proto.String(bm.DstPath),
+		req.BindMounts = append(req.BindMounts, &apitypes.CreateRequest_BindMount{
+			SrcPath: apitypes.String(bm.SrcPath),
+			DstPath: apitypes.String(bm.DstPath),
 			Mode:    &mode,
 			Origin:  &origin,
 		})
 	}
 
-	props := []*protocol.Property{}
+	props := []*apitypes.Property{}
 	for key, val := range spec.Properties {
-		props = append(props, &protocol.Property{
-			Key:   proto.String(key),
-			Value: proto.String(val),
+		props = append(props, &apitypes.Property{
+			Key:   apitypes.String(key),
+			Value: apitypes.String(val),
 		})
 	}
 
 	req.Properties = props
 
-	res := &protocol.CreateResponse{}
-	err := c.do(routes.Create, req, res, nil, nil)
+	res := &apitypes.CreateResponse{}
+	err = c.do(routes.Create, req, res, nil, nil)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return res.GetHandle(), nil
+	if res.Info == nil {
+		return res.GetHandle(), nil, nil
+	}
+
+	info := containerInfo(res.GetInfo())
+
+	return res.GetHandle(), &info, nil
 }
 
 func (c *connection) Stop(handle string, kill bool) error {
+	return c.StopWithReason(handle, kill, "")
+}
+
+func (c *connection) StopWithReason(handle string, kill bool, reason string) error {
+	req := &apitypes.StopRequest{
+		Handle: apitypes.String(handle),
+		Kill:   apitypes.Bool(kill),
+	}
+
+	if reason != "" {
+		req.Reason = apitypes.String(reason)
+	}
+
 	return c.do(
 		routes.Stop,
-		&protocol.StopRequest{
-			Handle: proto.String(handle),
-			Kill:   proto.Bool(kill),
+		req,
+		&apitypes.StopResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) SignalAll(handle string, signal api.Signal) error {
+	return c.do(
+		routes.SignalAll,
+		&apitypes.SignalAllRequest{
+			Handle: apitypes.String(handle),
+			Signal: apitypes.SignalAllRequest_Signal(signal).Enum(),
+		},
+		&apitypes.SignalAllResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) Pause(handle string) error {
+	return c.do(
+		routes.Pause,
+		&apitypes.PauseRequest{
+			Handle: apitypes.String(handle),
+		},
+		&apitypes.PauseResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) Resume(handle string) error {
+	return c.do(
+		routes.Resume,
+		&apitypes.ResumeRequest{
+			Handle: apitypes.String(handle),
+		},
+		&apitypes.ResumeResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) DestroyAt(handle string, at time.Time) error {
+	return c.do(
+		routes.ScheduleDestroy,
+		&apitypes.ScheduleDestroyRequest{
+			Handle: apitypes.String(handle),
+			At:     apitypes.Int64(at.Unix()),
+		},
+		&apitypes.ScheduleDestroyResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) DestroyAfter(handle string, delay time.Duration) error {
+	return c.do(
+		routes.ScheduleDestroy,
+		&apitypes.ScheduleDestroyRequest{
+			Handle: apitypes.String(handle),
+			After:  apitypes.Uint32(uint32(delay.Seconds())),
+		},
+		&apitypes.ScheduleDestroyResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) CancelScheduledDestroy(handle string) error {
+	return c.do(
+		routes.CancelScheduledDestroy,
+		nil,
+		&apitypes.CancelScheduledDestroyResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) SetGraceTime(handle string, graceTime time.Duration) error {
+	return c.do(
+		routes.SetGraceTime,
+		&apitypes.SetGraceTimeRequest{
+			Handle:    apitypes.String(handle),
+			GraceTime: apitypes.Uint32(uint32(graceTime.Seconds())),
+		},
+		&apitypes.SetGraceTimeResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) SetHold(handle string, held bool) error {
+	return c.do(
+		routes.SetHold,
+		&apitypes.SetHoldRequest{
+			Handle: apitypes.String(handle),
+			Held:   apitypes.Bool(held),
 		},
-		&protocol.StopResponse{},
+		&apitypes.SetHoldResponse{},
 		rata.Params{
 			"handle": handle,
 		},
@@ -214,7 +683,7 @@ func (c *connection) Destroy(handle string) error {
 	return c.do(
 		routes.Destroy,
 		nil,
-		&protocol.DestroyResponse{},
+		&apitypes.DestroyResponse{},
 		rata.Params{
 			"handle": handle,
 		},
@@ -222,97 +691,279 @@ func (c *connection) Destroy(handle string) error {
 	)
 }
 
-func (c *connection) Run(handle string, spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
-	reqBody := new(bytes.Buffer)
+func (c *connection) ForceDestroy(handle string) error {
+	return c.do(
+		routes.Destroy,
+		nil,
+		&apitypes.DestroyResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		url.Values{
+			"force": []string{"true"},
+		},
+	)
+}
 
-	var dir *string
-	if spec.Dir != "" {
-		dir = proto.String(spec.Dir)
+func (c *connection) DestroyWithReason(handle string, force bool, reason string) error {
+	query := url.Values{}
+
+	if force {
+		query.Set("force", "true")
 	}
 
-	var tty *protocol.TTY
-	if spec.TTY != nil {
-		tty = &protocol.TTY{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
 
-		if spec.TTY.WindowSize != nil {
-			tty.WindowSize = &protocol.TTY_WindowSize{
-				Columns: proto.Uint32(uint32(spec.TTY.WindowSize.Columns)),
-				Rows:    proto.Uint32(uint32(spec.TTY.WindowSize.Rows)),
-			}
-		}
+	return c.do(
+		routes.Destroy,
+		nil,
+		&apitypes.DestroyResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		query,
+	)
+}
+
+func (c *connection) DestroyAsync(handle string, force bool) (string, error) {
+	query := url.Values{
+		"async": []string{"true"},
 	}
 
-	err := transport.WriteMessage(reqBody, &protocol.RunRequest{
-		Handle:     proto.String(handle),
-		Path:       proto.String(spec.Path),
-		Args:       spec.Args,
-		Dir:        dir,
-		Privileged: proto.Bool(spec.Privileged),
-		User:       proto.String(spec.User),
-		Tty:        tty,
-		Rlimits: &protocol.ResourceLimits{
-			As:         spec.Limits.As,
-			Core:       spec.Limits.Core,
-			Cpu:        spec.Limits.Cpu,
-			Data:       spec.Limits.Data,
-			Fsize:      spec.Limits.Fsize,
-			Locks:      spec.Limits.Locks,
-			Memlock:    spec.Limits.Memlock,
-			Msgqueue:   spec.Limits.Msgqueue,
-			Nice:       spec.Limits.Nice,
-			Nofile:     spec.Limits.Nofile,
-			Nproc:      spec.Limits.Nproc,
-			Rss:        spec.Limits.Rss,
-			Rtprio:     spec.Limits.Rtprio,
-			Sigpending: spec.Limits.Sigpending,
-			Stack:      spec.Limits.Stack,
-		},
-		Env: convertEnvironmentVariables(spec.Env),
-	})
-	if err != nil {
-		return nil, err
+	if force {
+		query.Set("force", "true")
 	}
 
-	conn, br, err := c.doHijack(
-		routes.Run,
-		reqBody,
+	res := &apitypes.DestroyResponse{}
+
+	err := c.do(
+		routes.Destroy,
+		nil,
+		res,
 		rata.Params{
 			"handle": handle,
 		},
-		nil,
-		"application/json",
+		query,
 	)
+
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	decoder := json.NewDecoder(br)
+	return res.GetOperationId(), nil
+}
+
+func (c *connection) DestroyOperation(id string) (api.DestroyOperation, error) {
+	res := &apitypes.DestroyOperationResponse{}
+
+	err := c.do(
+		routes.DestroyOperation,
+		nil,
+		res,
+		rata.Params{
+			"id": id,
+		},
+		nil,
+	)
 
-	firstResponse := &protocol.ProcessPayload{}
-	err = decoder.Decode(firstResponse)
 	if err != nil {
-		return nil, err
+		return api.DestroyOperation{}, err
 	}
 
-	p := newProcess(firstResponse.GetProcessId(), conn)
+	operation := api.DestroyOperation{
+		ID:     res.GetId(),
+		Handle: res.GetHandle(),
+		State:  api.DestroyState(res.GetState()),
+	}
 
-	go p.streamPayloads(decoder, processIO)
+	if res.GetError() != nil {
+		operation.Error = errorFrom(res.GetError())
+	}
 
-	return p, nil
+	return operation, nil
 }
 
-func (c *connection) Attach(handle string, processID uint32, processIO api.ProcessIO) (api.Process, error) {
+func (c *connection) Rename(oldHandle, newHandle string) error {
+	return c.do(
+		routes.Rename,
+		&apitypes.RenameRequest{
+			Handle:    apitypes.String(oldHandle),
+			NewHandle: apitypes.String(newHandle),
+		},
+		&apitypes.RenameResponse{},
+		rata.Params{
+			"handle": oldHandle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) Snapshot(handle string) (io.ReadCloser, error) {
+	return c.doStream(
+		routes.Snapshot,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+		"",
+	)
+}
+
+func (c *connection) Restore(snapshot io.Reader) (string, error) {
+	body, err := c.doStream(
+		routes.Restore,
+		snapshot,
+		nil,
+		nil,
+		"application/octet-stream",
+	)
+	if err != nil {
+		return "", err
+	}
+
+	defer body.Close()
+
+	res := &apitypes.RestoreResponse{}
+
+	err = json.NewDecoder(transport.LimitMessage(body)).Decode(res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.GetHandle(), nil
+}
+
+func (c *connection) Run(handle string, spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+	err := validateEnv(spec.Env)
+	if err != nil {
+		return nil, err
+	}
+
 	reqBody := new(bytes.Buffer)
 
-	err := transport.WriteMessage(reqBody, &protocol.AttachRequest{
-		Handle:    proto.String(handle),
-		ProcessId: proto.Uint32(processID),
+	var dir *string
+	if spec.Dir != "" {
+		dir = apitypes.String(spec.Dir)
+	}
+
+	var tty *apitypes.TTY
+	if spec.TTY != nil {
+		tty = &apitypes.TTY{}
+
+		if spec.TTY.WindowSize != nil {
+			tty.WindowSize = &apitypes.TTY_WindowSize{
+				Columns: apitypes.Uint32(uint32(spec.TTY.WindowSize.Columns)),
+				Rows:    apitypes.Uint32(uint32(spec.TTY.WindowSize.Rows)),
+			}
+		}
+	}
+
+	var passed []apitypes.ProcessPayload_Source
+	var files []*os.File
+	if processIO.PassFiles && c.passFiles {
+		passed, files, processIO = passedFiles(processIO)
+	}
+
+	err = transport.WriteMessage(reqBody, &apitypes.RunRequest{
+		Handle:     apitypes.String(handle),
+		Path:       apitypes.String(spec.Path),
+		Args:       spec.Args,
+		Dir:        dir,
+		Privileged: apitypes.Bool(spec.Privileged),
+		User:       apitypes.String(spec.User),
+		Uid:        spec.UID,
+		Gid:        spec.GID,
+		Tty:        tty,
+		Rlimits:    resourceLimits(spec.Limits),
+		Env:        convertEnvironmentVariables(spec.Env),
+		Labels:     convertLabels(spec.Labels),
+
+		DiscardStdout: discarded(processIO.Stdout),
+		DiscardStderr: discarded(processIO.Stderr),
+
+		PassedFiles: passed,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	conn, br, err := c.doHijack(
+	conn, reader, writer, err := c.doHijack(
+		routes.Run,
+		reqBody,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+		"application/json",
+		files,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	firstResponse := &apitypes.ProcessPayload{}
+	err = reader.ReadMessage(firstResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newProcess(firstResponse.GetProcessId(), conn, writer)
+	p.stream.setWindow(firstResponse.GetStdinWindow())
+	p.reconnectToken = firstResponse.GetReconnectToken()
+
+	go p.streamPayloads(reader, processIO)
+
+	return p, nil
+}
+
+func (c *connection) Attach(handle string, processID uint32, processIO api.ProcessIO) (api.Process, error) {
+	p, err := c.attach(handle, processID, &apitypes.AttachRequest{
+		Handle:        apitypes.String(handle),
+		ProcessId:     apitypes.Uint32(processID),
+		DiscardStdout: discarded(processIO.Stdout),
+		DiscardStderr: discarded(processIO.Stderr),
+	}, processIO)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Reconnect attaches to the process with the reconnect token its server gave
+// out when it was run, having the server replay the output it retained which
+// was not yet received, e.g. after losing the process's streams to the server
+// restarting. Servers which did not retain the output stream it from now on.
+// The process returned may be reconnected to in turn.
+func (c *connection) Reconnect(handle string, processID uint32, spec api.ReconnectSpec, processIO api.ProcessIO) (api.Process, error) {
+	p, err := c.attach(handle, processID, &apitypes.AttachRequest{
+		Handle:         apitypes.String(handle),
+		ProcessId:      apitypes.Uint32(processID),
+		DiscardStdout:  discarded(processIO.Stdout),
+		DiscardStderr:  discarded(processIO.Stderr),
+		ReconnectToken: apitypes.String(spec.Token),
+		StdoutOffset:   apitypes.Uint64(spec.StdoutOffset),
+		StderrOffset:   apitypes.Uint64(spec.StderrOffset),
+	}, processIO)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (c *connection) attach(handle string, processID uint32, request *apitypes.AttachRequest, processIO api.ProcessIO) (*process, error) {
+	reqBody := new(bytes.Buffer)
+
+	err := transport.WriteMessage(reqBody, request)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, reader, writer, err := c.doHijack(
 		routes.Attach,
 		reqBody,
 		rata.Params{
@@ -320,68 +971,458 @@ func (c *connection) Attach(handle string, processID uint32, processIO api.Proce
 			"pid":    fmt.Sprintf("%d", processID),
 		},
 		nil,
-		"",
+		"application/json",
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := newProcess(processID, conn, writer)
+
+	// reconnected processes count on from the output already received
+	p.reconnectToken = request.GetReconnectToken()
+	p.stdoutReceived = request.GetStdoutOffset()
+	p.stderrReceived = request.GetStderrOffset()
+
+	go p.streamPayloads(reader, processIO)
+
+	return p, nil
+}
+
+func (c *connection) AttachAll(handle string, processIO func(uint32) api.ProcessIO) ([]api.Process, error) {
+	conn, reader, writer, err := c.doHijack(
+		routes.AttachAll,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+		"",
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	firstResponse := &apitypes.AttachAllResponse{}
+	err = reader.ReadMessage(firstResponse)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	processes := map[uint32]*process{}
+	processIOs := map[uint32]api.ProcessIO{}
+
+	attached := []api.Process{}
+	for _, processID := range firstResponse.GetProcessIds() {
+		p := newProcess(processID, conn, writer)
+		p.stream.setWindow(firstResponse.GetStdinWindow())
+
+		processes[processID] = p
+		processIOs[processID] = processIO(processID)
+
+		attached = append(attached, p)
+	}
+
+	go streamMultiplexedPayloads(conn, reader, processes, processIOs)
+
+	return attached, nil
+}
+
+func (c *connection) ProcessInfo(handle string, processID uint32) (api.ProcessInfo, error) {
+	res := &apitypes.ProcessInfoResponse{}
+
+	err := c.do(
+		routes.ProcessInfo,
+		nil,
+		res,
+		rata.Params{
+			"handle": handle,
+			"pid":    fmt.Sprintf("%d", processID),
+		},
+		nil,
+	)
+
+	if err != nil {
+		return api.ProcessInfo{}, err
+	}
+
+	info := api.ProcessInfo{
+		ID:         res.GetProcessId(),
+		State:      api.ProcessState(res.GetState()),
+		CPUTime:    time.Duration(res.GetCpuTime()),
+		RSSInBytes: res.GetRss(),
+	}
+
+	if res.StartedAt != nil {
+		info.StartedAt = time.Unix(res.GetStartedAt(), 0)
+	}
+
+	return info, nil
+}
+
+// FindProcesses sends the labels as query parameters, as List does properties.
+func (c *connection) FindProcesses(handle string, labels map[string]string) ([]uint32, error) {
+	values := url.Values{}
+	for key, value := range labels {
+		values[key] = []string{value}
+	}
+
+	res := &apitypes.FindProcessesResponse{}
+
+	err := c.do(
+		routes.FindProcesses,
+		nil,
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		values,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetProcessIds(), nil
+}
+
+// WaitForProcess is not bounded by the connection's RequestTimeout, but by its
+// own timeout, which the server bounds.
+func (c *connection) WaitForProcess(handle string, matcher api.ProcessMatcher, timeout time.Duration) (uint32, error) {
+	values := url.Values{}
+
+	if matcher.ID != 0 {
+		values.Set("id", strconv.FormatUint(uint64(matcher.ID), 10))
+	}
+
+	for key, value := range matcher.Labels {
+		values.Add("label", key+"="+value)
+	}
+
+	values.Set("timeout", strconv.FormatInt(int64(timeout/time.Millisecond), 10))
+
+	res := &apitypes.WaitForProcessResponse{}
+
+	err := c.doWith(
+		c.httpClient,
+		routes.WaitForProcess,
+		nil,
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		values,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.GetProcessId(), nil
+}
+
+// Logs streams the container's retained output, which, when following, lasts
+// until the stream is closed, so is bounded by neither the RequestTimeout nor
+// the StreamTimeout.
+func (c *connection) Logs(handle string, tail int, follow bool) (api.LogStream, error) {
+	values := url.Values{}
+
+	if tail != 0 {
+		values.Set("tail", strconv.Itoa(tail))
+	}
+
+	client := c.streamClient
+
+	if follow {
+		values.Set("follow", "true")
+
+		// followed logs stream for as long as the container runs, so are
+		// not cut short by the stream timeout
+		client = c.httpClient
+	}
+
+	request, err := c.newRequest(routes.Logs, nil, rata.Params{"handle": handle}, values, "")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(client, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logStream{
+		body:   body,
+		reader: transport.NewJSONReader(body),
+	}, nil
+}
+
+type logStream struct {
+	body   io.ReadCloser
+	reader transport.MessageReader
+}
+
+func (s *logStream) Next() (api.LogEntry, error) {
+	entry := &apitypes.LogEntry{}
+
+	err := s.reader.ReadMessage(entry)
+	if err != nil {
+		return api.LogEntry{}, err
+	}
+
+	source := api.LogSourceStdout
+	if entry.GetSource() == apitypes.ProcessPayload_stderr {
+		source = api.LogSourceStderr
+	}
+
+	return api.LogEntry{
+		ProcessID: entry.GetProcessId(),
+		Source:    source,
+		Data:      []byte(entry.GetData()),
+		Time:      time.Unix(0, entry.GetTimestamp()),
+	}, nil
+}
+
+func (s *logStream) Close() error {
+	return s.body.Close()
+}
+
+func (c *connection) NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
+	return c.NetInSpec(handle, api.NetInSpec{
+		HostPort:      hostPort,
+		ContainerPort: containerPort,
+	})
+}
+
+func (c *connection) NetInSpec(handle string, spec api.NetInSpec) (uint32, uint32, error) {
+	req := &apitypes.NetInRequest{
+		Handle:        apitypes.String(handle),
+		HostPort:      apitypes.Uint32(spec.HostPort),
+		ContainerPort: apitypes.Uint32(spec.ContainerPort),
+	}
+
+	// TCP is left unsaid, so that servers which predate protocols understand
+	// the request
+	switch spec.Protocol {
+	case 0, api.ProtocolTCP:
+	case api.ProtocolUDP:
+		req.Protocol = apitypes.NetInRequest_UDP.Enum()
+	default:
+		return 0, 0, errors.New("invalid protocol")
+	}
+
+	if spec.HostIP != nil {
+		req.HostIp = apitypes.String(spec.HostIP.String())
+	}
+
+	res := &apitypes.NetInResponse{}
+
+	err := c.do(
+		routes.NetIn,
+		req,
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return res.GetHostPort(), res.GetContainerPort(), nil
+}
+
+func (c *connection) MappedPorts(handle string) ([]api.PortMapping, error) {
+	res := &apitypes.MappedPortsResponse{}
+
+	err := c.do(
+		routes.MappedPorts,
+		nil,
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return portMappings(res.GetMappedPorts()), nil
+}
+
+func portMappings(mappedPorts []*apitypes.InfoResponse_PortMapping) []api.PortMapping {
+	mappings := []api.PortMapping{}
+	for _, mappedPort := range mappedPorts {
+		mapping := api.PortMapping{
+			HostPort:      mappedPort.GetHostPort(),
+			ContainerPort: mappedPort.GetContainerPort(),
+			Protocol:      api.ProtocolTCP,
+		}
+
+		if mappedPort.GetProtocol() == apitypes.NetInRequest_UDP {
+			mapping.Protocol = api.ProtocolUDP
+		}
+
+		if mappedPort.HostIp != nil {
+			mapping.HostIP = net.ParseIP(mappedPort.GetHostIp())
+		}
+
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings
+}
+
+func (c *connection) NetOut(handle string, network string, port uint32, portRange string, netProto api.Protocol) error {
+	var np apitypes.NetOutRequest_Protocol
+
+	switch netProto {
+	case api.ProtocolTCP:
+		np = apitypes.NetOutRequest_TCP
+	case api.ProtocolAll:
+		np = apitypes.NetOutRequest_ALL
+	default:
+		return errors.New("invalid protocol")
+	}
+
+	return c.do(
+		routes.NetOut,
+		&apitypes.NetOutRequest{
+			Handle:    apitypes.String(handle),
+			Network:   apitypes.String(network),
+			Port:      apitypes.Uint32(port),
+			PortRange: apitypes.String(portRange),
+			Protocol:  &np,
+		},
+		&apitypes.NetOutResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) NetOutRule(handle string, rule api.NetOutRule) error {
+	var protocol apitypes.NetOutRuleRequest_Protocol
+
+	switch rule.Protocol {
+	case api.ProtocolAll:
+		protocol = apitypes.NetOutRuleRequest_ALL
+	case api.ProtocolTCP:
+		protocol = apitypes.NetOutRuleRequest_TCP
+	case api.ProtocolUDP:
+		protocol = apitypes.NetOutRuleRequest_UDP
+	case api.ProtocolICMP:
+		protocol = apitypes.NetOutRuleRequest_ICMP
+	default:
+		return errors.New("invalid protocol")
+	}
+
+	networks := []*apitypes.NetOutRuleRequest_IPRange{}
+	for _, network := range rule.Networks {
+		networks = append(networks, &apitypes.NetOutRuleRequest_IPRange{
+			Start: apitypes.String(network.Start.String()),
+			End:   apitypes.String(network.End.String()),
+		})
+	}
+
+	ports := []*apitypes.NetOutRuleRequest_PortRange{}
+	for _, portRange := range rule.Ports {
+		ports = append(ports, &apitypes.NetOutRuleRequest_PortRange{
+			Start: apitypes.Uint32(uint32(portRange.Start)),
+			End:   apitypes.Uint32(uint32(portRange.End)),
+		})
+	}
+
+	return c.do(
+		routes.NetOutRule,
+		&apitypes.NetOutRuleRequest{
+			Handle:   apitypes.String(handle),
+			Protocol: &protocol,
+			Networks: networks,
+			Ports:    ports,
+			Log:      apitypes.Bool(rule.Log),
+		},
+		&apitypes.NetOutRuleResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) GetProperty(handle string, name string) (string, error) {
+	res := &apitypes.GetPropertyResponse{}
+
+	err := c.do(
+		routes.GetProperty,
+		&apitypes.GetPropertyRequest{
+			Handle: apitypes.String(handle),
+			Key:    apitypes.String(name),
+		},
+		res,
+		rata.Params{
+			"handle": handle,
+			"key":    name,
+		},
+		nil,
 	)
 
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	decoder := json.NewDecoder(br)
-
-	p := newProcess(processID, conn)
-
-	go p.streamPayloads(decoder, processIO)
-
-	return p, nil
+	return res.GetValue(), nil
 }
 
-func (c *connection) NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
-	res := &protocol.NetInResponse{}
+func (c *connection) SetProperty(handle string, name string, value string) error {
+	res := &apitypes.SetPropertyResponse{}
 
 	err := c.do(
-		routes.NetIn,
-		&protocol.NetInRequest{
-			Handle:        proto.String(handle),
-			HostPort:      proto.Uint32(hostPort),
-			ContainerPort: proto.Uint32(containerPort),
+		routes.SetProperty,
+		&apitypes.SetPropertyRequest{
+			Handle: apitypes.String(handle),
+			Key:    apitypes.String(name),
+			Value:  apitypes.String(value),
 		},
 		res,
 		rata.Params{
 			"handle": handle,
+			"key":    name,
 		},
 		nil,
 	)
 
 	if err != nil {
-		return 0, 0, err
+		return err
 	}
 
-	return res.GetHostPort(), res.GetContainerPort(), nil
+	return nil
 }
 
-func (c *connection) NetOut(handle string, network string, port uint32, portRange string, netProto api.Protocol) error {
-	var np protocol.NetOutRequest_Protocol
-
-	switch netProto {
-	case api.ProtocolTCP:
-		np = protocol.NetOutRequest_TCP
-	case api.ProtocolAll:
-		np = protocol.NetOutRequest_ALL
-	default:
-		return errors.New("invalid protocol")
+func (c *connection) SetProperties(handle string, properties api.Properties) error {
+	props := []*apitypes.Property{}
+	for key, val := range properties {
+		props = append(props, &apitypes.Property{
+			Key:   apitypes.String(key),
+			Value: apitypes.String(val),
+		})
 	}
 
 	return c.do(
-		routes.NetOut,
-		&protocol.NetOutRequest{
-			Handle:    proto.String(handle),
-			Network:   proto.String(network),
-			Port:      proto.Uint32(port),
-			PortRange: proto.String(portRange),
-			Protocol:  &np,
+		routes.SetProperties,
+		&apitypes.SetPropertiesRequest{
+			Handle:     apitypes.String(handle),
+			Properties: props,
 		},
-		&protocol.NetOutResponse{},
+		&apitypes.SetPropertiesResponse{},
 		rata.Params{
 			"handle": handle,
 		},
@@ -389,14 +1430,16 @@ func (c *connection) NetOut(handle string, network string, port uint32, portRang
 	)
 }
 
-func (c *connection) GetProperty(handle string, name string) (string, error) {
-	res := &protocol.GetPropertyResponse{}
+func (c *connection) CompareAndSwapProperty(handle string, name string, oldValue string, newValue string) (bool, error) {
+	res := &apitypes.CompareAndSwapPropertyResponse{}
 
 	err := c.do(
-		routes.GetProperty,
-		&protocol.GetPropertyRequest{
-			Handle: proto.String(handle),
-			Key:    proto.String(name),
+		routes.CompareAndSwapProperty,
+		&apitypes.CompareAndSwapPropertyRequest{
+			Handle:   apitypes.String(handle),
+			Key:      apitypes.String(name),
+			OldValue: apitypes.String(oldValue),
+			NewValue: apitypes.String(newValue),
 		},
 		res,
 		rata.Params{
@@ -407,21 +1450,20 @@ func (c *connection) GetProperty(handle string, name string) (string, error) {
 	)
 
 	if err != nil {
-		return "", err
+		return false, err
 	}
 
-	return res.GetValue(), nil
+	return res.GetSwapped(), nil
 }
 
-func (c *connection) SetProperty(handle string, name string, value string) error {
-	res := &protocol.SetPropertyResponse{}
+func (c *connection) RemoveProperty(handle string, name string) error {
+	res := &apitypes.RemovePropertyResponse{}
 
 	err := c.do(
-		routes.SetProperty,
-		&protocol.SetPropertyRequest{
-			Handle: proto.String(handle),
-			Key:    proto.String(name),
-			Value:  proto.String(value),
+		routes.RemoveProperty,
+		&apitypes.RemovePropertyRequest{
+			Handle: apitypes.String(handle),
+			Key:    apitypes.String(name),
 		},
 		res,
 		rata.Params{
@@ -438,39 +1480,47 @@ func (c *connection) SetProperty(handle string, name string, value string) error
 	return nil
 }
 
-func (c *connection) RemoveProperty(handle string, name string) error {
-	res := &protocol.RemovePropertyResponse{}
+func (c *connection) BulkProperties(handles []string, keys []string) (map[string]api.Properties, error) {
+	res := &apitypes.BulkPropertiesResponse{}
 
 	err := c.do(
-		routes.RemoveProperty,
-		&protocol.RemovePropertyRequest{
-			Handle: proto.String(handle),
-			Key:    proto.String(name),
+		routes.BulkProperties,
+		&apitypes.BulkPropertiesRequest{
+			Handles: handles,
+			Keys:    keys,
 		},
 		res,
-		rata.Params{
-			"handle": handle,
-			"key":    name,
-		},
+		nil,
 		nil,
 	)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	containers := map[string]api.Properties{}
+
+	for _, container := range res.GetContainers() {
+		properties := api.Properties{}
+		for _, prop := range container.GetProperties() {
+			properties[prop.GetKey()] = prop.GetValue()
+		}
+
+		containers[container.GetHandle()] = properties
+	}
+
+	return containers, nil
 }
 
 func (c *connection) LimitBandwidth(handle string, limits api.BandwidthLimits) (api.BandwidthLimits, error) {
-	res := &protocol.LimitBandwidthResponse{}
+	res := &apitypes.LimitBandwidthResponse{}
 
 	err := c.do(
 		routes.LimitBandwidth,
-		&protocol.LimitBandwidthRequest{
-			Handle: proto.String(handle),
-			Rate:   proto.Uint64(limits.RateInBytesPerSecond),
-			Burst:  proto.Uint64(limits.BurstRateInBytesPerSecond),
+		&apitypes.LimitBandwidthRequest{
+			Handle: apitypes.String(handle),
+			Rate:   apitypes.Uint64(limits.RateInBytesPerSecond),
+			Burst:  apitypes.Uint64(limits.BurstRateInBytesPerSecond),
 		},
 		res,
 		rata.Params{
@@ -490,7 +1540,7 @@ func (c *connection) LimitBandwidth(handle string, limits api.BandwidthLimits) (
 }
 
 func (c *connection) CurrentBandwidthLimits(handle string) (api.BandwidthLimits, error) {
-	res := &protocol.LimitBandwidthResponse{}
+	res := &apitypes.LimitBandwidthResponse{}
 
 	err := c.do(
 		routes.CurrentBandwidthLimits,
@@ -513,13 +1563,16 @@ func (c *connection) CurrentBandwidthLimits(handle string) (api.BandwidthLimits,
 }
 
 func (c *connection) LimitCPU(handle string, limits api.CPULimits) (api.CPULimits, error) {
-	res := &protocol.LimitCpuResponse{}
+	res := &apitypes.LimitCpuResponse{}
 
 	err := c.do(
 		routes.LimitCPU,
-		&protocol.LimitCpuRequest{
-			Handle:        proto.String(handle),
-			LimitInShares: proto.Uint64(limits.LimitInShares),
+		&apitypes.LimitCpuRequest{
+			Handle:        apitypes.String(handle),
+			LimitInShares: apitypes.Uint64(limits.LimitInShares),
+			Quota:         apitypes.Uint64(limits.Quota),
+			Period:        apitypes.Uint64(limits.Period),
+			Cpuset:        apitypes.String(limits.Cpuset),
 		},
 		res,
 		rata.Params{
@@ -534,11 +1587,14 @@ func (c *connection) LimitCPU(handle string, limits api.CPULimits) (api.CPULimit
 
 	return api.CPULimits{
 		LimitInShares: res.GetLimitInShares(),
+		Quota:         res.GetQuota(),
+		Period:        res.GetPeriod(),
+		Cpuset:        res.GetCpuset(),
 	}, nil
 }
 
 func (c *connection) CurrentCPULimits(handle string) (api.CPULimits, error) {
-	res := &protocol.LimitCpuResponse{}
+	res := &apitypes.LimitCpuResponse{}
 
 	err := c.do(
 		routes.CurrentCPULimits,
@@ -556,25 +1612,28 @@ func (c *connection) CurrentCPULimits(handle string) (api.CPULimits, error) {
 
 	return api.CPULimits{
 		LimitInShares: res.GetLimitInShares(),
+		Quota:         res.GetQuota(),
+		Period:        res.GetPeriod(),
+		Cpuset:        res.GetCpuset(),
 	}, nil
 }
 
 func (c *connection) LimitDisk(handle string, limits api.DiskLimits) (api.DiskLimits, error) {
-	res := &protocol.LimitDiskResponse{}
+	res := &apitypes.LimitDiskResponse{}
 
 	err := c.do(
 		routes.LimitDisk,
-		&protocol.LimitDiskRequest{
-			Handle: proto.String(handle),
+		&apitypes.LimitDiskRequest{
+			Handle: apitypes.String(handle),
 
-			BlockSoft: proto.Uint64(limits.BlockSoft),
-			BlockHard: proto.Uint64(limits.BlockHard),
+			BlockSoft: apitypes.Uint64(limits.BlockSoft),
+			BlockHard: apitypes.Uint64(limits.BlockHard),
 
-			InodeSoft: proto.Uint64(limits.InodeSoft),
-			InodeHard: proto.Uint64(limits.InodeHard),
+			InodeSoft: apitypes.Uint64(limits.InodeSoft),
+			InodeHard: apitypes.Uint64(limits.InodeHard),
 
-			ByteSoft: proto.Uint64(limits.ByteSoft),
-			ByteHard: proto.Uint64(limits.ByteHard),
+			ByteSoft: apitypes.Uint64(limits.ByteSoft),
+			ByteHard: apitypes.Uint64(limits.ByteHard),
 		},
 		res,
 		rata.Params{
@@ -600,7 +1659,7 @@ func (c *connection) LimitDisk(handle string, limits api.DiskLimits) (api.DiskLi
 }
 
 func (c *connection) CurrentDiskLimits(handle string) (api.DiskLimits, error) {
-	res := &protocol.LimitDiskResponse{}
+	res := &apitypes.LimitDiskResponse{}
 
 	err := c.do(
 		routes.CurrentDiskLimits,
@@ -628,14 +1687,100 @@ func (c *connection) CurrentDiskLimits(handle string) (api.DiskLimits, error) {
 	}, nil
 }
 
+func resourceLimits(limits api.ResourceLimits) *apitypes.ResourceLimits {
+	return &apitypes.ResourceLimits{
+		As:         limits.As,
+		Core:       limits.Core,
+		Cpu:        limits.Cpu,
+		Data:       limits.Data,
+		Fsize:      limits.Fsize,
+		Locks:      limits.Locks,
+		Memlock:    limits.Memlock,
+		Msgqueue:   limits.Msgqueue,
+		Nice:       limits.Nice,
+		Nofile:     limits.Nofile,
+		Nproc:      limits.Nproc,
+		Rss:        limits.Rss,
+		Rtprio:     limits.Rtprio,
+		Sigpending: limits.Sigpending,
+		Stack:      limits.Stack,
+	}
+}
+
+func (c *connection) DiskUsage(handle string) (api.ContainerDiskUsage, error) {
+	res := &apitypes.DiskUsageResponse{}
+
+	err := c.do(
+		routes.DiskUsage,
+		nil,
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	if err != nil {
+		return api.ContainerDiskUsage{}, err
+	}
+
+	return api.ContainerDiskUsage{
+		RootFSLayers: diskUsageEntries(res.GetRootfsLayers()),
+		Scratch:      diskUsageEntry(res.GetScratch()),
+		BindMounts:   diskUsageEntries(res.GetBindMounts()),
+	}, nil
+}
+
+func (c *connection) Env(handle string) ([]string, error) {
+	res := &apitypes.ContainerEnvResponse{}
+
+	err := c.do(
+		routes.Env,
+		nil,
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	env := []string{}
+	for _, variable := range res.GetEnv() {
+		env = append(env, variable.GetKey()+"="+variable.GetValue())
+	}
+
+	return env, nil
+}
+
+func diskUsageEntries(entries []*apitypes.DiskUsageResponse_Entry) []api.DiskUsageEntry {
+	var converted []api.DiskUsageEntry
+	for _, entry := range entries {
+		converted = append(converted, diskUsageEntry(entry))
+	}
+
+	return converted
+}
+
+func diskUsageEntry(entry *apitypes.DiskUsageResponse_Entry) api.DiskUsageEntry {
+	return api.DiskUsageEntry{
+		Name:       entry.GetName(),
+		BytesUsed:  entry.GetBytesUsed(),
+		InodesUsed: entry.GetInodesUsed(),
+	}
+}
+
 func (c *connection) LimitMemory(handle string, limits api.MemoryLimits) (api.MemoryLimits, error) {
-	res := &protocol.LimitMemoryResponse{}
+	res := &apitypes.LimitMemoryResponse{}
 
 	err := c.do(
 		routes.LimitMemory,
-		&protocol.LimitMemoryRequest{
-			Handle:       proto.String(handle),
-			LimitInBytes: proto.Uint64(limits.LimitInBytes),
+		&apitypes.LimitMemoryRequest{
+			Handle:       apitypes.String(handle),
+			LimitInBytes: apitypes.Uint64(limits.LimitInBytes),
 		},
 		res,
 		rata.Params{
@@ -654,7 +1799,7 @@ func (c *connection) LimitMemory(handle string, limits api.MemoryLimits) (api.Me
 }
 
 func (c *connection) CurrentMemoryLimits(handle string) (api.MemoryLimits, error) {
-	res := &protocol.LimitMemoryResponse{}
+	res := &apitypes.LimitMemoryResponse{}
 
 	err := c.do(
 		routes.CurrentMemoryLimits,
@@ -667,77 +1812,425 @@ func (c *connection) CurrentMemoryLimits(handle string) (api.MemoryLimits, error
 	)
 
 	if err != nil {
-		return api.MemoryLimits{}, err
+		return api.MemoryLimits{}, err
+	}
+
+	return api.MemoryLimits{
+		LimitInBytes: res.GetLimitInBytes(),
+	}, nil
+}
+
+// StreamIn streams to the atomic route for atomic streams, so that servers
+// which predate them fail the request rather than extracting in place.
+func (c *connection) StreamIn(handle string, spec api.StreamInSpec) error {
+	handler := routes.StreamIn
+	if spec.Atomic {
+		handler = routes.StreamInAtomically
+	}
+
+	query := url.Values{
+		"destination": []string{spec.Path},
+	}
+
+	addStreamOptions(query, spec.User, spec.Compression)
+
+	request, err := c.newRequest(
+		handler,
+		spec.TarStream,
+		rata.Params{
+			"handle": handle,
+		},
+		query,
+		"application/x-tar",
+	)
+	if err != nil {
+		return err
+	}
+
+	if spec.ExpectedSize != 0 {
+		request.Header.Set(transport.ExpectedSizeHeader, strconv.FormatUint(spec.ExpectedSize, 10))
+	}
+
+	return c.streamIn(request)
+}
+
+func addStreamOptions(query url.Values, user string, compression api.Compression) {
+	if user != "" {
+		query.Set("user", user)
+	}
+
+	if compression == api.CompressionGzip {
+		query.Set("compression", transport.CompressionGzip)
+	}
+}
+
+func (c *connection) streamIn(request *http.Request) error {
+	body, err := c.doRequest(c.streamClient, request)
+	if err != nil {
+		return err
+	}
+
+	return body.Close()
+}
+
+func (c *connection) StreamOut(handle string, spec api.StreamOutSpec) (io.ReadCloser, error) {
+	query := url.Values{
+		"source": []string{spec.Path},
+	}
+
+	addStreamOptions(query, spec.User, spec.Compression)
+
+	if spec.Offset > 0 {
+		query.Set("offset", strconv.FormatUint(spec.Offset, 10))
+	}
+
+	request, err := c.newRequest(
+		routes.StreamOut,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		query,
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := c.doResponse(c.streamClient, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var stream io.ReadCloser = httpResp.Body
+
+	// offsets are into the uncompressed archive, so only uncompressed
+	// streams count their way to one to resume from
+	if spec.Compression == api.CompressionNone {
+		stream = &resumableStream{
+			ReadCloser: httpResp.Body,
+			offset:     spec.Offset,
+		}
+	}
+
+	if spec.Cancel == nil {
+		return stream, nil
+	}
+
+	return newCancellableStream(stream, spec.Cancel), nil
+}
+
+// resumableStream is a stream of files which fails with the offset it reached
+// if the server cuts it short, e.g. as the backend failed part way through the
+// archive.
+type resumableStream struct {
+	io.ReadCloser
+
+	offset uint64
+}
+
+func (s *resumableStream) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	s.offset += uint64(n)
+
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+
+	return n, api.StreamOutInterruptedError{
+		Offset:  s.offset,
+		Message: err.Error(),
+	}
+}
+
+// cancellableStream is closed once cancelled, so that the server stops
+// streaming too.
+type cancellableStream struct {
+	io.ReadCloser
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newCancellableStream(stream io.ReadCloser, cancel <-chan struct{}) *cancellableStream {
+	s := &cancellableStream{
+		ReadCloser: stream,
+
+		closed: make(chan struct{}),
+	}
+
+	go func() {
+		select {
+		case <-cancel:
+			s.Close()
+		case <-s.closed:
+		}
+	}()
+
+	return s
+}
+
+func (s *cancellableStream) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.closeErr = s.ReadCloser.Close()
+	})
+
+	return s.closeErr
+}
+
+func (c *connection) VerifyStreamIn(handle string, dstPath string, reader io.Reader) (api.StreamInReport, error) {
+	body, err := c.doStream(
+		routes.VerifyStreamIn,
+		reader,
+		rata.Params{
+			"handle": handle,
+		},
+		url.Values{
+			"destination": []string{dstPath},
+		},
+		"application/x-tar",
+	)
+	if err != nil {
+		return api.StreamInReport{}, err
+	}
+
+	defer body.Close()
+
+	res := &apitypes.VerifyStreamInResponse{}
+
+	err = json.NewDecoder(transport.LimitMessage(body)).Decode(res)
+	if err != nil {
+		return api.StreamInReport{}, err
+	}
+
+	var problems []api.StreamInProblem
+	for _, problem := range res.GetProblems() {
+		problems = append(problems, api.StreamInProblem{
+			Path:   problem.GetPath(),
+			Reason: problem.GetReason(),
+		})
+	}
+
+	return api.StreamInReport{
+		Entries:  res.GetEntries(),
+		Bytes:    res.GetBytes(),
+		Problems: problems,
+	}, nil
+}
+
+func (c *connection) List(filterProperties api.Properties) ([]string, error) {
+	values := url.Values{}
+	for name, val := range filterProperties {
+		values[name] = []string{val}
+	}
+
+	res := &apitypes.ListResponse{}
+
+	err := c.do(
+		routes.List,
+		nil,
+		res,
+		nil,
+		values,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetHandles(), nil
+}
+
+// ListWhere lists the containers matching every filter. Filters other than
+// PropertyEquals are sent with their operator prefixed to the property name,
+// as are those whose name contains a colon, so that it is not mistaken for one.
+func (c *connection) ListWhere(filters []api.PropertyFilter) ([]string, error) {
+	values := url.Values{}
+	for _, filter := range filters {
+		key := filter.Name
+		if filter.Operator != api.PropertyEquals || strings.Contains(key, ":") {
+			key = string(filter.Operator) + ":" + key
+		}
+
+		values.Add(key, filter.Value)
+	}
+
+	res := &apitypes.ListResponse{}
+
+	err := c.do(
+		routes.List,
+		nil,
+		res,
+		nil,
+		values,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetHandles(), nil
+}
+
+func (c *connection) ListEach(filterProperties api.Properties, each func(string) error) error {
+	values := url.Values{}
+	for name, val := range filterProperties {
+		values[name] = []string{val}
+	}
+
+	request, err := c.newRequest(routes.List, nil, nil, values, "")
+	if err != nil {
+		return err
 	}
 
-	return api.MemoryLimits{
-		LimitInBytes: res.GetLimitInBytes(),
-	}, nil
-}
+	request.Header.Set(transport.ListStreamHeader, "true")
 
-func (c *connection) StreamIn(handle string, dstPath string, reader io.Reader) error {
-	body, err := c.doStream(
-		routes.StreamIn,
-		reader,
-		rata.Params{
-			"handle": handle,
-		},
-		url.Values{
-			"destination": []string{dstPath},
-		},
-		"application/x-tar",
-	)
+	body, err := c.doRequest(c.streamClient, request)
 	if err != nil {
 		return err
 	}
 
-	return body.Close()
-}
+	defer body.Close()
 
-func (c *connection) StreamOut(handle string, srcPath string) (io.ReadCloser, error) {
-	return c.doStream(
-		routes.StreamOut,
-		nil,
-		rata.Params{
-			"handle": handle,
-		},
-		url.Values{
-			"source": []string{srcPath},
-		},
-		"",
-	)
+	reader := transport.NewJSONReader(body)
+
+	for {
+		entry := &apitypes.ListResponse_Container{}
+
+		err := reader.ReadMessage(entry)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// servers which do not stream ignore the header, and respond with a
+		// whole listing
+		if entry.Handle == nil {
+			return api.ErrUnsupportedOperation
+		}
+
+		err = each(entry.GetHandle())
+		if err != nil {
+			return err
+		}
+	}
 }
 
-func (c *connection) List(filterProperties api.Properties) ([]string, error) {
+func (c *connection) LookupBy(filterProperties api.Properties) (string, error) {
 	values := url.Values{}
 	for name, val := range filterProperties {
 		values[name] = []string{val}
 	}
 
-	res := &protocol.ListResponse{}
+	res := &apitypes.LookupResponse{}
 
 	err := c.do(
-		routes.List,
+		routes.LookupBy,
 		nil,
 		res,
 		nil,
 		values,
 	)
+	if err != nil {
+		return "", err
+	}
+
+	return res.GetHandle(), nil
+}
+
+// ListVerbose lists containers with their state and properties, in the given
+// order, or the server's default order if it is empty. Servers which predate
+// verbose listings ignore the header asking for one, and list only handles.
+func (c *connection) ListVerbose(filterProperties api.Properties, order api.ListOrder) ([]api.ContainerSummary, error) {
+	values := url.Values{}
+	for name, val := range filterProperties {
+		values[name] = []string{val}
+	}
+
+	request, err := c.newRequest(routes.List, nil, nil, values, "")
 	if err != nil {
 		return nil, err
 	}
 
-	return res.GetHandles(), nil
+	request.Header.Set(transport.ListVerboseHeader, "true")
+
+	if order != "" {
+		request.Header.Set(transport.ListSortHeader, string(order))
+	}
+
+	res := &apitypes.ListResponse{}
+
+	err = c.doDecode(c.noKeepaliveClient, request, res)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []api.ContainerSummary{}
+
+	if len(res.GetContainers()) == 0 {
+		// servers that do not support verbose listing only return handles
+		for _, handle := range res.GetHandles() {
+			summaries = append(summaries, api.ContainerSummary{Handle: handle})
+		}
+
+		return summaries, nil
+	}
+
+	for _, container := range res.GetContainers() {
+		properties := api.Properties{}
+		for _, prop := range container.GetProperties() {
+			properties[prop.GetKey()] = prop.GetValue()
+		}
+
+		summaries = append(summaries, api.ContainerSummary{
+			Handle:     container.GetHandle(),
+			State:      container.GetState(),
+			Properties: properties,
+		})
+	}
+
+	return summaries, nil
 }
 
 func (c *connection) Info(handle string) (api.ContainerInfo, error) {
-	res := &protocol.InfoResponse{}
+	res := &apitypes.InfoResponse{}
 
 	err := c.do(routes.Info, nil, res, rata.Params{"handle": handle}, nil)
 	if err != nil {
 		return api.ContainerInfo{}, err
 	}
 
+	return containerInfo(res), nil
+}
+
+// InfoFields asks for only some of a container's info. Servers which predate
+// field selection return all of it.
+func (c *connection) InfoFields(handle string, fields []api.InfoField) (api.ContainerInfo, error) {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = string(field)
+	}
+
+	res := &apitypes.InfoResponse{}
+
+	err := c.do(
+		routes.Info,
+		nil,
+		res,
+		rata.Params{"handle": handle},
+		url.Values{"fields": []string{strings.Join(names, ",")}},
+	)
+	if err != nil {
+		return api.ContainerInfo{}, err
+	}
+
+	return containerInfo(res), nil
+}
+
+func containerInfo(res *apitypes.InfoResponse) api.ContainerInfo {
 	processIDs := []uint32{}
 	for _, pid := range res.GetProcessIds() {
 		processIDs = append(processIDs, uint32(pid))
@@ -748,20 +2241,12 @@ func (c *connection) Info(handle string) (api.ContainerInfo, error) {
 		properties[prop.GetKey()] = prop.GetValue()
 	}
 
-	mappedPorts := []api.PortMapping{}
-	for _, mapping := range res.GetMappedPorts() {
-		mappedPorts = append(mappedPorts, api.PortMapping{
-			HostPort:      mapping.GetHostPort(),
-			ContainerPort: mapping.GetContainerPort(),
-		})
-	}
-
 	bandwidthStat := res.GetBandwidthStat()
 	cpuStat := res.GetCpuStat()
 	diskStat := res.GetDiskStat()
 	memoryStat := res.GetMemoryStat()
 
-	return api.ContainerInfo{
+	info := api.ContainerInfo{
 		State:  res.GetState(),
 		Events: res.GetEvents(),
 
@@ -769,6 +2254,9 @@ func (c *connection) Info(handle string) (api.ContainerInfo, error) {
 		ContainerIP: res.GetContainerIp(),
 		ExternalIP:  res.GetExternalIp(),
 
+		ExternalInterface: res.GetExternalInterface(),
+		TimeToGraceExpiry: time.Duration(res.GetTimeToGraceExpiry()) * time.Millisecond,
+
 		ContainerPath: res.GetContainerPath(),
 
 		ProcessIDs: processIDs,
@@ -824,19 +2312,75 @@ func (c *connection) Info(handle string) (api.ContainerInfo, error) {
 			TotalUnevictable:        memoryStat.GetTotalUnevictable(),
 		},
 
-		MappedPorts: mappedPorts,
-	}, nil
+		MappedPorts: portMappings(res.GetMappedPorts()),
+
+		Held:      res.GetHeld(),
+		GraceTime: time.Duration(res.GetGraceTime()) * time.Second,
+	}
+
+	if res.CreatedAt != nil {
+		info.CreatedAt = time.Unix(res.GetCreatedAt(), 0)
+	}
+
+	if res.LastActivityAt != nil {
+		info.LastActivityAt = time.Unix(res.GetLastActivityAt(), 0)
+	}
+
+	info.Limits = containerLimits(res.GetLimits())
+
+	return info
+}
+
+func containerLimits(res *apitypes.InfoResponse_Limits) api.ContainerLimits {
+	var limits api.ContainerLimits
+
+	if bandwidth := res.GetBandwidth(); bandwidth != nil {
+		limits.Bandwidth = &api.BandwidthLimits{
+			RateInBytesPerSecond:      bandwidth.GetRate(),
+			BurstRateInBytesPerSecond: bandwidth.GetBurst(),
+		}
+	}
+
+	if cpu := res.GetCpu(); cpu != nil {
+		limits.CPU = &api.CPULimits{
+			LimitInShares: cpu.GetLimitInShares(),
+			Quota:         cpu.GetQuota(),
+			Period:        cpu.GetPeriod(),
+			Cpuset:        cpu.GetCpuset(),
+		}
+	}
+
+	if disk := res.GetDisk(); disk != nil {
+		limits.Disk = &api.DiskLimits{
+			BlockSoft: disk.GetBlockSoft(),
+			BlockHard: disk.GetBlockHard(),
+			InodeSoft: disk.GetInodeSoft(),
+			InodeHard: disk.GetInodeHard(),
+			ByteSoft:  disk.GetByteSoft(),
+			ByteHard:  disk.GetByteHard(),
+		}
+	}
+
+	if memory := res.GetMemory(); memory != nil {
+		limits.Memory = &api.MemoryLimits{
+			LimitInBytes: memory.GetLimitInBytes(),
+		}
+	}
+
+	return limits
 }
 
-func convertEnvironmentVariables(environmentVariables []string) []*protocol.EnvironmentVariable {
-	convertedEnvironmentVariables := []*protocol.EnvironmentVariable{}
+// convertEnvironmentVariables splits each KEY=VALUE variable at its first =,
+// so that values may contain more. The variables must have been validated.
+func convertEnvironmentVariables(environmentVariables []string) []*apitypes.EnvironmentVariable {
+	convertedEnvironmentVariables := []*apitypes.EnvironmentVariable{}
 
 	for _, env := range environmentVariables {
 		segs := strings.SplitN(env, "=", 2)
 
-		convertedEnvironmentVariable := &protocol.EnvironmentVariable{
-			Key:   proto.String(segs[0]),
-			Value: proto.String(segs[1]),
+		convertedEnvironmentVariable := &apitypes.EnvironmentVariable{
+			Key:   apitypes.String(segs[0]),
+			Value: apitypes.String(segs[1]),
 		}
 
 		convertedEnvironmentVariables = append(
@@ -848,9 +2392,82 @@ func convertEnvironmentVariables(environmentVariables []string) []*protocol.Envi
 	return convertedEnvironmentVariables
 }
 
+// convertLabels sorts the labels by key, so that requests are the same
+// however the map is ordered.
+func convertLabels(labels map[string]string) []*apitypes.Property {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	keys := []string{}
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	converted := []*apitypes.Property{}
+	for _, key := range keys {
+		converted = append(converted, &apitypes.Property{
+			Key:   apitypes.String(key),
+			Value: apitypes.String(labels[key]),
+		})
+	}
+
+	return converted
+}
+
+// discarded asks the server not to stream an output that the caller has no
+// writer for
+// passedFiles takes those of the process's streams which are files from the
+// streams to be copied over the connection, to pass them to the server
+// instead, along with which stream each of them is.
+func passedFiles(processIO api.ProcessIO) ([]apitypes.ProcessPayload_Source, []*os.File, api.ProcessIO) {
+	var passed []apitypes.ProcessPayload_Source
+	var files []*os.File
+
+	if file, ok := processIO.Stdin.(*os.File); ok && file != nil {
+		passed = append(passed, apitypes.ProcessPayload_stdin)
+		files = append(files, file)
+		processIO.Stdin = nil
+	}
+
+	if file, ok := processIO.Stdout.(*os.File); ok && file != nil {
+		passed = append(passed, apitypes.ProcessPayload_stdout)
+		files = append(files, file)
+		processIO.Stdout = nil
+	}
+
+	if file, ok := processIO.Stderr.(*os.File); ok && file != nil {
+		passed = append(passed, apitypes.ProcessPayload_stderr)
+		files = append(files, file)
+		processIO.Stderr = nil
+	}
+
+	return passed, files, processIO
+}
+
+func discarded(w io.Writer) *bool {
+	if w == nil {
+		return apitypes.Bool(true)
+	}
+
+	return nil
+}
+
 func (c *connection) do(
 	handler string,
-	req, res proto.Message,
+	req, res interface{},
+	params rata.Params,
+	query url.Values,
+) error {
+	return c.doWith(c.noKeepaliveClient, handler, req, res, params, query)
+}
+
+func (c *connection) doWith(
+	client *http.Client,
+	handler string,
+	req, res interface{},
 	params rata.Params,
 	query url.Values,
 ) error {
@@ -859,7 +2476,7 @@ func (c *connection) do(
 	if req != nil {
 		buf := new(bytes.Buffer)
 
-		err := transport.WriteMessage(buf, req)
+		err := c.codec.Encode(buf, req)
 		if err != nil {
 			return err
 		}
@@ -869,23 +2486,48 @@ func (c *connection) do(
 
 	contentType := ""
 	if req != nil {
-		contentType = "application/json"
+		contentType = c.codec.ContentType()
 	}
 
-	response, err := c.doStream(
-		handler,
-		body,
-		params,
-		query,
-		contentType,
-	)
+	request, err := c.newRequest(handler, body, params, query, contentType)
 	if err != nil {
 		return err
 	}
 
-	defer response.Close()
+	return c.doDecode(client, request, res)
+}
+
+// doDecode sends the request, and decodes its response into res.
+func (c *connection) doDecode(client *http.Client, request *http.Request, res interface{}) error {
+	httpResp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return responseError(httpResp)
+	}
+
+	if c.onWarning != nil {
+		for _, warning := range httpResp.Header[transport.WarningHeader] {
+			c.onWarning(warning)
+		}
+	}
+
+	return c.responseCodec(httpResp).Decode(httpResp.Body, res)
+}
+
+// responseCodec is the codec registered for a response's content type,
+// falling back to the connection's own codec for unlabelled responses.
+func (c *connection) responseCodec(httpResp *http.Response) transport.Codec {
+	codec, err := transport.CodecFor(httpResp.Header.Get("Content-Type"))
+	if err != nil {
+		return c.codec
+	}
 
-	return json.NewDecoder(response).Decode(res)
+	return codec
 }
 
 func (c *connection) doStream(
@@ -895,34 +2537,69 @@ func (c *connection) doStream(
 	query url.Values,
 	contentType string,
 ) (io.ReadCloser, error) {
+	request, err := c.newRequest(handler, body, params, query, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.doRequest(c.streamClient, request)
+}
+
+func (c *connection) newRequest(
+	handler string,
+	body io.Reader,
+	params rata.Params,
+	query url.Values,
+	contentType string,
+) (*http.Request, error) {
 	request, err := c.req.CreateRequest(handler, params, body)
 	if err != nil {
 		return nil, err
 	}
 
+	// servers which predate negotiating codecs respond in JSON regardless
+	request.Header.Set("Accept", c.codec.ContentType())
+
+	for key, values := range c.header {
+		request.Header[key] = values
+	}
+
 	if contentType != "" {
 		request.Header.Set("Content-Type", contentType)
 	}
 
+	if c.confirmDestroys && (handler == routes.Destroy || handler == routes.ScheduleDestroy) {
+		request.Header.Set(transport.DestroyConfirmationHeader, params["handle"])
+	}
+
 	if query != nil {
 		request.URL.RawQuery = query.Encode()
 	}
 
-	httpResp, err := c.noKeepaliveClient.Do(request)
+	return request, nil
+}
+
+func (c *connection) doRequest(client *http.Client, request *http.Request) (io.ReadCloser, error) {
+	httpResp, err := c.doResponse(client, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return httpResp.Body, nil
+}
+
+func (c *connection) doResponse(client *http.Client, request *http.Request) (*http.Response, error) {
+	httpResp, err := client.Do(request)
 	if err != nil {
 		return nil, err
 	}
 
 	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
-		errResponse, err := ioutil.ReadAll(httpResp.Body)
-		httpResp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("bad response: %s", httpResp.Status)
-		}
-		return nil, fmt.Errorf(string(errResponse))
+		defer httpResp.Body.Close()
+		return nil, responseError(httpResp)
 	}
 
-	return httpResp.Body, nil
+	return httpResp, nil
 }
 
 func (c *connection) doHijack(
@@ -931,38 +2608,155 @@ func (c *connection) doHijack(
 	params rata.Params,
 	query url.Values,
 	contentType string,
-) (net.Conn, *bufio.Reader, error) {
-	request, err := c.req.CreateRequest(handler, params, body)
+	files []*os.File,
+) (net.Conn, transport.MessageReader, transport.MessageWriter, error) {
+	request, err := c.newRequest(handler, body, params, query, contentType)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	if contentType != "" {
-		request.Header.Set("Content-Type", contentType)
+		return nil, nil, nil, err
 	}
 
-	if query != nil {
-		request.URL.RawQuery = query.Encode()
-	}
+	request.Header.Set(transport.ProcessStreamFramingHeader, transport.FramingBinary)
 
 	conn, err := c.dialer("tcp", "api") // net/addr don't matter here
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	if len(files) > 0 {
+		unixConn, ok := conn.(*net.UnixConn)
+		if !ok {
+			conn.Close()
+			return nil, nil, nil, transport.ErrFilePassingUnsupported
+		}
+
+		// the files go along with the first of the request, so that the
+		// server has them by the time it handles it
+		conn = transport.NewFileSender(unixConn, files)
 	}
 
 	client := httputil.NewClientConn(conn, nil)
 
+	// hijacked requests are not sent by the clients' transports, so are
+	// hooked here
+	after := c.hooks.before(request)
+
 	httpResp, err := client.Do(request)
 	if err != nil {
-		return nil, nil, err
+		after(err)
+		return nil, nil, nil, err
 	}
 
 	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
-		httpResp.Body.Close()
-		return nil, nil, fmt.Errorf("bad response: %s", httpResp.Status)
+		defer httpResp.Body.Close()
+
+		err := responseError(httpResp)
+		after(err)
+
+		return nil, nil, nil, err
 	}
 
+	after(nil)
+
 	conn, br := client.Hijack()
 
-	return conn, br, nil
+	// older servers don't echo the header, and stream JSON messages
+	if httpResp.Header.Get(transport.ProcessStreamFramingHeader) == transport.FramingBinary {
+		return conn, transport.NewFramedReader(br), transport.NewFramedWriter(conn), nil
+	}
+
+	return conn, transport.NewJSONReader(br), transport.NewJSONWriter(conn), nil
+}
+
+// responseError converts a failed response into an error. Servers reply with
+// an ErrorResponse, which becomes the api package's error for its type, or a
+// *GardenError for any other error; older servers reply with the error
+// message as plain text.
+func responseError(httpResp *http.Response) error {
+	errResponse, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("bad response: %s", httpResp.Status)
+	}
+
+	codec, err := transport.CodecFor(httpResp.Header.Get("Content-Type"))
+	if err != nil {
+		return errors.New(string(errResponse))
+	}
+
+	var res apitypes.ErrorResponse
+	err = codec.Decode(bytes.NewReader(errResponse), &res)
+	if err != nil {
+		return fmt.Errorf("bad response: %s", httpResp.Status)
+	}
+
+	return errorFrom(&res)
+}
+
+// errorFrom returns the error an error response describes, as the api
+// package's error where it is one of them.
+func errorFrom(res *apitypes.ErrorResponse) error {
+	if res.GetType() == apitypes.ErrorTypeDiskQuotaExceeded {
+		return api.DiskQuotaExceededError{
+			ExpectedBytes:  res.GetDiskQuota().GetExpectedBytes(),
+			RemainingBytes: res.GetDiskQuota().GetRemainingBytes(),
+		}
+	}
+
+	if res.GetType() == apitypes.ErrorTypeStdinLimitExceeded {
+		return api.StdinLimitExceededError{
+			LimitInBytes: res.GetStdinLimit(),
+		}
+	}
+
+	if res.GetType() == apitypes.ErrorTypeStreamInLimitExceeded {
+		return api.StreamInLimitExceededError{
+			LimitInBytes:   res.GetStreamInLimit().GetLimitInBytes(),
+			LimitInEntries: res.GetStreamInLimit().GetLimitInEntries(),
+		}
+	}
+
+	if res.GetType() == apitypes.ErrorTypeMessageTooLarge {
+		return api.MessageTooLargeError{
+			LimitInBytes: res.GetMessageSizeLimit(),
+		}
+	}
+
+	if res.GetType() == apitypes.ErrorTypeValidation {
+		return api.ValidationError{
+			Field:  api.SpecField(res.GetValidation().GetField()),
+			Reason: res.GetValidation().GetReason(),
+		}
+	}
+
+	if res.GetType() == apitypes.ErrorTypeInvalidRequest {
+		violations := []api.ValidationError{}
+		for _, violation := range res.GetViolations() {
+			violations = append(violations, api.ValidationError{
+				Field:  api.SpecField(violation.GetField()),
+				Reason: violation.GetReason(),
+			})
+		}
+
+		return api.InvalidRequestError{Violations: violations}
+	}
+
+	if typedErr, found := typedErrors[res.GetType()]; found {
+		return typedErr
+	}
+
+	var annotations map[string]string
+	if len(res.GetAnnotations()) > 0 {
+		annotations = make(map[string]string)
+		for _, annotation := range res.GetAnnotations() {
+			annotations[annotation.GetKey()] = annotation.GetValue()
+		}
+	}
+
+	return &GardenError{
+		Message:     res.GetMessage(),
+		Data:        res.GetData(),
+		Backtrace:   res.GetBacktrace(),
+		Annotations: annotations,
+		Class:       api.ErrorClass(res.GetClass()),
+		TraceID:     res.GetTraceId(),
+	}
 }
@@ -6,18 +6,20 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"reflect"
 	"time"
 
-	"github.com/gogo/protobuf/proto"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/ghttp"
 
 	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/client"
 	. "github.com/cloudfoundry-incubator/garden/client/connection"
-	protocol "github.com/cloudfoundry-incubator/garden/protocol"
 	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
@@ -38,21 +40,21 @@ var _ = Describe("Connection", func() {
 
 	BeforeEach(func() {
 		rlimits := &api.ResourceLimits{
-			As:         proto.Uint64(1),
-			Core:       proto.Uint64(2),
-			Cpu:        proto.Uint64(4),
-			Data:       proto.Uint64(5),
-			Fsize:      proto.Uint64(6),
-			Locks:      proto.Uint64(7),
-			Memlock:    proto.Uint64(8),
-			Msgqueue:   proto.Uint64(9),
-			Nice:       proto.Uint64(10),
-			Nofile:     proto.Uint64(11),
-			Nproc:      proto.Uint64(12),
-			Rss:        proto.Uint64(13),
-			Rtprio:     proto.Uint64(14),
-			Sigpending: proto.Uint64(15),
-			Stack:      proto.Uint64(16),
+			As:         apitypes.Uint64(1),
+			Core:       apitypes.Uint64(2),
+			Cpu:        apitypes.Uint64(4),
+			Data:       apitypes.Uint64(5),
+			Fsize:      apitypes.Uint64(6),
+			Locks:      apitypes.Uint64(7),
+			Memlock:    apitypes.Uint64(8),
+			Msgqueue:   apitypes.Uint64(9),
+			Nice:       apitypes.Uint64(10),
+			Nofile:     apitypes.Uint64(11),
+			Nproc:      apitypes.Uint64(12),
+			Rss:        apitypes.Uint64(13),
+			Rtprio:     apitypes.Uint64(14),
+			Sigpending: apitypes.Uint64(15),
+			Stack:      apitypes.Uint64(16),
 		}
 
 		resourceLimits = api.ResourceLimits{
@@ -74,763 +76,2878 @@ var _ = Describe("Connection", func() {
 		}
 	})
 
-	Describe("Ping", func() {
-		Context("when the response is successful", func() {
-			BeforeEach(func() {
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/ping"),
-						ghttp.RespondWith(200, marshalProto(&protocol.PingResponse{})),
-					),
-				)
-			})
-
-			It("should return the server's capacity", func() {
-				err := connection.Ping()
-				Ω(err).ShouldNot(HaveOccurred())
+	Describe("Custom headers", func() {
+		JustBeforeEach(func() {
+			connection = NewWithHeader("tcp", server.HTTPTestServer.Listener.Addr().String(), http.Header{
+				"Authorization": []string{"Bearer some-token"},
 			})
 		})
 
-		Context("when the request fails", func() {
-			BeforeEach(func() {
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/ping"),
-						ghttp.RespondWith(500, ""),
-					),
-				)
-			})
-
-			It("should return an error", func() {
-				err := connection.Ping()
-				Ω(err).Should(HaveOccurred())
-			})
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ping"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": []string{"Bearer some-token"},
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
+				),
+			)
 		})
-	})
 
-	Describe("Getting capacity", func() {
-		Context("when the response is successful", func() {
-			BeforeEach(func() {
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/capacity"),
-						ghttp.RespondWith(200, marshalProto(&protocol.CapacityResponse{
-							MemoryInBytes: proto.Uint64(1111),
-							DiskInBytes:   proto.Uint64(2222),
-							MaxContainers: proto.Uint64(42),
-						}))))
-			})
-
-			It("should return the server's capacity", func() {
-				capacity, err := connection.Capacity()
-				Ω(err).ShouldNot(HaveOccurred())
-
-				Ω(capacity.MemoryInBytes).Should(BeNumerically("==", 1111))
-				Ω(capacity.DiskInBytes).Should(BeNumerically("==", 2222))
-				Ω(capacity.MaxContainers).Should(BeNumerically("==", 42))
-			})
+		It("sends them with every request", func() {
+			err := connection.Ping()
+			Ω(err).ShouldNot(HaveOccurred())
 		})
+	})
 
-		Context("when the request fails", func() {
-			BeforeEach(func() {
-				server.AppendHandlers(
-					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/capacity"),
-						ghttp.RespondWith(500, "")))
-			})
-
-			It("should return an error", func() {
-				_, err := connection.Capacity()
-				Ω(err).Should(HaveOccurred())
+	Describe("Configured codec", func() {
+		JustBeforeEach(func() {
+			connection = NewWithConfig("tcp", server.HTTPTestServer.Listener.Addr().String(), Config{
+				Codec: relabelledCodec{transport.JSON, "application/x-garden-test"},
 			})
 		})
-	})
 
-	Describe("Creating", func() {
 		BeforeEach(func() {
-			ro := protocol.CreateRequest_BindMount_RO
-			rw := protocol.CreateRequest_BindMount_RW
-			hostOrigin := protocol.CreateRequest_BindMount_Host
-			containerOrigin := protocol.CreateRequest_BindMount_Container
-
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("POST", "/containers"),
-					verifyProtoBody(&protocol.CreateRequest{
-						Handle:     proto.String("some-handle"),
-						GraceTime:  proto.Uint32(10),
-						Rootfs:     proto.String("some-rootfs-path"),
-						Network:    proto.String("some-network"),
-						Privileged: proto.Bool(false),
-						BindMounts: []*protocol.CreateRequest_BindMount{
-							{
-								SrcPath: proto.String("/src-a"),
-								DstPath: proto.String("/dst-a"),
-								Mode:    &ro,
-								Origin:  &hostOrigin,
-							},
-							{
-								SrcPath: proto.String("/src-b"),
-								DstPath: proto.String("/dst-b"),
-								Mode:    &rw,
-								Origin:  &containerOrigin,
-							},
-						},
-						Properties: []*protocol.Property{
-							{
-								Key:   proto.String("foo"),
-								Value: proto.String("bar"),
-							},
-						},
-						Env: []*protocol.EnvironmentVariable{
-							{
-								Key:   proto.String("env1"),
-								Value: proto.String("env1Value1"),
-							},
-						},
+					ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+					ghttp.VerifyHeader(http.Header{
+						"Content-Type": []string{"application/x-garden-test"},
+						"Accept":       []string{"application/x-garden-test"},
 					}),
-					ghttp.RespondWith(200, marshalProto(&protocol.CreateResponse{
-						Handle: proto.String("foohandle"),
-					}))))
+					verifyProtoBody(&apitypes.StopRequest{
+						Handle: apitypes.String("foo"),
+						Kill:   apitypes.Bool(true),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.StopResponse{})),
+				),
+			)
 		})
 
-		It("should create a container", func() {
-			handle, err := connection.Create(api.ContainerSpec{
-				Handle:     "some-handle",
-				GraceTime:  10 * time.Second,
-				RootFSPath: "some-rootfs-path",
-				Network:    "some-network",
-				BindMounts: []api.BindMount{
-					{
-						SrcPath: "/src-a",
-						DstPath: "/dst-a",
-						Mode:    api.BindMountModeRO,
-						Origin:  api.BindMountOriginHost,
-					},
-					{
-						SrcPath: "/src-b",
-						DstPath: "/dst-b",
-						Mode:    api.BindMountModeRW,
-						Origin:  api.BindMountOriginContainer,
-					},
-				},
-				Properties: map[string]string{
-					"foo": "bar",
-				},
-				Env: []string{"env1=env1Value1"},
-			})
-
+		It("encodes requests with it, and asks for responses in it", func() {
+			err := connection.Stop("foo", true)
 			Ω(err).ShouldNot(HaveOccurred())
-			Ω(handle).Should(Equal("foohandle"))
 		})
 	})
 
-	Describe("Destroying", func() {
+	Describe("Warnings", func() {
+		var warnings []string
+
+		JustBeforeEach(func() {
+			warnings = nil
+
+			connection = NewWithConfig("tcp", server.HTTPTestServer.Listener.Addr().String(), Config{
+				OnWarning: func(warning string) {
+					warnings = append(warnings, warning)
+				},
+			})
+		})
+
 		BeforeEach(func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("DELETE", "/containers/foo"),
-					ghttp.RespondWith(200, marshalProto(&protocol.DestroyResponse{}))))
+					ghttp.VerifyRequest("PUT", "/containers/foo/grace-time"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.SetGraceTimeResponse{}), http.Header{
+						transport.WarningHeader: []string{"first warning", "second warning"},
+					}),
+				),
+			)
 		})
 
-		It("should stop the container", func() {
-			err := connection.Destroy("foo")
+		It("are passed to the configured hook", func() {
+			err := connection.SetGraceTime("foo", time.Hour)
 			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(warnings).Should(Equal([]string{"first warning", "second warning"}))
 		})
 	})
 
-	Describe("Stopping", func() {
+	Describe("Hooks", func() {
+		var hookedRoutes []string
+		var errs []error
+
+		JustBeforeEach(func() {
+			hookedRoutes = nil
+			errs = nil
+
+			connection = NewWithConfig("tcp", server.HTTPTestServer.Listener.Addr().String(), Config{
+				Hooks: Hooks{
+					BeforeRequest: func(route string, request *http.Request) {
+						hookedRoutes = append(hookedRoutes, route)
+						request.Header.Set("X-Trace-Id", "some-trace")
+					},
+					AfterRequest: func(route string, err error, duration time.Duration) {
+						errs = append(errs, err)
+					},
+				},
+			})
+		})
+
 		BeforeEach(func() {
 			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ping"),
+					ghttp.VerifyHeaderKV("X-Trace-Id", "some-trace"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
+				),
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
-					verifyProtoBody(&protocol.StopRequest{
-						Handle: proto.String("foo"),
-						Kill:   proto.Bool(true),
-					}),
-					ghttp.RespondWith(200, marshalProto(&protocol.StopResponse{}))))
+					ghttp.RespondWith(500, marshalProto(&apitypes.ErrorResponse{
+						Message: apitypes.String("container not found"),
+						Type:    apitypes.String(apitypes.ErrorTypeContainerNotFound),
+					}), http.Header{"Content-Type": []string{"application/json"}}),
+				),
+			)
 		})
 
-		It("should stop the container", func() {
-			err := connection.Stop("foo", true)
-			Ω(err).ShouldNot(HaveOccurred())
+		It("are called around each request, with its route and the error it failed with", func() {
+			Ω(connection.Ping()).Should(Succeed())
+
+			err := connection.Stop("foo", false)
+			Ω(err).Should(Equal(api.ErrContainerNotFound))
+
+			Ω(hookedRoutes).Should(Equal([]string{"Ping", "Stop"}))
+			Ω(errs).Should(Equal([]error{nil, api.ErrContainerNotFound}))
 		})
 	})
 
-	Describe("Limiting Memory", func() {
-		Describe("setting the memory limit", func() {
+	Describe("Multiplexing", func() {
+		JustBeforeEach(func() {
+			connection = NewWithConfig("tcp", server.HTTPTestServer.Listener.Addr().String(), Config{
+				Multiplex: true,
+			})
+		})
+
+		Context("when the server does not support it", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo/limits/memory"),
-						verifyProtoBody(&protocol.LimitMemoryRequest{
-							Handle:       proto.String("foo"),
-							LimitInBytes: proto.Uint64(42),
-						}),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitMemoryResponse{
-							LimitInBytes: proto.Uint64(40),
-						})),
+						ghttp.VerifyRequest("POST", "/multiplex"),
+						ghttp.RespondWith(404, ""),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
 					),
 				)
 			})
 
-			It("should limit memory", func() {
-				newLimits, err := connection.LimitMemory("foo", api.MemoryLimits{
-					LimitInBytes: 42,
-				})
+			It("dials the server directly", func() {
+				Ω(connection.Ping()).Should(Succeed())
+				Ω(connection.Ping()).Should(Succeed())
 
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(newLimits.LimitInBytes).Should(BeNumerically("==", 40))
+				Ω(server.ReceivedRequests()).Should(HaveLen(3))
 			})
 		})
 
-		Describe("getting the memory limit", func() {
+		Context("when the server refuses it", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo/limits/memory"),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitMemoryResponse{
-							LimitInBytes: proto.Uint64(40),
-						})),
+						ghttp.VerifyRequest("POST", "/multiplex"),
+						ghttp.RespondWith(http.StatusUnauthorized, "bad token"),
 					),
 				)
 			})
 
-			It("gets the memory limit", func() {
-				currentLimits, err := connection.CurrentMemoryLimits("foo")
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(currentLimits.LimitInBytes).Should(BeNumerically("==", 40))
+			It("returns the error", func() {
+				err := connection.Ping()
+				Ω(err).Should(HaveOccurred())
+				Ω(err.Error()).Should(ContainSubstring("bad token"))
 			})
 		})
 	})
 
-	Describe("Limiting CPU", func() {
-		Describe("setting", func() {
+	Describe("Configured timeouts", func() {
+		var config Config
+
+		BeforeEach(func() {
+			config = Config{}
+		})
+
+		JustBeforeEach(func() {
+			connection = NewWithConfig("tcp", server.HTTPTestServer.Listener.Addr().String(), config)
+		})
+
+		slowly := func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		Context("with a request timeout", func() {
 			BeforeEach(func() {
+				config.RequestTimeout = 50 * time.Millisecond
+			})
+
+			It("fails requests that take too long", func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo/limits/cpu"),
-						verifyProtoBody(&protocol.LimitCpuRequest{
-							Handle:        proto.String("foo"),
-							LimitInShares: proto.Uint64(42),
-						}),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitCpuResponse{
-							LimitInShares: proto.Uint64(40),
-						})),
+						ghttp.VerifyRequest("GET", "/ping"),
+						slowly,
+						ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
 					),
 				)
+
+				err := connection.Ping()
+				Ω(err).Should(HaveOccurred())
 			})
 
-			It("should limit CPU", func() {
-				newLimits, err := connection.LimitCPU("foo", api.CPULimits{
-					LimitInShares: 42,
-				})
+			It("does not apply it to streams", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "source=%2Fbar"),
+						slowly,
+						ghttp.RespondWith(200, "hello-world!"),
+					),
+				)
 
+				reader, err := connection.StreamOut("foo-handle", api.StreamOutSpec{Path: "/bar"})
 				Ω(err).ShouldNot(HaveOccurred())
-				Ω(newLimits.LimitInShares).Should(BeNumerically("==", 40))
+
+				defer reader.Close()
+
+				Ω(ioutil.ReadAll(reader)).Should(Equal([]byte("hello-world!")))
 			})
 		})
 
-		Describe("getting", func() {
+		Context("with a stream timeout", func() {
 			BeforeEach(func() {
+				config.StreamTimeout = 50 * time.Millisecond
+			})
+
+			It("fails streams that take too long", func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo/limits/cpu"),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitCpuResponse{
-							LimitInShares: proto.Uint64(40),
-						})),
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "source=%2Fbar"),
+						slowly,
+						ghttp.RespondWith(200, "hello-world!"),
 					),
 				)
+
+				_, err := connection.StreamOut("foo-handle", api.StreamOutSpec{Path: "/bar"})
+				Ω(err).Should(HaveOccurred())
 			})
 
-			It("sends a nil cpu limit request", func() {
-				limits, err := connection.CurrentCPULimits("foo")
+			It("does not apply it to followed logs, which stream for as long as the container runs", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/logs", "follow=true"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.Write([]byte(marshalProto(&apitypes.LogEntry{
+								ProcessId: apitypes.Uint32(42),
+								Data:      apitypes.String("hello"),
+							})))
+
+							w.(http.Flusher).Flush()
+
+							time.Sleep(200 * time.Millisecond)
+
+							w.Write([]byte(marshalProto(&apitypes.LogEntry{
+								ProcessId: apitypes.Uint32(42),
+								Data:      apitypes.String("goodbye"),
+							})))
+						},
+					),
+				)
+
+				stream, err := connection.Logs("foo-handle", 0, true)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(limits.LimitInShares).Should(BeNumerically("==", 40))
+				defer stream.Close()
+
+				entry, err := stream.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(entry.Data).Should(Equal([]byte("hello")))
+
+				entry, err = stream.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(entry.Data).Should(Equal([]byte("goodbye")))
+			})
+
+			It("does not apply it to other requests", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/ping"),
+						slowly,
+						ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
+					),
+				)
+
+				err := connection.Ping()
+				Ω(err).ShouldNot(HaveOccurred())
 			})
 		})
-	})
 
-	Describe("Limiting Bandwidth", func() {
-		Describe("setting", func() {
+		Context("with a header", func() {
 			BeforeEach(func() {
+				config.Header = http.Header{"Authorization": []string{"Bearer some-token"}}
+			})
+
+			It("sends it with every request", func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo/limits/bandwidth"),
-						verifyProtoBody(&protocol.LimitBandwidthRequest{
-							Handle: proto.String("foo"),
-							Rate:   proto.Uint64(42),
-							Burst:  proto.Uint64(43),
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.VerifyHeader(http.Header{
+							"Authorization": []string{"Bearer some-token"},
 						}),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitBandwidthResponse{
-							Rate:  proto.Uint64(1),
-							Burst: proto.Uint64(2),
-						})),
+						ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
 					),
 				)
-			})
-
-			It("should limit Bandwidth", func() {
-				newLimits, err := connection.LimitBandwidth("foo", api.BandwidthLimits{
-					RateInBytesPerSecond:      42,
-					BurstRateInBytesPerSecond: 43,
-				})
 
+				err := connection.Ping()
 				Ω(err).ShouldNot(HaveOccurred())
-				Ω(newLimits.RateInBytesPerSecond).Should(BeNumerically("==", 1))
-				Ω(newLimits.BurstRateInBytesPerSecond).Should(BeNumerically("==", 2))
 			})
 		})
+	})
 
-		Describe("getting", func() {
+	Describe("ProtocolVersion", func() {
+		Context("when the server reports its version", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo/limits/bandwidth"),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitBandwidthResponse{
-							Rate:  proto.Uint64(1),
-							Burst: proto.Uint64(2),
+						ghttp.VerifyRequest("GET", "/version"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.VersionResponse{
+							ProtocolVersion: apitypes.Uint32(42),
 						})),
 					),
 				)
 			})
 
-			It("sends a nil bandwidth limit request", func() {
-				limits, err := connection.CurrentBandwidthLimits("foo")
+			It("returns it", func() {
+				version, err := connection.ProtocolVersion()
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(limits.RateInBytesPerSecond).Should(BeNumerically("==", 1))
-				Ω(limits.BurstRateInBytesPerSecond).Should(BeNumerically("==", 2))
+				Ω(version).Should(Equal(uint32(42)))
 			})
 		})
-	})
 
-	Describe("Limiting Disk", func() {
-		Describe("setting", func() {
+		Context("when the server predates the version endpoint", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo/limits/disk"),
-						verifyProtoBody(&protocol.LimitDiskRequest{
-							Handle: proto.String("foo"),
-
-							BlockSoft: proto.Uint64(42),
-							BlockHard: proto.Uint64(42),
-
-							InodeSoft: proto.Uint64(42),
-							InodeHard: proto.Uint64(42),
-
-							ByteSoft: proto.Uint64(42),
-							ByteHard: proto.Uint64(42),
-						}),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitDiskResponse{
-							BlockSoft: proto.Uint64(3),
-							BlockHard: proto.Uint64(4),
-							InodeSoft: proto.Uint64(7),
-							InodeHard: proto.Uint64(8),
-							ByteSoft:  proto.Uint64(11),
-							ByteHard:  proto.Uint64(12),
-						})),
+						ghttp.VerifyRequest("GET", "/version"),
+						ghttp.RespondWith(404, "404 page not found"),
 					),
 				)
 			})
 
-			It("should limit disk", func() {
-				newLimits, err := connection.LimitDisk("foo", api.DiskLimits{
-					BlockSoft: 42,
-					BlockHard: 42,
-
-					InodeSoft: 42,
-					InodeHard: 42,
-
-					ByteSoft: 42,
-					ByteHard: 42,
-				})
-
+			It("returns version 0", func() {
+				version, err := connection.ProtocolVersion()
 				Ω(err).ShouldNot(HaveOccurred())
-				Ω(newLimits).Should(Equal(api.DiskLimits{
-					BlockSoft: 3,
-					BlockHard: 4,
-					InodeSoft: 7,
-					InodeHard: 8,
-					ByteSoft:  11,
-					ByteHard:  12,
-				}))
+
+				Ω(version).Should(BeZero())
 			})
 		})
 
-		Describe("getting", func() {
+		Context("when the request fails", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo/limits/disk"),
-						ghttp.RespondWith(200, marshalProto(&protocol.LimitDiskResponse{
-							BlockSoft: proto.Uint64(3),
-							BlockHard: proto.Uint64(4),
-							InodeSoft: proto.Uint64(7),
-							InodeHard: proto.Uint64(8),
-							ByteSoft:  proto.Uint64(11),
-							ByteHard:  proto.Uint64(12),
-						})),
+						ghttp.VerifyRequest("GET", "/version"),
+						ghttp.RespondWith(500, ""),
 					),
 				)
 			})
 
-			It("sends a nil disk limit request", func() {
-				limits, err := connection.CurrentDiskLimits("foo")
-				Ω(err).ShouldNot(HaveOccurred())
-
-				Ω(limits).Should(Equal(api.DiskLimits{
-					BlockSoft: 3,
-					BlockHard: 4,
-					InodeSoft: 7,
-					InodeHard: 8,
-					ByteSoft:  11,
-					ByteHard:  12,
-				}))
+			It("should return an error", func() {
+				_, err := connection.ProtocolVersion()
+				Ω(err).Should(HaveOccurred())
 			})
 		})
 	})
 
-	Describe("NetIn", func() {
-		BeforeEach(func() {
-			server.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("POST", "/containers/foo-handle/net/in"),
-					verifyProtoBody(&protocol.NetInRequest{
-						Handle:        proto.String("foo-handle"),
-						HostPort:      proto.Uint32(8080),
-						ContainerPort: proto.Uint32(8081),
-					}),
-					ghttp.RespondWith(200, marshalProto(&protocol.NetInResponse{
-						HostPort:      proto.Uint32(1234),
-						ContainerPort: proto.Uint32(1235),
-					}))))
-		})
-
-		It("should return the allocated ports", func() {
-			hostPort, containerPort, err := connection.NetIn("foo-handle", 8080, 8081)
-			Ω(err).ShouldNot(HaveOccurred())
-			Ω(hostPort).Should(Equal(uint32(1234)))
-			Ω(containerPort).Should(Equal(uint32(1235)))
-		})
-	})
-
-	Describe("NetOut", func() {
-		Context("with port", func() {
+	Describe("ServerVersion", func() {
+		Context("when the server reports its capabilities", func() {
 			BeforeEach(func() {
-				all := protocol.NetOutRequest_ALL
-
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("POST", "/containers/foo-handle/net/out"),
-						verifyProtoBody(&protocol.NetOutRequest{
-							Handle:    proto.String("foo-handle"),
-							Network:   proto.String("foo-network"),
-							Port:      proto.Uint32(42),
-							PortRange: proto.String(""),
-							Protocol:  &all,
-						}),
-						ghttp.RespondWith(200, marshalProto(&protocol.NetOutResponse{}))))
+						ghttp.VerifyRequest("GET", "/version"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.VersionResponse{
+							ProtocolVersion: apitypes.Uint32(42),
+							Capabilities:    []string{"rename", "something-new"},
+						})),
+					),
+				)
 			})
 
-			It("should return the port", func() {
-				err := connection.NetOut("foo-handle", "foo-network", 42, "", api.ProtocolAll)
+			It("returns them", func() {
+				version, err := connection.ServerVersion()
 				Ω(err).ShouldNot(HaveOccurred())
-			})
 
-			It("returns an error if the protocol is unknown", func() {
-				err := connection.NetOut("foo-handle", "foo-network", 42, "", 58)
-				Ω(err).Should(MatchError("invalid protocol"))
+				Ω(version).Should(Equal(api.ServerVersion{
+					ProtocolVersion: 42,
+					Capabilities:    []api.Capability{api.CapabilityRename, "something-new"},
+				}))
 			})
 		})
 
-		Context("with port range", func() {
+		Context("when the server predates the version endpoint", func() {
 			BeforeEach(func() {
-				all := protocol.NetOutRequest_ALL
-
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("POST", "/containers/foo-handle/net/out"),
-						verifyProtoBody(&protocol.NetOutRequest{
-							Handle:    proto.String("foo-handle"),
-							Network:   proto.String("foo-network"),
-							Port:      proto.Uint32(0),
-							PortRange: proto.String("8080:8081"),
-							Protocol:  &all,
-						}),
-						ghttp.RespondWith(200, marshalProto(&protocol.NetOutResponse{}))))
+						ghttp.VerifyRequest("GET", "/version"),
+						ghttp.RespondWith(404, "404 page not found"),
+					),
+				)
 			})
 
-			It("should return the port range", func() {
-				err := connection.NetOut("foo-handle", "foo-network", 0, "8080:8081", api.ProtocolAll)
+			It("returns version 0 without capabilities", func() {
+				version, err := connection.ServerVersion()
 				Ω(err).ShouldNot(HaveOccurred())
-			})
-		})
-
-	})
-
-	Describe("Listing containers", func() {
-		BeforeEach(func() {
-			server.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/containers", "foo=bar"),
-					ghttp.RespondWith(200, marshalProto(&protocol.ListResponse{
-						Handles: []string{"container1", "container2", "container3"},
-					}))))
-		})
-
-		It("should return the list of containers", func() {
-			handles, err := connection.List(map[string]string{"foo": "bar"})
-
-			Ω(err).ShouldNot(HaveOccurred())
-			Ω(handles).Should(Equal([]string{"container1", "container2", "container3"}))
-		})
-	})
-
-	Describe("Getting container info", func() {
-		BeforeEach(func() {
-			server.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/containers/some-handle/info"),
-					ghttp.RespondWith(200, marshalProto(&protocol.InfoResponse{
-						State:         proto.String("chilling out"),
-						Events:        []string{"maxing", "relaxing all cool"},
-						HostIp:        proto.String("host-ip"),
-						ContainerIp:   proto.String("container-ip"),
-						ContainerPath: proto.String("container-path"), ProcessIds: []uint64{1, 2}, Properties: []*protocol.Property{
-							{
-								Key:   proto.String("prop-key"),
-								Value: proto.String("prop-value"),
-							},
-						},
-
-						MemoryStat: &protocol.InfoResponse_MemoryStat{
-							Cache:                   proto.Uint64(1),
-							Rss:                     proto.Uint64(2),
-							MappedFile:              proto.Uint64(3),
-							Pgpgin:                  proto.Uint64(4),
-							Pgpgout:                 proto.Uint64(5),
-							Swap:                    proto.Uint64(6),
-							Pgfault:                 proto.Uint64(7),
-							Pgmajfault:              proto.Uint64(8),
-							InactiveAnon:            proto.Uint64(9),
-							ActiveAnon:              proto.Uint64(10),
-							InactiveFile:            proto.Uint64(11),
-							ActiveFile:              proto.Uint64(12),
-							Unevictable:             proto.Uint64(13),
-							HierarchicalMemoryLimit: proto.Uint64(14),
-							HierarchicalMemswLimit:  proto.Uint64(15),
-							TotalCache:              proto.Uint64(16),
-							TotalRss:                proto.Uint64(17),
-							TotalMappedFile:         proto.Uint64(18),
-							TotalPgpgin:             proto.Uint64(19),
-							TotalPgpgout:            proto.Uint64(20),
-							TotalSwap:               proto.Uint64(21),
-							TotalPgfault:            proto.Uint64(22),
-							TotalPgmajfault:         proto.Uint64(23),
-							TotalInactiveAnon:       proto.Uint64(24),
-							TotalActiveAnon:         proto.Uint64(25),
-							TotalInactiveFile:       proto.Uint64(26),
-							TotalActiveFile:         proto.Uint64(27),
-							TotalUnevictable:        proto.Uint64(28),
-						},
-
-						CpuStat: &protocol.InfoResponse_CpuStat{
-							Usage:  proto.Uint64(1),
-							User:   proto.Uint64(2),
-							System: proto.Uint64(3),
-						},
-
-						DiskStat: &protocol.InfoResponse_DiskStat{
-							BytesUsed:  proto.Uint64(1),
-							InodesUsed: proto.Uint64(2),
-						},
-
-						BandwidthStat: &protocol.InfoResponse_BandwidthStat{
-							InRate:   proto.Uint64(1),
-							InBurst:  proto.Uint64(2),
-							OutRate:  proto.Uint64(3),
-							OutBurst: proto.Uint64(4),
-						},
-
-						MappedPorts: []*protocol.InfoResponse_PortMapping{
-							&protocol.InfoResponse_PortMapping{
-								HostPort:      proto.Uint32(1234),
-								ContainerPort: proto.Uint32(5678),
-							},
-							&protocol.InfoResponse_PortMapping{
-								HostPort:      proto.Uint32(1235),
-								ContainerPort: proto.Uint32(5679),
-							},
-						},
-					}))))
-		})
-
-		It("should return the container's info", func() {
-			info, err := connection.Info("some-handle")
-			Ω(err).ShouldNot(HaveOccurred())
-
-			Ω(info.State).Should(Equal("chilling out"))
-			Ω(info.Events).Should(Equal([]string{"maxing", "relaxing all cool"}))
-			Ω(info.HostIP).Should(Equal("host-ip"))
-			Ω(info.ContainerIP).Should(Equal("container-ip"))
-			Ω(info.ContainerPath).Should(Equal("container-path"))
-			Ω(info.ProcessIDs).Should(Equal([]uint32{1, 2}))
-
-			Ω(info.Properties).Should(Equal(api.Properties{
-				"prop-key": "prop-value",
-			}))
-
-			Ω(info.MemoryStat).Should(Equal(api.ContainerMemoryStat{
-				Cache:                   1,
-				Rss:                     2,
-				MappedFile:              3,
-				Pgpgin:                  4,
-				Pgpgout:                 5,
-				Swap:                    6,
-				Pgfault:                 7,
-				Pgmajfault:              8,
-				InactiveAnon:            9,
-				ActiveAnon:              10,
-				InactiveFile:            11,
-				ActiveFile:              12,
-				Unevictable:             13,
-				HierarchicalMemoryLimit: 14,
-				HierarchicalMemswLimit:  15,
-				TotalCache:              16,
-				TotalRss:                17,
-				TotalMappedFile:         18,
-				TotalPgpgin:             19,
-				TotalPgpgout:            20,
-				TotalSwap:               21,
-				TotalPgfault:            22,
-				TotalPgmajfault:         23,
-				TotalInactiveAnon:       24,
-				TotalActiveAnon:         25,
-				TotalInactiveFile:       26,
-				TotalActiveFile:         27,
-				TotalUnevictable:        28,
-			}))
-
-			Ω(info.CPUStat).Should(Equal(api.ContainerCPUStat{
-				Usage:  1,
-				User:   2,
-				System: 3,
-			}))
-
-			Ω(info.DiskStat).Should(Equal(api.ContainerDiskStat{
-				BytesUsed:  1,
-				InodesUsed: 2,
-			}))
-
-			Ω(info.BandwidthStat).Should(Equal(api.ContainerBandwidthStat{
-				InRate:   1,
-				InBurst:  2,
-				OutRate:  3,
-				OutBurst: 4,
-			}))
 
-			Ω(info.MappedPorts).Should(Equal([]api.PortMapping{
-				{HostPort: 1234, ContainerPort: 5678},
-				{HostPort: 1235, ContainerPort: 5679},
-			}))
+				Ω(version).Should(Equal(api.ServerVersion{}))
+			})
 		})
 	})
 
-	Describe("Streaming in", func() {
-		Context("when streaming in succeeds", func() {
+	Describe("HealthCheck", func() {
+		Context("when the server is healthy", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
-						func(w http.ResponseWriter, r *http.Request) {
-							body, err := ioutil.ReadAll(r.Body)
-							Ω(err).ShouldNot(HaveOccurred())
-
-							Ω(string(body)).Should(Equal("chunk-1chunk-2"))
-						},
+						ghttp.VerifyRequest("GET", "/healthcheck"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.HealthCheckResponse{
+							Healthy:           apitypes.Bool(true),
+							Containers:        apitypes.Uint64(3),
+							MaxContainers:     apitypes.Uint64(10),
+							ContainerHeadroom: apitypes.Uint64(7),
+							Uptime:            apitypes.Uint64(60),
+							ProtocolVersion:   apitypes.Uint32(42),
+						})),
 					),
 				)
 			})
 
-			It("tells api to stream, and then streams the content as a series of chunks", func() {
-				buffer := bytes.NewBufferString("chunk-1chunk-2")
-
-				err := connection.StreamIn("foo-handle", "/bar", buffer)
+			It("returns its status", func() {
+				status, err := connection.HealthCheck()
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+				Ω(status).Should(Equal(api.HealthStatus{
+					Healthy:           true,
+					Containers:        3,
+					MaxContainers:     10,
+					ContainerHeadroom: 7,
+					Uptime:            time.Minute,
+					ProtocolVersion:   42,
+				}))
 			})
 		})
 
-		Context("when streaming in returns an error response", func() {
+		Context("when the server reports it is unhealthy", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
-						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+						ghttp.VerifyRequest("GET", "/healthcheck"),
+						ghttp.RespondWith(503, marshalProto(&apitypes.HealthCheckResponse{
+							Healthy:      apitypes.Bool(false),
+							BackendError: apitypes.String("oh no!"),
+						})),
 					),
 				)
 			})
 
-			It("returns an error on close", func() {
-				buffer := bytes.NewBufferString("chunk-1chunk-2")
-				err := connection.StreamIn("foo-handle", "/bar", buffer)
-				Ω(err).Should(HaveOccurred())
+			It("returns its status without failing", func() {
+				status, err := connection.HealthCheck()
+				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+				Ω(status.Healthy).Should(BeFalse())
+				Ω(status.BackendError).Should(Equal("oh no!"))
 			})
 		})
 
-		Context("when streaming in fails hard", func() {
+		Context("when the request fails", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
-						ghttp.RespondWith(http.StatusInternalServerError, "no."),
-						func(w http.ResponseWriter, r *http.Request) {
-							server.CloseClientConnections()
-						},
+						ghttp.VerifyRequest("GET", "/healthcheck"),
+						ghttp.RespondWith(500, ""),
 					),
 				)
 			})
 
-			It("returns an error on close", func() {
-				buffer := bytes.NewBufferString("chunk-1chunk-2")
-
-				err := connection.StreamIn("foo-handle", "/bar", buffer)
+			It("should return an error", func() {
+				_, err := connection.HealthCheck()
 				Ω(err).Should(HaveOccurred())
-
-				Ω(server.ReceivedRequests()).Should(HaveLen(1))
 			})
 		})
 	})
 
-	Describe("Streaming Out", func() {
-		Context("when streaming succeeds", func() {
+	Describe("Ping", func() {
+		Context("when the response is successful", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
-						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "source=%2Fbar"),
-						ghttp.RespondWith(200, "hello-world!"),
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.PingResponse{})),
 					),
 				)
 			})
 
-			It("asks api for the given file, then reads its content", func() {
-				reader, err := connection.StreamOut("foo-handle", "/bar")
+			It("should return the server's capacity", func() {
+				err := connection.Ping()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when the request fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.RespondWith(500, ""),
+					),
+				)
+			})
+
+			It("should return an error", func() {
+				err := connection.Ping()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Error responses", func() {
+		Context("when the server responds with an ErrorResponse", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(500, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("oh no!"),
+							Annotations: []*apitypes.Property{
+								{Key: apitypes.String("owner"), Value: apitypes.String("some-team")},
+							},
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns a GardenError with the message and annotations", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(Equal(&GardenError{
+					Message: "oh no!",
+					Annotations: map[string]string{
+						"owner": "some-team",
+					},
+				}))
+			})
+		})
+
+		Context("when the server responds with a typed ErrorResponse", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(500, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("container not found"),
+							Type:    apitypes.String(apitypes.ErrorTypeContainerNotFound),
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns the api package's error for the type", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(Equal(api.ErrContainerNotFound))
+			})
+		})
+
+		Context("when the server responds with a validation ErrorResponse", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(500, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("invalid dir: must be a clean path"),
+							Type:    apitypes.String(apitypes.ErrorTypeValidation),
+							Validation: &apitypes.ErrorResponse_Validation{
+								Field:  apitypes.String("dir"),
+								Reason: apitypes.String("must be a clean path"),
+							},
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns a ValidationError naming the field", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldDir,
+					Reason: "must be a clean path",
+				}))
+			})
+		})
+
+		Context("when the server responds with an invalid request ErrorResponse", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(transport.StatusUnprocessableEntity, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("invalid request: invalid handle: must match the handle in the URL"),
+							Type:    apitypes.String(apitypes.ErrorTypeInvalidRequest),
+							Violations: []*apitypes.ErrorResponse_Validation{
+								{
+									Field:  apitypes.String("handle"),
+									Reason: apitypes.String("must match the handle in the URL"),
+								},
+							},
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns an InvalidRequestError listing the violations", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(Equal(api.InvalidRequestError{
+					Violations: []api.ValidationError{
+						{Field: "handle", Reason: "must match the handle in the URL"},
+					},
+				}))
+			})
+		})
+
+		Context("when the server responds with a message too large ErrorResponse", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(http.StatusRequestEntityTooLarge, marshalProto(&apitypes.ErrorResponse{
+							Message:          apitypes.String("message too large: messages may be at most 1024 bytes"),
+							Type:             apitypes.String(apitypes.ErrorTypeMessageTooLarge),
+							MessageSizeLimit: apitypes.Uint64(1024),
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns a MessageTooLargeError", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(Equal(api.MessageTooLargeError{LimitInBytes: 1024}))
+			})
+		})
+
+		Context("when the server responds with an ErrorResponse of an unknown type", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(500, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("something new"),
+							Type:    apitypes.String("SomethingNew"),
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns a GardenError", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(Equal(&GardenError{
+					Message: "something new",
+				}))
+			})
+		})
+
+		Context("when the server responds with a classified ErrorResponse", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(503, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("backend busy"),
+							Class:   apitypes.String("retryable"),
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns a GardenError of the class", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(Equal(&GardenError{
+					Message: "backend busy",
+					Class:   api.ErrorClassRetryable,
+				}))
+
+				Ω(api.ClassOf(err)).Should(Equal(api.ErrorClassRetryable))
+			})
+		})
+
+		Context("when the server responds with plain text", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.RespondWith(500, "oh no!"),
+					),
+				)
+			})
+
+			It("returns the text as the error", func() {
+				err := connection.Stop("foo", false)
+				Ω(err).Should(MatchError("oh no!"))
+			})
+		})
+	})
+
+	Describe("Getting capacity", func() {
+		Context("when the response is successful", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/capacity"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.CapacityResponse{
+							MemoryInBytes: apitypes.Uint64(1111),
+							DiskInBytes:   apitypes.Uint64(2222),
+							MaxContainers: apitypes.Uint64(42),
+						}))))
+			})
+
+			It("should return the server's capacity", func() {
+				capacity, err := connection.Capacity()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(capacity.MemoryInBytes).Should(BeNumerically("==", 1111))
+				Ω(capacity.DiskInBytes).Should(BeNumerically("==", 2222))
+				Ω(capacity.MaxContainers).Should(BeNumerically("==", 42))
+			})
+		})
+
+		Context("when the request fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/capacity"),
+						ghttp.RespondWith(500, "")))
+			})
+
+			It("should return an error", func() {
+				_, err := connection.Capacity()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("when asking for fresh capacity", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/capacity", "fresh=true"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.CapacityResponse{
+							MaxContainers: apitypes.Uint64(42),
+						}))))
+			})
+
+			It("asks the server to bypass its cache", func() {
+				capacity, err := connection.FreshCapacity()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(capacity.MaxContainers).Should(BeNumerically("==", 42))
+			})
+		})
+	})
+
+	Describe("Creating", func() {
+		BeforeEach(func() {
+			ro := apitypes.CreateRequest_BindMount_RO
+			rw := apitypes.CreateRequest_BindMount_RW
+			hostOrigin := apitypes.CreateRequest_BindMount_Host
+			containerOrigin := apitypes.CreateRequest_BindMount_Container
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers"),
+					verifyProtoBody(&apitypes.CreateRequest{
+						Handle:     apitypes.String("some-handle"),
+						GraceTime:  apitypes.Uint32(10),
+						Rootfs:     apitypes.String("some-rootfs-path"),
+						Network:    apitypes.String("some-network"),
+						Privileged: apitypes.Bool(false),
+						BindMounts: []*apitypes.CreateRequest_BindMount{
+							{
+								SrcPath: apitypes.String("/src-a"),
+								DstPath: apitypes.String("/dst-a"),
+								Mode:    &ro,
+								Origin:  &hostOrigin,
+							},
+							{
+								SrcPath: apitypes.String("/src-b"),
+								DstPath: apitypes.String("/dst-b"),
+								Mode:    &rw,
+								Origin:  &containerOrigin,
+							},
+						},
+						Properties: []*apitypes.Property{
+							{
+								Key:   apitypes.String("foo"),
+								Value: apitypes.String("bar"),
+							},
+						},
+						Env: []*apitypes.EnvironmentVariable{
+							{
+								Key:   apitypes.String("env1"),
+								Value: apitypes.String("env1Value1"),
+							},
+						},
+						DefaultProcessUser: apitypes.String("some-user"),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.CreateResponse{
+						Handle: apitypes.String("foohandle"),
+					}))))
+		})
+
+		It("should create a container", func() {
+			handle, info, err := connection.Create(api.ContainerSpec{
+				Handle:     "some-handle",
+				GraceTime:  10 * time.Second,
+				RootFSPath: "some-rootfs-path",
+				Network:    "some-network",
+				BindMounts: []api.BindMount{
+					{
+						SrcPath: "/src-a",
+						DstPath: "/dst-a",
+						Mode:    api.BindMountModeRO,
+						Origin:  api.BindMountOriginHost,
+					},
+					{
+						SrcPath: "/src-b",
+						DstPath: "/dst-b",
+						Mode:    api.BindMountModeRW,
+						Origin:  api.BindMountOriginContainer,
+					},
+				},
+				Properties: map[string]string{
+					"foo": "bar",
+				},
+				Env:                []string{"env1=env1Value1"},
+				DefaultProcessUser: "some-user",
+			})
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handle).Should(Equal("foohandle"))
+			Ω(info).Should(BeNil())
+		})
+	})
+
+	Describe("Creating a container from an image", func() {
+		Context("when the image is in a registry backends pull from", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers"),
+						verifyProtoBody(&apitypes.CreateRequest{
+							Privileged: apitypes.Bool(false),
+							Image: &apitypes.CreateRequest_Image{
+								Uri:      apitypes.String("docker://registry.example.com/some/image:tag"),
+								Username: apitypes.String("some-user"),
+								Password: apitypes.String("some-password"),
+							},
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.CreateResponse{
+							Handle: apitypes.String("foohandle"),
+						}))))
+			})
+
+			It("sends the image and its credentials", func() {
+				handle, _, err := connection.Create(api.ContainerSpec{
+					Image: api.ImageRef{
+						URI:      "docker://registry.example.com/some/image:tag",
+						Username: "some-user",
+						Password: "some-password",
+					},
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(handle).Should(Equal("foohandle"))
+			})
+		})
+
+		Context("when the image's scheme is unknown", func() {
+			It("fails without asking the server", func() {
+				_, _, err := connection.Create(api.ContainerSpec{
+					Image: api.ImageRef{URI: "ftp://example.com/some-image.tar"},
+				})
+
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldImageURI,
+					Reason: "must be a docker:// or oci:// URI",
+				}))
+
+				Ω(server.ReceivedRequests()).Should(BeEmpty())
+			})
+		})
+
+		Context("when a bind mount's path is not absolute", func() {
+			It("fails without asking the server", func() {
+				_, _, err := connection.Create(api.ContainerSpec{
+					BindMounts: []api.BindMount{
+						{SrcPath: "/src", DstPath: "dst"},
+					},
+				})
+
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldBindMountDstPath,
+					Reason: "must be an absolute path",
+				}))
+
+				Ω(server.ReceivedRequests()).Should(BeEmpty())
+			})
+		})
+
+		Context("when a bind mount's path leaves its directory", func() {
+			It("fails without asking the server", func() {
+				_, _, err := connection.Create(api.ContainerSpec{
+					BindMounts: []api.BindMount{
+						{SrcPath: "/src/../etc", DstPath: "/dst"},
+					},
+				})
+
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldBindMountSrcPath,
+					Reason: "must not contain ..",
+				}))
+
+				Ω(server.ReceivedRequests()).Should(BeEmpty())
+			})
+		})
+
+		Context("when a bind mount's mode is unknown", func() {
+			It("fails without asking the server", func() {
+				_, _, err := connection.Create(api.ContainerSpec{
+					BindMounts: []api.BindMount{
+						{SrcPath: "/src", DstPath: "/dst", Mode: 7},
+					},
+				})
+
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldBindMountMode,
+					Reason: "must be RO or RW",
+				}))
+
+				Ω(server.ReceivedRequests()).Should(BeEmpty())
+			})
+		})
+
+		Context("when credentials are given without an image", func() {
+			It("fails without asking the server", func() {
+				_, _, err := connection.Create(api.ContainerSpec{
+					Image: api.ImageRef{Username: "some-user", Password: "some-password"},
+				})
+
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldImageURI,
+					Reason: "must be given with credentials",
+				}))
+
+				Ω(server.ReceivedRequests()).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Creating a container on a server that reports its info", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.CreateResponse{
+						Handle: apitypes.String("foohandle"),
+						Info: &apitypes.InfoResponse{
+							State:       apitypes.String("active"),
+							ContainerIp: apitypes.String("container-ip"),
+							MappedPorts: []*apitypes.InfoResponse_PortMapping{
+								{HostPort: apitypes.Uint32(1234), ContainerPort: apitypes.Uint32(5678)},
+							},
+							GraceTime: apitypes.Uint32(10),
+						},
+					}))))
+		})
+
+		It("should return the container's info", func() {
+			handle, info, err := connection.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handle).Should(Equal("foohandle"))
+
+			Ω(info).ShouldNot(BeNil())
+			Ω(info.State).Should(Equal("active"))
+			Ω(info.ContainerIP).Should(Equal("container-ip"))
+			Ω(info.MappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 1234, ContainerPort: 5678, Protocol: api.ProtocolTCP},
+			}))
+			Ω(info.GraceTime).Should(Equal(10 * time.Second))
+		})
+	})
+
+	Describe("Creating a container on a server which is at capacity", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers"),
+					ghttp.RespondWith(http.StatusInternalServerError, marshalProto(&apitypes.ErrorResponse{
+						Message: apitypes.String("container capacity exceeded"),
+						Type:    apitypes.String(apitypes.ErrorTypeCapacityExceeded),
+					}), http.Header{"Content-Type": []string{"application/json"}}),
+				),
+			)
+		})
+
+		It("returns ErrCapacityExceeded", func() {
+			_, _, err := connection.Create(api.ContainerSpec{})
+			Ω(err).Should(Equal(api.ErrCapacityExceeded))
+		})
+	})
+
+	Describe("Making requests beyond the server's rate limits", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ping"),
+					ghttp.RespondWith(transport.StatusTooManyRequests, marshalProto(&apitypes.ErrorResponse{
+						Message: apitypes.String("rate limit exceeded"),
+						Type:    apitypes.String(apitypes.ErrorTypeRateLimited),
+					}), http.Header{"Content-Type": []string{"application/json"}}),
+				),
+			)
+		})
+
+		It("returns ErrRateLimited", func() {
+			err := connection.Ping()
+			Ω(err).Should(Equal(api.ErrRateLimited))
+		})
+	})
+
+	Describe("Destroying", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/containers/foo"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.DestroyResponse{}))))
+		})
+
+		It("should stop the container", func() {
+			err := connection.Destroy("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Destroying with confirmation", func() {
+		JustBeforeEach(func() {
+			connection = NewWithConfig("tcp", server.HTTPTestServer.Listener.Addr().String(), Config{
+				ConfirmDestroys: true,
+			})
+		})
+
+		Context("when the server confirms it", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/containers/foo"),
+						ghttp.VerifyHeader(http.Header{
+							transport.DestroyConfirmationHeader: []string{"foo"},
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.DestroyResponse{}))),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/scheduled-destroy"),
+						ghttp.VerifyHeader(http.Header{
+							transport.DestroyConfirmationHeader: []string{"foo"},
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.ScheduleDestroyResponse{}))),
+				)
+			})
+
+			It("names the container in the confirmation header of destroys and schedules", func() {
+				err := connection.Destroy("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = connection.DestroyAfter("foo", time.Hour)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when the server refuses the destroy as unconfirmed", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/containers/foo"),
+						ghttp.RespondWith(412, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("destroy not confirmed"),
+							Type:    apitypes.String(apitypes.ErrorTypeDestroyNotConfirmed),
+						}), http.Header{"Content-Type": []string{"application/json"}})))
+			})
+
+			It("returns ErrDestroyNotConfirmed", func() {
+				err := connection.Destroy("foo")
+				Ω(err).Should(Equal(api.ErrDestroyNotConfirmed))
+			})
+		})
+	})
+
+	Describe("Force destroying", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/containers/foo", "force=true"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.DestroyResponse{}))))
+		})
+
+		It("should destroy the container, forcing it", func() {
+			err := connection.ForceDestroy("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Destroying with a reason", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/containers/foo", "force=true&reason=tenant+deleted"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.DestroyResponse{}))))
+		})
+
+		It("should destroy the container, sending the reason", func() {
+			err := connection.DestroyWithReason("foo", true, "tenant deleted")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Destroying asynchronously", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/containers/foo", "async=true&force=true"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.DestroyResponse{
+						OperationId: apitypes.String("some-operation"),
+					}))))
+		})
+
+		It("returns the id of the destroy", func() {
+			id, err := connection.DestroyAsync("foo", true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(id).Should(Equal("some-operation"))
+		})
+	})
+
+	Describe("Getting a destroy operation", func() {
+		Context("when the destroy failed", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/destroys/some-operation"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.DestroyOperationResponse{
+							Id:     apitypes.String("some-operation"),
+							Handle: apitypes.String("foo"),
+							State:  apitypes.String("failed"),
+							Error: &apitypes.ErrorResponse{
+								Message: apitypes.String("container not found"),
+								Type:    apitypes.String(apitypes.ErrorTypeContainerNotFound),
+							},
+						}))))
+			})
+
+			It("returns the operation, with its error", func() {
+				operation, err := connection.DestroyOperation("some-operation")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(operation).Should(Equal(api.DestroyOperation{
+					ID:     "some-operation",
+					Handle: "foo",
+					State:  api.DestroyStateFailed,
+					Error:  api.ErrContainerNotFound,
+				}))
+			})
+		})
+
+		Context("when the server does not know the operation", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/destroys/some-operation"),
+						ghttp.RespondWith(500, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("operation not found"),
+							Type:    apitypes.String(apitypes.ErrorTypeOperationNotFound),
+						}), http.Header{"Content-Type": []string{"application/json"}})))
+			})
+
+			It("returns api.ErrOperationNotFound", func() {
+				_, err := connection.DestroyOperation("some-operation")
+				Ω(err).Should(Equal(api.ErrOperationNotFound))
+			})
+		})
+	})
+
+	Describe("Renaming", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo/rename"),
+					verifyProtoBody(&apitypes.RenameRequest{
+						Handle:    apitypes.String("foo"),
+						NewHandle: apitypes.String("bar"),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.RenameResponse{}))))
+		})
+
+		It("should rename the container", func() {
+			err := connection.Rename("foo", "bar")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Scheduling destruction", func() {
+		Context("at a time", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/scheduled-destroy"),
+						func(w http.ResponseWriter, r *http.Request) {
+							var request apitypes.ScheduleDestroyRequest
+							err := json.NewDecoder(r.Body).Decode(&request)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(request).Should(Equal(apitypes.ScheduleDestroyRequest{
+								Handle: apitypes.String("foo"),
+								At:     apitypes.Int64(1234567890),
+							}))
+						},
+						ghttp.RespondWith(200, marshalProto(&apitypes.ScheduleDestroyResponse{}))))
+			})
+
+			It("should schedule the destruction", func() {
+				err := connection.DestroyAt("foo", time.Unix(1234567890, 0))
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("after a delay", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/scheduled-destroy"),
+						func(w http.ResponseWriter, r *http.Request) {
+							var request apitypes.ScheduleDestroyRequest
+							err := json.NewDecoder(r.Body).Decode(&request)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(request).Should(Equal(apitypes.ScheduleDestroyRequest{
+								Handle: apitypes.String("foo"),
+								After:  apitypes.Uint32(60),
+							}))
+						},
+						ghttp.RespondWith(200, marshalProto(&apitypes.ScheduleDestroyResponse{}))))
+			})
+
+			It("should schedule the destruction", func() {
+				err := connection.DestroyAfter("foo", time.Minute)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Describe("cancelling", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/containers/foo/scheduled-destroy"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.CancelScheduledDestroyResponse{}))))
+			})
+
+			It("should cancel the scheduled destruction", func() {
+				err := connection.CancelScheduledDestroy("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Holding a container", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/hold"),
+					func(w http.ResponseWriter, r *http.Request) {
+						var request apitypes.SetHoldRequest
+						err := json.NewDecoder(r.Body).Decode(&request)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(request).Should(Equal(apitypes.SetHoldRequest{
+							Handle: apitypes.String("foo"),
+							Held:   apitypes.Bool(true),
+						}))
+					},
+					ghttp.RespondWith(200, marshalProto(&apitypes.SetHoldResponse{}))))
+		})
+
+		It("should hold the container", func() {
+			err := connection.SetHold("foo", true)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Snapshotting", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/snapshot"),
+					ghttp.RespondWith(200, "some-snapshot")))
+		})
+
+		It("should stream the container's snapshot", func() {
+			reader, err := connection.Snapshot("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(ioutil.ReadAll(reader)).Should(Equal([]byte("some-snapshot")))
+		})
+	})
+
+	Describe("Restoring", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/restore"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Ω(r.Header.Get("Content-Type")).Should(Equal("application/octet-stream"))
+						Ω(ioutil.ReadAll(r.Body)).Should(Equal([]byte("some-snapshot")))
+					},
+					ghttp.RespondWith(200, marshalProto(&apitypes.RestoreResponse{
+						Handle: apitypes.String("restored-handle"),
+					}))))
+		})
+
+		It("should stream the snapshot and return the restored handle", func() {
+			handle, err := connection.Restore(bytes.NewBufferString("some-snapshot"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handle).Should(Equal("restored-handle"))
+		})
+	})
+
+	Describe("Stopping", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+					verifyProtoBody(&apitypes.StopRequest{
+						Handle: apitypes.String("foo"),
+						Kill:   apitypes.Bool(true),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.StopResponse{}))))
+		})
+
+		It("should stop the container", func() {
+			err := connection.Stop("foo", true)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Stopping with a reason", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+					verifyProtoBody(&apitypes.StopRequest{
+						Handle: apitypes.String("foo"),
+						Kill:   apitypes.Bool(false),
+						Reason: apitypes.String("evacuating the cell"),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.StopResponse{}))))
+		})
+
+		It("should stop the container, sending the reason", func() {
+			err := connection.StopWithReason("foo", false, "evacuating the cell")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Signalling all processes", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/signal"),
+					verifyProtoBody(&apitypes.SignalAllRequest{
+						Handle: apitypes.String("foo"),
+						Signal: apitypes.SignalAllRequest_HUP.Enum(),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.SignalAllResponse{}))))
+		})
+
+		It("should signal the container's processes", func() {
+			err := connection.SignalAll("foo", api.SignalHangup)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Pausing", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/pause"),
+					verifyProtoBody(&apitypes.PauseRequest{
+						Handle: apitypes.String("foo"),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.PauseResponse{}))))
+		})
+
+		It("should pause the container", func() {
+			err := connection.Pause("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Resuming", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/resume"),
+					verifyProtoBody(&apitypes.ResumeRequest{
+						Handle: apitypes.String("foo"),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.ResumeResponse{}))))
+		})
+
+		It("should resume the container", func() {
+			err := connection.Resume("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Setting the grace time", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/grace-time"),
+					verifyProtoBody(&apitypes.SetGraceTimeRequest{
+						Handle:    apitypes.String("foo"),
+						GraceTime: apitypes.Uint32(60),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.SetGraceTimeResponse{}))))
+		})
+
+		It("should set the container's grace time in seconds", func() {
+			err := connection.SetGraceTime("foo", time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Limiting Memory", func() {
+		Describe("setting the memory limit", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits/memory"),
+						verifyProtoBody(&apitypes.LimitMemoryRequest{
+							Handle:       apitypes.String("foo"),
+							LimitInBytes: apitypes.Uint64(42),
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitMemoryResponse{
+							LimitInBytes: apitypes.Uint64(40),
+						})),
+					),
+				)
+			})
+
+			It("should limit memory", func() {
+				newLimits, err := connection.LimitMemory("foo", api.MemoryLimits{
+					LimitInBytes: 42,
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(newLimits.LimitInBytes).Should(BeNumerically("==", 40))
+			})
+		})
+
+		Describe("getting the memory limit", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/memory"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitMemoryResponse{
+							LimitInBytes: apitypes.Uint64(40),
+						})),
+					),
+				)
+			})
+
+			It("gets the memory limit", func() {
+				currentLimits, err := connection.CurrentMemoryLimits("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(currentLimits.LimitInBytes).Should(BeNumerically("==", 40))
+			})
+		})
+	})
+
+	Describe("Limiting CPU", func() {
+		Describe("setting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits/cpu"),
+						verifyProtoBody(&apitypes.LimitCpuRequest{
+							Handle:        apitypes.String("foo"),
+							LimitInShares: apitypes.Uint64(42),
+							Quota:         apitypes.Uint64(50000),
+							Period:        apitypes.Uint64(100000),
+							Cpuset:        apitypes.String("0-2"),
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitCpuResponse{
+							LimitInShares: apitypes.Uint64(40),
+							Quota:         apitypes.Uint64(40000),
+							Period:        apitypes.Uint64(100000),
+							Cpuset:        apitypes.String("0-1"),
+						})),
+					),
+				)
+			})
+
+			It("should limit CPU", func() {
+				newLimits, err := connection.LimitCPU("foo", api.CPULimits{
+					LimitInShares: 42,
+					Quota:         50000,
+					Period:        100000,
+					Cpuset:        "0-2",
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(newLimits).Should(Equal(api.CPULimits{
+					LimitInShares: 40,
+					Quota:         40000,
+					Period:        100000,
+					Cpuset:        "0-1",
+				}))
+			})
+		})
+
+		Describe("getting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/cpu"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitCpuResponse{
+							LimitInShares: apitypes.Uint64(40),
+							Quota:         apitypes.Uint64(40000),
+							Period:        apitypes.Uint64(100000),
+							Cpuset:        apitypes.String("0-1"),
+						})),
+					),
+				)
+			})
+
+			It("sends a nil cpu limit request", func() {
+				limits, err := connection.CurrentCPULimits("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(limits.LimitInShares).Should(BeNumerically("==", 40))
+				Ω(limits.Quota).Should(BeNumerically("==", 40000))
+				Ω(limits.Period).Should(BeNumerically("==", 100000))
+				Ω(limits.Cpuset).Should(Equal("0-1"))
+			})
+		})
+	})
+
+	Describe("Limiting Bandwidth", func() {
+		Describe("setting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits/bandwidth"),
+						verifyProtoBody(&apitypes.LimitBandwidthRequest{
+							Handle: apitypes.String("foo"),
+							Rate:   apitypes.Uint64(42),
+							Burst:  apitypes.Uint64(43),
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitBandwidthResponse{
+							Rate:  apitypes.Uint64(1),
+							Burst: apitypes.Uint64(2),
+						})),
+					),
+				)
+			})
+
+			It("should limit Bandwidth", func() {
+				newLimits, err := connection.LimitBandwidth("foo", api.BandwidthLimits{
+					RateInBytesPerSecond:      42,
+					BurstRateInBytesPerSecond: 43,
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(newLimits.RateInBytesPerSecond).Should(BeNumerically("==", 1))
+				Ω(newLimits.BurstRateInBytesPerSecond).Should(BeNumerically("==", 2))
+			})
+		})
+
+		Describe("getting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/bandwidth"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitBandwidthResponse{
+							Rate:  apitypes.Uint64(1),
+							Burst: apitypes.Uint64(2),
+						})),
+					),
+				)
+			})
+
+			It("sends a nil bandwidth limit request", func() {
+				limits, err := connection.CurrentBandwidthLimits("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(limits.RateInBytesPerSecond).Should(BeNumerically("==", 1))
+				Ω(limits.BurstRateInBytesPerSecond).Should(BeNumerically("==", 2))
+			})
+		})
+	})
+
+	Describe("Limiting Disk", func() {
+		Describe("setting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits/disk"),
+						verifyProtoBody(&apitypes.LimitDiskRequest{
+							Handle: apitypes.String("foo"),
+
+							BlockSoft: apitypes.Uint64(42),
+							BlockHard: apitypes.Uint64(42),
+
+							InodeSoft: apitypes.Uint64(42),
+							InodeHard: apitypes.Uint64(42),
+
+							ByteSoft: apitypes.Uint64(42),
+							ByteHard: apitypes.Uint64(42),
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitDiskResponse{
+							BlockSoft: apitypes.Uint64(3),
+							BlockHard: apitypes.Uint64(4),
+							InodeSoft: apitypes.Uint64(7),
+							InodeHard: apitypes.Uint64(8),
+							ByteSoft:  apitypes.Uint64(11),
+							ByteHard:  apitypes.Uint64(12),
+						})),
+					),
+				)
+			})
+
+			It("should limit disk", func() {
+				newLimits, err := connection.LimitDisk("foo", api.DiskLimits{
+					BlockSoft: 42,
+					BlockHard: 42,
+
+					InodeSoft: 42,
+					InodeHard: 42,
+
+					ByteSoft: 42,
+					ByteHard: 42,
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(newLimits).Should(Equal(api.DiskLimits{
+					BlockSoft: 3,
+					BlockHard: 4,
+					InodeSoft: 7,
+					InodeHard: 8,
+					ByteSoft:  11,
+					ByteHard:  12,
+				}))
+			})
+		})
+
+		Describe("getting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/disk"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.LimitDiskResponse{
+							BlockSoft: apitypes.Uint64(3),
+							BlockHard: apitypes.Uint64(4),
+							InodeSoft: apitypes.Uint64(7),
+							InodeHard: apitypes.Uint64(8),
+							ByteSoft:  apitypes.Uint64(11),
+							ByteHard:  apitypes.Uint64(12),
+						})),
+					),
+				)
+			})
+
+			It("sends a nil disk limit request", func() {
+				limits, err := connection.CurrentDiskLimits("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(limits).Should(Equal(api.DiskLimits{
+					BlockSoft: 3,
+					BlockHard: 4,
+					InodeSoft: 7,
+					InodeHard: 8,
+					ByteSoft:  11,
+					ByteHard:  12,
+				}))
+			})
+		})
+	})
+
+	Describe("DiskUsage", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/disk-usage"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.DiskUsageResponse{
+						RootfsLayers: []*apitypes.DiskUsageResponse_Entry{
+							{
+								Name:       apitypes.String("layer-a"),
+								BytesUsed:  apitypes.Uint64(1),
+								InodesUsed: apitypes.Uint64(2),
+							},
+						},
+						Scratch: &apitypes.DiskUsageResponse_Entry{
+							BytesUsed:  apitypes.Uint64(3),
+							InodesUsed: apitypes.Uint64(4),
+						},
+						BindMounts: []*apitypes.DiskUsageResponse_Entry{
+							{
+								Name:       apitypes.String("/some/dst"),
+								BytesUsed:  apitypes.Uint64(5),
+								InodesUsed: apitypes.Uint64(6),
+							},
+						},
+					})),
+				),
+			)
+		})
+
+		It("returns the usage of each part of the filesystem", func() {
+			usage, err := connection.DiskUsage("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(usage).Should(Equal(api.ContainerDiskUsage{
+				RootFSLayers: []api.DiskUsageEntry{
+					{Name: "layer-a", BytesUsed: 1, InodesUsed: 2},
+				},
+				Scratch: api.DiskUsageEntry{BytesUsed: 3, InodesUsed: 4},
+				BindMounts: []api.DiskUsageEntry{
+					{Name: "/some/dst", BytesUsed: 5, InodesUsed: 6},
+				},
+			}))
+		})
+	})
+
+	Describe("Env", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/env"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.ContainerEnvResponse{
+						Env: []*apitypes.EnvironmentVariable{
+							{Key: apitypes.String("FLAVOR"), Value: apitypes.String("vanilla")},
+							{Key: apitypes.String("EMPTY"), Value: apitypes.String("")},
+						},
+					})),
+				),
+			)
+		})
+
+		It("returns the container's environment", func() {
+			env, err := connection.Env("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(env).Should(Equal([]string{"FLAVOR=vanilla", "EMPTY="}))
+		})
+	})
+
+	Describe("ProcessInfo", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/processes/42/info"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.ProcessInfoResponse{
+						ProcessId: apitypes.Uint32(42),
+						State:     apitypes.String("running"),
+						CpuTime:   apitypes.Uint64(1500000000),
+						Rss:       apitypes.Uint64(4096),
+						StartedAt: apitypes.Int64(1234567890),
+					})),
+				),
+			)
+		})
+
+		It("returns the process's state and resource usage", func() {
+			info, err := connection.ProcessInfo("foo", 42)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info).Should(Equal(api.ProcessInfo{
+				ID:         42,
+				State:      api.ProcessStateRunning,
+				CPUTime:    1500 * time.Millisecond,
+				RSSInBytes: 4096,
+				StartedAt:  time.Unix(1234567890, 0),
+			}))
+		})
+	})
+
+	Describe("FindProcesses", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/process-ids", "role=health-check"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.FindProcessesResponse{
+						ProcessIds: []uint32{42, 43},
+					})),
+				),
+			)
+		})
+
+		It("asks for the processes with the labels", func() {
+			processIDs, err := connection.FindProcesses("foo", map[string]string{"role": "health-check"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(processIDs).Should(Equal([]uint32{42, 43}))
+		})
+	})
+
+	Describe("WaitForProcess", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/process-ids/wait", "id=42&label=role%3Ddaemon&timeout=5000"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.WaitForProcessResponse{
+						ProcessId: apitypes.Uint32(42),
+					})),
+				),
+			)
+		})
+
+		It("waits for a matching process, for up to the timeout", func() {
+			processID, err := connection.WaitForProcess("foo", api.ProcessMatcher{
+				ID:     42,
+				Labels: map[string]string{"role": "daemon"},
+			}, 5*time.Second)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(processID).Should(Equal(uint32(42)))
+		})
+	})
+
+	Describe("Logs", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/logs", "follow=true&tail=10"),
+					ghttp.RespondWith(200, marshalProto(
+						&apitypes.LogEntry{
+							ProcessId: apitypes.Uint32(42),
+							Source:    apitypes.ProcessPayload_stdout.Enum(),
+							Data:      apitypes.String("hello"),
+							Timestamp: apitypes.Int64(1234567890000000000),
+						},
+						&apitypes.LogEntry{
+							ProcessId: apitypes.Uint32(42),
+							Source:    apitypes.ProcessPayload_stderr.Enum(),
+							Data:      apitypes.String("oops"),
+							Timestamp: apitypes.Int64(1234567891000000000),
+						},
+					)),
+				),
+			)
+		})
+
+		It("streams the container's retained output, until the stream ends", func() {
+			stream, err := connection.Logs("foo", 10, true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer stream.Close()
+
+			entry, err := stream.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(entry).Should(Equal(api.LogEntry{
+				ProcessID: 42,
+				Source:    api.LogSourceStdout,
+				Data:      []byte("hello"),
+				Time:      time.Unix(1234567890, 0),
+			}))
+
+			entry, err = stream.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(entry).Should(Equal(api.LogEntry{
+				ProcessID: 42,
+				Source:    api.LogSourceStderr,
+				Data:      []byte("oops"),
+				Time:      time.Unix(1234567891, 0),
+			}))
+
+			_, err = stream.Next()
+			Ω(err).Should(Equal(io.EOF))
+		})
+	})
+
+	Describe("NetIn", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo-handle/net/in"),
+					verifyProtoBody(&apitypes.NetInRequest{
+						Handle:        apitypes.String("foo-handle"),
+						HostPort:      apitypes.Uint32(8080),
+						ContainerPort: apitypes.Uint32(8081),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.NetInResponse{
+						HostPort:      apitypes.Uint32(1234),
+						ContainerPort: apitypes.Uint32(1235),
+					}))))
+		})
+
+		It("should return the allocated ports", func() {
+			hostPort, containerPort, err := connection.NetIn("foo-handle", 8080, 8081)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(hostPort).Should(Equal(uint32(1234)))
+			Ω(containerPort).Should(Equal(uint32(1235)))
+		})
+	})
+
+	Describe("NetInSpec", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo-handle/net/in"),
+					verifyProtoBody(&apitypes.NetInRequest{
+						Handle:        apitypes.String("foo-handle"),
+						HostPort:      apitypes.Uint32(8080),
+						ContainerPort: apitypes.Uint32(8081),
+						Protocol:      apitypes.NetInRequest_UDP.Enum(),
+						HostIp:        apitypes.String("10.0.0.1"),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.NetInResponse{
+						HostPort:      apitypes.Uint32(8080),
+						ContainerPort: apitypes.Uint32(8081),
+					}))))
+		})
+
+		It("sends the protocol and host IP", func() {
+			hostPort, containerPort, err := connection.NetInSpec("foo-handle", api.NetInSpec{
+				HostPort:      8080,
+				ContainerPort: 8081,
+				Protocol:      api.ProtocolUDP,
+				HostIP:        net.ParseIP("10.0.0.1"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(hostPort).Should(Equal(uint32(8080)))
+			Ω(containerPort).Should(Equal(uint32(8081)))
+		})
+
+		It("returns an error if the protocol is unknown", func() {
+			_, _, err := connection.NetInSpec("foo-handle", api.NetInSpec{
+				HostPort: 8080,
+				Protocol: api.ProtocolICMP,
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Getting mapped ports", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/net/in"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.MappedPortsResponse{
+						MappedPorts: []*apitypes.InfoResponse_PortMapping{
+							{HostPort: apitypes.Uint32(1234), ContainerPort: apitypes.Uint32(1235)},
+							{HostPort: apitypes.Uint32(1236), ContainerPort: apitypes.Uint32(1237)},
+							{
+								HostPort:      apitypes.Uint32(1238),
+								ContainerPort: apitypes.Uint32(1239),
+								Protocol:      apitypes.NetInRequest_UDP.Enum(),
+								HostIp:        apitypes.String("10.0.0.1"),
+							},
+						},
+					}))))
+		})
+
+		It("should return the mapped ports, with their protocols and host IPs", func() {
+			mappedPorts, err := connection.MappedPorts("foo-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(mappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 1234, ContainerPort: 1235, Protocol: api.ProtocolTCP},
+				{HostPort: 1236, ContainerPort: 1237, Protocol: api.ProtocolTCP},
+				{HostPort: 1238, ContainerPort: 1239, Protocol: api.ProtocolUDP, HostIP: net.ParseIP("10.0.0.1")},
+			}))
+		})
+	})
+
+	Describe("NetOut", func() {
+		Context("with port", func() {
+			BeforeEach(func() {
+				all := apitypes.NetOutRequest_ALL
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/net/out"),
+						verifyProtoBody(&apitypes.NetOutRequest{
+							Handle:    apitypes.String("foo-handle"),
+							Network:   apitypes.String("foo-network"),
+							Port:      apitypes.Uint32(42),
+							PortRange: apitypes.String(""),
+							Protocol:  &all,
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.NetOutResponse{}))))
+			})
+
+			It("should return the port", func() {
+				err := connection.NetOut("foo-handle", "foo-network", 42, "", api.ProtocolAll)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns an error if the protocol is unknown", func() {
+				err := connection.NetOut("foo-handle", "foo-network", 42, "", 58)
+				Ω(err).Should(MatchError("invalid protocol"))
+			})
+		})
+
+		Context("with port range", func() {
+			BeforeEach(func() {
+				all := apitypes.NetOutRequest_ALL
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/net/out"),
+						verifyProtoBody(&apitypes.NetOutRequest{
+							Handle:    apitypes.String("foo-handle"),
+							Network:   apitypes.String("foo-network"),
+							Port:      apitypes.Uint32(0),
+							PortRange: apitypes.String("8080:8081"),
+							Protocol:  &all,
+						}),
+						ghttp.RespondWith(200, marshalProto(&apitypes.NetOutResponse{}))))
+			})
+
+			It("should return the port range", func() {
+				err := connection.NetOut("foo-handle", "foo-network", 0, "8080:8081", api.ProtocolAll)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+	})
+
+	Describe("NetOutRule", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo-handle/net/out/rules"),
+					verifyProtoBody(&apitypes.NetOutRuleRequest{
+						Handle:   apitypes.String("foo-handle"),
+						Protocol: apitypes.NetOutRuleRequest_UDP.Enum(),
+						Networks: []*apitypes.NetOutRuleRequest_IPRange{
+							{
+								Start: apitypes.String("10.0.0.1"),
+								End:   apitypes.String("10.0.0.9"),
+							},
+						},
+						Ports: []*apitypes.NetOutRuleRequest_PortRange{
+							{
+								Start: apitypes.Uint32(53),
+								End:   apitypes.Uint32(53),
+							},
+						},
+						Log: apitypes.Bool(true),
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.NetOutRuleResponse{}))))
+		})
+
+		It("should send the rule", func() {
+			err := connection.NetOutRule("foo-handle", api.NetOutRule{
+				Protocol: api.ProtocolUDP,
+				Networks: []api.IPRange{
+					{Start: net.ParseIP("10.0.0.1"), End: net.ParseIP("10.0.0.9")},
+				},
+				Ports: []api.PortRange{api.PortRangeFromPort(53)},
+				Log:   true,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns an error if the protocol is unknown", func() {
+			err := connection.NetOutRule("foo-handle", api.NetOutRule{Protocol: 58})
+			Ω(err).Should(MatchError("invalid protocol"))
+		})
+	})
+
+	Describe("Listing containers", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers", "foo=bar"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.ListResponse{
+						Handles: []string{"container1", "container2", "container3"},
+					}))))
+		})
+
+		It("should return the list of containers", func() {
+			handles, err := connection.List(map[string]string{"foo": "bar"})
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handles).Should(Equal([]string{"container1", "container2", "container3"}))
+		})
+	})
+
+	Describe("Listing containers with property filter operators", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers", "env=prod&eq%3Aa%3Ab=c&exists%3Aowner=&ne%3Aowner=team-b&prefix%3Aowner=team-"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.ListResponse{
+						Handles: []string{"container1", "container2"},
+					}))))
+		})
+
+		It("sends each operator prefixed to its property's name", func() {
+			handles, err := connection.ListWhere([]api.PropertyFilter{
+				{Name: "env", Operator: api.PropertyEquals, Value: "prod"},
+				{Name: "a:b", Operator: api.PropertyEquals, Value: "c"},
+				{Name: "owner", Operator: api.PropertyExists},
+				{Name: "owner", Operator: api.PropertyNotEquals, Value: "team-b"},
+				{Name: "owner", Operator: api.PropertyPrefix, Value: "team-"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(handles).Should(Equal([]string{"container1", "container2"}))
+		})
+	})
+
+	Describe("Listing containers as a stream", func() {
+		Context("when the server streams the listing", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers", "foo=bar"),
+						ghttp.VerifyHeaderKV(transport.ListStreamHeader, "true"),
+						ghttp.RespondWith(200, marshalProto(
+							&apitypes.ListResponse_Container{Handle: apitypes.String("container1")},
+							&apitypes.ListResponse_Container{Handle: apitypes.String("container2")},
+						))))
+			})
+
+			It("calls back with each handle", func() {
+				handles := []string{}
+
+				err := connection.ListEach(map[string]string{"foo": "bar"}, func(handle string) error {
+					handles = append(handles, handle)
+					return nil
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(handles).Should(Equal([]string{"container1", "container2"}))
+			})
+
+			It("stops at the first error the callback returns", func() {
+				disaster := errors.New("oh no!")
+
+				calls := 0
+
+				err := connection.ListEach(map[string]string{"foo": "bar"}, func(string) error {
+					calls++
+					return disaster
+				})
+
+				Ω(err).Should(Equal(disaster))
+				Ω(calls).Should(Equal(1))
+			})
+		})
+
+		Context("when the server responds with a whole listing", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers"),
+						ghttp.VerifyHeaderKV(transport.ListStreamHeader, "true"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.ListResponse{
+							Handles: []string{"container1"},
+						}))))
+			})
+
+			It("returns ErrUnsupportedOperation", func() {
+				err := connection.ListEach(nil, func(string) error {
+					return nil
+				})
+
+				Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+			})
+		})
+	})
+
+	Describe("Looking up a container by its properties", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/lookup", "foo=bar"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.LookupResponse{
+						Handle: apitypes.String("container1"),
+					}))))
+		})
+
+		It("should return the matching container's handle", func() {
+			handle, err := connection.LookupBy(map[string]string{"foo": "bar"})
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handle).Should(Equal("container1"))
+		})
+	})
+
+	Describe("Getting the properties of several containers", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/properties"),
+					ghttp.VerifyJSONRepresenting(&apitypes.BulkPropertiesRequest{
+						Handles: []string{"container1", "container2"},
+						Keys:    []string{"foo"},
+					}),
+					ghttp.RespondWith(200, marshalProto(&apitypes.BulkPropertiesResponse{
+						Containers: []*apitypes.BulkPropertiesResponse_Container{
+							{
+								Handle: apitypes.String("container1"),
+								Properties: []*apitypes.Property{
+									{Key: apitypes.String("foo"), Value: apitypes.String("bar")},
+								},
+							},
+							{
+								Handle: apitypes.String("container2"),
+							},
+						},
+					}))))
+		})
+
+		It("should return the properties by handle", func() {
+			containers, err := connection.BulkProperties([]string{"container1", "container2"}, []string{"foo"})
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(Equal(map[string]api.Properties{
+				"container1": {"foo": "bar"},
+				"container2": {},
+			}))
+		})
+	})
+
+	Describe("Listing containers verbosely", func() {
+		Context("when the server returns container summaries", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers", "foo=bar"),
+						ghttp.VerifyHeaderKV(transport.ListVerboseHeader, "true"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.ListResponse{
+							Handles: []string{"container1"},
+							Containers: []*apitypes.ListResponse_Container{
+								{
+									Handle: apitypes.String("container1"),
+									State:  apitypes.String("active"),
+									Properties: []*apitypes.Property{
+										{Key: apitypes.String("foo"), Value: apitypes.String("bar")},
+									},
+								},
+							},
+						}))))
+			})
+
+			It("should return the summaries", func() {
+				summaries, err := connection.ListVerbose(map[string]string{"foo": "bar"}, "")
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(summaries).Should(Equal([]api.ContainerSummary{
+					{
+						Handle:     "container1",
+						State:      "active",
+						Properties: api.Properties{"foo": "bar"},
+					},
+				}))
+			})
+		})
+
+		Context("when the server only returns handles", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers"),
+						ghttp.VerifyHeaderKV(transport.ListVerboseHeader, "true"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.ListResponse{
+							Handles: []string{"container1", "container2"},
+						}))))
+			})
+
+			It("should return summaries with only the handles", func() {
+				summaries, err := connection.ListVerbose(nil, "")
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(summaries).Should(Equal([]api.ContainerSummary{
+					{Handle: "container1"},
+					{Handle: "container2"},
+				}))
+			})
+		})
+
+		Context("when given an order", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers"),
+						ghttp.VerifyHeaderKV(transport.ListVerboseHeader, "true"),
+						ghttp.VerifyHeaderKV(transport.ListSortHeader, "created"),
+						ghttp.RespondWith(200, marshalProto(&apitypes.ListResponse{
+							Handles: []string{"container1"},
+						}))))
+			})
+
+			It("asks for it", func() {
+				_, err := connection.ListVerbose(nil, api.ListOrderCreated)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Getting some of a container's info", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/some-handle/info", "fields=state%2Cmapped_ports"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.InfoResponse{
+						State: apitypes.String("chilling out"),
+						MappedPorts: []*apitypes.InfoResponse_PortMapping{
+							{
+								HostPort:      apitypes.Uint32(1234),
+								ContainerPort: apitypes.Uint32(5678),
+							},
+						},
+					}))))
+		})
+
+		It("asks for only those fields", func() {
+			info, err := connection.InfoFields("some-handle", []api.InfoField{
+				api.InfoFieldState,
+				api.InfoFieldMappedPorts,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info.State).Should(Equal("chilling out"))
+			Ω(info.MappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 1234, ContainerPort: 5678, Protocol: api.ProtocolTCP},
+			}))
+		})
+	})
+
+	Describe("Getting container info", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/some-handle/info"),
+					ghttp.RespondWith(200, marshalProto(&apitypes.InfoResponse{
+						State:         apitypes.String("chilling out"),
+						Events:        []string{"maxing", "relaxing all cool"},
+						HostIp:        apitypes.String("host-ip"),
+						ContainerIp:   apitypes.String("container-ip"),
+						ContainerPath: apitypes.String("container-path"), ProcessIds: []uint64{1, 2}, Properties: []*apitypes.Property{
+							{
+								Key:   apitypes.String("prop-key"),
+								Value: apitypes.String("prop-value"),
+							},
+						},
+
+						MemoryStat: &apitypes.InfoResponse_MemoryStat{
+							Cache:                   apitypes.Uint64(1),
+							Rss:                     apitypes.Uint64(2),
+							MappedFile:              apitypes.Uint64(3),
+							Pgpgin:                  apitypes.Uint64(4),
+							Pgpgout:                 apitypes.Uint64(5),
+							Swap:                    apitypes.Uint64(6),
+							Pgfault:                 apitypes.Uint64(7),
+							Pgmajfault:              apitypes.Uint64(8),
+							InactiveAnon:            apitypes.Uint64(9),
+							ActiveAnon:              apitypes.Uint64(10),
+							InactiveFile:            apitypes.Uint64(11),
+							ActiveFile:              apitypes.Uint64(12),
+							Unevictable:             apitypes.Uint64(13),
+							HierarchicalMemoryLimit: apitypes.Uint64(14),
+							HierarchicalMemswLimit:  apitypes.Uint64(15),
+							TotalCache:              apitypes.Uint64(16),
+							TotalRss:                apitypes.Uint64(17),
+							TotalMappedFile:         apitypes.Uint64(18),
+							TotalPgpgin:             apitypes.Uint64(19),
+							TotalPgpgout:            apitypes.Uint64(20),
+							TotalSwap:               apitypes.Uint64(21),
+							TotalPgfault:            apitypes.Uint64(22),
+							TotalPgmajfault:         apitypes.Uint64(23),
+							TotalInactiveAnon:       apitypes.Uint64(24),
+							TotalActiveAnon:         apitypes.Uint64(25),
+							TotalInactiveFile:       apitypes.Uint64(26),
+							TotalActiveFile:         apitypes.Uint64(27),
+							TotalUnevictable:        apitypes.Uint64(28),
+						},
+
+						CpuStat: &apitypes.InfoResponse_CpuStat{
+							Usage:  apitypes.Uint64(1),
+							User:   apitypes.Uint64(2),
+							System: apitypes.Uint64(3),
+						},
+
+						DiskStat: &apitypes.InfoResponse_DiskStat{
+							BytesUsed:  apitypes.Uint64(1),
+							InodesUsed: apitypes.Uint64(2),
+						},
+
+						BandwidthStat: &apitypes.InfoResponse_BandwidthStat{
+							InRate:   apitypes.Uint64(1),
+							InBurst:  apitypes.Uint64(2),
+							OutRate:  apitypes.Uint64(3),
+							OutBurst: apitypes.Uint64(4),
+						},
+
+						MappedPorts: []*apitypes.InfoResponse_PortMapping{
+							&apitypes.InfoResponse_PortMapping{
+								HostPort:      apitypes.Uint32(1234),
+								ContainerPort: apitypes.Uint32(5678),
+							},
+							&apitypes.InfoResponse_PortMapping{
+								HostPort:      apitypes.Uint32(1235),
+								ContainerPort: apitypes.Uint32(5679),
+							},
+						},
+						Held: apitypes.Bool(true),
+					}))))
+		})
+
+		It("should return the container's info", func() {
+			info, err := connection.Info("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info.State).Should(Equal("chilling out"))
+			Ω(info.Events).Should(Equal([]string{"maxing", "relaxing all cool"}))
+			Ω(info.HostIP).Should(Equal("host-ip"))
+			Ω(info.ContainerIP).Should(Equal("container-ip"))
+			Ω(info.ContainerPath).Should(Equal("container-path"))
+			Ω(info.ProcessIDs).Should(Equal([]uint32{1, 2}))
+
+			Ω(info.Properties).Should(Equal(api.Properties{
+				"prop-key": "prop-value",
+			}))
+
+			Ω(info.MemoryStat).Should(Equal(api.ContainerMemoryStat{
+				Cache:                   1,
+				Rss:                     2,
+				MappedFile:              3,
+				Pgpgin:                  4,
+				Pgpgout:                 5,
+				Swap:                    6,
+				Pgfault:                 7,
+				Pgmajfault:              8,
+				InactiveAnon:            9,
+				ActiveAnon:              10,
+				InactiveFile:            11,
+				ActiveFile:              12,
+				Unevictable:             13,
+				HierarchicalMemoryLimit: 14,
+				HierarchicalMemswLimit:  15,
+				TotalCache:              16,
+				TotalRss:                17,
+				TotalMappedFile:         18,
+				TotalPgpgin:             19,
+				TotalPgpgout:            20,
+				TotalSwap:               21,
+				TotalPgfault:            22,
+				TotalPgmajfault:         23,
+				TotalInactiveAnon:       24,
+				TotalActiveAnon:         25,
+				TotalInactiveFile:       26,
+				TotalActiveFile:         27,
+				TotalUnevictable:        28,
+			}))
+
+			Ω(info.CPUStat).Should(Equal(api.ContainerCPUStat{
+				Usage:  1,
+				User:   2,
+				System: 3,
+			}))
+
+			Ω(info.DiskStat).Should(Equal(api.ContainerDiskStat{
+				BytesUsed:  1,
+				InodesUsed: 2,
+			}))
+
+			Ω(info.BandwidthStat).Should(Equal(api.ContainerBandwidthStat{
+				InRate:   1,
+				InBurst:  2,
+				OutRate:  3,
+				OutBurst: 4,
+			}))
+
+			Ω(info.MappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 1234, ContainerPort: 5678, Protocol: api.ProtocolTCP},
+				{HostPort: 1235, ContainerPort: 5679, Protocol: api.ProtocolTCP},
+			}))
+
+			Ω(info.Held).Should(BeTrue())
+		})
+	})
+
+	Describe("Streaming in", func() {
+		Context("when streaming in succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
+						func(w http.ResponseWriter, r *http.Request) {
+							body, err := ioutil.ReadAll(r.Body)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(string(body)).Should(Equal("chunk-1chunk-2"))
+						},
+					),
+				)
+			})
+
+			It("tells api to stream, and then streams the content as a series of chunks", func() {
+				buffer := bytes.NewBufferString("chunk-1chunk-2")
+
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{Path: "/bar", TarStream: buffer})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("with a user and compression", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "compression=gzip&destination=%2Fbar&user=some-user"),
+						ghttp.RespondWith(200, "{}"),
+					),
+				)
+			})
+
+			It("passes them as query parameters", func() {
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{
+					Path:        "/bar",
+					TarStream:   bytes.NewBufferString("chunk-1chunk-2"),
+					User:        "some-user",
+					Compression: api.CompressionGzip,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when streaming in returns an error response", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+					),
+				)
+			})
+
+			It("returns an error on close", func() {
+				buffer := bytes.NewBufferString("chunk-1chunk-2")
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{Path: "/bar", TarStream: buffer})
+				Ω(err).Should(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when streaming in fails hard", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+						func(w http.ResponseWriter, r *http.Request) {
+							server.CloseClientConnections()
+						},
+					),
+				)
+			})
+
+			It("returns an error on close", func() {
+				buffer := bytes.NewBufferString("chunk-1chunk-2")
+
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{Path: "/bar", TarStream: buffer})
+				Ω(err).Should(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Streaming in atomically", func() {
+		Context("when streaming succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files/atomic", "compression=gzip&destination=%2Fbar&user=some-user"),
+						func(w http.ResponseWriter, r *http.Request) {
+							body, err := ioutil.ReadAll(r.Body)
+							Ω(err).ShouldNot(HaveOccurred())
+							Ω(string(body)).Should(Equal("chunk-1chunk-2"))
+						},
+						ghttp.RespondWith(200, ""),
+					),
+				)
+			})
+
+			It("streams the data to the atomic endpoint, as the user and with the compression asked for", func() {
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{
+					Path:        "/bar",
+					TarStream:   bytes.NewBufferString("chunk-1chunk-2"),
+					User:        "some-user",
+					Compression: api.CompressionGzip,
+					Atomic:      true,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when streaming in returns an error response", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files/atomic", "destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+					),
+				)
+			})
+
+			It("returns an error", func() {
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{
+					Path:      "/bar",
+					TarStream: bytes.NewBufferString("chunk-1chunk-2"),
+					Atomic:    true,
+				})
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Streaming in with an expected size", func() {
+		Context("when streaming succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
+						ghttp.VerifyHeaderKV(transport.ExpectedSizeHeader, "42"),
+						ghttp.RespondWith(200, ""),
+					),
+				)
+			})
+
+			It("tells the server the expected size", func() {
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{
+					Path:         "/bar",
+					TarStream:    bytes.NewBufferString("chunk-1chunk-2"),
+					ExpectedSize: 42,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("when the server rejects it for exceeding the disk quota", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files", "destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInternalServerError, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("disk quota exceeded: expected 42 bytes, but only 7 remain"),
+							Type:    apitypes.String(apitypes.ErrorTypeDiskQuotaExceeded),
+							DiskQuota: &apitypes.ErrorResponse_DiskQuota{
+								ExpectedBytes:  apitypes.Uint64(42),
+								RemainingBytes: apitypes.Uint64(7),
+							},
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns a quota error", func() {
+				err := connection.StreamIn("foo-handle", api.StreamInSpec{
+					Path:         "/bar",
+					TarStream:    bytes.NewBufferString("chunk-1chunk-2"),
+					ExpectedSize: 42,
+				})
+				Ω(err).Should(Equal(api.DiskQuotaExceededError{
+					ExpectedBytes:  42,
+					RemainingBytes: 7,
+				}))
+			})
+		})
+	})
+
+	Describe("Verifying a stream in", func() {
+		Context("when verifying succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files/verification", "destination=%2Fbar"),
+						func(w http.ResponseWriter, r *http.Request) {
+							body, err := ioutil.ReadAll(r.Body)
+							Ω(err).ShouldNot(HaveOccurred())
+							Ω(string(body)).Should(Equal("chunk-1chunk-2"))
+						},
+						ghttp.RespondWith(200, marshalProto(&apitypes.VerifyStreamInResponse{
+							Entries: apitypes.Uint64(3),
+							Bytes:   apitypes.Uint64(42),
+							Problems: []*apitypes.VerifyStreamInResponse_Problem{
+								{
+									Path:   apitypes.String("../escaped"),
+									Reason: apitypes.String("path escapes the destination"),
+								},
+							},
+						})),
+					),
+				)
+			})
+
+			It("returns the report", func() {
+				report, err := connection.VerifyStreamIn("foo-handle", "/bar", bytes.NewBufferString("chunk-1chunk-2"))
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(report).Should(Equal(api.StreamInReport{
+					Entries: 3,
+					Bytes:   42,
+					Problems: []api.StreamInProblem{
+						{Path: "../escaped", Reason: "path escapes the destination"},
+					},
+				}))
+			})
+		})
+
+		Context("when verifying returns an error response", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo-handle/files/verification", "destination=%2Fbar"),
+						ghttp.RespondWith(http.StatusInternalServerError, "no."),
+					),
+				)
+			})
+
+			It("returns an error", func() {
+				_, err := connection.VerifyStreamIn("foo-handle", "/bar", bytes.NewBufferString("chunk-1chunk-2"))
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Streaming Out", func() {
+		Context("when streaming succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "source=%2Fbar"),
+						ghttp.RespondWith(200, "hello-world!"),
+					),
+				)
+			})
+
+			It("asks api for the given file, then reads its content", func() {
+				reader, err := connection.StreamOut("foo-handle", api.StreamOutSpec{Path: "/bar"})
 				Ω(err).ShouldNot(HaveOccurred())
 
 				readBytes, err := ioutil.ReadAll(reader)
 				Ω(err).ShouldNot(HaveOccurred())
-				Ω(readBytes).Should(Equal([]byte("hello-world!")))
+				Ω(readBytes).Should(Equal([]byte("hello-world!")))
+
+				reader.Close()
+			})
+		})
+
+		Context("with a user and compression", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "compression=gzip&source=%2Fbar&user=some-user"),
+						ghttp.RespondWith(200, "compressed"),
+					),
+				)
+			})
+
+			It("passes them as query parameters", func() {
+				reader, err := connection.StreamOut("foo-handle", api.StreamOutSpec{
+					Path:        "/bar",
+					User:        "some-user",
+					Compression: api.CompressionGzip,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				reader.Close()
+
+				Ω(server.ReceivedRequests()).Should(HaveLen(1))
+			})
+		})
+
+		Context("with an offset", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "offset=6&source=%2Fbar"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(200)
+							w.Write([]byte("wor"))
+							w.(http.Flusher).Flush()
+
+							// cut the response short
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							conn.Close()
+						},
+					),
+				)
+			})
+
+			It("resumes the stream from it, failing with the offset reached if the server cuts it short", func() {
+				reader, err := connection.StreamOut("foo-handle", api.StreamOutSpec{
+					Path:   "/bar",
+					Offset: 6,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				readBytes, err := ioutil.ReadAll(reader)
+				Ω(readBytes).Should(Equal([]byte("wor")))
+				Ω(err).Should(Equal(api.StreamOutInterruptedError{
+					Offset:  9,
+					Message: io.ErrUnexpectedEOF.Error(),
+				}))
 
 				reader.Close()
 			})
 		})
 
+		Context("with a cancel channel", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/files", "source=%2Fbar"),
+						ghttp.RespondWith(200, "hello-world!"),
+					),
+				)
+			})
+
+			It("closes the stream once the channel is closed", func() {
+				cancel := make(chan struct{})
+
+				reader, err := connection.StreamOut("foo-handle", api.StreamOutSpec{
+					Path:   "/bar",
+					Cancel: cancel,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				close(cancel)
+
+				Eventually(func() error {
+					_, err := reader.Read(make([]byte, 1024))
+					return err
+				}).Should(And(HaveOccurred(), Not(Equal(io.EOF))))
+
+				Ω(reader.Close()).Should(Succeed())
+			})
+		})
+
 		Context("when streaming fails", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
@@ -844,7 +2961,7 @@ var _ = Describe("Connection", func() {
 			})
 
 			It("asks api for the given file, then reads its content", func() {
-				reader, err := connection.StreamOut("foo-handle", "/bar")
+				reader, err := connection.StreamOut("foo-handle", api.StreamOutSpec{Path: "/bar"})
 				Ω(err).ShouldNot(HaveOccurred())
 
 				_, err = ioutil.ReadAll(reader)
@@ -852,107 +2969,420 @@ var _ = Describe("Connection", func() {
 				Ω(err).Should(HaveOccurred())
 			})
 		})
-	})
+	})
+
+	Describe("Running", func() {
+		stdin := apitypes.ProcessPayload_stdin
+		stdout := apitypes.ProcessPayload_stdout
+		stderr := apitypes.ProcessPayload_stderr
+
+		Context("when streaming succeeds to completion", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						ghttp.VerifyJSONRepresenting(&apitypes.RunRequest{
+							Handle:     apitypes.String("foo-handle"),
+							Path:       apitypes.String("lol"),
+							Args:       []string{"arg1", "arg2"},
+							Dir:        apitypes.String("/some/dir"),
+							Privileged: apitypes.Bool(true),
+							User:       apitypes.String(""),
+							Rlimits: &apitypes.ResourceLimits{
+								As:         apitypes.Uint64(1),
+								Core:       apitypes.Uint64(2),
+								Cpu:        apitypes.Uint64(4),
+								Data:       apitypes.Uint64(5),
+								Fsize:      apitypes.Uint64(6),
+								Locks:      apitypes.Uint64(7),
+								Memlock:    apitypes.Uint64(8),
+								Msgqueue:   apitypes.Uint64(9),
+								Nice:       apitypes.Uint64(10),
+								Nofile:     apitypes.Uint64(11),
+								Nproc:      apitypes.Uint64(12),
+								Rss:        apitypes.Uint64(13),
+								Rtprio:     apitypes.Uint64(14),
+								Sigpending: apitypes.Uint64(15),
+								Stack:      apitypes.Uint64(16),
+							},
+						}),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							decoder := json.NewDecoder(br)
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)})
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stdout, Data: apitypes.String("stdout data")})
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stderr, Data: apitypes.String("stderr data")})
+
+							var payload apitypes.ProcessPayload
+							err = decoder.Decode(&payload)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(payload).Should(Equal(apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
+								Source:    &stdin,
+								Data:      apitypes.String("stdin data"),
+							}))
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
+								Source:    &stdout,
+								Data:      apitypes.String("roundtripped " + payload.GetData()),
+							})
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("streams the data, closes the destinations, and notifies of exit", func() {
+				stdout := gbytes.NewBuffer()
+				stderr := gbytes.NewBuffer()
+
+				process, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path:       "lol",
+					Args:       []string{"arg1", "arg2"},
+					Dir:        "/some/dir",
+					Privileged: true,
+					Limits:     resourceLimits,
+				}, api.ProcessIO{
+					Stdin:  bytes.NewBufferString("stdin data"),
+					Stdout: stdout,
+					Stderr: stderr,
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(process.ID()).Should(Equal(uint32(42)))
+
+				Eventually(stdout).Should(gbytes.Say("stdout data"))
+				Eventually(stdout).Should(gbytes.Say("roundtripped stdin data"))
+				Eventually(stderr).Should(gbytes.Say("stderr data"))
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+			})
+		})
+
+		Context("when the server agrees to binary framing", func() {
+			binaryData := string([]byte{0xff, 0x00, 0xfe, 0x80})
+
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						ghttp.VerifyHeaderKV(transport.ProcessStreamFramingHeader, transport.FramingBinary),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.Header().Set(transport.ProcessStreamFramingHeader, transport.FramingBinary)
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							writer := transport.NewFramedWriter(conn)
+							reader := transport.NewFramedReader(br)
+
+							writer.WriteMessage(&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)})
+
+							var payload apitypes.ProcessPayload
+							err = reader.ReadMessage(&payload)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(payload).Should(Equal(apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
+								Source:    &stdin,
+								Data:      apitypes.String(binaryData),
+							}))
+
+							writer.WriteMessage(&apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
+								Source:    &stdout,
+								Data:      apitypes.String(payload.GetData()),
+							})
+
+							writer.WriteMessage(&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("streams binary data intact", func() {
+				stdout := gbytes.NewBuffer()
+
+				process, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+				}, api.ProcessIO{
+					Stdin:  bytes.NewBufferString(binaryData),
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+
+				Ω(stdout.Contents()).Should(Equal([]byte(binaryData)))
+			})
+		})
+
+		Context("when the server advertises a stdin window", func() {
+			var received chan string
+			var acks chan struct{}
+
+			BeforeEach(func() {
+				received = make(chan string, 10)
+				acks = make(chan struct{})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{
+								ProcessId:   apitypes.Uint32(42),
+								StdinWindow: apitypes.Uint32(2),
+							})
+
+							go func() {
+								for _ = range acks {
+									transport.WriteMessage(conn, &apitypes.ProcessPayload{
+										ProcessId: apitypes.Uint32(42),
+										StdinAck:  apitypes.Uint32(1),
+									})
+								}
+							}()
+
+							decoder := json.NewDecoder(br)
+
+							for {
+								var payload apitypes.ProcessPayload
+								err := decoder.Decode(&payload)
+								if err != nil {
+									return
+								}
+
+								received <- payload.GetData()
+							}
+						},
+					),
+				)
+			})
+
+			AfterEach(func() {
+				close(acks)
+			})
+
+			It("does not send more stdin than the window until the server acknowledges it", func() {
+				stdinR, stdinW := io.Pipe()
+
+				_, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+				}, api.ProcessIO{
+					Stdin: stdinR,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				go func() {
+					for _, chunk := range []string{"a", "b", "c", "d"} {
+						stdinW.Write([]byte(chunk))
+					}
+				}()
+
+				Eventually(received).Should(Receive(Equal("a")))
+				Eventually(received).Should(Receive(Equal("b")))
+				Consistently(received).ShouldNot(Receive())
+
+				acks <- struct{}{}
+
+				Eventually(received).Should(Receive(Equal("c")))
+				Consistently(received).ShouldNot(Receive())
+
+				acks <- struct{}{}
+
+				Eventually(received).Should(Receive(Equal("d")))
+			})
+		})
+
+		Context("when the server cuts off stdin", func() {
+			var received chan string
+			var exit chan struct{}
+
+			BeforeEach(func() {
+				received = make(chan string, 10)
+				exit = make(chan struct{})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{
+								ProcessId:   apitypes.Uint32(42),
+								StdinWindow: apitypes.Uint32(1),
+							})
+
+							cutOff := make(chan struct{})
+
+							go func() {
+								decoder := json.NewDecoder(br)
+
+								// skip the request body, and cut off stdin after
+								// its first payload
+								for {
+									var payload apitypes.ProcessPayload
+									err := decoder.Decode(&payload)
+									if err != nil {
+										return
+									}
+
+									if payload.GetData() == "a" {
+										break
+									}
+								}
+
+								transport.WriteMessage(conn, &apitypes.ProcessPayload{
+									ProcessId: apitypes.Uint32(42),
+									StdinError: &apitypes.ErrorResponse{
+										Message:    apitypes.String("stdin limit exceeded"),
+										Type:       apitypes.String(apitypes.ErrorTypeStdinLimitExceeded),
+										StdinLimit: apitypes.Uint64(1),
+									},
+								})
+
+								close(cutOff)
+
+								for {
+									var payload apitypes.ProcessPayload
+									err := decoder.Decode(&payload)
+									if err != nil {
+										return
+									}
+
+									received <- payload.GetData()
+								}
+							}()
+
+							<-cutOff
+							<-exit
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
+								Source:    &stdout,
+								Data:      apitypes.String("still running"),
+							})
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{
+								ProcessId:  apitypes.Uint32(42),
+								ExitStatus: apitypes.Uint32(3),
+							})
+						},
+					),
+				)
+			})
+
+			It("stops sending stdin, and returns StdinLimitExceededError with the exit status", func() {
+				stdinR, stdinW := io.Pipe()
+				stdout := gbytes.NewBuffer()
+
+				process, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+				}, api.ProcessIO{
+					Stdin:  stdinR,
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = stdinW.Write([]byte("a"))
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = stdinW.Write([]byte("b"))
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Consistently(received).ShouldNot(Receive())
+
+				close(exit)
+
+				status, err := process.Wait()
+				Ω(status).Should(Equal(3))
+				Ω(err).Should(Equal(api.StdinLimitExceededError{LimitInBytes: 1}))
+
+				Ω(stdout).Should(gbytes.Say("still running"))
+			})
+		})
 
-	Describe("Running", func() {
-		stdin := protocol.ProcessPayload_stdin
-		stdout := protocol.ProcessPayload_stdout
-		stderr := protocol.ProcessPayload_stderr
+		Context("when waiting for the process with a timeout", func() {
+			var exit chan struct{}
 
-		Context("when streaming succeeds to completion", func() {
 			BeforeEach(func() {
+				exit = make(chan struct{})
+
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
 						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
-						ghttp.VerifyJSONRepresenting(&protocol.RunRequest{
-							Handle:     proto.String("foo-handle"),
-							Path:       proto.String("lol"),
-							Args:       []string{"arg1", "arg2"},
-							Dir:        proto.String("/some/dir"),
-							Privileged: proto.Bool(true),
-							User:       proto.String(""),
-							Rlimits: &protocol.ResourceLimits{
-								As:         proto.Uint64(1),
-								Core:       proto.Uint64(2),
-								Cpu:        proto.Uint64(4),
-								Data:       proto.Uint64(5),
-								Fsize:      proto.Uint64(6),
-								Locks:      proto.Uint64(7),
-								Memlock:    proto.Uint64(8),
-								Msgqueue:   proto.Uint64(9),
-								Nice:       proto.Uint64(10),
-								Nofile:     proto.Uint64(11),
-								Nproc:      proto.Uint64(12),
-								Rss:        proto.Uint64(13),
-								Rtprio:     proto.Uint64(14),
-								Sigpending: proto.Uint64(15),
-								Stack:      proto.Uint64(16),
-							},
-						}),
 						func(w http.ResponseWriter, r *http.Request) {
 							w.WriteHeader(http.StatusOK)
 
-							conn, br, err := w.(http.Hijacker).Hijack()
+							conn, _, err := w.(http.Hijacker).Hijack()
 							Ω(err).ShouldNot(HaveOccurred())
 
 							defer conn.Close()
 
-							decoder := json.NewDecoder(br)
-
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42)})
-
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("stdout data")})
-
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stderr, Data: proto.String("stderr data")})
-
-							var payload protocol.ProcessPayload
-							err = decoder.Decode(&payload)
-							Ω(err).ShouldNot(HaveOccurred())
-
-							Ω(payload).Should(Equal(protocol.ProcessPayload{
-								ProcessId: proto.Uint32(42),
-								Source:    &stdin,
-								Data:      proto.String("stdin data"),
-							}))
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)})
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{
-								ProcessId: proto.Uint32(42),
-								Source:    &stdout,
-								Data:      proto.String("roundtripped " + payload.GetData()),
-							})
+							<-exit
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
 						},
 					),
 				)
 			})
 
-			It("streams the data, closes the destinations, and notifies of exit", func() {
-				stdout := gbytes.NewBuffer()
-				stderr := gbytes.NewBuffer()
+			It("times out if the process has not exited, and reports its status once it has", func() {
+				ran, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+				}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
 
-				process, err := connection.Run("foo-handle", api.ProcessSpec{
-					Path:       "lol",
-					Args:       []string{"arg1", "arg2"},
-					Dir:        "/some/dir",
-					Privileged: true,
-					Limits:     resourceLimits,
-				}, api.ProcessIO{
-					Stdin:  bytes.NewBufferString("stdin data"),
-					Stdout: stdout,
-					Stderr: stderr,
-				})
+				process := ran.(client.Process)
 
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(process.ID()).Should(Equal(uint32(42)))
+				_, err = process.WaitWithTimeout(50 * time.Millisecond)
+				Ω(err).Should(Equal(ErrWaitTimedOut))
 
-				Eventually(stdout).Should(gbytes.Say("stdout data"))
-				Eventually(stdout).Should(gbytes.Say("roundtripped stdin data"))
-				Eventually(stderr).Should(gbytes.Say("stderr data"))
+				exited, _ := process.Exited()
+				Ω(exited).Should(BeFalse())
 
-				status, err := process.Wait()
+				close(exit)
+
+				status, err := process.WaitWithTimeout(time.Second)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(status).Should(Equal(3))
+
+				exited, status = process.Exited()
+				Ω(exited).Should(BeTrue())
+				Ω(status).Should(Equal(3))
 			})
 		})
 
@@ -961,19 +3391,19 @@ var _ = Describe("Connection", func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
 						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
-						ghttp.VerifyJSONRepresenting(&protocol.RunRequest{
-							Handle:     proto.String("foo-handle"),
-							Path:       proto.String("lol"),
+						ghttp.VerifyJSONRepresenting(&apitypes.RunRequest{
+							Handle:     apitypes.String("foo-handle"),
+							Path:       apitypes.String("lol"),
 							Args:       []string{"arg1", "arg2"},
-							Privileged: proto.Bool(false),
-							User:       proto.String(""),
-							Tty: &protocol.TTY{
-								WindowSize: &protocol.TTY_WindowSize{
-									Columns: proto.Uint32(100),
-									Rows:    proto.Uint32(200),
+							Privileged: apitypes.Bool(false),
+							User:       apitypes.String(""),
+							Tty: &apitypes.TTY{
+								WindowSize: &apitypes.TTY_WindowSize{
+									Columns: apitypes.Uint32(100),
+									Rows:    apitypes.Uint32(200),
 								},
 							},
-							Rlimits: &protocol.ResourceLimits{},
+							Rlimits: &apitypes.ResourceLimits{},
 						}),
 						func(w http.ResponseWriter, r *http.Request) {
 							w.WriteHeader(http.StatusOK)
@@ -985,23 +3415,23 @@ var _ = Describe("Connection", func() {
 
 							decoder := json.NewDecoder(br)
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42)})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)})
 
-							var payload protocol.ProcessPayload
+							var payload apitypes.ProcessPayload
 							err = decoder.Decode(&payload)
 							Ω(err).ShouldNot(HaveOccurred())
 
-							Ω(payload).Should(Equal(protocol.ProcessPayload{
-								ProcessId: proto.Uint32(42),
-								Tty: &protocol.TTY{
-									WindowSize: &protocol.TTY_WindowSize{
-										Columns: proto.Uint32(80),
-										Rows:    proto.Uint32(24),
+							Ω(payload).Should(Equal(apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
+								Tty: &apitypes.TTY{
+									WindowSize: &apitypes.TTY_WindowSize{
+										Columns: apitypes.Uint32(80),
+										Rows:    apitypes.Uint32(24),
 									},
 								},
 							}))
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
 						},
 					),
 				)
@@ -1040,6 +3470,155 @@ var _ = Describe("Connection", func() {
 			})
 		})
 
+		Context("when the process's resource limits are changed", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							decoder := json.NewDecoder(br)
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)})
+
+							var payload apitypes.ProcessPayload
+							err = decoder.Decode(&payload)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(payload).Should(Equal(apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
+								Rlimits: &apitypes.ResourceLimits{
+									Memlock: apitypes.Uint64(1024),
+									Nofile:  apitypes.Uint64(4096),
+								},
+							}))
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("sends them to the server", func() {
+				process, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+				}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				memlock := uint64(1024)
+				nofile := uint64(4096)
+
+				err = process.SetRlimits(api.ResourceLimits{
+					Memlock: &memlock,
+					Nofile:  &nofile,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+			})
+		})
+
+		Context("when only some of the process's output is wanted", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						ghttp.VerifyJSONRepresenting(&apitypes.RunRequest{
+							Handle:        apitypes.String("foo-handle"),
+							Path:          apitypes.String("lol"),
+							Privileged:    apitypes.Bool(false),
+							User:          apitypes.String(""),
+							Rlimits:       &apitypes.ResourceLimits{},
+							DiscardStderr: apitypes.Bool(true),
+						}),
+						ghttp.RespondWith(200, marshalProto(
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)},
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(0)})),
+					),
+				)
+			})
+
+			It("asks the server to discard the rest", func() {
+				process, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+				}, api.ProcessIO{
+					Stdout: gbytes.NewBuffer(),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when running as a uid and gid", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						ghttp.VerifyJSONRepresenting(&apitypes.RunRequest{
+							Handle:     apitypes.String("foo-handle"),
+							Path:       apitypes.String("lol"),
+							Privileged: apitypes.Bool(false),
+							User:       apitypes.String(""),
+							Uid:        apitypes.Uint32(1000),
+							Gid:        apitypes.Uint32(2000),
+							Rlimits:    &apitypes.ResourceLimits{},
+						}),
+						ghttp.RespondWith(200, marshalProto(
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)},
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(0)})),
+					),
+				)
+			})
+
+			It("sends them to the server", func() {
+				process, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+					UID:  apitypes.Uint32(1000),
+					GID:  apitypes.Uint32(2000),
+				}, api.ProcessIO{
+					Stdout: gbytes.NewBuffer(),
+					Stderr: gbytes.NewBuffer(),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(0))
+			})
+		})
+
+		Context("when the user is not found", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						ghttp.RespondWith(http.StatusInternalServerError, marshalProto(&apitypes.ErrorResponse{
+							Message: apitypes.String("user not found"),
+							Type:    apitypes.String(apitypes.ErrorTypeUserNotFound),
+						}), http.Header{"Content-Type": []string{"application/json"}}),
+					),
+				)
+			})
+
+			It("returns ErrUserNotFound", func() {
+				_, err := connection.Run("foo-handle", api.ProcessSpec{
+					Path: "lol",
+					User: "nobody-here",
+				}, api.ProcessIO{})
+				Ω(err).Should(Equal(api.ErrUserNotFound))
+			})
+		})
+
 		Context("when the connection breaks before an exit status is received", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
@@ -1053,11 +3632,11 @@ var _ = Describe("Connection", func() {
 
 							defer conn.Close()
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42)})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)})
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("stdout data")})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stdout, Data: apitypes.String("stdout data")})
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stderr, Data: proto.String("stderr data")})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stderr, Data: apitypes.String("stderr data")})
 						},
 					),
 				)
@@ -1085,10 +3664,10 @@ var _ = Describe("Connection", func() {
 					ghttp.CombineHandlers(
 						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
 						ghttp.RespondWith(200, marshalProto(
-							&protocol.ProcessPayload{ProcessId: proto.Uint32(42)},
-							&protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("stdout data")},
-							&protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stderr, Data: proto.String("stderr data")},
-							&protocol.ProcessPayload{ProcessId: proto.Uint32(42), Error: proto.String("oh no!")})),
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42)},
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stdout, Data: apitypes.String("stdout data")},
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stderr, Data: apitypes.String("stderr data")},
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Error: apitypes.String("oh no!")})),
 					),
 				)
 			})
@@ -1112,9 +3691,9 @@ var _ = Describe("Connection", func() {
 	})
 
 	Describe("Attaching", func() {
-		stdin := protocol.ProcessPayload_stdin
-		stdout := protocol.ProcessPayload_stdout
-		stderr := protocol.ProcessPayload_stderr
+		stdin := apitypes.ProcessPayload_stdin
+		stdout := apitypes.ProcessPayload_stdout
+		stderr := apitypes.ProcessPayload_stderr
 
 		Context("when streaming succeeds to completion", func() {
 			BeforeEach(func() {
@@ -1129,27 +3708,27 @@ var _ = Describe("Connection", func() {
 
 							defer conn.Close()
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("stdout data")})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stdout, Data: apitypes.String("stdout data")})
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stderr, Data: proto.String("stderr data")})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stderr, Data: apitypes.String("stderr data")})
 
-							var payload protocol.ProcessPayload
+							var payload apitypes.ProcessPayload
 							err = json.NewDecoder(br).Decode(&payload)
 							Ω(err).ShouldNot(HaveOccurred())
 
-							Ω(payload).Should(Equal(protocol.ProcessPayload{
-								ProcessId: proto.Uint32(42),
+							Ω(payload).Should(Equal(apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
 								Source:    &stdin,
-								Data:      proto.String("stdin data"),
+								Data:      apitypes.String("stdin data"),
 							}))
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{
-								ProcessId: proto.Uint32(42),
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
 								Source:    &stdout,
-								Data:      proto.String("roundtripped " + payload.GetData()),
+								Data:      apitypes.String("roundtripped " + payload.GetData()),
 							})
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
 						},
 					),
 				)
@@ -1194,17 +3773,17 @@ var _ = Describe("Connection", func() {
 							defer conn.Close()
 							decoder := json.NewDecoder(br)
 
-							var payload protocol.ProcessPayload
+							var payload apitypes.ProcessPayload
 							err = decoder.Decode(&payload)
 							Ω(err).ShouldNot(HaveOccurred())
 
-							Ω(payload).Should(Equal(protocol.ProcessPayload{
-								ProcessId: proto.Uint32(42),
+							Ω(payload).Should(Equal(apitypes.ProcessPayload{
+								ProcessId: apitypes.Uint32(42),
 								Source:    &stdin,
-								Data:      proto.String("stdin data"),
+								Data:      apitypes.String("stdin data"),
 							}))
 
-							var payload2 protocol.ProcessPayload
+							var payload2 apitypes.ProcessPayload
 							err = decoder.Decode(&payload2)
 							Ω(err).Should(HaveOccurred())
 
@@ -1233,9 +3812,9 @@ var _ = Describe("Connection", func() {
 					ghttp.CombineHandlers(
 						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
 						ghttp.RespondWith(200, marshalProto(
-							&protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("stdout data")},
-							&protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stderr, Data: proto.String("stderr data")},
-							&protocol.ProcessPayload{ProcessId: proto.Uint32(42), Error: proto.String("oh no!")})),
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stdout, Data: apitypes.String("stdout data")},
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stderr, Data: apitypes.String("stderr data")},
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Error: apitypes.String("oh no!")})),
 					),
 				)
 			})
@@ -1254,6 +3833,34 @@ var _ = Describe("Connection", func() {
 			})
 		})
 
+		Context("when only some of the process's output is wanted", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
+						ghttp.VerifyContentType("application/json"),
+						ghttp.VerifyJSONRepresenting(&apitypes.AttachRequest{
+							Handle:        apitypes.String("foo-handle"),
+							ProcessId:     apitypes.Uint32(42),
+							DiscardStdout: apitypes.Bool(true),
+						}),
+						ghttp.RespondWith(200, marshalProto(
+							&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(0)})),
+					),
+				)
+			})
+
+			It("asks the server to discard the rest", func() {
+				process, err := connection.Attach("foo-handle", 42, api.ProcessIO{
+					Stderr: gbytes.NewBuffer(),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
 		Context("when the connection breaks before an exit status is received", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
@@ -1267,9 +3874,9 @@ var _ = Describe("Connection", func() {
 
 							defer conn.Close()
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("stdout data")})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stdout, Data: apitypes.String("stdout data")})
 
-							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stderr, Data: proto.String("stderr data")})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stderr, Data: apitypes.String("stderr data")})
 						},
 					),
 				)
@@ -1288,16 +3895,208 @@ var _ = Describe("Connection", func() {
 			})
 		})
 	})
+
+	Describe("Reconnecting", func() {
+		stdout := apitypes.ProcessPayload_stdout
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
+					ghttp.VerifyJSONRepresenting(&apitypes.AttachRequest{
+						Handle:         apitypes.String("foo-handle"),
+						ProcessId:      apitypes.Uint32(42),
+						ReconnectToken: apitypes.String("some-token"),
+						StdoutOffset:   apitypes.Uint64(5),
+						StderrOffset:   apitypes.Uint64(3),
+					}),
+					ghttp.RespondWith(200, marshalProto(
+						&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), Source: &stdout, Data: apitypes.String("more")},
+						&apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(0)})),
+				),
+			)
+		})
+
+		It("resumes the process's streams after the output already received", func() {
+			out := gbytes.NewBuffer()
+
+			process, err := connection.Reconnect("foo-handle", 42, api.ReconnectSpec{
+				Token:        "some-token",
+				StdoutOffset: 5,
+				StderrOffset: 3,
+			}, api.ProcessIO{
+				Stdout: out,
+				Stderr: gbytes.NewBuffer(),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(out).Should(gbytes.Say("more"))
+
+			spec, ok := process.(Reconnectable).ReconnectSpec()
+			Ω(ok).Should(BeTrue())
+			Ω(spec).Should(Equal(api.ReconnectSpec{
+				Token:        "some-token",
+				StdoutOffset: 5 + uint64(len("more")),
+				StderrOffset: 3,
+			}))
+		})
+	})
+
+	Describe("Attaching to all processes", func() {
+		var stdin apitypes.ProcessPayload_Source
+		var stdout apitypes.ProcessPayload_Source
+		var stderr apitypes.ProcessPayload_Source
+
+		BeforeEach(func() {
+			stdin = apitypes.ProcessPayload_stdin
+			stdout = apitypes.ProcessPayload_stdout
+			stderr = apitypes.ProcessPayload_stderr
+		})
+
+		Context("when streaming succeeds to completion", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							decoder := json.NewDecoder(br)
+
+							transport.WriteMessage(conn, &apitypes.AttachAllResponse{
+								ProcessIds: []uint32{42, 43},
+							})
+
+							for _, id := range []uint32{42, 43} {
+								transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(id), Source: &stdout, Data: apitypes.String("stdout data")})
+								transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(id), Source: &stderr, Data: apitypes.String("stderr data")})
+							}
+
+							received := map[uint32]string{}
+							for len(received) < 2 {
+								var payload apitypes.ProcessPayload
+								err := decoder.Decode(&payload)
+								Ω(err).ShouldNot(HaveOccurred())
+
+								if payload.GetSource() == stdin && payload.Data != nil {
+									received[payload.GetProcessId()] = payload.GetData()
+								}
+							}
+
+							Ω(received).Should(Equal(map[uint32]string{
+								42: "stdin data",
+								43: "stdin data",
+							}))
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(43), ExitStatus: apitypes.Uint32(4)})
+						},
+					),
+				)
+			})
+
+			It("streams the data and notifies of exit for each process", func() {
+				stdouts := map[uint32]*gbytes.Buffer{}
+				stderrs := map[uint32]*gbytes.Buffer{}
+
+				processes, err := connection.AttachAll("foo-handle", func(processID uint32) api.ProcessIO {
+					stdouts[processID] = gbytes.NewBuffer()
+					stderrs[processID] = gbytes.NewBuffer()
+
+					return api.ProcessIO{
+						Stdin:  bytes.NewBufferString("stdin data"),
+						Stdout: stdouts[processID],
+						Stderr: stderrs[processID],
+					}
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processes).Should(HaveLen(2))
+
+				Ω(processes[0].ID()).Should(Equal(uint32(42)))
+				Ω(processes[1].ID()).Should(Equal(uint32(43)))
+
+				for _, id := range []uint32{42, 43} {
+					Eventually(stdouts[id]).Should(gbytes.Say("stdout data"))
+					Eventually(stderrs[id]).Should(gbytes.Say("stderr data"))
+				}
+
+				status, err := processes[0].Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+
+				status, err = processes[1].Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(4))
+			})
+		})
+
+		Context("when the connection breaks before an exit status is received", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							transport.WriteMessage(conn, &apitypes.AttachAllResponse{
+								ProcessIds: []uint32{42, 43},
+							})
+
+							transport.WriteMessage(conn, &apitypes.ProcessPayload{ProcessId: apitypes.Uint32(42), ExitStatus: apitypes.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("returns an error when waiting on the remaining processes", func() {
+				processes, err := connection.AttachAll("foo-handle", func(uint32) api.ProcessIO {
+					return api.ProcessIO{}
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processes).Should(HaveLen(2))
+
+				status, err := processes[0].Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+
+				_, err = processes[1].Wait()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
 })
 
-func verifyProtoBody(expectedBodyMessages ...proto.Message) http.HandlerFunc {
+type relabelledCodec struct {
+	transport.Codec
+
+	contentType string
+}
+
+func (c relabelledCodec) ContentType() string {
+	return c.contentType
+}
+
+func verifyProtoBody(expectedBodyMessages ...interface{}) http.HandlerFunc {
 	return func(resp http.ResponseWriter, req *http.Request) {
 		defer GinkgoRecover()
 
 		decoder := json.NewDecoder(req.Body)
 
 		for _, msg := range expectedBodyMessages {
-			received := protocol.RequestMessageForType(protocol.TypeForMessage(msg))
+			received := reflect.New(reflect.TypeOf(msg).Elem()).Interface()
 
 			err := decoder.Decode(received)
 			Ω(err).ShouldNot(HaveOccurred())
@@ -1307,7 +4106,7 @@ func verifyProtoBody(expectedBodyMessages ...proto.Message) http.HandlerFunc {
 	}
 }
 
-func marshalProto(messages ...proto.Message) string {
+func marshalProto(messages ...interface{}) string {
 	result := new(bytes.Buffer)
 	for _, msg := range messages {
 		err := transport.WriteMessage(result, msg)
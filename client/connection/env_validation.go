@@ -0,0 +1,38 @@
+package connection
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// validateEnv checks that each environment variable is a KEY=VALUE pair with
+// a key, before the server is asked to run or create anything with them.
+// Values may be empty, and may contain = or newlines.
+func validateEnv(env []string) error {
+	for _, variable := range env {
+		eq := strings.Index(variable, "=")
+
+		switch {
+		case eq == -1:
+			return api.ValidationError{
+				Field:  api.SpecFieldEnv,
+				Reason: "must be KEY=VALUE pairs",
+			}
+
+		case eq == 0:
+			return api.ValidationError{
+				Field:  api.SpecFieldEnv,
+				Reason: "must not have empty keys",
+			}
+
+		case strings.ContainsRune(variable, 0):
+			return api.ValidationError{
+				Field:  api.SpecFieldEnv,
+				Reason: "must not contain NUL bytes",
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,1157 @@
+// Package fake_connection provides a connection.Connection which keeps its
+// containers and processes in memory, so that code using the client can be
+// tested without running a server. Unlike the counterfeiter fakes, it behaves
+// like a server does: containers must be created before they are used,
+// properties filter lists, and processes stream their output to whoever runs
+// or attaches to them.
+package fake_connection
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// ProcessScript plays the part of a process run in a container. Its io is the
+// process's: stdin is what the clients running and attaching to it write, and
+// what it writes to stdout and stderr is streamed to them. The process exits
+// with the status returned once the script returns.
+type ProcessScript func(handle string, spec api.ProcessSpec, io api.ProcessIO) (int, error)
+
+// Container is the state of a fake container. Tests may inspect and change it
+// with Container and UpdateContainer.
+type Container struct {
+	Handle string
+	Spec   api.ContainerSpec
+	Info   api.ContainerInfo
+
+	BandwidthLimits api.BandwidthLimits
+	CPULimits       api.CPULimits
+	DiskLimits      api.DiskLimits
+	MemoryLimits    api.MemoryLimits
+	DiskUsage       api.ContainerDiskUsage
+
+	NetOutRules []api.NetOutRule
+
+	// Files holds the tar streams streamed in, by destination path, which
+	// are streamed out of the same path as they are.
+	Files map[string][]byte
+
+	// DestroyAt is when the container is scheduled to be destroyed, or zero.
+	DestroyAt time.Time
+
+	// Logs are replayed by Logs, oldest first. Processes' output is not
+	// added to them.
+	Logs []api.LogEntry
+}
+
+type Connection struct {
+	// Script runs each process. By default processes exit 0 at once, without
+	// output. Set it before running any.
+	Script ProcessScript
+
+	// Fail, if set, is called before each request with the name of its
+	// Connection method and the handle of its container, if any. Requests
+	// fail with the error it returns, without effect.
+	Fail func(method string, handle string) error
+
+	// MaxContainers, if non-zero, is reported as the capacity and enforced
+	// by Create, which fails with api.ErrCapacityExceeded.
+	MaxContainers uint64
+
+	started time.Time
+
+	containers map[string]*container
+
+	// destroys are the outcomes of DestroyAsync, by id
+	destroys map[string]api.DestroyOperation
+
+	lastHandle    int
+	lastDestroyID int
+	lastProcessID uint32
+	lastHostPort  uint32
+
+	mu sync.Mutex
+}
+
+type container struct {
+	Container
+
+	processes    map[uint32]*process
+	destroyTimer *time.Timer
+}
+
+// New returns a Connection with no containers.
+func New() *Connection {
+	return &Connection{
+		started:      time.Now(),
+		containers:   map[string]*container{},
+		destroys:     map[string]api.DestroyOperation{},
+		lastHostPort: 61000,
+	}
+}
+
+// Container returns a copy of the state of the container with the handle.
+func (c *Connection) Container(handle string) (Container, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	container, found := c.containers[handle]
+	if !found {
+		return Container{}, false
+	}
+
+	return container.state(), true
+}
+
+// UpdateContainer changes the state of the container with the handle, e.g.
+// to script its info or disk usage.
+func (c *Connection) UpdateContainer(handle string, update func(*Container)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	container, found := c.containers[handle]
+	if !found {
+		return api.ErrContainerNotFound
+	}
+
+	update(&container.Container)
+	container.Handle = handle
+
+	return nil
+}
+
+func (c *Connection) Ping() error {
+	return c.fail("Ping", "")
+}
+
+func (c *Connection) ProtocolVersion() (uint32, error) {
+	if err := c.fail("ProtocolVersion", ""); err != nil {
+		return 0, err
+	}
+
+	return api.ProtocolVersion, nil
+}
+
+func (c *Connection) ServerVersion() (api.ServerVersion, error) {
+	if err := c.fail("ServerVersion", ""); err != nil {
+		return api.ServerVersion{}, err
+	}
+
+	return api.ServerVersion{
+		ProtocolVersion: api.ProtocolVersion,
+		Capabilities:    api.Capabilities,
+	}, nil
+}
+
+func (c *Connection) HealthCheck() (api.HealthStatus, error) {
+	if err := c.fail("HealthCheck", ""); err != nil {
+		return api.HealthStatus{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := api.HealthStatus{
+		Healthy:         true,
+		Containers:      uint64(len(c.containers)),
+		MaxContainers:   c.MaxContainers,
+		Uptime:          time.Since(c.started),
+		ProtocolVersion: api.ProtocolVersion,
+	}
+
+	if status.MaxContainers > status.Containers {
+		status.ContainerHeadroom = status.MaxContainers - status.Containers
+	}
+
+	return status, nil
+}
+
+func (c *Connection) Capacity() (api.Capacity, error) {
+	if err := c.fail("Capacity", ""); err != nil {
+		return api.Capacity{}, err
+	}
+
+	return api.Capacity{MaxContainers: c.MaxContainers}, nil
+}
+
+func (c *Connection) FreshCapacity() (api.Capacity, error) {
+	if err := c.fail("FreshCapacity", ""); err != nil {
+		return api.Capacity{}, err
+	}
+
+	return api.Capacity{MaxContainers: c.MaxContainers}, nil
+}
+
+func (c *Connection) Create(spec api.ContainerSpec) (string, *api.ContainerInfo, error) {
+	if err := c.fail("Create", spec.Handle); err != nil {
+		return "", nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.MaxContainers != 0 && uint64(len(c.containers)) >= c.MaxContainers {
+		return "", nil, api.ErrCapacityExceeded
+	}
+
+	handle := spec.Handle
+	if handle == "" {
+		c.lastHandle++
+		handle = fmt.Sprintf("container-%d", c.lastHandle)
+	}
+
+	if _, found := c.containers[handle]; found {
+		return "", nil, fmt.Errorf("container already exists: %s", handle)
+	}
+
+	properties := api.Properties{}
+	for name, value := range spec.Properties {
+		properties[name] = value
+	}
+
+	container := &container{
+		Container: Container{
+			Handle: handle,
+			Spec:   spec,
+			Info: api.ContainerInfo{
+				State:      "active",
+				Properties: properties,
+				GraceTime:  spec.GraceTime,
+				CreatedAt:  time.Now(),
+
+				ExternalIP:        spec.ExternalIP,
+				ExternalInterface: spec.ExternalInterface,
+			},
+			Files: map[string][]byte{},
+		},
+		processes: map[uint32]*process{},
+	}
+
+	container.Spec.Handle = handle
+
+	c.containers[handle] = container
+
+	info := container.info()
+
+	return handle, &info, nil
+}
+
+func (c *Connection) List(properties api.Properties) ([]string, error) {
+	if err := c.fail("List", ""); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	handles := []string{}
+	for _, container := range c.matching(properties, api.ListOrderHandle) {
+		handles = append(handles, container.Handle)
+	}
+
+	return handles, nil
+}
+
+func (c *Connection) ListWhere(filters []api.PropertyFilter) ([]string, error) {
+	if err := c.fail("ListWhere", ""); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	handles := []string{}
+	for _, container := range c.matching(api.Properties{}, api.ListOrderHandle) {
+		if api.MatchesAll(filters, container.properties()) {
+			handles = append(handles, container.Handle)
+		}
+	}
+
+	return handles, nil
+}
+
+func (c *Connection) ListVerbose(properties api.Properties, order api.ListOrder) ([]api.ContainerSummary, error) {
+	if err := c.fail("ListVerbose", ""); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	summaries := []api.ContainerSummary{}
+	for _, container := range c.matching(properties, order) {
+		summaries = append(summaries, api.ContainerSummary{
+			Handle:     container.Handle,
+			State:      container.Info.State,
+			Properties: container.properties(),
+		})
+	}
+
+	return summaries, nil
+}
+
+// ListEach calls each with the containers listed as of the call, without
+// holding the connection, so that each may use it.
+func (c *Connection) ListEach(properties api.Properties, each func(string) error) error {
+	if err := c.fail("ListEach", ""); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	handles := []string{}
+	for _, container := range c.matching(properties, api.ListOrderHandle) {
+		handles = append(handles, container.Handle)
+	}
+	c.mu.Unlock()
+
+	for _, handle := range handles {
+		err := each(handle)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Connection) LookupBy(properties api.Properties) (string, error) {
+	if err := c.fail("LookupBy", ""); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matching := c.matching(properties, api.ListOrderHandle)
+
+	switch len(matching) {
+	case 0:
+		return "", api.ErrContainerNotFound
+	case 1:
+		return matching[0].Handle, nil
+	default:
+		return "", api.ErrMultipleContainersMatch
+	}
+}
+
+func (c *Connection) Destroy(handle string) error {
+	if err := c.fail("Destroy", handle); err != nil {
+		return err
+	}
+
+	return c.destroy(handle)
+}
+
+func (c *Connection) destroy(handle string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	container, found := c.containers[handle]
+	if !found {
+		return api.ErrContainerNotFound
+	}
+
+	if container.destroyTimer != nil {
+		container.destroyTimer.Stop()
+	}
+
+	delete(c.containers, handle)
+
+	return nil
+}
+
+// ForceDestroy destroys the container like Destroy; its processes are
+// abandoned either way.
+func (c *Connection) ForceDestroy(handle string) error {
+	if err := c.fail("ForceDestroy", handle); err != nil {
+		return err
+	}
+
+	return c.destroy(handle)
+}
+
+// DestroyWithReason destroys the container like Destroy; the reason is not
+// kept.
+func (c *Connection) DestroyWithReason(handle string, force bool, reason string) error {
+	if err := c.fail("DestroyWithReason", handle); err != nil {
+		return err
+	}
+
+	return c.destroy(handle)
+}
+
+// DestroyAsync destroys the container before returning, recording the outcome
+// for DestroyOperation as a server does once the destroy finishes.
+func (c *Connection) DestroyAsync(handle string, force bool) (string, error) {
+	if err := c.fail("DestroyAsync", handle); err != nil {
+		return "", err
+	}
+
+	operation := api.DestroyOperation{
+		Handle: handle,
+		State:  api.DestroyStateDestroyed,
+	}
+
+	err := c.destroy(handle)
+	if err != nil {
+		operation.State = api.DestroyStateFailed
+		operation.Error = err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastDestroyID++
+	operation.ID = fmt.Sprintf("destroy-%d", c.lastDestroyID)
+
+	c.destroys[operation.ID] = operation
+
+	return operation.ID, nil
+}
+
+func (c *Connection) DestroyOperation(id string) (api.DestroyOperation, error) {
+	if err := c.fail("DestroyOperation", ""); err != nil {
+		return api.DestroyOperation{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	operation, found := c.destroys[id]
+	if !found {
+		return api.DestroyOperation{}, api.ErrOperationNotFound
+	}
+
+	return operation, nil
+}
+
+func (c *Connection) Rename(oldHandle, newHandle string) error {
+	if err := c.fail("Rename", oldHandle); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	container, found := c.containers[oldHandle]
+	if !found {
+		return api.ErrContainerNotFound
+	}
+
+	if _, found := c.containers[newHandle]; found {
+		return fmt.Errorf("container already exists: %s", newHandle)
+	}
+
+	delete(c.containers, oldHandle)
+
+	container.Handle = newHandle
+	container.Spec.Handle = newHandle
+	c.containers[newHandle] = container
+
+	return nil
+}
+
+// Snapshot returns the container's state as JSON, which Restore recreates the
+// container from. Its processes are not included.
+func (c *Connection) Snapshot(handle string) (io.ReadCloser, error) {
+	if err := c.fail("Snapshot", handle); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	container, found := c.containers[handle]
+	if !found {
+		return nil, api.ErrContainerNotFound
+	}
+
+	snapshot, err := json.Marshal(container.state())
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(snapshot)), nil
+}
+
+func (c *Connection) Restore(snapshot io.Reader) (string, error) {
+	if err := c.fail("Restore", ""); err != nil {
+		return "", err
+	}
+
+	var state Container
+	err := json.NewDecoder(snapshot).Decode(&state)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, found := c.containers[state.Handle]; found {
+		return "", fmt.Errorf("container already exists: %s", state.Handle)
+	}
+
+	if state.Files == nil {
+		state.Files = map[string][]byte{}
+	}
+
+	c.containers[state.Handle] = &container{
+		Container: state,
+		processes: map[uint32]*process{},
+	}
+
+	return state.Handle, nil
+}
+
+// Stop marks the container stopped. Scripts are not interrupted, so processes
+// exit only once theirs return.
+func (c *Connection) Stop(handle string, kill bool) error {
+	return c.update("Stop", handle, func(container *container) error {
+		container.Info.State = "stopped"
+		return nil
+	})
+}
+
+// StopWithReason stops the container like Stop; the reason is not kept.
+func (c *Connection) StopWithReason(handle string, kill bool, reason string) error {
+	return c.update("StopWithReason", handle, func(container *container) error {
+		container.Info.State = "stopped"
+		return nil
+	})
+}
+
+func (c *Connection) SignalAll(handle string, signal api.Signal) error {
+	return c.update("SignalAll", handle, func(*container) error {
+		return nil
+	})
+}
+
+func (c *Connection) Pause(handle string) error {
+	return c.update("Pause", handle, func(container *container) error {
+		container.Info.State = "paused"
+		return nil
+	})
+}
+
+func (c *Connection) Resume(handle string) error {
+	return c.update("Resume", handle, func(container *container) error {
+		container.Info.State = "active"
+		return nil
+	})
+}
+
+// DestroyAt destroys the container at the time, as the server would.
+func (c *Connection) DestroyAt(handle string, at time.Time) error {
+	return c.update("DestroyAt", handle, func(container *container) error {
+		c.scheduleDestroy(container, at)
+		return nil
+	})
+}
+
+func (c *Connection) DestroyAfter(handle string, delay time.Duration) error {
+	return c.update("DestroyAfter", handle, func(container *container) error {
+		c.scheduleDestroy(container, time.Now().Add(delay))
+		return nil
+	})
+}
+
+func (c *Connection) CancelScheduledDestroy(handle string) error {
+	return c.update("CancelScheduledDestroy", handle, func(container *container) error {
+		if container.destroyTimer != nil {
+			container.destroyTimer.Stop()
+			container.destroyTimer = nil
+		}
+
+		container.DestroyAt = time.Time{}
+
+		return nil
+	})
+}
+
+func (c *Connection) SetHold(handle string, held bool) error {
+	return c.update("SetHold", handle, func(container *container) error {
+		container.Info.Held = held
+		return nil
+	})
+}
+
+func (c *Connection) SetGraceTime(handle string, graceTime time.Duration) error {
+	return c.update("SetGraceTime", handle, func(container *container) error {
+		container.Info.GraceTime = graceTime
+		return nil
+	})
+}
+
+func (c *Connection) Info(handle string) (api.ContainerInfo, error) {
+	var info api.ContainerInfo
+
+	err := c.update("Info", handle, func(container *container) error {
+		info = container.info()
+		return nil
+	})
+
+	return info, err
+}
+
+// InfoFields returns all of the container's info, as servers which predate
+// info fields do.
+func (c *Connection) InfoFields(handle string, fields []api.InfoField) (api.ContainerInfo, error) {
+	var info api.ContainerInfo
+
+	err := c.update("InfoFields", handle, func(container *container) error {
+		info = container.info()
+		return nil
+	})
+
+	return info, err
+}
+
+func (c *Connection) StreamIn(handle string, spec api.StreamInSpec) error {
+	return c.streamIn("StreamIn", handle, spec.Path, spec.TarStream)
+}
+
+func (c *Connection) StreamOut(handle string, spec api.StreamOutSpec) (io.ReadCloser, error) {
+	var stream []byte
+
+	err := c.update("StreamOut", handle, func(container *container) error {
+		var found bool
+
+		stream, found = container.Files[spec.Path]
+		if !found {
+			return fmt.Errorf("no such file: %s", spec.Path)
+		}
+
+		if spec.Offset > uint64(len(stream)) {
+			return fmt.Errorf("offset %d is past the end of %s", spec.Offset, spec.Path)
+		}
+
+		stream = stream[spec.Offset:]
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(stream)), nil
+}
+
+// VerifyStreamIn reads the tar stream, reporting its entries and bytes, but
+// does not keep it.
+func (c *Connection) VerifyStreamIn(handle string, dstPath string, reader io.Reader) (api.StreamInReport, error) {
+	err := c.update("VerifyStreamIn", handle, func(*container) error {
+		return nil
+	})
+	if err != nil {
+		return api.StreamInReport{}, err
+	}
+
+	report := api.StreamInReport{}
+
+	tarReader := tar.NewReader(reader)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return report, nil
+		}
+
+		if err != nil {
+			return api.StreamInReport{}, err
+		}
+
+		report.Entries++
+		report.Bytes += uint64(header.Size)
+	}
+}
+
+func (c *Connection) LimitBandwidth(handle string, limits api.BandwidthLimits) (api.BandwidthLimits, error) {
+	err := c.update("LimitBandwidth", handle, func(container *container) error {
+		container.BandwidthLimits = limits
+		return nil
+	})
+
+	return limits, err
+}
+
+func (c *Connection) LimitCPU(handle string, limits api.CPULimits) (api.CPULimits, error) {
+	err := c.update("LimitCPU", handle, func(container *container) error {
+		container.CPULimits = limits
+		return nil
+	})
+
+	return limits, err
+}
+
+func (c *Connection) LimitDisk(handle string, limits api.DiskLimits) (api.DiskLimits, error) {
+	err := c.update("LimitDisk", handle, func(container *container) error {
+		container.DiskLimits = limits
+		return nil
+	})
+
+	return limits, err
+}
+
+func (c *Connection) LimitMemory(handle string, limits api.MemoryLimits) (api.MemoryLimits, error) {
+	err := c.update("LimitMemory", handle, func(container *container) error {
+		container.MemoryLimits = limits
+		return nil
+	})
+
+	return limits, err
+}
+
+func (c *Connection) CurrentBandwidthLimits(handle string) (api.BandwidthLimits, error) {
+	var limits api.BandwidthLimits
+
+	err := c.update("CurrentBandwidthLimits", handle, func(container *container) error {
+		limits = container.BandwidthLimits
+		return nil
+	})
+
+	return limits, err
+}
+
+func (c *Connection) CurrentCPULimits(handle string) (api.CPULimits, error) {
+	var limits api.CPULimits
+
+	err := c.update("CurrentCPULimits", handle, func(container *container) error {
+		limits = container.CPULimits
+		return nil
+	})
+
+	return limits, err
+}
+
+func (c *Connection) CurrentDiskLimits(handle string) (api.DiskLimits, error) {
+	var limits api.DiskLimits
+
+	err := c.update("CurrentDiskLimits", handle, func(container *container) error {
+		limits = container.DiskLimits
+		return nil
+	})
+
+	return limits, err
+}
+
+func (c *Connection) DiskUsage(handle string) (api.ContainerDiskUsage, error) {
+	var usage api.ContainerDiskUsage
+
+	err := c.update("DiskUsage", handle, func(container *container) error {
+		usage = container.DiskUsage
+		return nil
+	})
+
+	return usage, err
+}
+
+// Logs replays the container's Logs, or the last tail of them. Following is
+// not supported, and fails with api.ErrUnsupportedOperation.
+func (c *Connection) Logs(handle string, tail int, follow bool) (api.LogStream, error) {
+	if follow {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	var entries []api.LogEntry
+
+	err := c.update("Logs", handle, func(container *container) error {
+		entries = container.Logs
+
+		if tail != 0 && tail < len(entries) {
+			entries = entries[len(entries)-tail:]
+		}
+
+		entries = append([]api.LogEntry{}, entries...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &logStream{entries: entries}, nil
+}
+
+type logStream struct {
+	entries []api.LogEntry
+}
+
+func (s *logStream) Next() (api.LogEntry, error) {
+	if len(s.entries) == 0 {
+		return api.LogEntry{}, io.EOF
+	}
+
+	entry := s.entries[0]
+	s.entries = s.entries[1:]
+
+	return entry, nil
+}
+
+func (s *logStream) Close() error {
+	return nil
+}
+
+// Env returns the environment of the container's spec.
+func (c *Connection) Env(handle string) ([]string, error) {
+	env := []string{}
+
+	err := c.update("Env", handle, func(container *container) error {
+		env = append(env, container.Spec.Env...)
+		return nil
+	})
+
+	return env, err
+}
+
+func (c *Connection) CurrentMemoryLimits(handle string) (api.MemoryLimits, error) {
+	var limits api.MemoryLimits
+
+	err := c.update("CurrentMemoryLimits", handle, func(container *container) error {
+		limits = container.MemoryLimits
+		return nil
+	})
+
+	return limits, err
+}
+
+// NetIn maps the ports as NetInSpec does.
+func (c *Connection) NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
+	return c.netIn("NetIn", handle, api.NetInSpec{
+		HostPort:      hostPort,
+		ContainerPort: containerPort,
+	})
+}
+
+// NetInSpec maps the ports, choosing a host port from 61001 if it is 0. The
+// container port defaults to the host port, and the protocol to TCP.
+func (c *Connection) NetInSpec(handle string, spec api.NetInSpec) (uint32, uint32, error) {
+	return c.netIn("NetInSpec", handle, spec)
+}
+
+func (c *Connection) netIn(call string, handle string, spec api.NetInSpec) (uint32, uint32, error) {
+	hostPort := spec.HostPort
+	containerPort := spec.ContainerPort
+
+	protocol := spec.Protocol
+	if protocol == 0 {
+		protocol = api.ProtocolTCP
+	}
+
+	err := c.update(call, handle, func(container *container) error {
+		if hostPort == 0 {
+			c.lastHostPort++
+			hostPort = c.lastHostPort
+		}
+
+		if containerPort == 0 {
+			containerPort = hostPort
+		}
+
+		container.Info.MappedPorts = append(container.Info.MappedPorts, api.PortMapping{
+			HostPort:      hostPort,
+			ContainerPort: containerPort,
+			Protocol:      protocol,
+			HostIP:        spec.HostIP,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return hostPort, containerPort, nil
+}
+
+func (c *Connection) MappedPorts(handle string) ([]api.PortMapping, error) {
+	var mappings []api.PortMapping
+
+	err := c.update("MappedPorts", handle, func(container *container) error {
+		mappings = append([]api.PortMapping{}, container.Info.MappedPorts...)
+		return nil
+	})
+
+	return mappings, err
+}
+
+// NetOut only checks that the container exists; use NetOutRule to have rules
+// recorded in the container's NetOutRules.
+func (c *Connection) NetOut(handle string, network string, port uint32, portRange string, protocol api.Protocol) error {
+	return c.update("NetOut", handle, func(*container) error {
+		return nil
+	})
+}
+
+func (c *Connection) NetOutRule(handle string, rule api.NetOutRule) error {
+	return c.update("NetOutRule", handle, func(container *container) error {
+		container.NetOutRules = append(container.NetOutRules, rule)
+		return nil
+	})
+}
+
+func (c *Connection) GetProperty(handle string, name string) (string, error) {
+	var value string
+
+	err := c.update("GetProperty", handle, func(container *container) error {
+		var found bool
+
+		value, found = container.Info.Properties[name]
+		if !found {
+			return fmt.Errorf("property does not exist: %s", name)
+		}
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (c *Connection) SetProperty(handle string, name string, value string) error {
+	return c.update("SetProperty", handle, func(container *container) error {
+		container.setProperty(name, value)
+		return nil
+	})
+}
+
+func (c *Connection) SetProperties(handle string, properties api.Properties) error {
+	return c.update("SetProperties", handle, func(container *container) error {
+		for name, value := range properties {
+			container.setProperty(name, value)
+		}
+
+		return nil
+	})
+}
+
+func (c *Connection) CompareAndSwapProperty(handle string, name string, oldValue string, newValue string) (bool, error) {
+	var swapped bool
+
+	err := c.update("CompareAndSwapProperty", handle, func(container *container) error {
+		if container.Info.Properties[name] != oldValue {
+			return nil
+		}
+
+		container.setProperty(name, newValue)
+		swapped = true
+
+		return nil
+	})
+
+	return swapped, err
+}
+
+func (c *Connection) RemoveProperty(handle string, name string) error {
+	return c.update("RemoveProperty", handle, func(container *container) error {
+		if _, found := container.Info.Properties[name]; !found {
+			return fmt.Errorf("property does not exist: %s", name)
+		}
+
+		delete(container.Info.Properties, name)
+
+		return nil
+	})
+}
+
+func (c *Connection) BulkProperties(handles []string, keys []string) (map[string]api.Properties, error) {
+	if err := c.fail("BulkProperties", ""); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	containers := map[string]api.Properties{}
+
+	for _, handle := range handles {
+		container, found := c.containers[handle]
+		if !found {
+			continue
+		}
+
+		properties := api.Properties{}
+
+		if len(keys) == 0 {
+			for name, value := range container.Info.Properties {
+				properties[name] = value
+			}
+		}
+
+		for _, key := range keys {
+			if value, found := container.Info.Properties[key]; found {
+				properties[key] = value
+			}
+		}
+
+		containers[handle] = properties
+	}
+
+	return containers, nil
+}
+
+func (c *Connection) fail(method string, handle string) error {
+	if c.Fail == nil {
+		return nil
+	}
+
+	return c.Fail(method, handle)
+}
+
+// update calls the function with the container while holding the lock,
+// failing if the request is scripted to or the container does not exist.
+func (c *Connection) update(method string, handle string, update func(*container) error) error {
+	if err := c.fail(method, handle); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	container, found := c.containers[handle]
+	if !found {
+		return api.ErrContainerNotFound
+	}
+
+	return update(container)
+}
+
+func (c *Connection) streamIn(method string, handle string, dstPath string, reader io.Reader) error {
+	stream, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	return c.update(method, handle, func(container *container) error {
+		container.Files[dstPath] = stream
+		return nil
+	})
+}
+
+// matching returns the containers with all of the properties, in the order.
+func (c *Connection) matching(properties api.Properties, order api.ListOrder) []*container {
+	matching := []*container{}
+
+	for _, container := range c.containers {
+		if container.matches(properties) {
+			matching = append(matching, container)
+		}
+	}
+
+	sort.Sort(containersInOrder{matching, order})
+
+	return matching
+}
+
+func (c *Connection) scheduleDestroy(container *container, at time.Time) {
+	if container.destroyTimer != nil {
+		container.destroyTimer.Stop()
+	}
+
+	container.DestroyAt = at
+
+	var timer *time.Timer
+	timer = time.AfterFunc(at.Sub(time.Now()), func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// the container may have been rescheduled or renamed since
+		if container.destroyTimer == timer && c.containers[container.Handle] == container {
+			delete(c.containers, container.Handle)
+		}
+	})
+
+	container.destroyTimer = timer
+}
+
+func (container *container) state() Container {
+	state := container.Container
+	state.Info = container.info()
+
+	state.Files = map[string][]byte{}
+	for path, stream := range container.Files {
+		state.Files[path] = stream
+	}
+
+	state.NetOutRules = append([]api.NetOutRule(nil), container.NetOutRules...)
+
+	return state
+}
+
+func (container *container) info() api.ContainerInfo {
+	info := container.Info
+	info.Properties = container.properties()
+	info.MappedPorts = append([]api.PortMapping(nil), container.Info.MappedPorts...)
+
+	info.ProcessIDs = []uint32{}
+	for id := range container.processes {
+		info.ProcessIDs = append(info.ProcessIDs, id)
+	}
+
+	sort.Sort(processIDs(info.ProcessIDs))
+
+	return info
+}
+
+func (container *container) properties() api.Properties {
+	properties := api.Properties{}
+	for name, value := range container.Info.Properties {
+		properties[name] = value
+	}
+
+	return properties
+}
+
+func (container *container) setProperty(name string, value string) {
+	if container.Info.Properties == nil {
+		container.Info.Properties = api.Properties{}
+	}
+
+	container.Info.Properties[name] = value
+}
+
+func (container *container) matches(properties api.Properties) bool {
+	for name, value := range properties {
+		if container.Info.Properties[name] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+type containersInOrder struct {
+	containers []*container
+	order      api.ListOrder
+}
+
+func (c containersInOrder) Len() int {
+	return len(c.containers)
+}
+
+func (c containersInOrder) Swap(i, j int) {
+	c.containers[i], c.containers[j] = c.containers[j], c.containers[i]
+}
+
+func (c containersInOrder) Less(i, j int) bool {
+	a, b := c.containers[i], c.containers[j]
+
+	if c.order == api.ListOrderCreated && !a.Info.CreatedAt.Equal(b.Info.CreatedAt) {
+		return a.Info.CreatedAt.Before(b.Info.CreatedAt)
+	}
+
+	return a.Handle < b.Handle
+}
+
+type processIDs []uint32
+
+func (ids processIDs) Len() int           { return len(ids) }
+func (ids processIDs) Swap(i, j int)      { ids[i], ids[j] = ids[j], ids[i] }
+func (ids processIDs) Less(i, j int) bool { return ids[i] < ids[j] }
@@ -0,0 +1,400 @@
+package fake_connection_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/client/connection/fake_connection"
+	"github.com/onsi/gomega/gbytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ connection.Connection = fake_connection.New()
+
+var _ = Describe("Connection", func() {
+	var fakeConnection *fake_connection.Connection
+	var gardenClient client.Client
+
+	BeforeEach(func() {
+		fakeConnection = fake_connection.New()
+		gardenClient = client.New(fakeConnection)
+	})
+
+	Describe("containers", func() {
+		It("can be created, looked up, and destroyed", func() {
+			container, err := gardenClient.Create(api.ContainerSpec{Handle: "some-handle"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(container.Handle()).Should(Equal("some-handle"))
+
+			found, err := gardenClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(found.Handle()).Should(Equal("some-handle"))
+
+			err = gardenClient.Destroy("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = gardenClient.Lookup("some-handle")
+			Ω(err).Should(Equal(api.ErrContainerNotFound))
+		})
+
+		It("records the outcome of asynchronous destroys", func() {
+			_, err := gardenClient.Create(api.ContainerSpec{Handle: "some-handle"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			id, err := gardenClient.DestroyAsync("some-handle", false)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			operation, err := gardenClient.DestroyOperation(id)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(operation.State).Should(Equal(api.DestroyStateDestroyed))
+
+			id, err = gardenClient.DestroyAsync("some-handle", false)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			operation, err = gardenClient.DestroyOperation(id)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(operation.State).Should(Equal(api.DestroyStateFailed))
+			Ω(operation.Error).Should(Equal(api.ErrContainerNotFound))
+		})
+
+		It("generates handles for containers created without one", func() {
+			first, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			second, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(first.Handle()).ShouldNot(BeEmpty())
+			Ω(second.Handle()).ShouldNot(Equal(first.Handle()))
+		})
+
+		It("lists the containers with the properties", func() {
+			_, err := gardenClient.Create(api.ContainerSpec{
+				Handle:     "b",
+				Properties: api.Properties{"app": "x"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = gardenClient.Create(api.ContainerSpec{
+				Handle:     "a",
+				Properties: api.Properties{"app": "x"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = gardenClient.Create(api.ContainerSpec{
+				Handle:     "c",
+				Properties: api.Properties{"app": "y"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			containers, err := gardenClient.Containers(api.Properties{"app": "x"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(HaveLen(2))
+			Ω(containers[0].Handle()).Should(Equal("a"))
+			Ω(containers[1].Handle()).Should(Equal("b"))
+
+			containers, err = gardenClient.ContainersInOrder(nil, api.ListOrderCreated)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(HaveLen(3))
+			Ω(containers[0].Handle()).Should(Equal("b"))
+
+			handles := []string{}
+			err = gardenClient.EachContainer(api.Properties{"app": "x"}, func(container api.Container) error {
+				handles = append(handles, container.Handle())
+
+				// the connection is free to use meanwhile
+				_, err := container.Info()
+				return err
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handles).Should(Equal([]string{"a", "b"}))
+
+			containers, err = gardenClient.ContainersWhere([]api.PropertyFilter{
+				{Name: "app", Operator: api.PropertyNotEquals, Value: "x"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(HaveLen(1))
+			Ω(containers[0].Handle()).Should(Equal("c"))
+		})
+
+		It("keeps each container's properties and limits", func() {
+			container, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.SetProperty("some-name", "some-value")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			swapped, err := container.CompareAndSwapProperty("some-name", "other-value", "new-value")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(swapped).Should(BeFalse())
+
+			value, err := container.GetProperty("some-name")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(value).Should(Equal("some-value"))
+
+			err = container.LimitMemory(api.MemoryLimits{LimitInBytes: 1024})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			limits, err := container.CurrentMemoryLimits()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(limits.LimitInBytes).Should(BeNumerically("==", 1024))
+		})
+
+		It("maps ports with their protocols and host IPs", func() {
+			container, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, _, err = container.NetInSpec(api.NetInSpec{HostPort: 1234})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, _, err = container.NetInSpec(api.NetInSpec{
+				HostPort:      53,
+				ContainerPort: 5353,
+				Protocol:      api.ProtocolUDP,
+				HostIP:        net.ParseIP("10.0.0.1"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.MappedPorts()).Should(Equal([]api.PortMapping{
+				{HostPort: 1234, ContainerPort: 1234, Protocol: api.ProtocolTCP},
+				{HostPort: 53, ContainerPort: 5353, Protocol: api.ProtocolUDP, HostIP: net.ParseIP("10.0.0.1")},
+			}))
+		})
+
+		It("streams out what was streamed in", func() {
+			container, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.StreamIn(api.StreamInSpec{
+				Path:      "/some/path",
+				TarStream: bytes.NewBufferString("some-tar"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			stream, err := container.StreamOut(api.StreamOutSpec{Path: "/some/path"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(ioutil.ReadAll(stream)).Should(Equal([]byte("some-tar")))
+		})
+
+		It("destroys containers once they are scheduled to be", func() {
+			container, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.(client.Container).DestroyAfter(10 * time.Millisecond)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(func() error {
+				_, err := gardenClient.Lookup(container.Handle())
+				return err
+			}).Should(Equal(api.ErrContainerNotFound))
+		})
+
+		It("restores snapshotted containers", func() {
+			container, err := gardenClient.Create(api.ContainerSpec{
+				Handle:     "some-handle",
+				Properties: api.Properties{"some-name": "some-value"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			snapshot, err := gardenClient.Snapshot(container.Handle())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = gardenClient.Destroy(container.Handle())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			restored, err := gardenClient.Restore(snapshot)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(restored.Handle()).Should(Equal("some-handle"))
+
+			value, err := restored.GetProperty("some-name")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(value).Should(Equal("some-value"))
+		})
+
+		It("lets tests change their state", func() {
+			container, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = fakeConnection.UpdateContainer(container.Handle(), func(state *fake_connection.Container) {
+				state.Info.ContainerIP = "10.0.0.1"
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			info, err := container.Info()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(info.ContainerIP).Should(Equal("10.0.0.1"))
+
+			state, found := fakeConnection.Container(container.Handle())
+			Ω(found).Should(BeTrue())
+			Ω(state.Info.ContainerIP).Should(Equal("10.0.0.1"))
+		})
+
+		Context("when MaxContainers is set", func() {
+			BeforeEach(func() {
+				fakeConnection.MaxContainers = 1
+			})
+
+			It("fails to create more", func() {
+				_, err := gardenClient.Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = gardenClient.Create(api.ContainerSpec{})
+				Ω(err).Should(Equal(api.ErrCapacityExceeded))
+			})
+		})
+	})
+
+	Describe("processes", func() {
+		var container client.Container
+
+		BeforeEach(func() {
+			created, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			container = created.(client.Container)
+		})
+
+		It("exit 0 without output by default", func() {
+			process, err := container.Run(api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(process.Wait()).Should(Equal(0))
+		})
+
+		Context("with a script", func() {
+			BeforeEach(func() {
+				fakeConnection.Script = func(handle string, spec api.ProcessSpec, processIO api.ProcessIO) (int, error) {
+					if spec.Path == "false" {
+						return 0, errors.New("oh no")
+					}
+
+					io.Copy(processIO.Stdout, processIO.Stdin)
+					processIO.Stderr.Write([]byte("done"))
+
+					return 42, nil
+				}
+			})
+
+			It("streams its io and exits with its status", func() {
+				stdout := gbytes.NewBuffer()
+				stderr := gbytes.NewBuffer()
+
+				process, err := container.Run(api.ProcessSpec{Path: "cat"}, api.ProcessIO{
+					Stdin:  bytes.NewBufferString("hello"),
+					Stdout: stdout,
+					Stderr: stderr,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(process.Wait()).Should(Equal(42))
+				Ω(stdout).Should(gbytes.Say("hello"))
+				Ω(stderr).Should(gbytes.Say("done"))
+			})
+
+			It("fails the process with the script's error", func() {
+				process, err := container.Run(api.ProcessSpec{Path: "false"}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = process.Wait()
+				Ω(err).Should(MatchError("oh no"))
+			})
+
+			It("streams its output to clients which attach", func() {
+				stdin, stdinWriter := io.Pipe()
+
+				process, err := container.Run(api.ProcessSpec{Path: "cat"}, api.ProcessIO{
+					Stdin: stdin,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				info, err := container.Info()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(info.ProcessIDs).Should(Equal([]uint32{process.ID()}))
+
+				processInfo, err := container.ProcessInfo(process.ID())
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processInfo.State).Should(Equal(api.ProcessStateRunning))
+
+				stdout := gbytes.NewBuffer()
+
+				_, err = container.Attach(process.ID(), api.ProcessIO{Stdout: stdout})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				stdinWriter.Write([]byte("hello"))
+				Eventually(stdout).Should(gbytes.Say("hello"))
+
+				stdinWriter.Close()
+				Ω(process.Wait()).Should(Equal(42))
+
+				Eventually(func() ([]uint32, error) {
+					info, err := container.Info()
+					return info.ProcessIDs, err
+				}).Should(BeEmpty())
+
+				_, err = container.Attach(process.ID(), api.ProcessIO{})
+				Ω(err).Should(Equal(api.ErrProcessNotFound))
+
+				_, err = container.ProcessInfo(process.ID())
+				Ω(err).Should(Equal(api.ErrProcessNotFound))
+			})
+
+			It("finds running processes by their labels", func() {
+				stdin, stdinWriter := io.Pipe()
+
+				process, err := container.Run(api.ProcessSpec{
+					Path:   "cat",
+					Labels: map[string]string{"role": "health-check"},
+				}, api.ProcessIO{
+					Stdin: stdin,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				processIDs, err := container.FindProcesses(map[string]string{"role": "health-check"})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processIDs).Should(Equal([]uint32{process.ID()}))
+
+				processIDs, err = container.FindProcesses(map[string]string{"role": "app"})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processIDs).Should(BeEmpty())
+
+				stdinWriter.Close()
+				Ω(process.Wait()).Should(Equal(42))
+
+				Eventually(func() ([]uint32, error) {
+					return container.FindProcesses(map[string]string{"role": "health-check"})
+				}).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("when Fail returns an error", func() {
+		BeforeEach(func() {
+			fakeConnection.Fail = func(method string, handle string) error {
+				if method == "Create" {
+					return errors.New("oh no")
+				}
+
+				return nil
+			}
+		})
+
+		It("fails the request", func() {
+			_, err := gardenClient.Create(api.ContainerSpec{})
+			Ω(err).Should(MatchError("oh no"))
+
+			containers, err := gardenClient.Containers(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(BeEmpty())
+		})
+	})
+})
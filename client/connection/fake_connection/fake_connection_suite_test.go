@@ -0,0 +1,13 @@
+package fake_connection_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFakeConnection(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FakeConnection Suite")
+}
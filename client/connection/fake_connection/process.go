@@ -0,0 +1,324 @@
+package fake_connection
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+)
+
+// Run starts the Script in the background as the container's process,
+// streaming its output to the io until it exits.
+func (c *Connection) Run(handle string, spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+	var started *process
+
+	err := c.update("Run", handle, func(container *container) error {
+		c.lastProcessID++
+
+		started = newProcess(c.lastProcessID)
+		started.labels = spec.Labels
+		started.attach(processIO, true)
+
+		container.processes[started.id] = started
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	script := c.Script
+	if script == nil {
+		script = func(string, api.ProcessSpec, api.ProcessIO) (int, error) {
+			return 0, nil
+		}
+	}
+
+	go func() {
+		exitStatus, err := script(handle, spec, api.ProcessIO{
+			Stdin:  started.stdinReader,
+			Stdout: started.stdout,
+			Stderr: started.stderr,
+		})
+
+		c.mu.Lock()
+		for _, container := range c.containers {
+			if container.processes[started.id] == started {
+				delete(container.processes, started.id)
+			}
+		}
+		c.mu.Unlock()
+
+		started.exited(exitStatus, err)
+	}()
+
+	return started, nil
+}
+
+// Attach streams the running process's output to the io too, and its stdin
+// to the process, until it exits.
+func (c *Connection) Attach(handle string, processID uint32, processIO api.ProcessIO) (api.Process, error) {
+	var attached *process
+
+	err := c.update("Attach", handle, func(container *container) error {
+		var found bool
+
+		attached, found = container.processes[processID]
+		if !found {
+			return api.ErrProcessNotFound
+		}
+
+		attached.attach(processIO, false)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attached, nil
+}
+
+// Reconnect fails with api.ErrUnsupportedOperation, as the fake gives out no
+// reconnect tokens.
+func (c *Connection) Reconnect(handle string, processID uint32, spec api.ReconnectSpec, processIO api.ProcessIO) (api.Process, error) {
+	return nil, api.ErrUnsupportedOperation
+}
+
+func (c *Connection) AttachAll(handle string, processIO func(uint32) api.ProcessIO) ([]api.Process, error) {
+	processes := []api.Process{}
+
+	err := c.update("AttachAll", handle, func(container *container) error {
+		for _, id := range container.info().ProcessIDs {
+			attached := container.processes[id]
+			attached.attach(processIO(id), false)
+
+			processes = append(processes, attached)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// ProcessInfo reports a running process, which uses no resources. Processes
+// are forgotten once they exit.
+func (c *Connection) ProcessInfo(handle string, processID uint32) (api.ProcessInfo, error) {
+	var info api.ProcessInfo
+
+	err := c.update("ProcessInfo", handle, func(container *container) error {
+		running, found := container.processes[processID]
+		if !found {
+			return api.ErrProcessNotFound
+		}
+
+		info = api.ProcessInfo{
+			ID:        processID,
+			State:     api.ProcessStateRunning,
+			StartedAt: running.startedAt,
+		}
+
+		return nil
+	})
+
+	return info, err
+}
+
+// FindProcesses returns the running processes which were run with every one of
+// the labels.
+func (c *Connection) FindProcesses(handle string, labels map[string]string) ([]uint32, error) {
+	found := []uint32{}
+
+	err := c.update("FindProcesses", handle, func(container *container) error {
+		for _, id := range container.info().ProcessIDs {
+			if container.processes[id].hasLabels(labels) {
+				found = append(found, id)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// WaitForProcess polls for a running process matching the matcher.
+func (c *Connection) WaitForProcess(handle string, matcher api.ProcessMatcher, timeout time.Duration) (uint32, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		var found uint32
+
+		err := c.update("WaitForProcess", handle, func(container *container) error {
+			for _, id := range container.info().ProcessIDs {
+				if (matcher.ID == 0 || id == matcher.ID) && container.processes[id].hasLabels(matcher.Labels) {
+					found = id
+					return nil
+				}
+			}
+
+			return api.ErrProcessNotFound
+		})
+		if err != api.ErrProcessNotFound {
+			return found, err
+		}
+
+		if time.Now().After(deadline) {
+			return 0, err
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type process struct {
+	id        uint32
+	startedAt time.Time
+	labels    map[string]string
+
+	stdinReader *io.PipeReader
+	stdinWriter *io.PipeWriter
+
+	stdout *fanOut
+	stderr *fanOut
+
+	done       chan struct{}
+	exitStatus int
+	exitErr    error
+}
+
+func newProcess(id uint32) *process {
+	stdinReader, stdinWriter := io.Pipe()
+
+	return &process{
+		id:        id,
+		startedAt: time.Now(),
+
+		stdinReader: stdinReader,
+		stdinWriter: stdinWriter,
+
+		stdout: &fanOut{},
+		stderr: &fanOut{},
+
+		done: make(chan struct{}),
+	}
+}
+
+func (p *process) ID() uint32 {
+	return p.id
+}
+
+func (p *process) hasLabels(labels map[string]string) bool {
+	for key, value := range labels {
+		if actual, found := p.labels[key]; !found || actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *process) Wait() (int, error) {
+	<-p.done
+	return p.exitStatus, p.exitErr
+}
+
+func (p *process) WaitWithTimeout(timeout time.Duration) (int, error) {
+	select {
+	case <-p.done:
+		return p.exitStatus, p.exitErr
+	case <-time.After(timeout):
+		return 0, connection.ErrWaitTimedOut
+	}
+}
+
+func (p *process) Exited() (bool, int) {
+	select {
+	case <-p.done:
+		return true, p.exitStatus
+	default:
+		return false, 0
+	}
+}
+
+func (p *process) SetTTY(api.TTYSpec) error {
+	return nil
+}
+
+func (p *process) SetRlimits(api.ResourceLimits) error {
+	return nil
+}
+
+func (p *process) AllocateTTY(api.TTYSpec) error {
+	return nil
+}
+
+// attach streams the process's output to the io, and the io's stdin to the
+// process. Only the stdin of the client which ran the process closes the
+// process's on EOF, as with the server.
+func (p *process) attach(processIO api.ProcessIO, running bool) {
+	p.stdout.add(processIO.Stdout)
+	p.stderr.add(processIO.Stderr)
+
+	if processIO.Stdin == nil {
+		if running {
+			p.stdinWriter.Close()
+		}
+
+		return
+	}
+
+	go func() {
+		io.Copy(p.stdinWriter, processIO.Stdin)
+
+		if running {
+			p.stdinWriter.Close()
+		}
+	}()
+}
+
+func (p *process) exited(exitStatus int, err error) {
+	p.exitStatus = exitStatus
+	p.exitErr = err
+
+	// unblock clients still writing stdin the script did not read
+	p.stdinReader.Close()
+
+	close(p.done)
+}
+
+// fanOut writes to each of the writers attached to a process's output.
+type fanOut struct {
+	writers []io.Writer
+	l       sync.Mutex
+}
+
+func (f *fanOut) add(w io.Writer) {
+	if w == nil {
+		return
+	}
+
+	f.l.Lock()
+	f.writers = append(f.writers, w)
+	f.l.Unlock()
+}
+
+func (f *fanOut) Write(data []byte) (int, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	for _, w := range f.writers {
+		w.Write(data)
+	}
+
+	return len(data), nil
+}
@@ -4,6 +4,7 @@ package fakes
 import (
 	"io"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden/api"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
@@ -16,6 +17,27 @@ type FakeConnection struct {
 	pingReturns struct {
 		result1 error
 	}
+	ProtocolVersionStub        func() (uint32, error)
+	protocolVersionMutex       sync.RWMutex
+	protocolVersionArgsForCall []struct{}
+	protocolVersionReturns     struct {
+		result1 uint32
+		result2 error
+	}
+	ServerVersionStub        func() (api.ServerVersion, error)
+	serverVersionMutex       sync.RWMutex
+	serverVersionArgsForCall []struct{}
+	serverVersionReturns     struct {
+		result1 api.ServerVersion
+		result2 error
+	}
+	HealthCheckStub        func() (api.HealthStatus, error)
+	healthCheckMutex       sync.RWMutex
+	healthCheckArgsForCall []struct{}
+	healthCheckReturns     struct {
+		result1 api.HealthStatus
+		result2 error
+	}
 	CapacityStub        func() (api.Capacity, error)
 	capacityMutex       sync.RWMutex
 	capacityArgsForCall []struct{}
@@ -23,14 +45,22 @@ type FakeConnection struct {
 		result1 api.Capacity
 		result2 error
 	}
-	CreateStub        func(spec api.ContainerSpec) (string, error)
+	FreshCapacityStub        func() (api.Capacity, error)
+	freshCapacityMutex       sync.RWMutex
+	freshCapacityArgsForCall []struct{}
+	freshCapacityReturns     struct {
+		result1 api.Capacity
+		result2 error
+	}
+	CreateStub        func(spec api.ContainerSpec) (string, *api.ContainerInfo, error)
 	createMutex       sync.RWMutex
 	createArgsForCall []struct {
 		spec api.ContainerSpec
 	}
 	createReturns struct {
 		result1 string
-		result2 error
+		result2 *api.ContainerInfo
+		result3 error
 	}
 	ListStub        func(properties api.Properties) ([]string, error)
 	listMutex       sync.RWMutex
@@ -41,6 +71,43 @@ type FakeConnection struct {
 		result1 []string
 		result2 error
 	}
+	ListWhereStub        func(filters []api.PropertyFilter) ([]string, error)
+	listWhereMutex       sync.RWMutex
+	listWhereArgsForCall []struct {
+		filters []api.PropertyFilter
+	}
+	listWhereReturns struct {
+		result1 []string
+		result2 error
+	}
+	ListVerboseStub        func(properties api.Properties, order api.ListOrder) ([]api.ContainerSummary, error)
+	listVerboseMutex       sync.RWMutex
+	listVerboseArgsForCall []struct {
+		properties api.Properties
+		order      api.ListOrder
+	}
+	listVerboseReturns struct {
+		result1 []api.ContainerSummary
+		result2 error
+	}
+	ListEachStub        func(properties api.Properties, each func(handle string) error) error
+	listEachMutex       sync.RWMutex
+	listEachArgsForCall []struct {
+		properties api.Properties
+		each       func(handle string) error
+	}
+	listEachReturns struct {
+		result1 error
+	}
+	LookupByStub        func(properties api.Properties) (string, error)
+	lookupByMutex       sync.RWMutex
+	lookupByArgsForCall []struct {
+		properties api.Properties
+	}
+	lookupByReturns struct {
+		result1 string
+		result2 error
+	}
 	DestroyStub        func(handle string) error
 	destroyMutex       sync.RWMutex
 	destroyArgsForCall []struct {
@@ -49,6 +116,70 @@ type FakeConnection struct {
 	destroyReturns struct {
 		result1 error
 	}
+	ForceDestroyStub        func(handle string) error
+	forceDestroyMutex       sync.RWMutex
+	forceDestroyArgsForCall []struct {
+		handle string
+	}
+	forceDestroyReturns struct {
+		result1 error
+	}
+	DestroyWithReasonStub        func(handle string, force bool, reason string) error
+	destroyWithReasonMutex       sync.RWMutex
+	destroyWithReasonArgsForCall []struct {
+		handle string
+		force  bool
+		reason string
+	}
+	destroyWithReasonReturns struct {
+		result1 error
+	}
+	DestroyAsyncStub        func(handle string, force bool) (string, error)
+	destroyAsyncMutex       sync.RWMutex
+	destroyAsyncArgsForCall []struct {
+		handle string
+		force  bool
+	}
+	destroyAsyncReturns struct {
+		result1 string
+		result2 error
+	}
+	DestroyOperationStub        func(id string) (api.DestroyOperation, error)
+	destroyOperationMutex       sync.RWMutex
+	destroyOperationArgsForCall []struct {
+		id string
+	}
+	destroyOperationReturns struct {
+		result1 api.DestroyOperation
+		result2 error
+	}
+	RenameStub        func(oldHandle string, newHandle string) error
+	renameMutex       sync.RWMutex
+	renameArgsForCall []struct {
+		oldHandle string
+		newHandle string
+	}
+	renameReturns struct {
+		result1 error
+	}
+	SnapshotStub        func(handle string) (io.ReadCloser, error)
+	snapshotMutex       sync.RWMutex
+	snapshotArgsForCall []struct {
+		handle string
+	}
+	snapshotReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	RestoreStub        func(snapshot io.Reader) (string, error)
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		snapshot io.Reader
+	}
+	restoreReturns struct {
+		result1 string
+		result2 error
+	}
 	StopStub        func(handle string, kill bool) error
 	stopMutex       sync.RWMutex
 	stopArgsForCall []struct {
@@ -58,6 +189,85 @@ type FakeConnection struct {
 	stopReturns struct {
 		result1 error
 	}
+	StopWithReasonStub        func(handle string, kill bool, reason string) error
+	stopWithReasonMutex       sync.RWMutex
+	stopWithReasonArgsForCall []struct {
+		handle string
+		kill   bool
+		reason string
+	}
+	stopWithReasonReturns struct {
+		result1 error
+	}
+	SignalAllStub        func(handle string, signal api.Signal) error
+	signalAllMutex       sync.RWMutex
+	signalAllArgsForCall []struct {
+		handle string
+		signal api.Signal
+	}
+	signalAllReturns struct {
+		result1 error
+	}
+	PauseStub        func(handle string) error
+	pauseMutex       sync.RWMutex
+	pauseArgsForCall []struct {
+		handle string
+	}
+	pauseReturns struct {
+		result1 error
+	}
+	ResumeStub        func(handle string) error
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct {
+		handle string
+	}
+	resumeReturns struct {
+		result1 error
+	}
+	DestroyAtStub        func(handle string, at time.Time) error
+	destroyAtMutex       sync.RWMutex
+	destroyAtArgsForCall []struct {
+		handle string
+		at     time.Time
+	}
+	destroyAtReturns struct {
+		result1 error
+	}
+	DestroyAfterStub        func(handle string, delay time.Duration) error
+	destroyAfterMutex       sync.RWMutex
+	destroyAfterArgsForCall []struct {
+		handle string
+		delay  time.Duration
+	}
+	destroyAfterReturns struct {
+		result1 error
+	}
+	CancelScheduledDestroyStub        func(handle string) error
+	cancelScheduledDestroyMutex       sync.RWMutex
+	cancelScheduledDestroyArgsForCall []struct {
+		handle string
+	}
+	cancelScheduledDestroyReturns struct {
+		result1 error
+	}
+	SetHoldStub        func(handle string, held bool) error
+	setHoldMutex       sync.RWMutex
+	setHoldArgsForCall []struct {
+		handle string
+		held   bool
+	}
+	setHoldReturns struct {
+		result1 error
+	}
+	SetGraceTimeStub        func(handle string, graceTime time.Duration) error
+	setGraceTimeMutex       sync.RWMutex
+	setGraceTimeArgsForCall []struct {
+		handle    string
+		graceTime time.Duration
+	}
+	setGraceTimeReturns struct {
+		result1 error
+	}
 	InfoStub        func(handle string) (api.ContainerInfo, error)
 	infoMutex       sync.RWMutex
 	infoArgsForCall []struct {
@@ -67,26 +277,46 @@ type FakeConnection struct {
 		result1 api.ContainerInfo
 		result2 error
 	}
-	StreamInStub        func(handle string, dstPath string, reader io.Reader) error
+	InfoFieldsStub        func(handle string, fields []api.InfoField) (api.ContainerInfo, error)
+	infoFieldsMutex       sync.RWMutex
+	infoFieldsArgsForCall []struct {
+		handle string
+		fields []api.InfoField
+	}
+	infoFieldsReturns struct {
+		result1 api.ContainerInfo
+		result2 error
+	}
+	StreamInStub        func(handle string, spec api.StreamInSpec) error
 	streamInMutex       sync.RWMutex
 	streamInArgsForCall []struct {
-		handle  string
-		dstPath string
-		reader  io.Reader
+		handle string
+		spec   api.StreamInSpec
 	}
 	streamInReturns struct {
 		result1 error
 	}
-	StreamOutStub        func(handle string, srcPath string) (io.ReadCloser, error)
+	StreamOutStub        func(handle string, spec api.StreamOutSpec) (io.ReadCloser, error)
 	streamOutMutex       sync.RWMutex
 	streamOutArgsForCall []struct {
-		handle  string
-		srcPath string
+		handle string
+		spec   api.StreamOutSpec
 	}
 	streamOutReturns struct {
 		result1 io.ReadCloser
 		result2 error
 	}
+	VerifyStreamInStub        func(handle string, dstPath string, reader io.Reader) (api.StreamInReport, error)
+	verifyStreamInMutex       sync.RWMutex
+	verifyStreamInArgsForCall []struct {
+		handle  string
+		dstPath string
+		reader  io.Reader
+	}
+	verifyStreamInReturns struct {
+		result1 api.StreamInReport
+		result2 error
+	}
 	LimitBandwidthStub        func(handle string, limits api.BandwidthLimits) (api.BandwidthLimits, error)
 	limitBandwidthMutex       sync.RWMutex
 	limitBandwidthArgsForCall []struct {
@@ -154,6 +384,24 @@ type FakeConnection struct {
 		result1 api.DiskLimits
 		result2 error
 	}
+	DiskUsageStub        func(handle string) (api.ContainerDiskUsage, error)
+	diskUsageMutex       sync.RWMutex
+	diskUsageArgsForCall []struct {
+		handle string
+	}
+	diskUsageReturns struct {
+		result1 api.ContainerDiskUsage
+		result2 error
+	}
+	EnvStub        func(handle string) ([]string, error)
+	envMutex       sync.RWMutex
+	envArgsForCall []struct {
+		handle string
+	}
+	envReturns struct {
+		result1 []string
+		result2 error
+	}
 	CurrentMemoryLimitsStub        func(handle string) (api.MemoryLimits, error)
 	currentMemoryLimitsMutex       sync.RWMutex
 	currentMemoryLimitsArgsForCall []struct {
@@ -185,6 +433,70 @@ type FakeConnection struct {
 		result1 api.Process
 		result2 error
 	}
+	ReconnectStub        func(handle string, processID uint32, spec api.ReconnectSpec, io api.ProcessIO) (api.Process, error)
+	reconnectMutex       sync.RWMutex
+	reconnectArgsForCall []struct {
+		handle    string
+		processID uint32
+		spec      api.ReconnectSpec
+		io        api.ProcessIO
+	}
+	reconnectReturns struct {
+		result1 api.Process
+		result2 error
+	}
+	AttachAllStub        func(handle string, io func(uint32) api.ProcessIO) ([]api.Process, error)
+	attachAllMutex       sync.RWMutex
+	attachAllArgsForCall []struct {
+		handle string
+		io     func(uint32) api.ProcessIO
+	}
+	attachAllReturns struct {
+		result1 []api.Process
+		result2 error
+	}
+	ProcessInfoStub        func(handle string, processID uint32) (api.ProcessInfo, error)
+	processInfoMutex       sync.RWMutex
+	processInfoArgsForCall []struct {
+		handle    string
+		processID uint32
+	}
+	processInfoReturns struct {
+		result1 api.ProcessInfo
+		result2 error
+	}
+	FindProcessesStub        func(handle string, labels map[string]string) ([]uint32, error)
+	findProcessesMutex       sync.RWMutex
+	findProcessesArgsForCall []struct {
+		handle string
+		labels map[string]string
+	}
+	findProcessesReturns struct {
+		result1 []uint32
+		result2 error
+	}
+	WaitForProcessStub        func(handle string, matcher api.ProcessMatcher, timeout time.Duration) (uint32, error)
+	waitForProcessMutex       sync.RWMutex
+	waitForProcessArgsForCall []struct {
+		handle  string
+		matcher api.ProcessMatcher
+		timeout time.Duration
+	}
+	waitForProcessReturns struct {
+		result1 uint32
+		result2 error
+	}
+	LogsStub        func(handle string, tail int, follow bool) (api.LogStream, error)
+	logsMutex       sync.RWMutex
+	logsArgsForCall []struct {
+		handle string
+		tail   int
+		follow bool
+	}
+	logsReturns struct {
+		result1 api.LogStream
+		result2 error
+	}
 	NetInStub        func(handle string, hostPort, containerPort uint32) (uint32, uint32, error)
 	netInMutex       sync.RWMutex
 	netInArgsForCall []struct {
@@ -197,6 +509,26 @@ type FakeConnection struct {
 		result2 uint32
 		result3 error
 	}
+	NetInSpecStub        func(handle string, spec api.NetInSpec) (uint32, uint32, error)
+	netInSpecMutex       sync.RWMutex
+	netInSpecArgsForCall []struct {
+		handle string
+		spec   api.NetInSpec
+	}
+	netInSpecReturns struct {
+		result1 uint32
+		result2 uint32
+		result3 error
+	}
+	MappedPortsStub        func(handle string) ([]api.PortMapping, error)
+	mappedPortsMutex       sync.RWMutex
+	mappedPortsArgsForCall []struct {
+		handle string
+	}
+	mappedPortsReturns struct {
+		result1 []api.PortMapping
+		result2 error
+	}
 	NetOutStub        func(handle string, network string, port uint32, portRange string, protocol api.Protocol) error
 	netOutMutex       sync.RWMutex
 	netOutArgsForCall []struct {
@@ -209,6 +541,15 @@ type FakeConnection struct {
 	netOutReturns struct {
 		result1 error
 	}
+	NetOutRuleStub        func(handle string, rule api.NetOutRule) error
+	netOutRuleMutex       sync.RWMutex
+	netOutRuleArgsForCall []struct {
+		handle string
+		rule   api.NetOutRule
+	}
+	netOutRuleReturns struct {
+		result1 error
+	}
 	GetPropertyStub        func(handle string, name string) (string, error)
 	getPropertyMutex       sync.RWMutex
 	getPropertyArgsForCall []struct {
@@ -229,6 +570,27 @@ type FakeConnection struct {
 	setPropertyReturns struct {
 		result1 error
 	}
+	SetPropertiesStub        func(handle string, properties api.Properties) error
+	setPropertiesMutex       sync.RWMutex
+	setPropertiesArgsForCall []struct {
+		handle     string
+		properties api.Properties
+	}
+	setPropertiesReturns struct {
+		result1 error
+	}
+	CompareAndSwapPropertyStub        func(handle string, name string, oldValue string, newValue string) (bool, error)
+	compareAndSwapPropertyMutex       sync.RWMutex
+	compareAndSwapPropertyArgsForCall []struct {
+		handle   string
+		name     string
+		oldValue string
+		newValue string
+	}
+	compareAndSwapPropertyReturns struct {
+		result1 bool
+		result2 error
+	}
 	RemovePropertyStub        func(handle string, name string) error
 	removePropertyMutex       sync.RWMutex
 	removePropertyArgsForCall []struct {
@@ -238,6 +600,16 @@ type FakeConnection struct {
 	removePropertyReturns struct {
 		result1 error
 	}
+	BulkPropertiesStub        func(handles []string, keys []string) (map[string]api.Properties, error)
+	bulkPropertiesMutex       sync.RWMutex
+	bulkPropertiesArgsForCall []struct {
+		handles []string
+		keys    []string
+	}
+	bulkPropertiesReturns struct {
+		result1 map[string]api.Properties
+		result2 error
+	}
 }
 
 func (fake *FakeConnection) Ping() error {
@@ -264,6 +636,81 @@ func (fake *FakeConnection) PingReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) ProtocolVersion() (uint32, error) {
+	fake.protocolVersionMutex.Lock()
+	fake.protocolVersionArgsForCall = append(fake.protocolVersionArgsForCall, struct{}{})
+	fake.protocolVersionMutex.Unlock()
+	if fake.ProtocolVersionStub != nil {
+		return fake.ProtocolVersionStub()
+	} else {
+		return fake.protocolVersionReturns.result1, fake.protocolVersionReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProtocolVersionCallCount() int {
+	fake.protocolVersionMutex.RLock()
+	defer fake.protocolVersionMutex.RUnlock()
+	return len(fake.protocolVersionArgsForCall)
+}
+
+func (fake *FakeConnection) ProtocolVersionReturns(result1 uint32, result2 error) {
+	fake.ProtocolVersionStub = nil
+	fake.protocolVersionReturns = struct {
+		result1 uint32
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ServerVersion() (api.ServerVersion, error) {
+	fake.serverVersionMutex.Lock()
+	fake.serverVersionArgsForCall = append(fake.serverVersionArgsForCall, struct{}{})
+	fake.serverVersionMutex.Unlock()
+	if fake.ServerVersionStub != nil {
+		return fake.ServerVersionStub()
+	} else {
+		return fake.serverVersionReturns.result1, fake.serverVersionReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ServerVersionCallCount() int {
+	fake.serverVersionMutex.RLock()
+	defer fake.serverVersionMutex.RUnlock()
+	return len(fake.serverVersionArgsForCall)
+}
+
+func (fake *FakeConnection) ServerVersionReturns(result1 api.ServerVersion, result2 error) {
+	fake.ServerVersionStub = nil
+	fake.serverVersionReturns = struct {
+		result1 api.ServerVersion
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) HealthCheck() (api.HealthStatus, error) {
+	fake.healthCheckMutex.Lock()
+	fake.healthCheckArgsForCall = append(fake.healthCheckArgsForCall, struct{}{})
+	fake.healthCheckMutex.Unlock()
+	if fake.HealthCheckStub != nil {
+		return fake.HealthCheckStub()
+	} else {
+		return fake.healthCheckReturns.result1, fake.healthCheckReturns.result2
+	}
+}
+
+func (fake *FakeConnection) HealthCheckCallCount() int {
+	fake.healthCheckMutex.RLock()
+	defer fake.healthCheckMutex.RUnlock()
+	return len(fake.healthCheckArgsForCall)
+}
+
+func (fake *FakeConnection) HealthCheckReturns(result1 api.HealthStatus, result2 error) {
+	fake.HealthCheckStub = nil
+	fake.healthCheckReturns = struct {
+		result1 api.HealthStatus
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) Capacity() (api.Capacity, error) {
 	fake.capacityMutex.Lock()
 	fake.capacityArgsForCall = append(fake.capacityArgsForCall, struct{}{})
@@ -289,7 +736,32 @@ func (fake *FakeConnection) CapacityReturns(result1 api.Capacity, result2 error)
 	}{result1, result2}
 }
 
-func (fake *FakeConnection) Create(spec api.ContainerSpec) (string, error) {
+func (fake *FakeConnection) FreshCapacity() (api.Capacity, error) {
+	fake.freshCapacityMutex.Lock()
+	fake.freshCapacityArgsForCall = append(fake.freshCapacityArgsForCall, struct{}{})
+	fake.freshCapacityMutex.Unlock()
+	if fake.FreshCapacityStub != nil {
+		return fake.FreshCapacityStub()
+	} else {
+		return fake.freshCapacityReturns.result1, fake.freshCapacityReturns.result2
+	}
+}
+
+func (fake *FakeConnection) FreshCapacityCallCount() int {
+	fake.freshCapacityMutex.RLock()
+	defer fake.freshCapacityMutex.RUnlock()
+	return len(fake.freshCapacityArgsForCall)
+}
+
+func (fake *FakeConnection) FreshCapacityReturns(result1 api.Capacity, result2 error) {
+	fake.FreshCapacityStub = nil
+	fake.freshCapacityReturns = struct {
+		result1 api.Capacity
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Create(spec api.ContainerSpec) (string, *api.ContainerInfo, error) {
 	fake.createMutex.Lock()
 	fake.createArgsForCall = append(fake.createArgsForCall, struct {
 		spec api.ContainerSpec
@@ -298,7 +770,7 @@ func (fake *FakeConnection) Create(spec api.ContainerSpec) (string, error) {
 	if fake.CreateStub != nil {
 		return fake.CreateStub(spec)
 	} else {
-		return fake.createReturns.result1, fake.createReturns.result2
+		return fake.createReturns.result1, fake.createReturns.result2, fake.createReturns.result3
 	}
 }
 
@@ -314,12 +786,13 @@ func (fake *FakeConnection) CreateArgsForCall(i int) api.ContainerSpec {
 	return fake.createArgsForCall[i].spec
 }
 
-func (fake *FakeConnection) CreateReturns(result1 string, result2 error) {
+func (fake *FakeConnection) CreateReturns(result1 string, result2 *api.ContainerInfo, result3 error) {
 	fake.CreateStub = nil
 	fake.createReturns = struct {
 		result1 string
-		result2 error
-	}{result1, result2}
+		result2 *api.ContainerInfo
+		result3 error
+	}{result1, result2, result3}
 }
 
 func (fake *FakeConnection) List(properties api.Properties) ([]string, error) {
@@ -355,6 +828,139 @@ func (fake *FakeConnection) ListReturns(result1 []string, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) ListWhere(filters []api.PropertyFilter) ([]string, error) {
+	fake.listWhereMutex.Lock()
+	fake.listWhereArgsForCall = append(fake.listWhereArgsForCall, struct {
+		filters []api.PropertyFilter
+	}{filters})
+	fake.listWhereMutex.Unlock()
+	if fake.ListWhereStub != nil {
+		return fake.ListWhereStub(filters)
+	} else {
+		return fake.listWhereReturns.result1, fake.listWhereReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ListWhereCallCount() int {
+	fake.listWhereMutex.RLock()
+	defer fake.listWhereMutex.RUnlock()
+	return len(fake.listWhereArgsForCall)
+}
+
+func (fake *FakeConnection) ListWhereArgsForCall(i int) []api.PropertyFilter {
+	fake.listWhereMutex.RLock()
+	defer fake.listWhereMutex.RUnlock()
+	return fake.listWhereArgsForCall[i].filters
+}
+
+func (fake *FakeConnection) ListWhereReturns(result1 []string, result2 error) {
+	fake.ListWhereStub = nil
+	fake.listWhereReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ListVerbose(properties api.Properties, order api.ListOrder) ([]api.ContainerSummary, error) {
+	fake.listVerboseMutex.Lock()
+	fake.listVerboseArgsForCall = append(fake.listVerboseArgsForCall, struct {
+		properties api.Properties
+		order      api.ListOrder
+	}{properties, order})
+	fake.listVerboseMutex.Unlock()
+	if fake.ListVerboseStub != nil {
+		return fake.ListVerboseStub(properties, order)
+	} else {
+		return fake.listVerboseReturns.result1, fake.listVerboseReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ListVerboseCallCount() int {
+	fake.listVerboseMutex.RLock()
+	defer fake.listVerboseMutex.RUnlock()
+	return len(fake.listVerboseArgsForCall)
+}
+
+func (fake *FakeConnection) ListVerboseArgsForCall(i int) (api.Properties, api.ListOrder) {
+	fake.listVerboseMutex.RLock()
+	defer fake.listVerboseMutex.RUnlock()
+	return fake.listVerboseArgsForCall[i].properties, fake.listVerboseArgsForCall[i].order
+}
+
+func (fake *FakeConnection) ListVerboseReturns(result1 []api.ContainerSummary, result2 error) {
+	fake.ListVerboseStub = nil
+	fake.listVerboseReturns = struct {
+		result1 []api.ContainerSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ListEach(properties api.Properties, each func(handle string) error) error {
+	fake.listEachMutex.Lock()
+	fake.listEachArgsForCall = append(fake.listEachArgsForCall, struct {
+		properties api.Properties
+		each       func(handle string) error
+	}{properties, each})
+	fake.listEachMutex.Unlock()
+	if fake.ListEachStub != nil {
+		return fake.ListEachStub(properties, each)
+	} else {
+		return fake.listEachReturns.result1
+	}
+}
+
+func (fake *FakeConnection) ListEachCallCount() int {
+	fake.listEachMutex.RLock()
+	defer fake.listEachMutex.RUnlock()
+	return len(fake.listEachArgsForCall)
+}
+
+func (fake *FakeConnection) ListEachArgsForCall(i int) (api.Properties, func(handle string) error) {
+	fake.listEachMutex.RLock()
+	defer fake.listEachMutex.RUnlock()
+	return fake.listEachArgsForCall[i].properties, fake.listEachArgsForCall[i].each
+}
+
+func (fake *FakeConnection) ListEachReturns(result1 error) {
+	fake.ListEachStub = nil
+	fake.listEachReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) LookupBy(properties api.Properties) (string, error) {
+	fake.lookupByMutex.Lock()
+	fake.lookupByArgsForCall = append(fake.lookupByArgsForCall, struct {
+		properties api.Properties
+	}{properties})
+	fake.lookupByMutex.Unlock()
+	if fake.LookupByStub != nil {
+		return fake.LookupByStub(properties)
+	} else {
+		return fake.lookupByReturns.result1, fake.lookupByReturns.result2
+	}
+}
+
+func (fake *FakeConnection) LookupByCallCount() int {
+	fake.lookupByMutex.RLock()
+	defer fake.lookupByMutex.RUnlock()
+	return len(fake.lookupByArgsForCall)
+}
+
+func (fake *FakeConnection) LookupByArgsForCall(i int) api.Properties {
+	fake.lookupByMutex.RLock()
+	defer fake.lookupByMutex.RUnlock()
+	return fake.lookupByArgsForCall[i].properties
+}
+
+func (fake *FakeConnection) LookupByReturns(result1 string, result2 error) {
+	fake.LookupByStub = nil
+	fake.lookupByReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) Destroy(handle string) error {
 	fake.destroyMutex.Lock()
 	fake.destroyArgsForCall = append(fake.destroyArgsForCall, struct {
@@ -380,42 +986,569 @@ func (fake *FakeConnection) DestroyArgsForCall(i int) string {
 	return fake.destroyArgsForCall[i].handle
 }
 
-func (fake *FakeConnection) DestroyReturns(result1 error) {
-	fake.DestroyStub = nil
-	fake.destroyReturns = struct {
+func (fake *FakeConnection) DestroyReturns(result1 error) {
+	fake.DestroyStub = nil
+	fake.destroyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) ForceDestroy(handle string) error {
+	fake.forceDestroyMutex.Lock()
+	fake.forceDestroyArgsForCall = append(fake.forceDestroyArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.forceDestroyMutex.Unlock()
+	if fake.ForceDestroyStub != nil {
+		return fake.ForceDestroyStub(handle)
+	} else {
+		return fake.forceDestroyReturns.result1
+	}
+}
+
+func (fake *FakeConnection) ForceDestroyCallCount() int {
+	fake.forceDestroyMutex.RLock()
+	defer fake.forceDestroyMutex.RUnlock()
+	return len(fake.forceDestroyArgsForCall)
+}
+
+func (fake *FakeConnection) ForceDestroyArgsForCall(i int) string {
+	fake.forceDestroyMutex.RLock()
+	defer fake.forceDestroyMutex.RUnlock()
+	return fake.forceDestroyArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) ForceDestroyReturns(result1 error) {
+	fake.ForceDestroyStub = nil
+	fake.forceDestroyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) DestroyWithReason(handle string, force bool, reason string) error {
+	fake.destroyWithReasonMutex.Lock()
+	fake.destroyWithReasonArgsForCall = append(fake.destroyWithReasonArgsForCall, struct {
+		handle string
+		force  bool
+		reason string
+	}{handle, force, reason})
+	fake.destroyWithReasonMutex.Unlock()
+	if fake.DestroyWithReasonStub != nil {
+		return fake.DestroyWithReasonStub(handle, force, reason)
+	} else {
+		return fake.destroyWithReasonReturns.result1
+	}
+}
+
+func (fake *FakeConnection) DestroyWithReasonCallCount() int {
+	fake.destroyWithReasonMutex.RLock()
+	defer fake.destroyWithReasonMutex.RUnlock()
+	return len(fake.destroyWithReasonArgsForCall)
+}
+
+func (fake *FakeConnection) DestroyWithReasonArgsForCall(i int) (string, bool, string) {
+	fake.destroyWithReasonMutex.RLock()
+	defer fake.destroyWithReasonMutex.RUnlock()
+	return fake.destroyWithReasonArgsForCall[i].handle, fake.destroyWithReasonArgsForCall[i].force, fake.destroyWithReasonArgsForCall[i].reason
+}
+
+func (fake *FakeConnection) DestroyWithReasonReturns(result1 error) {
+	fake.DestroyWithReasonStub = nil
+	fake.destroyWithReasonReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) DestroyAsync(handle string, force bool) (string, error) {
+	fake.destroyAsyncMutex.Lock()
+	fake.destroyAsyncArgsForCall = append(fake.destroyAsyncArgsForCall, struct {
+		handle string
+		force  bool
+	}{handle, force})
+	fake.destroyAsyncMutex.Unlock()
+	if fake.DestroyAsyncStub != nil {
+		return fake.DestroyAsyncStub(handle, force)
+	} else {
+		return fake.destroyAsyncReturns.result1, fake.destroyAsyncReturns.result2
+	}
+}
+
+func (fake *FakeConnection) DestroyAsyncCallCount() int {
+	fake.destroyAsyncMutex.RLock()
+	defer fake.destroyAsyncMutex.RUnlock()
+	return len(fake.destroyAsyncArgsForCall)
+}
+
+func (fake *FakeConnection) DestroyAsyncArgsForCall(i int) (string, bool) {
+	fake.destroyAsyncMutex.RLock()
+	defer fake.destroyAsyncMutex.RUnlock()
+	return fake.destroyAsyncArgsForCall[i].handle, fake.destroyAsyncArgsForCall[i].force
+}
+
+func (fake *FakeConnection) DestroyAsyncReturns(result1 string, result2 error) {
+	fake.DestroyAsyncStub = nil
+	fake.destroyAsyncReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) DestroyOperation(id string) (api.DestroyOperation, error) {
+	fake.destroyOperationMutex.Lock()
+	fake.destroyOperationArgsForCall = append(fake.destroyOperationArgsForCall, struct {
+		id string
+	}{id})
+	fake.destroyOperationMutex.Unlock()
+	if fake.DestroyOperationStub != nil {
+		return fake.DestroyOperationStub(id)
+	} else {
+		return fake.destroyOperationReturns.result1, fake.destroyOperationReturns.result2
+	}
+}
+
+func (fake *FakeConnection) DestroyOperationCallCount() int {
+	fake.destroyOperationMutex.RLock()
+	defer fake.destroyOperationMutex.RUnlock()
+	return len(fake.destroyOperationArgsForCall)
+}
+
+func (fake *FakeConnection) DestroyOperationArgsForCall(i int) string {
+	fake.destroyOperationMutex.RLock()
+	defer fake.destroyOperationMutex.RUnlock()
+	return fake.destroyOperationArgsForCall[i].id
+}
+
+func (fake *FakeConnection) DestroyOperationReturns(result1 api.DestroyOperation, result2 error) {
+	fake.DestroyOperationStub = nil
+	fake.destroyOperationReturns = struct {
+		result1 api.DestroyOperation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Rename(oldHandle string, newHandle string) error {
+	fake.renameMutex.Lock()
+	fake.renameArgsForCall = append(fake.renameArgsForCall, struct {
+		oldHandle string
+		newHandle string
+	}{oldHandle, newHandle})
+	fake.renameMutex.Unlock()
+	if fake.RenameStub != nil {
+		return fake.RenameStub(oldHandle, newHandle)
+	} else {
+		return fake.renameReturns.result1
+	}
+}
+
+func (fake *FakeConnection) RenameCallCount() int {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return len(fake.renameArgsForCall)
+}
+
+func (fake *FakeConnection) RenameArgsForCall(i int) (string, string) {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return fake.renameArgsForCall[i].oldHandle, fake.renameArgsForCall[i].newHandle
+}
+
+func (fake *FakeConnection) RenameReturns(result1 error) {
+	fake.RenameStub = nil
+	fake.renameReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) Snapshot(handle string) (io.ReadCloser, error) {
+	fake.snapshotMutex.Lock()
+	fake.snapshotArgsForCall = append(fake.snapshotArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.snapshotMutex.Unlock()
+	if fake.SnapshotStub != nil {
+		return fake.SnapshotStub(handle)
+	} else {
+		return fake.snapshotReturns.result1, fake.snapshotReturns.result2
+	}
+}
+
+func (fake *FakeConnection) SnapshotCallCount() int {
+	fake.snapshotMutex.RLock()
+	defer fake.snapshotMutex.RUnlock()
+	return len(fake.snapshotArgsForCall)
+}
+
+func (fake *FakeConnection) SnapshotArgsForCall(i int) string {
+	fake.snapshotMutex.RLock()
+	defer fake.snapshotMutex.RUnlock()
+	return fake.snapshotArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) SnapshotReturns(result1 io.ReadCloser, result2 error) {
+	fake.SnapshotStub = nil
+	fake.snapshotReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Restore(snapshot io.Reader) (string, error) {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		snapshot io.Reader
+	}{snapshot})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(snapshot)
+	} else {
+		return fake.restoreReturns.result1, fake.restoreReturns.result2
+	}
+}
+
+func (fake *FakeConnection) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeConnection) RestoreArgsForCall(i int) io.Reader {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].snapshot
+}
+
+func (fake *FakeConnection) RestoreReturns(result1 string, result2 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Stop(handle string, kill bool) error {
+	fake.stopMutex.Lock()
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
+		handle string
+		kill   bool
+	}{handle, kill})
+	fake.stopMutex.Unlock()
+	if fake.StopStub != nil {
+		return fake.StopStub(handle, kill)
+	} else {
+		return fake.stopReturns.result1
+	}
+}
+
+func (fake *FakeConnection) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+func (fake *FakeConnection) StopArgsForCall(i int) (string, bool) {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return fake.stopArgsForCall[i].handle, fake.stopArgsForCall[i].kill
+}
+
+func (fake *FakeConnection) StopReturns(result1 error) {
+	fake.StopStub = nil
+	fake.stopReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) StopWithReason(handle string, kill bool, reason string) error {
+	fake.stopWithReasonMutex.Lock()
+	fake.stopWithReasonArgsForCall = append(fake.stopWithReasonArgsForCall, struct {
+		handle string
+		kill   bool
+		reason string
+	}{handle, kill, reason})
+	fake.stopWithReasonMutex.Unlock()
+	if fake.StopWithReasonStub != nil {
+		return fake.StopWithReasonStub(handle, kill, reason)
+	} else {
+		return fake.stopWithReasonReturns.result1
+	}
+}
+
+func (fake *FakeConnection) StopWithReasonCallCount() int {
+	fake.stopWithReasonMutex.RLock()
+	defer fake.stopWithReasonMutex.RUnlock()
+	return len(fake.stopWithReasonArgsForCall)
+}
+
+func (fake *FakeConnection) StopWithReasonArgsForCall(i int) (string, bool, string) {
+	fake.stopWithReasonMutex.RLock()
+	defer fake.stopWithReasonMutex.RUnlock()
+	return fake.stopWithReasonArgsForCall[i].handle, fake.stopWithReasonArgsForCall[i].kill, fake.stopWithReasonArgsForCall[i].reason
+}
+
+func (fake *FakeConnection) StopWithReasonReturns(result1 error) {
+	fake.StopWithReasonStub = nil
+	fake.stopWithReasonReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) SignalAll(handle string, signal api.Signal) error {
+	fake.signalAllMutex.Lock()
+	fake.signalAllArgsForCall = append(fake.signalAllArgsForCall, struct {
+		handle string
+		signal api.Signal
+	}{handle, signal})
+	fake.signalAllMutex.Unlock()
+	if fake.SignalAllStub != nil {
+		return fake.SignalAllStub(handle, signal)
+	} else {
+		return fake.signalAllReturns.result1
+	}
+}
+
+func (fake *FakeConnection) SignalAllCallCount() int {
+	fake.signalAllMutex.RLock()
+	defer fake.signalAllMutex.RUnlock()
+	return len(fake.signalAllArgsForCall)
+}
+
+func (fake *FakeConnection) SignalAllArgsForCall(i int) (string, api.Signal) {
+	fake.signalAllMutex.RLock()
+	defer fake.signalAllMutex.RUnlock()
+	return fake.signalAllArgsForCall[i].handle, fake.signalAllArgsForCall[i].signal
+}
+
+func (fake *FakeConnection) SignalAllReturns(result1 error) {
+	fake.SignalAllStub = nil
+	fake.signalAllReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) Pause(handle string) error {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		return fake.PauseStub(handle)
+	} else {
+		return fake.pauseReturns.result1
+	}
+}
+
+func (fake *FakeConnection) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeConnection) PauseArgsForCall(i int) string {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return fake.pauseArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) PauseReturns(result1 error) {
+	fake.PauseStub = nil
+	fake.pauseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) Resume(handle string) error {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		return fake.ResumeStub(handle)
+	} else {
+		return fake.resumeReturns.result1
+	}
+}
+
+func (fake *FakeConnection) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+func (fake *FakeConnection) ResumeArgsForCall(i int) string {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return fake.resumeArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) ResumeReturns(result1 error) {
+	fake.ResumeStub = nil
+	fake.resumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) DestroyAt(handle string, at time.Time) error {
+	fake.destroyAtMutex.Lock()
+	fake.destroyAtArgsForCall = append(fake.destroyAtArgsForCall, struct {
+		handle string
+		at     time.Time
+	}{handle, at})
+	fake.destroyAtMutex.Unlock()
+	if fake.DestroyAtStub != nil {
+		return fake.DestroyAtStub(handle, at)
+	} else {
+		return fake.destroyAtReturns.result1
+	}
+}
+
+func (fake *FakeConnection) DestroyAtCallCount() int {
+	fake.destroyAtMutex.RLock()
+	defer fake.destroyAtMutex.RUnlock()
+	return len(fake.destroyAtArgsForCall)
+}
+
+func (fake *FakeConnection) DestroyAtArgsForCall(i int) (string, time.Time) {
+	fake.destroyAtMutex.RLock()
+	defer fake.destroyAtMutex.RUnlock()
+	return fake.destroyAtArgsForCall[i].handle, fake.destroyAtArgsForCall[i].at
+}
+
+func (fake *FakeConnection) DestroyAtReturns(result1 error) {
+	fake.DestroyAtStub = nil
+	fake.destroyAtReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) DestroyAfter(handle string, delay time.Duration) error {
+	fake.destroyAfterMutex.Lock()
+	fake.destroyAfterArgsForCall = append(fake.destroyAfterArgsForCall, struct {
+		handle string
+		delay  time.Duration
+	}{handle, delay})
+	fake.destroyAfterMutex.Unlock()
+	if fake.DestroyAfterStub != nil {
+		return fake.DestroyAfterStub(handle, delay)
+	} else {
+		return fake.destroyAfterReturns.result1
+	}
+}
+
+func (fake *FakeConnection) DestroyAfterCallCount() int {
+	fake.destroyAfterMutex.RLock()
+	defer fake.destroyAfterMutex.RUnlock()
+	return len(fake.destroyAfterArgsForCall)
+}
+
+func (fake *FakeConnection) DestroyAfterArgsForCall(i int) (string, time.Duration) {
+	fake.destroyAfterMutex.RLock()
+	defer fake.destroyAfterMutex.RUnlock()
+	return fake.destroyAfterArgsForCall[i].handle, fake.destroyAfterArgsForCall[i].delay
+}
+
+func (fake *FakeConnection) DestroyAfterReturns(result1 error) {
+	fake.DestroyAfterStub = nil
+	fake.destroyAfterReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) CancelScheduledDestroy(handle string) error {
+	fake.cancelScheduledDestroyMutex.Lock()
+	fake.cancelScheduledDestroyArgsForCall = append(fake.cancelScheduledDestroyArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.cancelScheduledDestroyMutex.Unlock()
+	if fake.CancelScheduledDestroyStub != nil {
+		return fake.CancelScheduledDestroyStub(handle)
+	} else {
+		return fake.cancelScheduledDestroyReturns.result1
+	}
+}
+
+func (fake *FakeConnection) CancelScheduledDestroyCallCount() int {
+	fake.cancelScheduledDestroyMutex.RLock()
+	defer fake.cancelScheduledDestroyMutex.RUnlock()
+	return len(fake.cancelScheduledDestroyArgsForCall)
+}
+
+func (fake *FakeConnection) CancelScheduledDestroyArgsForCall(i int) string {
+	fake.cancelScheduledDestroyMutex.RLock()
+	defer fake.cancelScheduledDestroyMutex.RUnlock()
+	return fake.cancelScheduledDestroyArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) CancelScheduledDestroyReturns(result1 error) {
+	fake.CancelScheduledDestroyStub = nil
+	fake.cancelScheduledDestroyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) SetHold(handle string, held bool) error {
+	fake.setHoldMutex.Lock()
+	fake.setHoldArgsForCall = append(fake.setHoldArgsForCall, struct {
+		handle string
+		held   bool
+	}{handle, held})
+	fake.setHoldMutex.Unlock()
+	if fake.SetHoldStub != nil {
+		return fake.SetHoldStub(handle, held)
+	} else {
+		return fake.setHoldReturns.result1
+	}
+}
+
+func (fake *FakeConnection) SetHoldCallCount() int {
+	fake.setHoldMutex.RLock()
+	defer fake.setHoldMutex.RUnlock()
+	return len(fake.setHoldArgsForCall)
+}
+
+func (fake *FakeConnection) SetHoldArgsForCall(i int) (string, bool) {
+	fake.setHoldMutex.RLock()
+	defer fake.setHoldMutex.RUnlock()
+	return fake.setHoldArgsForCall[i].handle, fake.setHoldArgsForCall[i].held
+}
+
+func (fake *FakeConnection) SetHoldReturns(result1 error) {
+	fake.SetHoldStub = nil
+	fake.setHoldReturns = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *FakeConnection) Stop(handle string, kill bool) error {
-	fake.stopMutex.Lock()
-	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
-		handle string
-		kill   bool
-	}{handle, kill})
-	fake.stopMutex.Unlock()
-	if fake.StopStub != nil {
-		return fake.StopStub(handle, kill)
+func (fake *FakeConnection) SetGraceTime(handle string, graceTime time.Duration) error {
+	fake.setGraceTimeMutex.Lock()
+	fake.setGraceTimeArgsForCall = append(fake.setGraceTimeArgsForCall, struct {
+		handle    string
+		graceTime time.Duration
+	}{handle, graceTime})
+	fake.setGraceTimeMutex.Unlock()
+	if fake.SetGraceTimeStub != nil {
+		return fake.SetGraceTimeStub(handle, graceTime)
 	} else {
-		return fake.stopReturns.result1
+		return fake.setGraceTimeReturns.result1
 	}
 }
 
-func (fake *FakeConnection) StopCallCount() int {
-	fake.stopMutex.RLock()
-	defer fake.stopMutex.RUnlock()
-	return len(fake.stopArgsForCall)
+func (fake *FakeConnection) SetGraceTimeCallCount() int {
+	fake.setGraceTimeMutex.RLock()
+	defer fake.setGraceTimeMutex.RUnlock()
+	return len(fake.setGraceTimeArgsForCall)
 }
 
-func (fake *FakeConnection) StopArgsForCall(i int) (string, bool) {
-	fake.stopMutex.RLock()
-	defer fake.stopMutex.RUnlock()
-	return fake.stopArgsForCall[i].handle, fake.stopArgsForCall[i].kill
+func (fake *FakeConnection) SetGraceTimeArgsForCall(i int) (string, time.Duration) {
+	fake.setGraceTimeMutex.RLock()
+	defer fake.setGraceTimeMutex.RUnlock()
+	return fake.setGraceTimeArgsForCall[i].handle, fake.setGraceTimeArgsForCall[i].graceTime
 }
 
-func (fake *FakeConnection) StopReturns(result1 error) {
-	fake.StopStub = nil
-	fake.stopReturns = struct {
+func (fake *FakeConnection) SetGraceTimeReturns(result1 error) {
+	fake.SetGraceTimeStub = nil
+	fake.setGraceTimeReturns = struct {
 		result1 error
 	}{result1}
 }
@@ -453,16 +1586,49 @@ func (fake *FakeConnection) InfoReturns(result1 api.ContainerInfo, result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeConnection) StreamIn(handle string, dstPath string, reader io.Reader) error {
+func (fake *FakeConnection) InfoFields(handle string, fields []api.InfoField) (api.ContainerInfo, error) {
+	fake.infoFieldsMutex.Lock()
+	fake.infoFieldsArgsForCall = append(fake.infoFieldsArgsForCall, struct {
+		handle string
+		fields []api.InfoField
+	}{handle, fields})
+	fake.infoFieldsMutex.Unlock()
+	if fake.InfoFieldsStub != nil {
+		return fake.InfoFieldsStub(handle, fields)
+	} else {
+		return fake.infoFieldsReturns.result1, fake.infoFieldsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) InfoFieldsCallCount() int {
+	fake.infoFieldsMutex.RLock()
+	defer fake.infoFieldsMutex.RUnlock()
+	return len(fake.infoFieldsArgsForCall)
+}
+
+func (fake *FakeConnection) InfoFieldsArgsForCall(i int) (string, []api.InfoField) {
+	fake.infoFieldsMutex.RLock()
+	defer fake.infoFieldsMutex.RUnlock()
+	return fake.infoFieldsArgsForCall[i].handle, fake.infoFieldsArgsForCall[i].fields
+}
+
+func (fake *FakeConnection) InfoFieldsReturns(result1 api.ContainerInfo, result2 error) {
+	fake.InfoFieldsStub = nil
+	fake.infoFieldsReturns = struct {
+		result1 api.ContainerInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) StreamIn(handle string, spec api.StreamInSpec) error {
 	fake.streamInMutex.Lock()
 	fake.streamInArgsForCall = append(fake.streamInArgsForCall, struct {
-		handle  string
-		dstPath string
-		reader  io.Reader
-	}{handle, dstPath, reader})
+		handle string
+		spec   api.StreamInSpec
+	}{handle, spec})
 	fake.streamInMutex.Unlock()
 	if fake.StreamInStub != nil {
-		return fake.StreamInStub(handle, dstPath, reader)
+		return fake.StreamInStub(handle, spec)
 	} else {
 		return fake.streamInReturns.result1
 	}
@@ -474,10 +1640,10 @@ func (fake *FakeConnection) StreamInCallCount() int {
 	return len(fake.streamInArgsForCall)
 }
 
-func (fake *FakeConnection) StreamInArgsForCall(i int) (string, string, io.Reader) {
+func (fake *FakeConnection) StreamInArgsForCall(i int) (string, api.StreamInSpec) {
 	fake.streamInMutex.RLock()
 	defer fake.streamInMutex.RUnlock()
-	return fake.streamInArgsForCall[i].handle, fake.streamInArgsForCall[i].dstPath, fake.streamInArgsForCall[i].reader
+	return fake.streamInArgsForCall[i].handle, fake.streamInArgsForCall[i].spec
 }
 
 func (fake *FakeConnection) StreamInReturns(result1 error) {
@@ -487,15 +1653,15 @@ func (fake *FakeConnection) StreamInReturns(result1 error) {
 	}{result1}
 }
 
-func (fake *FakeConnection) StreamOut(handle string, srcPath string) (io.ReadCloser, error) {
+func (fake *FakeConnection) StreamOut(handle string, spec api.StreamOutSpec) (io.ReadCloser, error) {
 	fake.streamOutMutex.Lock()
 	fake.streamOutArgsForCall = append(fake.streamOutArgsForCall, struct {
-		handle  string
-		srcPath string
-	}{handle, srcPath})
+		handle string
+		spec   api.StreamOutSpec
+	}{handle, spec})
 	fake.streamOutMutex.Unlock()
 	if fake.StreamOutStub != nil {
-		return fake.StreamOutStub(handle, srcPath)
+		return fake.StreamOutStub(handle, spec)
 	} else {
 		return fake.streamOutReturns.result1, fake.streamOutReturns.result2
 	}
@@ -507,10 +1673,10 @@ func (fake *FakeConnection) StreamOutCallCount() int {
 	return len(fake.streamOutArgsForCall)
 }
 
-func (fake *FakeConnection) StreamOutArgsForCall(i int) (string, string) {
+func (fake *FakeConnection) StreamOutArgsForCall(i int) (string, api.StreamOutSpec) {
 	fake.streamOutMutex.RLock()
 	defer fake.streamOutMutex.RUnlock()
-	return fake.streamOutArgsForCall[i].handle, fake.streamOutArgsForCall[i].srcPath
+	return fake.streamOutArgsForCall[i].handle, fake.streamOutArgsForCall[i].spec
 }
 
 func (fake *FakeConnection) StreamOutReturns(result1 io.ReadCloser, result2 error) {
@@ -521,6 +1687,41 @@ func (fake *FakeConnection) StreamOutReturns(result1 io.ReadCloser, result2 erro
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) VerifyStreamIn(handle string, dstPath string, reader io.Reader) (api.StreamInReport, error) {
+	fake.verifyStreamInMutex.Lock()
+	fake.verifyStreamInArgsForCall = append(fake.verifyStreamInArgsForCall, struct {
+		handle  string
+		dstPath string
+		reader  io.Reader
+	}{handle, dstPath, reader})
+	fake.verifyStreamInMutex.Unlock()
+	if fake.VerifyStreamInStub != nil {
+		return fake.VerifyStreamInStub(handle, dstPath, reader)
+	} else {
+		return fake.verifyStreamInReturns.result1, fake.verifyStreamInReturns.result2
+	}
+}
+
+func (fake *FakeConnection) VerifyStreamInCallCount() int {
+	fake.verifyStreamInMutex.RLock()
+	defer fake.verifyStreamInMutex.RUnlock()
+	return len(fake.verifyStreamInArgsForCall)
+}
+
+func (fake *FakeConnection) VerifyStreamInArgsForCall(i int) (string, string, io.Reader) {
+	fake.verifyStreamInMutex.RLock()
+	defer fake.verifyStreamInMutex.RUnlock()
+	return fake.verifyStreamInArgsForCall[i].handle, fake.verifyStreamInArgsForCall[i].dstPath, fake.verifyStreamInArgsForCall[i].reader
+}
+
+func (fake *FakeConnection) VerifyStreamInReturns(result1 api.StreamInReport, result2 error) {
+	fake.VerifyStreamInStub = nil
+	fake.verifyStreamInReturns = struct {
+		result1 api.StreamInReport
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) LimitBandwidth(handle string, limits api.BandwidthLimits) (api.BandwidthLimits, error) {
 	fake.limitBandwidthMutex.Lock()
 	fake.limitBandwidthArgsForCall = append(fake.limitBandwidthArgsForCall, struct {
@@ -756,6 +1957,72 @@ func (fake *FakeConnection) CurrentDiskLimitsReturns(result1 api.DiskLimits, res
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) DiskUsage(handle string) (api.ContainerDiskUsage, error) {
+	fake.diskUsageMutex.Lock()
+	fake.diskUsageArgsForCall = append(fake.diskUsageArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.diskUsageMutex.Unlock()
+	if fake.DiskUsageStub != nil {
+		return fake.DiskUsageStub(handle)
+	} else {
+		return fake.diskUsageReturns.result1, fake.diskUsageReturns.result2
+	}
+}
+
+func (fake *FakeConnection) DiskUsageCallCount() int {
+	fake.diskUsageMutex.RLock()
+	defer fake.diskUsageMutex.RUnlock()
+	return len(fake.diskUsageArgsForCall)
+}
+
+func (fake *FakeConnection) DiskUsageArgsForCall(i int) string {
+	fake.diskUsageMutex.RLock()
+	defer fake.diskUsageMutex.RUnlock()
+	return fake.diskUsageArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) DiskUsageReturns(result1 api.ContainerDiskUsage, result2 error) {
+	fake.DiskUsageStub = nil
+	fake.diskUsageReturns = struct {
+		result1 api.ContainerDiskUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Env(handle string) ([]string, error) {
+	fake.envMutex.Lock()
+	fake.envArgsForCall = append(fake.envArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.envMutex.Unlock()
+	if fake.EnvStub != nil {
+		return fake.EnvStub(handle)
+	} else {
+		return fake.envReturns.result1, fake.envReturns.result2
+	}
+}
+
+func (fake *FakeConnection) EnvCallCount() int {
+	fake.envMutex.RLock()
+	defer fake.envMutex.RUnlock()
+	return len(fake.envArgsForCall)
+}
+
+func (fake *FakeConnection) EnvArgsForCall(i int) string {
+	fake.envMutex.RLock()
+	defer fake.envMutex.RUnlock()
+	return fake.envArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) EnvReturns(result1 []string, result2 error) {
+	fake.EnvStub = nil
+	fake.envReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) CurrentMemoryLimits(handle string) (api.MemoryLimits, error) {
 	fake.currentMemoryLimitsMutex.Lock()
 	fake.currentMemoryLimitsArgsForCall = append(fake.currentMemoryLimitsArgsForCall, struct {
@@ -859,6 +2126,214 @@ func (fake *FakeConnection) AttachReturns(result1 api.Process, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) Reconnect(handle string, processID uint32, spec api.ReconnectSpec, io api.ProcessIO) (api.Process, error) {
+	fake.reconnectMutex.Lock()
+	fake.reconnectArgsForCall = append(fake.reconnectArgsForCall, struct {
+		handle    string
+		processID uint32
+		spec      api.ReconnectSpec
+		io        api.ProcessIO
+	}{handle, processID, spec, io})
+	fake.reconnectMutex.Unlock()
+	if fake.ReconnectStub != nil {
+		return fake.ReconnectStub(handle, processID, spec, io)
+	} else {
+		return fake.reconnectReturns.result1, fake.reconnectReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ReconnectCallCount() int {
+	fake.reconnectMutex.RLock()
+	defer fake.reconnectMutex.RUnlock()
+	return len(fake.reconnectArgsForCall)
+}
+
+func (fake *FakeConnection) ReconnectArgsForCall(i int) (string, uint32, api.ReconnectSpec, api.ProcessIO) {
+	fake.reconnectMutex.RLock()
+	defer fake.reconnectMutex.RUnlock()
+	return fake.reconnectArgsForCall[i].handle, fake.reconnectArgsForCall[i].processID, fake.reconnectArgsForCall[i].spec, fake.reconnectArgsForCall[i].io
+}
+
+func (fake *FakeConnection) ReconnectReturns(result1 api.Process, result2 error) {
+	fake.ReconnectStub = nil
+	fake.reconnectReturns = struct {
+		result1 api.Process
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) AttachAll(handle string, io func(uint32) api.ProcessIO) ([]api.Process, error) {
+	fake.attachAllMutex.Lock()
+	fake.attachAllArgsForCall = append(fake.attachAllArgsForCall, struct {
+		handle string
+		io     func(uint32) api.ProcessIO
+	}{handle, io})
+	fake.attachAllMutex.Unlock()
+	if fake.AttachAllStub != nil {
+		return fake.AttachAllStub(handle, io)
+	} else {
+		return fake.attachAllReturns.result1, fake.attachAllReturns.result2
+	}
+}
+
+func (fake *FakeConnection) AttachAllCallCount() int {
+	fake.attachAllMutex.RLock()
+	defer fake.attachAllMutex.RUnlock()
+	return len(fake.attachAllArgsForCall)
+}
+
+func (fake *FakeConnection) AttachAllArgsForCall(i int) (string, func(uint32) api.ProcessIO) {
+	fake.attachAllMutex.RLock()
+	defer fake.attachAllMutex.RUnlock()
+	return fake.attachAllArgsForCall[i].handle, fake.attachAllArgsForCall[i].io
+}
+
+func (fake *FakeConnection) AttachAllReturns(result1 []api.Process, result2 error) {
+	fake.AttachAllStub = nil
+	fake.attachAllReturns = struct {
+		result1 []api.Process
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ProcessInfo(handle string, processID uint32) (api.ProcessInfo, error) {
+	fake.processInfoMutex.Lock()
+	fake.processInfoArgsForCall = append(fake.processInfoArgsForCall, struct {
+		handle    string
+		processID uint32
+	}{handle, processID})
+	fake.processInfoMutex.Unlock()
+	if fake.ProcessInfoStub != nil {
+		return fake.ProcessInfoStub(handle, processID)
+	} else {
+		return fake.processInfoReturns.result1, fake.processInfoReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessInfoCallCount() int {
+	fake.processInfoMutex.RLock()
+	defer fake.processInfoMutex.RUnlock()
+	return len(fake.processInfoArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessInfoArgsForCall(i int) (string, uint32) {
+	fake.processInfoMutex.RLock()
+	defer fake.processInfoMutex.RUnlock()
+	return fake.processInfoArgsForCall[i].handle, fake.processInfoArgsForCall[i].processID
+}
+
+func (fake *FakeConnection) ProcessInfoReturns(result1 api.ProcessInfo, result2 error) {
+	fake.ProcessInfoStub = nil
+	fake.processInfoReturns = struct {
+		result1 api.ProcessInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) FindProcesses(handle string, labels map[string]string) ([]uint32, error) {
+	fake.findProcessesMutex.Lock()
+	fake.findProcessesArgsForCall = append(fake.findProcessesArgsForCall, struct {
+		handle string
+		labels map[string]string
+	}{handle, labels})
+	fake.findProcessesMutex.Unlock()
+	if fake.FindProcessesStub != nil {
+		return fake.FindProcessesStub(handle, labels)
+	} else {
+		return fake.findProcessesReturns.result1, fake.findProcessesReturns.result2
+	}
+}
+
+func (fake *FakeConnection) FindProcessesCallCount() int {
+	fake.findProcessesMutex.RLock()
+	defer fake.findProcessesMutex.RUnlock()
+	return len(fake.findProcessesArgsForCall)
+}
+
+func (fake *FakeConnection) FindProcessesArgsForCall(i int) (string, map[string]string) {
+	fake.findProcessesMutex.RLock()
+	defer fake.findProcessesMutex.RUnlock()
+	return fake.findProcessesArgsForCall[i].handle, fake.findProcessesArgsForCall[i].labels
+}
+
+func (fake *FakeConnection) FindProcessesReturns(result1 []uint32, result2 error) {
+	fake.FindProcessesStub = nil
+	fake.findProcessesReturns = struct {
+		result1 []uint32
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) WaitForProcess(handle string, matcher api.ProcessMatcher, timeout time.Duration) (uint32, error) {
+	fake.waitForProcessMutex.Lock()
+	fake.waitForProcessArgsForCall = append(fake.waitForProcessArgsForCall, struct {
+		handle  string
+		matcher api.ProcessMatcher
+		timeout time.Duration
+	}{handle, matcher, timeout})
+	fake.waitForProcessMutex.Unlock()
+	if fake.WaitForProcessStub != nil {
+		return fake.WaitForProcessStub(handle, matcher, timeout)
+	} else {
+		return fake.waitForProcessReturns.result1, fake.waitForProcessReturns.result2
+	}
+}
+
+func (fake *FakeConnection) WaitForProcessCallCount() int {
+	fake.waitForProcessMutex.RLock()
+	defer fake.waitForProcessMutex.RUnlock()
+	return len(fake.waitForProcessArgsForCall)
+}
+
+func (fake *FakeConnection) WaitForProcessArgsForCall(i int) (string, api.ProcessMatcher, time.Duration) {
+	fake.waitForProcessMutex.RLock()
+	defer fake.waitForProcessMutex.RUnlock()
+	return fake.waitForProcessArgsForCall[i].handle, fake.waitForProcessArgsForCall[i].matcher, fake.waitForProcessArgsForCall[i].timeout
+}
+
+func (fake *FakeConnection) WaitForProcessReturns(result1 uint32, result2 error) {
+	fake.WaitForProcessStub = nil
+	fake.waitForProcessReturns = struct {
+		result1 uint32
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Logs(handle string, tail int, follow bool) (api.LogStream, error) {
+	fake.logsMutex.Lock()
+	fake.logsArgsForCall = append(fake.logsArgsForCall, struct {
+		handle string
+		tail   int
+		follow bool
+	}{handle, tail, follow})
+	fake.logsMutex.Unlock()
+	if fake.LogsStub != nil {
+		return fake.LogsStub(handle, tail, follow)
+	} else {
+		return fake.logsReturns.result1, fake.logsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) LogsCallCount() int {
+	fake.logsMutex.RLock()
+	defer fake.logsMutex.RUnlock()
+	return len(fake.logsArgsForCall)
+}
+
+func (fake *FakeConnection) LogsArgsForCall(i int) (string, int, bool) {
+	fake.logsMutex.RLock()
+	defer fake.logsMutex.RUnlock()
+	return fake.logsArgsForCall[i].handle, fake.logsArgsForCall[i].tail, fake.logsArgsForCall[i].follow
+}
+
+func (fake *FakeConnection) LogsReturns(result1 api.LogStream, result2 error) {
+	fake.LogsStub = nil
+	fake.logsReturns = struct {
+		result1 api.LogStream
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) NetIn(handle string, hostPort uint32, containerPort uint32) (uint32, uint32, error) {
 	fake.netInMutex.Lock()
 	fake.netInArgsForCall = append(fake.netInArgsForCall, struct {
@@ -895,6 +2370,74 @@ func (fake *FakeConnection) NetInReturns(result1 uint32, result2 uint32, result3
 	}{result1, result2, result3}
 }
 
+func (fake *FakeConnection) NetInSpec(handle string, spec api.NetInSpec) (uint32, uint32, error) {
+	fake.netInSpecMutex.Lock()
+	fake.netInSpecArgsForCall = append(fake.netInSpecArgsForCall, struct {
+		handle string
+		spec   api.NetInSpec
+	}{handle, spec})
+	fake.netInSpecMutex.Unlock()
+	if fake.NetInSpecStub != nil {
+		return fake.NetInSpecStub(handle, spec)
+	} else {
+		return fake.netInSpecReturns.result1, fake.netInSpecReturns.result2, fake.netInSpecReturns.result3
+	}
+}
+
+func (fake *FakeConnection) NetInSpecCallCount() int {
+	fake.netInSpecMutex.RLock()
+	defer fake.netInSpecMutex.RUnlock()
+	return len(fake.netInSpecArgsForCall)
+}
+
+func (fake *FakeConnection) NetInSpecArgsForCall(i int) (string, api.NetInSpec) {
+	fake.netInSpecMutex.RLock()
+	defer fake.netInSpecMutex.RUnlock()
+	return fake.netInSpecArgsForCall[i].handle, fake.netInSpecArgsForCall[i].spec
+}
+
+func (fake *FakeConnection) NetInSpecReturns(result1 uint32, result2 uint32, result3 error) {
+	fake.NetInSpecStub = nil
+	fake.netInSpecReturns = struct {
+		result1 uint32
+		result2 uint32
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeConnection) MappedPorts(handle string) ([]api.PortMapping, error) {
+	fake.mappedPortsMutex.Lock()
+	fake.mappedPortsArgsForCall = append(fake.mappedPortsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.mappedPortsMutex.Unlock()
+	if fake.MappedPortsStub != nil {
+		return fake.MappedPortsStub(handle)
+	} else {
+		return fake.mappedPortsReturns.result1, fake.mappedPortsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) MappedPortsCallCount() int {
+	fake.mappedPortsMutex.RLock()
+	defer fake.mappedPortsMutex.RUnlock()
+	return len(fake.mappedPortsArgsForCall)
+}
+
+func (fake *FakeConnection) MappedPortsArgsForCall(i int) string {
+	fake.mappedPortsMutex.RLock()
+	defer fake.mappedPortsMutex.RUnlock()
+	return fake.mappedPortsArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) MappedPortsReturns(result1 []api.PortMapping, result2 error) {
+	fake.MappedPortsStub = nil
+	fake.mappedPortsReturns = struct {
+		result1 []api.PortMapping
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) NetOut(handle string, network string, port uint32, portRange string, protocol api.Protocol) error {
 	fake.netOutMutex.Lock()
 	fake.netOutArgsForCall = append(fake.netOutArgsForCall, struct {
@@ -931,6 +2474,39 @@ func (fake *FakeConnection) NetOutReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) NetOutRule(handle string, rule api.NetOutRule) error {
+	fake.netOutRuleMutex.Lock()
+	fake.netOutRuleArgsForCall = append(fake.netOutRuleArgsForCall, struct {
+		handle string
+		rule   api.NetOutRule
+	}{handle, rule})
+	fake.netOutRuleMutex.Unlock()
+	if fake.NetOutRuleStub != nil {
+		return fake.NetOutRuleStub(handle, rule)
+	} else {
+		return fake.netOutRuleReturns.result1
+	}
+}
+
+func (fake *FakeConnection) NetOutRuleCallCount() int {
+	fake.netOutRuleMutex.RLock()
+	defer fake.netOutRuleMutex.RUnlock()
+	return len(fake.netOutRuleArgsForCall)
+}
+
+func (fake *FakeConnection) NetOutRuleArgsForCall(i int) (string, api.NetOutRule) {
+	fake.netOutRuleMutex.RLock()
+	defer fake.netOutRuleMutex.RUnlock()
+	return fake.netOutRuleArgsForCall[i].handle, fake.netOutRuleArgsForCall[i].rule
+}
+
+func (fake *FakeConnection) NetOutRuleReturns(result1 error) {
+	fake.NetOutRuleStub = nil
+	fake.netOutRuleReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConnection) GetProperty(handle string, name string) (string, error) {
 	fake.getPropertyMutex.Lock()
 	fake.getPropertyArgsForCall = append(fake.getPropertyArgsForCall, struct {
@@ -999,6 +2575,75 @@ func (fake *FakeConnection) SetPropertyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) SetProperties(handle string, properties api.Properties) error {
+	fake.setPropertiesMutex.Lock()
+	fake.setPropertiesArgsForCall = append(fake.setPropertiesArgsForCall, struct {
+		handle     string
+		properties api.Properties
+	}{handle, properties})
+	fake.setPropertiesMutex.Unlock()
+	if fake.SetPropertiesStub != nil {
+		return fake.SetPropertiesStub(handle, properties)
+	} else {
+		return fake.setPropertiesReturns.result1
+	}
+}
+
+func (fake *FakeConnection) SetPropertiesCallCount() int {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return len(fake.setPropertiesArgsForCall)
+}
+
+func (fake *FakeConnection) SetPropertiesArgsForCall(i int) (string, api.Properties) {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return fake.setPropertiesArgsForCall[i].handle, fake.setPropertiesArgsForCall[i].properties
+}
+
+func (fake *FakeConnection) SetPropertiesReturns(result1 error) {
+	fake.SetPropertiesStub = nil
+	fake.setPropertiesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) CompareAndSwapProperty(handle string, name string, oldValue string, newValue string) (bool, error) {
+	fake.compareAndSwapPropertyMutex.Lock()
+	fake.compareAndSwapPropertyArgsForCall = append(fake.compareAndSwapPropertyArgsForCall, struct {
+		handle   string
+		name     string
+		oldValue string
+		newValue string
+	}{handle, name, oldValue, newValue})
+	fake.compareAndSwapPropertyMutex.Unlock()
+	if fake.CompareAndSwapPropertyStub != nil {
+		return fake.CompareAndSwapPropertyStub(handle, name, oldValue, newValue)
+	} else {
+		return fake.compareAndSwapPropertyReturns.result1, fake.compareAndSwapPropertyReturns.result2
+	}
+}
+
+func (fake *FakeConnection) CompareAndSwapPropertyCallCount() int {
+	fake.compareAndSwapPropertyMutex.RLock()
+	defer fake.compareAndSwapPropertyMutex.RUnlock()
+	return len(fake.compareAndSwapPropertyArgsForCall)
+}
+
+func (fake *FakeConnection) CompareAndSwapPropertyArgsForCall(i int) (string, string, string, string) {
+	fake.compareAndSwapPropertyMutex.RLock()
+	defer fake.compareAndSwapPropertyMutex.RUnlock()
+	return fake.compareAndSwapPropertyArgsForCall[i].handle, fake.compareAndSwapPropertyArgsForCall[i].name, fake.compareAndSwapPropertyArgsForCall[i].oldValue, fake.compareAndSwapPropertyArgsForCall[i].newValue
+}
+
+func (fake *FakeConnection) CompareAndSwapPropertyReturns(result1 bool, result2 error) {
+	fake.CompareAndSwapPropertyStub = nil
+	fake.compareAndSwapPropertyReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) RemoveProperty(handle string, name string) error {
 	fake.removePropertyMutex.Lock()
 	fake.removePropertyArgsForCall = append(fake.removePropertyArgsForCall, struct {
@@ -1033,3 +2678,37 @@ func (fake *FakeConnection) RemovePropertyReturns(result1 error) {
 }
 
 var _ connection.Connection = new(FakeConnection)
+
+func (fake *FakeConnection) BulkProperties(handles []string, keys []string) (map[string]api.Properties, error) {
+	fake.bulkPropertiesMutex.Lock()
+	fake.bulkPropertiesArgsForCall = append(fake.bulkPropertiesArgsForCall, struct {
+		handles []string
+		keys    []string
+	}{handles, keys})
+	fake.bulkPropertiesMutex.Unlock()
+	if fake.BulkPropertiesStub != nil {
+		return fake.BulkPropertiesStub(handles, keys)
+	} else {
+		return fake.bulkPropertiesReturns.result1, fake.bulkPropertiesReturns.result2
+	}
+}
+
+func (fake *FakeConnection) BulkPropertiesCallCount() int {
+	fake.bulkPropertiesMutex.RLock()
+	defer fake.bulkPropertiesMutex.RUnlock()
+	return len(fake.bulkPropertiesArgsForCall)
+}
+
+func (fake *FakeConnection) BulkPropertiesArgsForCall(i int) ([]string, []string) {
+	fake.bulkPropertiesMutex.RLock()
+	defer fake.bulkPropertiesMutex.RUnlock()
+	return fake.bulkPropertiesArgsForCall[i].handles, fake.bulkPropertiesArgsForCall[i].keys
+}
+
+func (fake *FakeConnection) BulkPropertiesReturns(result1 map[string]api.Properties, result2 error) {
+	fake.BulkPropertiesStub = nil
+	fake.bulkPropertiesReturns = struct {
+		result1 map[string]api.Properties
+		result2 error
+	}{result1, result2}
+}
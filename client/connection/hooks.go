@@ -0,0 +1,138 @@
+package connection
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/routes"
+)
+
+// Hooks are called around each request a connection sends, e.g. to trace or
+// time them, without wrapping each of its methods. Either may be nil.
+type Hooks struct {
+	// BeforeRequest is called with the name of each request's route, e.g.
+	// routes.Create, as it is sent. It may add headers to the request, e.g.
+	// to propagate a trace.
+	BeforeRequest func(route string, request *http.Request)
+
+	// AfterRequest is called once each request's response has begun, with
+	// the error the request failed with, if any, and how long it took. The
+	// streams of files and processes which follow are not timed.
+	AfterRequest func(route string, err error, duration time.Duration)
+}
+
+func (h Hooks) empty() bool {
+	return h.BeforeRequest == nil && h.AfterRequest == nil
+}
+
+// routeOf names the request's route, matching its method and path with the
+// routes' in order, as the server does.
+func routeOf(request *http.Request) string {
+	segments := strings.Split(request.URL.Path, "/")
+
+	for _, route := range routes.Routes {
+		if route.Method == request.Method && matchesPath(strings.Split(route.Path, "/"), segments) {
+			return route.Name
+		}
+	}
+
+	return ""
+}
+
+func matchesPath(pattern []string, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+
+	for i, p := range pattern {
+		if strings.HasPrefix(p, ":") {
+			if segments[i] == "" {
+				return false
+			}
+
+			continue
+		}
+
+		if p != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// before calls the BeforeRequest hook, returning a func which calls the
+// AfterRequest hook with the request's outcome.
+func (h Hooks) before(request *http.Request) func(error) {
+	route := routeOf(request)
+
+	if h.BeforeRequest != nil {
+		h.BeforeRequest(route, request)
+	}
+
+	started := time.Now()
+
+	return func(err error) {
+		if h.AfterRequest != nil {
+			h.AfterRequest(route, err, time.Since(started))
+		}
+	}
+}
+
+// hookedTransport calls the hooks around each request it sends.
+type hookedTransport struct {
+	http.RoundTripper
+
+	hooks Hooks
+}
+
+func (t *hookedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	after := t.hooks.before(request)
+
+	httpResp, err := t.RoundTripper.RoundTrip(request)
+	if err != nil {
+		after(err)
+		return nil, err
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		// error responses are small; they are read to tell the hook the
+		// error, and left for the caller to read again
+		body, err := ioutil.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
+
+		if err != nil {
+			after(err)
+			return nil, err
+		}
+
+		httpResp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		after(responseError(&http.Response{
+			Status:     httpResp.Status,
+			StatusCode: httpResp.StatusCode,
+			Header:     httpResp.Header,
+			Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		}))
+
+		return httpResp, nil
+	}
+
+	after(nil)
+
+	return httpResp, nil
+}
+
+func hookTransport(transport http.RoundTripper, hooks Hooks) http.RoundTripper {
+	if hooks.empty() {
+		return transport
+	}
+
+	return &hookedTransport{
+		RoundTripper: transport,
+		hooks:        hooks,
+	}
+}
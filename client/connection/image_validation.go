@@ -0,0 +1,40 @@
+package connection
+
+import (
+	"net/url"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// validateImage checks that an image refers to a registry which backends
+// know how to pull from, before the server is asked to create a container
+// from it. Servers pass images to their backends as they are.
+func validateImage(image api.ImageRef) error {
+	if image.URI == "" {
+		if image.Username != "" || image.Password != "" {
+			return api.ValidationError{
+				Field:  api.SpecFieldImageURI,
+				Reason: "must be given with credentials",
+			}
+		}
+
+		return nil
+	}
+
+	uri, err := url.Parse(image.URI)
+	if err != nil {
+		return api.ValidationError{
+			Field:  api.SpecFieldImageURI,
+			Reason: "must be a URI",
+		}
+	}
+
+	if uri.Scheme != api.ImageSchemeDocker && uri.Scheme != api.ImageSchemeOCI {
+		return api.ValidationError{
+			Field:  api.SpecFieldImageURI,
+			Reason: "must be a docker:// or oci:// URI",
+		}
+	}
+
+	return nil
+}
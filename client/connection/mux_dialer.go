@@ -0,0 +1,98 @@
+package connection
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/routes"
+	"github.com/cloudfoundry-incubator/garden/transport"
+	"github.com/tedsuo/rata"
+)
+
+var errMultiplexUnsupported = errors.New("server does not support multiplexing")
+
+// muxDialer dials each connection as a stream of a single multiplexed session
+// with the server, starting a new session whenever the last has gone away.
+// Servers which predate multiplexing are dialed directly instead.
+type muxDialer struct {
+	dial   func() (net.Conn, error)
+	req    *rata.RequestGenerator
+	header http.Header
+
+	session     *transport.MuxSession
+	unsupported bool
+	l           sync.Mutex
+}
+
+func (d *muxDialer) Dial(string, string) (net.Conn, error) {
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.unsupported {
+		return d.dial()
+	}
+
+	if d.session != nil {
+		stream, err := d.session.Open()
+		if err == nil {
+			return stream, nil
+		}
+	}
+
+	session, err := d.startSession()
+	if err == errMultiplexUnsupported {
+		d.unsupported = true
+		return d.dial()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.session = session
+
+	return session.Open()
+}
+
+func (d *muxDialer) startSession() (*transport.MuxSession, error) {
+	request, err := d.req.CreateRequest(routes.Multiplex, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range d.header {
+		request.Header[key] = values
+	}
+
+	conn, err := d.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	client := httputil.NewClientConn(conn, nil)
+
+	httpResp, err := client.Do(request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// the router of a server without the route answers 404
+	if httpResp.StatusCode == http.StatusNotFound {
+		conn.Close()
+		return nil, errMultiplexUnsupported
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		defer conn.Close()
+		defer httpResp.Body.Close()
+		return nil, responseError(httpResp)
+	}
+
+	conn, br := client.Hijack()
+
+	return transport.NewMuxClient(conn, br), nil
+}
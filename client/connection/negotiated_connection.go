@@ -0,0 +1,275 @@
+package connection
+
+import (
+	"io"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// NewNegotiated asks the server which capabilities it supports, and wraps the
+// Connection so that calls needing a capability the server lacks fail with
+// api.ErrUnsupportedOperation instead of being sent to it. This lets clients
+// be upgraded before the servers they talk to. Binary process streams need no
+// such care, as they are agreed per request.
+func NewNegotiated(conn Connection) (Connection, error) {
+	version, err := conn.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return &negotiatedConnection{
+		Connection: conn,
+		version:    version,
+	}, nil
+}
+
+type negotiatedConnection struct {
+	Connection
+
+	version api.ServerVersion
+}
+
+// ServerVersion is the version negotiated when the connection was made.
+func (c *negotiatedConnection) ServerVersion() (api.ServerVersion, error) {
+	return c.version, nil
+}
+
+func (c *negotiatedConnection) ProtocolVersion() (uint32, error) {
+	return c.version.ProtocolVersion, nil
+}
+
+func (c *negotiatedConnection) HealthCheck() (api.HealthStatus, error) {
+	if !c.version.Supports(api.CapabilityHealthCheck) {
+		return api.HealthStatus{}, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.HealthCheck()
+}
+
+func (c *negotiatedConnection) Create(spec api.ContainerSpec) (string, *api.ContainerInfo, error) {
+	// servers which do not know the fields would place the container's
+	// traffic anywhere
+	placed := spec.ExternalIP != "" || spec.ExternalInterface != ""
+	if placed && !c.version.Supports(api.CapabilityExternalIP) {
+		return "", nil, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.Create(spec)
+}
+
+func (c *negotiatedConnection) Rename(oldHandle, newHandle string) error {
+	if !c.version.Supports(api.CapabilityRename) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.Rename(oldHandle, newHandle)
+}
+
+func (c *negotiatedConnection) ForceDestroy(handle string) error {
+	if !c.version.Supports(api.CapabilityAsyncDestroy) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.ForceDestroy(handle)
+}
+
+func (c *negotiatedConnection) DestroyWithReason(handle string, force bool, reason string) error {
+	if force && !c.version.Supports(api.CapabilityAsyncDestroy) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.DestroyWithReason(handle, force, reason)
+}
+
+func (c *negotiatedConnection) DestroyAsync(handle string, force bool) (string, error) {
+	if !c.version.Supports(api.CapabilityAsyncDestroy) {
+		return "", api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.DestroyAsync(handle, force)
+}
+
+func (c *negotiatedConnection) DestroyOperation(id string) (api.DestroyOperation, error) {
+	if !c.version.Supports(api.CapabilityAsyncDestroy) {
+		return api.DestroyOperation{}, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.DestroyOperation(id)
+}
+
+func (c *negotiatedConnection) Pause(handle string) error {
+	if !c.version.Supports(api.CapabilityPauseResume) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.Pause(handle)
+}
+
+func (c *negotiatedConnection) Resume(handle string) error {
+	if !c.version.Supports(api.CapabilityPauseResume) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.Resume(handle)
+}
+
+func (c *negotiatedConnection) SetGraceTime(handle string, graceTime time.Duration) error {
+	if !c.version.Supports(api.CapabilitySetGraceTime) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.SetGraceTime(handle, graceTime)
+}
+
+func (c *negotiatedConnection) ListVerbose(properties api.Properties, order api.ListOrder) ([]api.ContainerSummary, error) {
+	if order != "" && !c.version.Supports(api.CapabilityListOrder) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.ListVerbose(properties, order)
+}
+
+func (c *negotiatedConnection) ListWhere(filters []api.PropertyFilter) ([]string, error) {
+	if !c.version.Supports(api.CapabilityListFilterOperators) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.ListWhere(filters)
+}
+
+func (c *negotiatedConnection) ListEach(properties api.Properties, each func(string) error) error {
+	if !c.version.Supports(api.CapabilityListStream) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.ListEach(properties, each)
+}
+
+func (c *negotiatedConnection) StreamOut(handle string, spec api.StreamOutSpec) (io.ReadCloser, error) {
+	if spec.Offset > 0 && !c.version.Supports(api.CapabilityStreamOutOffset) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.StreamOut(handle, spec)
+}
+
+func (c *negotiatedConnection) NetInSpec(handle string, spec api.NetInSpec) (uint32, uint32, error) {
+	plain := (spec.Protocol == 0 || spec.Protocol == api.ProtocolTCP) && spec.HostIP == nil
+	if !plain && !c.version.Supports(api.CapabilityNetInSpec) {
+		return 0, 0, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.NetInSpec(handle, spec)
+}
+
+func (c *negotiatedConnection) NetOutRule(handle string, rule api.NetOutRule) error {
+	if !c.version.Supports(api.CapabilityNetOutRules) {
+		return api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.NetOutRule(handle, rule)
+}
+
+func (c *negotiatedConnection) Run(handle string, spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+	if len(spec.Labels) > 0 && !c.version.Supports(api.CapabilityProcessLabels) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	// servers which cannot take files have them copied instead
+	if io.PassFiles && !c.version.Supports(api.CapabilityPassFiles) {
+		io.PassFiles = false
+	}
+
+	process, err := c.Connection.Run(handle, spec, io)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.negotiatedProcess(process), nil
+}
+
+func (c *negotiatedConnection) FindProcesses(handle string, labels map[string]string) ([]uint32, error) {
+	if !c.version.Supports(api.CapabilityProcessLabels) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.FindProcesses(handle, labels)
+}
+
+func (c *negotiatedConnection) WaitForProcess(handle string, matcher api.ProcessMatcher, timeout time.Duration) (uint32, error) {
+	if !c.version.Supports(api.CapabilityWaitForProcess) {
+		return 0, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.WaitForProcess(handle, matcher, timeout)
+}
+
+func (c *negotiatedConnection) Logs(handle string, tail int, follow bool) (api.LogStream, error) {
+	if !c.version.Supports(api.CapabilityContainerLogs) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.Logs(handle, tail, follow)
+}
+
+func (c *negotiatedConnection) BulkProperties(handles []string, keys []string) (map[string]api.Properties, error) {
+	if !c.version.Supports(api.CapabilityBulkProperties) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	return c.Connection.BulkProperties(handles, keys)
+}
+
+func (c *negotiatedConnection) Attach(handle string, processID uint32, io api.ProcessIO) (api.Process, error) {
+	process, err := c.Connection.Attach(handle, processID, io)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.negotiatedProcess(process), nil
+}
+
+func (c *negotiatedConnection) Reconnect(handle string, processID uint32, spec api.ReconnectSpec, io api.ProcessIO) (api.Process, error) {
+	if !c.version.Supports(api.CapabilityReconnectTokens) {
+		return nil, api.ErrUnsupportedOperation
+	}
+
+	process, err := c.Connection.Reconnect(handle, processID, spec, io)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.negotiatedProcess(process), nil
+}
+
+func (c *negotiatedConnection) AttachAll(handle string, io func(uint32) api.ProcessIO) ([]api.Process, error) {
+	processes, err := c.Connection.AttachAll(handle, io)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, process := range processes {
+		processes[i] = c.negotiatedProcess(process)
+	}
+
+	return processes, nil
+}
+
+func (c *negotiatedConnection) negotiatedProcess(process api.Process) api.Process {
+	if c.version.Supports(api.CapabilityAllocateTTY) {
+		return process
+	}
+
+	return ttylessProcess{process}
+}
+
+// ttylessProcess is a process on a server which cannot allocate TTYs, and
+// would not understand being asked to.
+type ttylessProcess struct {
+	api.Process
+}
+
+func (ttylessProcess) AllocateTTY(api.TTYSpec) error {
+	return api.ErrUnsupportedOperation
+}
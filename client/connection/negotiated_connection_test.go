@@ -0,0 +1,281 @@
+package connection_test
+
+import (
+	"errors"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	wfakes "github.com/cloudfoundry-incubator/garden/api/fakes"
+	. "github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/client/connection/fakes"
+)
+
+var _ = Describe("Negotiated connection", func() {
+	var (
+		fakeConnection *fakes.FakeConnection
+		connection     Connection
+		negotiateErr   error
+	)
+
+	BeforeEach(func() {
+		fakeConnection = new(fakes.FakeConnection)
+	})
+
+	JustBeforeEach(func() {
+		connection, negotiateErr = NewNegotiated(fakeConnection)
+	})
+
+	Context("when the server supports a capability", func() {
+		BeforeEach(func() {
+			fakeConnection.ServerVersionReturns(api.ServerVersion{
+				ProtocolVersion: 1,
+				Capabilities:    []api.Capability{api.CapabilityRename},
+			}, nil)
+		})
+
+		It("sends calls which need it", func() {
+			err := connection.Rename("some-handle", "new-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.RenameCallCount()).Should(Equal(1))
+		})
+
+		Context("when the capability is allocating TTYs", func() {
+			BeforeEach(func() {
+				fakeConnection.ServerVersionReturns(api.ServerVersion{
+					ProtocolVersion: 1,
+					Capabilities:    []api.Capability{api.CapabilityAllocateTTY},
+				}, nil)
+			})
+
+			It("asks the server to give processes a TTY", func() {
+				fakeProcess := new(wfakes.FakeProcess)
+				fakeConnection.AttachReturns(fakeProcess, nil)
+
+				process, err := connection.Attach("some-handle", 42, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = process.AllocateTTY(api.TTYSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeProcess.AllocateTTYCallCount()).Should(Equal(1))
+			})
+		})
+
+		It("reports the negotiated version without asking again", func() {
+			version, err := connection.ServerVersion()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(version.Supports(api.CapabilityRename)).Should(BeTrue())
+
+			protocolVersion, err := connection.ProtocolVersion()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(protocolVersion).Should(Equal(uint32(1)))
+
+			Ω(fakeConnection.ServerVersionCallCount()).Should(Equal(1))
+		})
+	})
+
+	Context("when the server lacks a capability", func() {
+		BeforeEach(func() {
+			fakeConnection.ServerVersionReturns(api.ServerVersion{}, nil)
+		})
+
+		It("fails calls which need it with ErrUnsupportedOperation, without sending them", func() {
+			err := connection.Rename("some-handle", "new-handle")
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			err = connection.NetOutRule("some-handle", api.NetOutRule{})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.RenameCallCount()).Should(BeZero())
+			Ω(fakeConnection.NetOutRuleCallCount()).Should(BeZero())
+		})
+
+		It("fails to map UDP ports or bind host IPs, but maps plain TCP ports", func() {
+			_, _, err := connection.NetInSpec("some-handle", api.NetInSpec{
+				HostPort: 123,
+				Protocol: api.ProtocolUDP,
+			})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, _, err = connection.NetInSpec("some-handle", api.NetInSpec{
+				HostPort: 123,
+				HostIP:   net.ParseIP("10.0.0.1"),
+			})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, _, err = connection.NetInSpec("some-handle", api.NetInSpec{
+				HostPort: 123,
+				Protocol: api.ProtocolTCP,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.NetInSpecCallCount()).Should(Equal(1))
+		})
+
+		It("fails to label processes or find them by their labels, without asking the server", func() {
+			_, err := connection.Run("some-handle", api.ProcessSpec{
+				Path:   "ls",
+				Labels: map[string]string{"role": "health-check"},
+			}, api.ProcessIO{})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, err = connection.FindProcesses("some-handle", map[string]string{"role": "health-check"})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.RunCallCount()).Should(BeZero())
+			Ω(fakeConnection.FindProcessesCallCount()).Should(BeZero())
+		})
+
+		It("fails ordered listings, but not unordered ones", func() {
+			_, err := connection.ListVerbose(nil, api.ListOrderCreated)
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, err = connection.ListVerbose(nil, "")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ListVerboseCallCount()).Should(Equal(1))
+		})
+
+		It("fails streamed listings", func() {
+			err := connection.ListEach(nil, func(string) error {
+				return nil
+			})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.ListEachCallCount()).Should(BeZero())
+		})
+
+		It("fails listings with property filter operators", func() {
+			_, err := connection.ListWhere([]api.PropertyFilter{
+				{Name: "owner", Operator: api.PropertyExists},
+			})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.ListWhereCallCount()).Should(BeZero())
+		})
+
+		It("fails forced and asynchronous destroys, but not plain ones", func() {
+			err := connection.ForceDestroy("some-handle")
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, err = connection.DestroyAsync("some-handle", false)
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, err = connection.DestroyOperation("some-operation")
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			err = connection.DestroyWithReason("some-handle", true, "some-reason")
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			err = connection.Destroy("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = connection.DestroyWithReason("some-handle", false, "some-reason")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ForceDestroyCallCount()).Should(BeZero())
+			Ω(fakeConnection.DestroyAsyncCallCount()).Should(BeZero())
+			Ω(fakeConnection.DestroyOperationCallCount()).Should(BeZero())
+			Ω(fakeConnection.DestroyWithReasonCallCount()).Should(Equal(1))
+		})
+
+		It("fails to give processes a TTY, without asking the server", func() {
+			fakeProcess := new(wfakes.FakeProcess)
+			fakeConnection.RunReturns(fakeProcess, nil)
+
+			process, err := connection.Run("some-handle", api.ProcessSpec{}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = process.AllocateTTY(api.TTYSpec{})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeProcess.AllocateTTYCallCount()).Should(BeZero())
+		})
+
+		It("fails to resume streams of files from an offset, but streams them from the start", func() {
+			_, err := connection.StreamOut("some-handle", api.StreamOutSpec{
+				Path:   "/some/path",
+				Offset: 42,
+			})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, err = connection.StreamOut("some-handle", api.StreamOutSpec{
+				Path: "/some/path",
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.StreamOutCallCount()).Should(Equal(1))
+		})
+
+		It("has processes' files copied rather than passing them to the server", func() {
+			fakeConnection.RunReturns(new(wfakes.FakeProcess), nil)
+
+			_, err := connection.Run("some-handle", api.ProcessSpec{}, api.ProcessIO{
+				PassFiles: true,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, _, processIO := fakeConnection.RunArgsForCall(0)
+			Ω(processIO.PassFiles).Should(BeFalse())
+		})
+
+		It("fails to place containers' outbound traffic, but creates containers without it", func() {
+			_, _, err := connection.Create(api.ContainerSpec{ExternalIP: "10.0.0.1"})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, _, err = connection.Create(api.ContainerSpec{ExternalInterface: "eth1"})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.CreateCallCount()).Should(BeZero())
+
+			_, _, err = connection.Create(api.ContainerSpec{Handle: "some-handle"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.CreateCallCount()).Should(Equal(1))
+		})
+
+		It("fails to get the properties of several containers at once", func() {
+			_, err := connection.BulkProperties([]string{"some-handle"}, nil)
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.BulkPropertiesCallCount()).Should(BeZero())
+		})
+
+		It("fails to replay containers' logs", func() {
+			_, err := connection.Logs("some-handle", 0, false)
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.LogsCallCount()).Should(BeZero())
+		})
+
+		It("fails to reconnect to processes", func() {
+			_, err := connection.Reconnect("some-handle", 42, api.ReconnectSpec{Token: "some-token"}, api.ProcessIO{})
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(fakeConnection.ReconnectCallCount()).Should(BeZero())
+		})
+
+		It("sends calls which are part of every protocol version", func() {
+			err := connection.Destroy("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.DestroyCallCount()).Should(Equal(1))
+		})
+	})
+
+	Context("when asking for the server's version fails", func() {
+		disaster := errors.New("oh no!")
+
+		BeforeEach(func() {
+			fakeConnection.ServerVersionReturns(api.ServerVersion{}, disaster)
+		})
+
+		It("fails to connect", func() {
+			Ω(negotiateErr).Should(Equal(disaster))
+		})
+	})
+})
@@ -1,14 +1,16 @@
 package connection
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden/api"
-	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
 type process struct {
@@ -16,22 +18,27 @@ type process struct {
 
 	stream *processStream
 
+	// reconnectToken is the server's, if it gave one out, for Reconnect to
+	// resume the process's streams after the bytes of each received so far
+	reconnectToken string
+	stdoutReceived uint64
+	stderrReceived uint64
+
 	done       bool
 	exitStatus int
 	exitErr    error
 	doneL      *sync.Cond
+	doneC      chan struct{}
 }
 
-func newProcess(id uint32, conn net.Conn) *process {
+func newProcess(id uint32, conn net.Conn, writer transport.MessageWriter) *process {
 	return &process{
 		id: id,
 
-		stream: &processStream{
-			id:   id,
-			conn: conn,
-		},
+		stream: newProcessStream(id, conn, writer),
 
 		doneL: sync.NewCond(&sync.Mutex{}),
+		doneC: make(chan struct{}),
 	}
 }
 
@@ -39,6 +46,27 @@ func (p *process) ID() uint32 {
 	return p.id
 }
 
+// Reconnectable is implemented by the processes of a connection, for resuming
+// their streams with Reconnect.
+type Reconnectable interface {
+	// ReconnectSpec returns the spec with which Reconnect resumes the
+	// process's streams after the output received so far, and false if the
+	// server gave out no reconnect token for it.
+	ReconnectSpec() (api.ReconnectSpec, bool)
+}
+
+func (p *process) ReconnectSpec() (api.ReconnectSpec, bool) {
+	if p.reconnectToken == "" {
+		return api.ReconnectSpec{}, false
+	}
+
+	return api.ReconnectSpec{
+		Token:        p.reconnectToken,
+		StdoutOffset: atomic.LoadUint64(&p.stdoutReceived),
+		StderrOffset: atomic.LoadUint64(&p.stderrReceived),
+	}, true
+}
+
 func (p *process) Wait() (int, error) {
 	p.doneL.L.Lock()
 
@@ -51,64 +79,168 @@ func (p *process) Wait() (int, error) {
 	return p.exitStatus, p.exitErr
 }
 
+func (p *process) WaitWithTimeout(timeout time.Duration) (int, error) {
+	select {
+	case <-p.doneC:
+		return p.Wait()
+	case <-time.After(timeout):
+		return 0, ErrWaitTimedOut
+	}
+}
+
+func (p *process) Exited() (bool, int) {
+	p.doneL.L.Lock()
+	defer p.doneL.L.Unlock()
+
+	return p.done, p.exitStatus
+}
+
 func (p *process) SetTTY(tty api.TTYSpec) error {
 	return p.stream.SetTTY(tty)
 }
 
+func (p *process) SetRlimits(limits api.ResourceLimits) error {
+	return p.stream.SetRlimits(limits)
+}
+
+func (p *process) AllocateTTY(tty api.TTYSpec) error {
+	return p.stream.AllocateTTY(tty)
+}
+
 func (p *process) exited(exitStatus int, err error) {
 	p.doneL.L.Lock()
 	p.exitStatus = exitStatus
 	p.exitErr = err
+	if !p.done {
+		close(p.doneC)
+	}
 	p.done = true
 	p.doneL.L.Unlock()
 
 	p.doneL.Broadcast()
+
+	p.stream.finish()
 }
 
-func (p *process) streamPayloads(decoder *json.Decoder, processIO api.ProcessIO) {
+func (p *process) streamPayloads(reader transport.MessageReader, processIO api.ProcessIO) {
 	defer p.stream.Close()
 
-	if processIO.Stdin != nil {
-		writer := &stdinWriter{p.stream}
-
-		go func() {
-			_, err := io.Copy(writer, processIO.Stdin)
-			if err == nil {
-				writer.Close()
-			} else {
-				p.stream.Close()
-			}
-		}()
-	}
+	p.streamStdin(processIO.Stdin)
 
 	for {
-		payload := &protocol.ProcessPayload{}
+		payload := &apitypes.ProcessPayload{}
 
-		err := decoder.Decode(payload)
+		err := reader.ReadMessage(payload)
 		if err != nil {
 			p.exited(0, err)
 			break
 		}
 
-		if payload.Error != nil {
-			p.exited(0, fmt.Errorf("process error: %s", payload.GetError()))
+		if p.handlePayload(payload, processIO) {
 			break
 		}
+	}
+}
 
-		if payload.ExitStatus != nil {
-			p.exited(int(payload.GetExitStatus()), nil)
-			break
+func (p *process) streamStdin(stdin io.Reader) {
+	if stdin == nil {
+		return
+	}
+
+	writer := &stdinWriter{p.stream}
+
+	go func() {
+		_, err := io.Copy(writer, stdin)
+		if err == nil {
+			writer.Close()
+		} else if p.stream.stdinError() == nil {
+			p.stream.Close()
 		}
+	}()
+}
 
-		switch payload.GetSource() {
-		case protocol.ProcessPayload_stdout:
-			if processIO.Stdout != nil {
-				processIO.Stdout.Write([]byte(payload.GetData()))
-			}
-		case protocol.ProcessPayload_stderr:
-			if processIO.Stderr != nil {
-				processIO.Stderr.Write([]byte(payload.GetData()))
+// handlePayload forwards a payload to the process's output streams, returning
+// true once the process has exited.
+func (p *process) handlePayload(payload *apitypes.ProcessPayload, processIO api.ProcessIO) bool {
+	if payload.Error != nil {
+		p.exited(0, fmt.Errorf("process error: %s", payload.GetError()))
+		return true
+	}
+
+	if payload.ExitStatus != nil {
+		p.exited(int(payload.GetExitStatus()), p.stream.stdinError())
+		return true
+	}
+
+	if payload.TtyAllocated != nil {
+		p.stream.ttyReplied(nil)
+		return false
+	}
+
+	if payload.TtyError != nil {
+		p.stream.ttyReplied(errorFrom(payload.GetTtyError()))
+		return false
+	}
+
+	if payload.StdinError != nil {
+		p.stream.failStdin(errorFrom(payload.GetStdinError()))
+		return false
+	}
+
+	if payload.StdinWindow != nil {
+		p.stream.setWindow(payload.GetStdinWindow())
+		return false
+	}
+
+	if payload.StdinAck != nil {
+		p.stream.ack(payload.GetStdinAck())
+		return false
+	}
+
+	switch payload.GetSource() {
+	case apitypes.ProcessPayload_stdout:
+		atomic.AddUint64(&p.stdoutReceived, uint64(len(payload.GetData())))
+
+		if processIO.Stdout != nil {
+			processIO.Stdout.Write([]byte(payload.GetData()))
+		}
+	case apitypes.ProcessPayload_stderr:
+		atomic.AddUint64(&p.stderrReceived, uint64(len(payload.GetData())))
+
+		if processIO.Stderr != nil {
+			processIO.Stderr.Write([]byte(payload.GetData()))
+		}
+	}
+
+	return false
+}
+
+func streamMultiplexedPayloads(conn net.Conn, reader transport.MessageReader, processes map[uint32]*process, processIOs map[uint32]api.ProcessIO) {
+	defer conn.Close()
+
+	for id, p := range processes {
+		p.streamStdin(processIOs[id].Stdin)
+	}
+
+	for len(processes) > 0 {
+		payload := &apitypes.ProcessPayload{}
+
+		err := reader.ReadMessage(payload)
+		if err != nil {
+			for _, p := range processes {
+				p.exited(0, err)
 			}
+
+			break
+		}
+
+		p, found := processes[payload.GetProcessId()]
+		if !found {
+			continue
+		}
+
+		if p.handlePayload(payload, processIOs[p.id]) {
+			delete(processes, p.id)
 		}
 	}
 }
@@ -1,52 +1,191 @@
 package connection
 
 import (
+	"errors"
 	"net"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden/api"
-	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
 	"github.com/cloudfoundry-incubator/garden/transport"
-	"github.com/gogo/protobuf/proto"
 )
 
-var stdin = protocol.ProcessPayload_stdin
+var stdin = apitypes.ProcessPayload_stdin
+
+var errProcessExited = errors.New("process exited")
 
 type processStream struct {
-	id   uint32
-	conn net.Conn
+	id     uint32
+	conn   net.Conn
+	writer transport.MessageWriter
+
+	// window holds a slot for each stdin payload not yet acknowledged by the
+	// server; it is nil if the server does not advertise a window.
+	window  chan struct{}
+	windowL sync.Mutex
+
+	done     chan struct{}
+	doneOnce sync.Once
+
+	// stdinErr is why the server stopped accepting stdin, once stdinFailed
+	// is closed
+	stdinErr    error
+	stdinFailed chan struct{}
+	stdinOnce   sync.Once
+
+	// ttyReplies receives the server's reply to AllocateTTY, of which only
+	// one is sent at a time
+	ttyReplies chan error
+	ttyL       sync.Mutex
 
 	sync.Mutex
 }
 
+func newProcessStream(id uint32, conn net.Conn, writer transport.MessageWriter) *processStream {
+	return &processStream{
+		id:     id,
+		conn:   conn,
+		writer: writer,
+
+		done: make(chan struct{}),
+
+		stdinFailed: make(chan struct{}),
+
+		ttyReplies: make(chan error, 1),
+	}
+}
+
 func (s *processStream) WriteStdin(data []byte) error {
-	return s.sendPayload(&protocol.ProcessPayload{
-		ProcessId: proto.Uint32(s.id),
+	if err := s.stdinError(); err != nil {
+		return err
+	}
+
+	s.windowL.Lock()
+	window := s.window
+	s.windowL.Unlock()
+
+	if window != nil {
+		select {
+		case window <- struct{}{}:
+		case <-s.stdinFailed:
+			return s.stdinErr
+		case <-s.done:
+			// nothing will be acknowledged anymore; fall back to writing
+			// unthrottled, as the server discards stdin for exited processes
+		}
+	}
+
+	return s.sendPayload(&apitypes.ProcessPayload{
+		ProcessId: apitypes.Uint32(s.id),
 		Source:    &stdin,
-		Data:      proto.String(string(data)),
+		Data:      apitypes.String(string(data)),
 	})
 }
 
 func (s *processStream) CloseStdin() error {
-	return s.sendPayload(&protocol.ProcessPayload{
-		ProcessId: proto.Uint32(s.id),
+	if err := s.stdinError(); err != nil {
+		return err
+	}
+
+	return s.sendPayload(&apitypes.ProcessPayload{
+		ProcessId: apitypes.Uint32(s.id),
 		Source:    &stdin,
 	})
 }
 
 func (s *processStream) SetTTY(spec api.TTYSpec) error {
-	tty := &protocol.TTY{}
+	return s.sendPayload(&apitypes.ProcessPayload{
+		ProcessId: apitypes.Uint32(s.id),
+		Tty:       ttyFrom(spec),
+	})
+}
 
-	if spec.WindowSize != nil {
-		tty.WindowSize = &protocol.TTY_WindowSize{
-			Columns: proto.Uint32(uint32(spec.WindowSize.Columns)),
-			Rows:    proto.Uint32(uint32(spec.WindowSize.Rows)),
+// AllocateTTY asks the server to give the process a TTY, waiting for its
+// reply.
+func (s *processStream) AllocateTTY(spec api.TTYSpec) error {
+	s.ttyL.Lock()
+	defer s.ttyL.Unlock()
+
+	err := s.sendPayload(&apitypes.ProcessPayload{
+		ProcessId:   apitypes.Uint32(s.id),
+		AllocateTty: ttyFrom(spec),
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-s.ttyReplies:
+		return err
+	case <-s.done:
+		return errProcessExited
+	}
+}
+
+// ttyReplied passes on the server's reply to AllocateTTY; replies that were
+// not asked for are dropped.
+func (s *processStream) ttyReplied(err error) {
+	select {
+	case s.ttyReplies <- err:
+	default:
+	}
+}
+
+func (s *processStream) SetRlimits(limits api.ResourceLimits) error {
+	return s.sendPayload(&apitypes.ProcessPayload{
+		ProcessId: apitypes.Uint32(s.id),
+		Rlimits:   resourceLimits(limits),
+	})
+}
+
+// setWindow limits the number of unacknowledged stdin payloads to size.
+func (s *processStream) setWindow(size uint32) {
+	if size == 0 {
+		return
+	}
+
+	s.windowL.Lock()
+	s.window = make(chan struct{}, size)
+	s.windowL.Unlock()
+}
+
+// ack frees count slots in the send window.
+func (s *processStream) ack(count uint32) {
+	s.windowL.Lock()
+	window := s.window
+	s.windowL.Unlock()
+
+	for i := uint32(0); i < count; i++ {
+		select {
+		case <-window:
+		default:
+			return
 		}
 	}
+}
+
+// failStdin stops stdin being written, as the server no longer accepts it.
+func (s *processStream) failStdin(err error) {
+	s.stdinOnce.Do(func() {
+		s.stdinErr = err
+		close(s.stdinFailed)
+	})
+}
+
+// stdinError returns why the server stopped accepting stdin, if it has.
+func (s *processStream) stdinError() error {
+	select {
+	case <-s.stdinFailed:
+		return s.stdinErr
+	default:
+		return nil
+	}
+}
 
-	return s.sendPayload(&protocol.ProcessPayload{
-		ProcessId: proto.Uint32(s.id),
-		Tty:       tty,
+// finish releases any writers waiting on the send window.
+func (s *processStream) finish() {
+	s.doneOnce.Do(func() {
+		close(s.done)
 	})
 }
 
@@ -54,10 +193,10 @@ func (s *processStream) Close() error {
 	return s.conn.Close()
 }
 
-func (s *processStream) sendPayload(payload *protocol.ProcessPayload) error {
+func (s *processStream) sendPayload(payload *apitypes.ProcessPayload) error {
 	s.Lock()
 
-	err := transport.WriteMessage(s.conn, payload)
+	err := s.writer.WriteMessage(payload)
 	if err != nil {
 		s.Unlock()
 		return err
@@ -67,3 +206,16 @@ func (s *processStream) sendPayload(payload *protocol.ProcessPayload) error {
 
 	return nil
 }
+
+func ttyFrom(spec api.TTYSpec) *apitypes.TTY {
+	tty := &apitypes.TTY{}
+
+	if spec.WindowSize != nil {
+		tty.WindowSize = &apitypes.TTY_WindowSize{
+			Columns: apitypes.Uint32(uint32(spec.WindowSize.Columns)),
+			Rows:    apitypes.Uint32(uint32(spec.WindowSize.Rows)),
+		}
+	}
+
+	return tty
+}
@@ -0,0 +1,378 @@
+package connection
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// RetryPolicy configures how a retrying Connection retries calls.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a call is made, including the first.
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry; it doubles with each
+	// retry after that, up to MaxBackoff if that is non-zero.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// NewRetrying wraps a Connection so that idempotent calls, which only read
+// state, are retried on transient network failures, and on errors the server
+// classifies as retryable. Calls which change state, such as Create, Run and
+// Destroy, are never retried.
+func NewRetrying(conn Connection, policy RetryPolicy) Connection {
+	return &retryingConnection{
+		Connection: conn,
+		policy:     policy,
+	}
+}
+
+type retryingConnection struct {
+	Connection
+
+	policy RetryPolicy
+}
+
+func (c *retryingConnection) Ping() error {
+	return c.retry(c.Connection.Ping)
+}
+
+func (c *retryingConnection) ProtocolVersion() (uint32, error) {
+	var version uint32
+
+	err := c.retry(func() error {
+		var err error
+		version, err = c.Connection.ProtocolVersion()
+		return err
+	})
+
+	return version, err
+}
+
+func (c *retryingConnection) ServerVersion() (api.ServerVersion, error) {
+	var version api.ServerVersion
+
+	err := c.retry(func() error {
+		var err error
+		version, err = c.Connection.ServerVersion()
+		return err
+	})
+
+	return version, err
+}
+
+func (c *retryingConnection) HealthCheck() (api.HealthStatus, error) {
+	var status api.HealthStatus
+
+	err := c.retry(func() error {
+		var err error
+		status, err = c.Connection.HealthCheck()
+		return err
+	})
+
+	return status, err
+}
+
+func (c *retryingConnection) Capacity() (api.Capacity, error) {
+	var capacity api.Capacity
+
+	err := c.retry(func() error {
+		var err error
+		capacity, err = c.Connection.Capacity()
+		return err
+	})
+
+	return capacity, err
+}
+
+func (c *retryingConnection) FreshCapacity() (api.Capacity, error) {
+	var capacity api.Capacity
+
+	err := c.retry(func() error {
+		var err error
+		capacity, err = c.Connection.FreshCapacity()
+		return err
+	})
+
+	return capacity, err
+}
+
+func (c *retryingConnection) List(properties api.Properties) ([]string, error) {
+	var handles []string
+
+	err := c.retry(func() error {
+		var err error
+		handles, err = c.Connection.List(properties)
+		return err
+	})
+
+	return handles, err
+}
+
+func (c *retryingConnection) ListWhere(filters []api.PropertyFilter) ([]string, error) {
+	var handles []string
+
+	err := c.retry(func() error {
+		var err error
+		handles, err = c.Connection.ListWhere(filters)
+		return err
+	})
+
+	return handles, err
+}
+
+func (c *retryingConnection) ListVerbose(properties api.Properties, order api.ListOrder) ([]api.ContainerSummary, error) {
+	var summaries []api.ContainerSummary
+
+	err := c.retry(func() error {
+		var err error
+		summaries, err = c.Connection.ListVerbose(properties, order)
+		return err
+	})
+
+	return summaries, err
+}
+
+func (c *retryingConnection) LookupBy(properties api.Properties) (string, error) {
+	var handle string
+
+	err := c.retry(func() error {
+		var err error
+		handle, err = c.Connection.LookupBy(properties)
+		return err
+	})
+
+	return handle, err
+}
+
+func (c *retryingConnection) DestroyOperation(id string) (api.DestroyOperation, error) {
+	var operation api.DestroyOperation
+
+	err := c.retry(func() error {
+		var err error
+		operation, err = c.Connection.DestroyOperation(id)
+		return err
+	})
+
+	return operation, err
+}
+
+func (c *retryingConnection) Info(handle string) (api.ContainerInfo, error) {
+	var info api.ContainerInfo
+
+	err := c.retry(func() error {
+		var err error
+		info, err = c.Connection.Info(handle)
+		return err
+	})
+
+	return info, err
+}
+
+func (c *retryingConnection) InfoFields(handle string, fields []api.InfoField) (api.ContainerInfo, error) {
+	var info api.ContainerInfo
+
+	err := c.retry(func() error {
+		var err error
+		info, err = c.Connection.InfoFields(handle, fields)
+		return err
+	})
+
+	return info, err
+}
+
+func (c *retryingConnection) CurrentBandwidthLimits(handle string) (api.BandwidthLimits, error) {
+	var limits api.BandwidthLimits
+
+	err := c.retry(func() error {
+		var err error
+		limits, err = c.Connection.CurrentBandwidthLimits(handle)
+		return err
+	})
+
+	return limits, err
+}
+
+func (c *retryingConnection) CurrentCPULimits(handle string) (api.CPULimits, error) {
+	var limits api.CPULimits
+
+	err := c.retry(func() error {
+		var err error
+		limits, err = c.Connection.CurrentCPULimits(handle)
+		return err
+	})
+
+	return limits, err
+}
+
+func (c *retryingConnection) CurrentDiskLimits(handle string) (api.DiskLimits, error) {
+	var limits api.DiskLimits
+
+	err := c.retry(func() error {
+		var err error
+		limits, err = c.Connection.CurrentDiskLimits(handle)
+		return err
+	})
+
+	return limits, err
+}
+
+func (c *retryingConnection) ProcessInfo(handle string, processID uint32) (api.ProcessInfo, error) {
+	var info api.ProcessInfo
+
+	err := c.retry(func() error {
+		var err error
+		info, err = c.Connection.ProcessInfo(handle, processID)
+		return err
+	})
+
+	return info, err
+}
+
+func (c *retryingConnection) FindProcesses(handle string, labels map[string]string) ([]uint32, error) {
+	var processIDs []uint32
+
+	err := c.retry(func() error {
+		var err error
+		processIDs, err = c.Connection.FindProcesses(handle, labels)
+		return err
+	})
+
+	return processIDs, err
+}
+
+func (c *retryingConnection) WaitForProcess(handle string, matcher api.ProcessMatcher, timeout time.Duration) (uint32, error) {
+	var processID uint32
+
+	err := c.retry(func() error {
+		var err error
+		processID, err = c.Connection.WaitForProcess(handle, matcher, timeout)
+		return err
+	})
+
+	return processID, err
+}
+
+func (c *retryingConnection) Logs(handle string, tail int, follow bool) (api.LogStream, error) {
+	var stream api.LogStream
+
+	err := c.retry(func() error {
+		var err error
+		stream, err = c.Connection.Logs(handle, tail, follow)
+		return err
+	})
+
+	return stream, err
+}
+
+func (c *retryingConnection) DiskUsage(handle string) (api.ContainerDiskUsage, error) {
+	var usage api.ContainerDiskUsage
+
+	err := c.retry(func() error {
+		var err error
+		usage, err = c.Connection.DiskUsage(handle)
+		return err
+	})
+
+	return usage, err
+}
+
+func (c *retryingConnection) Env(handle string) ([]string, error) {
+	var env []string
+
+	err := c.retry(func() error {
+		var err error
+		env, err = c.Connection.Env(handle)
+		return err
+	})
+
+	return env, err
+}
+
+func (c *retryingConnection) CurrentMemoryLimits(handle string) (api.MemoryLimits, error) {
+	var limits api.MemoryLimits
+
+	err := c.retry(func() error {
+		var err error
+		limits, err = c.Connection.CurrentMemoryLimits(handle)
+		return err
+	})
+
+	return limits, err
+}
+
+func (c *retryingConnection) MappedPorts(handle string) ([]api.PortMapping, error) {
+	var mappings []api.PortMapping
+
+	err := c.retry(func() error {
+		var err error
+		mappings, err = c.Connection.MappedPorts(handle)
+		return err
+	})
+
+	return mappings, err
+}
+
+func (c *retryingConnection) GetProperty(handle string, name string) (string, error) {
+	var value string
+
+	err := c.retry(func() error {
+		var err error
+		value, err = c.Connection.GetProperty(handle, name)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *retryingConnection) BulkProperties(handles []string, keys []string) (map[string]api.Properties, error) {
+	var containers map[string]api.Properties
+
+	err := c.retry(func() error {
+		var err error
+		containers, err = c.Connection.BulkProperties(handles, keys)
+		return err
+	})
+
+	return containers, err
+}
+
+func (c *retryingConnection) retry(call func() error) error {
+	backoff := c.policy.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= c.policy.MaxAttempts || !isTransient(err) {
+			return err
+		}
+
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if c.policy.MaxBackoff > 0 && backoff > c.policy.MaxBackoff {
+			backoff = c.policy.MaxBackoff
+		}
+	}
+}
+
+// isTransient reports whether an error is a network failure which may not
+// recur, or an error the server says may not recur.
+func isTransient(err error) bool {
+	if api.ClassOf(err) == api.ErrorClassRetryable {
+		return true
+	}
+
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
@@ -0,0 +1,156 @@
+package connection_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	. "github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/client/connection/fakes"
+)
+
+var _ = Describe("Retrying connection", func() {
+	var (
+		fakeConnection *fakes.FakeConnection
+		policy         RetryPolicy
+		connection     Connection
+
+		transientErr error
+	)
+
+	BeforeEach(func() {
+		fakeConnection = new(fakes.FakeConnection)
+
+		policy = RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: 10 * time.Millisecond,
+		}
+
+		transientErr = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	})
+
+	JustBeforeEach(func() {
+		connection = NewRetrying(fakeConnection, policy)
+	})
+
+	Context("when an idempotent call fails transiently", func() {
+		BeforeEach(func() {
+			fakeConnection.InfoStub = func(handle string) (api.ContainerInfo, error) {
+				if fakeConnection.InfoCallCount() < 3 {
+					return api.ContainerInfo{}, transientErr
+				}
+
+				return api.ContainerInfo{State: "active"}, nil
+			}
+		})
+
+		It("retries it until it succeeds", func() {
+			info, err := connection.Info("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(info.State).Should(Equal("active"))
+
+			Ω(fakeConnection.InfoCallCount()).Should(Equal(3))
+			Ω(fakeConnection.InfoArgsForCall(2)).Should(Equal("some-handle"))
+		})
+
+		It("backs off exponentially between attempts", func() {
+			started := time.Now()
+
+			_, err := connection.Info("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(time.Since(started)).Should(BeNumerically(">=", 30*time.Millisecond))
+		})
+
+		Context("and it keeps failing", func() {
+			BeforeEach(func() {
+				policy.MaxAttempts = 2
+			})
+
+			It("gives up after the maximum number of attempts", func() {
+				_, err := connection.Info("some-handle")
+				Ω(err).Should(Equal(transientErr))
+
+				Ω(fakeConnection.InfoCallCount()).Should(Equal(2))
+			})
+		})
+	})
+
+	Context("when the server returns an error", func() {
+		var serverErr error
+
+		BeforeEach(func() {
+			serverErr = &GardenError{Message: "no such container"}
+			fakeConnection.GetPropertyReturns("", serverErr)
+		})
+
+		It("does not retry", func() {
+			_, err := connection.GetProperty("some-handle", "some-property")
+			Ω(err).Should(Equal(serverErr))
+
+			Ω(fakeConnection.GetPropertyCallCount()).Should(Equal(1))
+		})
+
+		Context("which the server classifies as retryable", func() {
+			BeforeEach(func() {
+				serverErr = &GardenError{Message: "backend busy", Class: api.ErrorClassRetryable}
+
+				fakeConnection.GetPropertyStub = func(string, string) (string, error) {
+					if fakeConnection.GetPropertyCallCount() < 2 {
+						return "", serverErr
+					}
+
+					return "some-value", nil
+				}
+			})
+
+			It("retries it until it succeeds", func() {
+				value, err := connection.GetProperty("some-handle", "some-property")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(value).Should(Equal("some-value"))
+
+				Ω(fakeConnection.GetPropertyCallCount()).Should(Equal(2))
+			})
+		})
+	})
+
+	Context("when a non-idempotent call fails transiently", func() {
+		BeforeEach(func() {
+			fakeConnection.CreateReturns("", nil, transientErr)
+			fakeConnection.DestroyReturns(transientErr)
+			fakeConnection.RunReturns(nil, transientErr)
+		})
+
+		It("does not retry it", func() {
+			_, _, err := connection.Create(api.ContainerSpec{})
+			Ω(err).Should(Equal(transientErr))
+
+			err = connection.Destroy("some-handle")
+			Ω(err).Should(Equal(transientErr))
+
+			_, err = connection.Run("some-handle", api.ProcessSpec{}, api.ProcessIO{})
+			Ω(err).Should(Equal(transientErr))
+
+			Ω(fakeConnection.CreateCallCount()).Should(Equal(1))
+			Ω(fakeConnection.DestroyCallCount()).Should(Equal(1))
+			Ω(fakeConnection.RunCallCount()).Should(Equal(1))
+		})
+	})
+
+	Context("when connecting to the server fails", func() {
+		It("retries idempotent calls", func() {
+			connection = NewRetrying(New("tcp", "127.0.0.1:1"), policy)
+
+			started := time.Now()
+
+			err := connection.Ping()
+			Ω(err).Should(HaveOccurred())
+
+			Ω(time.Since(started)).Should(BeNumerically(">=", 30*time.Millisecond))
+		})
+	})
+})
@@ -2,14 +2,95 @@ package client
 
 import (
 	"io"
+	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden/api"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
 )
 
+// Container is the api.Container this package's clients return, with calls
+// which the server answers itself, or the client makes of others, which
+// backends' containers therefore need not implement. Containers returned as
+// api.Containers by clients may be asserted to it.
+type Container interface {
+	api.Container
+
+	// StopWithReason is like Stop, but explains why the container was
+	// stopped, for whoever later investigates it. The server records the
+	// reason itself, stopping the container with its backend's Stop.
+	StopWithReason(kill bool, reason string) error
+
+	// NetIn is the older form of NetInSpec, mapping TCP ports on all of the
+	// host's addresses.
+	NetIn(hostPort, containerPort uint32) (uint32, uint32, error)
+
+	// NetOut is the older form of NetOutRule.
+	NetOut(network string, port uint32, portRange string, protocol api.Protocol) error
+
+	// DestroyAt has the server destroy the container at the time, unless it
+	// is held then, replacing any destroy already scheduled.
+	DestroyAt(time.Time) error
+
+	// DestroyAfter is like DestroyAt, the delay from now.
+	DestroyAfter(time.Duration) error
+
+	// CancelScheduledDestroy cancels the container's scheduled destroy, if it
+	// has one.
+	CancelScheduledDestroy() error
+
+	// SetHold holds the container, so that the server does not destroy it
+	// for being idle or when scheduled to, or releases it.
+	SetHold(held bool) error
+
+	// Exec runs a process and waits for it to exit, returning its output and
+	// exit status. If the spec's timeout passes first, it returns the output
+	// so far with an error, leaving the process running.
+	Exec(api.ExecSpec) (stdout, stderr []byte, exitCode int, err error)
+
+	// FindProcesses returns the IDs of the container's running processes
+	// which were run with every one of the labels, e.g. to find a health
+	// check again without having kept its ID.
+	FindProcesses(labels map[string]string) ([]uint32, error)
+
+	// WaitForProcess waits up to the timeout for the container to run a
+	// process matching the matcher, e.g. a daemon started by an init script,
+	// returning its ID, so that callers need not poll Info. It fails with
+	// api.ErrProcessNotFound if none does in time.
+	WaitForProcess(matcher api.ProcessMatcher, timeout time.Duration) (uint32, error)
+
+	// Logs replays the output of the container's processes which the server
+	// retained, the last tail entries of it, or all of it if tail is 0, so
+	// that a client which went away while a process ran can still read what
+	// it printed. If follow is true the stream goes on to yield output as it
+	// is written, until it is closed or the container is destroyed.
+	Logs(tail int, follow bool) (api.LogStream, error)
+
+	// Env returns the environment that processes run in the container
+	// inherit: the server's default environment, beneath the container's own
+	// from its spec. Processes' own environments override it in turn.
+	Env() ([]string, error)
+
+	// Refresh fetches the container's info again. MappedPorts and Properties
+	// are answered from the info the container last had, from its creation
+	// or verbose listing, or the last Info or Refresh, rather than asked of
+	// the server each time, so they do not see changes made other than
+	// through the container until it is refreshed. Changes made through the
+	// container discard the info, and the next call asks the server.
+	Refresh() error
+}
+
 type container struct {
 	handle string
 
+	// properties are known up front when the container came from a verbose
+	// listing, and info when it was just created. Info and Refresh replace
+	// both with the info they fetch, and changes made through the container
+	// discard both; otherwise they are fetched when asked for.
+	properties api.Properties
+	info       *api.ContainerInfo
+	snapshotL  sync.Mutex
+
 	connection connection.Connection
 }
 
@@ -21,6 +102,24 @@ func newContainer(handle string, connection connection.Connection) api.Container
 	}
 }
 
+func newListedContainer(summary api.ContainerSummary, connection connection.Connection) api.Container {
+	return &container{
+		handle:     summary.Handle,
+		properties: summary.Properties,
+
+		connection: connection,
+	}
+}
+
+func newCreatedContainer(handle string, info *api.ContainerInfo, connection connection.Connection) api.Container {
+	return &container{
+		handle: handle,
+		info:   info,
+
+		connection: connection,
+	}
+}
+
 func (container *container) Handle() string {
 	return container.handle
 }
@@ -29,16 +128,75 @@ func (container *container) Stop(kill bool) error {
 	return container.connection.Stop(container.handle, kill)
 }
 
+func (container *container) StopWithReason(kill bool, reason string) error {
+	return container.connection.StopWithReason(container.handle, kill, reason)
+}
+
+func (container *container) SignalAll(signal api.Signal) error {
+	return container.connection.SignalAll(container.handle, signal)
+}
+
+func (container *container) Pause() error {
+	return container.connection.Pause(container.handle)
+}
+
+func (container *container) Resume() error {
+	return container.connection.Resume(container.handle)
+}
+
+func (container *container) DestroyAt(at time.Time) error {
+	return container.connection.DestroyAt(container.handle, at)
+}
+
+func (container *container) DestroyAfter(delay time.Duration) error {
+	return container.connection.DestroyAfter(container.handle, delay)
+}
+
+func (container *container) CancelScheduledDestroy() error {
+	return container.connection.CancelScheduledDestroy(container.handle)
+}
+
+func (container *container) SetHold(held bool) error {
+	return container.connection.SetHold(container.handle, held)
+}
+
+func (container *container) SetGraceTime(graceTime time.Duration) error {
+	return container.connection.SetGraceTime(container.handle, graceTime)
+}
+
 func (container *container) Info() (api.ContainerInfo, error) {
-	return container.connection.Info(container.handle)
+	info, err := container.connection.Info(container.handle)
+	if err != nil {
+		return api.ContainerInfo{}, err
+	}
+
+	container.snapshotL.Lock()
+	container.properties = nil
+	container.info = &info
+	container.snapshotL.Unlock()
+
+	return info, nil
+}
+
+func (container *container) Refresh() error {
+	_, err := container.Info()
+	return err
+}
+
+func (container *container) InfoFields(fields []api.InfoField) (api.ContainerInfo, error) {
+	return container.connection.InfoFields(container.handle, fields)
+}
+
+func (container *container) StreamIn(spec api.StreamInSpec) error {
+	return container.connection.StreamIn(container.handle, spec)
 }
 
-func (container *container) StreamIn(dstPath string, reader io.Reader) error {
-	return container.connection.StreamIn(container.handle, dstPath, reader)
+func (container *container) StreamOut(spec api.StreamOutSpec) (io.ReadCloser, error) {
+	return container.connection.StreamOut(container.handle, spec)
 }
 
-func (container *container) StreamOut(srcPath string) (io.ReadCloser, error) {
-	return container.connection.StreamOut(container.handle, srcPath)
+func (container *container) VerifyStreamIn(dstPath string, reader io.Reader) (api.StreamInReport, error) {
+	return container.connection.VerifyStreamIn(container.handle, dstPath, reader)
 }
 
 func (container *container) LimitBandwidth(limits api.BandwidthLimits) error {
@@ -80,6 +238,14 @@ func (container *container) CurrentDiskLimits() (api.DiskLimits, error) {
 	return container.connection.CurrentDiskLimits(container.handle)
 }
 
+func (container *container) DiskUsage() (api.ContainerDiskUsage, error) {
+	return container.connection.DiskUsage(container.handle)
+}
+
+func (container *container) Env() ([]string, error) {
+	return container.connection.Env(container.handle)
+}
+
 func (container *container) LimitMemory(limits api.MemoryLimits) error {
 	_, err := container.connection.LimitMemory(container.handle, limits)
 	if err != nil {
@@ -101,22 +267,104 @@ func (container *container) Attach(processID uint32, io api.ProcessIO) (api.Proc
 	return container.connection.Attach(container.handle, processID, io)
 }
 
+func (container *container) AttachAll(io func(uint32) api.ProcessIO) ([]api.Process, error) {
+	return container.connection.AttachAll(container.handle, io)
+}
+
+func (container *container) ProcessInfo(processID uint32) (api.ProcessInfo, error) {
+	return container.connection.ProcessInfo(container.handle, processID)
+}
+
+func (container *container) FindProcesses(labels map[string]string) ([]uint32, error) {
+	return container.connection.FindProcesses(container.handle, labels)
+}
+
+func (container *container) Logs(tail int, follow bool) (api.LogStream, error) {
+	return container.connection.Logs(container.handle, tail, follow)
+}
+
+func (container *container) WaitForProcess(matcher api.ProcessMatcher, timeout time.Duration) (uint32, error) {
+	return container.connection.WaitForProcess(container.handle, matcher, timeout)
+}
+
 func (container *container) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
+	defer container.discardSnapshot()
 	return container.connection.NetIn(container.handle, hostPort, containerPort)
 }
 
+func (container *container) NetInSpec(spec api.NetInSpec) (uint32, uint32, error) {
+	defer container.discardSnapshot()
+	return container.connection.NetInSpec(container.handle, spec)
+}
+
+func (container *container) MappedPorts() ([]api.PortMapping, error) {
+	container.snapshotL.Lock()
+	info := container.info
+	container.snapshotL.Unlock()
+
+	if info != nil {
+		return info.MappedPorts, nil
+	}
+
+	return container.connection.MappedPorts(container.handle)
+}
+
 func (container *container) NetOut(network string, port uint32, portRange string, protocol api.Protocol) error {
 	return container.connection.NetOut(container.handle, network, port, portRange, protocol)
 }
 
+func (container *container) NetOutRule(rule api.NetOutRule) error {
+	return container.connection.NetOutRule(container.handle, rule)
+}
+
 func (container *container) GetProperty(name string) (string, error) {
 	return container.connection.GetProperty(container.handle, name)
 }
 
+func (container *container) Properties() (api.Properties, error) {
+	container.snapshotL.Lock()
+	properties, info := container.properties, container.info
+	container.snapshotL.Unlock()
+
+	if properties != nil {
+		return properties, nil
+	}
+
+	if info != nil {
+		return info.Properties, nil
+	}
+
+	fetched, err := container.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return fetched.Properties, nil
+}
+
 func (container *container) SetProperty(name string, value string) error {
+	defer container.discardSnapshot()
 	return container.connection.SetProperty(container.handle, name, value)
 }
 
+func (container *container) SetProperties(properties api.Properties) error {
+	defer container.discardSnapshot()
+	return container.connection.SetProperties(container.handle, properties)
+}
+
+func (container *container) CompareAndSwapProperty(name string, oldValue string, newValue string) (bool, error) {
+	defer container.discardSnapshot()
+	return container.connection.CompareAndSwapProperty(container.handle, name, oldValue, newValue)
+}
+
 func (container *container) RemoveProperty(name string) error {
+	defer container.discardSnapshot()
 	return container.connection.RemoveProperty(container.handle, name)
 }
+
+func (container *container) discardSnapshot() {
+	container.snapshotL.Lock()
+	container.properties = nil
+	container.info = nil
+	container.snapshotL.Unlock()
+}
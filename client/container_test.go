@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,11 +16,12 @@ import (
 	"github.com/cloudfoundry-incubator/garden/api"
 	wfakes "github.com/cloudfoundry-incubator/garden/api/fakes"
 	. "github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
 	"github.com/cloudfoundry-incubator/garden/client/connection/fakes"
 )
 
 var _ = Describe("Container", func() {
-	var container api.Container
+	var container Container
 
 	var fakeConnection *fakes.FakeConnection
 
@@ -28,14 +30,14 @@ var _ = Describe("Container", func() {
 	})
 
 	JustBeforeEach(func() {
-		var err error
-
 		client := New(fakeConnection)
 
-		fakeConnection.CreateReturns("some-handle", nil)
+		fakeConnection.CreateReturns("some-handle", nil, nil)
 
-		container, err = client.Create(api.ContainerSpec{})
+		created, err := client.Create(api.ContainerSpec{})
 		Ω(err).ShouldNot(HaveOccurred())
+
+		container = created.(Container)
 	})
 
 	Describe("Handle", func() {
@@ -68,6 +70,180 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("StopWithReason", func() {
+		It("sends a stop request with the reason", func() {
+			err := container.StopWithReason(false, "evacuating the cell")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, kill, reason := fakeConnection.StopWithReasonArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(kill).Should(BeFalse())
+			Ω(reason).Should(Equal("evacuating the cell"))
+		})
+	})
+
+	Describe("SignalAll", func() {
+		It("sends a signal request", func() {
+			err := container.SignalAll(api.SignalHangup)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, signal := fakeConnection.SignalAllArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(signal).Should(Equal(api.SignalHangup))
+		})
+
+		Context("when signalling fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.SignalAllReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.SignalAll(api.SignalHangup)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("SetGraceTime", func() {
+		It("sends a set grace time request", func() {
+			err := container.SetGraceTime(time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, graceTime := fakeConnection.SetGraceTimeArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(graceTime).Should(Equal(time.Minute))
+		})
+
+		Context("when setting the grace time fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.SetGraceTimeReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.SetGraceTime(time.Minute)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Pause", func() {
+		It("sends a pause request", func() {
+			err := container.Pause()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.PauseArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when pausing fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.PauseReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.Pause()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Resume", func() {
+		It("sends a resume request", func() {
+			err := container.Resume()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ResumeArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when resuming fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ResumeReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.Resume()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("DestroyAt", func() {
+		It("sends a scheduled destroy request", func() {
+			at := time.Now().Add(time.Hour)
+
+			err := container.DestroyAt(at)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, scheduledAt := fakeConnection.DestroyAtArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(scheduledAt).Should(Equal(at))
+		})
+	})
+
+	Describe("DestroyAfter", func() {
+		It("sends a scheduled destroy request", func() {
+			err := container.DestroyAfter(time.Hour)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, delay := fakeConnection.DestroyAfterArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(delay).Should(Equal(time.Hour))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.DestroyAfterReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.DestroyAfter(time.Hour)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("CancelScheduledDestroy", func() {
+		It("sends a cancel request", func() {
+			err := container.CancelScheduledDestroy()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.CancelScheduledDestroyArgsForCall(0)).Should(Equal("some-handle"))
+		})
+	})
+
+	Describe("SetHold", func() {
+		It("sends a hold request", func() {
+			err := container.SetHold(true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, held := fakeConnection.SetHoldArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(held).Should(BeTrue())
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.SetHoldReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.SetHold(true)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Info", func() {
 		It("sends an info request", func() {
 			infoToReturn := api.ContainerInfo{
@@ -98,19 +274,56 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("InfoFields", func() {
+		It("sends an info request for the fields", func() {
+			infoToReturn := api.ContainerInfo{
+				State: "chillin",
+			}
+
+			fakeConnection.InfoFieldsReturns(infoToReturn, nil)
+
+			info, err := container.InfoFields([]api.InfoField{api.InfoFieldState})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, fields := fakeConnection.InfoFieldsArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(fields).Should(Equal([]api.InfoField{api.InfoFieldState}))
+
+			Ω(info).Should(Equal(infoToReturn))
+		})
+
+		Context("when getting info fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.InfoFieldsReturns(api.ContainerInfo{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.InfoFields([]api.InfoField{api.InfoFieldState})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("StreamIn", func() {
 		It("sends a stream in request", func() {
-			fakeConnection.StreamInStub = func(handle string, dst string, reader io.Reader) error {
-				Ω(dst).Should(Equal("to"))
+			fakeConnection.StreamInStub = func(handle string, spec api.StreamInSpec) error {
+				Ω(spec.Path).Should(Equal("to"))
+				Ω(spec.User).Should(Equal("some-user"))
 
-				content, err := ioutil.ReadAll(reader)
+				content, err := ioutil.ReadAll(spec.TarStream)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(string(content)).Should(Equal("stuff"))
 
 				return nil
 			}
 
-			err := container.StreamIn("to", bytes.NewBufferString("stuff"))
+			err := container.StreamIn(api.StreamInSpec{
+				Path:      "to",
+				TarStream: bytes.NewBufferString("stuff"),
+				User:      "some-user",
+			})
 			Ω(err).ShouldNot(HaveOccurred())
 		})
 
@@ -123,7 +336,41 @@ var _ = Describe("Container", func() {
 			})
 
 			It("returns the error", func() {
-				err := container.StreamIn("to", nil)
+				err := container.StreamIn(api.StreamInSpec{Path: "to"})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("VerifyStreamIn", func() {
+		It("sends a verify stream in request", func() {
+			report := api.StreamInReport{Entries: 1, Bytes: 5}
+
+			fakeConnection.VerifyStreamInStub = func(handle string, dst string, reader io.Reader) (api.StreamInReport, error) {
+				Ω(handle).Should(Equal("some-handle"))
+				Ω(dst).Should(Equal("to"))
+
+				content, err := ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(Equal("stuff"))
+
+				return report, nil
+			}
+
+			verified, err := container.VerifyStreamIn("to", bytes.NewBufferString("stuff"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(verified).Should(Equal(report))
+		})
+
+		Context("when verifying fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.VerifyStreamInReturns(api.StreamInReport{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.VerifyStreamIn("to", nil)
 				Ω(err).Should(Equal(disaster))
 			})
 		})
@@ -133,14 +380,14 @@ var _ = Describe("Container", func() {
 		It("sends a stream out request", func() {
 			fakeConnection.StreamOutReturns(ioutil.NopCloser(strings.NewReader("kewl")), nil)
 
-			reader, err := container.StreamOut("from")
+			reader, err := container.StreamOut(api.StreamOutSpec{Path: "from"})
 			bytes, err := ioutil.ReadAll(reader)
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(string(bytes)).Should(Equal("kewl"))
 
-			handle, src := fakeConnection.StreamOutArgsForCall(0)
+			handle, spec := fakeConnection.StreamOutArgsForCall(0)
 			Ω(handle).Should(Equal("some-handle"))
-			Ω(src).Should(Equal("from"))
+			Ω(spec).Should(Equal(api.StreamOutSpec{Path: "from"}))
 		})
 
 		Context("when streaming out fails", func() {
@@ -151,7 +398,7 @@ var _ = Describe("Container", func() {
 			})
 
 			It("returns the error", func() {
-				_, err := container.StreamOut("from")
+				_, err := container.StreamOut(api.StreamOutSpec{Path: "from"})
 				Ω(err).Should(Equal(disaster))
 			})
 		})
@@ -351,6 +598,65 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("DiskUsage", func() {
+		It("gets the disk usage", func() {
+			usageToReturn := api.ContainerDiskUsage{
+				RootFSLayers: []api.DiskUsageEntry{
+					{Name: "layer-a", BytesUsed: 1, InodesUsed: 2},
+				},
+				Scratch: api.DiskUsageEntry{BytesUsed: 3, InodesUsed: 4},
+			}
+
+			fakeConnection.DiskUsageReturns(usageToReturn, nil)
+
+			usage, err := container.DiskUsage()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(usage).Should(Equal(usageToReturn))
+
+			Ω(fakeConnection.DiskUsageArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.DiskUsageReturns(api.ContainerDiskUsage{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.DiskUsage()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Env", func() {
+		It("gets the environment", func() {
+			fakeConnection.EnvReturns([]string{"FLAVOR=vanilla"}, nil)
+
+			env, err := container.Env()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(env).Should(Equal([]string{"FLAVOR=vanilla"}))
+
+			Ω(fakeConnection.EnvArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.EnvReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.Env()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("CurrentMemoryLimits", func() {
 		It("gets the current limits", func() {
 			limitsToReturn := api.MemoryLimits{
@@ -431,6 +737,94 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("Exec", func() {
+		var process *wfakes.FakeProcess
+
+		BeforeEach(func() {
+			process = new(wfakes.FakeProcess)
+			process.WaitReturns(3, nil)
+
+			fakeConnection.RunStub = func(handle string, spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+				fmt.Fprintf(io.Stdout, "stdout data")
+				fmt.Fprintf(io.Stderr, "stderr data")
+
+				return process, nil
+			}
+		})
+
+		It("runs the process, returning its output and exit status once it exits", func() {
+			stdin := bytes.NewBufferString("stdin data")
+
+			stdout, stderr, exitCode, err := container.Exec(api.ExecSpec{
+				ProcessSpec: api.ProcessSpec{Path: "some-script"},
+				Stdin:       stdin,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(string(stdout)).Should(Equal("stdout data"))
+			Ω(string(stderr)).Should(Equal("stderr data"))
+			Ω(exitCode).Should(Equal(3))
+
+			ranHandle, ranSpec, ranIO := fakeConnection.RunArgsForCall(0)
+			Ω(ranHandle).Should(Equal("some-handle"))
+			Ω(ranSpec).Should(Equal(api.ProcessSpec{Path: "some-script"}))
+			Ω(ranIO.Stdin).Should(Equal(stdin))
+		})
+
+		Context("with a timeout", func() {
+			var exit chan int
+
+			BeforeEach(func() {
+				exit = make(chan int)
+
+				process.WaitStub = func() (int, error) {
+					return <-exit, nil
+				}
+			})
+
+			AfterEach(func() {
+				close(exit)
+			})
+
+			It("waits at most that long, returning the output so far", func() {
+				stdout, _, _, err := container.Exec(api.ExecSpec{
+					ProcessSpec: api.ProcessSpec{Path: "some-script"},
+					Timeout:     50 * time.Millisecond,
+				})
+				Ω(err).Should(Equal(connection.ErrWaitTimedOut))
+
+				Ω(string(stdout)).Should(Equal("stdout data"))
+			})
+
+			It("returns the exit status if the process exits in time", func() {
+				go func() {
+					exit <- 3
+				}()
+
+				_, _, exitCode, err := container.Exec(api.ExecSpec{
+					ProcessSpec: api.ProcessSpec{Path: "some-script"},
+					Timeout:     time.Second,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(exitCode).Should(Equal(3))
+			})
+		})
+
+		Context("when running the process fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.RunStub = nil
+				fakeConnection.RunReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				_, _, _, err := container.Exec(api.ExecSpec{})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Attach", func() {
 		It("sends an attach request and returns a stream", func() {
 			fakeConnection.AttachStub = func(handle string, processID uint32, io api.ProcessIO) (api.Process, error) {
@@ -479,6 +873,96 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("AttachAll", func() {
+		It("sends an attach-all request and returns the attached processes", func() {
+			fakeConnection.AttachAllStub = func(handle string, io func(uint32) api.ProcessIO) ([]api.Process, error) {
+				process := new(wfakes.FakeProcess)
+
+				process.IDReturns(42)
+				process.WaitReturns(123, nil)
+
+				processIO := io(42)
+
+				go func() {
+					defer GinkgoRecover()
+
+					_, err := fmt.Fprintf(processIO.Stdout, "stdout data")
+					Ω(err).ShouldNot(HaveOccurred())
+				}()
+
+				return []api.Process{process}, nil
+			}
+
+			stdout := gbytes.NewBuffer()
+
+			processes, err := container.AttachAll(func(processID uint32) api.ProcessIO {
+				Ω(processID).Should(Equal(uint32(42)))
+				return api.ProcessIO{Stdout: stdout}
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			attachedHandle, _ := fakeConnection.AttachAllArgsForCall(0)
+			Ω(attachedHandle).Should(Equal("some-handle"))
+
+			Ω(processes).Should(HaveLen(1))
+			Ω(processes[0].ID()).Should(Equal(uint32(42)))
+
+			status, err := processes[0].Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status).Should(Equal(123))
+
+			Eventually(stdout).Should(gbytes.Say("stdout data"))
+		})
+
+		Context("when attaching fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.AttachAllReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.AttachAll(func(uint32) api.ProcessIO { return api.ProcessIO{} })
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("ProcessInfo", func() {
+		It("gets the process's info", func() {
+			infoToReturn := api.ProcessInfo{
+				ID:         42,
+				State:      api.ProcessStateRunning,
+				RSSInBytes: 4096,
+			}
+
+			fakeConnection.ProcessInfoReturns(infoToReturn, nil)
+
+			info, err := container.ProcessInfo(42)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info).Should(Equal(infoToReturn))
+
+			handle, processID := fakeConnection.ProcessInfoArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(processID).Should(Equal(uint32(42)))
+		})
+	})
+
+	Describe("FindProcesses", func() {
+		It("asks for the processes with the labels", func() {
+			fakeConnection.FindProcessesReturns([]uint32{42}, nil)
+
+			processIDs, err := container.FindProcesses(map[string]string{"role": "health-check"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(processIDs).Should(Equal([]uint32{42}))
+
+			handle, labels := fakeConnection.FindProcessesArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(labels).Should(Equal(map[string]string{"role": "health-check"}))
+		})
+	})
+
 	Describe("NetIn", func() {
 		It("sends a net in request", func() {
 			fakeConnection.NetInReturns(111, 222, nil)
@@ -508,6 +992,159 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("NetInSpec", func() {
+		It("sends a net in request with the spec", func() {
+			fakeConnection.NetInSpecReturns(111, 222, nil)
+
+			spec := api.NetInSpec{
+				HostPort:      123,
+				ContainerPort: 456,
+				Protocol:      api.ProtocolUDP,
+			}
+
+			hostPort, containerPort, err := container.NetInSpec(spec)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(hostPort).Should(Equal(uint32(111)))
+			Ω(containerPort).Should(Equal(uint32(222)))
+
+			h, sentSpec := fakeConnection.NetInSpecArgsForCall(0)
+			Ω(h).Should(Equal("some-handle"))
+			Ω(sentSpec).Should(Equal(spec))
+		})
+	})
+
+	Describe("MappedPorts", func() {
+		It("sends a mapped ports request", func() {
+			mappings := []api.PortMapping{
+				{HostPort: 111, ContainerPort: 222},
+			}
+
+			fakeConnection.MappedPortsReturns(mappings, nil)
+
+			mappedPorts, err := container.MappedPorts()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(mappedPorts).Should(Equal(mappings))
+
+			Ω(fakeConnection.MappedPortsArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.MappedPortsReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.MappedPorts()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Context("when the server reported the container's info on creation", func() {
+		JustBeforeEach(func() {
+			fakeConnection.CreateReturns("some-handle", &api.ContainerInfo{
+				State: "active",
+				MappedPorts: []api.PortMapping{
+					{HostPort: 111, ContainerPort: 222},
+				},
+				Properties: api.Properties{"foo": "bar"},
+			}, nil)
+
+			created, err := New(fakeConnection).Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			container = created.(Container)
+		})
+
+		It("answers MappedPorts and Properties from it", func() {
+			mappedPorts, err := container.MappedPorts()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(mappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 111, ContainerPort: 222},
+			}))
+
+			properties, err := container.Properties()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(properties).Should(Equal(api.Properties{"foo": "bar"}))
+
+			Ω(fakeConnection.MappedPortsCallCount()).Should(BeZero())
+			Ω(fakeConnection.InfoCallCount()).Should(BeZero())
+		})
+
+		It("refreshes it when asked for info", func() {
+			fakeConnection.InfoReturns(api.ContainerInfo{
+				State: "stopped",
+				MappedPorts: []api.PortMapping{
+					{HostPort: 333, ContainerPort: 444},
+				},
+			}, nil)
+
+			info, err := container.Info()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(info.State).Should(Equal("stopped"))
+
+			mappedPorts, err := container.MappedPorts()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(mappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 333, ContainerPort: 444},
+			}))
+
+			Ω(fakeConnection.MappedPortsCallCount()).Should(BeZero())
+		})
+
+		It("answers from the info it fetches when refreshed", func() {
+			fakeConnection.InfoReturns(api.ContainerInfo{
+				Properties: api.Properties{"foo": "baz"},
+			}, nil)
+
+			properties, err := container.Properties()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(properties).Should(Equal(api.Properties{"foo": "bar"}))
+
+			err = container.Refresh()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.InfoArgsForCall(0)).Should(Equal("some-handle"))
+
+			properties, err = container.Properties()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(properties).Should(Equal(api.Properties{"foo": "baz"}))
+
+			Ω(fakeConnection.InfoCallCount()).Should(Equal(1))
+		})
+
+		Context("when refreshing fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.InfoReturns(api.ContainerInfo{}, disaster)
+			})
+
+			It("returns the error", func() {
+				Ω(container.Refresh()).Should(Equal(disaster))
+			})
+		})
+
+		It("discards it when the container is changed", func() {
+			fakeConnection.MappedPortsReturns([]api.PortMapping{
+				{HostPort: 333, ContainerPort: 444},
+			}, nil)
+
+			_, _, err := container.NetIn(333, 444)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			mappedPorts, err := container.MappedPorts()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(mappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 333, ContainerPort: 444},
+			}))
+
+			Ω(fakeConnection.MappedPortsCallCount()).Should(Equal(1))
+		})
+	})
+
 	Describe("NetOut", func() {
 		It("sends a net out request with a port", func() {
 			err := container.NetOut("some-network", 1234, "", api.ProtocolTCP)
@@ -546,4 +1183,33 @@ var _ = Describe("Container", func() {
 			})
 		})
 	})
+
+	Describe("NetOutRule", func() {
+		rule := api.NetOutRule{
+			Protocol: api.ProtocolTCP,
+			Ports:    []api.PortRange{api.PortRangeFromPort(443)},
+		}
+
+		It("sends a net out rule request", func() {
+			err := container.NetOutRule(rule)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			h, sentRule := fakeConnection.NetOutRuleArgsForCall(0)
+			Ω(h).Should(Equal("some-handle"))
+			Ω(sentRule).Should(Equal(rule))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.NetOutRuleReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.NetOutRule(rule)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
 })
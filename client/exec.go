@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+)
+
+func (container *container) Exec(spec api.ExecSpec) ([]byte, []byte, int, error) {
+	stdout := new(outputBuffer)
+	stderr := new(outputBuffer)
+
+	process, err := container.Run(spec.ProcessSpec, api.ProcessIO{
+		Stdin:  spec.Stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var exitCode int
+	if spec.Timeout > 0 {
+		exitCode, err = waitWithTimeout(process, spec.Timeout)
+	} else {
+		exitCode, err = process.Wait()
+	}
+
+	return stdout.Bytes(), stderr.Bytes(), exitCode, err
+}
+
+// waitWithTimeout waits for the process as Process.WaitWithTimeout does, for
+// processes which the connection returns without it.
+func waitWithTimeout(process api.Process, timeout time.Duration) (int, error) {
+	if process, ok := process.(Process); ok {
+		return process.WaitWithTimeout(timeout)
+	}
+
+	type exit struct {
+		status int
+		err    error
+	}
+
+	exited := make(chan exit, 1)
+
+	go func() {
+		status, err := process.Wait()
+		exited <- exit{status, err}
+	}()
+
+	select {
+	case exit := <-exited:
+		return exit.status, exit.err
+	case <-time.After(timeout):
+		return 0, connection.ErrWaitTimedOut
+	}
+}
+
+// outputBuffer collects a process's output, which is still being written
+// when Exec times out waiting for it.
+type outputBuffer struct {
+	buffer bytes.Buffer
+	mu     sync.Mutex
+}
+
+func (b *outputBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buffer.Write(data)
+}
+
+func (b *outputBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]byte{}, b.buffer.Bytes()...)
+}
@@ -0,0 +1,366 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+)
+
+// Address is where a server listens, as given to connection.New.
+type Address struct {
+	Network string
+	Address string
+}
+
+// Strategy chooses which server of a pool creates each container.
+type Strategy interface {
+	// Choose returns the index of the server, of those given, to create the
+	// next container on.
+	Choose(servers []Client) (int, error)
+}
+
+// StrategyFunc adapts a function to a Strategy.
+type StrategyFunc func(servers []Client) (int, error)
+
+func (f StrategyFunc) Choose(servers []Client) (int, error) {
+	return f(servers)
+}
+
+// RoundRobin creates containers on each server in turn.
+func RoundRobin() Strategy {
+	var next uint64
+
+	return StrategyFunc(func(servers []Client) (int, error) {
+		return int((atomic.AddUint64(&next, 1) - 1) % uint64(len(servers))), nil
+	})
+}
+
+// MostHeadroom creates containers on the healthy server with room for the
+// most more, asking each for its health first. Servers which do not report a
+// maximum are chosen last. It fails with the last error if no server is
+// healthy.
+func MostHeadroom() Strategy {
+	return StrategyFunc(func(servers []Client) (int, error) {
+		chosen := -1
+		var most uint64
+		var lastErr error
+
+		for i, server := range servers {
+			health, err := server.HealthCheck()
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			if !health.Healthy {
+				lastErr = fmt.Errorf("unhealthy server: %s", health.BackendError)
+				continue
+			}
+
+			if chosen == -1 || health.ContainerHeadroom > most {
+				chosen = i
+				most = health.ContainerHeadroom
+			}
+		}
+
+		if chosen == -1 {
+			return 0, lastErr
+		}
+
+		return chosen, nil
+	})
+}
+
+// Pool is the client NewPool returns: an api.Client, with the calls of Client
+// which it routes to the server which has the container.
+type Pool interface {
+	api.Client
+
+	Rename(oldHandle, newHandle string) error
+	LookupBy(filter api.Properties) (api.Container, error)
+	Snapshot(handle string) (io.ReadCloser, error)
+	Restore(snapshot io.Reader) (api.Container, error)
+}
+
+type pool struct {
+	servers  []Client
+	strategy Strategy
+
+	// owners maps the handles of containers to the index of the server
+	// which has them, for those the pool has created or come across
+	owners   map[string]int
+	ownersMu sync.RWMutex
+}
+
+// NewPool returns a client which spreads the containers it creates across
+// the servers at the addresses, choosing each one's server with the
+// strategy, e.g. for test rigs of several hosts without a scheduler. Calls
+// for a container by its handle go to the server which has it, found by
+// asking each server in turn for containers the pool has not come across.
+func NewPool(addresses []Address, strategy Strategy) Pool {
+	servers := make([]Client, len(addresses))
+	for i, address := range addresses {
+		servers[i] = New(connection.New(address.Network, address.Address))
+	}
+
+	return NewPoolOf(servers, strategy)
+}
+
+// NewPoolOf is like NewPool, but pools the clients given, e.g. ones made with
+// NewNegotiated or with connections configured other than by their address.
+func NewPoolOf(servers []Client, strategy Strategy) Pool {
+	return &pool{
+		servers:  servers,
+		strategy: strategy,
+		owners:   map[string]int{},
+	}
+}
+
+// Ping pings every server, failing if any fails.
+func (p *pool) Ping() error {
+	for _, server := range p.servers {
+		err := server.Ping()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Capacity is the total capacity of the servers.
+func (p *pool) Capacity() (api.Capacity, error) {
+	var total api.Capacity
+
+	for _, server := range p.servers {
+		capacity, err := server.Capacity()
+		if err != nil {
+			return api.Capacity{}, err
+		}
+
+		total.MemoryInBytes += capacity.MemoryInBytes
+		total.DiskInBytes += capacity.DiskInBytes
+		total.MaxContainers += capacity.MaxContainers
+	}
+
+	return total, nil
+}
+
+func (p *pool) Create(spec api.ContainerSpec) (api.Container, error) {
+	index, err := p.choose()
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := p.servers[index].Create(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	p.own(container.Handle(), index)
+
+	return container, nil
+}
+
+func (p *pool) Destroy(handle string) error {
+	return p.routed(handle, func(server Client) error {
+		err := server.Destroy(handle)
+		if err == nil {
+			p.forget(handle)
+		}
+
+		return err
+	})
+}
+
+func (p *pool) Rename(oldHandle, newHandle string) error {
+	index, err := p.owner(oldHandle)
+	if err != nil {
+		return err
+	}
+
+	err = p.servers[index].Rename(oldHandle, newHandle)
+	if err != nil && err != api.ErrContainerNotFound {
+		return err
+	}
+
+	p.forget(oldHandle)
+
+	if err != nil {
+		return err
+	}
+
+	p.own(newHandle, index)
+
+	return nil
+}
+
+// Containers lists the containers of every server.
+func (p *pool) Containers(properties api.Properties) ([]api.Container, error) {
+	containers := []api.Container{}
+
+	for i, server := range p.servers {
+		found, err := server.Containers(properties)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, container := range found {
+			p.own(container.Handle(), i)
+		}
+
+		containers = append(containers, found...)
+	}
+
+	return containers, nil
+}
+
+func (p *pool) Lookup(handle string) (api.Container, error) {
+	var container api.Container
+
+	err := p.routed(handle, func(server Client) error {
+		var err error
+		container, err = server.Lookup(handle)
+		return err
+	})
+
+	return container, err
+}
+
+// LookupBy looks for the container on every server, failing if none or
+// several of them have one whose properties match.
+func (p *pool) LookupBy(filter api.Properties) (api.Container, error) {
+	matching := []api.Container{}
+
+	for i, server := range p.servers {
+		container, err := server.LookupBy(filter)
+		if err == api.ErrContainerNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.own(container.Handle(), i)
+
+		matching = append(matching, container)
+	}
+
+	switch len(matching) {
+	case 0:
+		return nil, api.ErrContainerNotFound
+	case 1:
+		return matching[0], nil
+	default:
+		return nil, fmt.Errorf("%d containers match the properties", len(matching))
+	}
+}
+
+func (p *pool) Snapshot(handle string) (io.ReadCloser, error) {
+	var snapshot io.ReadCloser
+
+	err := p.routed(handle, func(server Client) error {
+		var err error
+		snapshot, err = server.Snapshot(handle)
+		return err
+	})
+
+	return snapshot, err
+}
+
+// Restore restores the container on the server the strategy chooses, which
+// need not be the one it was snapshotted on.
+func (p *pool) Restore(snapshot io.Reader) (api.Container, error) {
+	index, err := p.choose()
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := p.servers[index].Restore(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	p.own(container.Handle(), index)
+
+	return container, nil
+}
+
+func (p *pool) choose() (int, error) {
+	if len(p.servers) == 0 {
+		return 0, api.ErrCapacityExceeded
+	}
+
+	index, err := p.strategy.Choose(p.servers)
+	if err != nil {
+		return 0, err
+	}
+
+	if index < 0 || index >= len(p.servers) {
+		return 0, fmt.Errorf("strategy chose server %d of %d", index, len(p.servers))
+	}
+
+	return index, nil
+}
+
+// routed calls call with the server which has the container, locating it if
+// the pool has not come across it. A container the server no longer has, e.g.
+// having been renamed or destroyed by another client, is forgotten, so that
+// it is located afresh next time.
+func (p *pool) routed(handle string, call func(Client) error) error {
+	index, err := p.owner(handle)
+	if err != nil {
+		return err
+	}
+
+	err = call(p.servers[index])
+	if err == api.ErrContainerNotFound {
+		p.forget(handle)
+	}
+
+	return err
+}
+
+func (p *pool) owner(handle string) (int, error) {
+	p.ownersMu.RLock()
+	index, found := p.owners[handle]
+	p.ownersMu.RUnlock()
+
+	if found {
+		return index, nil
+	}
+
+	for i, server := range p.servers {
+		_, err := server.Lookup(handle)
+		if err == api.ErrContainerNotFound {
+			continue
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		p.own(handle, i)
+
+		return i, nil
+	}
+
+	return 0, api.ErrContainerNotFound
+}
+
+func (p *pool) own(handle string, index int) {
+	p.ownersMu.Lock()
+	p.owners[handle] = index
+	p.ownersMu.Unlock()
+}
+
+func (p *pool) forget(handle string) {
+	p.ownersMu.Lock()
+	delete(p.owners, handle)
+	p.ownersMu.Unlock()
+}
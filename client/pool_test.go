@@ -0,0 +1,201 @@
+package client_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	. "github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection/fakes"
+)
+
+var _ = Describe("Pool", func() {
+	var pool Pool
+
+	var firstConnection *fakes.FakeConnection
+	var secondConnection *fakes.FakeConnection
+
+	var strategy Strategy
+
+	BeforeEach(func() {
+		firstConnection = new(fakes.FakeConnection)
+		secondConnection = new(fakes.FakeConnection)
+
+		firstConnection.CreateReturns("first-handle", nil, nil)
+		secondConnection.CreateReturns("second-handle", nil, nil)
+
+		strategy = RoundRobin()
+	})
+
+	JustBeforeEach(func() {
+		pool = NewPoolOf([]Client{New(firstConnection), New(secondConnection)}, strategy)
+	})
+
+	Describe("Create", func() {
+		It("creates containers on the server the strategy chooses", func() {
+			container, err := pool.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(container.Handle()).Should(Equal("first-handle"))
+
+			container, err = pool.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(container.Handle()).Should(Equal("second-handle"))
+
+			Ω(firstConnection.CreateCallCount()).Should(Equal(1))
+			Ω(secondConnection.CreateCallCount()).Should(Equal(1))
+		})
+
+		It("sends calls for the containers by their handle to their server, without looking for them", func() {
+			_, err := pool.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = pool.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = pool.Destroy("second-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(firstConnection.DestroyCallCount()).Should(BeZero())
+			Ω(secondConnection.DestroyCallCount()).Should(Equal(1))
+			Ω(secondConnection.DestroyArgsForCall(0)).Should(Equal("second-handle"))
+
+			Ω(firstConnection.ListCallCount()).Should(BeZero())
+			Ω(secondConnection.ListCallCount()).Should(BeZero())
+		})
+
+		Context("with the most headroom strategy", func() {
+			BeforeEach(func() {
+				strategy = MostHeadroom()
+
+				firstConnection.HealthCheckReturns(api.HealthStatus{Healthy: true, ContainerHeadroom: 2}, nil)
+				secondConnection.HealthCheckReturns(api.HealthStatus{Healthy: true, ContainerHeadroom: 5}, nil)
+			})
+
+			It("creates containers on the server with room for the most", func() {
+				container, err := pool.Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(container.Handle()).Should(Equal("second-handle"))
+			})
+
+			Context("when that server is unhealthy", func() {
+				BeforeEach(func() {
+					secondConnection.HealthCheckReturns(api.HealthStatus{Healthy: false, ContainerHeadroom: 5}, nil)
+				})
+
+				It("creates containers on a healthy one", func() {
+					container, err := pool.Create(api.ContainerSpec{})
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(container.Handle()).Should(Equal("first-handle"))
+				})
+			})
+
+			Context("when no server is healthy", func() {
+				disaster := errors.New("oh no!")
+
+				BeforeEach(func() {
+					firstConnection.HealthCheckReturns(api.HealthStatus{}, disaster)
+					secondConnection.HealthCheckReturns(api.HealthStatus{}, disaster)
+				})
+
+				It("fails without creating the container", func() {
+					_, err := pool.Create(api.ContainerSpec{})
+					Ω(err).Should(Equal(disaster))
+
+					Ω(firstConnection.CreateCallCount()).Should(BeZero())
+					Ω(secondConnection.CreateCallCount()).Should(BeZero())
+				})
+			})
+		})
+	})
+
+	Describe("calls for containers the pool has not created", func() {
+		BeforeEach(func() {
+			firstConnection.ListReturns([]string{"some-handle"}, nil)
+			secondConnection.ListReturns([]string{"other-handle"}, nil)
+		})
+
+		It("finds the server which has the container", func() {
+			err := pool.Destroy("other-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(firstConnection.DestroyCallCount()).Should(BeZero())
+			Ω(secondConnection.DestroyCallCount()).Should(Equal(1))
+		})
+
+		It("fails with ErrContainerNotFound when no server has it", func() {
+			err := pool.Destroy("missing-handle")
+			Ω(err).Should(Equal(api.ErrContainerNotFound))
+
+			Ω(firstConnection.DestroyCallCount()).Should(BeZero())
+			Ω(secondConnection.DestroyCallCount()).Should(BeZero())
+		})
+
+		Context("once the container has been renamed", func() {
+			It("sends calls for its new handle to the same server", func() {
+				err := pool.Rename("other-handle", "new-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = pool.Destroy("new-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(secondConnection.DestroyCallCount()).Should(Equal(1))
+				Ω(secondConnection.DestroyArgsForCall(0)).Should(Equal("new-handle"))
+			})
+		})
+	})
+
+	Describe("Containers", func() {
+		BeforeEach(func() {
+			firstConnection.ListReturns([]string{"some-handle"}, nil)
+			secondConnection.ListReturns([]string{"other-handle"}, nil)
+		})
+
+		It("lists the containers of every server", func() {
+			containers, err := pool.Containers(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(containers).Should(HaveLen(2))
+			Ω(containers[0].Handle()).Should(Equal("some-handle"))
+			Ω(containers[1].Handle()).Should(Equal("other-handle"))
+		})
+	})
+
+	Describe("LookupBy", func() {
+		BeforeEach(func() {
+			firstConnection.LookupByReturns("", api.ErrContainerNotFound)
+			secondConnection.LookupByReturns("other-handle", nil)
+		})
+
+		It("returns the one container matching on any server", func() {
+			container, err := pool.LookupBy(api.Properties{"role": "db"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(container.Handle()).Should(Equal("other-handle"))
+		})
+
+		Context("when containers on several servers match", func() {
+			BeforeEach(func() {
+				firstConnection.LookupByReturns("some-handle", nil)
+			})
+
+			It("fails", func() {
+				_, err := pool.LookupBy(api.Properties{"role": "db"})
+				Ω(err).Should(MatchError("2 containers match the properties"))
+			})
+		})
+	})
+
+	Describe("Capacity", func() {
+		BeforeEach(func() {
+			firstConnection.CapacityReturns(api.Capacity{MemoryInBytes: 1, DiskInBytes: 2, MaxContainers: 3}, nil)
+			secondConnection.CapacityReturns(api.Capacity{MemoryInBytes: 10, DiskInBytes: 20, MaxContainers: 30}, nil)
+		})
+
+		It("totals the servers' capacity", func() {
+			capacity, err := pool.Capacity()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity).Should(Equal(api.Capacity{MemoryInBytes: 11, DiskInBytes: 22, MaxContainers: 33}))
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// Process is the api.Process this package's clients return, with calls which
+// the client answers itself from the process's stream, which backends'
+// processes therefore need not implement. Processes returned as api.Processes
+// by clients may be asserted to it.
+type Process interface {
+	api.Process
+
+	// WaitWithTimeout is like Wait, but gives up with an error if the process
+	// has not exited within the timeout.
+	WaitWithTimeout(time.Duration) (int, error)
+
+	// Exited reports, without blocking, whether the process has exited and if
+	// so its exit status.
+	Exited() (bool, int)
+}
@@ -0,0 +1,13 @@
+package gardentest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGardentest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gardentest Suite")
+}
@@ -0,0 +1,272 @@
+// Package gardentest runs a GardenServer against a fake backend, with a
+// client connected to it, so that tests of code talking to Garden can
+// exercise the real client and server without a real backend:
+//
+//	harness, err := gardentest.Start(gardentest.Config{})
+//	defer harness.Stop()
+//
+//	container := harness.NewContainer("some-handle")
+//	container.InfoReturns(api.ContainerInfo{State: "active"}, nil)
+//
+//	info, err := harness.Client.Lookup("some-handle") ...
+//
+// The backend's containers are fakes too, which tests script as usual.
+package gardentest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/api/fakes"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+// StartTimeout is how long Start waits for the server to accept connections.
+var StartTimeout = 5 * time.Second
+
+type Config struct {
+	// GraceTime is the server's default container grace time.
+	GraceTime time.Duration
+
+	// ErrorAnnotationKeys are the container properties the server reports
+	// with errors.
+	ErrorAnnotationKeys []string
+
+	// Setup, if set, is called with the server before it is started, e.g. to
+	// call its setters.
+	Setup func(*server.GardenServer)
+}
+
+// Harness is a running GardenServer, listening on a temporary unix socket.
+type Harness struct {
+	Server  *server.GardenServer
+	Backend *fakes.FakeBackend
+	Client  client.Client
+	Logger  *lagertest.TestLogger
+
+	SocketPath string
+
+	tmpdir   string
+	stopOnce sync.Once
+
+	containers map[string]*fakes.FakeContainer
+	lastHandle int
+	mu         sync.Mutex
+}
+
+// Start starts a server against a fake backend, returning once it accepts
+// connections. The backend keeps the containers made with NewContainer or
+// created through the server: it looks them up, lists them, and destroys
+// them. Tests may stub its methods differently.
+func Start(config Config) (*Harness, error) {
+	tmpdir, err := ioutil.TempDir("", "gardentest")
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
+		Backend: new(fakes.FakeBackend),
+		Logger:  lagertest.NewTestLogger("gardentest"),
+
+		SocketPath: path.Join(tmpdir, "garden.sock"),
+
+		tmpdir: tmpdir,
+
+		containers: map[string]*fakes.FakeContainer{},
+	}
+
+	h.Backend.CreateStub = h.create
+	h.Backend.DestroyStub = h.destroy
+	h.Backend.LookupStub = h.lookup
+	h.Backend.ContainersStub = h.list
+
+	h.Server = server.New(
+		"unix",
+		h.SocketPath,
+		config.GraceTime,
+		h.Backend,
+		h.Logger,
+	)
+
+	h.Server.SetErrorAnnotationKeys(config.ErrorAnnotationKeys)
+
+	if config.Setup != nil {
+		config.Setup(h.Server)
+	}
+
+	err = h.Server.Start()
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		return nil, err
+	}
+
+	err = h.waitForServer()
+	if err != nil {
+		h.Stop()
+		return nil, err
+	}
+
+	h.Client = h.NewClient()
+
+	return h, nil
+}
+
+// NewClient returns another client connected to the server, e.g. one of
+// several concurrent clients.
+func (h *Harness) NewClient() client.Client {
+	return client.New(h.NewConnection())
+}
+
+// NewConnection returns a connection to the server, for tests of the
+// connection itself.
+func (h *Harness) NewConnection() connection.Connection {
+	return connection.New("unix", h.SocketPath)
+}
+
+// NewContainer adds a fake container with the handle to the backend,
+// returning it to be scripted.
+func (h *Harness) NewContainer(handle string) *fakes.FakeContainer {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.newContainer(handle)
+}
+
+// Container returns the backend's container with the handle, or nil.
+func (h *Harness) Container(handle string) *fakes.FakeContainer {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.containers[handle]
+}
+
+// Stop stops the server and removes its socket. It may be called more than
+// once, e.g. by a test and again by its cleanup.
+func (h *Harness) Stop() {
+	h.stopOnce.Do(func() {
+		h.Server.Stop()
+		os.RemoveAll(h.tmpdir)
+	})
+}
+
+func (h *Harness) waitForServer() error {
+	deadline := time.Now().Add(StartTimeout)
+
+	for {
+		conn, err := net.Dial("unix", h.SocketPath)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("server did not start listening: %s", err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (h *Harness) newContainer(handle string) *fakes.FakeContainer {
+	container := new(fakes.FakeContainer)
+	container.HandleReturns(handle)
+
+	h.containers[handle] = container
+
+	return container
+}
+
+func (h *Harness) create(spec api.ContainerSpec) (api.Container, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	handle := spec.Handle
+	if handle == "" {
+		h.lastHandle++
+		handle = fmt.Sprintf("container-%d", h.lastHandle)
+	}
+
+	if _, found := h.containers[handle]; found {
+		return nil, fmt.Errorf("container already exists: %s", handle)
+	}
+
+	container := h.newContainer(handle)
+	container.PropertiesReturns(spec.Properties, nil)
+
+	return container, nil
+}
+
+func (h *Harness) destroy(handle string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, found := h.containers[handle]; !found {
+		return api.ErrContainerNotFound
+	}
+
+	delete(h.containers, handle)
+
+	return nil
+}
+
+func (h *Harness) lookup(handle string) (api.Container, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	container, found := h.containers[handle]
+	if !found {
+		return nil, api.ErrContainerNotFound
+	}
+
+	return container, nil
+}
+
+// list returns the containers whose properties match, by handle.
+func (h *Harness) list(filter api.Properties) ([]api.Container, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	handles := []string{}
+	for handle := range h.containers {
+		handles = append(handles, handle)
+	}
+
+	sort.Strings(handles)
+
+	containers := []api.Container{}
+
+	for _, handle := range handles {
+		container := h.containers[handle]
+
+		properties, err := container.Properties()
+		if err != nil {
+			return nil, err
+		}
+
+		if matches(properties, filter) {
+			containers = append(containers, container)
+		}
+	}
+
+	return containers, nil
+}
+
+func matches(properties api.Properties, filter api.Properties) bool {
+	for name, value := range filter {
+		if properties[name] != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,105 @@
+package gardentest_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/gardentest"
+	"github.com/cloudfoundry-incubator/garden/server"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Harness", func() {
+	var config gardentest.Config
+	var harness *gardentest.Harness
+
+	BeforeEach(func() {
+		config = gardentest.Config{}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		harness, err = gardentest.Start(config)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		harness.Stop()
+	})
+
+	It("serves the fake backend to its client", func() {
+		Ω(harness.Client.Ping()).Should(Succeed())
+
+		harness.Backend.PingReturns(errors.New("oh no"))
+
+		Ω(harness.Client.Ping()).ShouldNot(Succeed())
+	})
+
+	It("serves the containers it is given", func() {
+		fakeContainer := harness.NewContainer("some-handle")
+		fakeContainer.InfoReturns(api.ContainerInfo{State: "active"}, nil)
+
+		container, err := harness.Client.Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		info, err := container.Info()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(info.State).Should(Equal("active"))
+
+		_, err = harness.Client.Lookup("bogus-handle")
+		Ω(err).Should(Equal(api.ErrContainerNotFound))
+	})
+
+	It("keeps the containers created and destroyed through the server", func() {
+		_, err := harness.Client.Create(api.ContainerSpec{
+			Handle:     "some-handle",
+			Properties: api.Properties{"app": "x"},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = harness.Client.Create(api.ContainerSpec{})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(harness.Container("some-handle")).ShouldNot(BeNil())
+
+		containers, err := harness.Client.Containers(api.Properties{"app": "x"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(containers).Should(HaveLen(1))
+		Ω(containers[0].Handle()).Should(Equal("some-handle"))
+
+		err = harness.Client.Destroy("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(harness.Container("some-handle")).Should(BeNil())
+	})
+
+	It("removes its socket once stopped", func() {
+		harness.Stop()
+
+		_, err := os.Stat(harness.SocketPath)
+		Ω(os.IsNotExist(err)).Should(BeTrue())
+	})
+
+	Context("with a setup", func() {
+		BeforeEach(func() {
+			config.Setup = func(apiServer *server.GardenServer) {
+				apiServer.SetCapacityCacheTTL(time.Minute)
+			}
+		})
+
+		It("configures the server before starting it", func() {
+			_, err := harness.Client.Capacity()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = harness.Client.Capacity()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(harness.Backend.CapacityCallCount()).Should(Equal(1))
+		})
+	})
+})
@@ -1,39 +0,0 @@
-// Code generated by protoc-gen-gogo.
-// source: destroy.proto
-// DO NOT EDIT!
-
-package garden
-
-import proto "github.com/gogo/protobuf/proto"
-import math "math"
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = math.Inf
-
-type DestroyRequest struct {
-	Handle           *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
-}
-
-func (m *DestroyRequest) Reset()         { *m = DestroyRequest{} }
-func (m *DestroyRequest) String() string { return proto.CompactTextString(m) }
-func (*DestroyRequest) ProtoMessage()    {}
-
-func (m *DestroyRequest) GetHandle() string {
-	if m != nil && m.Handle != nil {
-		return *m.Handle
-	}
-	return ""
-}
-
-type DestroyResponse struct {
-	XXX_unrecognized []byte `json:"-"`
-}
-
-func (m *DestroyResponse) Reset()         { *m = DestroyResponse{} }
-func (m *DestroyResponse) String() string { return proto.CompactTextString(m) }
-func (*DestroyResponse) ProtoMessage()    {}
-
-func init() {
-}
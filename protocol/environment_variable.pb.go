@@ -1,39 +0,0 @@
-// Code generated by protoc-gen-gogo.
-// source: environment_variable.proto
-// DO NOT EDIT!
-
-package garden
-
-import proto "github.com/gogo/protobuf/proto"
-import math "math"
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = math.Inf
-
-type EnvironmentVariable struct {
-	Key              *string `protobuf:"bytes,1,req" json:"Key,omitempty"`
-	Value            *string `protobuf:"bytes,2,req" json:"Value,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
-}
-
-func (m *EnvironmentVariable) Reset()         { *m = EnvironmentVariable{} }
-func (m *EnvironmentVariable) String() string { return proto.CompactTextString(m) }
-func (*EnvironmentVariable) ProtoMessage()    {}
-
-func (m *EnvironmentVariable) GetKey() string {
-	if m != nil && m.Key != nil {
-		return *m.Key
-	}
-	return ""
-}
-
-func (m *EnvironmentVariable) GetValue() string {
-	if m != nil && m.Value != nil {
-		return *m.Value
-	}
-	return ""
-}
-
-func init() {
-}
@@ -1,49 +0,0 @@
-// Code generated by protoc-gen-gogo.
-// source: stop.proto
-// DO NOT EDIT!
-
-package garden
-
-import proto "github.com/gogo/protobuf/proto"
-import math "math"
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = math.Inf
-
-type StopRequest struct {
-	Handle           *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
-	Kill             *bool   `protobuf:"varint,20,opt,name=kill,def=0" json:"kill,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
-}
-
-func (m *StopRequest) Reset()         { *m = StopRequest{} }
-func (m *StopRequest) String() string { return proto.CompactTextString(m) }
-func (*StopRequest) ProtoMessage()    {}
-
-const Default_StopRequest_Kill bool = false
-
-func (m *StopRequest) GetHandle() string {
-	if m != nil && m.Handle != nil {
-		return *m.Handle
-	}
-	return ""
-}
-
-func (m *StopRequest) GetKill() bool {
-	if m != nil && m.Kill != nil {
-		return *m.Kill
-	}
-	return Default_StopRequest_Kill
-}
-
-type StopResponse struct {
-	XXX_unrecognized []byte `json:"-"`
-}
-
-func (m *StopResponse) Reset()         { *m = StopResponse{} }
-func (m *StopResponse) String() string { return proto.CompactTextString(m) }
-func (*StopResponse) ProtoMessage()    {}
-
-func init() {
-}
@@ -5,16 +5,46 @@ import "github.com/tedsuo/rata"
 const (
 	Ping     = "Ping"
 	Capacity = "Capacity"
+	Version  = "Version"
 
-	List    = "List"
-	Create  = "Create"
-	Info    = "Info"
-	Destroy = "Destroy"
+	HealthCheck = "HealthCheck"
 
-	Stop = "Stop"
+	GetDefaults    = "GetDefaults"
+	SetDefaults    = "SetDefaults"
+	GetDiagnostics = "GetDiagnostics"
 
-	StreamIn  = "StreamIn"
-	StreamOut = "StreamOut"
+	Multiplex = "Multiplex"
+
+	List     = "List"
+	LookupBy = "LookupBy"
+	Create   = "Create"
+	Info     = "Info"
+	Env      = "Env"
+	Destroy  = "Destroy"
+	Rename   = "Rename"
+
+	DestroyOperation = "DestroyOperation"
+
+	Stop      = "Stop"
+	SignalAll = "SignalAll"
+
+	Pause  = "Pause"
+	Resume = "Resume"
+
+	ScheduleDestroy        = "ScheduleDestroy"
+	CancelScheduledDestroy = "CancelScheduledDestroy"
+
+	SetHold = "SetHold"
+
+	SetGraceTime = "SetGraceTime"
+
+	Snapshot = "Snapshot"
+	Restore  = "Restore"
+
+	StreamIn           = "StreamIn"
+	StreamInAtomically = "StreamInAtomically"
+	StreamOut          = "StreamOut"
+	VerifyStreamIn     = "VerifyStreamIn"
 
 	LimitBandwidth         = "LimitBandwidth"
 	CurrentBandwidthLimits = "CurrentBandwidthLimits"
@@ -24,35 +54,75 @@ const (
 
 	LimitDisk         = "LimitDisk"
 	CurrentDiskLimits = "CurrentDiskLimits"
+	DiskUsage         = "DiskUsage"
 
 	LimitMemory         = "LimitMemory"
 	CurrentMemoryLimits = "CurrentMemoryLimits"
 
-	NetIn  = "NetIn"
-	NetOut = "NetOut"
-
-	Run    = "Run"
-	Attach = "Attach"
-
-	GetProperty    = "GetProperty"
-	SetProperty    = "SetProperty"
-	RemoveProperty = "RemoveProperty"
+	NetIn       = "NetIn"
+	MappedPorts = "MappedPorts"
+	NetOut      = "NetOut"
+	NetOutRule  = "NetOutRule"
+
+	Run       = "Run"
+	Attach    = "Attach"
+	AttachAll = "AttachAll"
+
+	ProcessInfo    = "ProcessInfo"
+	FindProcesses  = "FindProcesses"
+	WaitForProcess = "WaitForProcess"
+	Logs           = "Logs"
+
+	GetProperty            = "GetProperty"
+	SetProperty            = "SetProperty"
+	SetProperties          = "SetProperties"
+	CompareAndSwapProperty = "CompareAndSwapProperty"
+	RemoveProperty         = "RemoveProperty"
+	BulkProperties         = "BulkProperties"
 )
 
 var Routes = rata.Routes{
 	{Path: "/ping", Method: "GET", Name: Ping},
 	{Path: "/capacity", Method: "GET", Name: Capacity},
+	{Path: "/version", Method: "GET", Name: Version},
+	{Path: "/healthcheck", Method: "GET", Name: HealthCheck},
+
+	{Path: "/admin/defaults", Method: "GET", Name: GetDefaults},
+	{Path: "/admin/defaults", Method: "PUT", Name: SetDefaults},
+	{Path: "/admin/diagnostics", Method: "GET", Name: GetDiagnostics},
+
+	{Path: "/multiplex", Method: "POST", Name: Multiplex},
 
 	{Path: "/containers", Method: "GET", Name: List},
+	{Path: "/containers/lookup", Method: "GET", Name: LookupBy},
 	{Path: "/containers", Method: "POST", Name: Create},
 
 	{Path: "/containers/:handle/info", Method: "GET", Name: Info},
+	{Path: "/containers/:handle/env", Method: "GET", Name: Env},
 
 	{Path: "/containers/:handle", Method: "DELETE", Name: Destroy},
+	{Path: "/containers/:handle/rename", Method: "POST", Name: Rename},
+	{Path: "/destroys/:id", Method: "GET", Name: DestroyOperation},
 	{Path: "/containers/:handle/stop", Method: "PUT", Name: Stop},
+	{Path: "/containers/:handle/signal", Method: "PUT", Name: SignalAll},
+
+	{Path: "/containers/:handle/pause", Method: "PUT", Name: Pause},
+	{Path: "/containers/:handle/resume", Method: "PUT", Name: Resume},
+
+	{Path: "/containers/:handle/scheduled-destroy", Method: "PUT", Name: ScheduleDestroy},
+	{Path: "/containers/:handle/scheduled-destroy", Method: "DELETE", Name: CancelScheduledDestroy},
+
+	{Path: "/containers/:handle/hold", Method: "PUT", Name: SetHold},
+
+	{Path: "/containers/:handle/grace-time", Method: "PUT", Name: SetGraceTime},
+
+	{Path: "/containers/:handle/snapshot", Method: "GET", Name: Snapshot},
+	{Path: "/containers/restore", Method: "POST", Name: Restore},
 
 	{Path: "/containers/:handle/files", Method: "PUT", Name: StreamIn},
+	{Path: "/containers/:handle/files/atomic", Method: "PUT", Name: StreamInAtomically},
 	{Path: "/containers/:handle/files", Method: "GET", Name: StreamOut},
+	{Path: "/containers/:handle/files/verification", Method: "PUT", Name: VerifyStreamIn},
 
 	{Path: "/containers/:handle/limits/bandwidth", Method: "PUT", Name: LimitBandwidth},
 	{Path: "/containers/:handle/limits/bandwidth", Method: "GET", Name: CurrentBandwidthLimits},
@@ -62,17 +132,28 @@ var Routes = rata.Routes{
 
 	{Path: "/containers/:handle/limits/disk", Method: "PUT", Name: LimitDisk},
 	{Path: "/containers/:handle/limits/disk", Method: "GET", Name: CurrentDiskLimits},
+	{Path: "/containers/:handle/disk-usage", Method: "GET", Name: DiskUsage},
 
 	{Path: "/containers/:handle/limits/memory", Method: "PUT", Name: LimitMemory},
 	{Path: "/containers/:handle/limits/memory", Method: "GET", Name: CurrentMemoryLimits},
 
 	{Path: "/containers/:handle/net/in", Method: "POST", Name: NetIn},
+	{Path: "/containers/:handle/net/in", Method: "GET", Name: MappedPorts},
 	{Path: "/containers/:handle/net/out", Method: "POST", Name: NetOut},
+	{Path: "/containers/:handle/net/out/rules", Method: "POST", Name: NetOutRule},
 
 	{Path: "/containers/:handle/processes", Method: "POST", Name: Run},
 	{Path: "/containers/:handle/processes/:pid", Method: "GET", Name: Attach},
+	{Path: "/containers/:handle/processes/:pid/info", Method: "GET", Name: ProcessInfo},
+	{Path: "/containers/:handle/processes", Method: "GET", Name: AttachAll},
+	{Path: "/containers/:handle/process-ids", Method: "GET", Name: FindProcesses},
+	{Path: "/containers/:handle/process-ids/wait", Method: "GET", Name: WaitForProcess},
+	{Path: "/containers/:handle/logs", Method: "GET", Name: Logs},
 
 	{Path: "/containers/:handle/properties/:key", Method: "GET", Name: GetProperty},
 	{Path: "/containers/:handle/properties/:key", Method: "PUT", Name: SetProperty},
+	{Path: "/containers/:handle/properties", Method: "PUT", Name: SetProperties},
+	{Path: "/containers/:handle/properties/:key/compare-and-swap", Method: "POST", Name: CompareAndSwapProperty},
 	{Path: "/containers/:handle/properties/:key", Method: "DELETE", Name: RemoveProperty},
+	{Path: "/containers/properties", Method: "POST", Name: BulkProperties},
 }
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"io"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+type attachedStream struct {
+	stdout chan []byte
+	stderr chan []byte
+	stdinW *io.PipeWriter
+}
+
+type attachedProcess struct {
+	process api.Process
+	stdin   *stdinMeter
+
+	*attachedStream
+}
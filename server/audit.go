@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/routes"
+	"github.com/cloudfoundry-incubator/garden/transport"
+	"github.com/pivotal-golang/lager"
+)
+
+// An AuditEvent records a request which changed, or tried to change, the
+// server's containers, for deployments which must account for who did what.
+type AuditEvent struct {
+	// Time is when the request was received.
+	Time time.Time `json:"time"`
+
+	// Route names the request, e.g. routes.Create.
+	Route string `json:"route"`
+
+	// Requester identifies the client which made the request, as the
+	// server's requester id hook did; empty if it has none.
+	Requester  string `json:"requester,omitempty"`
+	RemoteAddr string `json:"remote_addr"`
+
+	// Trace is the ID of the trace the request was part of, if it gave one.
+	Trace string `json:"trace,omitempty"`
+
+	// Handle is the container's, for requests made of an existing container.
+	Handle string `json:"handle,omitempty"`
+
+	// Arguments are the request's JSON body, e.g. a container spec, with
+	// secrets such as passwords redacted, and Query its query parameters.
+	// Bodies which are not JSON, such as streamed files, are left out.
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Query     url.Values      `json:"query,omitempty"`
+
+	// Status is the HTTP status the server responded with, e.g. 201 for a
+	// process which was started.
+	Status int `json:"status"`
+}
+
+// An AuditSink records audit events, e.g. to a log or a file. Sinks are
+// called from the goroutines handling requests, so must be safe for
+// concurrent use and should not block.
+type AuditSink interface {
+	Record(AuditEvent)
+}
+
+type AuditSinkFunc func(AuditEvent)
+
+func (f AuditSinkFunc) Record(event AuditEvent) {
+	f(event)
+}
+
+// NewLagerAuditSink returns a sink logging each event as an "audit" message.
+func NewLagerAuditSink(logger lager.Logger) AuditSink {
+	return AuditSinkFunc(func(event AuditEvent) {
+		logger.Info("audit", lager.Data{"event": event})
+	})
+}
+
+// NewWriterAuditSink returns a sink writing each event as a line of JSON,
+// e.g. to an append-only file.
+func NewWriterAuditSink(w io.Writer) AuditSink {
+	return &writerAuditSink{encoder: json.NewEncoder(w)}
+}
+
+type writerAuditSink struct {
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+func (s *writerAuditSink) Record(event AuditEvent) {
+	s.mu.Lock()
+	s.encoder.Encode(event)
+	s.mu.Unlock()
+}
+
+// auditedRoutes are those which change containers
+var auditedRoutes = map[string]bool{
+	routes.Create:                 true,
+	routes.Destroy:                true,
+	routes.Rename:                 true,
+	routes.Stop:                   true,
+	routes.SignalAll:              true,
+	routes.Pause:                  true,
+	routes.Resume:                 true,
+	routes.ScheduleDestroy:        true,
+	routes.CancelScheduledDestroy: true,
+	routes.SetHold:                true,
+	routes.SetGraceTime:           true,
+	routes.Restore:                true,
+	routes.StreamIn:               true,
+	routes.StreamInAtomically:     true,
+	routes.LimitBandwidth:         true,
+	routes.LimitCPU:               true,
+	routes.LimitDisk:              true,
+	routes.LimitMemory:            true,
+	routes.NetIn:                  true,
+	routes.NetOut:                 true,
+	routes.NetOutRule:             true,
+	routes.Run:                    true,
+	routes.SetProperty:            true,
+	routes.SetProperties:          true,
+	routes.CompareAndSwapProperty: true,
+	routes.RemoveProperty:         true,
+}
+
+func (s *GardenServer) audited(route string, handler http.Handler) http.Handler {
+	if !auditedRoutes[route] {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sink := s.auditSink
+		if sink == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		event := AuditEvent{
+			Time:       time.Now(),
+			Route:      route,
+			RemoteAddr: r.RemoteAddr,
+			Handle:     r.FormValue(":handle"),
+			Query:      auditQuery(r.URL.Query()),
+			Trace:      traceID(r),
+		}
+
+		if s.auditRequesterID != nil {
+			event.Requester = s.auditRequesterID(r)
+		}
+
+		if isJSON(r) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				s.writeError(w, err, s.logger)
+				return
+			}
+
+			event.Arguments = redactArguments(body)
+
+			// the handler decodes the body itself
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		recorder := &auditRecorder{ResponseWriter: w, sink: sink, event: event}
+		handler.ServeHTTP(recorder, r)
+
+		// e.g. a handler which hijacked without responding
+		recorder.record(http.StatusOK)
+	})
+}
+
+// auditQuery is the request's query without the route's parameters, which
+// rata passes to handlers in it
+func auditQuery(query url.Values) url.Values {
+	for name := range query {
+		if strings.HasPrefix(name, ":") {
+			query.Del(name)
+		}
+	}
+
+	if len(query) == 0 {
+		return nil
+	}
+
+	return query
+}
+
+func isJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == transport.ContentTypeJSON
+}
+
+// auditRecorder records its request's event once the response's status is
+// known, rather than once the request is handled, so that e.g. processes are
+// audited as they start rather than as they exit.
+type auditRecorder struct {
+	http.ResponseWriter
+
+	sink     AuditSink
+	event    AuditEvent
+	recorded bool
+}
+
+func (r *auditRecorder) record(status int) {
+	if r.recorded {
+		return
+	}
+
+	r.recorded = true
+
+	r.event.Status = status
+	r.sink.Record(r.event)
+}
+
+func (r *auditRecorder) WriteHeader(status int) {
+	r.record(status)
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *auditRecorder) Write(data []byte) (int, error) {
+	r.record(http.StatusOK)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *auditRecorder) Flush() {
+	r.record(http.StatusOK)
+
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *auditRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (r *auditRecorder) CloseNotify() <-chan bool {
+	return closeNotify(r.ResponseWriter)
+}
@@ -1,6 +1,9 @@
 package bomberman
 
 import (
+	"sync"
+	"time"
+
 	"github.com/cloudfoundry-incubator/garden/api"
 	"github.com/cloudfoundry-incubator/garden/server/timebomb"
 )
@@ -11,10 +14,73 @@ type Bomberman struct {
 	detonate func(api.Container)
 
 	strap   chan api.Container
+	regrace chan graceTimeChange
+	rename  chan renaming
 	pause   chan string
 	unpause chan string
 	defuse  chan string
 	cleanup chan string
+
+	schedule   chan scheduledDestroy
+	unschedule chan string
+
+	hold    chan string
+	release chan string
+	held    chan heldQuery
+	status  chan statusQuery
+
+	snapshot chan chan Snapshot
+}
+
+// A Snapshot is the holds and scheduled destroys of containers, which unlike
+// their grace times are not kept by the backend.
+type Snapshot struct {
+	Held      []string
+	Scheduled map[string]time.Time
+}
+
+type heldQuery struct {
+	handle string
+	held   chan bool
+}
+
+type statusQuery struct {
+	handle string
+	status chan timebomb.Status
+}
+
+type graceTimeChange struct {
+	handle    string
+	graceTime time.Duration
+}
+
+type renaming struct {
+	oldHandle string
+	container api.Container
+}
+
+// a target is the container that a container's bombs detonate, which is
+// replaced when the container is renamed
+type target struct {
+	container api.Container
+	lock      sync.Mutex
+}
+
+func (t *target) get() api.Container {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.container
+}
+
+func (t *target) set(container api.Container) {
+	t.lock.Lock()
+	t.container = container
+	t.lock.Unlock()
+}
+
+type scheduledDestroy struct {
+	container api.Container
+	at        time.Time
 }
 
 func New(backend api.Backend, detonate func(api.Container)) *Bomberman {
@@ -23,10 +89,22 @@ func New(backend api.Backend, detonate func(api.Container)) *Bomberman {
 		detonate: detonate,
 
 		strap:   make(chan api.Container),
+		regrace: make(chan graceTimeChange),
+		rename:  make(chan renaming),
 		pause:   make(chan string),
 		unpause: make(chan string),
 		defuse:  make(chan string),
 		cleanup: make(chan string),
+
+		schedule:   make(chan scheduledDestroy),
+		unschedule: make(chan string),
+
+		hold:    make(chan string),
+		release: make(chan string),
+		held:    make(chan heldQuery),
+		status:  make(chan statusQuery),
+
+		snapshot: make(chan chan Snapshot),
 	}
 
 	go b.manageBombs()
@@ -38,6 +116,20 @@ func (b *Bomberman) Strap(container api.Container) {
 	b.strap <- container
 }
 
+// SetGraceTime replaces the countdown of the container's timebomb, restarting
+// it unless the container is in use or held. A grace time of zero means the
+// container is never destroyed for being idle.
+func (b *Bomberman) SetGraceTime(name string, graceTime time.Duration) {
+	b.regrace <- graceTimeChange{name, graceTime}
+}
+
+// Rename moves the bombs of the container formerly named oldHandle, along with
+// their countdowns, schedules and holds, to the renamed container. Pauses
+// made before the rename are still undone by unpausing oldHandle.
+func (b *Bomberman) Rename(oldHandle string, container api.Container) {
+	b.rename <- renaming{oldHandle, container}
+}
+
 func (b *Bomberman) Pause(name string) {
 	b.pause <- name
 }
@@ -50,56 +142,285 @@ func (b *Bomberman) Defuse(name string) {
 	b.defuse <- name
 }
 
+// Schedule detonates the container at the given time, regardless of its
+// grace time or whether it is in use. Scheduling again replaces the previous
+// schedule.
+func (b *Bomberman) Schedule(container api.Container, at time.Time) {
+	b.schedule <- scheduledDestroy{container, at}
+}
+
+func (b *Bomberman) Unschedule(name string) {
+	b.unschedule <- name
+}
+
+// Hold prevents the container from being detonated, whether by its grace
+// time or by a schedule, until it is released.
+func (b *Bomberman) Hold(name string) {
+	b.hold <- name
+}
+
+// Release undoes Hold. The grace time starts counting down again, and a
+// scheduled destroy whose time has passed detonates immediately.
+func (b *Bomberman) Release(name string) {
+	b.release <- name
+}
+
+func (b *Bomberman) IsHeld(name string) bool {
+	query := heldQuery{name, make(chan bool, 1)}
+	b.held <- query
+	return <-query.held
+}
+
+// Status returns how far the countdown of the container's grace time has
+// got. Its Remaining is zero while the container is in use or held, or if it
+// has no grace time.
+func (b *Bomberman) Status(name string) timebomb.Status {
+	query := statusQuery{name, make(chan timebomb.Status, 1)}
+	b.status <- query
+	return <-query.status
+}
+
+// Snapshot returns the containers which are held and those scheduled to be
+// destroyed, with when.
+func (b *Bomberman) Snapshot() Snapshot {
+	snapshot := make(chan Snapshot, 1)
+	b.snapshot <- snapshot
+	return <-snapshot
+}
+
 func (b *Bomberman) manageBombs() {
 	timeBombs := map[string]*timebomb.TimeBomb{}
+	scheduledBombs := map[string]*timebomb.TimeBomb{}
+
+	schedules := map[string]scheduledDestroy{}
+	held := map[string]bool{}
+	targets := map[string]*target{}
+
+	// pauses are counted by the handle they were made under, so that they
+	// are undone on the right bomb even if the container has since been
+	// renamed or destroyed and recreated
+	pauses := map[string]map[*timebomb.TimeBomb]int{}
+
+	targetFor := func(container api.Container) *target {
+		t, found := targets[container.Handle()]
+		if !found {
+			t = &target{container: container}
+			targets[container.Handle()] = t
+		}
+
+		return t
+	}
+
+	detonator := func(t *target) func() {
+		return func() {
+			container := t.get()
+			b.detonate(container)
+			b.cleanup <- container.Handle()
+		}
+	}
+
+	strapScheduled := func(scheduled scheduledDestroy) {
+		handle := scheduled.container.Handle()
+
+		bomb := timebomb.New(
+			scheduled.at.Sub(time.Now()),
+			detonator(targetFor(scheduled.container)),
+		)
+
+		scheduledBombs[handle] = bomb
+
+		bomb.Strap()
+	}
 
 	for {
 		select {
 		case container := <-b.strap:
-			if b.backend.GraceTime(container) == 0 {
-				continue
-			}
-
+			// bombs are strapped even with no grace time, so that it can be
+			// set later without losing track of pauses
 			bomb := timebomb.New(
 				b.backend.GraceTime(container),
-				func() {
-					b.detonate(container)
-					b.cleanup <- container.Handle()
-				},
+				detonator(targetFor(container)),
 			)
 
 			timeBombs[container.Handle()] = bomb
 
 			bomb.Strap()
 
+		case change := <-b.regrace:
+			bomb, found := timeBombs[change.handle]
+			if !found {
+				continue
+			}
+
+			bomb.SetCountdown(change.graceTime)
+
+		case renamed := <-b.rename:
+			oldHandle := renamed.oldHandle
+			newHandle := renamed.container.Handle()
+
+			if t, found := targets[oldHandle]; found {
+				t.set(renamed.container)
+				targets[newHandle] = t
+				delete(targets, oldHandle)
+			}
+
+			if bomb, found := timeBombs[oldHandle]; found {
+				timeBombs[newHandle] = bomb
+				delete(timeBombs, oldHandle)
+			}
+
+			if bomb, found := scheduledBombs[oldHandle]; found {
+				scheduledBombs[newHandle] = bomb
+				delete(scheduledBombs, oldHandle)
+			}
+
+			if scheduled, found := schedules[oldHandle]; found {
+				scheduled.container = renamed.container
+				schedules[newHandle] = scheduled
+				delete(schedules, oldHandle)
+			}
+
+			if held[oldHandle] {
+				held[newHandle] = true
+				delete(held, oldHandle)
+			}
+
 		case handle := <-b.pause:
 			bomb, found := timeBombs[handle]
 			if !found {
 				continue
 			}
 
+			if pauses[handle] == nil {
+				pauses[handle] = map[*timebomb.TimeBomb]int{}
+			}
+
+			pauses[handle][bomb]++
+
 			bomb.Pause()
 
 		case handle := <-b.unpause:
+			paused := pauses[handle]
+
 			bomb, found := timeBombs[handle]
+			if !found || paused[bomb] == 0 {
+				// paused under a handle the container no longer has
+				found = false
+
+				for pausedBomb := range paused {
+					bomb, found = pausedBomb, true
+					break
+				}
+			}
+
 			if !found {
 				continue
 			}
 
+			paused[bomb]--
+			if paused[bomb] == 0 {
+				delete(paused, bomb)
+			}
+
+			if len(paused) == 0 {
+				delete(pauses, handle)
+			}
+
 			bomb.Unpause()
 
 		case handle := <-b.defuse:
-			bomb, found := timeBombs[handle]
-			if !found {
+			defuse(timeBombs, handle)
+			defuse(scheduledBombs, handle)
+			delete(schedules, handle)
+			delete(held, handle)
+			delete(targets, handle)
+
+		case scheduled := <-b.schedule:
+			handle := scheduled.container.Handle()
+
+			defuse(scheduledBombs, handle)
+
+			schedules[handle] = scheduled
+
+			if held[handle] {
 				continue
 			}
 
-			bomb.Defuse()
+			strapScheduled(scheduled)
 
-			delete(timeBombs, handle)
+		case handle := <-b.unschedule:
+			defuse(scheduledBombs, handle)
+			delete(schedules, handle)
+
+		case handle := <-b.hold:
+			if held[handle] {
+				continue
+			}
+
+			held[handle] = true
+
+			if bomb, found := timeBombs[handle]; found {
+				bomb.Pause()
+			}
+
+			defuse(scheduledBombs, handle)
+
+		case handle := <-b.release:
+			if !held[handle] {
+				continue
+			}
+
+			delete(held, handle)
+
+			if bomb, found := timeBombs[handle]; found {
+				bomb.Unpause()
+			}
+
+			if scheduled, found := schedules[handle]; found {
+				strapScheduled(scheduled)
+			}
+
+		case query := <-b.held:
+			query.held <- held[query.handle]
+
+		case query := <-b.status:
+			var status timebomb.Status
+			if bomb, found := timeBombs[query.handle]; found {
+				status = bomb.Status()
+			}
+
+			query.status <- status
+
+		case snapshot := <-b.snapshot:
+			taken := Snapshot{Scheduled: map[string]time.Time{}}
+
+			for handle := range held {
+				taken.Held = append(taken.Held, handle)
+			}
+
+			for handle, scheduled := range schedules {
+				taken.Scheduled[handle] = scheduled.at
+			}
+
+			snapshot <- taken
 
 		case handle := <-b.cleanup:
-			delete(timeBombs, handle)
+			defuse(timeBombs, handle)
+			defuse(scheduledBombs, handle)
+			delete(schedules, handle)
+			delete(held, handle)
+			delete(targets, handle)
 		}
 	}
 }
+
+func defuse(bombs map[string]*timebomb.TimeBomb, handle string) {
+	bomb, found := bombs[handle]
+	if !found {
+		return
+	}
+
+	bomb.Defuse()
+
+	delete(bombs, handle)
+}
@@ -58,6 +58,267 @@ var _ = Describe("Bomberman", func() {
 		})
 	})
 
+	Describe("setting a container's grace time", func() {
+		It("detonates after the new grace time", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			bomberman.Strap(container)
+			bomberman.SetGraceTime("doomed", 200*time.Millisecond)
+
+			select {
+			case <-detonated:
+				Fail("detonated!")
+			case <-time.After(150 * time.Millisecond):
+			}
+
+			select {
+			case <-detonated:
+			case <-time.After(100 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		Context("when the container had a grace time of 0", func() {
+			It("starts counting down", func() {
+				detonated := make(chan api.Container)
+
+				backend := new(fakes.FakeBackend)
+				backend.GraceTimeReturns(0)
+
+				bomberman := bomberman.New(backend, func(container api.Container) {
+					detonated <- container
+				})
+
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("doomed")
+
+				bomberman.Strap(container)
+				bomberman.SetGraceTime("doomed", 100*time.Millisecond)
+
+				select {
+				case <-detonated:
+				case <-time.After(150 * time.Millisecond):
+					Fail("did not detonate!")
+				}
+			})
+		})
+
+		Context("to 0", func() {
+			It("never detonates", func() {
+				detonated := make(chan api.Container)
+
+				backend := new(fakes.FakeBackend)
+				backend.GraceTimeReturns(100 * time.Millisecond)
+
+				bomberman := bomberman.New(backend, func(container api.Container) {
+					detonated <- container
+				})
+
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("doomed")
+
+				bomberman.Strap(container)
+				bomberman.SetGraceTime("doomed", 0)
+
+				select {
+				case <-detonated:
+					Fail("detonated!")
+				case <-time.After(150 * time.Millisecond):
+				}
+			})
+		})
+
+		Context("when the handle is invalid", func() {
+			It("doesn't launch any missiles or anything like that", func() {
+				bomberman := bomberman.New(new(fakes.FakeBackend), func(container api.Container) {
+					panic("dont call me")
+				})
+
+				bomberman.SetGraceTime("BOOM?!", time.Millisecond)
+			})
+		})
+	})
+
+	Describe("renaming a container", func() {
+		It("detonates the renamed container", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			renamed := new(fakes.FakeContainer)
+			renamed.HandleReturns("still-doomed")
+
+			bomberman.Strap(container)
+			bomberman.Rename("doomed", renamed)
+
+			select {
+			case c := <-detonated:
+				Expect(c).To(Equal(renamed))
+			case <-time.After(150 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		It("keeps pauses made before the rename, undone under the old handle", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			renamed := new(fakes.FakeContainer)
+			renamed.HandleReturns("still-doomed")
+
+			bomberman.Strap(container)
+			bomberman.Pause("doomed")
+			bomberman.Rename("doomed", renamed)
+
+			select {
+			case <-detonated:
+				Fail("detonated!")
+			case <-time.After(150 * time.Millisecond):
+			}
+
+			bomberman.Unpause("doomed")
+
+			select {
+			case c := <-detonated:
+				Expect(c).To(Equal(renamed))
+			case <-time.After(150 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		It("pauses and unpauses under the new handle independently of the old one", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			renamed := new(fakes.FakeContainer)
+			renamed.HandleReturns("still-doomed")
+
+			bomberman.Strap(container)
+			bomberman.Pause("doomed")
+			bomberman.Rename("doomed", renamed)
+			bomberman.Pause("still-doomed")
+			bomberman.Unpause("doomed")
+
+			select {
+			case <-detonated:
+				Fail("detonated!")
+			case <-time.After(150 * time.Millisecond):
+			}
+
+			bomberman.Unpause("still-doomed")
+
+			select {
+			case c := <-detonated:
+				Expect(c).To(Equal(renamed))
+			case <-time.After(150 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		It("can be defused under the new handle", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			renamed := new(fakes.FakeContainer)
+			renamed.HandleReturns("saved")
+
+			bomberman.Strap(container)
+			bomberman.Rename("doomed", renamed)
+			bomberman.Defuse("saved")
+
+			select {
+			case <-detonated:
+				Fail("detonated!")
+			case <-time.After(150 * time.Millisecond):
+			}
+		})
+
+		It("detonates a scheduled destroy on the renamed container", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			renamed := new(fakes.FakeContainer)
+			renamed.HandleReturns("still-doomed")
+
+			bomberman.Strap(container)
+			bomberman.Schedule(container, time.Now().Add(100*time.Millisecond))
+			bomberman.Rename("doomed", renamed)
+
+			select {
+			case c := <-detonated:
+				Expect(c).To(Equal(renamed))
+			case <-time.After(150 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		Context("when the handle is invalid", func() {
+			It("doesn't launch any missiles or anything like that", func() {
+				bomberman := bomberman.New(new(fakes.FakeBackend), func(container api.Container) {
+					panic("dont call me")
+				})
+
+				renamed := new(fakes.FakeContainer)
+				renamed.HandleReturns("BOOM?!")
+
+				bomberman.Rename("nope", renamed)
+			})
+		})
+	})
+
 	Describe("pausing a container's timebomb", func() {
 		It("prevents it from detonating", func() {
 			detonated := make(chan api.Container)
@@ -166,4 +427,350 @@ var _ = Describe("Bomberman", func() {
 			})
 		})
 	})
+
+	Describe("scheduling a container's destruction", func() {
+		It("detonates at the scheduled time", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			before := time.Now()
+			bomberman.Schedule(container, before.Add(100*time.Millisecond))
+
+			select {
+			case <-detonated:
+				Ω(time.Since(before)).Should(BeNumerically(">=", 100*time.Millisecond))
+			case <-time.After(150 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		It("detonates even if the container's timebomb is paused", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(time.Hour)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			bomberman.Strap(container)
+			bomberman.Pause("doomed")
+			bomberman.Schedule(container, time.Now().Add(100*time.Millisecond))
+
+			select {
+			case <-detonated:
+			case <-time.After(150 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		Context("when scheduled again", func() {
+			It("only detonates at the latest scheduled time", func() {
+				detonated := make(chan api.Container)
+
+				backend := new(fakes.FakeBackend)
+
+				bomberman := bomberman.New(backend, func(container api.Container) {
+					detonated <- container
+				})
+
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("doomed")
+
+				before := time.Now()
+				bomberman.Schedule(container, before.Add(50*time.Millisecond))
+				bomberman.Schedule(container, before.Add(150*time.Millisecond))
+
+				select {
+				case <-detonated:
+					Ω(time.Since(before)).Should(BeNumerically(">=", 150*time.Millisecond))
+				case <-time.After(200 * time.Millisecond):
+					Fail("did not detonate!")
+				}
+			})
+		})
+
+		Describe("and then unscheduling it", func() {
+			It("prevents it from detonating", func() {
+				detonated := make(chan api.Container)
+
+				backend := new(fakes.FakeBackend)
+
+				bomberman := bomberman.New(backend, func(container api.Container) {
+					detonated <- container
+				})
+
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("doomed")
+
+				bomberman.Schedule(container, time.Now().Add(100*time.Millisecond))
+				bomberman.Unschedule("doomed")
+
+				select {
+				case <-detonated:
+					Fail("detonated!")
+				case <-time.After(150 * time.Millisecond):
+				}
+			})
+
+			Context("when the handle is invalid", func() {
+				It("doesn't launch any missiles or anything like that", func() {
+					bomberman := bomberman.New(new(fakes.FakeBackend), func(container api.Container) {
+						panic("dont call me")
+					})
+
+					bomberman.Unschedule("BOOM?!")
+				})
+			})
+		})
+
+		Describe("and then defusing the container", func() {
+			It("prevents it from detonating", func() {
+				detonated := make(chan api.Container)
+
+				backend := new(fakes.FakeBackend)
+
+				bomberman := bomberman.New(backend, func(container api.Container) {
+					detonated <- container
+				})
+
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("doomed")
+
+				bomberman.Schedule(container, time.Now().Add(100*time.Millisecond))
+				bomberman.Defuse("doomed")
+
+				select {
+				case <-detonated:
+					Fail("detonated!")
+				case <-time.After(150 * time.Millisecond):
+				}
+			})
+		})
+	})
+
+	Describe("holding a container", func() {
+		It("prevents its timebomb from detonating", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			bomberman.Strap(container)
+			bomberman.Hold("doomed")
+
+			Ω(bomberman.IsHeld("doomed")).Should(BeTrue())
+
+			select {
+			case <-detonated:
+				Fail("detonated!")
+			case <-time.After(150 * time.Millisecond):
+			}
+		})
+
+		It("prevents a scheduled destroy from detonating", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			bomberman.Hold("doomed")
+			bomberman.Schedule(container, time.Now().Add(100*time.Millisecond))
+
+			select {
+			case <-detonated:
+				Fail("detonated!")
+			case <-time.After(150 * time.Millisecond):
+			}
+		})
+
+		It("is idempotent", func() {
+			detonated := make(chan api.Container)
+
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(100 * time.Millisecond)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {
+				detonated <- container
+			})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			bomberman.Strap(container)
+			bomberman.Hold("doomed")
+			bomberman.Hold("doomed")
+			bomberman.Release("doomed")
+
+			select {
+			case <-detonated:
+			case <-time.After(150 * time.Millisecond):
+				Fail("did not detonate!")
+			}
+		})
+
+		Describe("and then releasing it", func() {
+			It("causes its timebomb to detonate after the countdown", func() {
+				detonated := make(chan api.Container)
+
+				backend := new(fakes.FakeBackend)
+				backend.GraceTimeReturns(100 * time.Millisecond)
+
+				bomberman := bomberman.New(backend, func(container api.Container) {
+					detonated <- container
+				})
+
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("doomed")
+
+				bomberman.Strap(container)
+				bomberman.Hold("doomed")
+
+				time.Sleep(150 * time.Millisecond)
+
+				bomberman.Release("doomed")
+
+				Ω(bomberman.IsHeld("doomed")).Should(BeFalse())
+
+				select {
+				case <-detonated:
+				case <-time.After(150 * time.Millisecond):
+					Fail("did not detonate!")
+				}
+			})
+
+			It("detonates a scheduled destroy whose time has passed", func() {
+				detonated := make(chan api.Container)
+
+				backend := new(fakes.FakeBackend)
+
+				bomberman := bomberman.New(backend, func(container api.Container) {
+					detonated <- container
+				})
+
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("doomed")
+
+				bomberman.Schedule(container, time.Now().Add(100*time.Millisecond))
+				bomberman.Hold("doomed")
+
+				time.Sleep(150 * time.Millisecond)
+
+				bomberman.Release("doomed")
+
+				select {
+				case <-detonated:
+				case <-time.After(50 * time.Millisecond):
+					Fail("did not detonate!")
+				}
+			})
+
+			Context("when the handle is invalid", func() {
+				It("doesn't launch any missiles or anything like that", func() {
+					bomberman := bomberman.New(new(fakes.FakeBackend), func(container api.Container) {
+						panic("dont call me")
+					})
+
+					bomberman.Release("BOOM?!")
+				})
+			})
+		})
+	})
+
+	Describe("taking a snapshot", func() {
+		It("returns the held containers and scheduled destroys", func() {
+			bomberman := bomberman.New(new(fakes.FakeBackend), func(container api.Container) {})
+
+			held := new(fakes.FakeContainer)
+			held.HandleReturns("held")
+
+			scheduled := new(fakes.FakeContainer)
+			scheduled.HandleReturns("scheduled")
+
+			at := time.Now().Add(time.Hour)
+
+			bomberman.Strap(held)
+			bomberman.Hold("held")
+			bomberman.Schedule(scheduled, at)
+
+			snapshot := bomberman.Snapshot()
+			Ω(snapshot.Held).Should(ConsistOf("held"))
+			Ω(snapshot.Scheduled).Should(HaveLen(1))
+			Ω(snapshot.Scheduled["scheduled"]).Should(BeTemporally("==", at))
+		})
+
+		It("leaves out unscheduled destroys and released holds", func() {
+			bomberman := bomberman.New(new(fakes.FakeBackend), func(container api.Container) {})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("some-handle")
+
+			bomberman.Hold("some-handle")
+			bomberman.Schedule(container, time.Now().Add(time.Hour))
+			bomberman.Release("some-handle")
+			bomberman.Unschedule("some-handle")
+
+			snapshot := bomberman.Snapshot()
+			Ω(snapshot.Held).Should(BeEmpty())
+			Ω(snapshot.Scheduled).Should(BeEmpty())
+		})
+	})
+
+	Describe("getting the status of a container's grace time", func() {
+		It("returns how long is left of the countdown, and when the container was last used", func() {
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(time.Minute)
+
+			bomberman := bomberman.New(backend, func(container api.Container) {})
+
+			container := new(fakes.FakeContainer)
+			container.HandleReturns("doomed")
+
+			bomberman.Strap(container)
+			bomberman.Pause("doomed")
+
+			status := bomberman.Status("doomed")
+			Ω(status.Remaining).Should(BeZero())
+			Ω(status.LastActivity).Should(BeTemporally("~", time.Now(), time.Second))
+
+			bomberman.Unpause("doomed")
+
+			status = bomberman.Status("doomed")
+			Ω(status.Remaining).Should(BeNumerically("~", time.Minute, time.Second))
+		})
+
+		Context("when the handle is invalid", func() {
+			It("returns a zero status", func() {
+				bomberman := bomberman.New(new(fakes.FakeBackend), func(container api.Container) {})
+
+				Ω(bomberman.Status("BOOM?!").Remaining).Should(BeZero())
+				Ω(bomberman.Status("BOOM?!").LastActivity).Should(BeZero())
+			})
+		})
+	})
 })
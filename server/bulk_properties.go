@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/pivotal-golang/lager"
+)
+
+// bulkProperties returns the container's properties with the keys, or all of
+// them if no keys are given.
+func bulkProperties(container api.Container, keys []string) ([]*apitypes.Property, error) {
+	properties, err := container.Properties()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > 0 {
+		wanted := api.Properties{}
+
+		for _, key := range keys {
+			if value, found := properties[key]; found {
+				wanted[key] = value
+			}
+		}
+
+		properties = wanted
+	}
+
+	props := []*apitypes.Property{}
+	for key, value := range properties {
+		props = append(props, &apitypes.Property{
+			Key:   apitypes.String(key),
+			Value: apitypes.String(value),
+		})
+	}
+
+	return props, nil
+}
+
+func (s *GardenServer) handleBulkProperties(w http.ResponseWriter, r *http.Request) {
+	var request apitypes.BulkPropertiesRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	handles := request.GetHandles()
+	keys := request.GetKeys()
+
+	hLog := s.session(r, "bulk-properties", lager.Data{
+		"handles": len(handles),
+		"keys":    keys,
+	})
+
+	containers := []*apitypes.BulkPropertiesResponse_Container{}
+
+	for _, handle := range handles {
+		container, err := s.backend.Lookup(handle)
+		if err == api.ErrContainerNotFound {
+			continue
+		}
+
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+
+		s.bomberman.Pause(container.Handle())
+		props, err := bulkProperties(container, keys)
+		s.bomberman.Unpause(container.Handle())
+
+		// destroyed since it was looked up
+		if err == api.ErrContainerNotFound {
+			continue
+		}
+
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+
+		containers = append(containers, &apitypes.BulkPropertiesResponse_Container{
+			Handle:     apitypes.String(container.Handle()),
+			Properties: props,
+		})
+	}
+
+	hLog.Info("got-properties", lager.Data{
+		"containers": len(containers),
+	})
+
+	s.writeResponse(w, &apitypes.BulkPropertiesResponse{
+		Containers: containers,
+	})
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// capacityCache remembers the backend's capacity for a while, as computing it
+// may be expensive. A ttl of 0 disables caching.
+type capacityCache struct {
+	backend api.Backend
+	ttl     time.Duration
+
+	capacity  api.Capacity
+	fetchedAt time.Time
+	mu        sync.Mutex
+}
+
+func newCapacityCache(backend api.Backend) *capacityCache {
+	return &capacityCache{backend: backend}
+}
+
+func (c *capacityCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+	c.fetchedAt = time.Time{}
+}
+
+// get returns the cached capacity if it is fresh enough, and otherwise asks
+// the backend for it. fresh always asks the backend, refreshing the cache.
+func (c *capacityCache) get(fresh bool) (api.Capacity, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !fresh && c.ttl > 0 && !c.fetchedAt.IsZero() && time.Since(c.fetchedAt) < c.ttl {
+		return c.capacity, nil
+	}
+
+	capacity, err := c.backend.Capacity()
+	if err != nil {
+		return api.Capacity{}, err
+	}
+
+	if c.ttl > 0 {
+		c.capacity = capacity
+		c.fetchedAt = time.Now()
+	}
+
+	return capacity, nil
+}
@@ -1,5 +1,10 @@
 package server
 
+import (
+	"io"
+	"io/ioutil"
+)
+
 type chanWriter struct {
 	ch chan<- []byte
 }
@@ -23,3 +28,13 @@ func (w *chanWriter) Close() error {
 	close(w.ch)
 	return nil
 }
+
+// outputWriter returns the writer for a process's output stream, which is
+// sent to ch unless the client asked for it to be discarded.
+func outputWriter(ch chan<- []byte, discard bool) io.Writer {
+	if discard {
+		return ioutil.Discard
+	}
+
+	return &chanWriter{ch}
+}
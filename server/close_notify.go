@@ -0,0 +1,13 @@
+package server
+
+import "net/http"
+
+// closeNotify returns a channel receiving once the client has gone away, or
+// nil, which never receives, if the response cannot tell.
+func closeNotify(w http.ResponseWriter) <-chan bool {
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// negotiateCodec returns the codec to encode the response to the request
+// with: that of the first type the Accept header names which has a codec
+// registered, or else the one the request was encoded with, so that clients
+// are answered in the wire format they speak. It returns nil if neither has
+// a codec registered.
+func negotiateCodec(r *http.Request) transport.Codec {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		codec, err := transport.CodecFor(strings.TrimSpace(accepted))
+		if err == nil {
+			return codec
+		}
+	}
+
+	codec, err := transport.CodecFor(r.Header.Get("Content-Type"))
+	if err == nil {
+		return codec
+	}
+
+	return nil
+}
+
+// negotiated encodes the responses of the handler with the codec negotiated
+// for each request.
+func (s *GardenServer) negotiated(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		codec := negotiateCodec(r)
+		if codec == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		handler.ServeHTTP(&negotiatedWriter{
+			ResponseWriter: w,
+			codec:          codec,
+		}, r)
+	})
+}
+
+type negotiatedWriter struct {
+	http.ResponseWriter
+
+	codec transport.Codec
+}
+
+func (w *negotiatedWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *negotiatedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *negotiatedWriter) CloseNotify() <-chan bool {
+	return closeNotify(w.ResponseWriter)
+}
+
+// responseCodec returns the codec negotiated for the response, or the
+// server's own if none was.
+func (s *GardenServer) responseCodec(w http.ResponseWriter) transport.Codec {
+	for {
+		switch writer := w.(type) {
+		case *negotiatedWriter:
+			return writer.codec
+		case *serverTiming:
+			w = writer.ResponseWriter
+		default:
+			return s.codec
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// ContainerHooks are called as the server creates and destroys containers,
+// e.g. for the process embedding the server to keep its own index of them
+// without polling the backend. They are called synchronously, so should not
+// block for long.
+type ContainerHooks struct {
+	// OnContainerCreated is called with each container created through the
+	// server, and the spec it was created with, including any handle the
+	// server generated, before the client is told. Containers restored from
+	// snapshots are given a spec of the handle, grace time and properties
+	// their backend restored them with.
+	OnContainerCreated func(handle string, spec api.ContainerSpec)
+
+	// OnContainerDestroyed is called with each container the server destroys,
+	// whether a client asked it to or the container's grace time ran out,
+	// and the spec it was created with. Containers the server did not create,
+	// e.g. those created before it started, have a spec with only their
+	// handle.
+	OnContainerDestroyed func(handle string, spec api.ContainerSpec)
+}
+
+// SetContainerHooks registers hooks called as the server creates and
+// destroys containers. It must be called before Start.
+func (s *GardenServer) SetContainerHooks(hooks ContainerHooks) {
+	s.containerHooks = hooks
+}
+
+// containerSpecs remembers the spec of each container created through the
+// server, for its destroyed hook. rename expects the container state lock to
+// be held.
+type containerSpecs struct {
+	specs map[string]api.ContainerSpec
+	mu    *sync.Mutex
+}
+
+func newContainerSpecs(mu *sync.Mutex) *containerSpecs {
+	return &containerSpecs{
+		specs: map[string]api.ContainerSpec{},
+		mu:    mu,
+	}
+}
+
+func (c *containerSpecs) add(handle string, spec api.ContainerSpec) {
+	c.mu.Lock()
+	c.specs[handle] = spec
+	c.mu.Unlock()
+}
+
+func (c *containerSpecs) rename(handle, newHandle string) {
+	if spec, found := c.specs[handle]; found {
+		spec.Handle = newHandle
+		c.specs[newHandle] = spec
+		delete(c.specs, handle)
+	}
+}
+
+// remove forgets the container's spec, returning it, or a spec with only the
+// handle if it was not remembered.
+func (c *containerSpecs) remove(handle string) api.ContainerSpec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	spec, found := c.specs[handle]
+	if !found {
+		return api.ContainerSpec{Handle: handle}
+	}
+
+	delete(c.specs, handle)
+
+	return spec
+}
+
+func (s *GardenServer) containerCreated(handle string, spec api.ContainerSpec) {
+	if s.containerHooks.OnContainerDestroyed != nil {
+		s.containerSpecs.add(handle, spec)
+	}
+
+	if s.containerHooks.OnContainerCreated != nil {
+		s.containerHooks.OnContainerCreated(handle, spec)
+	}
+}
+
+func (s *GardenServer) containerDestroyed(handle string) {
+	spec := s.containerSpecs.remove(handle)
+
+	if s.containerHooks.OnContainerDestroyed != nil {
+		s.containerHooks.OnContainerDestroyed(handle, spec)
+	}
+}
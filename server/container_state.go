@@ -0,0 +1,30 @@
+package server
+
+// renameContainerState moves everything the server keeps of a container by
+// handle to its new handle, all at once, so that no request sees some of it
+// under the old handle and some under the new.
+func (s *GardenServer) renameContainerState(handle, newHandle string) {
+	s.stateL.Lock()
+	defer s.stateL.Unlock()
+
+	s.processLabels.rename(handle, newHandle)
+	s.processLimit.rename(handle, newHandle)
+	s.processWaits.rename(handle, newHandle)
+	s.processLogs.rename(handle, newHandle)
+	s.processDefaults.rename(handle, newHandle)
+	s.stdinAccounting.rename(handle, newHandle)
+	s.containerSpecs.rename(handle, newHandle)
+}
+
+// forgetContainerState drops what the server keeps of a destroyed container by
+// handle, other than its spec, which its destroyed hook is given.
+func (s *GardenServer) forgetContainerState(handle string) {
+	s.stateL.Lock()
+	defer s.stateL.Unlock()
+
+	s.processLabels.forget(handle)
+	s.processLimit.forget(handle)
+	s.processWaits.forget(handle)
+	s.processLogs.forget(handle)
+	s.processDefaults.forget(handle)
+}
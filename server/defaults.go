@@ -0,0 +1,194 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/pivotal-golang/lager"
+)
+
+// Defaults are the server's settings for the containers, processes and
+// clients which do not ask otherwise, which may be changed while it runs.
+type Defaults struct {
+	// GraceTime is given to containers created without one. Containers
+	// already created keep theirs.
+	GraceTime time.Duration
+
+	// RequestsPerSecond, Burst and MaxConcurrentStreams are the rate limits
+	// of each client, as with SetRateLimits.
+	RequestsPerSecond    float64
+	Burst                int
+	MaxConcurrentStreams int
+
+	// StdinLimit is the limit of SetStdinLimit.
+	StdinLimit uint64
+
+	// MaxProcessesPerContainer is the limit of SetMaxProcessesPerContainer.
+	MaxProcessesPerContainer uint32
+}
+
+// SetDefaults replaces the server's defaults. Unlike the other setters, it
+// may be called while the server runs, e.g. to tune the reaping of idle
+// containers without restarting it; requests and streams in flight are not
+// interrupted, and clients' streams already open count towards a lowered
+// limit.
+func (s *GardenServer) SetDefaults(defaults Defaults) {
+	s.updateDefaults(func(current *Defaults) {
+		*current = defaults
+	})
+}
+
+// Defaults returns the server's current defaults.
+func (s *GardenServer) Defaults() Defaults {
+	s.defaultsL.RLock()
+	defer s.defaultsL.RUnlock()
+
+	return s.defaults()
+}
+
+// updateDefaults changes the defaults with the update, returning them.
+func (s *GardenServer) updateDefaults(update func(*Defaults)) Defaults {
+	s.defaultsL.Lock()
+	defer s.defaultsL.Unlock()
+
+	defaults := s.defaults()
+
+	update(&defaults)
+
+	s.containerGraceTime = defaults.GraceTime
+
+	if s.rateLimiter != nil {
+		s.rateLimiter.setLimits(defaults.RequestsPerSecond, defaults.Burst, defaults.MaxConcurrentStreams)
+	} else if defaults.RequestsPerSecond != 0 || defaults.MaxConcurrentStreams != 0 {
+		s.rateLimiter = newRateLimiter(RateLimits{
+			RequestsPerSecond: defaults.RequestsPerSecond,
+			Burst:             defaults.Burst,
+			MaxStreams:        defaults.MaxConcurrentStreams,
+		})
+	}
+
+	s.stdinAccounting.setLimit(defaults.StdinLimit)
+	s.processLimit.setMax(defaults.MaxProcessesPerContainer)
+
+	return s.defaults()
+}
+
+// defaults collects the defaults. s.defaultsL must be held.
+func (s *GardenServer) defaults() Defaults {
+	defaults := Defaults{
+		GraceTime:                s.containerGraceTime,
+		StdinLimit:               s.stdinAccounting.getLimit(),
+		MaxProcessesPerContainer: s.processLimit.getMax(),
+	}
+
+	if s.rateLimiter != nil {
+		limits := s.rateLimiter.getLimits()
+
+		defaults.RequestsPerSecond = limits.RequestsPerSecond
+		defaults.Burst = limits.Burst
+		defaults.MaxConcurrentStreams = limits.MaxStreams
+	}
+
+	return defaults
+}
+
+func (s *GardenServer) defaultGraceTime() time.Duration {
+	s.defaultsL.RLock()
+	defer s.defaultsL.RUnlock()
+
+	return s.containerGraceTime
+}
+
+func (s *GardenServer) currentRateLimiter() *rateLimiter {
+	s.defaultsL.RLock()
+	defer s.defaultsL.RUnlock()
+
+	return s.rateLimiter
+}
+
+// allowAdmin authenticates a request of an admin route, writing an error
+// response if it is refused.
+func (s *GardenServer) allowAdmin(w http.ResponseWriter, r *http.Request, logger lager.Logger) bool {
+	if s.adminAuthenticator == nil {
+		s.writeError(w, api.ErrPermissionDenied, logger)
+		return false
+	}
+
+	err := s.adminAuthenticator.Authenticate(r)
+	if err != nil {
+		logger.Error("unauthorized", err)
+		s.writeError(w, api.ErrPermissionDenied, logger)
+		return false
+	}
+
+	return true
+}
+
+func (s *GardenServer) handleGetDefaults(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r, "get-defaults")
+
+	if !s.allowAdmin(w, r, hLog) {
+		return
+	}
+
+	s.writeResponse(w, defaultsResponse(s.Defaults()))
+}
+
+func (s *GardenServer) handleSetDefaults(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r, "set-defaults")
+
+	if !s.allowAdmin(w, r, hLog) {
+		return
+	}
+
+	var request apitypes.SetDefaultsRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	// only the defaults given are changed
+	defaults := s.updateDefaults(func(defaults *Defaults) {
+		if request.GraceTime != nil {
+			defaults.GraceTime = time.Duration(request.GetGraceTime()) * time.Second
+		}
+
+		if request.RequestsPerSecond != nil {
+			defaults.RequestsPerSecond = request.GetRequestsPerSecond()
+		}
+
+		if request.Burst != nil {
+			defaults.Burst = int(request.GetBurst())
+		}
+
+		if request.MaxConcurrentStreams != nil {
+			defaults.MaxConcurrentStreams = int(request.GetMaxConcurrentStreams())
+		}
+
+		if request.StdinLimit != nil {
+			defaults.StdinLimit = request.GetStdinLimit()
+		}
+
+		if request.MaxProcessesPerContainer != nil {
+			defaults.MaxProcessesPerContainer = request.GetMaxProcessesPerContainer()
+		}
+	})
+
+	hLog.Info("set", lager.Data{
+		"defaults": defaults,
+	})
+
+	s.writeResponse(w, &apitypes.SetDefaultsResponse{})
+}
+
+func defaultsResponse(defaults Defaults) *apitypes.DefaultsResponse {
+	return &apitypes.DefaultsResponse{
+		GraceTime:                apitypes.Uint32(uint32(defaults.GraceTime.Seconds())),
+		RequestsPerSecond:        apitypes.Float64(defaults.RequestsPerSecond),
+		Burst:                    apitypes.Uint32(uint32(defaults.Burst)),
+		MaxConcurrentStreams:     apitypes.Uint32(uint32(defaults.MaxConcurrentStreams)),
+		StdinLimit:               apitypes.Uint64(defaults.StdinLimit),
+		MaxProcessesPerContainer: apitypes.Uint32(uint32(defaults.MaxProcessesPerContainer)),
+	}
+}
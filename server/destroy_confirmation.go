@@ -0,0 +1,25 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// SetDestroyConfirmation makes the server refuse to destroy a container, or
+// to schedule its destruction, unless the request confirms it by naming the
+// container in its transport.DestroyConfirmationHeader. Clients which have not
+// opted in to destroying containers, e.g. misconfigured tooling, then fail
+// with api.ErrDestroyNotConfirmed. It must be called before Start.
+func (s *GardenServer) SetDestroyConfirmation(required bool) {
+	s.confirmDestroys = required
+}
+
+// destroyConfirmed says whether the request may destroy the container.
+func (s *GardenServer) destroyConfirmed(r *http.Request, handle string) bool {
+	if !s.confirmDestroys {
+		return true
+	}
+
+	return r.Header.Get(transport.DestroyConfirmationHeader) == handle
+}
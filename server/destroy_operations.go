@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// DestroyOperationRetention is how long the server remembers the outcome of
+// each destroy once it finishes, for clients which destroyed asynchronously
+// to look it up.
+var DestroyOperationRetention = 10 * time.Minute
+
+// destroyOperations tracks each destroy of a container, so that a container
+// is only destroyed once at a time, and so that clients can look up the
+// outcome of their destroys by id.
+type destroyOperations struct {
+	// idPrefix differs between servers, so that ids are not reused by servers
+	// which restart while clients are waiting on their destroys
+	idPrefix string
+	lastID   uint64
+
+	// destroying are the operations in progress, by handle
+	destroying map[string]*destroyOperation
+
+	// operations are all of the operations remembered, by id
+	operations map[string]*destroyOperation
+
+	running sync.WaitGroup
+	mu      sync.Mutex
+}
+
+type destroyOperation struct {
+	id     string
+	handle string
+
+	state api.DestroyState
+	err   error
+
+	// annotations are the container's, for reporting its failure
+	annotations []*apitypes.Property
+
+	finishedAt time.Time
+}
+
+func newDestroyOperations() *destroyOperations {
+	return &destroyOperations{
+		idPrefix: strconv.FormatInt(time.Now().UnixNano(), 36),
+
+		destroying: map[string]*destroyOperation{},
+		operations: map[string]*destroyOperation{},
+	}
+}
+
+// start records a destroy of the container. If the container is already
+// being destroyed, it returns that operation's id and false instead.
+func (o *destroyOperations) start(handle string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if operation, found := o.destroying[handle]; found {
+		return operation.id, false
+	}
+
+	o.forgetFinished()
+
+	o.lastID++
+
+	operation := &destroyOperation{
+		id:     fmt.Sprintf("%s-%d", o.idPrefix, o.lastID),
+		handle: handle,
+		state:  api.DestroyStateDestroying,
+	}
+
+	o.destroying[handle] = operation
+	o.operations[operation.id] = operation
+
+	o.running.Add(1)
+
+	return operation.id, true
+}
+
+// finish records the outcome of the destroy with the id, and the container's
+// annotations to report with its error.
+func (o *destroyOperations) finish(id string, err error, annotations []*apitypes.Property) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	operation := o.operations[id]
+
+	if err != nil {
+		operation.state = api.DestroyStateFailed
+		operation.err = err
+		operation.annotations = annotations
+	} else {
+		operation.state = api.DestroyStateDestroyed
+	}
+
+	operation.finishedAt = time.Now()
+
+	delete(o.destroying, operation.handle)
+
+	o.running.Done()
+}
+
+// lookup returns the operation with the id, as it is now.
+func (o *destroyOperations) lookup(id string) (destroyOperation, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	operation, found := o.operations[id]
+	if !found {
+		return destroyOperation{}, false
+	}
+
+	return *operation, true
+}
+
+// wait waits for the destroys in progress to finish.
+func (o *destroyOperations) wait() {
+	o.running.Wait()
+}
+
+func (o *destroyOperations) forgetFinished() {
+	for id, operation := range o.operations {
+		if operation.state == api.DestroyStateDestroying {
+			continue
+		}
+
+		if time.Since(operation.finishedAt) > DestroyOperationRetention {
+			delete(o.operations, id)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// Diagnostics describe the server's own use of resources, rather than its
+// containers', so that it can be watched for leaks.
+type Diagnostics struct {
+	Goroutines int
+
+	// OpenFiles is the number of file descriptors the server's process has
+	// open, or -1 where it cannot be told, i.e. on platforms without
+	// /proc/self/fd.
+	OpenFiles int
+
+	// OpenConnections is the number of connections being served which have
+	// not been hijacked, and HijackedConnections the number which have, e.g.
+	// for process streams and multiplexed sessions.
+	OpenConnections     int
+	HijackedConnections int
+
+	// HeapBytes is the memory allocated for the heap's live objects, and
+	// SysBytes the memory obtained from the system for everything.
+	HeapBytes uint64
+	SysBytes  uint64
+}
+
+// Diagnostics returns the server's current diagnostics.
+func (s *GardenServer) Diagnostics() Diagnostics {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	s.mu.Lock()
+	openConns := s.openConns
+	hijackedConns := s.hijackedConns
+	s.mu.Unlock()
+
+	return Diagnostics{
+		Goroutines:          runtime.NumGoroutine(),
+		OpenFiles:           openFiles(),
+		OpenConnections:     openConns,
+		HijackedConnections: hijackedConns,
+		HeapBytes:           memStats.HeapAlloc,
+		SysBytes:            memStats.Sys,
+	}
+}
+
+func openFiles() int {
+	dir, err := os.Open("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	defer dir.Close()
+
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return -1
+	}
+
+	// one of them is the directory being read
+	return len(names) - 1
+}
+
+func (s *GardenServer) handleGetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r, "get-diagnostics")
+
+	if !s.allowAdmin(w, r, hLog) {
+		return
+	}
+
+	diagnostics := s.Diagnostics()
+
+	res := &apitypes.DiagnosticsResponse{
+		Goroutines:          apitypes.Uint32(uint32(diagnostics.Goroutines)),
+		OpenConnections:     apitypes.Uint32(uint32(diagnostics.OpenConnections)),
+		HijackedConnections: apitypes.Uint32(uint32(diagnostics.HijackedConnections)),
+		HeapBytes:           apitypes.Uint64(diagnostics.HeapBytes),
+		SysBytes:            apitypes.Uint64(diagnostics.SysBytes),
+	}
+
+	if diagnostics.OpenFiles >= 0 {
+		res.OpenFiles = apitypes.Uint32(uint32(diagnostics.OpenFiles))
+	}
+
+	s.writeResponse(w, res)
+}
+
+// hijack takes over the request's connection, counting it among the hijacked
+// connections until it is closed.
+func (s *GardenServer) hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	conn, br, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	s.hijackedConns++
+	s.mu.Unlock()
+
+	return &hijackedConn{
+		Conn: conn,
+		closed: func() {
+			s.mu.Lock()
+			s.hijackedConns--
+			s.mu.Unlock()
+		},
+	}, br, nil
+}
+
+type hijackedConn struct {
+	net.Conn
+
+	closed    func()
+	closeOnce sync.Once
+}
+
+func (c *hijackedConn) Close() error {
+	c.closeOnce.Do(c.closed)
+	return c.Conn.Close()
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// A GraceTimePolicy says what the server does with requests for grace times
+// longer than its maximum, including for no grace time at all.
+type GraceTimePolicy int
+
+const (
+	// GraceTimeClamp gives the container the maximum grace time instead,
+	// warning the client in the response.
+	GraceTimeClamp GraceTimePolicy = iota
+
+	// GraceTimeReject fails the request with an api.ValidationError.
+	GraceTimeReject
+)
+
+// limitGraceTime returns the grace time to give a container whose client
+// asked for the given one, or an error if the request is to be rejected.
+func (s *GardenServer) limitGraceTime(w http.ResponseWriter, graceTime time.Duration) (time.Duration, error) {
+	if s.maxGraceTime == 0 {
+		return graceTime, nil
+	}
+
+	// a grace time of zero is never expiring
+	if graceTime != 0 && graceTime <= s.maxGraceTime {
+		return graceTime, nil
+	}
+
+	if s.graceTimePolicy == GraceTimeReject {
+		return 0, api.ValidationError{
+			Field:  api.SpecFieldGraceTime,
+			Reason: fmt.Sprintf("must be at most %s", s.maxGraceTime),
+		}
+	}
+
+	w.Header().Add(transport.WarningHeader, fmt.Sprintf("grace time clamped to the maximum of %s", s.maxGraceTime))
+
+	return s.maxGraceTime, nil
+}
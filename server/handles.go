@@ -0,0 +1,99 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// A HandleGenerator invents a handle for each container created without one,
+// e.g. a UUID, or a sequence for deterministic tests. Handles which are
+// already taken are generated again, so it need not guarantee uniqueness.
+type HandleGenerator func() (string, error)
+
+// RandomHandles generates handles of 16 random hex digits. It is the default.
+func RandomHandles() (string, error) {
+	handle := make([]byte, 8)
+
+	_, err := rand.Read(handle)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(handle), nil
+}
+
+// maxHandleAttempts bounds how many handles are generated for a container,
+// in case a generator keeps repeating itself.
+const maxHandleAttempts = 10
+
+// ErrNoUniqueHandle is the error creating a container fails with when every
+// handle generated for it was already taken.
+var ErrNoUniqueHandle = api.Classify(api.ErrorClassRetryable, errors.New("failed to generate a unique handle"))
+
+// handles generates handles for containers created without one, reserving
+// each until the container has been created, so that concurrent creates are
+// not given the same one.
+type handles struct {
+	generator HandleGenerator
+
+	// reserved are the handles generated for containers being created
+	reserved map[string]bool
+	mu       sync.Mutex
+}
+
+func newHandles() *handles {
+	return &handles{
+		generator: RandomHandles,
+		reserved:  map[string]bool{},
+	}
+}
+
+// generate returns a handle which no container has, nor is being created
+// with, failing with ErrNoUniqueHandle if none is generated in
+// maxHandleAttempts. If it succeeds, release must be called once the
+// container has been created or failed to be.
+func (h *handles) generate(backend api.Backend) (string, error) {
+	for i := 0; i < maxHandleAttempts; i++ {
+		handle, err := h.generator()
+		if err != nil {
+			return "", err
+		}
+
+		if h.reserve(backend, handle) {
+			return handle, nil
+		}
+	}
+
+	return "", ErrNoUniqueHandle
+}
+
+// reserve reserves the handle unless it is already taken. Backends differ in
+// how they fail to look up a container they do not have, so any failure is
+// taken to mean it is free; were it not, creating the container would fail.
+func (h *handles) reserve(backend api.Backend, handle string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if handle == "" || h.reserved[handle] {
+		return false
+	}
+
+	container, err := backend.Lookup(handle)
+	if err == nil && container != nil && container.Handle() == handle {
+		return false
+	}
+
+	h.reserved[handle] = true
+
+	return true
+}
+
+func (h *handles) release(handle string) {
+	h.mu.Lock()
+	delete(h.reserved, handle)
+	h.mu.Unlock()
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/transport"
+	"github.com/pivotal-golang/lager"
+)
+
+// handOffState is what a server keeps of its containers and processes which
+// their backend does not, handed to the server taking over from it.
+type handOffState struct {
+	Held      []string             `json:"held"`
+	Scheduled map[string]time.Time `json:"scheduled"`
+
+	// StdinWritten is the bytes written to each process's stdin, for
+	// processes which have streams attached
+	StdinWritten map[string]uint64 `json:"stdin_written"`
+
+	// ProcessLabels is the labels of each process run with any, by handle
+	// and process ID
+	ProcessLabels map[string]map[uint32]map[string]string `json:"process_labels"`
+
+	// ProcessDefaults is the default process user and environment of each
+	// container created with any, by handle
+	ProcessDefaults map[string]containerProcessDefaults `json:"process_defaults"`
+}
+
+// HandOff hands the server's listener, and the holds, scheduled destroys,
+// stdin limits, process labels and process defaults it keeps, to a new server
+// started with StartFromHandOff on the socket path, e.g. while upgrading the
+// server without its clients noticing.
+//
+// The new server serves every request from then on, while this one stops as
+// Stop does: process streams it has hijacked run until they end, rather than
+// being cut off. Idle containers are no longer destroyed by this server, and
+// its backend is not stopped, as the new server's backend takes over its
+// containers.
+func (s *GardenServer) HandOff(socketPath string) error {
+	hLog := s.logger.Session("hand-off", lager.Data{
+		"socket": socketPath,
+	})
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		hLog.Error("failed-to-dial", err)
+		return err
+	}
+
+	defer conn.Close()
+
+	snapshot := s.bomberman.Snapshot()
+
+	state := handOffState{
+		Held:            snapshot.Held,
+		Scheduled:       snapshot.Scheduled,
+		StdinWritten:    s.stdinAccounting.snapshot(),
+		ProcessLabels:   s.processLabels.snapshot(),
+		ProcessDefaults: s.processDefaults.snapshot(),
+	}
+
+	err = transport.SendListener(conn, s.listener)
+	if err != nil {
+		hLog.Error("failed-to-send-listener", err)
+		return err
+	}
+
+	s.mu.Lock()
+	s.handedOff = true
+	s.mu.Unlock()
+
+	err = json.NewEncoder(conn).Encode(state)
+	if err != nil {
+		hLog.Error("failed-to-send-state", err)
+	}
+
+	hLog.Info("handed-off")
+
+	s.stop()
+
+	hLog.Info("waiting-for-streams")
+	s.streaming.Wait()
+
+	hLog.Info("stopped")
+
+	return err
+}
+
+// StartFromHandOff starts the server once another has handed off to it over
+// the socket path with HandOff, serving requests on that server's listener
+// instead of listening anew.
+func (s *GardenServer) StartFromHandOff(socketPath string) error {
+	hLog := s.logger.Session("take-over", lager.Data{
+		"socket": socketPath,
+	})
+
+	handOffListener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		hLog.Error("failed-to-listen", err)
+		return err
+	}
+
+	defer handOffListener.Close()
+
+	hLog.Info("waiting")
+
+	for {
+		conn, err := handOffListener.AcceptUnix()
+		if err != nil {
+			hLog.Error("failed-to-accept", err)
+			return err
+		}
+
+		listener, state, err := receiveHandOff(conn)
+		if listener == nil {
+			// connections which hand off nothing, e.g. checking that the
+			// server is waiting, are ignored
+			hLog.Error("failed-to-receive-listener", err)
+			continue
+		}
+
+		if err != nil {
+			hLog.Error("failed-to-receive-state", err)
+		}
+
+		hLog.Info("taking-over", lager.Data{
+			"held":      len(state.Held),
+			"scheduled": len(state.Scheduled),
+		})
+
+		return s.start(listener, state)
+	}
+}
+
+func receiveHandOff(conn *net.UnixConn) (net.Listener, handOffState, error) {
+	defer conn.Close()
+
+	var state handOffState
+
+	listener, err := transport.ReceiveListener(conn)
+	if err != nil {
+		return nil, state, err
+	}
+
+	// the listener is served even without the state, as the old server has
+	// stopped accepting on it
+	err = json.NewDecoder(conn).Decode(&state)
+
+	return listener, state, err
+}
+
+// takeOver restores the state handed off by another server, for those of its
+// containers which the backend still has.
+func (s *GardenServer) takeOver(containers []api.Container, state handOffState) {
+	byHandle := map[string]api.Container{}
+	for _, container := range containers {
+		byHandle[container.Handle()] = container
+	}
+
+	for _, handle := range state.Held {
+		if _, found := byHandle[handle]; found {
+			s.bomberman.Hold(handle)
+		}
+	}
+
+	for handle, at := range state.Scheduled {
+		if container, found := byHandle[handle]; found {
+			s.bomberman.Schedule(container, at)
+		}
+	}
+
+	s.stdinAccounting.inherit(state.StdinWritten)
+
+	for handle, labels := range state.ProcessLabels {
+		if _, found := byHandle[handle]; found {
+			s.processLabels.inherit(handle, labels)
+		}
+	}
+
+	for handle, defaults := range state.ProcessDefaults {
+		if _, found := byHandle[handle]; found {
+			s.processDefaults.inherit(handle, defaults)
+		}
+	}
+}
+
+func (s *GardenServer) isHandedOff() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.handedOff
+}
+
+var errHandedOff = errors.New("listener handed off")
+
+// handOffListener stops accepting once its listener has been handed off,
+// without closing it, as closing a unix listener removes its socket file,
+// which the new server is listening on. The listener is left open until the
+// process exits.
+type handOffListener struct {
+	net.Listener
+
+	handedOff chan struct{}
+}
+
+func newHandOffListener(listener net.Listener) *handOffListener {
+	return &handOffListener{
+		Listener:  listener,
+		handedOff: make(chan struct{}),
+	}
+}
+
+func (l *handOffListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		select {
+		case <-l.handedOff:
+			// the deadline stopping accepts is not a temporary error, which
+			// would be retried
+			return nil, errHandedOff
+		default:
+		}
+	}
+
+	return conn, err
+}
+
+// Close closes the listener unless it has been handed off, as serving it
+// closes it once Accept fails.
+func (l *handOffListener) Close() error {
+	select {
+	case <-l.handedOff:
+		return nil
+	default:
+		return l.Listener.Close()
+	}
+}
+
+// stopAccepting wakes Accept with a deadline which has passed, falling back
+// to closing listeners without deadlines.
+func (l *handOffListener) stopAccepting() {
+	close(l.handedOff)
+
+	deadliner, ok := l.Listener.(interface {
+		SetDeadline(time.Time) error
+	})
+	if !ok {
+		l.Listener.Close()
+		return
+	}
+
+	deadliner.SetDeadline(time.Now())
+}
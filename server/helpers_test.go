@@ -18,3 +18,7 @@ func ErrorDialing(network, addr string) func() error {
 func uint64ptr(n uint64) *uint64 {
 	return &n
 }
+
+func uint32ptr(n uint32) *uint32 {
+	return &n
+}
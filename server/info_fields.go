@@ -0,0 +1,103 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// parseInfoFields parses a comma-separated fields parameter, returning no
+// fields if it is empty, meaning all of them.
+func parseInfoFields(param string) ([]api.InfoField, []api.ValidationError) {
+	if param == "" {
+		return nil, nil
+	}
+
+	known := map[api.InfoField]bool{}
+	for _, field := range api.InfoFields {
+		known[field] = true
+	}
+
+	var fields []api.InfoField
+	var violations []api.ValidationError
+
+	for _, name := range strings.Split(param, ",") {
+		field := api.InfoField(strings.TrimSpace(name))
+
+		if !known[field] {
+			violations = append(violations, api.ValidationError{
+				Field:  "fields",
+				Reason: "unknown field " + string(field),
+			})
+
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, violations
+}
+
+func hasInfoField(fields []api.InfoField, field api.InfoField) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectInfoFields returns a response with only the given fields of res.
+func selectInfoFields(res *apitypes.InfoResponse, fields []api.InfoField) *apitypes.InfoResponse {
+	selected := &apitypes.InfoResponse{}
+
+	for _, field := range fields {
+		switch field {
+		case api.InfoFieldState:
+			selected.State = res.State
+		case api.InfoFieldEvents:
+			selected.Events = res.Events
+		case api.InfoFieldHostIP:
+			selected.HostIp = res.HostIp
+		case api.InfoFieldContainerIP:
+			selected.ContainerIp = res.ContainerIp
+		case api.InfoFieldExternalIP:
+			selected.ExternalIp = res.ExternalIp
+		case api.InfoFieldExternalInterface:
+			selected.ExternalInterface = res.ExternalInterface
+		case api.InfoFieldContainerPath:
+			selected.ContainerPath = res.ContainerPath
+		case api.InfoFieldProcessIDs:
+			selected.ProcessIds = res.ProcessIds
+		case api.InfoFieldMemoryStat:
+			selected.MemoryStat = res.MemoryStat
+		case api.InfoFieldCPUStat:
+			selected.CpuStat = res.CpuStat
+		case api.InfoFieldDiskStat:
+			selected.DiskStat = res.DiskStat
+		case api.InfoFieldBandwidthStat:
+			selected.BandwidthStat = res.BandwidthStat
+		case api.InfoFieldProperties:
+			selected.Properties = res.Properties
+		case api.InfoFieldMappedPorts:
+			selected.MappedPorts = res.MappedPorts
+		case api.InfoFieldHeld:
+			selected.Held = res.Held
+		case api.InfoFieldGraceTime:
+			selected.GraceTime = res.GraceTime
+		case api.InfoFieldCreatedAt:
+			selected.CreatedAt = res.CreatedAt
+		case api.InfoFieldTimeToGraceExpiry:
+			selected.TimeToGraceExpiry = res.TimeToGraceExpiry
+		case api.InfoFieldLastActivityAt:
+			selected.LastActivityAt = res.LastActivityAt
+		case api.InfoFieldLimits:
+			selected.Limits = res.Limits
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/pivotal-golang/lager"
+)
+
+// currentLimits asks the backend for each of the limits applied to the
+// container, leaving out any it fails to report, e.g. as it does not support
+// them, rather than failing the request for the container's info.
+func currentLimits(container api.Container, logger lager.Logger) api.ContainerLimits {
+	var limits api.ContainerLimits
+
+	if bandwidth, err := container.CurrentBandwidthLimits(); err == nil {
+		limits.Bandwidth = &bandwidth
+	} else {
+		logLimitError(logger, "bandwidth", err)
+	}
+
+	if cpu, err := container.CurrentCPULimits(); err == nil {
+		limits.CPU = &cpu
+	} else {
+		logLimitError(logger, "cpu", err)
+	}
+
+	if disk, err := container.CurrentDiskLimits(); err == nil {
+		limits.Disk = &disk
+	} else {
+		logLimitError(logger, "disk", err)
+	}
+
+	if memory, err := container.CurrentMemoryLimits(); err == nil {
+		limits.Memory = &memory
+	} else {
+		logLimitError(logger, "memory", err)
+	}
+
+	return limits
+}
+
+func logLimitError(logger lager.Logger, limit string, err error) {
+	// backends need not support every limit
+	if err != api.ErrUnsupportedOperation {
+		logger.Error("failed-to-get-limits", err, lager.Data{"limit": limit})
+	}
+}
+
+func limitsResponse(limits api.ContainerLimits) *apitypes.InfoResponse_Limits {
+	if limits == (api.ContainerLimits{}) {
+		return nil
+	}
+
+	res := &apitypes.InfoResponse_Limits{}
+
+	if limits.Bandwidth != nil {
+		res.Bandwidth = &apitypes.LimitBandwidthResponse{
+			Rate:  apitypes.Uint64(limits.Bandwidth.RateInBytesPerSecond),
+			Burst: apitypes.Uint64(limits.Bandwidth.BurstRateInBytesPerSecond),
+		}
+	}
+
+	if limits.CPU != nil {
+		res.Cpu = &apitypes.LimitCpuResponse{
+			LimitInShares: apitypes.Uint64(limits.CPU.LimitInShares),
+			Quota:         apitypes.Uint64(limits.CPU.Quota),
+			Period:        apitypes.Uint64(limits.CPU.Period),
+			Cpuset:        apitypes.String(limits.CPU.Cpuset),
+		}
+	}
+
+	if limits.Disk != nil {
+		res.Disk = &apitypes.LimitDiskResponse{
+			BlockSoft: apitypes.Uint64(limits.Disk.BlockSoft),
+			BlockHard: apitypes.Uint64(limits.Disk.BlockHard),
+			InodeSoft: apitypes.Uint64(limits.Disk.InodeSoft),
+			InodeHard: apitypes.Uint64(limits.Disk.InodeHard),
+			ByteSoft:  apitypes.Uint64(limits.Disk.ByteSoft),
+			ByteHard:  apitypes.Uint64(limits.Disk.ByteHard),
+		}
+	}
+
+	if limits.Memory != nil {
+		res.Memory = &apitypes.LimitMemoryResponse{
+			LimitInBytes: apitypes.Uint64(limits.Memory.LimitInBytes),
+		}
+	}
+
+	return res
+}
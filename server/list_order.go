@@ -0,0 +1,52 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// a listedContainer is a container being listed, along with its info if the
+// listing needed it
+type listedContainer struct {
+	container api.Container
+	info      api.ContainerInfo
+}
+
+func validListOrder(order api.ListOrder) bool {
+	return order == api.ListOrderHandle || order == api.ListOrderCreated
+}
+
+func sortListed(listed []listedContainer, order api.ListOrder) {
+	switch order {
+	case api.ListOrderCreated:
+		sort.Sort(byCreation(listed))
+	default:
+		sort.Sort(byHandle(listed))
+	}
+}
+
+type byHandle []listedContainer
+
+func (l byHandle) Len() int      { return len(l) }
+func (l byHandle) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l byHandle) Less(i, j int) bool {
+	return l[i].container.Handle() < l[j].container.Handle()
+}
+
+type byCreation []listedContainer
+
+func (l byCreation) Len() int      { return len(l) }
+func (l byCreation) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l byCreation) Less(i, j int) bool {
+	created := l[i].info.CreatedAt
+	otherCreated := l[j].info.CreatedAt
+
+	if !created.Equal(otherCreated) {
+		return created.Before(otherCreated)
+	}
+
+	return l[i].container.Handle() < l[j].container.Handle()
+}
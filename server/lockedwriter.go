@@ -0,0 +1,20 @@
+package server
+
+import (
+	"io"
+	"sync"
+)
+
+// lockedWriter serializes writes to a hijacked connection, which may be
+// written to concurrently by output streaming and stdin acknowledgements.
+type lockedWriter struct {
+	w io.Writer
+	sync.Mutex
+}
+
+func (w *lockedWriter) Write(d []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.w.Write(d)
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// MetricsReporter receives metrics about the requests the server handles, so
+// that deployments can emit them however they like, e.g. with dropsonde or
+// statsd. Each metric is named for a route, e.g. "requests.Ping":
+//
+//	requests.<route>: counted as each request is received
+//	request_failures.<route>: counted as each request fails with a 4xx or 5xx
+//	request_duration.<route>: how long each request took to handle, which
+//	for process streams and multiplexed sessions is as long as they last
+//
+// Reporters are called from the goroutines handling requests, so must be
+// safe for concurrent use and should not block.
+type MetricsReporter interface {
+	IncrementCounter(name string)
+	SendDuration(name string, duration time.Duration)
+}
+
+func (s *GardenServer) metered(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reporter := s.metricsReporter
+		if reporter == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		reporter.IncrementCounter("requests." + route)
+
+		started := time.Now()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		handler.ServeHTTP(recorder, r)
+
+		reporter.SendDuration("request_duration."+route, time.Since(started))
+
+		if recorder.status >= http.StatusBadRequest {
+			reporter.IncrementCounter("request_failures." + route)
+		}
+	})
+}
+
+// statusRecorder records the status of a response, while still letting
+// handlers hijack its connection or flush what they stream.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *statusRecorder) Flush() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (r *statusRecorder) CloseNotify() <-chan bool {
+	return closeNotify(r.ResponseWriter)
+}
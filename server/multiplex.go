@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// handleMultiplex takes over the request's connection as a multiplexed
+// session, serving each stream the client opens over it as a connection of
+// its own.
+func (s *GardenServer) handleMultiplex(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r, "multiplex")
+
+	w.WriteHeader(http.StatusOK)
+
+	conn, br, err := s.hijack(w)
+	if err != nil {
+		hLog.Error("failed-to-hijack", err)
+		return
+	}
+
+	session := transport.NewMuxServer(conn, br.Reader)
+
+	s.mu.Lock()
+
+	select {
+	case <-s.stopping:
+		s.mu.Unlock()
+		session.Close()
+		return
+	default:
+	}
+
+	s.muxSessions[session] = struct{}{}
+
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.muxSessions, session)
+		s.mu.Unlock()
+	}()
+
+	hLog.Info("serving")
+
+	s.server.Serve(muxListener{session})
+
+	hLog.Info("done")
+}
+
+// muxListener serves a session's streams. http.Server closes the listeners it
+// stops serving, which for a session only stops it accepting streams, so
+// that hijacked ones are left to finish.
+type muxListener struct {
+	*transport.MuxSession
+}
+
+func (l muxListener) Close() error {
+	return l.GoAway()
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// netOutRuleFromNetOut translates the arguments of the older NetOut request
+// into a rule, so that backends need only implement NetOutRule.
+func netOutRuleFromNetOut(network string, port uint32, portRange string, protocol api.Protocol) (api.NetOutRule, error) {
+	rule := api.NetOutRule{
+		Protocol: protocol,
+	}
+
+	if network != "" {
+		ipRange, err := parseNetwork(network)
+		if err != nil {
+			return api.NetOutRule{}, err
+		}
+
+		rule.Networks = []api.IPRange{ipRange}
+	}
+
+	if port != 0 {
+		rule.Ports = append(rule.Ports, api.PortRangeFromPort(uint16(port)))
+	}
+
+	// the port range has already been checked by validPortRange
+	if portRange != "" {
+		bounds := strings.Split(portRange, ":")
+		start, _ := strconv.Atoi(bounds[0])
+		end, _ := strconv.Atoi(bounds[1])
+
+		rule.Ports = append(rule.Ports, api.PortRange{
+			Start: uint16(start),
+			End:   uint16(end),
+		})
+	}
+
+	return rule, nil
+}
+
+// a network is a CIDR, a single IP, or a range of IPs such as
+// "10.0.0.1-10.0.0.9"
+func parseNetwork(network string) (api.IPRange, error) {
+	if _, ipNet, err := net.ParseCIDR(network); err == nil {
+		return api.IPRangeFromIPNet(ipNet), nil
+	}
+
+	if bounds := strings.Split(network, "-"); len(bounds) == 2 {
+		start := parseIP(bounds[0])
+		end := parseIP(bounds[1])
+
+		if start != nil && end != nil {
+			return api.IPRange{Start: start, End: end}, nil
+		}
+	}
+
+	if ip := parseIP(network); ip != nil {
+		return api.IPRangeFromIP(ip), nil
+	}
+
+	return api.IPRange{}, fmt.Errorf("invalid network: %q", network)
+}
+
+// parseIP is net.ParseIP, but keeps IPv4 addresses in their 4-byte form to
+// match those parsed from a CIDR.
+func parseIP(ip string) net.IP {
+	parsed := net.ParseIP(ip)
+	if ipv4 := parsed.To4(); ipv4 != nil {
+		return ipv4
+	}
+
+	return parsed
+}
+
+// the request has already been checked by requestViolations
+func netOutRuleFromRequest(request *apitypes.NetOutRuleRequest) (api.NetOutRule, error) {
+	var rule api.NetOutRule
+
+	switch request.GetProtocol() {
+	case apitypes.NetOutRuleRequest_ALL:
+		rule.Protocol = api.ProtocolAll
+	case apitypes.NetOutRuleRequest_TCP:
+		rule.Protocol = api.ProtocolTCP
+	case apitypes.NetOutRuleRequest_UDP:
+		rule.Protocol = api.ProtocolUDP
+	case apitypes.NetOutRuleRequest_ICMP:
+		rule.Protocol = api.ProtocolICMP
+	default:
+		return api.NetOutRule{}, fmt.Errorf("invalid protocol: %d", request.GetProtocol())
+	}
+
+	for _, network := range request.GetNetworks() {
+		rule.Networks = append(rule.Networks, api.IPRange{
+			Start: parseIP(network.GetStart()),
+			End:   parseIP(network.GetEnd()),
+		})
+	}
+
+	for _, ports := range request.GetPorts() {
+		rule.Ports = append(rule.Ports, api.PortRange{
+			Start: uint16(ports.GetStart()),
+			End:   uint16(ports.GetEnd()),
+		})
+	}
+
+	rule.Log = request.GetLog()
+
+	return rule, nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// passedFiles are those a client passed with a request, by the process stream
+// each of them is for.
+type passedFiles map[apitypes.ProcessPayload_Source]*os.File
+
+// takePassedFiles takes the files passed with the request over its connection,
+// which must be those it says were, in the same order.
+func (s *GardenServer) takePassedFiles(r *http.Request, sources []apitypes.ProcessPayload_Source) (passedFiles, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	// each FileConn has a remote address of its own, by which the request's
+	// is found
+	s.mu.Lock()
+	fileConn, ok := s.fileConns[r.RemoteAddr]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, api.InvalidRequestError{
+			Violations: []api.ValidationError{{
+				Field:  "passed_files",
+				Reason: "can only be passed over a unix socket",
+			}},
+		}
+	}
+
+	files := fileConn.Files()
+	if len(files) != len(sources) {
+		for _, file := range files {
+			file.Close()
+		}
+
+		return nil, api.InvalidRequestError{
+			Violations: []api.ValidationError{{
+				Field:  "passed_files",
+				Reason: "must match the files passed with the request",
+			}},
+		}
+	}
+
+	passed := passedFiles{}
+	for i, source := range sources {
+		passed[source] = files[i]
+	}
+
+	return passed, nil
+}
+
+// connect has the process read and write the files in place of the streams
+// they were passed for.
+func (files passedFiles) connect(processIO *api.ProcessIO) {
+	if file, found := files[apitypes.ProcessPayload_stdin]; found {
+		processIO.Stdin = file
+	}
+
+	if file, found := files[apitypes.ProcessPayload_stdout]; found {
+		processIO.Stdout = file
+	}
+
+	if file, found := files[apitypes.ProcessPayload_stderr]; found {
+		processIO.Stderr = file
+	}
+}
+
+func (files passedFiles) close() {
+	for _, file := range files {
+		file.Close()
+	}
+}
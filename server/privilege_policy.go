@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// PrivilegePolicy decides whether the client making a request may create a
+// privileged container, or run a privileged process, e.g. by the principal it
+// authenticated as. It is only asked of privileged specs. It should return
+// api.ErrPermissionDenied to deny the request, which fails before the backend
+// sees it.
+type PrivilegePolicy interface {
+	AllowPrivilegedContainer(request *http.Request, spec api.ContainerSpec) error
+	AllowPrivilegedProcess(request *http.Request, container api.Container, spec api.ProcessSpec) error
+}
+
+// DenyPrivileged is a policy denying every privileged container and process.
+var DenyPrivileged PrivilegePolicy = denyPrivileged{}
+
+type denyPrivileged struct{}
+
+func (denyPrivileged) AllowPrivilegedContainer(*http.Request, api.ContainerSpec) error {
+	return api.ErrPermissionDenied
+}
+
+func (denyPrivileged) AllowPrivilegedProcess(*http.Request, api.Container, api.ProcessSpec) error {
+	return api.ErrPermissionDenied
+}
+
+func (s *GardenServer) allowContainer(r *http.Request, spec api.ContainerSpec) error {
+	if s.privilegePolicy == nil || !spec.Privileged {
+		return nil
+	}
+
+	return s.privilegePolicy.AllowPrivilegedContainer(r, spec)
+}
+
+func (s *GardenServer) allowProcess(r *http.Request, container api.Container, spec api.ProcessSpec) error {
+	if s.privilegePolicy == nil || !spec.Privileged {
+		return nil
+	}
+
+	return s.privilegePolicy.AllowPrivilegedProcess(r, container, spec)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// processDefaults keeps the default process user and environment of each
+// container created with any, by handle, which the server applies itself when
+// running processes. Backends do not keep them, so they are only known for
+// containers created through this server, or one which handed off to it.
+// rename and forget expect the container state lock to be held.
+type processDefaults struct {
+	containers map[string]containerProcessDefaults
+	mu         *sync.Mutex
+}
+
+type containerProcessDefaults struct {
+	User string   `json:"user,omitempty"`
+	Env  []string `json:"env,omitempty"`
+}
+
+func newProcessDefaults(mu *sync.Mutex) *processDefaults {
+	return &processDefaults{
+		containers: map[string]containerProcessDefaults{},
+		mu:         mu,
+	}
+}
+
+func (d *processDefaults) set(handle string, spec api.ContainerSpec) {
+	d.inherit(handle, containerProcessDefaults{
+		User: spec.DefaultProcessUser,
+		Env:  spec.Env,
+	})
+}
+
+func (d *processDefaults) get(handle string) containerProcessDefaults {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.containers[handle]
+}
+
+func (d *processDefaults) rename(handle, newHandle string) {
+	if defaults, found := d.containers[handle]; found {
+		d.containers[newHandle] = defaults
+		delete(d.containers, handle)
+	}
+}
+
+func (d *processDefaults) forget(handle string) {
+	delete(d.containers, handle)
+}
+
+// snapshot returns the defaults of every container which has any, by handle.
+func (d *processDefaults) snapshot() map[string]containerProcessDefaults {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snapshot := map[string]containerProcessDefaults{}
+	for handle, defaults := range d.containers {
+		snapshot[handle] = defaults
+	}
+
+	return snapshot
+}
+
+// inherit keeps the defaults of a container created through another server.
+func (d *processDefaults) inherit(handle string, defaults containerProcessDefaults) {
+	if defaults.User == "" && len(defaults.Env) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	d.containers[handle] = defaults
+	d.mu.Unlock()
+}
+
+// applyProcessDefaults runs processes which do not name a user or uid as the
+// container's default process user, and puts the container's environment
+// beneath the process's own, which wins for any variable set in both.
+func applyProcessDefaults(defaults containerProcessDefaults, defaultEnv []string, spec *api.ProcessSpec) {
+	if spec.User == "" && spec.UID == nil {
+		spec.User = defaults.User
+	}
+
+	spec.Env = mergeEnv(containerEnv(defaults, defaultEnv), spec.Env)
+}
+
+// containerEnv returns the environment the container's processes inherit: the
+// server's default environment, beneath the container's own.
+func containerEnv(defaults containerProcessDefaults, defaultEnv []string) []string {
+	if len(defaults.Env) == 0 {
+		return mergeEnv(nil, defaultEnv)
+	}
+
+	return mergeEnv(defaultEnv, defaults.Env)
+}
+
+// mergeEnv puts the base environment beneath the overrides, which win for any
+// variable set in both.
+func mergeEnv(base []string, overrides []string) []string {
+	if len(base) == 0 {
+		return overrides
+	}
+
+	set := map[string]bool{}
+	for _, variable := range overrides {
+		set[envKey(variable)] = true
+	}
+
+	env := []string{}
+	for _, variable := range base {
+		if !set[envKey(variable)] {
+			env = append(env, variable)
+		}
+	}
+
+	return append(env, overrides...)
+}
+
+func envKey(variable string) string {
+	return strings.SplitN(variable, "=", 2)[0]
+}
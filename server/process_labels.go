@@ -0,0 +1,147 @@
+package server
+
+import "sync"
+
+// processLabels keeps the labels each process was run with, by handle and
+// process ID, so that processes can be found by them later. Backends do not
+// keep them, so they are only known for processes run through this server,
+// or one which handed off to it.
+//
+// Like the server's other state kept by handle, it is guarded by the server's
+// container state lock, which rename and forget expect to be held.
+type processLabels struct {
+	processes map[string]map[uint32]labelledProcess
+
+	// generation increases as each process's labels are kept, so that those
+	// kept while the running processes were being listed are not taken for
+	// labels of processes which have exited
+	generation uint64
+
+	mu *sync.Mutex
+}
+
+type labelledProcess struct {
+	labels     map[string]string
+	generation uint64
+}
+
+func newProcessLabels(mu *sync.Mutex) *processLabels {
+	return &processLabels{
+		processes: map[string]map[uint32]labelledProcess{},
+		mu:        mu,
+	}
+}
+
+func (l *processLabels) set(handle string, processID uint32, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	processes, found := l.processes[handle]
+	if !found {
+		processes = map[uint32]labelledProcess{}
+		l.processes[handle] = processes
+	}
+
+	l.generation++
+
+	processes[processID] = labelledProcess{
+		labels:     labels,
+		generation: l.generation,
+	}
+}
+
+// mark returns the current generation, to be passed to find with the
+// processes found to be running after it was taken.
+func (l *processLabels) mark() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.generation
+}
+
+// find returns the IDs of the container's running processes having every one
+// of the labels, in the order they are given. The labels of processes which
+// were kept before the mark, and are no longer running, are forgotten.
+func (l *processLabels) find(handle string, labels map[string]string, running []uint32, mark uint64) []uint32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	processes := l.processes[handle]
+
+	isRunning := map[uint32]bool{}
+	found := []uint32{}
+
+	for _, id := range running {
+		process, known := processes[id]
+		if !known {
+			continue
+		}
+
+		isRunning[id] = true
+
+		if hasLabels(process.labels, labels) {
+			found = append(found, id)
+		}
+	}
+
+	for id, process := range processes {
+		if !isRunning[id] && process.generation <= mark {
+			delete(processes, id)
+		}
+	}
+
+	if len(processes) == 0 {
+		delete(l.processes, handle)
+	}
+
+	return found
+}
+
+func hasLabels(labels map[string]string, wanted map[string]string) bool {
+	for key, value := range wanted {
+		if actual, found := labels[key]; !found || actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (l *processLabels) rename(handle, newHandle string) {
+	if processes, found := l.processes[handle]; found {
+		l.processes[newHandle] = processes
+		delete(l.processes, handle)
+	}
+}
+
+func (l *processLabels) forget(handle string) {
+	delete(l.processes, handle)
+}
+
+// snapshot returns the labels of every process, by handle and process ID.
+func (l *processLabels) snapshot() map[string]map[uint32]map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snapshot := map[string]map[uint32]map[string]string{}
+	for handle, processes := range l.processes {
+		snapshot[handle] = map[uint32]map[string]string{}
+		for id, process := range processes {
+			snapshot[handle][id] = process.labels
+		}
+	}
+
+	return snapshot
+}
+
+// inherit keeps the labels of the container's processes run by another
+// server, by process ID.
+func (l *processLabels) inherit(handle string, labels map[uint32]map[string]string) {
+	for id, processLabels := range labels {
+		l.set(handle, id, processLabels)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// processLimit caps how many processes each container may run at once.
+// rename and forget expect the container state lock to be held.
+type processLimit struct {
+	// max is the most processes each container may run at once, or 0 for no
+	// limit
+	max uint32
+
+	// starting counts the processes being started in each container, by
+	// handle, which its backend may not report as running yet. They are
+	// counted even without a limit, in case one is set meanwhile.
+	starting map[string]*startingProcesses
+	mu       *sync.Mutex
+}
+
+// startingProcesses counts the processes being started in a container, under
+// whichever handle it has by the time they have started.
+type startingProcesses struct {
+	handle string
+	count  uint32
+
+	// admitting counts the admissions waiting on or holding admitL, which keep
+	// it counted under its handle even while none are starting
+	admitting uint32
+
+	// admitL is held while asking the backend which processes the container
+	// runs, and while a process finishes starting, so that one started
+	// meanwhile is counted either as running or as starting, but not neither.
+	// It is taken before the container state lock, which is not held while
+	// asking the backend, so that a slow backend holds up only the processes
+	// being started in the same container.
+	admitL sync.Mutex
+}
+
+func newProcessLimit(mu *sync.Mutex) *processLimit {
+	return &processLimit{
+		starting: map[string]*startingProcesses{},
+		mu:       mu,
+	}
+}
+
+// admit reserves room for a process being started in the container, failing
+// with api.ErrProcessLimitExceeded if it already runs as many as it may. If
+// it succeeds, finish must be called with what it returns once the process
+// has been started or failed to start.
+func (l *processLimit) admit(container api.Container) (*startingProcesses, error) {
+	l.mu.Lock()
+
+	handle := container.Handle()
+	max := l.max
+
+	starting, found := l.starting[handle]
+	if !found {
+		starting = &startingProcesses{handle: handle}
+		l.starting[handle] = starting
+	}
+
+	starting.admitting++
+
+	l.mu.Unlock()
+
+	starting.admitL.Lock()
+	defer starting.admitL.Unlock()
+
+	var running int
+	var err error
+
+	if max != 0 {
+		var info api.ContainerInfo
+
+		info, err = container.InfoFields([]api.InfoField{api.InfoFieldProcessIDs})
+		running = len(info.ProcessIDs)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	starting.admitting--
+
+	if err == nil && max != 0 && uint32(running)+starting.count >= max {
+		err = api.ErrProcessLimitExceeded
+	}
+
+	if err != nil {
+		l.release(starting)
+		return nil, err
+	}
+
+	starting.count++
+
+	return starting, nil
+}
+
+func (l *processLimit) setMax(max uint32) {
+	l.mu.Lock()
+	l.max = max
+	l.mu.Unlock()
+}
+
+func (l *processLimit) getMax() uint32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.max
+}
+
+func (l *processLimit) finish(starting *startingProcesses) {
+	starting.admitL.Lock()
+	defer starting.admitL.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	starting.count--
+
+	l.release(starting)
+}
+
+// release stops counting under its handle a container none of whose
+// processes are being started or admitted. It expects the container state
+// lock to be held.
+func (l *processLimit) release(starting *startingProcesses) {
+	if starting.count == 0 && starting.admitting == 0 && l.starting[starting.handle] == starting {
+		delete(l.starting, starting.handle)
+	}
+}
+
+func (l *processLimit) rename(handle, newHandle string) {
+	if starting, found := l.starting[handle]; found {
+		starting.handle = newHandle
+		l.starting[newHandle] = starting
+		delete(l.starting, handle)
+	}
+}
+
+// forget stops counting a destroyed container's starting processes; they
+// still finish, but are no longer counted against a container of the same
+// handle created meanwhile.
+func (l *processLimit) forget(handle string) {
+	delete(l.starting, handle)
+}
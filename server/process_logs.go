@@ -0,0 +1,423 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/transport"
+	"github.com/pivotal-golang/lager"
+)
+
+// logFollowInterval is the least time between the batches of output sent to
+// clients following a container's logs, so that processes writing many small
+// chunks do not have each sent and flushed on its own.
+const logFollowInterval = 100 * time.Millisecond
+
+// SetLogRetention keeps the most recent output of the processes run in each
+// container, up to the given bytes per container, for clients to replay with
+// Logs, e.g. after going away while a process ran. Retention is off by
+// default, and Logs then fails with api.ErrUnsupportedOperation. It must be
+// called before Start.
+func (s *GardenServer) SetLogRetention(bytesPerContainer uint64) {
+	s.processLogs.limit = bytesPerContainer
+}
+
+// processLogs retains the output of processes run through the server, by
+// handle. Output of processes run by other servers, or before one handed off
+// to this one, is not retained. rename and forget expect the container state
+// lock to be held.
+type processLogs struct {
+	// limit bounds the bytes retained for each container; 0 retains none
+	limit uint64
+
+	containers map[string]*containerLog
+	mu         *sync.Mutex
+}
+
+func newProcessLogs(mu *sync.Mutex) *processLogs {
+	return &processLogs{
+		containers: map[string]*containerLog{},
+		mu:         mu,
+	}
+}
+
+func (l *processLogs) enabled() bool {
+	return l.limit != 0
+}
+
+// log returns the container's log, starting an empty one if it has none.
+func (l *processLogs) log(handle string) *containerLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	log, found := l.containers[handle]
+	if !found {
+		log = &containerLog{
+			limit:     l.limit,
+			written:   make(chan struct{}),
+			forgotten: make(chan struct{}),
+		}
+
+		l.containers[handle] = log
+	}
+
+	return log
+}
+
+func (l *processLogs) rename(oldHandle, newHandle string) {
+	if log, found := l.containers[oldHandle]; found {
+		delete(l.containers, oldHandle)
+		l.containers[newHandle] = log
+	}
+}
+
+// forget drops a destroyed container's log, ending any streams following it.
+func (l *processLogs) forget(handle string) {
+	if log, found := l.containers[handle]; found {
+		delete(l.containers, handle)
+		log.forget()
+	}
+}
+
+// output returns the output of a process about to be run in the container,
+// which is retained once the process has started, or nil if retention is
+// off.
+func (l *processLogs) output(handle string) *processOutput {
+	if !l.enabled() {
+		return nil
+	}
+
+	log := l.log(handle)
+
+	return &processOutput{
+		log:    log,
+		offset: log.position(),
+	}
+}
+
+// containerLog is a container's retained output, oldest first, dropping the
+// oldest once it exceeds its limit.
+type containerLog struct {
+	limit uint64
+
+	entries []logEntry
+	bytes   uint64
+
+	// next is the sequence number of the next entry written
+	next uint64
+
+	// written is closed, and replaced, as each entry is written
+	written chan struct{}
+
+	// forgotten is closed once the container is destroyed
+	forgotten chan struct{}
+	gone      bool
+
+	mu sync.Mutex
+}
+
+type logEntry struct {
+	seq       uint64
+	processID uint32
+	source    apitypes.ProcessPayload_Source
+	data      []byte
+	at        time.Time
+}
+
+func (l *containerLog) write(processID uint32, source apitypes.ProcessPayload_Source, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.gone || len(data) == 0 {
+		return
+	}
+
+	// only the end of output bigger than the whole log is kept
+	if uint64(len(data)) > l.limit {
+		data = data[uint64(len(data))-l.limit:]
+	}
+
+	for len(l.entries) > 0 && l.bytes+uint64(len(data)) > l.limit {
+		l.bytes -= uint64(len(l.entries[0].data))
+		l.entries = l.entries[1:]
+	}
+
+	// prevent buffer reuse from clobbering the data
+	retained := make([]byte, len(data))
+	copy(retained, data)
+
+	l.entries = append(l.entries, logEntry{
+		seq:       l.next,
+		processID: processID,
+		source:    source,
+		data:      retained,
+		at:        time.Now(),
+	})
+
+	l.bytes += uint64(len(retained))
+	l.next++
+
+	close(l.written)
+	l.written = make(chan struct{})
+}
+
+// position returns the sequence number of the next entry written.
+func (l *containerLog) position() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.next
+}
+
+// tail returns the sequence number of the first of the last n entries, or of
+// the oldest entry if n is 0.
+func (l *containerLog) tail(n int) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n == 0 || n > len(l.entries) {
+		return l.next - uint64(len(l.entries))
+	}
+
+	return l.next - uint64(n)
+}
+
+// since returns the entries from the sequence number on, with the sequence
+// number following them and a channel closed when another is written. Entries
+// dropped before they were read are skipped.
+func (l *containerLog) since(seq uint64) ([]logEntry, uint64, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	oldest := l.next - uint64(len(l.entries))
+	if seq < oldest {
+		seq = oldest
+	}
+
+	entries := make([]logEntry, l.next-seq)
+	copy(entries, l.entries[seq-oldest:])
+
+	return entries, l.next, l.written
+}
+
+func (l *containerLog) forget() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.gone {
+		l.gone = true
+		close(l.forgotten)
+	}
+}
+
+// processOutput retains a process's output in its container's log, once the
+// process has started and its ID is known; output written before then, e.g.
+// while the backend is still starting it, is held until then.
+type processOutput struct {
+	log *containerLog
+
+	// offset is the sequence number from which the output is retained
+	offset uint64
+
+	processID uint32
+	started   bool
+	abandoned bool
+	pending   []pendingOutput
+
+	mu sync.Mutex
+}
+
+type pendingOutput struct {
+	source apitypes.ProcessPayload_Source
+	data   []byte
+}
+
+func (o *processOutput) writer(w io.Writer, source apitypes.ProcessPayload_Source) io.Writer {
+	if o == nil {
+		return w
+	}
+
+	return io.MultiWriter(w, &outputLogger{output: o, source: source})
+}
+
+// position returns the sequence number from which the output is retained, or
+// 0 if retention is off.
+func (o *processOutput) position() uint64 {
+	if o == nil {
+		return 0
+	}
+
+	return o.offset
+}
+
+func (o *processOutput) start(processID uint32) {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.processID = processID
+	o.started = true
+
+	for _, pending := range o.pending {
+		o.log.write(processID, pending.source, pending.data)
+	}
+
+	o.pending = nil
+}
+
+// abandon drops the output of a process which failed to start.
+func (o *processOutput) abandon() {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	o.abandoned = true
+	o.pending = nil
+	o.mu.Unlock()
+}
+
+func (o *processOutput) write(source apitypes.ProcessPayload_Source, data []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch {
+	case o.abandoned:
+	case o.started:
+		o.log.write(o.processID, source, data)
+	default:
+		// prevent buffer reuse from clobbering the data
+		held := make([]byte, len(data))
+		copy(held, data)
+
+		o.pending = append(o.pending, pendingOutput{source: source, data: held})
+	}
+}
+
+type outputLogger struct {
+	output *processOutput
+	source apitypes.ProcessPayload_Source
+}
+
+func (w *outputLogger) Write(data []byte) (int, error) {
+	w.output.write(w.source, data)
+	return len(data), nil
+}
+
+// parseLogsQuery parses the query of a request for a container's logs, e.g.
+// ?tail=100&follow=true.
+func parseLogsQuery(r *http.Request) (int, bool, []api.ValidationError) {
+	var tail int
+	var violations []api.ValidationError
+
+	if param := r.URL.Query().Get("tail"); param != "" {
+		parsed, err := strconv.ParseUint(param, 10, 31)
+		if err != nil {
+			violations = append(violations, api.ValidationError{
+				Field:  "tail",
+				Reason: "must be a non-negative number of entries",
+			})
+		}
+
+		tail = int(parsed)
+	}
+
+	return tail, r.URL.Query().Get("follow") == "true", violations
+}
+
+func (s *GardenServer) handleLogs(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	tail, follow, violations := parseLogsQuery(r)
+
+	hLog := s.session(r, "logs", lager.Data{
+		"handle": handle,
+		"tail":   tail,
+		"follow": follow,
+	})
+
+	if len(violations) > 0 {
+		s.writeError(w, api.InvalidRequestError{Violations: violations}, hLog)
+		return
+	}
+
+	if !s.processLogs.enabled() {
+		s.writeError(w, api.ErrUnsupportedOperation, hLog)
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	log := s.processLogs.log(container.Handle())
+
+	w.Header().Set("Content-Type", transport.ContentTypeJSON)
+
+	flusher, _ := w.(http.Flusher)
+
+	gone := closeNotify(w)
+
+	seq := log.tail(tail)
+
+	for {
+		entries, next, written := log.since(seq)
+
+		for _, entry := range entries {
+			err := transport.WriteMessage(w, &apitypes.LogEntry{
+				ProcessId: apitypes.Uint32(entry.processID),
+				Source:    entry.source.Enum(),
+				Data:      apitypes.String(string(entry.data)),
+				Timestamp: apitypes.Int64(entry.at.UnixNano()),
+			})
+			if err != nil {
+				hLog.Error("failed-to-stream", err)
+				return
+			}
+		}
+
+		seq = next
+
+		if !follow {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-written:
+		case <-log.forgotten:
+			// send whatever was written before the container was destroyed
+			follow = false
+			continue
+		case <-s.stopping:
+			return
+		case <-gone:
+			return
+		}
+
+		// batch whatever else is written meanwhile
+		select {
+		case <-time.After(logFollowInterval):
+		case <-s.stopping:
+			return
+		case <-gone:
+			return
+		}
+	}
+}
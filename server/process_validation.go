@@ -0,0 +1,47 @@
+package server
+
+import (
+	"path"
+	"regexp"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// a user is either a name or a numeric uid
+var userPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+const maxUserLength = 32
+
+func validateProcessSpec(spec api.ProcessSpec) error {
+	if spec.Path == "" {
+		return api.ValidationError{
+			Field:  api.SpecFieldPath,
+			Reason: "must not be empty",
+		}
+	}
+
+	if spec.Dir != "" && path.Clean(spec.Dir) != spec.Dir {
+		return api.ValidationError{
+			Field:  api.SpecFieldDir,
+			Reason: "must be a clean path, e.g. " + path.Clean(spec.Dir),
+		}
+	}
+
+	if spec.User != "" && spec.UID != nil {
+		return api.ValidationError{
+			Field:  api.SpecFieldUID,
+			Reason: "must not be given with a user",
+		}
+	}
+
+	if spec.User != "" {
+		if len(spec.User) > maxUserLength || !userPattern.MatchString(spec.User) {
+			return api.ValidationError{
+				Field:  api.SpecFieldUser,
+				Reason: "must be a user name or uid",
+			}
+		}
+	}
+
+	return nil
+}
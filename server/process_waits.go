@@ -0,0 +1,233 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/pivotal-golang/lager"
+)
+
+// maxProcessWait bounds how long a request may wait for a process, so that
+// waits are not left hanging on connections which went away unnoticed.
+const maxProcessWait = 5 * time.Minute
+
+// processPollInterval is how often the backend is asked for the container's
+// processes while waiting for one. Processes run through the server are
+// noticed at once, but others, e.g. those started by an init script, only
+// when the backend is asked.
+const processPollInterval = 100 * time.Millisecond
+
+var errServerStopping = api.Classify(api.ErrorClassRetryable, errors.New("server is stopping"))
+
+// processWaits tells requests waiting for processes when a process is run in
+// their container. rename and forget expect the container state lock to be
+// held.
+type processWaits struct {
+	// run is closed, and replaced, when a process is next run in each
+	// container being waited on, by handle
+	run map[string]chan struct{}
+	mu  *sync.Mutex
+}
+
+func newProcessWaits(mu *sync.Mutex) *processWaits {
+	return &processWaits{
+		run: map[string]chan struct{}{},
+		mu:  mu,
+	}
+}
+
+// next returns a channel which is closed when a process is next run in the
+// container.
+func (w *processWaits) next(handle string) <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	run, found := w.run[handle]
+	if !found {
+		run = make(chan struct{})
+		w.run[handle] = run
+	}
+
+	return run
+}
+
+func (w *processWaits) processRun(handle string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if run, found := w.run[handle]; found {
+		close(run)
+		delete(w.run, handle)
+	}
+}
+
+// rename moves the waits on the container to its new handle, so that they are
+// told of processes run under it.
+func (w *processWaits) rename(handle, newHandle string) {
+	if run, found := w.run[handle]; found {
+		w.run[newHandle] = run
+		delete(w.run, handle)
+	}
+}
+
+// forget stops tracking a destroyed container; anything still waiting on it
+// fails to find it when next asking the backend.
+func (w *processWaits) forget(handle string) {
+	delete(w.run, handle)
+}
+
+// parseProcessMatcher parses the query of a request to wait for a process,
+// e.g. ?id=42&label=role=daemon&timeout=5000, with its timeout in
+// milliseconds.
+func parseProcessMatcher(query url.Values) (api.ProcessMatcher, time.Duration, []api.ValidationError) {
+	var matcher api.ProcessMatcher
+	var timeout time.Duration
+	var violations []api.ValidationError
+
+	if id := query.Get("id"); id != "" {
+		parsed, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			violations = append(violations, api.ValidationError{
+				Field:  "id",
+				Reason: "must be a process ID",
+			})
+		}
+
+		matcher.ID = uint32(parsed)
+	}
+
+	for _, label := range query["label"] {
+		keyValue := strings.SplitN(label, "=", 2)
+		if len(keyValue) != 2 || keyValue[0] == "" {
+			violations = append(violations, api.ValidationError{
+				Field:  "label",
+				Reason: "must be a key=value pair",
+			})
+
+			continue
+		}
+
+		if matcher.Labels == nil {
+			matcher.Labels = map[string]string{}
+		}
+
+		matcher.Labels[keyValue[0]] = keyValue[1]
+	}
+
+	if ms := query.Get("timeout"); ms != "" {
+		parsed, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			violations = append(violations, api.ValidationError{
+				Field:  "timeout",
+				Reason: "must be a non-negative number of milliseconds",
+			})
+		}
+
+		timeout = time.Duration(parsed) * time.Millisecond
+	}
+
+	if timeout > maxProcessWait {
+		timeout = maxProcessWait
+	}
+
+	return matcher, timeout, violations
+}
+
+// matchProcess returns the first of the container's running processes which
+// the matcher matches.
+func (s *GardenServer) matchProcess(container api.Container, matcher api.ProcessMatcher) (uint32, bool, error) {
+	mark := s.processLabels.mark()
+
+	info, err := container.InfoFields([]api.InfoField{api.InfoFieldProcessIDs})
+	if err != nil {
+		return 0, false, err
+	}
+
+	candidates := info.ProcessIDs
+	if len(matcher.Labels) > 0 {
+		candidates = s.processLabels.find(container.Handle(), matcher.Labels, info.ProcessIDs, mark)
+	}
+
+	for _, id := range candidates {
+		if matcher.ID == 0 || id == matcher.ID {
+			return id, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func (s *GardenServer) handleWaitForProcess(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	matcher, timeout, violations := parseProcessMatcher(r.URL.Query())
+
+	hLog := s.session(r, "wait-for-process", lager.Data{
+		"handle":  handle,
+		"matcher": matcher,
+		"timeout": timeout.String(),
+	})
+
+	if len(violations) > 0 {
+		s.writeError(w, api.InvalidRequestError{Violations: violations}, hLog)
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	gone := closeNotify(w)
+
+	hLog.Debug("waiting")
+
+	for {
+		// taken before asking the backend, so that a process run meanwhile
+		// is not missed
+		run := s.processWaits.next(container.Handle())
+
+		processID, found, err := s.matchProcess(container, matcher)
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+
+		if found {
+			hLog.Info("found", lager.Data{"id": processID})
+
+			s.writeResponse(w, &apitypes.WaitForProcessResponse{
+				ProcessId: apitypes.Uint32(processID),
+			})
+
+			return
+		}
+
+		select {
+		case <-run:
+		case <-time.After(processPollInterval):
+		case <-deadline.C:
+			s.writeContainerError(w, container, api.ErrProcessNotFound, hLog)
+			return
+		case <-s.stopping:
+			s.writeContainerError(w, container, errServerStopping, hLog)
+			return
+		case <-gone:
+			return
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// propertyFilters parses a listing's property filters from its query, whose
+// keys are either property names, to match exactly, or property names
+// prefixed with an operator, e.g. "ne:owner". It returns the exact matches,
+// which the backend applies, apart from the other filters, which the server
+// applies itself. Keys with a prefix which is not an operator are names.
+func propertyFilters(query url.Values) (api.Properties, []api.PropertyFilter) {
+	properties := api.Properties{}
+	filters := []api.PropertyFilter{}
+
+	for key, vals := range query {
+		if len(vals) == 0 {
+			continue
+		}
+
+		operator, name := api.PropertyEquals, key
+
+		if i := strings.Index(key, ":"); i > 0 {
+			switch op := api.PropertyOperator(key[:i]); op {
+			case api.PropertyEquals, api.PropertyNotEquals, api.PropertyPrefix, api.PropertyExists:
+				operator, name = op, key[i+1:]
+			}
+		}
+
+		if operator == api.PropertyEquals {
+			properties[name] = vals[0]
+			continue
+		}
+
+		filters = append(filters, api.PropertyFilter{
+			Name:     name,
+			Operator: operator,
+			Value:    vals[0],
+		})
+	}
+
+	return properties, filters
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/routes"
+	"github.com/pivotal-golang/lager"
+)
+
+// RateLimits bound how much of the server each client may use, so that a
+// misbehaving client cannot starve the backend. Requests beyond them are
+// rejected with api.ErrRateLimited.
+type RateLimits struct {
+	// RequestsPerSecond is the rate at which each client may make requests,
+	// in bursts of up to Burst (by default, one second's worth). 0 leaves
+	// requests unlimited.
+	RequestsPerSecond float64
+	Burst             int
+
+	// MaxStreams bounds how many process, file, and snapshot streams each
+	// client may have open at once. 0 leaves streams unlimited.
+	MaxStreams int
+
+	// ClientID identifies the client making a request, e.g. by the principal
+	// it authenticated as. By default clients are identified by the host of
+	// their remote address.
+	ClientID func(*http.Request) string
+}
+
+type rateLimiter struct {
+	limits RateLimits
+	burst  float64
+
+	clients  map[string]*clientUsage
+	clientsL sync.Mutex
+}
+
+// clientUsage is a client's token bucket, and its open streams
+type clientUsage struct {
+	tokens  float64
+	updated time.Time
+
+	streams int
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	if limits.ClientID == nil {
+		limits.ClientID = remoteHost
+	}
+
+	return &rateLimiter{
+		limits: limits,
+		burst:  burstOf(limits),
+
+		clients: make(map[string]*clientUsage),
+	}
+}
+
+func burstOf(limits RateLimits) float64 {
+	if limits.Burst != 0 {
+		return float64(limits.Burst)
+	}
+
+	return math.Max(1, math.Ceil(limits.RequestsPerSecond))
+}
+
+// setLimits changes the limits, other than how clients are identified,
+// keeping each client's usage so far.
+func (l *rateLimiter) setLimits(requestsPerSecond float64, burst int, maxStreams int) {
+	l.clientsL.Lock()
+	defer l.clientsL.Unlock()
+
+	l.limits.RequestsPerSecond = requestsPerSecond
+	l.limits.Burst = burst
+	l.limits.MaxStreams = maxStreams
+
+	l.burst = burstOf(l.limits)
+}
+
+func (l *rateLimiter) getLimits() RateLimits {
+	l.clientsL.Lock()
+	defer l.clientsL.Unlock()
+
+	return l.limits
+}
+
+// admit reserves room for a client's request, returning false if it is over
+// its limits. Streams must be released once they end.
+func (l *rateLimiter) admit(client string, stream bool, now time.Time) bool {
+	l.clientsL.Lock()
+	defer l.clientsL.Unlock()
+
+	usage, found := l.clients[client]
+	if !found {
+		usage = &clientUsage{tokens: l.burst, updated: now}
+		l.clients[client] = usage
+	}
+
+	if stream && l.limits.MaxStreams > 0 && usage.streams >= l.limits.MaxStreams {
+		return false
+	}
+
+	if l.limits.RequestsPerSecond > 0 {
+		elapsed := now.Sub(usage.updated).Seconds()
+
+		usage.tokens = math.Min(l.burst, usage.tokens+elapsed*l.limits.RequestsPerSecond)
+		usage.updated = now
+
+		if usage.tokens < 1 {
+			return false
+		}
+
+		usage.tokens--
+	}
+
+	if stream {
+		usage.streams++
+	}
+
+	return true
+}
+
+func (l *rateLimiter) release(client string) {
+	l.clientsL.Lock()
+	defer l.clientsL.Unlock()
+
+	if usage, found := l.clients[client]; found {
+		usage.streams--
+	}
+}
+
+func (s *GardenServer) limited(route string, handler http.Handler) http.Handler {
+	stream := isStream(route)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := s.currentRateLimiter()
+		if limiter == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		client := limiter.limits.ClientID(r)
+
+		if !limiter.admit(client, stream, time.Now()) {
+			s.writeError(w, api.ErrRateLimited, s.session(r, "rate-limited", lager.Data{
+				"client": client,
+				"route":  route,
+			}))
+
+			return
+		}
+
+		if stream {
+			defer limiter.release(client)
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// isStream tells whether a route's requests stream a process, files, or a
+// snapshot, and so may stay open for a long time
+func isStream(route string) bool {
+	switch route {
+	case routes.Run, routes.Attach, routes.AttachAll:
+		return true
+	default:
+		return streamingRoutes[route]
+	}
+}
+
+func remoteHost(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// SetReconnectTokens has the server give each process it runs a token, signed
+// with the key and valid for the TTL, with which clients may resume its
+// streams by attaching, e.g. after losing them to the server restarting. Its
+// retained output, if any, is replayed from where the client left off; see
+// SetLogRetention. Servers sharing the key, e.g. one handed off to, accept
+// each other's tokens. Tokens are not given out by default. It must be
+// called before Start.
+func (s *GardenServer) SetReconnectTokens(key []byte, ttl time.Duration) {
+	s.reconnectKey = key
+	s.reconnectTTL = ttl
+	s.reconnectInstance = newRequestID()
+}
+
+// reconnectClaims are what a reconnect token vouches for: the process, and
+// the position in its container's log from which its output was retained by
+// the server instance which ran it.
+type reconnectClaims struct {
+	Handle    string `json:"handle"`
+	ProcessID uint32 `json:"process_id"`
+	Instance  string `json:"instance"`
+	Offset    uint64 `json:"offset"`
+	Expires   int64  `json:"expires"`
+}
+
+// reconnectToken signs the claims, returning "" if tokens are not given out.
+func (s *GardenServer) reconnectToken(handle string, processID uint32, offset uint64) string {
+	if s.reconnectKey == nil {
+		return ""
+	}
+
+	claims, err := json.Marshal(reconnectClaims{
+		Handle:    handle,
+		ProcessID: processID,
+		Instance:  s.reconnectInstance,
+		Offset:    offset,
+		Expires:   time.Now().Add(s.reconnectTTL).Unix(),
+	})
+	if err != nil {
+		return ""
+	}
+
+	encoded := base64.URLEncoding.EncodeToString(claims)
+
+	return encoded + "." + base64.URLEncoding.EncodeToString(s.reconnectSignature(encoded))
+}
+
+// verifyReconnectToken checks the token was signed by a server sharing the
+// key, has not expired, and is for the process, failing with
+// api.ErrPermissionDenied otherwise.
+func (s *GardenServer) verifyReconnectToken(token string, handle string, processID uint32) (reconnectClaims, error) {
+	var claims reconnectClaims
+
+	if s.reconnectKey == nil {
+		return claims, api.ErrUnsupportedOperation
+	}
+
+	dot := strings.Index(token, ".")
+	if dot == -1 {
+		return claims, api.ErrPermissionDenied
+	}
+
+	signature, err := base64.URLEncoding.DecodeString(token[dot+1:])
+	if err != nil || !hmac.Equal(signature, s.reconnectSignature(token[:dot])) {
+		return claims, api.ErrPermissionDenied
+	}
+
+	encoded, err := base64.URLEncoding.DecodeString(token[:dot])
+	if err != nil {
+		return claims, api.ErrPermissionDenied
+	}
+
+	err = json.Unmarshal(encoded, &claims)
+	if err != nil {
+		return claims, api.ErrPermissionDenied
+	}
+
+	if claims.Handle != handle || claims.ProcessID != processID || time.Now().Unix() > claims.Expires {
+		return claims, api.ErrPermissionDenied
+	}
+
+	return claims, nil
+}
+
+// reconnectLog returns the container's log to replay the process's output
+// from, or nil if its output is not retained, including when another server
+// instance, e.g. this one before restarting, ran it.
+func (s *GardenServer) reconnectLog(handle string, claims reconnectClaims) *containerLog {
+	if !s.processLogs.enabled() || claims.Instance != s.reconnectInstance {
+		return nil
+	}
+
+	return s.processLogs.log(handle)
+}
+
+func (s *GardenServer) reconnectSignature(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.reconnectKey)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// reconnectIO connects a process being reconnected to. Given its container's
+// retained output, the backend's output is discarded, and the process's is
+// instead replayed from the log, from the token's offset on, less what the
+// client already has, following it until the process exits. Otherwise only
+// output from now on is streamed, as for any attach.
+func reconnectIO(log *containerLog, claims reconnectClaims, request apitypes.AttachRequest, stdout, stderr chan<- []byte) (api.ProcessIO, *outputReplay) {
+	if log == nil {
+		return api.ProcessIO{
+			Stdout: outputWriter(stdout, request.GetDiscardStdout()),
+			Stderr: outputWriter(stderr, request.GetDiscardStderr()),
+		}, nil
+	}
+
+	replay := &outputReplay{
+		log:       log,
+		processID: claims.ProcessID,
+		seq:       claims.Offset,
+
+		skipStdout: request.GetStdoutOffset(),
+		skipStderr: request.GetStderrOffset(),
+
+		stdout: stdout,
+		stderr: stderr,
+
+		discardStdout: request.GetDiscardStdout(),
+		discardStderr: request.GetDiscardStderr(),
+
+		exited:  make(chan struct{}),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	return api.ProcessIO{
+		Stdout: ioutil.Discard,
+		Stderr: ioutil.Discard,
+	}, replay
+}
+
+// outputReplay feeds a process's retained output to its stream's channels.
+type outputReplay struct {
+	log       *containerLog
+	processID uint32
+	seq       uint64
+
+	skipStdout uint64
+	skipStderr uint64
+
+	stdout chan<- []byte
+	stderr chan<- []byte
+
+	discardStdout bool
+	discardStderr bool
+
+	// exited is closed once the process has exited, for the replay to catch
+	// up and then finish, closing done
+	exited     chan struct{}
+	exitedOnce sync.Once
+	done       chan struct{}
+
+	// stopped is closed once the stream is no longer read
+	stopped chan struct{}
+}
+
+func (r *outputReplay) run() {
+	defer close(r.done)
+
+	exited := false
+
+	for {
+		entries, next, written := r.log.since(r.seq)
+
+		for _, entry := range entries {
+			if entry.processID != r.processID {
+				continue
+			}
+
+			if !r.send(entry.source, entry.data) {
+				return
+			}
+		}
+
+		r.seq = next
+
+		if exited {
+			return
+		}
+
+		select {
+		case <-written:
+		case <-r.exited:
+			// the process's last output is in the log by the time it exits
+			exited = true
+		case <-r.log.forgotten:
+			return
+		case <-r.stopped:
+			return
+		}
+	}
+}
+
+// send sends the part of the data the client does not have, returning false
+// once the stream is no longer read.
+func (r *outputReplay) send(source apitypes.ProcessPayload_Source, data []byte) bool {
+	ch, skip, discard := r.stdout, &r.skipStdout, r.discardStdout
+	if source == apitypes.ProcessPayload_stderr {
+		ch, skip, discard = r.stderr, &r.skipStderr, r.discardStderr
+	}
+
+	if *skip >= uint64(len(data)) {
+		*skip -= uint64(len(data))
+		return true
+	}
+
+	data = data[*skip:]
+	*skip = 0
+
+	if discard {
+		return true
+	}
+
+	select {
+	case ch <- data:
+		return true
+	case <-r.stopped:
+		return false
+	}
+}
+
+// finish waits for the replay to catch up with the exited process.
+func (r *outputReplay) finish() {
+	r.exitedOnce.Do(func() { close(r.exited) })
+	<-r.done
+}
+
+// replayedProcess is a process whose output is replayed, which has not
+// exited, as far as its stream is concerned, until the replay has caught up.
+type replayedProcess struct {
+	api.Process
+
+	replay *outputReplay
+}
+
+func (p *replayedProcess) Wait() (int, error) {
+	status, err := p.Process.Wait()
+	p.replay.finish()
+	return status, err
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// redacted replaces secrets, such as registry passwords, in what the server
+// logs and audits
+const redacted = "[REDACTED]"
+
+// secretFields are the fields of JSON requests whose values are redacted,
+// wherever they are nested
+var secretFields = map[string]bool{
+	"password": true,
+}
+
+// redactCreateRequest returns a copy of the request to log, without the
+// credentials of its image.
+func redactCreateRequest(request apitypes.CreateRequest) apitypes.CreateRequest {
+	if request.Image == nil || request.Image.Password == nil {
+		return request
+	}
+
+	image := *request.Image
+	image.Password = apitypes.String(redacted)
+
+	request.Image = &image
+
+	return request
+}
+
+// redactArguments returns the JSON body of a request with the values of its
+// secret fields redacted. Bodies which are not JSON are returned as nil.
+func redactArguments(body []byte) json.RawMessage {
+	var arguments interface{}
+	if json.Unmarshal(body, &arguments) != nil {
+		return nil
+	}
+
+	if !redactSecrets(arguments) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(arguments)
+	if err != nil {
+		return nil
+	}
+
+	return redactedBody
+}
+
+// redactSecrets redacts the secret fields of a decoded JSON value in place,
+// returning whether there were any.
+func redactSecrets(value interface{}) bool {
+	found := false
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if secretFields[name] {
+				v[name] = redacted
+				found = true
+				continue
+			}
+
+			if redactSecrets(field) {
+				found = true
+			}
+		}
+
+	case []interface{}:
+		for _, element := range v {
+			if redactSecrets(element) {
+				found = true
+			}
+		}
+	}
+
+	return found
+}
@@ -1,29 +1,42 @@
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/gogo/protobuf/proto"
-
 	"github.com/cloudfoundry-incubator/garden/api"
-	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/server/tarcheck"
+	"github.com/cloudfoundry-incubator/garden/server/timebomb"
 	"github.com/cloudfoundry-incubator/garden/transport"
 	"github.com/pivotal-golang/lager"
 )
 
+// ErrInvalidContentType keeps the message it had when JSON was the only format
+// the server decoded, as clients match on it.
 var ErrInvalidContentType = errors.New("content-type must be application/json")
-var ErrConcurrentDestroy = errors.New("container already being destroyed")
+
+// ErrConcurrentDestroy is api.ErrDestroyInProgress, kept for existing callers.
+var ErrConcurrentDestroy = api.ErrDestroyInProgress
+
+var ErrMissingDestroyTime = errors.New("either a time or a delay to destroy after must be given")
+
+// StdinWindow is the number of stdin payloads a client may have in flight to a
+// process before it must wait for the server to acknowledge them.
+const StdinWindow = 16
 
 func (s *GardenServer) handlePing(w http.ResponseWriter, r *http.Request) {
-	hLog := s.logger.Session("ping")
+	hLog := s.session(r, "ping")
 
 	err := s.backend.Ping()
 	if err != nil {
@@ -32,33 +45,105 @@ func (s *GardenServer) handlePing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.writeResponse(w, &protocol.PingResponse{})
+	s.writeResponse(w, &apitypes.PingResponse{})
+}
+
+func (s *GardenServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	_, renames := s.backend.(api.Renamer)
+
+	capabilities := []string{}
+	for _, capability := range api.Capabilities {
+		if capability == api.CapabilityRename && !renames {
+			continue
+		}
+
+		capabilities = append(capabilities, string(capability))
+	}
+
+	s.writeResponse(w, &apitypes.VersionResponse{
+		ProtocolVersion: apitypes.Uint32(api.ProtocolVersion),
+		Capabilities:    capabilities,
+	})
+}
+
+func (s *GardenServer) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r, "health-check")
+
+	res := &apitypes.HealthCheckResponse{
+		Uptime:          apitypes.Uint64(uint64(time.Since(s.startedAt) / time.Second)),
+		ProtocolVersion: apitypes.Uint32(api.ProtocolVersion),
+	}
+
+	err := s.checkBackendHealth(res)
+	if err != nil {
+		hLog.Error("unhealthy", err)
+
+		res.Healthy = apitypes.Bool(false)
+		res.BackendError = apitypes.String(err.Error())
+
+		// the status lets load balancers act without decoding the body
+		w.Header().Set("Content-Type", s.responseCodec(w).ContentType())
+		s.encode(w, http.StatusServiceUnavailable, res)
+		return
+	}
+
+	res.Healthy = apitypes.Bool(true)
+
+	s.writeResponse(w, res)
+}
+
+func (s *GardenServer) checkBackendHealth(res *apitypes.HealthCheckResponse) error {
+	err := s.backend.Ping()
+	if err != nil {
+		return err
+	}
+
+	containers, err := s.backend.Containers(nil)
+	if err != nil {
+		return err
+	}
+
+	capacity, err := s.capacityCache.get(false)
+	if err != nil {
+		return err
+	}
+
+	count := uint64(len(containers))
+
+	res.Containers = apitypes.Uint64(count)
+	res.MaxContainers = apitypes.Uint64(capacity.MaxContainers)
+
+	if capacity.MaxContainers > count {
+		res.ContainerHeadroom = apitypes.Uint64(capacity.MaxContainers - count)
+	}
+
+	return nil
 }
 
 func (s *GardenServer) handleCapacity(w http.ResponseWriter, r *http.Request) {
-	hLog := s.logger.Session("capacity")
+	hLog := s.session(r, "capacity")
 
-	capacity, err := s.backend.Capacity()
+	capacity, err := s.capacityCache.get(r.URL.Query().Get("fresh") == "true")
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.writeResponse(w, &protocol.CapacityResponse{
-		MemoryInBytes: proto.Uint64(capacity.MemoryInBytes),
-		DiskInBytes:   proto.Uint64(capacity.DiskInBytes),
-		MaxContainers: proto.Uint64(capacity.MaxContainers),
+	s.writeResponse(w, &apitypes.CapacityResponse{
+		MemoryInBytes: apitypes.Uint64(capacity.MemoryInBytes),
+		DiskInBytes:   apitypes.Uint64(capacity.DiskInBytes),
+		MaxContainers: apitypes.Uint64(capacity.MaxContainers),
 	})
 }
 
 func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
-	var request protocol.CreateRequest
+	var request apitypes.CreateRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	hLog := s.logger.Session("create", lager.Data{
-		"request": request,
+	hLog := s.session(r, "create", lager.Data{
+		"request": redactCreateRequest(request),
 	})
 
 	bindMounts := []api.BindMount{}
@@ -80,15 +165,18 @@ func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
 		properties[prop.GetKey()] = prop.GetValue()
 	}
 
-	graceTime := s.containerGraceTime
+	graceTime := s.defaultGraceTime()
 
 	if request.GraceTime != nil {
-		graceTime = time.Duration(request.GetGraceTime()) * time.Second
+		var err error
+		graceTime, err = s.limitGraceTime(w, time.Duration(request.GetGraceTime())*time.Second)
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
 	}
 
-	hLog.Debug("creating")
-
-	container, err := s.backend.Create(api.ContainerSpec{
+	spec := api.ContainerSpec{
 		Handle:     request.GetHandle(),
 		GraceTime:  graceTime,
 		RootFSPath: request.GetRootfs(),
@@ -97,301 +185,495 @@ func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
 		Properties: properties,
 		Env:        convertEnv(request.GetEnv()),
 		Privileged: request.GetPrivileged(),
-	})
+
+		DefaultProcessUser: request.GetDefaultProcessUser(),
+
+		Image: api.ImageRef{
+			URI:      request.GetImage().GetUri(),
+			Username: request.GetImage().GetUsername(),
+			Password: request.GetImage().GetPassword(),
+		},
+
+		ExternalIP:        request.GetExternalIp(),
+		ExternalInterface: request.GetExternalInterface(),
+	}
+
+	err := s.allowContainer(r, spec)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("created")
-
-	s.bomberman.Strap(container)
+	if s.enforceCapacity {
+		err = s.admitCreate()
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
 
-	s.writeResponse(w, &protocol.CreateResponse{
-		Handle: proto.String(container.Handle()),
-	})
-}
+		defer s.finishCreate()
+	}
 
-func (s *GardenServer) handleList(w http.ResponseWriter, r *http.Request) {
-	properties := api.Properties{}
-	for name, vals := range r.URL.Query() {
-		if len(vals) > 0 {
-			properties[name] = vals[0]
+	if spec.Handle == "" {
+		spec.Handle, err = s.handles.generate(s.backend)
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
 		}
+
+		defer s.handles.release(spec.Handle)
 	}
 
-	hLog := s.logger.Session("list", lager.Data{
-		"properties": properties,
-	})
+	hLog.Debug("creating")
 
-	containers, err := s.backend.Containers(properties)
+	container, err := s.backend.Create(spec)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	handles := []string{}
+	hLog.Info("created")
+
+	s.containerAdded(container, spec)
 
-	for _, container := range containers {
-		handles = append(handles, container.Handle())
+	response := &apitypes.CreateResponse{
+		Handle: apitypes.String(container.Handle()),
 	}
 
-	s.writeResponse(w, &protocol.ListResponse{Handles: handles})
+	info, err := container.Info()
+	if err != nil {
+		// the container exists regardless; the client fetches its info later
+		hLog.Error("failed-to-get-info", err)
+	} else {
+		response.Info = s.infoResponse(container, info, s.bomberman.Status(container.Handle()))
+	}
+
+	s.writeResponse(w, response)
 }
 
-func (s *GardenServer) handleDestroy(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+// admitCreate reserves room for a container being created, counting creates
+// which are still in flight so that concurrent requests cannot overshoot the
+// backend's capacity.
+func (s *GardenServer) admitCreate() error {
+	capacity, err := s.backend.Capacity()
+	if err != nil {
+		return err
+	}
 
-	hLog := s.logger.Session("destroy", lager.Data{
-		"handle": handle,
-	})
+	s.creatingL.Lock()
+	defer s.creatingL.Unlock()
 
-	s.destroysL.Lock()
+	// backends which cannot say how many containers they fit are unlimited
+	if capacity.MaxContainers != 0 {
+		containers, err := s.backend.Containers(nil)
+		if err != nil {
+			return err
+		}
 
-	_, alreadyDestroying := s.destroys[handle]
-	if !alreadyDestroying {
-		s.destroys[handle] = struct{}{}
+		if uint64(len(containers)+s.creating) >= capacity.MaxContainers {
+			return api.ErrCapacityExceeded
+		}
 	}
 
-	s.destroysL.Unlock()
+	s.creating++
 
-	if alreadyDestroying {
-		s.writeError(w, ErrConcurrentDestroy, hLog)
-		return
+	return nil
+}
+
+func (s *GardenServer) finishCreate() {
+	s.creatingL.Lock()
+	s.creating--
+	s.creatingL.Unlock()
+}
+
+// containerAdded starts keeping track of a container the server has brought
+// into being, whether by creating it or restoring it from a snapshot.
+func (s *GardenServer) containerAdded(container api.Container, spec api.ContainerSpec) {
+	s.bomberman.Strap(container)
+
+	s.processDefaults.set(container.Handle(), spec)
+
+	s.containerCreated(container.Handle(), spec)
+}
+
+func (s *GardenServer) handleList(w http.ResponseWriter, r *http.Request) {
+	verbose := r.Header.Get(transport.ListVerboseHeader) == "true"
+	order := api.ListOrder(r.Header.Get(transport.ListSortHeader))
+	stream := r.Header.Get(transport.ListStreamHeader) == "true"
+
+	if order == "" {
+		order = api.ListOrderHandle
 	}
 
-	hLog.Debug("destroying")
+	properties, filters := propertyFilters(r.URL.Query())
 
-	err := s.backend.Destroy(handle)
+	hLog := s.session(r, "list", lager.Data{
+		"properties": properties,
+		"filters":    filters,
+		"verbose":    verbose,
+		"sort":       order,
+		"stream":     stream,
+	})
 
-	if !alreadyDestroying {
-		s.destroysL.Lock()
-		delete(s.destroys, handle)
-		s.destroysL.Unlock()
+	if !validListOrder(order) {
+		s.writeError(w, api.InvalidRequestError{
+			Violations: []api.ValidationError{
+				{Field: transport.ListSortHeader, Reason: "must be handle or created"},
+			},
+		}, hLog)
+		return
 	}
 
+	containers, err := s.backend.Containers(properties)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("destroyed")
+	// the backend only matches properties exactly
+	if len(filters) > 0 {
+		matching := []api.Container{}
 
-	s.bomberman.Defuse(handle)
+		for _, container := range containers {
+			containerProperties, err := container.Properties()
+			if err != nil {
+				s.writeContainerError(w, container, err, hLog)
+				return
+			}
 
-	s.writeResponse(w, &protocol.DestroyResponse{})
-}
+			if api.MatchesAll(filters, containerProperties) {
+				matching = append(matching, container)
+			}
+		}
 
-func (s *GardenServer) handleStop(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+		containers = matching
+	}
 
-	hLog := s.logger.Session("stop", lager.Data{
-		"handle": handle,
-	})
+	listed := make([]listedContainer, len(containers))
 
-	var request protocol.StopRequest
-	if !s.readRequest(&request, w, r) {
-		return
+	for i, container := range containers {
+		listed[i].container = container
+
+		if verbose || order == api.ListOrderCreated {
+			listed[i].info, err = container.Info()
+			if err != nil {
+				s.writeContainerError(w, container, err, hLog)
+				return
+			}
+		}
 	}
 
-	kill := request.GetKill()
+	sortListed(listed, order)
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
+	if stream {
+		s.streamListed(w, listed, verbose, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	handles := []string{}
 
-	hLog.Debug("stopping")
+	for _, l := range listed {
+		handles = append(handles, l.container.Handle())
+	}
 
-	err = container.Stop(kill)
-	if err != nil {
-		s.writeError(w, err, hLog)
+	if !verbose {
+		s.writeResponse(w, &apitypes.ListResponse{Handles: handles})
 		return
 	}
 
-	hLog.Info("stopped")
+	summaries := []*apitypes.ListResponse_Container{}
+
+	for _, l := range listed {
+		summaries = append(summaries, listedSummary(l))
+	}
 
-	s.writeResponse(w, &protocol.StopResponse{})
+	s.writeResponse(w, &apitypes.ListResponse{
+		Handles:    handles,
+		Containers: summaries,
+	})
 }
 
-func (s *GardenServer) handleStreamIn(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+// streamListed writes each container as a JSON message of its own, so that
+// clients listing very many containers can handle them as they arrive rather
+// than holding the whole listing.
+func (s *GardenServer) streamListed(w http.ResponseWriter, listed []listedContainer, verbose bool, logger lager.Logger) {
+	w.Header().Set("Content-Type", transport.ContentTypeJSON)
 
-	dstPath := r.URL.Query().Get("destination")
+	for _, l := range listed {
+		entry := &apitypes.ListResponse_Container{
+			Handle: apitypes.String(l.container.Handle()),
+		}
 
-	hLog := s.logger.Session("stream-in", lager.Data{
-		"handle":      handle,
-		"destination": dstPath,
+		if verbose {
+			entry = listedSummary(l)
+		}
+
+		err := transport.WriteMessage(w, entry)
+		if err != nil {
+			logger.Error("failed-to-stream", err)
+			return
+		}
+	}
+}
+
+func listedSummary(l listedContainer) *apitypes.ListResponse_Container {
+	containerProperties := []*apitypes.Property{}
+	for key, val := range l.info.Properties {
+		containerProperties = append(containerProperties, &apitypes.Property{
+			Key:   apitypes.String(key),
+			Value: apitypes.String(val),
+		})
+	}
+
+	return &apitypes.ListResponse_Container{
+		Handle:     apitypes.String(l.container.Handle()),
+		State:      apitypes.String(l.info.State),
+		Properties: containerProperties,
+	}
+}
+
+func (s *GardenServer) handleDestroy(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	force := r.FormValue("force") == "true"
+	async := r.FormValue("async") == "true"
+	reason := r.FormValue("reason")
+
+	hLog := s.session(r, "destroy", lager.Data{
+		"handle": handle,
+		"force":  force,
+		"async":  async,
+		"reason": reason,
 	})
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
+	if !s.destroyConfirmed(r, handle) {
+		s.writeError(w, api.ErrDestroyNotConfirmed, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	operationID, started := s.destroyOperations.start(handle)
+	if !started {
+		if async {
+			hLog.Info("already-destroying", lager.Data{
+				"operation": operationID,
+			})
 
-	hLog.Debug("streaming-in")
+			s.writeResponse(w, &apitypes.DestroyResponse{
+				OperationId: apitypes.String(operationID),
+			})
 
-	err = container.StreamIn(dstPath, r.Body)
-	if err != nil {
-		s.writeError(w, err, hLog)
+			return
+		}
+
+		s.writeError(w, ErrConcurrentDestroy, hLog)
 		return
 	}
 
-	hLog.Info("streamed-in")
+	var container api.Container
+	if force || len(s.errorAnnotationKeys) > 0 {
+		container, _ = s.backend.Lookup(handle)
+	}
 
-	s.writeResponse(w, &protocol.StreamInResponse{})
-}
+	var annotations []*apitypes.Property
+	if container != nil {
+		annotations = s.errorAnnotations(container)
+	}
 
-func (s *GardenServer) handleStreamOut(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+	if async {
+		go func() {
+			err := s.destroy(hLog, handle, container, force)
+			if err != nil {
+				hLog.Error("failed", err)
+			}
 
-	srcPath := r.URL.Query().Get("source")
+			s.destroyOperations.finish(operationID, err, annotations)
+		}()
 
-	hLog := s.logger.Session("stream-out", lager.Data{
-		"handle": handle,
-		"source": srcPath,
-	})
+		s.writeResponse(w, &apitypes.DestroyResponse{
+			OperationId: apitypes.String(operationID),
+		})
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	err := s.destroy(hLog, handle, container, force)
 
-	hLog.Debug("streaming-out")
+	s.destroyOperations.finish(operationID, err, annotations)
 
-	reader, err := container.StreamOut(srcPath)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeErrorResponse(w, err, annotations, hLog)
 		return
 	}
 
-	n, err := io.Copy(w, reader)
-	if err != nil {
-		if err := reader.Close(); err != nil {
-			hLog.Error("failed-to-close", err)
-		}
+	s.writeResponse(w, &apitypes.DestroyResponse{
+		OperationId: apitypes.String(operationID),
+	})
+}
 
-		if n == 0 {
-			s.writeError(w, err, hLog)
+// destroy destroys the container. Forced destroys kill its processes first,
+// rather than leaving the backend to wait for them to stop.
+func (s *GardenServer) destroy(logger lager.Logger, handle string, container api.Container, force bool) error {
+	if force && container != nil {
+		logger.Debug("killing")
+
+		err := container.Stop(true)
+		if err != nil {
+			logger.Error("failed-to-kill", err)
 		}
+	}
 
-		return
+	logger.Debug("destroying")
+
+	err := s.backend.Destroy(handle)
+	if err != nil {
+		return err
 	}
 
-	hLog.Info("streamed-out")
+	logger.Info("destroyed")
+
+	s.bomberman.Defuse(handle)
+	s.forgetContainerState(handle)
+
+	s.containerDestroyed(handle)
+
+	return nil
 }
 
-func (s *GardenServer) handleLimitBandwidth(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+func (s *GardenServer) handleDestroyOperation(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue(":id")
 
-	var request protocol.LimitBandwidthRequest
-	if !s.readRequest(&request, w, r) {
+	hLog := s.session(r, "destroy-operation", lager.Data{
+		"id": id,
+	})
+
+	operation, found := s.destroyOperations.lookup(id)
+	if !found {
+		s.writeError(w, api.ErrOperationNotFound, hLog)
 		return
 	}
 
-	hLog := s.logger.Session("limit-bandwidth", lager.Data{
+	res := &apitypes.DestroyOperationResponse{
+		Id:     apitypes.String(operation.id),
+		Handle: apitypes.String(operation.handle),
+		State:  apitypes.String(string(operation.state)),
+	}
+
+	if operation.err != nil {
+		res.Error, _ = errorResponse(operation.err, operation.annotations)
+	}
+
+	s.writeResponse(w, res)
+}
+
+func (s *GardenServer) handleRename(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "rename", lager.Data{
 		"handle": handle,
 	})
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
+	var request apitypes.RenameRequest
+	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	newHandle := request.GetNewHandle()
 
-	requestedLimits := api.BandwidthLimits{
-		RateInBytesPerSecond:      request.GetRate(),
-		BurstRateInBytesPerSecond: request.GetBurst(),
-	}
+	// pauses are unpaused under the handle they were paused under, whichever
+	// the container ends up with
+	s.bomberman.Pause(handle)
+	defer s.bomberman.Unpause(handle)
 
-	hLog.Debug("limiting", lager.Data{
-		"requested-limits": requestedLimits,
+	hLog.Debug("renaming", lager.Data{
+		"new-handle": newHandle,
 	})
 
-	err = container.LimitBandwidth(requestedLimits)
+	renamer, ok := s.backend.(api.Renamer)
+	if !ok {
+		s.writeError(w, api.ErrUnsupportedOperation, hLog)
+		return
+	}
+
+	err := renamer.Rename(handle, newHandle)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	limits, err := container.CurrentBandwidthLimits()
+	container, err := s.backend.Lookup(newHandle)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	hLog.Info("limited", lager.Data{
-		"resulting-limits": limits,
-	})
+	s.bomberman.Rename(handle, container)
+	s.renameContainerState(handle, newHandle)
 
-	s.writeResponse(w, &protocol.LimitBandwidthResponse{
-		Rate:  proto.Uint64(limits.RateInBytesPerSecond),
-		Burst: proto.Uint64(limits.BurstRateInBytesPerSecond),
+	hLog.Info("renamed", lager.Data{
+		"new-handle": newHandle,
 	})
-}
 
-func (s *GardenServer) handleCurrentBandwidthLimits(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+	s.writeResponse(w, &apitypes.RenameResponse{})
+}
 
-	hLog := s.logger.Session("current-bandwidth-limits", lager.Data{
-		"handle": handle,
+func (s *GardenServer) handleLookupBy(w http.ResponseWriter, r *http.Request) {
+	properties := api.Properties{}
+	for name, vals := range r.URL.Query() {
+		if len(vals) > 0 {
+			properties[name] = vals[0]
+		}
+	}
+
+	hLog := s.session(r, "lookup-by", lager.Data{
+		"properties": properties,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	// backends filter by properties already, so the server finds the single
+	// match itself rather than asking them for one
+	containers, err := s.backend.Containers(properties)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	if len(containers) == 0 {
+		s.writeError(w, api.ErrContainerNotFound, hLog)
+		return
+	}
 
-	hLog.Debug("getting")
+	if len(containers) > 1 {
+		hLog.Info("ambiguous", lager.Data{
+			"matches": len(containers),
+		})
 
-	limits, err := container.CurrentBandwidthLimits()
-	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, api.ErrMultipleContainersMatch, hLog)
 		return
 	}
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
+	container := containers[0]
+
+	hLog.Info("found", lager.Data{
+		"handle": container.Handle(),
 	})
 
-	s.writeResponse(w, &protocol.LimitBandwidthResponse{
-		Rate:  proto.Uint64(limits.RateInBytesPerSecond),
-		Burst: proto.Uint64(limits.BurstRateInBytesPerSecond),
+	s.writeResponse(w, &apitypes.LookupResponse{
+		Handle: apitypes.String(container.Handle()),
 	})
 }
 
-func (s *GardenServer) handleLimitMemory(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleStop(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("limit-memory", lager.Data{
+	hLog := s.session(r, "stop", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.LimitMemoryRequest
+	var request apitypes.StopRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	limitInBytes := request.GetLimitInBytes()
+	kill := request.GetKill()
+	reason := request.GetReason()
 
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
@@ -402,45 +684,45 @@ func (s *GardenServer) handleLimitMemory(w http.ResponseWriter, r *http.Request)
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	requestedLimits := api.MemoryLimits{
-		LimitInBytes: limitInBytes,
-	}
-
-	if request.LimitInBytes != nil {
-		hLog.Debug("limiting", lager.Data{
-			"requested-limits": requestedLimits,
-		})
-
-		err = container.LimitMemory(requestedLimits)
-
-		if err != nil {
-			s.writeError(w, err, hLog)
-			return
-		}
-	}
+	hLog.Debug("stopping", lager.Data{
+		"reason": reason,
+	})
 
-	limits, err := container.CurrentMemoryLimits()
+	err = container.Stop(kill)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
-	hLog.Info("limited", lager.Data{
-		"resulting-limits": limits,
-	})
+	hLog.Info("stopped")
 
-	s.writeResponse(w, &protocol.LimitMemoryResponse{
-		LimitInBytes: proto.Uint64(limits.LimitInBytes),
-	})
+	if reason != "" {
+		recordStopReason(container, reason, hLog)
+	}
+
+	s.writeResponse(w, &apitypes.StopResponse{})
 }
 
-func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleSignalAll(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-memory-limits", lager.Data{
+	hLog := s.session(r, "signal-all", lager.Data{
 		"handle": handle,
 	})
 
+	var request apitypes.SignalAllRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	signal := request.GetSignal()
+
+	// refuse signals from newer clients rather than sending the wrong one
+	if _, known := apitypes.SignalAllRequest_Signal_name[int32(signal)]; !known {
+		s.writeError(w, fmt.Errorf("unknown signal: %d", signal), hLog)
+		return
+	}
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -450,50 +732,33 @@ func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	hLog.Debug("signalling", lager.Data{
+		"signal": signal.String(),
+	})
 
-	limits, err := container.CurrentMemoryLimits()
+	err = container.SignalAll(api.Signal(signal))
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
-	})
+	hLog.Info("signalled")
 
-	s.writeResponse(w, &protocol.LimitMemoryResponse{
-		LimitInBytes: proto.Uint64(limits.LimitInBytes),
-	})
+	s.writeResponse(w, &apitypes.SignalAllResponse{})
 }
 
-func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handlePause(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("limit-disk", lager.Data{
+	hLog := s.session(r, "pause", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.LimitDiskRequest
+	var request apitypes.PauseRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	blockSoft := request.GetBlockSoft()
-	blockHard := request.GetBlockHard()
-	inodeSoft := request.GetInodeSoft()
-	inodeHard := request.GetInodeHard()
-	byteSoft := request.GetByteSoft()
-	byteHard := request.GetByteHard()
-
-	settingLimit := false
-
-	if request.BlockSoft != nil || request.BlockHard != nil ||
-		request.InodeSoft != nil || request.InodeHard != nil ||
-		request.ByteSoft != nil || request.ByteHard != nil {
-		settingLimit = true
-	}
-
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -503,54 +768,31 @@ func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	requestedLimits := api.DiskLimits{
-		BlockSoft: blockSoft,
-		BlockHard: blockHard,
-		InodeSoft: inodeSoft,
-		InodeHard: inodeHard,
-		ByteSoft:  byteSoft,
-		ByteHard:  byteHard,
-	}
-
-	if settingLimit {
-		hLog.Debug("limiting", lager.Data{
-			"requested-limits": requestedLimits,
-		})
-
-		err = container.LimitDisk(requestedLimits)
-		if err != nil {
-			s.writeError(w, err, hLog)
-			return
-		}
-	}
+	hLog.Debug("pausing")
 
-	limits, err := container.CurrentDiskLimits()
+	err = container.Pause()
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
-	hLog.Info("limited", lager.Data{
-		"resulting-limits": limits,
-	})
+	hLog.Info("paused")
 
-	s.writeResponse(w, &protocol.LimitDiskResponse{
-		BlockSoft: proto.Uint64(limits.BlockSoft),
-		BlockHard: proto.Uint64(limits.BlockHard),
-		InodeSoft: proto.Uint64(limits.InodeSoft),
-		InodeHard: proto.Uint64(limits.InodeHard),
-		ByteSoft:  proto.Uint64(limits.ByteSoft),
-		ByteHard:  proto.Uint64(limits.ByteHard),
-	})
+	s.writeResponse(w, &apitypes.PauseResponse{})
 }
 
-func (s *GardenServer) handleCurrentDiskLimits(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleResume(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-disk-limits", lager.Data{
+	hLog := s.session(r, "resume", lager.Data{
 		"handle": handle,
 	})
 
+	var request apitypes.ResumeRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -560,41 +802,45 @@ func (s *GardenServer) handleCurrentDiskLimits(w http.ResponseWriter, r *http.Re
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	hLog.Debug("resuming")
 
-	limits, err := container.CurrentDiskLimits()
+	err = container.Resume()
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
-	})
+	hLog.Info("resumed")
 
-	s.writeResponse(w, &protocol.LimitDiskResponse{
-		BlockSoft: proto.Uint64(limits.BlockSoft),
-		BlockHard: proto.Uint64(limits.BlockHard),
-		InodeSoft: proto.Uint64(limits.InodeSoft),
-		InodeHard: proto.Uint64(limits.InodeHard),
-		ByteSoft:  proto.Uint64(limits.ByteSoft),
-		ByteHard:  proto.Uint64(limits.ByteHard),
-	})
+	s.writeResponse(w, &apitypes.ResumeResponse{})
 }
 
-func (s *GardenServer) handleLimitCPU(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleScheduleDestroy(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("limit-cpu", lager.Data{
+	hLog := s.session(r, "schedule-destroy", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.LimitCpuRequest
+	if !s.destroyConfirmed(r, handle) {
+		s.writeError(w, api.ErrDestroyNotConfirmed, hLog)
+		return
+	}
+
+	var request apitypes.ScheduleDestroyRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	limitInShares := request.GetLimitInShares()
+	var at time.Time
+	if request.At != nil {
+		at = time.Unix(request.GetAt(), 0)
+	} else if request.After != nil {
+		at = time.Now().Add(time.Duration(request.GetAfter()) * time.Second)
+	} else {
+		s.writeError(w, ErrMissingDestroyTime, hLog)
+		return
+	}
 
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
@@ -605,41 +851,19 @@ func (s *GardenServer) handleLimitCPU(w http.ResponseWriter, r *http.Request) {
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	requestedLimits := api.CPULimits{
-		LimitInShares: limitInShares,
-	}
-
-	if request.LimitInShares != nil {
-		hLog.Debug("limiting", lager.Data{
-			"requested-limits": requestedLimits,
-		})
-
-		err = container.LimitCPU(requestedLimits)
-		if err != nil {
-			s.writeError(w, err, hLog)
-			return
-		}
-	}
-
-	limits, err := container.CurrentCPULimits()
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
-	}
+	s.bomberman.Schedule(container, at)
 
-	hLog.Info("limited", lager.Data{
-		"resulting-limits": limits,
+	hLog.Info("scheduled", lager.Data{
+		"at": at,
 	})
 
-	s.writeResponse(w, &protocol.LimitCpuResponse{
-		LimitInShares: proto.Uint64(limits.LimitInShares),
-	})
+	s.writeResponse(w, &apitypes.ScheduleDestroyResponse{})
 }
 
-func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleCancelScheduledDestroy(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-cpu-limits", lager.Data{
+	hLog := s.session(r, "cancel-scheduled-destroy", lager.Data{
 		"handle": handle,
 	})
 
@@ -652,112 +876,58 @@ func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Req
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
-
-	limits, err := container.CurrentCPULimits()
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
-	}
+	s.bomberman.Unschedule(container.Handle())
 
-	hLog.Info("got", lager.Data{
-		"limits": limits,
-	})
+	hLog.Info("cancelled")
 
-	s.writeResponse(w, &protocol.LimitCpuResponse{
-		LimitInShares: proto.Uint64(limits.LimitInShares),
-	})
+	s.writeResponse(w, &apitypes.CancelScheduledDestroyResponse{})
 }
 
-func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleSetHold(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("net-in", lager.Data{
+	hLog := s.session(r, "set-hold", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.NetInRequest
+	var request apitypes.SetHoldRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	hostPort := request.GetHostPort()
-	containerPort := request.GetContainerPort()
-
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
-
-	hLog.Debug("port-mapping", lager.Data{
-		"host-port":      hostPort,
-		"container-port": containerPort,
-	})
-
-	hostPort, containerPort, err = container.NetIn(hostPort, containerPort)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+	if request.GetHeld() {
+		s.bomberman.Hold(container.Handle())
+	} else {
+		s.bomberman.Release(container.Handle())
 	}
 
-	hLog.Info("port-mapped", lager.Data{
-		"host-port":      hostPort,
-		"container-port": containerPort,
-	})
-
-	s.writeResponse(w, &protocol.NetInResponse{
-		HostPort:      proto.Uint32(hostPort),
-		ContainerPort: proto.Uint32(containerPort),
+	hLog.Info("set", lager.Data{
+		"held": request.GetHeld(),
 	})
-}
 
-func validPortRange(portRange string) bool {
-	if portRange != "" {
-		r := strings.Split(portRange, ":")
-		if len(r) != 2 {
-			return false
-		}
-		lo, startErr := strconv.Atoi(r[0])
-		hi, endErr := strconv.Atoi(r[1])
-		return startErr == nil && endErr == nil && lo > 0 && lo <= 65535 && hi > 0 && hi <= 65535
-	}
-	return true
+	s.writeResponse(w, &apitypes.SetHoldResponse{})
 }
 
-func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleSetGraceTime(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("net-out", lager.Data{
+	hLog := s.session(r, "set-grace-time", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.NetOutRequest
+	var request apitypes.SetGraceTimeRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	var protoc api.Protocol
-	switch request.GetProtocol() {
-	case protocol.NetOutRequest_TCP:
-		protoc = api.ProtocolTCP
-	case protocol.NetOutRequest_ALL:
-		protoc = api.ProtocolAll
-	default:
-		err := fmt.Errorf("invalid protocol: %d", request.GetProtocol())
-		s.writeError(w, err, hLog)
-		return
-	}
-
-	network := request.GetNetwork()
-	port := request.GetPort()
-	portRange := request.GetPortRange()
-
-	if !validPortRange(portRange) {
-		err := fmt.Errorf("invalid port range: %q", portRange)
+	graceTime, err := s.limitGraceTime(w, time.Duration(request.GetGraceTime())*time.Second)
+	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
@@ -771,41 +941,38 @@ func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("allowing-out", lager.Data{
-		"network":   network,
-		"port":      port,
-		"portRange": portRange,
-		"protocol":  protoc,
+	hLog.Debug("setting", lager.Data{
+		"grace-time": graceTime.String(),
 	})
 
-	err = container.NetOut(network, port, portRange, protoc)
+	err = container.SetGraceTime(graceTime)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
-	hLog.Info("allowed", lager.Data{
-		"network": network,
-		"port":    port,
+	s.bomberman.SetGraceTime(container.Handle(), graceTime)
+
+	hLog.Info("set", lager.Data{
+		"grace-time": graceTime.String(),
 	})
 
-	s.writeResponse(w, &protocol.NetOutResponse{})
+	s.writeResponse(w, &apitypes.SetGraceTimeResponse{})
 }
 
-func (s *GardenServer) handleGetProperty(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleSnapshot(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("get-property", lager.Data{
+	hLog := s.session(r, "snapshot", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.GetPropertyRequest
-	if !s.readRequest(&request, w, r) {
+	snapshotter, ok := s.backend.(api.Snapshotter)
+	if !ok {
+		s.writeError(w, api.ErrUnsupportedOperation, hLog)
 		return
 	}
 
-	key := request.GetKey()
-
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -815,48 +982,1210 @@ func (s *GardenServer) handleGetProperty(w http.ResponseWriter, r *http.Request)
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("get-property", lager.Data{
-		"key": key,
-	})
+	hLog.Debug("snapshotting")
 
-	value, err := container.GetProperty(key)
+	reader, err := snapshotter.Snapshot(container.Handle())
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
-	hLog.Info("got-property", lager.Data{
-		"key":   key,
-		"value": value,
-	})
-
-	s.writeResponse(w, &protocol.GetPropertyResponse{
-		Value: proto.String(value),
-	})
-}
+	defer reader.Close()
 
-func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
-	key := r.FormValue(":key")
+	w.Header().Set("Content-Type", "application/octet-stream")
 
-	hLog := s.logger.Session("set-property", lager.Data{
-		"handle": handle,
-	})
+	n, err := io.Copy(w, reader)
+	if err != nil {
+		if n == 0 {
+			s.writeContainerError(w, container, err, hLog)
+		} else {
+			hLog.Error("failed-to-stream", err)
+		}
 
-	var request protocol.SetPropertyRequest
-	if !s.readRequest(&request, w, r) {
 		return
 	}
 
-	value := request.GetValue()
+	hLog.Info("snapshotted")
+}
 
-	container, err := s.backend.Lookup(handle)
-	if err != nil {
-		s.writeError(w, err, hLog)
+func (s *GardenServer) handleRestore(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r, "restore")
+
+	snapshotter, ok := s.backend.(api.Snapshotter)
+	if !ok {
+		s.writeError(w, api.ErrUnsupportedOperation, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
+	// restored containers count against the backend's capacity as created
+	// ones do
+	if s.enforceCapacity {
+		err := s.admitCreate()
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+
+		defer s.finishCreate()
+	}
+
+	hLog.Debug("restoring")
+
+	container, err := snapshotter.Restore(r.Body)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("restored", lager.Data{
+		"handle": container.Handle(),
+	})
+
+	s.containerAdded(container, s.restoredSpec(container, hLog))
+
+	s.writeResponse(w, &apitypes.RestoreResponse{
+		Handle: apitypes.String(container.Handle()),
+	})
+}
+
+// restoredSpec is the spec a restored container is tracked with, which is
+// what the backend restored of it, as the snapshot is opaque to the server.
+func (s *GardenServer) restoredSpec(container api.Container, logger lager.Logger) api.ContainerSpec {
+	properties, err := container.Properties()
+	if err != nil {
+		logger.Error("failed-to-get-properties", err)
+	}
+
+	return api.ContainerSpec{
+		Handle:     container.Handle(),
+		GraceTime:  s.backend.GraceTime(container),
+		Properties: properties,
+	}
+}
+
+func (s *GardenServer) handleStreamIn(w http.ResponseWriter, r *http.Request) {
+	s.streamIn(w, r, false)
+}
+
+func (s *GardenServer) handleStreamInAtomically(w http.ResponseWriter, r *http.Request) {
+	s.streamIn(w, r, true)
+}
+
+func (s *GardenServer) streamIn(w http.ResponseWriter, r *http.Request, atomic bool) {
+	handle := r.FormValue(":handle")
+
+	query := r.URL.Query()
+
+	dstPath := query.Get("destination")
+	user := query.Get("user")
+
+	hLog := s.session(r, "stream-in", lager.Data{
+		"handle":      handle,
+		"destination": dstPath,
+		"user":        user,
+		"atomic":      atomic,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	var expectedBytes uint64
+
+	if expected := r.Header.Get(transport.ExpectedSizeHeader); expected != "" {
+		expectedBytes, err = strconv.ParseUint(expected, 10, 64)
+		if err != nil {
+			s.writeContainerError(w, container, fmt.Errorf("invalid expected size: %s", expected), hLog)
+			return
+		}
+
+		if maxBytes := s.streamInLimits.MaxBytes; maxBytes != 0 && expectedBytes > maxBytes {
+			s.writeContainerError(w, container, api.StreamInLimitExceededError{LimitInBytes: maxBytes}, hLog)
+			return
+		}
+
+		quota, err := s.diskQuota(container)
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+
+		if !quota.FitsBytes(expectedBytes) {
+			s.writeContainerError(w, container, api.DiskQuotaExceededError{
+				ExpectedBytes:  expectedBytes,
+				RemainingBytes: quota.RemainingBytes(),
+			}, hLog)
+			return
+		}
+	}
+
+	tarStream, err := decompressedStream(r.Body, query.Get("compression"))
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	limitedStream := limitStreamIn(tarStream, s.streamInLimits)
+	defer limitedStream.Close()
+
+	hLog.Debug("streaming-in")
+
+	err = container.StreamIn(api.StreamInSpec{
+		Path:      dstPath,
+		TarStream: limitedStream,
+		User:      user,
+		Atomic:    atomic,
+
+		ExpectedSize: expectedBytes,
+	})
+
+	if exceeded := limitedStream.exceededLimit(); exceeded != nil {
+		err = exceeded
+	}
+
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("streamed-in")
+
+	s.writeResponse(w, &apitypes.StreamInResponse{})
+}
+
+func (s *GardenServer) handleVerifyStreamIn(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	dstPath := r.URL.Query().Get("destination")
+
+	hLog := s.session(r, "verify-stream-in", lager.Data{
+		"handle":      handle,
+		"destination": dstPath,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	quota, err := s.diskQuota(container)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Debug("verifying")
+
+	report := tarcheck.Check(r.Body, quota)
+
+	hLog.Info("verified", lager.Data{
+		"entries":  report.Entries,
+		"bytes":    report.Bytes,
+		"problems": len(report.Problems),
+	})
+
+	problems := make([]*apitypes.VerifyStreamInResponse_Problem, len(report.Problems))
+	for i, problem := range report.Problems {
+		problems[i] = &apitypes.VerifyStreamInResponse_Problem{
+			Path:   apitypes.String(problem.Path),
+			Reason: apitypes.String(problem.Reason),
+		}
+	}
+
+	s.writeResponse(w, &apitypes.VerifyStreamInResponse{
+		Entries:  apitypes.Uint64(report.Entries),
+		Bytes:    apitypes.Uint64(report.Bytes),
+		Problems: problems,
+	})
+}
+
+// decompressedStream undoes the compression named by a request to stream
+// files in, so that backends are only given uncompressed tar streams.
+func decompressedStream(body io.Reader, compression string) (io.Reader, error) {
+	switch compression {
+	case "":
+		return body, nil
+
+	case transport.CompressionGzip:
+		return gzip.NewReader(body)
+
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// diskQuota returns the container's hard disk limits and current usage.
+func (s *GardenServer) diskQuota(container api.Container) (tarcheck.Quota, error) {
+	limits, err := container.CurrentDiskLimits()
+	if err != nil {
+		return tarcheck.Quota{}, err
+	}
+
+	info, err := container.Info()
+	if err != nil {
+		return tarcheck.Quota{}, err
+	}
+
+	return tarcheck.Quota{
+		ByteLimit:  limits.ByteHard,
+		BytesUsed:  info.DiskStat.BytesUsed,
+		InodeLimit: limits.InodeHard,
+		InodesUsed: info.DiskStat.InodesUsed,
+	}, nil
+}
+
+func (s *GardenServer) handleStreamOut(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	query := r.URL.Query()
+
+	srcPath := query.Get("source")
+	user := query.Get("user")
+	compression := query.Get("compression")
+
+	hLog := s.session(r, "stream-out", lager.Data{
+		"handle": handle,
+		"source": srcPath,
+		"user":   user,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	if compression != "" && compression != transport.CompressionGzip {
+		s.writeContainerError(w, container, fmt.Errorf("unsupported compression: %s", compression), hLog)
+		return
+	}
+
+	// offsets are into the uncompressed archive, which clients of gzipped
+	// streams cannot count
+	if compression != "" && query.Get("offset") != "" {
+		s.writeContainerError(w, container, api.InvalidRequestError{
+			Violations: []api.ValidationError{{
+				Field:  "offset",
+				Reason: "must not be given for gzipped streams",
+			}},
+		}, hLog)
+		return
+	}
+
+	var offset uint64
+	if query.Get("offset") != "" {
+		offset, err = strconv.ParseUint(query.Get("offset"), 10, 64)
+		if err != nil {
+			s.writeContainerError(w, container, api.InvalidRequestError{
+				Violations: []api.ValidationError{{
+					Field:  "offset",
+					Reason: "must be a number of bytes",
+				}},
+			}, hLog)
+			return
+		}
+	}
+
+	hLog.Debug("streaming-out", lager.Data{
+		"offset": offset,
+	})
+
+	// cancelled once the client goes away, or the transfer has ended
+	cancel := make(chan struct{})
+	transferred := make(chan struct{})
+	defer close(transferred)
+
+	go func(gone <-chan bool) {
+		select {
+		case <-gone:
+		case <-transferred:
+		}
+
+		close(cancel)
+	}(closeNotify(w))
+
+	reader, err := container.StreamOut(api.StreamOutSpec{
+		Path:   srcPath,
+		User:   user,
+		Cancel: cancel,
+	})
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	defer reader.Close()
+
+	// the backend streams the whole archive, up to the offset of which the
+	// client already has; it is read and discarded, which costs as much as
+	// streaming it, but for sending it
+	if offset > 0 {
+		_, err := io.CopyN(ioutil.Discard, reader, int64(offset))
+		if err == io.EOF {
+			err = api.InvalidRequestError{
+				Violations: []api.ValidationError{{
+					Field:  "offset",
+					Reason: "must not be past the end of the archive",
+				}},
+			}
+		}
+
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+	}
+
+	var out io.Writer = w
+
+	var gzipWriter *lazyGzipWriter
+	if compression == transport.CompressionGzip {
+		gzipWriter = &lazyGzipWriter{w: w}
+		out = gzipWriter
+	}
+
+	n, err := io.Copy(out, reader)
+	if err != nil {
+		if n == 0 {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+
+		hLog.Error("failed-to-stream-out", err, lager.Data{
+			"offset": offset + uint64(n),
+		})
+
+		// the response is cut short, rather than ended, so that the client
+		// sees the stream fail rather than finish early, and may resume it
+		// from as much as it read
+		s.cutShort(w)
+		return
+	}
+
+	if gzipWriter != nil {
+		gzipWriter.Close()
+	}
+
+	hLog.Info("streamed-out")
+}
+
+// cutShort closes the response's connection without ending the response, so
+// that the client sees it fail rather than finish.
+func (s *GardenServer) cutShort(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	conn, _, err := s.hijack(w)
+	if err != nil {
+		return
+	}
+
+	conn.Close()
+}
+
+// lazyGzipWriter only starts gzipping once written to, so that a stream
+// failing before any of it is written leaves the response free for the error.
+type lazyGzipWriter struct {
+	w          io.Writer
+	gzipWriter *gzip.Writer
+}
+
+func (w *lazyGzipWriter) Write(p []byte) (int, error) {
+	if w.gzipWriter == nil {
+		w.gzipWriter = gzip.NewWriter(w.w)
+	}
+
+	return w.gzipWriter.Write(p)
+}
+
+func (w *lazyGzipWriter) Close() error {
+	if w.gzipWriter == nil {
+		return nil
+	}
+
+	return w.gzipWriter.Close()
+}
+
+func (s *GardenServer) handleLimitBandwidth(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var request apitypes.LimitBandwidthRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	hLog := s.session(r, "limit-bandwidth", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	requestedLimits := api.BandwidthLimits{
+		RateInBytesPerSecond:      request.GetRate(),
+		BurstRateInBytesPerSecond: request.GetBurst(),
+	}
+
+	hLog.Debug("limiting", lager.Data{
+		"requested-limits": requestedLimits,
+	})
+
+	err = container.LimitBandwidth(requestedLimits)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	limits, err := container.CurrentBandwidthLimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("limited", lager.Data{
+		"resulting-limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitBandwidthResponse{
+		Rate:  apitypes.Uint64(limits.RateInBytesPerSecond),
+		Burst: apitypes.Uint64(limits.BurstRateInBytesPerSecond),
+	})
+}
+
+func (s *GardenServer) handleCurrentBandwidthLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "current-bandwidth-limits", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentBandwidthLimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitBandwidthResponse{
+		Rate:  apitypes.Uint64(limits.RateInBytesPerSecond),
+		Burst: apitypes.Uint64(limits.BurstRateInBytesPerSecond),
+	})
+}
+
+func (s *GardenServer) handleDiskUsage(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "disk-usage", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	usage, err := container.DiskUsage()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"usage": usage,
+	})
+
+	s.writeResponse(w, &apitypes.DiskUsageResponse{
+		RootfsLayers: diskUsageEntries(usage.RootFSLayers),
+		Scratch:      diskUsageEntry(usage.Scratch),
+		BindMounts:   diskUsageEntries(usage.BindMounts),
+	})
+}
+
+func (s *GardenServer) handleEnv(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "env", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	env := containerEnv(s.processDefaults.get(container.Handle()), s.defaultProcessEnv)
+
+	hLog.Debug("got", lager.Data{
+		"variables": len(env),
+	})
+
+	s.writeResponse(w, &apitypes.ContainerEnvResponse{
+		Env: environmentVariables(env),
+	})
+}
+
+func diskUsageEntries(entries []api.DiskUsageEntry) []*apitypes.DiskUsageResponse_Entry {
+	converted := make([]*apitypes.DiskUsageResponse_Entry, len(entries))
+	for i, entry := range entries {
+		converted[i] = diskUsageEntry(entry)
+	}
+
+	return converted
+}
+
+func diskUsageEntry(entry api.DiskUsageEntry) *apitypes.DiskUsageResponse_Entry {
+	return &apitypes.DiskUsageResponse_Entry{
+		Name:       apitypes.String(entry.Name),
+		BytesUsed:  apitypes.Uint64(entry.BytesUsed),
+		InodesUsed: apitypes.Uint64(entry.InodesUsed),
+	}
+}
+
+func (s *GardenServer) handleLimitMemory(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "limit-memory", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.LimitMemoryRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	limitInBytes := request.GetLimitInBytes()
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	requestedLimits := api.MemoryLimits{
+		LimitInBytes: limitInBytes,
+	}
+
+	if request.LimitInBytes != nil {
+		hLog.Debug("limiting", lager.Data{
+			"requested-limits": requestedLimits,
+		})
+
+		err = container.LimitMemory(requestedLimits)
+
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+	}
+
+	limits, err := container.CurrentMemoryLimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("limited", lager.Data{
+		"resulting-limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitMemoryResponse{
+		LimitInBytes: apitypes.Uint64(limits.LimitInBytes),
+	})
+}
+
+func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "current-memory-limits", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentMemoryLimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitMemoryResponse{
+		LimitInBytes: apitypes.Uint64(limits.LimitInBytes),
+	})
+}
+
+func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "limit-disk", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.LimitDiskRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	blockSoft := request.GetBlockSoft()
+	blockHard := request.GetBlockHard()
+	inodeSoft := request.GetInodeSoft()
+	inodeHard := request.GetInodeHard()
+	byteSoft := request.GetByteSoft()
+	byteHard := request.GetByteHard()
+
+	settingLimit := false
+
+	if request.BlockSoft != nil || request.BlockHard != nil ||
+		request.InodeSoft != nil || request.InodeHard != nil ||
+		request.ByteSoft != nil || request.ByteHard != nil {
+		settingLimit = true
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	requestedLimits := api.DiskLimits{
+		BlockSoft: blockSoft,
+		BlockHard: blockHard,
+		InodeSoft: inodeSoft,
+		InodeHard: inodeHard,
+		ByteSoft:  byteSoft,
+		ByteHard:  byteHard,
+	}
+
+	if settingLimit {
+		hLog.Debug("limiting", lager.Data{
+			"requested-limits": requestedLimits,
+		})
+
+		err = container.LimitDisk(requestedLimits)
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+	}
+
+	limits, err := container.CurrentDiskLimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("limited", lager.Data{
+		"resulting-limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitDiskResponse{
+		BlockSoft: apitypes.Uint64(limits.BlockSoft),
+		BlockHard: apitypes.Uint64(limits.BlockHard),
+		InodeSoft: apitypes.Uint64(limits.InodeSoft),
+		InodeHard: apitypes.Uint64(limits.InodeHard),
+		ByteSoft:  apitypes.Uint64(limits.ByteSoft),
+		ByteHard:  apitypes.Uint64(limits.ByteHard),
+	})
+}
+
+func (s *GardenServer) handleCurrentDiskLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "current-disk-limits", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentDiskLimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitDiskResponse{
+		BlockSoft: apitypes.Uint64(limits.BlockSoft),
+		BlockHard: apitypes.Uint64(limits.BlockHard),
+		InodeSoft: apitypes.Uint64(limits.InodeSoft),
+		InodeHard: apitypes.Uint64(limits.InodeHard),
+		ByteSoft:  apitypes.Uint64(limits.ByteSoft),
+		ByteHard:  apitypes.Uint64(limits.ByteHard),
+	})
+}
+
+func (s *GardenServer) handleLimitCPU(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "limit-cpu", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.LimitCpuRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	requestedLimits := api.CPULimits{
+		LimitInShares: request.GetLimitInShares(),
+		Quota:         request.GetQuota(),
+		Period:        request.GetPeriod(),
+		Cpuset:        request.GetCpuset(),
+	}
+
+	if request.LimitInShares != nil || request.Quota != nil || request.Period != nil || request.Cpuset != nil {
+		hLog.Debug("limiting", lager.Data{
+			"requested-limits": requestedLimits,
+		})
+
+		err = container.LimitCPU(requestedLimits)
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+	}
+
+	limits, err := container.CurrentCPULimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("limited", lager.Data{
+		"resulting-limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitCpuResponse{
+		LimitInShares: apitypes.Uint64(limits.LimitInShares),
+		Quota:         apitypes.Uint64(limits.Quota),
+		Period:        apitypes.Uint64(limits.Period),
+		Cpuset:        apitypes.String(limits.Cpuset),
+	})
+}
+
+func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "current-cpu-limits", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentCPULimits()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, &apitypes.LimitCpuResponse{
+		LimitInShares: apitypes.Uint64(limits.LimitInShares),
+		Quota:         apitypes.Uint64(limits.Quota),
+		Period:        apitypes.Uint64(limits.Period),
+		Cpuset:        apitypes.String(limits.Cpuset),
+	})
+}
+
+func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "net-in", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.NetInRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	// the request has already been checked by requestViolations
+	spec := api.NetInSpec{
+		HostPort:      request.GetHostPort(),
+		ContainerPort: request.GetContainerPort(),
+		Protocol:      api.ProtocolTCP,
+	}
+
+	if request.GetProtocol() == apitypes.NetInRequest_UDP {
+		spec.Protocol = api.ProtocolUDP
+	}
+
+	if request.HostIp != nil {
+		spec.HostIP = parseIP(request.GetHostIp())
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("port-mapping", lager.Data{
+		"host-port":      spec.HostPort,
+		"container-port": spec.ContainerPort,
+		"protocol":       request.GetProtocol().String(),
+		"host-ip":        request.GetHostIp(),
+	})
+
+	hostPort, containerPort, err := container.NetInSpec(spec)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("port-mapped", lager.Data{
+		"host-port":      hostPort,
+		"container-port": containerPort,
+	})
+
+	s.writeResponse(w, &apitypes.NetInResponse{
+		HostPort:      apitypes.Uint32(hostPort),
+		ContainerPort: apitypes.Uint32(containerPort),
+	})
+}
+
+func (s *GardenServer) handleMappedPorts(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "mapped-ports", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	mappings, err := container.MappedPorts()
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"mapped-ports": mappings,
+	})
+
+	s.writeResponse(w, &apitypes.MappedPortsResponse{
+		MappedPorts: portMappingsResponse(mappings),
+	})
+}
+
+func portMappingsResponse(mappings []api.PortMapping) []*apitypes.InfoResponse_PortMapping {
+	mappedPorts := []*apitypes.InfoResponse_PortMapping{}
+	for _, mapping := range mappings {
+		mappedPort := &apitypes.InfoResponse_PortMapping{
+			HostPort:      apitypes.Uint32(mapping.HostPort),
+			ContainerPort: apitypes.Uint32(mapping.ContainerPort),
+		}
+
+		if mapping.Protocol == api.ProtocolUDP {
+			mappedPort.Protocol = apitypes.NetInRequest_UDP.Enum()
+		}
+
+		if mapping.HostIP != nil {
+			mappedPort.HostIp = apitypes.String(mapping.HostIP.String())
+		}
+
+		mappedPorts = append(mappedPorts, mappedPort)
+	}
+
+	return mappedPorts
+}
+
+func validPortRange(portRange string) bool {
+	if portRange != "" {
+		r := strings.Split(portRange, ":")
+		if len(r) != 2 {
+			return false
+		}
+		lo, startErr := strconv.Atoi(r[0])
+		hi, endErr := strconv.Atoi(r[1])
+		return startErr == nil && endErr == nil && lo > 0 && lo <= 65535 && hi > 0 && hi <= 65535
+	}
+	return true
+}
+
+func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "net-out", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.NetOutRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	var protoc api.Protocol
+	switch request.GetProtocol() {
+	case apitypes.NetOutRequest_TCP:
+		protoc = api.ProtocolTCP
+	case apitypes.NetOutRequest_ALL:
+		protoc = api.ProtocolAll
+	default:
+		err := fmt.Errorf("invalid protocol: %d", request.GetProtocol())
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	network := request.GetNetwork()
+	port := request.GetPort()
+	portRange := request.GetPortRange()
+
+	if !validPortRange(portRange) {
+		err := fmt.Errorf("invalid port range: %q", portRange)
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	rule, err := netOutRuleFromNetOut(network, port, portRange, protoc)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("allowing-out", lager.Data{
+		"network":   network,
+		"port":      port,
+		"portRange": portRange,
+		"protocol":  protoc,
+	})
+
+	err = container.NetOutRule(rule)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("allowed", lager.Data{
+		"network": network,
+		"port":    port,
+	})
+
+	s.writeResponse(w, &apitypes.NetOutResponse{})
+}
+
+func (s *GardenServer) handleNetOutRule(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "net-out-rule", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.NetOutRuleRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	rule, err := netOutRuleFromRequest(&request)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("allowing-out", lager.Data{
+		"rule": rule,
+	})
+
+	err = container.NetOutRule(rule)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("allowed")
+
+	s.writeResponse(w, &apitypes.NetOutRuleResponse{})
+}
+
+func (s *GardenServer) handleGetProperty(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "get-property", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.GetPropertyRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	key := request.GetKey()
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("get-property", lager.Data{
+		"key": key,
+	})
+
+	value, err := container.GetProperty(key)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("got-property", lager.Data{
+		"key":   key,
+		"value": value,
+	})
+
+	s.writeResponse(w, &apitypes.GetPropertyResponse{
+		Value: apitypes.String(value),
+	})
+}
+
+func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	key := r.FormValue(":key")
+
+	hLog := s.session(r, "set-property", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.SetPropertyRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	value := request.GetValue()
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
 	hLog.Debug("set-property", lager.Data{
@@ -866,7 +2195,7 @@ func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request)
 
 	err = container.SetProperty(key, value)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
@@ -875,17 +2204,107 @@ func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request)
 		"value": value,
 	})
 
-	s.writeResponse(w, &protocol.SetPropertyResponse{})
+	s.writeResponse(w, &apitypes.SetPropertyResponse{})
+}
+
+func (s *GardenServer) handleSetProperties(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r, "set-properties", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.SetPropertiesRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	properties := api.Properties{}
+	for _, prop := range request.GetProperties() {
+		properties[prop.GetKey()] = prop.GetValue()
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("set-properties", lager.Data{
+		"properties": properties,
+	})
+
+	err = container.SetProperties(properties)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("set-properties-complete", lager.Data{
+		"properties": properties,
+	})
+
+	s.writeResponse(w, &apitypes.SetPropertiesResponse{})
+}
+
+func (s *GardenServer) handleCompareAndSwapProperty(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+	key := r.FormValue(":key")
+
+	hLog := s.session(r, "compare-and-swap-property", lager.Data{
+		"handle": handle,
+	})
+
+	var request apitypes.CompareAndSwapPropertyRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	oldValue := request.GetOldValue()
+	newValue := request.GetNewValue()
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("compare-and-swap-property", lager.Data{
+		"key":       key,
+		"old-value": oldValue,
+		"new-value": newValue,
+	})
+
+	swapped, err := container.CompareAndSwapProperty(key, oldValue, newValue)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Info("compare-and-swap-property-complete", lager.Data{
+		"key":     key,
+		"swapped": swapped,
+	})
+
+	s.writeResponse(w, &apitypes.CompareAndSwapPropertyResponse{
+		Swapped: apitypes.Bool(swapped),
+	})
 }
 
 func (s *GardenServer) handleRemoveProperty(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("remove-property", lager.Data{
+	hLog := s.session(r, "remove-property", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.RemovePropertyRequest
+	var request apitypes.RemovePropertyRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
@@ -907,7 +2326,7 @@ func (s *GardenServer) handleRemoveProperty(w http.ResponseWriter, r *http.Reque
 
 	err = container.RemoveProperty(key)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
@@ -915,17 +2334,17 @@ func (s *GardenServer) handleRemoveProperty(w http.ResponseWriter, r *http.Reque
 		"key": key,
 	})
 
-	s.writeResponse(w, &protocol.RemovePropertyResponse{})
+	s.writeResponse(w, &apitypes.RemovePropertyResponse{})
 }
 
 func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("run", lager.Data{
+	hLog := s.session(r, "run", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.RunRequest
+	var request apitypes.RunRequest
 	if !s.readRequest(&request, w, r) {
 		return
 	}
@@ -953,16 +2372,61 @@ func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
 		Dir:        dir,
 		Privileged: privileged,
 		User:       user,
+		UID:        request.Uid,
+		GID:        request.Gid,
 		Env:        convertEnv(env),
 		TTY:        ttySpecFrom(tty),
 	}
 
+	if len(request.GetLabels()) > 0 {
+		processSpec.Labels = map[string]string{}
+		for _, label := range request.GetLabels() {
+			processSpec.Labels[label.GetKey()] = label.GetValue()
+		}
+	}
+
 	if request.Rlimits != nil {
 		processSpec.Limits = resourceLimits(request.Rlimits)
 	}
 
+	applyProcessDefaults(s.processDefaults.get(container.Handle()), s.defaultProcessEnv, &processSpec)
+
+	err = validateProcessSpec(processSpec)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	err = s.allowProcess(r, container, processSpec)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	if s.userChecker != nil {
+		err = s.userChecker.CheckUser(container, processSpec)
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+	}
+
+	passed, err := s.takePassedFiles(r, request.GetPassedFiles())
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	starting, err := s.processLimit.admit(container)
+	if err != nil {
+		passed.close()
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
 	hLog.Debug("running", lager.Data{
-		"spec": processSpec,
+		"spec":   processSpec,
+		"passed": request.GetPassedFiles(),
 	})
 
 	stdout := make(chan []byte, 1000)
@@ -970,59 +2434,299 @@ func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
 
 	stdinR, stdinW := io.Pipe()
 
+	// retained whether or not the client discards it, for clients to replay
+	output := s.processLogs.output(container.Handle())
+
 	processIO := api.ProcessIO{
 		Stdin:  stdinR,
-		Stdout: &chanWriter{stdout},
-		Stderr: &chanWriter{stderr},
+		Stdout: output.writer(outputWriter(stdout, request.GetDiscardStdout()), apitypes.ProcessPayload_stdout),
+		Stderr: output.writer(outputWriter(stderr, request.GetDiscardStderr()), apitypes.ProcessPayload_stderr),
 	}
 
+	passed.connect(&processIO)
+
 	process, err := container.Run(processSpec, processIO)
+
+	s.processLimit.finish(starting)
+
+	if err != nil {
+		output.abandon()
+		passed.close()
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	if len(passed) > 0 {
+		// the files are the process's until it exits, which it may do after
+		// this server has handed off
+		go func() {
+			process.Wait()
+			passed.close()
+		}()
+	}
+
+	output.start(process.ID())
+
+	hLog.Info("spawned", lager.Data{
+		"spec": processSpec,
+		"id":   process.ID(),
+	})
+
+	s.processLabels.set(container.Handle(), process.ID(), processSpec.Labels)
+	s.processWaits.processRun(container.Handle())
+
+	framed := negotiateFraming(w, r)
+
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+
+	conn, br, err := s.hijack(w)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		stdinW.Close()
+		return
+	}
+
+	defer conn.Close()
+
+	reader, out := processStreams(framed, br, s.timedWriter(conn))
+
+	firstPayload := &apitypes.ProcessPayload{
+		ProcessId:   apitypes.Uint32(process.ID()),
+		StdinWindow: apitypes.Uint32(StdinWindow),
+	}
+
+	if token := s.reconnectToken(container.Handle(), process.ID(), output.position()); token != "" {
+		firstPayload.ReconnectToken = apitypes.String(token)
+	}
+
+	out.WriteMessage(firstPayload)
+
+	stdin := s.stdinAccounting.meter(container.Handle(), process.ID())
+	defer stdin.release(hLog, process.ID())
+
+	go s.streamInput(reader, out, stdinW, stdin, process)
+
+	s.streamProcess(hLog, out, process, stdout, stderr, stdinW)
+}
+
+func (s *GardenServer) handleProcessInfo(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var processID uint32
+
+	hLog := s.session(r, "process-info", lager.Data{
+		"handle": handle,
+	})
+
+	_, err := fmt.Sscanf(r.FormValue(":pid"), "%d", &processID)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting", lager.Data{
+		"id": processID,
+	})
+
+	info, err := container.ProcessInfo(processID)
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	hLog.Debug("got", lager.Data{
+		"info": info,
+	})
+
+	res := &apitypes.ProcessInfoResponse{
+		ProcessId: apitypes.Uint32(info.ID),
+		State:     apitypes.String(string(info.State)),
+		CpuTime:   apitypes.Uint64(uint64(info.CPUTime)),
+		Rss:       apitypes.Uint64(info.RSSInBytes),
+	}
+
+	if !info.StartedAt.IsZero() {
+		res.StartedAt = apitypes.Int64(info.StartedAt.Unix())
+	}
+
+	s.writeResponse(w, res)
+}
+
+func (s *GardenServer) handleFindProcesses(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	// the route's parameters, which rata passes in the query, are not labels
+	labels := map[string]string{}
+	for key, values := range r.URL.Query() {
+		if !strings.HasPrefix(key, ":") {
+			labels[key] = values[0]
+		}
+	}
+
+	hLog := s.session(r, "find-processes", lager.Data{
+		"handle": handle,
+		"labels": labels,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	mark := s.processLabels.mark()
+
+	info, err := container.InfoFields([]api.InfoField{api.InfoFieldProcessIDs})
+	if err != nil {
+		s.writeContainerError(w, container, err, hLog)
+		return
+	}
+
+	processIDs := s.processLabels.find(container.Handle(), labels, info.ProcessIDs, mark)
+
+	hLog.Debug("found", lager.Data{
+		"ids": processIDs,
+	})
+
+	s.writeResponse(w, &apitypes.FindProcessesResponse{
+		ProcessIds: processIDs,
+	})
+}
+
+func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var processID uint32
+
+	hLog := s.session(r, "attach", lager.Data{
+		"handle": handle,
+	})
+
+	_, err := fmt.Sscanf(r.FormValue(":pid"), "%d", &processID)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	// older clients send the request without a content type, and only ever
+	// want both streams
+	var request apitypes.AttachRequest
+	if r.Header.Get("Content-Type") != "" {
+		if !s.readRequest(&request, w, r) {
+			return
+		}
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	stdout := make(chan []byte, 1000)
+	stderr := make(chan []byte, 1000)
+
+	var replay *outputReplay
+
+	processIO := api.ProcessIO{
+		Stdout: outputWriter(stdout, request.GetDiscardStdout()),
+		Stderr: outputWriter(stderr, request.GetDiscardStderr()),
+	}
+
+	if request.GetReconnectToken() != "" {
+		claims, err := s.verifyReconnectToken(request.GetReconnectToken(), container.Handle(), processID)
+		if err != nil {
+			s.writeContainerError(w, container, err, hLog)
+			return
+		}
+
+		log := s.reconnectLog(container.Handle(), claims)
+
+		hLog.Debug("reconnecting", lager.Data{
+			"id":       processID,
+			"replayed": log != nil,
+		})
+
+		processIO, replay = reconnectIO(log, claims, request, stdout, stderr)
+	}
+
+	stdinR, stdinW := io.Pipe()
+	processIO.Stdin = stdinR
+
+	hLog.Debug("attaching", lager.Data{
+		"id": processID,
+	})
+
+	process, err := container.Attach(processID, processIO)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
+		stdinW.Close()
 		return
 	}
 
-	hLog.Info("spawned", lager.Data{
-		"spec": processSpec,
-		"id":   process.ID(),
+	hLog.Info("attached", lager.Data{
+		"id": process.ID(),
 	})
 
-	w.WriteHeader(http.StatusCreated)
+	if replay != nil {
+		go replay.run()
+		defer close(replay.stopped)
+
+		process = &replayedProcess{Process: process, replay: replay}
+	}
+
+	framed := negotiateFraming(w, r)
+
+	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 
-	conn, br, err := w.(http.Hijacker).Hijack()
+	conn, br, err := s.hijack(w)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		stdinW.Close()
 		return
 	}
 
 	defer conn.Close()
 
-	transport.WriteMessage(conn, &protocol.ProcessPayload{
-		ProcessId: proto.Uint32(process.ID()),
+	reader, out := processStreams(framed, br, s.timedWriter(conn))
+
+	out.WriteMessage(&apitypes.ProcessPayload{
+		ProcessId:   apitypes.Uint32(process.ID()),
+		StdinWindow: apitypes.Uint32(StdinWindow),
 	})
 
-	go s.streamInput(json.NewDecoder(br), stdinW, process)
+	stdin := s.stdinAccounting.meter(container.Handle(), process.ID())
+	defer stdin.release(hLog, process.ID())
+
+	go s.streamInput(reader, out, stdinW, stdin, process)
 
-	s.streamProcess(hLog, conn, process, stdout, stderr, stdinW)
+	s.streamProcess(hLog, out, process, stdout, stderr, stdinW)
 }
 
-func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleAttachAll(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	var processID uint32
-
-	hLog := s.logger.Session("attach", lager.Data{
+	hLog := s.session(r, "attach-all", lager.Data{
 		"handle": handle,
 	})
 
-	_, err := fmt.Sscanf(r.FormValue(":pid"), "%d", &processID)
-	if err != nil {
-		s.writeError(w, err, hLog)
-		return
-	}
-
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -1032,80 +2736,170 @@ func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	stdout := make(chan []byte, 1000)
-	stderr := make(chan []byte, 1000)
+	streams := map[uint32]*attachedStream{}
+	streamsL := new(sync.Mutex)
 
-	stdinR, stdinW := io.Pipe()
+	processIO := func(processID uint32) api.ProcessIO {
+		stdout := make(chan []byte, 1000)
+		stderr := make(chan []byte, 1000)
 
-	processIO := api.ProcessIO{
-		Stdin:  stdinR,
-		Stdout: &chanWriter{stdout},
-		Stderr: &chanWriter{stderr},
+		stdinR, stdinW := io.Pipe()
+
+		streamsL.Lock()
+		streams[processID] = &attachedStream{
+			stdout: stdout,
+			stderr: stderr,
+			stdinW: stdinW,
+		}
+		streamsL.Unlock()
+
+		return api.ProcessIO{
+			Stdin:  stdinR,
+			Stdout: &chanWriter{stdout},
+			Stderr: &chanWriter{stderr},
+		}
 	}
 
-	hLog.Debug("attaching", lager.Data{
-		"id": processID,
-	})
+	hLog.Debug("attaching")
 
-	process, err := container.Attach(processID, processIO)
+	processes, err := container.AttachAll(processIO)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		stdinW.Close()
+		s.writeContainerError(w, container, err, hLog)
+
+		for _, stream := range streams {
+			stream.stdinW.Close()
+		}
+
 		return
 	}
 
+	processIDs := make([]uint32, len(processes))
+	for i, process := range processes {
+		processIDs[i] = process.ID()
+	}
+
 	hLog.Info("attached", lager.Data{
-		"id": process.ID(),
+		"ids": processIDs,
 	})
 
+	framed := negotiateFraming(w, r)
+
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 
-	conn, br, err := w.(http.Hijacker).Hijack()
+	conn, br, err := s.hijack(w)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		stdinW.Close()
+		s.writeContainerError(w, container, err, hLog)
+
+		for _, stream := range streams {
+			stream.stdinW.Close()
+		}
+
 		return
 	}
 
 	defer conn.Close()
 
-	go s.streamInput(json.NewDecoder(br), stdinW, process)
+	reader, out := processStreams(framed, br, s.timedWriter(conn))
+
+	out.WriteMessage(&apitypes.AttachAllResponse{
+		ProcessIds:  processIDs,
+		StdinWindow: apitypes.Uint32(StdinWindow),
+	})
+
+	attached := map[uint32]attachedProcess{}
+	for _, process := range processes {
+		if _, found := streams[process.ID()]; !found {
+			processIO(process.ID())
+		}
+
+		stdin := s.stdinAccounting.meter(container.Handle(), process.ID())
+		defer stdin.release(hLog, process.ID())
+
+		attached[process.ID()] = attachedProcess{
+			process:        process,
+			attachedStream: streams[process.ID()],
+			stdin:          stdin,
+		}
+	}
+
+	go s.streamMultiplexedInput(hLog, reader, out, attached)
+
+	streaming := new(sync.WaitGroup)
+
+	for _, a := range attached {
+		streaming.Add(1)
+
+		go func(a attachedProcess) {
+			defer streaming.Done()
+			s.streamProcess(hLog, out, a.process, a.stdout, a.stderr, a.stdinW)
+		}(a)
+	}
 
-	s.streamProcess(hLog, conn, process, stdout, stderr, stdinW)
+	streaming.Wait()
 }
 
 func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("info", lager.Data{
+	fields, violations := parseInfoFields(r.URL.Query().Get("fields"))
+
+	hLog := s.session(r, "info", lager.Data{
 		"handle": handle,
+		"fields": fields,
 	})
 
+	if len(violations) > 0 {
+		s.writeError(w, api.InvalidRequestError{Violations: violations}, hLog)
+		return
+	}
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
+	// taken before this request restarts the countdown
+	graceStatus := s.bomberman.Status(container.Handle())
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
 	hLog.Debug("getting-info")
 
-	info, err := container.Info()
+	var info api.ContainerInfo
+	if len(fields) == 0 {
+		info, err = container.Info()
+	} else {
+		info, err = container.InfoFields(fields)
+	}
+
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeContainerError(w, container, err, hLog)
 		return
 	}
 
 	hLog.Info("got-info")
 
-	properties := []*protocol.Property{}
+	if len(fields) == 0 || hasInfoField(fields, api.InfoFieldLimits) {
+		info.Limits = currentLimits(container, hLog)
+	}
+
+	res := s.infoResponse(container, info, graceStatus)
+	if len(fields) > 0 {
+		res = selectInfoFields(res, fields)
+	}
+
+	s.writeResponse(w, res)
+}
+
+func (s *GardenServer) infoResponse(container api.Container, info api.ContainerInfo, graceStatus timebomb.Status) *apitypes.InfoResponse {
+	properties := []*apitypes.Property{}
 	for key, val := range info.Properties {
-		properties = append(properties, &protocol.Property{
-			Key:   proto.String(key),
-			Value: proto.String(val),
+		properties = append(properties, &apitypes.Property{
+			Key:   apitypes.String(key),
+			Value: apitypes.String(val),
 		})
 	}
 
@@ -1114,79 +2908,94 @@ func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 		processIDs[i] = uint64(processID)
 	}
 
-	mappedPorts := []*protocol.InfoResponse_PortMapping{}
-	for _, mapping := range info.MappedPorts {
-		mappedPorts = append(mappedPorts, &protocol.InfoResponse_PortMapping{
-			HostPort:      proto.Uint32(mapping.HostPort),
-			ContainerPort: proto.Uint32(mapping.ContainerPort),
-		})
-	}
-
-	s.writeResponse(w, &protocol.InfoResponse{
-		State:         proto.String(info.State),
+	infoResponse := &apitypes.InfoResponse{
+		State:         apitypes.String(info.State),
 		Events:        info.Events,
-		HostIp:        proto.String(info.HostIP),
-		ContainerIp:   proto.String(info.ContainerIP),
-		ExternalIp:    proto.String(info.ExternalIP),
-		ContainerPath: proto.String(info.ContainerPath),
+		HostIp:        apitypes.String(info.HostIP),
+		ContainerIp:   apitypes.String(info.ContainerIP),
+		ExternalIp:    apitypes.String(info.ExternalIP),
+		ContainerPath: apitypes.String(info.ContainerPath),
 		ProcessIds:    processIDs,
 
 		Properties: properties,
 
-		MemoryStat: &protocol.InfoResponse_MemoryStat{
-			Cache:                   proto.Uint64(info.MemoryStat.Cache),
-			Rss:                     proto.Uint64(info.MemoryStat.Rss),
-			MappedFile:              proto.Uint64(info.MemoryStat.MappedFile),
-			Pgpgin:                  proto.Uint64(info.MemoryStat.Pgpgin),
-			Pgpgout:                 proto.Uint64(info.MemoryStat.Pgpgout),
-			Swap:                    proto.Uint64(info.MemoryStat.Swap),
-			Pgfault:                 proto.Uint64(info.MemoryStat.Pgfault),
-			Pgmajfault:              proto.Uint64(info.MemoryStat.Pgmajfault),
-			InactiveAnon:            proto.Uint64(info.MemoryStat.InactiveAnon),
-			ActiveAnon:              proto.Uint64(info.MemoryStat.ActiveAnon),
-			InactiveFile:            proto.Uint64(info.MemoryStat.InactiveFile),
-			ActiveFile:              proto.Uint64(info.MemoryStat.ActiveFile),
-			Unevictable:             proto.Uint64(info.MemoryStat.Unevictable),
-			HierarchicalMemoryLimit: proto.Uint64(info.MemoryStat.HierarchicalMemoryLimit),
-			HierarchicalMemswLimit:  proto.Uint64(info.MemoryStat.HierarchicalMemswLimit),
-			TotalCache:              proto.Uint64(info.MemoryStat.TotalCache),
-			TotalRss:                proto.Uint64(info.MemoryStat.TotalRss),
-			TotalMappedFile:         proto.Uint64(info.MemoryStat.TotalMappedFile),
-			TotalPgpgin:             proto.Uint64(info.MemoryStat.TotalPgpgin),
-			TotalPgpgout:            proto.Uint64(info.MemoryStat.TotalPgpgout),
-			TotalSwap:               proto.Uint64(info.MemoryStat.TotalSwap),
-			TotalPgfault:            proto.Uint64(info.MemoryStat.TotalPgfault),
-			TotalPgmajfault:         proto.Uint64(info.MemoryStat.TotalPgmajfault),
-			TotalInactiveAnon:       proto.Uint64(info.MemoryStat.TotalInactiveAnon),
-			TotalActiveAnon:         proto.Uint64(info.MemoryStat.TotalActiveAnon),
-			TotalInactiveFile:       proto.Uint64(info.MemoryStat.TotalInactiveFile),
-			TotalActiveFile:         proto.Uint64(info.MemoryStat.TotalActiveFile),
-			TotalUnevictable:        proto.Uint64(info.MemoryStat.TotalUnevictable),
+		MemoryStat: &apitypes.InfoResponse_MemoryStat{
+			Cache:                   apitypes.Uint64(info.MemoryStat.Cache),
+			Rss:                     apitypes.Uint64(info.MemoryStat.Rss),
+			MappedFile:              apitypes.Uint64(info.MemoryStat.MappedFile),
+			Pgpgin:                  apitypes.Uint64(info.MemoryStat.Pgpgin),
+			Pgpgout:                 apitypes.Uint64(info.MemoryStat.Pgpgout),
+			Swap:                    apitypes.Uint64(info.MemoryStat.Swap),
+			Pgfault:                 apitypes.Uint64(info.MemoryStat.Pgfault),
+			Pgmajfault:              apitypes.Uint64(info.MemoryStat.Pgmajfault),
+			InactiveAnon:            apitypes.Uint64(info.MemoryStat.InactiveAnon),
+			ActiveAnon:              apitypes.Uint64(info.MemoryStat.ActiveAnon),
+			InactiveFile:            apitypes.Uint64(info.MemoryStat.InactiveFile),
+			ActiveFile:              apitypes.Uint64(info.MemoryStat.ActiveFile),
+			Unevictable:             apitypes.Uint64(info.MemoryStat.Unevictable),
+			HierarchicalMemoryLimit: apitypes.Uint64(info.MemoryStat.HierarchicalMemoryLimit),
+			HierarchicalMemswLimit:  apitypes.Uint64(info.MemoryStat.HierarchicalMemswLimit),
+			TotalCache:              apitypes.Uint64(info.MemoryStat.TotalCache),
+			TotalRss:                apitypes.Uint64(info.MemoryStat.TotalRss),
+			TotalMappedFile:         apitypes.Uint64(info.MemoryStat.TotalMappedFile),
+			TotalPgpgin:             apitypes.Uint64(info.MemoryStat.TotalPgpgin),
+			TotalPgpgout:            apitypes.Uint64(info.MemoryStat.TotalPgpgout),
+			TotalSwap:               apitypes.Uint64(info.MemoryStat.TotalSwap),
+			TotalPgfault:            apitypes.Uint64(info.MemoryStat.TotalPgfault),
+			TotalPgmajfault:         apitypes.Uint64(info.MemoryStat.TotalPgmajfault),
+			TotalInactiveAnon:       apitypes.Uint64(info.MemoryStat.TotalInactiveAnon),
+			TotalActiveAnon:         apitypes.Uint64(info.MemoryStat.TotalActiveAnon),
+			TotalInactiveFile:       apitypes.Uint64(info.MemoryStat.TotalInactiveFile),
+			TotalActiveFile:         apitypes.Uint64(info.MemoryStat.TotalActiveFile),
+			TotalUnevictable:        apitypes.Uint64(info.MemoryStat.TotalUnevictable),
 		},
 
-		CpuStat: &protocol.InfoResponse_CpuStat{
-			Usage:  proto.Uint64(info.CPUStat.Usage),
-			User:   proto.Uint64(info.CPUStat.User),
-			System: proto.Uint64(info.CPUStat.System),
+		CpuStat: &apitypes.InfoResponse_CpuStat{
+			Usage:  apitypes.Uint64(info.CPUStat.Usage),
+			User:   apitypes.Uint64(info.CPUStat.User),
+			System: apitypes.Uint64(info.CPUStat.System),
 		},
 
-		DiskStat: &protocol.InfoResponse_DiskStat{
-			BytesUsed:  proto.Uint64(info.DiskStat.BytesUsed),
-			InodesUsed: proto.Uint64(info.DiskStat.InodesUsed),
+		DiskStat: &apitypes.InfoResponse_DiskStat{
+			BytesUsed:  apitypes.Uint64(info.DiskStat.BytesUsed),
+			InodesUsed: apitypes.Uint64(info.DiskStat.InodesUsed),
 		},
 
-		BandwidthStat: &protocol.InfoResponse_BandwidthStat{
-			InRate:   proto.Uint64(info.BandwidthStat.InRate),
-			InBurst:  proto.Uint64(info.BandwidthStat.InBurst),
-			OutRate:  proto.Uint64(info.BandwidthStat.OutRate),
-			OutBurst: proto.Uint64(info.BandwidthStat.OutBurst),
+		BandwidthStat: &apitypes.InfoResponse_BandwidthStat{
+			InRate:   apitypes.Uint64(info.BandwidthStat.InRate),
+			InBurst:  apitypes.Uint64(info.BandwidthStat.InBurst),
+			OutRate:  apitypes.Uint64(info.BandwidthStat.OutRate),
+			OutBurst: apitypes.Uint64(info.BandwidthStat.OutBurst),
 		},
 
-		MappedPorts: mappedPorts,
-	})
+		MappedPorts: portMappingsResponse(info.MappedPorts),
+
+		Held:      apitypes.Bool(s.bomberman.IsHeld(container.Handle())),
+		GraceTime: apitypes.Uint32(uint32(s.backend.GraceTime(container).Seconds())),
+	}
+
+	if !info.CreatedAt.IsZero() {
+		infoResponse.CreatedAt = apitypes.Int64(info.CreatedAt.Unix())
+	}
+
+	if info.ExternalInterface != "" {
+		infoResponse.ExternalInterface = apitypes.String(info.ExternalInterface)
+	}
+
+	if graceStatus.Remaining > 0 {
+		infoResponse.TimeToGraceExpiry = apitypes.Uint64(uint64(graceStatus.Remaining / time.Millisecond))
+	}
+
+	if !graceStatus.LastActivity.IsZero() {
+		infoResponse.LastActivityAt = apitypes.Int64(graceStatus.LastActivity.Unix())
+	}
+
+	infoResponse.Limits = limitsResponse(info.Limits)
+
+	return infoResponse
 }
 
-func resourceLimits(limits *protocol.ResourceLimits) api.ResourceLimits {
+func resourceLimits(limits *apitypes.ResourceLimits) api.ResourceLimits {
 	return api.ResourceLimits{
 		As:         limits.As,
 		Core:       limits.Core,
@@ -1207,34 +3016,271 @@ func resourceLimits(limits *protocol.ResourceLimits) api.ResourceLimits {
 }
 
 func (s *GardenServer) writeError(w http.ResponseWriter, err error, logger lager.Logger) {
+	s.writeErrorResponse(w, err, nil, logger)
+}
+
+func (s *GardenServer) writeContainerError(w http.ResponseWriter, container api.Container, err error, logger lager.Logger) {
+	s.writeErrorResponse(w, err, s.errorAnnotations(container), logger)
+}
+
+func (s *GardenServer) writeErrorResponse(w http.ResponseWriter, err error, annotations []*apitypes.Property, logger lager.Logger) {
 	logger.Error("failed", err)
 
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(http.StatusInternalServerError)
-	w.Write([]byte(err.Error()))
+	res, status := errorResponse(err, annotations)
+
+	if id := w.Header().Get(transport.TraceHeader); id != "" {
+		res.TraceId = apitypes.String(id)
+	}
+
+	w.Header().Set("Content-Type", s.responseCodec(w).ContentType())
+	s.encode(w, status, res)
 }
 
-func (s *GardenServer) writeResponse(w http.ResponseWriter, msg proto.Message) {
-	w.Header().Set("Content-Type", "application/json")
-	transport.WriteMessage(w, msg)
+// errorResponse describes the error to the client, returning the status to
+// respond with, which follows from the error's class.
+func errorResponse(err error, annotations []*apitypes.Property) (*apitypes.ErrorResponse, int) {
+	class := api.ClassOf(err)
+
+	status, found := classStatuses[class]
+	if !found {
+		status = http.StatusInternalServerError
+	}
+
+	res := &apitypes.ErrorResponse{
+		Message:     apitypes.String(err.Error()),
+		Annotations: annotations,
+		Class:       apitypes.String(string(class)),
+	}
+
+	switch typedErr := err.(type) {
+	case api.DiskQuotaExceededError:
+		res.Type = apitypes.String(apitypes.ErrorTypeDiskQuotaExceeded)
+		res.DiskQuota = &apitypes.ErrorResponse_DiskQuota{
+			ExpectedBytes:  apitypes.Uint64(typedErr.ExpectedBytes),
+			RemainingBytes: apitypes.Uint64(typedErr.RemainingBytes),
+		}
+
+	case api.StdinLimitExceededError:
+		res.Type = apitypes.String(apitypes.ErrorTypeStdinLimitExceeded)
+		res.StdinLimit = apitypes.Uint64(typedErr.LimitInBytes)
+
+	case api.StreamInLimitExceededError:
+		res.Type = apitypes.String(apitypes.ErrorTypeStreamInLimitExceeded)
+		res.StreamInLimit = &apitypes.ErrorResponse_StreamInLimit{}
+
+		if typedErr.LimitInBytes != 0 {
+			res.StreamInLimit.LimitInBytes = apitypes.Uint64(typedErr.LimitInBytes)
+		}
+
+		if typedErr.LimitInEntries != 0 {
+			res.StreamInLimit.LimitInEntries = apitypes.Uint64(typedErr.LimitInEntries)
+		}
+
+	case api.MessageTooLargeError:
+		status = http.StatusRequestEntityTooLarge
+		res.Type = apitypes.String(apitypes.ErrorTypeMessageTooLarge)
+		res.MessageSizeLimit = apitypes.Uint64(typedErr.LimitInBytes)
+
+	case api.ValidationError:
+		status = transport.StatusUnprocessableEntity
+		res.Type = apitypes.String(apitypes.ErrorTypeValidation)
+		res.Validation = validationResponse(typedErr)
+
+	case api.InvalidRequestError:
+		status = transport.StatusUnprocessableEntity
+		res.Type = apitypes.String(apitypes.ErrorTypeInvalidRequest)
+		for _, violation := range typedErr.Violations {
+			res.Violations = append(res.Violations, validationResponse(violation))
+		}
+
+	default:
+		// errors are compared rather than looked up, as only comparable ones
+		// can be; the api package's are, so none is ever compared with an
+		// error of the same but uncomparable type
+		for apiErr, errorType := range errorTypes {
+			if err == apiErr {
+				res.Type = apitypes.String(errorType)
+				break
+			}
+		}
+
+		if err == api.ErrRateLimited {
+			status = transport.StatusTooManyRequests
+		}
+
+		if err == api.ErrPermissionDenied {
+			status = http.StatusForbidden
+		}
+
+		if err == api.ErrDestroyNotConfirmed {
+			status = http.StatusPreconditionFailed
+		}
+
+		if err == api.ErrMultipleContainersMatch {
+			status = http.StatusConflict
+		}
+	}
+
+	return res, status
+}
+
+var classStatuses = map[api.ErrorClass]int{
+	api.ErrorClassRetryable:   http.StatusServiceUnavailable,
+	api.ErrorClassTerminal:    http.StatusInternalServerError,
+	api.ErrorClassNotFound:    http.StatusNotFound,
+	api.ErrorClassQuota:       http.StatusConflict,
+	api.ErrorClassUnsupported: http.StatusNotImplemented,
+}
+
+var errorTypes = map[error]string{
+	api.ErrContainerNotFound:       apitypes.ErrorTypeContainerNotFound,
+	api.ErrProcessNotFound:         apitypes.ErrorTypeProcessNotFound,
+	api.ErrUnsupportedOperation:    apitypes.ErrorTypeUnsupportedOperation,
+	api.ErrCapacityExceeded:        apitypes.ErrorTypeCapacityExceeded,
+	api.ErrUserNotFound:            apitypes.ErrorTypeUserNotFound,
+	api.ErrRateLimited:             apitypes.ErrorTypeRateLimited,
+	api.ErrProcessLimitExceeded:    apitypes.ErrorTypeProcessLimitExceeded,
+	api.ErrDestroyInProgress:       apitypes.ErrorTypeDestroyInProgress,
+	api.ErrOperationNotFound:       apitypes.ErrorTypeOperationNotFound,
+	api.ErrPermissionDenied:        apitypes.ErrorTypePermissionDenied,
+	api.ErrDestroyNotConfirmed:     apitypes.ErrorTypeDestroyNotConfirmed,
+	api.ErrMultipleContainersMatch: apitypes.ErrorTypeMultipleContainersMatch,
+}
+
+func (s *GardenServer) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	if s.authenticator == nil {
+		return true
+	}
+
+	err := s.authenticator.Authenticate(r)
+	if err == nil {
+		return true
+	}
+
+	s.logger.Error("unauthorized", err, lager.Data{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	})
+
+	codec := negotiateCodec(r)
+	if codec == nil {
+		codec = s.codec
+	}
+
+	w.Header().Set("Content-Type", codec.ContentType())
+	w.WriteHeader(http.StatusUnauthorized)
+
+	codec.Encode(w, &apitypes.ErrorResponse{
+		Message: apitypes.String(err.Error()),
+	})
+
+	return false
+}
+
+// errorAnnotations collects the container's properties named by the server's
+// configured error annotation keys, skipping any the container does not have.
+func (s *GardenServer) errorAnnotations(container api.Container) []*apitypes.Property {
+	var annotations []*apitypes.Property
+
+	for _, key := range s.errorAnnotationKeys {
+		value, err := container.GetProperty(key)
+		if err != nil {
+			continue
+		}
+
+		annotations = append(annotations, &apitypes.Property{
+			Key:   apitypes.String(key),
+			Value: apitypes.String(value),
+		})
+	}
+
+	return annotations
+}
+
+func (s *GardenServer) writeResponse(w http.ResponseWriter, msg interface{}) {
+	w.Header().Set("Content-Type", s.responseCodec(w).ContentType())
+	s.encode(w, http.StatusOK, msg)
 }
 
-func (s *GardenServer) readRequest(msg proto.Message, w http.ResponseWriter, r *http.Request) bool {
-	if r.Header.Get("Content-Type") != "application/json" {
+// encode writes the response with the status, timing its encoding if the
+// server reports timings.
+func (s *GardenServer) encode(w http.ResponseWriter, status int, msg interface{}) {
+	codec := s.responseCodec(w)
+
+	timing, timed := w.(*serverTiming)
+	if !timed {
+		w.WriteHeader(status)
+		codec.Encode(w, msg)
+		return
+	}
+
+	began := time.Now()
+
+	buf := new(bytes.Buffer)
+	codec.Encode(buf, msg)
+
+	timing.encoded(began)
+
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// readRequest decodes a request with the codec registered for its content
+// type, so that clients may use any wire format the server understands.
+func (s *GardenServer) readRequest(msg interface{}, w http.ResponseWriter, r *http.Request) bool {
+	if timing, timed := w.(*serverTiming); timed {
+		defer timing.decoded(time.Now())
+	}
+
+	codec, err := transport.CodecFor(r.Header.Get("Content-Type"))
+	if err != nil {
 		s.writeError(w, ErrInvalidContentType, s.logger)
 		return false
 	}
 
-	err := json.NewDecoder(r.Body).Decode(msg)
+	var body io.Reader = transport.LimitMessage(r.Body)
+
+	// strict requests are checked for unknown fields once decoded, so the
+	// body is kept
+	var data []byte
+	if s.strictRequests {
+		data, err = ioutil.ReadAll(body)
+		if err != nil {
+			s.writeError(w, err, s.logger)
+			return false
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	err = codec.Decode(body, msg)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		err = api.InvalidRequestError{
+			Violations: []api.ValidationError{typeViolation(typeErr)},
+		}
+	}
+
 	if err != nil {
 		s.writeError(w, err, s.logger)
 		return false
 	}
 
+	violations := requestViolations(msg, r.FormValue(":handle"))
+
+	// only JSON can be checked; other codecs' messages are decoded as usual
+	if s.strictRequests && codec.ContentType() == transport.ContentTypeJSON {
+		violations = append(unknownFieldViolations(data, msg), violations...)
+	}
+
+	if len(violations) > 0 {
+		s.writeError(w, api.InvalidRequestError{Violations: violations}, s.logger)
+		return false
+	}
+
 	return true
 }
 
-func convertEnv(env []*protocol.EnvironmentVariable) []string {
+func convertEnv(env []*apitypes.EnvironmentVariable) []string {
 	converted := []string{}
 
 	for _, e := range env {
@@ -1244,10 +3290,28 @@ func convertEnv(env []*protocol.EnvironmentVariable) []string {
 	return converted
 }
 
-func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, process api.Process) {
+func environmentVariables(env []string) []*apitypes.EnvironmentVariable {
+	converted := []*apitypes.EnvironmentVariable{}
+
+	for _, variable := range env {
+		segs := strings.SplitN(variable, "=", 2)
+		if len(segs) < 2 {
+			segs = append(segs, "")
+		}
+
+		converted = append(converted, &apitypes.EnvironmentVariable{
+			Key:   apitypes.String(segs[0]),
+			Value: apitypes.String(segs[1]),
+		})
+	}
+
+	return converted
+}
+
+func (s *GardenServer) streamInput(reader transport.MessageReader, out transport.MessageWriter, in *io.PipeWriter, stdin *stdinMeter, process api.Process) {
 	for {
-		var payload protocol.ProcessPayload
-		err := decoder.Decode(&payload)
+		var payload apitypes.ProcessPayload
+		err := reader.ReadMessage(&payload)
 		if err != nil {
 			in.CloseWithError(errors.New("Connection closed"))
 			return
@@ -1257,12 +3321,18 @@ func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, pro
 		case payload.Tty != nil:
 			process.SetTTY(*ttySpecFrom(payload.GetTty()))
 
+		case payload.Rlimits != nil:
+			s.setRlimits(s.logger, process, payload.GetRlimits())
+
+		case payload.AllocateTty != nil:
+			s.allocateTTY(s.logger, out, process, payload.GetAllocateTty())
+
 		case payload.Source != nil:
 			if payload.Data == nil {
 				in.Close()
 				return
 			} else {
-				_, err := in.Write([]byte(payload.GetData()))
+				err := stdin.write(in, out, process.ID(), []byte(payload.GetData()))
 				if err != nil {
 					return
 				}
@@ -1276,7 +3346,88 @@ func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, pro
 	}
 }
 
-func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process api.Process, stdout <-chan []byte, stderr <-chan []byte, stdinPipe *io.PipeWriter) {
+// setRlimits changes a running process's limits; there is no reply to the
+// payload, so backends which do not support it are only logged.
+func (s *GardenServer) setRlimits(logger lager.Logger, process api.Process, limits *apitypes.ResourceLimits) {
+	err := process.SetRlimits(resourceLimits(limits))
+	if err != nil {
+		logger.Error("set-rlimits-failed", err, lager.Data{
+			"process": process.ID(),
+		})
+	}
+}
+
+// allocateTTY gives a running process a TTY, replying to the client with
+// whether it could.
+func (s *GardenServer) allocateTTY(logger lager.Logger, out transport.MessageWriter, process api.Process, tty *apitypes.TTY) {
+	err := process.AllocateTTY(*ttySpecFrom(tty))
+	if err != nil {
+		logger.Error("allocate-tty-failed", err, lager.Data{
+			"process": process.ID(),
+		})
+
+		res, _ := errorResponse(err, nil)
+
+		out.WriteMessage(&apitypes.ProcessPayload{
+			ProcessId: apitypes.Uint32(process.ID()),
+			TtyError:  res,
+		})
+
+		return
+	}
+
+	out.WriteMessage(&apitypes.ProcessPayload{
+		ProcessId:    apitypes.Uint32(process.ID()),
+		TtyAllocated: apitypes.Bool(true),
+	})
+}
+
+func (s *GardenServer) streamMultiplexedInput(logger lager.Logger, reader transport.MessageReader, out transport.MessageWriter, attached map[uint32]attachedProcess) {
+	for {
+		var payload apitypes.ProcessPayload
+		err := reader.ReadMessage(&payload)
+		if err != nil {
+			for _, a := range attached {
+				a.stdinW.CloseWithError(errors.New("Connection closed"))
+			}
+
+			return
+		}
+
+		a, found := attached[payload.GetProcessId()]
+		if !found {
+			logger.Error("stream-input-unknown-process", nil, lager.Data{"payload": payload})
+			continue
+		}
+
+		switch {
+		case payload.Tty != nil:
+			a.process.SetTTY(*ttySpecFrom(payload.GetTty()))
+
+		case payload.Rlimits != nil:
+			s.setRlimits(logger, a.process, payload.GetRlimits())
+
+		case payload.AllocateTty != nil:
+			s.allocateTTY(logger, out, a.process, payload.GetAllocateTty())
+
+		case payload.Source != nil:
+			if payload.Data == nil {
+				a.stdinW.Close()
+			} else {
+				a.stdin.write(a.stdinW, out, a.process.ID(), []byte(payload.GetData()))
+			}
+
+		default:
+			logger.Error("stream-input-unknown-process-payload", nil, lager.Data{"payload": payload})
+			a.stdinW.Close()
+		}
+	}
+}
+
+func (s *GardenServer) streamProcess(logger lager.Logger, out transport.MessageWriter, process api.Process, stdout <-chan []byte, stderr <-chan []byte, stdinPipe *io.PipeWriter) {
+	s.streaming.Add(1)
+	defer s.streaming.Done()
+
 	statusCh := make(chan int, 1)
 	errCh := make(chan error, 1)
 
@@ -1298,48 +3449,48 @@ func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process
 		}
 	}()
 
-	stdoutSource := protocol.ProcessPayload_stdout
-	stderrSource := protocol.ProcessPayload_stderr
+	stdoutSource := apitypes.ProcessPayload_stdout
+	stderrSource := apitypes.ProcessPayload_stderr
 
 	for {
 		select {
 		case data := <-stdout:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
+			out.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(process.ID()),
 				Source:    &stdoutSource,
-				Data:      proto.String(string(data)),
+				Data:      apitypes.String(string(data)),
 			})
 
 		case data := <-stderr:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
+			out.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(process.ID()),
 				Source:    &stderrSource,
-				Data:      proto.String(string(data)),
+				Data:      apitypes.String(string(data)),
 			})
 
 		case status := <-statusCh:
-			flushProcess(conn, process, stdout, stderr)
+			flushProcess(out, process, stdout, stderr)
 
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId:  proto.Uint32(process.ID()),
-				ExitStatus: proto.Uint32(uint32(status)),
+			out.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId:  apitypes.Uint32(process.ID()),
+				ExitStatus: apitypes.Uint32(uint32(status)),
 			})
 
 			stdinPipe.Close()
 			return
 
 		case err := <-errCh:
-			flushProcess(conn, process, stdout, stderr)
+			flushProcess(out, process, stdout, stderr)
 
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
-				Error:     proto.String(err.Error()),
+			out.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(process.ID()),
+				Error:     apitypes.String(err.Error()),
 			})
 
 			stdinPipe.Close()
 			return
 
-		case <-s.stopping:
+		case <-s.detaching:
 			logger.Debug("detaching", lager.Data{
 				"id": process.ID(),
 			})
@@ -1349,24 +3500,24 @@ func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process
 	}
 }
 
-func flushProcess(conn net.Conn, process api.Process, stdout <-chan []byte, stderr <-chan []byte) {
-	stdoutSource := protocol.ProcessPayload_stdout
-	stderrSource := protocol.ProcessPayload_stderr
+func flushProcess(out transport.MessageWriter, process api.Process, stdout <-chan []byte, stderr <-chan []byte) {
+	stdoutSource := apitypes.ProcessPayload_stdout
+	stderrSource := apitypes.ProcessPayload_stderr
 
 	for {
 		select {
 		case data := <-stdout:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
+			out.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(process.ID()),
 				Source:    &stdoutSource,
-				Data:      proto.String(string(data)),
+				Data:      apitypes.String(string(data)),
 			})
 
 		case data := <-stderr:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
+			out.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(process.ID()),
 				Source:    &stderrSource,
-				Data:      proto.String(string(data)),
+				Data:      apitypes.String(string(data)),
 			})
 
 		default:
@@ -1375,7 +3526,41 @@ func flushProcess(conn net.Conn, process api.Process, stdout <-chan []byte, stde
 	}
 }
 
-func ttySpecFrom(tty *protocol.TTY) *api.TTYSpec {
+// ackStdin tells the client that a stdin payload has been consumed by the
+// process, freeing a slot in its send window.
+func ackStdin(out transport.MessageWriter, processID uint32) {
+	out.WriteMessage(&apitypes.ProcessPayload{
+		ProcessId: apitypes.Uint32(processID),
+		StdinAck:  apitypes.Uint32(1),
+	})
+}
+
+// negotiateFraming agrees to binary framing of the process stream if the
+// client asked for it. It must be called before writing the response header.
+func negotiateFraming(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get(transport.ProcessStreamFramingHeader) != transport.FramingBinary {
+		return false
+	}
+
+	w.Header().Set(transport.ProcessStreamFramingHeader, transport.FramingBinary)
+
+	return true
+}
+
+// processStreams returns the reader and writer for a hijacked process stream.
+// Writes are serialized, as output streaming and stdin acknowledgements share
+// the connection.
+func processStreams(framed bool, r io.Reader, conn io.Writer) (transport.MessageReader, transport.MessageWriter) {
+	out := &lockedWriter{w: conn}
+
+	if framed {
+		return transport.NewFramedReader(r), transport.NewFramedWriter(out)
+	}
+
+	return transport.NewJSONReader(r), transport.NewJSONWriter(out)
+}
+
+func ttySpecFrom(tty *apitypes.TTY) *api.TTYSpec {
 	var ttySpec *api.TTYSpec
 	if tty != nil {
 		ttySpec = &api.TTYSpec{}
@@ -1,13 +1,20 @@
 package server_test
 
 import (
+	"archive/tar"
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
 	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,9 +25,11 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden/api"
 	"github.com/cloudfoundry-incubator/garden/api/fakes"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
 	"github.com/cloudfoundry-incubator/garden/client"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
 	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
 var _ = Describe("When a client connects", func() {
@@ -28,13 +37,16 @@ var _ = Describe("When a client connects", func() {
 	var tmpdir string
 
 	var serverBackend *fakes.FakeBackend
+	var serverRenamer *fakes.FakeRenamer
+	var serverSnapshotter *fakes.FakeSnapshotter
 
 	var serverContainerGraceTime time.Duration
+	var serverErrorAnnotationKeys []string
 
 	var logger *lagertest.TestLogger
 
 	var apiServer *server.GardenServer
-	var apiClient api.Client
+	var apiClient client.Client
 	var isRunning bool
 
 	BeforeEach(func() {
@@ -46,16 +58,21 @@ var _ = Describe("When a client connects", func() {
 
 		socketPath = path.Join(tmpdir, "api.sock")
 		serverBackend = new(fakes.FakeBackend)
+		serverRenamer = new(fakes.FakeRenamer)
+		serverSnapshotter = new(fakes.FakeSnapshotter)
 		serverContainerGraceTime = 42 * time.Second
+		serverErrorAnnotationKeys = []string{"owner"}
 
 		apiServer = server.New(
 			"unix",
 			socketPath,
 			serverContainerGraceTime,
-			serverBackend,
+			capableBackend{serverBackend, serverRenamer, serverSnapshotter},
 			logger,
 		)
 
+		apiServer.SetErrorAnnotationKeys(serverErrorAnnotationKeys)
+
 		err = apiServer.Start()
 		Ω(err).ShouldNot(HaveOccurred())
 
@@ -104,6 +121,82 @@ var _ = Describe("When a client connects", func() {
 		})
 	})
 
+	Context("and the client asks for the protocol version", func() {
+		It("returns the version the server speaks", func() {
+			version, err := connection.New("unix", socketPath).ProtocolVersion()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(version).Should(Equal(api.ProtocolVersion))
+		})
+
+		It("returns the capabilities the server supports", func() {
+			version, err := connection.New("unix", socketPath).ServerVersion()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(version.Capabilities).Should(Equal(api.Capabilities))
+		})
+
+		It("is accepted by a strict client", func() {
+			_, err := client.NewStrict(connection.New("unix", socketPath))
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("and the client checks the server's health", func() {
+		BeforeEach(func() {
+			serverBackend.ContainersReturns([]api.Container{
+				new(fakes.FakeContainer),
+				new(fakes.FakeContainer),
+			}, nil)
+
+			serverBackend.CapacityReturns(api.Capacity{MaxContainers: 5}, nil)
+		})
+
+		It("reports the backend's containers and headroom", func() {
+			status, err := client.New(connection.New("unix", socketPath)).HealthCheck()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(status.Healthy).Should(BeTrue())
+			Ω(status.BackendError).Should(BeEmpty())
+			Ω(status.Containers).Should(Equal(uint64(2)))
+			Ω(status.MaxContainers).Should(Equal(uint64(5)))
+			Ω(status.ContainerHeadroom).Should(Equal(uint64(3)))
+			Ω(status.ProtocolVersion).Should(Equal(api.ProtocolVersion))
+		})
+
+		Context("when the backend is unreachable", func() {
+			BeforeEach(func() {
+				serverBackend.PingReturns(errors.New("oh no!"))
+			})
+
+			It("reports why", func() {
+				status, err := client.New(connection.New("unix", socketPath)).HealthCheck()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(status.Healthy).Should(BeFalse())
+				Ω(status.BackendError).Should(Equal("oh no!"))
+			})
+
+			It("responds with 503 Service Unavailable", func() {
+				conn, err := net.Dial("unix", socketPath)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				defer conn.Close()
+
+				request, err := http.NewRequest("GET", "http://api/healthcheck", nil)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = request.Write(conn)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				response, err := http.ReadResponse(bufio.NewReader(conn), request)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(response.StatusCode).Should(Equal(http.StatusServiceUnavailable))
+			})
+		})
+	})
+
 	Context("and the client sends a CapacityRequest", func() {
 		BeforeEach(func() {
 			serverBackend.CapacityReturns(api.Capacity{
@@ -171,7 +264,8 @@ var _ = Describe("When a client connects", func() {
 					"prop-a": "val-a",
 					"prop-b": "val-b",
 				},
-				Env: []string{"env1=env1Value", "env2=env2Value"},
+				Env:                []string{"env1=env1Value", "env2=env2Value"},
+				DefaultProcessUser: "some-user",
 			})
 			Ω(err).ShouldNot(HaveOccurred())
 
@@ -192,8 +286,133 @@ var _ = Describe("When a client connects", func() {
 					"prop-a": "val-a",
 					"prop-b": "val-b",
 				},
-				Env: []string{"env1=env1Value", "env2=env2Value"},
+				Env:                []string{"env1=env1Value", "env2=env2Value"},
+				DefaultProcessUser: "some-user",
+			}))
+		})
+
+		It("creates the container with environment values intact, however they are written", func() {
+			env := []string{"EMPTY=", "EQUATION=a=b+c", "LINES=one\ntwo\n"}
+
+			_, err := apiClient.Create(api.ContainerSpec{
+				Handle: "some-handle",
+				Env:    env,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			spec := serverBackend.CreateArgsForCall(0)
+			Ω(spec.Env).Should(Equal(env))
+		})
+
+		Context("when an environment variable is not KEY=VALUE", func() {
+			It("fails without asking the server", func() {
+				_, err := apiClient.Create(api.ContainerSpec{
+					Handle: "some-handle",
+					Env:    []string{"FLAVOR=chocolate", "TOPPINGS"},
+				})
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldEnv,
+					Reason: "must be KEY=VALUE pairs",
+				}))
+
+				Ω(serverBackend.CreateCallCount()).Should(BeZero())
+			})
+		})
+
+		Context("when placing the container's outbound traffic", func() {
+			It("creates the container with the chosen address and interface", func() {
+				_, err := apiClient.Create(api.ContainerSpec{
+					Handle:            "some-handle",
+					ExternalIP:        "10.0.0.1",
+					ExternalInterface: "eth1",
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				spec := serverBackend.CreateArgsForCall(0)
+				Ω(spec.ExternalIP).Should(Equal("10.0.0.1"))
+				Ω(spec.ExternalInterface).Should(Equal("eth1"))
+			})
+
+			Context("when they are invalid", func() {
+				It("returns an InvalidRequestError naming each, without creating the container", func() {
+					_, err := apiClient.Create(api.ContainerSpec{
+						Handle:            "some-handle",
+						ExternalIP:        "banana",
+						ExternalInterface: "eth1/../eth0",
+					})
+					Ω(err).Should(Equal(api.InvalidRequestError{
+						Violations: []api.ValidationError{
+							{Field: "external_ip", Reason: "must be an IP address"},
+							{Field: "external_interface", Reason: "must be a network interface's name"},
+						},
+					}))
+
+					Ω(serverBackend.CreateCallCount()).Should(BeZero())
+				})
+			})
+		})
+
+		Context("when creating the container from an image", func() {
+			image := api.ImageRef{
+				URI:      "oci://registry.example.com/some/image:tag",
+				Username: "some-user",
+				Password: "some-password",
+			}
+
+			It("passes the image to the backend untouched", func() {
+				_, err := apiClient.Create(api.ContainerSpec{Image: image})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(serverBackend.CreateArgsForCall(0).Image).Should(Equal(image))
+			})
+
+			It("does not log its password", func() {
+				_, err := apiClient.Create(api.ContainerSpec{Image: image})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				logs, err := json.Marshal(logger.Logs())
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(string(logs)).Should(ContainSubstring("some-user"))
+				Ω(string(logs)).ShouldNot(ContainSubstring("some-password"))
+			})
+		})
+
+		It("returns the created container's info and grace time", func() {
+			fakeContainer.InfoReturns(api.ContainerInfo{
+				State:       "active",
+				ContainerIP: "container-ip",
+				MappedPorts: []api.PortMapping{
+					{HostPort: 1234, ContainerPort: 5678, Protocol: api.ProtocolTCP},
+				},
+			}, nil)
+
+			serverBackend.GraceTimeReturns(42 * time.Second)
+
+			_, info, err := connection.New("unix", socketPath).Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info).ShouldNot(BeNil())
+			Ω(info.State).Should(Equal("active"))
+			Ω(info.ContainerIP).Should(Equal("container-ip"))
+			Ω(info.MappedPorts).Should(Equal([]api.PortMapping{
+				{HostPort: 1234, ContainerPort: 5678, Protocol: api.ProtocolTCP},
 			}))
+			Ω(info.GraceTime).Should(Equal(42 * time.Second))
+		})
+
+		Context("when getting the created container's info fails", func() {
+			BeforeEach(func() {
+				fakeContainer.InfoReturns(api.ContainerInfo{}, errors.New("oh no!"))
+			})
+
+			It("returns the handle without any info", func() {
+				handle, info, err := connection.New("unix", socketPath).Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(handle).Should(Equal("some-handle"))
+				Ω(info).Should(BeNil())
+			})
 		})
 
 		Context("when a grace time is given", func() {
@@ -245,7 +464,63 @@ var _ = Describe("When a client connects", func() {
 		})
 	})
 
+	Context("and the client sends a restore request", func() {
+		var fakeContainer *fakes.FakeContainer
+
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("restored-handle")
+
+			serverSnapshotter.RestoreReturns(fakeContainer, nil)
+		})
+
+		It("restores the container from the streamed snapshot", func() {
+			serverSnapshotter.RestoreStub = func(snapshot io.Reader) (api.Container, error) {
+				Ω(ioutil.ReadAll(snapshot)).Should(Equal([]byte("some-snapshot")))
+				return fakeContainer, nil
+			}
+
+			container, err := apiClient.Restore(bytes.NewBufferString("some-snapshot"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.Handle()).Should(Equal("restored-handle"))
+		})
+
+		Context("when a grace time is configured", func() {
+			It("destroys the restored container after it has been idle for the grace time", func() {
+				graceTime := time.Second
+
+				serverBackend.GraceTimeReturns(graceTime)
+
+				before := time.Now()
+
+				_, err := apiClient.Restore(bytes.NewBufferString("some-snapshot"))
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
+				Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("restored-handle"))
+
+				Ω(time.Since(before)).Should(BeNumerically("~", graceTime, 100*time.Millisecond))
+			})
+		})
+
+		Context("when restoring the container fails", func() {
+			BeforeEach(func() {
+				serverSnapshotter.RestoreReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, err := apiClient.Restore(bytes.NewBufferString("some-snapshot"))
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
 	Context("and the client sends a destroy request", func() {
+		BeforeEach(func() {
+			serverBackend.LookupReturns(new(fakes.FakeContainer), nil)
+		})
+
 		It("destroys the container", func() {
 			err := apiClient.Destroy("some-handle")
 			Ω(err).ShouldNot(HaveOccurred())
@@ -253,6 +528,20 @@ var _ = Describe("When a client connects", func() {
 			Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
 		})
 
+		Context("with a reason", func() {
+			It("destroys the container, logging the reason", func() {
+				err := client.New(connection.New("unix", socketPath)).DestroyWithReason("some-handle", false, "tenant deleted")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
+
+				logs, err := json.Marshal(logger.Logs())
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(string(logs)).Should(ContainSubstring("tenant deleted"))
+			})
+		})
+
 		Context("concurrent with other destroy requests", func() {
 			var destroying chan struct{}
 
@@ -272,12 +561,164 @@ var _ = Describe("When a client connects", func() {
 				<-destroying
 
 				err := apiClient.Destroy("some-handle")
-				Ω(err).Should(HaveOccurred())
+				Ω(err).Should(Equal(api.ErrDestroyInProgress))
+
+				Ω(serverBackend.DestroyCallCount()).Should(Equal(1))
+			})
+
+			It("returns the destroy in progress to asynchronous destroys", func() {
+				gardenClient := client.New(connection.New("unix", socketPath))
+
+				firstID, err := gardenClient.DestroyAsync("some-handle", false)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				<-destroying
+
+				secondID, err := gardenClient.DestroyAsync("some-handle", false)
+				Ω(err).ShouldNot(HaveOccurred())
 
+				Ω(secondID).Should(Equal(firstID))
 				Ω(serverBackend.DestroyCallCount()).Should(Equal(1))
 			})
 		})
 
+		Context("when forced", func() {
+			var fakeContainer *fakes.FakeContainer
+			var stoppedBeforeDestroying bool
+
+			BeforeEach(func() {
+				fakeContainer = new(fakes.FakeContainer)
+				serverBackend.LookupReturns(fakeContainer, nil)
+
+				stoppedBeforeDestroying = false
+
+				serverBackend.DestroyStub = func(string) error {
+					stoppedBeforeDestroying = fakeContainer.StopCallCount() == 1
+					return nil
+				}
+			})
+
+			It("kills the container's processes before destroying it", func() {
+				err := client.New(connection.New("unix", socketPath)).ForceDestroy("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(stoppedBeforeDestroying).Should(BeTrue())
+				Ω(fakeContainer.StopArgsForCall(0)).Should(BeTrue())
+				Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
+			})
+
+			Context("when killing the processes fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StopReturns(errors.New("oh no!"))
+				})
+
+				It("destroys the container anyway", func() {
+					err := client.New(connection.New("unix", socketPath)).ForceDestroy("some-handle")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(serverBackend.DestroyCallCount()).Should(Equal(1))
+				})
+			})
+		})
+
+		Context("asynchronously", func() {
+			var gardenClient client.Client
+			var destroyed chan error
+
+			BeforeEach(func() {
+				gardenClient = client.New(connection.New("unix", socketPath))
+
+				destroyed = make(chan error)
+
+				serverBackend.DestroyStub = func(string) error {
+					return <-destroyed
+				}
+			})
+
+			It("returns before the container is destroyed, reporting the destroy's progress", func() {
+				id, err := gardenClient.DestroyAsync("some-handle", false)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				operation, err := gardenClient.DestroyOperation(id)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(operation).Should(Equal(api.DestroyOperation{
+					ID:     id,
+					Handle: "some-handle",
+					State:  api.DestroyStateDestroying,
+				}))
+
+				destroyed <- nil
+
+				Eventually(func() api.DestroyState {
+					operation, err := gardenClient.DestroyOperation(id)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					return operation.State
+				}).Should(Equal(api.DestroyStateDestroyed))
+
+				Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
+			})
+
+			Context("when destroying the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer := new(fakes.FakeContainer)
+					fakeContainer.GetPropertyReturns("some-team", nil)
+
+					serverBackend.LookupReturns(fakeContainer, nil)
+				})
+
+				It("reports the error, with the container's annotations", func() {
+					id, err := gardenClient.DestroyAsync("some-handle", false)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					destroyed <- errors.New("oh no!")
+
+					var operation api.DestroyOperation
+					Eventually(func() api.DestroyState {
+						operation, err = gardenClient.DestroyOperation(id)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						return operation.State
+					}).Should(Equal(api.DestroyStateFailed))
+
+					Ω(operation.Error).Should(Equal(&connection.GardenError{
+						Message: "oh no!",
+						Annotations: map[string]string{
+							"owner": "some-team",
+						},
+						Class: api.ErrorClassTerminal,
+					}))
+				})
+			})
+
+			Context("when forced", func() {
+				var fakeContainer *fakes.FakeContainer
+
+				BeforeEach(func() {
+					fakeContainer = new(fakes.FakeContainer)
+					serverBackend.LookupReturns(fakeContainer, nil)
+				})
+
+				It("kills the container's processes before destroying it", func() {
+					_, err := gardenClient.DestroyAsync("some-handle", true)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					destroyed <- nil
+
+					Ω(fakeContainer.StopCallCount()).Should(Equal(1))
+					Ω(fakeContainer.StopArgsForCall(0)).Should(BeTrue())
+				})
+			})
+		})
+
+		Context("when looking up a destroy the server does not know", func() {
+			It("fails with api.ErrOperationNotFound", func() {
+				_, err := client.New(connection.New("unix", socketPath)).DestroyOperation("bogus-id")
+				Ω(err).Should(Equal(api.ErrOperationNotFound))
+			})
+		})
+
 		Context("when destroying the container fails", func() {
 			BeforeEach(func() {
 				serverBackend.DestroyReturns(errors.New("oh no!"))
@@ -286,6 +727,26 @@ var _ = Describe("When a client connects", func() {
 			It("sends a GardenError response", func() {
 				err := apiClient.Destroy("some-handle")
 				Ω(err).Should(HaveOccurred())
+				Ω(err).Should(BeAssignableToTypeOf(&connection.GardenError{}))
+				Ω(err.Error()).Should(Equal("oh no!"))
+			})
+
+			Context("when the container has annotated properties", func() {
+				BeforeEach(func() {
+					fakeContainer := new(fakes.FakeContainer)
+					fakeContainer.GetPropertyReturns("some-team", nil)
+
+					serverBackend.LookupReturns(fakeContainer, nil)
+				})
+
+				It("includes them in the error, looked up before destroying", func() {
+					err := apiClient.Destroy("some-handle")
+					Ω(err).Should(HaveOccurred())
+
+					Ω(err.(*connection.GardenError).Annotations).Should(Equal(map[string]string{
+						"owner": "some-team",
+					}))
+				})
 			})
 
 			Context("and destroying is attempted again", func() {
@@ -306,21 +767,248 @@ var _ = Describe("When a client connects", func() {
 		})
 	})
 
-	Context("and the client sends a ListRequest", func() {
+	Context("and the client sends a rename request", func() {
+		var renamedContainer *fakes.FakeContainer
+
 		BeforeEach(func() {
-			c1 := new(fakes.FakeContainer)
-			c1.HandleReturns("some-handle")
+			renamedContainer = new(fakes.FakeContainer)
+			renamedContainer.HandleReturns("new-handle")
 
-			c2 := new(fakes.FakeContainer)
-			c2.HandleReturns("another-handle")
+			serverBackend.LookupReturns(renamedContainer, nil)
+		})
 
-			c3 := new(fakes.FakeContainer)
-			c3.HandleReturns("super-handle")
+		It("renames the container", func() {
+			err := apiClient.Rename("some-handle", "new-handle")
+			Ω(err).ShouldNot(HaveOccurred())
 
-			serverBackend.ContainersReturns([]api.Container{c1, c2, c3}, nil)
+			oldHandle, newHandle := serverRenamer.RenameArgsForCall(0)
+			Ω(oldHandle).Should(Equal("some-handle"))
+			Ω(newHandle).Should(Equal("new-handle"))
 		})
 
-		It("returns the containers from the backend", func() {
+		Context("when the container was created with a grace time", func() {
+			graceTime := 200 * time.Millisecond
+
+			BeforeEach(func() {
+				serverBackend.GraceTimeReturns(graceTime)
+
+				createdContainer := new(fakes.FakeContainer)
+				createdContainer.HandleReturns("some-handle")
+
+				serverBackend.CreateReturns(createdContainer, nil)
+
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("destroys it under its new handle once the grace time elapses", func() {
+				err := apiClient.Rename("some-handle", "new-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
+				Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("new-handle"))
+			})
+
+			Context("when renaming the container fails", func() {
+				BeforeEach(func() {
+					serverRenamer.RenameReturns(errors.New("oh no!"))
+				})
+
+				It("still destroys it under its old handle", func() {
+					err := apiClient.Rename("some-handle", "new-handle")
+					Ω(err).Should(HaveOccurred())
+
+					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
+					Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
+				})
+			})
+		})
+
+		Context("when the container was created with process defaults", func() {
+			BeforeEach(func() {
+				createdContainer := new(fakes.FakeContainer)
+				createdContainer.HandleReturns("some-handle")
+
+				serverBackend.CreateReturns(createdContainer, nil)
+
+				renamedContainer.RunReturns(new(fakes.FakeProcess), nil)
+
+				_, err := apiClient.Create(api.ContainerSpec{
+					Env:                []string{"FLAVOR=vanilla"},
+					DefaultProcessUser: "default-user",
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("runs its processes with them under its new handle", func() {
+				err := apiClient.Rename("some-handle", "new-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = connection.New("unix", socketPath).Run("new-handle", api.ProcessSpec{
+					Path: "ls",
+				}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				ranSpec, _ := renamedContainer.RunArgsForCall(0)
+				Ω(ranSpec.User).Should(Equal("default-user"))
+				Ω(ranSpec.Env).Should(Equal([]string{"FLAVOR=vanilla"}))
+			})
+		})
+
+		Context("when the container has processes across the rename", func() {
+			var originalContainer *fakes.FakeContainer
+
+			BeforeEach(func() {
+				originalContainer = new(fakes.FakeContainer)
+				originalContainer.HandleReturns("some-handle")
+
+				serverBackend.LookupStub = func(handle string) (api.Container, error) {
+					if handle == "new-handle" {
+						return renamedContainer, nil
+					}
+
+					return originalContainer, nil
+				}
+			})
+
+			It("counts those still starting against the limit under its new handle", func() {
+				apiServer.SetMaxProcessesPerContainer(1)
+
+				started := make(chan struct{})
+				release := make(chan struct{})
+				defer close(release)
+
+				originalContainer.RunStub = func(api.ProcessSpec, api.ProcessIO) (api.Process, error) {
+					close(started)
+					<-release
+					return new(fakes.FakeProcess), nil
+				}
+
+				go connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+
+				<-started
+
+				err := apiClient.Rename("some-handle", "new-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = connection.New("unix", socketPath).Run("new-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+				Ω(err).Should(Equal(api.ErrProcessLimitExceeded))
+
+				Ω(renamedContainer.RunCallCount()).Should(BeZero())
+			})
+
+			It("finds them by their labels under its new handle", func() {
+				process := new(fakes.FakeProcess)
+				process.IDReturns(5)
+
+				originalContainer.RunReturns(process, nil)
+				renamedContainer.InfoFieldsReturns(api.ContainerInfo{ProcessIDs: []uint32{5}}, nil)
+
+				conn := connection.New("unix", socketPath)
+
+				_, err := conn.Run("some-handle", api.ProcessSpec{
+					Path:   "/some/daemon",
+					Labels: map[string]string{"role": "daemon"},
+				}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = apiClient.Rename("some-handle", "new-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				processIDs, err := conn.FindProcesses("new-handle", map[string]string{"role": "daemon"})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processIDs).Should(Equal([]uint32{5}))
+
+				processID, err := conn.WaitForProcess("new-handle", api.ProcessMatcher{
+					Labels: map[string]string{"role": "daemon"},
+				}, time.Second)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processID).Should(Equal(uint32(5)))
+			})
+
+			Context("when the container was created with a grace time", func() {
+				graceTime := 200 * time.Millisecond
+
+				BeforeEach(func() {
+					serverBackend.GraceTimeReturns(graceTime)
+					serverBackend.CreateReturns(originalContainer, nil)
+
+					_, err := apiClient.Create(api.ContainerSpec{})
+					Ω(err).ShouldNot(HaveOccurred())
+				})
+
+				It("destroys it under its new handle once the grace time elapses after they exit", func() {
+					exit := make(chan struct{})
+
+					process := new(fakes.FakeProcess)
+					process.WaitStub = func() (int, error) {
+						<-exit
+						return 0, nil
+					}
+
+					originalContainer.RunReturns(process, nil)
+
+					streamed, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = apiClient.Rename("some-handle", "new-handle")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Consistently(serverBackend.DestroyCallCount, 2*graceTime).Should(BeZero())
+
+					close(exit)
+
+					_, err = streamed.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
+					Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("new-handle"))
+				})
+			})
+		})
+
+		Context("when renaming the container fails", func() {
+			BeforeEach(func() {
+				serverRenamer.RenameReturns(errors.New("oh no!"))
+			})
+
+			It("sends a GardenError response", func() {
+				err := apiClient.Rename("some-handle", "new-handle")
+				Ω(err).Should(HaveOccurred())
+				Ω(err).Should(BeAssignableToTypeOf(&connection.GardenError{}))
+				Ω(err.Error()).Should(Equal("oh no!"))
+			})
+		})
+
+		Context("when the new handle is empty", func() {
+			It("returns an InvalidRequestError without renaming", func() {
+				err := apiClient.Rename("some-handle", "")
+				Ω(err).Should(Equal(api.InvalidRequestError{
+					Violations: []api.ValidationError{
+						{Field: "new_handle", Reason: "must not be empty"},
+					},
+				}))
+
+				Ω(serverRenamer.RenameCallCount()).Should(Equal(0))
+			})
+		})
+	})
+
+	Context("and the client sends a ListRequest", func() {
+		BeforeEach(func() {
+			c1 := new(fakes.FakeContainer)
+			c1.HandleReturns("some-handle")
+
+			c2 := new(fakes.FakeContainer)
+			c2.HandleReturns("another-handle")
+
+			c3 := new(fakes.FakeContainer)
+			c3.HandleReturns("super-handle")
+
+			serverBackend.ContainersReturns([]api.Container{c1, c2, c3}, nil)
+		})
+
+		It("returns the containers from the backend", func() {
 			containers, err := apiClient.Containers(nil)
 			Ω(err).ShouldNot(HaveOccurred())
 
@@ -336,6 +1024,63 @@ var _ = Describe("When a client connects", func() {
 			Ω(handles).Should(ContainElement("super-handle"))
 		})
 
+		It("lists them by handle", func() {
+			handles, err := connection.New("unix", socketPath).List(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(handles).Should(Equal([]string{"another-handle", "some-handle", "super-handle"}))
+		})
+
+		Context("when asked to list them by creation time", func() {
+			BeforeEach(func() {
+				created := time.Unix(1000000, 0)
+
+				c1 := new(fakes.FakeContainer)
+				c1.HandleReturns("newest")
+				c1.InfoReturns(api.ContainerInfo{CreatedAt: created.Add(time.Minute)}, nil)
+
+				c2 := new(fakes.FakeContainer)
+				c2.HandleReturns("oldest")
+				c2.InfoReturns(api.ContainerInfo{CreatedAt: created}, nil)
+
+				c3 := new(fakes.FakeContainer)
+				c3.HandleReturns("also-oldest")
+				c3.InfoReturns(api.ContainerInfo{CreatedAt: created}, nil)
+
+				c4 := new(fakes.FakeContainer)
+				c4.HandleReturns("unknown")
+
+				serverBackend.ContainersReturns([]api.Container{c1, c2, c3, c4}, nil)
+			})
+
+			It("lists them from the oldest, breaking ties by handle", func() {
+				summaries, err := connection.New("unix", socketPath).ListVerbose(nil, api.ListOrderCreated)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				handles := []string{}
+				for _, summary := range summaries {
+					handles = append(handles, summary.Handle)
+				}
+
+				Ω(handles).Should(Equal([]string{"unknown", "also-oldest", "oldest", "newest"}))
+			})
+		})
+
+		Context("when asked for an unknown order", func() {
+			It("returns an InvalidRequestError without listing", func() {
+				listings := serverBackend.ContainersCallCount()
+
+				_, err := connection.New("unix", socketPath).ListVerbose(nil, "size")
+				Ω(err).Should(Equal(api.InvalidRequestError{
+					Violations: []api.ValidationError{
+						{Field: transport.ListSortHeader, Reason: "must be handle or created"},
+					},
+				}))
+
+				Ω(serverBackend.ContainersCallCount()).Should(Equal(listings))
+			})
+		})
+
 		Context("when getting the containers fails", func() {
 			BeforeEach(func() {
 				serverBackend.ContainersReturns(nil, errors.New("oh no!"))
@@ -347,6 +1092,126 @@ var _ = Describe("When a client connects", func() {
 			})
 		})
 
+		Context("and the client asks for a verbose listing", func() {
+			BeforeEach(func() {
+				c1 := new(fakes.FakeContainer)
+				c1.HandleReturns("some-handle")
+				c1.InfoReturns(api.ContainerInfo{
+					State:      "active",
+					Properties: api.Properties{"foo": "bar"},
+				}, nil)
+
+				serverBackend.ContainersReturns([]api.Container{c1}, nil)
+			})
+
+			It("returns each container's state and properties", func() {
+				summaries, err := connection.New("unix", socketPath).ListVerbose(nil, "")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(summaries).Should(Equal([]api.ContainerSummary{
+					{
+						Handle:     "some-handle",
+						State:      "active",
+						Properties: api.Properties{"foo": "bar"},
+					},
+				}))
+			})
+
+			It("filters by properties named like the listing's options", func() {
+				_, err := connection.New("unix", socketPath).ListVerbose(api.Properties{
+					"verbose": "false",
+					"sort":    "size",
+					"stream":  "true",
+				}, api.ListOrderHandle)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).Should(Equal(api.Properties{
+					"verbose": "false",
+					"sort":    "size",
+					"stream":  "true",
+				}))
+			})
+
+			Context("when getting a container's info fails", func() {
+				BeforeEach(func() {
+					c1 := new(fakes.FakeContainer)
+					c1.InfoReturns(api.ContainerInfo{}, errors.New("oh no!"))
+
+					serverBackend.ContainersReturns([]api.Container{c1}, nil)
+				})
+
+				It("returns an error", func() {
+					_, err := connection.New("unix", socketPath).ListVerbose(nil, "")
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Context("and the client streams the listing", func() {
+			var gardenClient client.Client
+
+			BeforeEach(func() {
+				gardenClient = client.New(connection.New("unix", socketPath))
+			})
+
+			It("calls back with each container, by handle", func() {
+				handles := []string{}
+
+				err := gardenClient.EachContainer(nil, func(container api.Container) error {
+					handles = append(handles, container.Handle())
+					return nil
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(handles).Should(Equal([]string{"another-handle", "some-handle", "super-handle"}))
+			})
+
+			It("stops at the first error the callback returns", func() {
+				disaster := errors.New("oh no!")
+
+				handles := []string{}
+
+				err := gardenClient.EachContainer(nil, func(container api.Container) error {
+					handles = append(handles, container.Handle())
+					return disaster
+				})
+				Ω(err).Should(Equal(disaster))
+
+				Ω(handles).Should(Equal([]string{"another-handle"}))
+			})
+
+			It("does not treat stream as a property filter", func() {
+				err := gardenClient.EachContainer(api.Properties{
+					"foo": "bar",
+				}, func(api.Container) error {
+					return nil
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).Should(Equal(api.Properties{
+					"foo": "bar",
+				}))
+			})
+
+			Context("when getting the containers fails", func() {
+				BeforeEach(func() {
+					serverBackend.ContainersReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns an error without calling back", func() {
+					called := false
+
+					err := gardenClient.EachContainer(nil, func(api.Container) error {
+						called = true
+						return nil
+					})
+					Ω(err).Should(HaveOccurred())
+
+					Ω(called).Should(BeFalse())
+				})
+			})
+		})
+
 		Context("and the client sends a ListRequest with a property filter", func() {
 			It("forwards the filter to the backend", func() {
 				_, err := apiClient.Containers(api.Properties{
@@ -361,51 +1226,264 @@ var _ = Describe("When a client connects", func() {
 				))
 			})
 		})
-	})
 
-	Context("when a container has been created", func() {
-		var container api.Container
+		Context("and the client sends a ListRequest with property filter operators", func() {
+			var gardenClient client.Client
 
-		var fakeContainer *fakes.FakeContainer
+			BeforeEach(func() {
+				gardenClient = client.New(connection.New("unix", socketPath))
+
+				containers := []api.Container{}
+				for handle, properties := range map[string]api.Properties{
+					"team-a-1": {"owner": "team-a", "env": "prod"},
+					"team-a-2": {"owner": "team-a", "env": "dev"},
+					"team-b-1": {"owner": "team-b", "env": "prod"},
+					"unowned":  {"env": "prod"},
+				} {
+					container := new(fakes.FakeContainer)
+					container.HandleReturns(handle)
+					container.PropertiesReturns(properties, nil)
+
+					containers = append(containers, container)
+				}
 
-		BeforeEach(func() {
-			fakeContainer = new(fakes.FakeContainer)
-			fakeContainer.HandleReturns("some-handle")
+				serverBackend.ContainersReturns(containers, nil)
+			})
 
-			serverBackend.CreateReturns(fakeContainer, nil)
-			serverBackend.LookupReturns(fakeContainer, nil)
-		})
+			handlesOf := func(containers []api.Container) []string {
+				handles := []string{}
+				for _, container := range containers {
+					handles = append(handles, container.Handle())
+				}
 
-		JustBeforeEach(func() {
-			var err error
+				return handles
+			}
 
-			container, err = apiClient.Create(api.ContainerSpec{})
-			Ω(err).ShouldNot(HaveOccurred())
-		})
+			It("forwards exact matches to the backend, and applies the rest itself", func() {
+				containers, err := gardenClient.ContainersWhere([]api.PropertyFilter{
+					{Name: "env", Operator: api.PropertyEquals, Value: "prod"},
+					{Name: "owner", Operator: api.PropertyPrefix, Value: "team-"},
+					{Name: "owner", Operator: api.PropertyNotEquals, Value: "team-b"},
+				})
+				Ω(err).ShouldNot(HaveOccurred())
 
-		itResetsGraceTimeWhenHandling := func(call func()) {
-			Context("when created with a grace time", func() {
-				graceTime := 200 * time.Millisecond
+				Ω(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).Should(Equal(api.Properties{
+					"env": "prod",
+				}))
 
-				BeforeEach(func() {
-					serverBackend.GraceTimeReturns(graceTime)
+				// the fake backend ignores the exact match
+				Ω(handlesOf(containers)).Should(ConsistOf("team-a-1", "team-a-2"))
+			})
+
+			It("filters by whether containers have a property", func() {
+				containers, err := gardenClient.ContainersWhere([]api.PropertyFilter{
+					{Name: "owner", Operator: api.PropertyExists},
 				})
+				Ω(err).ShouldNot(HaveOccurred())
 
-				It("resets the container's grace time", func() {
-					for i := 0; i < 11; i++ {
-						time.Sleep(graceTime / 10)
-						call()
-					}
+				Ω(handlesOf(containers)).Should(ConsistOf("team-a-1", "team-a-2", "team-b-1"))
+			})
 
-					before := time.Now()
+			It("matches properties whose names contain colons exactly", func() {
+				_, err := gardenClient.ContainersWhere([]api.PropertyFilter{
+					{Name: "ne:owner", Operator: api.PropertyEquals, Value: "team-a"},
+				})
+				Ω(err).ShouldNot(HaveOccurred())
 
-					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
-					Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal(container.Handle()))
+				Ω(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).Should(Equal(api.Properties{
+					"ne:owner": "team-a",
+				}))
+			})
 
-					Ω(time.Since(before)).Should(BeNumerically("~", graceTime, 20*time.Millisecond))
+			Context("when getting a container's properties fails", func() {
+				BeforeEach(func() {
+					container := new(fakes.FakeContainer)
+					container.PropertiesReturns(nil, errors.New("oh no!"))
+
+					serverBackend.ContainersReturns([]api.Container{container}, nil)
 				})
-			})
-		}
+
+				It("returns an error", func() {
+					_, err := gardenClient.ContainersWhere([]api.PropertyFilter{
+						{Name: "owner", Operator: api.PropertyExists},
+					})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+	})
+
+	Context("and the client looks up a container by its properties", func() {
+		BeforeEach(func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.ContainersReturns([]api.Container{fakeContainer}, nil)
+		})
+
+		It("returns the container matching the filter", func() {
+			container, err := apiClient.LookupBy(api.Properties{
+				"foo": "bar",
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.Handle()).Should(Equal("some-handle"))
+
+			Ω(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).Should(Equal(api.Properties{
+				"foo": "bar",
+			}))
+		})
+
+		Context("when no container matches", func() {
+			BeforeEach(func() {
+				serverBackend.ContainersReturns([]api.Container{}, nil)
+			})
+
+			It("returns ErrContainerNotFound", func() {
+				_, err := apiClient.LookupBy(api.Properties{
+					"foo": "bar",
+				})
+				Ω(err).Should(Equal(api.ErrContainerNotFound))
+			})
+		})
+
+		Context("when several containers match", func() {
+			BeforeEach(func() {
+				serverBackend.ContainersReturns([]api.Container{
+					new(fakes.FakeContainer),
+					new(fakes.FakeContainer),
+				}, nil)
+			})
+
+			It("returns ErrMultipleContainersMatch", func() {
+				_, err := apiClient.LookupBy(api.Properties{
+					"foo": "bar",
+				})
+				Ω(err).Should(Equal(api.ErrMultipleContainersMatch))
+			})
+		})
+
+		Context("when listing the containers fails", func() {
+			BeforeEach(func() {
+				serverBackend.ContainersReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, err := apiClient.LookupBy(api.Properties{
+					"foo": "bar",
+				})
+				Ω(err).Should(MatchError("oh no!"))
+			})
+		})
+	})
+
+	Context("and the client gets the properties of several containers", func() {
+		var fakeContainer *fakes.FakeContainer
+
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.PropertiesReturns(api.Properties{
+				"foo": "bar",
+				"baz": "quux",
+			}, nil)
+
+			serverBackend.LookupStub = func(handle string) (api.Container, error) {
+				if handle == "some-handle" {
+					return fakeContainer, nil
+				}
+
+				return nil, api.ErrContainerNotFound
+			}
+		})
+
+		It("returns the properties of each container that exists, by handle", func() {
+			containers, err := client.New(connection.New("unix", socketPath)).BulkProperties(
+				[]string{"some-handle", "missing-handle"},
+				nil,
+			)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(containers).Should(Equal(map[string]api.Properties{
+				"some-handle": {"foo": "bar", "baz": "quux"},
+			}))
+		})
+
+		It("returns only the properties with the keys given", func() {
+			containers, err := client.New(connection.New("unix", socketPath)).BulkProperties(
+				[]string{"some-handle"},
+				[]string{"foo", "missing-key"},
+			)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(containers).Should(Equal(map[string]api.Properties{
+				"some-handle": {"foo": "bar"},
+			}))
+		})
+
+		Context("when getting a container's properties fails", func() {
+			BeforeEach(func() {
+				fakeContainer.PropertiesReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, err := client.New(connection.New("unix", socketPath)).BulkProperties(
+					[]string{"some-handle"},
+					nil,
+				)
+				Ω(err).Should(MatchError("oh no!"))
+			})
+		})
+	})
+
+	Context("when a container has been created", func() {
+		var container client.Container
+
+		var fakeContainer *fakes.FakeContainer
+		var containerSpec api.ContainerSpec
+
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+			serverBackend.LookupReturns(fakeContainer, nil)
+
+			containerSpec = api.ContainerSpec{}
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			created, err := apiClient.Create(containerSpec)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			container = created.(client.Container)
+		})
+
+		itResetsGraceTimeWhenHandling := func(call func()) {
+			Context("when created with a grace time", func() {
+				graceTime := 200 * time.Millisecond
+
+				BeforeEach(func() {
+					serverBackend.GraceTimeReturns(graceTime)
+				})
+
+				It("resets the container's grace time", func() {
+					for i := 0; i < 11; i++ {
+						time.Sleep(graceTime / 10)
+						call()
+					}
+
+					before := time.Now()
+
+					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
+					Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal(container.Handle()))
+
+					Ω(time.Since(before)).Should(BeNumerically("~", graceTime, 20*time.Millisecond))
+				})
+			})
+		}
 
 		itFailsWhenTheContainerIsNotFound := func(example func()) {
 			Context("when the container is not found", func() {
@@ -416,6 +1494,117 @@ var _ = Describe("When a client connects", func() {
 			})
 		}
 
+		Describe("failing requests", func() {
+			BeforeEach(func() {
+				fakeContainer.GetPropertyStub = func(key string) (string, error) {
+					if key == "owner" {
+						return "some-team", nil
+					}
+
+					return "", errors.New("no such property")
+				}
+
+				fakeContainer.StopReturns(errors.New("oh no!"))
+			})
+
+			It("annotates the error with the configured container properties", func() {
+				err := container.Stop(false)
+				Ω(err).Should(HaveOccurred())
+
+				gardenErr, ok := err.(*connection.GardenError)
+				Ω(ok).Should(BeTrue())
+
+				Ω(gardenErr.Message).Should(Equal("oh no!"))
+				Ω(gardenErr.Annotations).Should(Equal(map[string]string{
+					"owner": "some-team",
+				}))
+
+				Ω(fakeContainer.GetPropertyArgsForCall(0)).Should(Equal("owner"))
+			})
+
+			Context("when the container does not have an annotated property", func() {
+				BeforeEach(func() {
+					fakeContainer.GetPropertyStub = nil
+					fakeContainer.GetPropertyReturns("", errors.New("no such property"))
+				})
+
+				It("omits it from the error", func() {
+					err := container.Stop(false)
+					Ω(err).Should(HaveOccurred())
+
+					Ω(err.(*connection.GardenError).Annotations).Should(BeEmpty())
+				})
+			})
+
+			for _, typedErr := range []error{
+				api.ErrContainerNotFound,
+				api.ErrProcessNotFound,
+				api.ErrUnsupportedOperation,
+			} {
+				typedErr := typedErr
+
+				Context("when the backend returns "+typedErr.Error(), func() {
+					BeforeEach(func() {
+						fakeContainer.StopReturns(typedErr)
+					})
+
+					It("returns the same error to the client", func() {
+						err := container.Stop(false)
+						Ω(err).Should(Equal(typedErr))
+					})
+				})
+			}
+
+			Context("when the backend returns a classified error", func() {
+				BeforeEach(func() {
+					fakeContainer.StopReturns(api.Classify(api.ErrorClassRetryable, errors.New("backend busy")))
+				})
+
+				It("returns a GardenError of the same class to the client", func() {
+					err := container.Stop(false)
+					Ω(err).Should(HaveOccurred())
+
+					Ω(err.Error()).Should(Equal("backend busy"))
+					Ω(api.ClassOf(err)).Should(Equal(api.ErrorClassRetryable))
+				})
+			})
+
+			Context("when the backend returns an unclassified error", func() {
+				BeforeEach(func() {
+					fakeContainer.StopReturns(errors.New("oh no!"))
+				})
+
+				It("is terminal", func() {
+					err := container.Stop(false)
+					Ω(api.ClassOf(err)).Should(Equal(api.ErrorClassTerminal))
+				})
+			})
+
+			Context("when the backend returns an error of an uncomparable type", func() {
+				BeforeEach(func() {
+					fakeContainer.StopReturns(uncomparableError{"oh", "no!"})
+				})
+
+				It("returns its message to the client", func() {
+					err := container.Stop(false)
+					Ω(err).Should(HaveOccurred())
+
+					Ω(err.Error()).Should(Equal("oh no!"))
+				})
+			})
+
+			Context("when the container cannot be looked up", func() {
+				BeforeEach(func() {
+					serverBackend.LookupReturns(nil, api.ErrContainerNotFound)
+				})
+
+				It("returns ErrContainerNotFound", func() {
+					err := container.Stop(false)
+					Ω(err).Should(Equal(api.ErrContainerNotFound))
+				})
+			})
+		})
+
 		Describe("stopping", func() {
 			It("stops the container and sends a StopResponse", func() {
 				err := container.Stop(true)
@@ -424,607 +1613,2348 @@ var _ = Describe("When a client connects", func() {
 				Ω(fakeContainer.StopArgsForCall(0)).Should(Equal(true))
 			})
 
+			It("does not record a reason", func() {
+				err := container.Stop(true)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.SetPropertyCallCount()).Should(BeZero())
+			})
+
+			Context("with a reason", func() {
+				It("stops the container, recording the reason as a property", func() {
+					err := container.StopWithReason(false, "evacuating the cell")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(fakeContainer.StopArgsForCall(0)).Should(Equal(false))
+
+					name, value := fakeContainer.SetPropertyArgsForCall(0)
+					Ω(name).Should(Equal(server.StopReasonProperty))
+					Ω(value).Should(Equal("evacuating the cell"))
+				})
+
+				Context("when recording the reason fails", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertyReturns(errors.New("oh no!"))
+					})
+
+					It("stops the container anyway", func() {
+						err := container.StopWithReason(false, "evacuating the cell")
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.StopCallCount()).Should(Equal(1))
+					})
+				})
+
+				Context("when stopping the container fails", func() {
+					BeforeEach(func() {
+						fakeContainer.StopReturns(errors.New("oh no!"))
+					})
+
+					It("does not record the reason", func() {
+						err := container.StopWithReason(false, "evacuating the cell")
+						Ω(err).Should(HaveOccurred())
+
+						Ω(fakeContainer.SetPropertyCallCount()).Should(BeZero())
+					})
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.Stop(true)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when stopping the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StopReturns(errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					err := container.Stop(true)
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(
+				func() {
+					err := container.Stop(false)
+					Ω(err).ShouldNot(HaveOccurred())
+				},
+			)
+		})
+
+		Describe("signalling all processes", func() {
+			It("sends the signal to the container's processes", func() {
+				err := container.SignalAll(api.SignalHangup)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.SignalAllArgsForCall(0)).Should(Equal(api.SignalHangup))
+			})
+
+			It("does not stop the container", func() {
+				err := container.SignalAll(api.SignalTerminate)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.StopCallCount()).Should(BeZero())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.SignalAll(api.SignalHangup)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				err := container.SignalAll(api.SignalHangup)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			Context("when the signal is not known to the server", func() {
+				It("fails without signalling", func() {
+					err := container.SignalAll(api.Signal(42))
+					Ω(err).Should(HaveOccurred())
+
+					Ω(fakeContainer.SignalAllCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when signalling the processes fails", func() {
+				BeforeEach(func() {
+					fakeContainer.SignalAllReturns(errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					err := container.SignalAll(api.SignalHangup)
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("pausing", func() {
+			It("pauses the container", func() {
+				err := container.Pause()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.PauseCallCount()).Should(Equal(1))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.Pause()
+				Ω(err).Should(HaveOccurred())
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				err := container.Pause()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			Context("when pausing the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.PauseReturns(errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					err := container.Pause()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("resuming", func() {
+			It("resumes the container", func() {
+				err := container.Resume()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.ResumeCallCount()).Should(Equal(1))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.Resume()
+				Ω(err).Should(HaveOccurred())
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				err := container.Resume()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			Context("when resuming the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.ResumeReturns(errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					err := container.Resume()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("properties", func() {
+			Describe("getting", func() {
+				Context("when getting the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.GetPropertyReturns("some-property-value", nil)
+					})
+
+					It("returns the property from the container", func() {
+						value, err := container.GetProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(value).Should(Equal("some-property-value"))
+
+						Ω(fakeContainer.GetPropertyCallCount()).Should(Equal(1))
+
+						name := fakeContainer.GetPropertyArgsForCall(0)
+						Ω(name).Should(Equal("some-property"))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						_, err := container.GetProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						_, err := container.GetProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when getting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.GetPropertyReturns("", errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						value, err := container.GetProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+						Ω(value).Should(BeZero())
+					})
+				})
+			})
+
+			Describe("setting", func() {
+				Context("when setting the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertyReturns(nil)
+					})
+
+					It("sets the property on the container", func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.SetPropertyCallCount()).Should(Equal(1))
+
+						name, value := fakeContainer.SetPropertyArgsForCall(0)
+						Ω(name).Should(Equal("some-property"))
+						Ω(value).Should(Equal("some-value"))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when setting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertyReturns(errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+			})
+
+			Describe("setting several at once", func() {
+				Context("when setting the properties succeeds", func() {
+					It("sets the properties on the container", func() {
+						err := container.SetProperties(api.Properties{
+							"some-property":  "some-value",
+							"other-property": "other-value",
+						})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.SetPropertiesCallCount()).Should(Equal(1))
+
+						Ω(fakeContainer.SetPropertiesArgsForCall(0)).Should(Equal(api.Properties{
+							"some-property":  "some-value",
+							"other-property": "other-value",
+						}))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						err := container.SetProperties(api.Properties{"some-property": "some-value"})
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						err := container.SetProperties(api.Properties{"some-property": "some-value"})
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when setting the properties fails", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertiesReturns(errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						err := container.SetProperties(api.Properties{"some-property": "some-value"})
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+			})
+
+			Describe("compare and swap", func() {
+				Context("when the property is swapped", func() {
+					BeforeEach(func() {
+						fakeContainer.CompareAndSwapPropertyReturns(true, nil)
+					})
+
+					It("swaps the property on the container", func() {
+						swapped, err := container.CompareAndSwapProperty("some-property", "old-value", "new-value")
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(swapped).Should(BeTrue())
+
+						name, oldValue, newValue := fakeContainer.CompareAndSwapPropertyArgsForCall(0)
+						Ω(name).Should(Equal("some-property"))
+						Ω(oldValue).Should(Equal("old-value"))
+						Ω(newValue).Should(Equal("new-value"))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						_, err := container.CompareAndSwapProperty("some-property", "old-value", "new-value")
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						_, err := container.CompareAndSwapProperty("some-property", "old-value", "new-value")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when the property does not have the old value", func() {
+					BeforeEach(func() {
+						fakeContainer.CompareAndSwapPropertyReturns(false, nil)
+					})
+
+					It("reports that it was not swapped", func() {
+						swapped, err := container.CompareAndSwapProperty("some-property", "old-value", "new-value")
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(swapped).Should(BeFalse())
+					})
+				})
+
+				Context("when swapping the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.CompareAndSwapPropertyReturns(false, errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						_, err := container.CompareAndSwapProperty("some-property", "old-value", "new-value")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+			})
+
+			Describe("removing", func() {
+				Context("when removing the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(nil)
+					})
+
+					It("returns the property from the container", func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.RemovePropertyCallCount()).Should(Equal(1))
+
+						name := fakeContainer.RemovePropertyArgsForCall(0)
+						Ω(name).Should(Equal("some-property"))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when setting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+			})
+		})
+
+		Describe("scheduling destruction", func() {
+			It("destroys the container after the given delay", func() {
+				before := time.Now()
+
+				err := container.DestroyAfter(time.Second)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(serverBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
+				Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
+
+				Ω(time.Since(before)).Should(BeNumerically("~", time.Second, 100*time.Millisecond))
+			})
+
+			It("destroys the container at the given time", func() {
+				at := time.Now().Add(2 * time.Second).Truncate(time.Second)
+
+				err := container.DestroyAt(at)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(serverBackend.DestroyCallCount, 3*time.Second).Should(Equal(1))
+				Ω(time.Now().Sub(at)).Should(BeNumerically("~", 0, 100*time.Millisecond))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.DestroyAfter(time.Second)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("and then cancelling it", func() {
+				It("does not destroy the container", func() {
+					err := container.DestroyAfter(time.Second)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = container.CancelScheduledDestroy()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Consistently(serverBackend.DestroyCallCount, 1500*time.Millisecond).Should(BeZero())
+				})
+
+				itFailsWhenTheContainerIsNotFound(func() {
+					err := container.CancelScheduledDestroy()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("holding", func() {
+			graceTime := 200 * time.Millisecond
+
+			BeforeEach(func() {
+				serverBackend.GraceTimeReturns(graceTime)
+			})
+
+			It("does not destroy the container after its grace time", func() {
+				err := container.SetHold(true)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Consistently(serverBackend.DestroyCallCount, 2*graceTime).Should(BeZero())
+			})
+
+			It("does not destroy the container at its scheduled time", func() {
+				err := container.SetHold(true)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = container.DestroyAfter(time.Second)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Consistently(serverBackend.DestroyCallCount, 1500*time.Millisecond).Should(BeZero())
+			})
+
+			It("reports the container as held in its info", func() {
+				err := container.SetHold(true)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				info, err := container.Info()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(info.Held).Should(BeTrue())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.SetHold(true)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("and then releasing it", func() {
+				It("destroys the container after its grace time", func() {
+					err := container.SetHold(true)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					time.Sleep(2 * graceTime)
+
+					err = container.SetHold(false)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					before := time.Now()
+
+					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
+					Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
+
+					Ω(time.Since(before)).Should(BeNumerically("~", graceTime, 50*time.Millisecond))
+				})
+
+				It("no longer reports the container as held in its info", func() {
+					err := container.SetHold(true)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = container.SetHold(false)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					info, err := container.Info()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(info.Held).Should(BeFalse())
+				})
+			})
+		})
+
+		Describe("setting the grace time", func() {
+			graceTime := 200 * time.Millisecond
+
+			BeforeEach(func() {
+				serverBackend.GraceTimeReturns(graceTime)
+			})
+
+			It("sets the container's grace time", func() {
+				err := container.SetGraceTime(time.Minute)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.SetGraceTimeArgsForCall(0)).Should(Equal(time.Minute))
+			})
+
+			It("destroys the container after the new grace time instead", func() {
+				err := container.SetGraceTime(time.Second)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Consistently(serverBackend.DestroyCallCount, 2*graceTime).Should(BeZero())
+				Eventually(serverBackend.DestroyCallCount, time.Second).Should(Equal(1))
+			})
+
+			Context("to zero", func() {
+				It("never destroys the container for being idle", func() {
+					err := container.SetGraceTime(0)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Consistently(serverBackend.DestroyCallCount, 2*graceTime).Should(BeZero())
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.SetGraceTime(time.Minute)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when setting the grace time fails", func() {
+				BeforeEach(func() {
+					fakeContainer.SetGraceTimeReturns(errors.New("oh no!"))
+				})
+
+				It("returns an error, leaving the old grace time", func() {
+					err := container.SetGraceTime(time.Minute)
+					Ω(err).Should(HaveOccurred())
+
+					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
+				})
+			})
+		})
+
+		Describe("snapshotting", func() {
+			BeforeEach(func() {
+				serverSnapshotter.SnapshotReturns(ioutil.NopCloser(bytes.NewBufferString("some-snapshot")), nil)
+			})
+
+			It("streams the container's snapshot from the backend", func() {
+				reader, err := apiClient.Snapshot("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(ioutil.ReadAll(reader)).Should(Equal([]byte("some-snapshot")))
+
+				Ω(serverSnapshotter.SnapshotArgsForCall(0)).Should(Equal("some-handle"))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := apiClient.Snapshot("some-handle")
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when snapshotting fails", func() {
+				BeforeEach(func() {
+					serverSnapshotter.SnapshotReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					_, err := apiClient.Snapshot("some-handle")
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("streaming in", func() {
+			It("streams the file in, waits for completion, and succeeds", func() {
+				data := bytes.NewBufferString("chunk-1;chunk-2;chunk-3;")
+
+				fakeContainer.StreamInStub = func(spec api.StreamInSpec) error {
+					Ω(spec.Path).Should(Equal("/dst/path"))
+					Ω(ioutil.ReadAll(spec.TarStream)).Should(Equal([]byte("chunk-1;chunk-2;chunk-3;")))
+					return nil
+				}
+
+				err := container.StreamIn(api.StreamInSpec{
+					Path:      "/dst/path",
+					TarStream: data,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.StreamInCallCount()).Should(Equal(1))
+			})
+
+			It("extracts the files as the given user", func() {
+				err := container.StreamIn(api.StreamInSpec{
+					Path:      "/dst/path",
+					TarStream: bytes.NewBufferString("chunk-1;"),
+					User:      "some-user",
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.StreamInArgsForCall(0).User).Should(Equal("some-user"))
+			})
+
+			Context("when the stream is gzipped", func() {
+				It("gives the backend the uncompressed stream", func() {
+					var streamedIn []byte
+					fakeContainer.StreamInStub = func(spec api.StreamInSpec) error {
+						Ω(spec.Compression).Should(Equal(api.CompressionNone))
+
+						var err error
+						streamedIn, err = ioutil.ReadAll(spec.TarStream)
+						return err
+					}
+
+					compressed := new(bytes.Buffer)
+					gzipWriter := gzip.NewWriter(compressed)
+					gzipWriter.Write([]byte("chunk-1;chunk-2;"))
+					gzipWriter.Close()
+
+					err := container.StreamIn(api.StreamInSpec{
+						Path:        "/dst/path",
+						TarStream:   compressed,
+						Compression: api.CompressionGzip,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(string(streamedIn)).Should(Equal("chunk-1;chunk-2;"))
+				})
+
+				Context("but is not valid gzip", func() {
+					It("fails without streaming in", func() {
+						err := container.StreamIn(api.StreamInSpec{
+							Path:        "/dst/path",
+							TarStream:   bytes.NewBufferString("chunk-1;"),
+							Compression: api.CompressionGzip,
+						})
+						Ω(err).Should(HaveOccurred())
+
+						Ω(fakeContainer.StreamInCallCount()).Should(BeZero())
+					})
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.StreamIn(api.StreamInSpec{Path: "/dst/path"})
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when copying in to the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamInReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.StreamIn(api.StreamInSpec{Path: "/dst/path"})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+
+			Context("with an expected size", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{ByteHard: 100}, nil)
+					fakeContainer.InfoReturns(api.ContainerInfo{
+						DiskStat: api.ContainerDiskStat{BytesUsed: 90},
+					}, nil)
+				})
+
+				Context("when it fits in the container's disk quota", func() {
+					It("streams the file in", func() {
+						err := container.StreamIn(api.StreamInSpec{
+							Path:         "/dst/path",
+							TarStream:    bytes.NewBufferString("chunk-1;"),
+							ExpectedSize: 10,
+						})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.StreamInCallCount()).Should(Equal(1))
+					})
+				})
+
+				Context("when it would exceed the container's disk quota", func() {
+					It("fails with a quota error without streaming in", func() {
+						err := container.StreamIn(api.StreamInSpec{
+							Path:         "/dst/path",
+							TarStream:    bytes.NewBufferString("chunk-1;"),
+							ExpectedSize: 11,
+						})
+						Ω(err).Should(Equal(api.DiskQuotaExceededError{
+							ExpectedBytes:  11,
+							RemainingBytes: 10,
+						}))
+
+						Ω(fakeContainer.StreamInCallCount()).Should(Equal(0))
+					})
+				})
+
+				Context("when the container has no disk quota", func() {
+					BeforeEach(func() {
+						fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{}, nil)
+					})
+
+					It("streams the file in", func() {
+						err := container.StreamIn(api.StreamInSpec{
+							Path:         "/dst/path",
+							TarStream:    bytes.NewBufferString("chunk-1;"),
+							ExpectedSize: 1000,
+						})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.StreamInCallCount()).Should(Equal(1))
+					})
+				})
+
+				Context("when getting the disk limits fails", func() {
+					BeforeEach(func() {
+						fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{}, errors.New("oh no!"))
+					})
+
+					It("fails without streaming in", func() {
+						err := container.StreamIn(api.StreamInSpec{
+							Path:         "/dst/path",
+							TarStream:    bytes.NewBufferString("chunk-1;"),
+							ExpectedSize: 10,
+						})
+						Ω(err).Should(HaveOccurred())
+
+						Ω(fakeContainer.StreamInCallCount()).Should(Equal(0))
+					})
+				})
+			})
+
+			Context("when the backend fails with a quota error", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamInReturns(api.DiskQuotaExceededError{
+						ExpectedBytes:  20,
+						RemainingBytes: 5,
+					})
+				})
+
+				It("returns the quota error", func() {
+					err := container.StreamIn(api.StreamInSpec{
+						Path:      "/dst/path",
+						TarStream: bytes.NewBufferString("chunk-1;"),
+					})
+					Ω(err).Should(Equal(api.DiskQuotaExceededError{
+						ExpectedBytes:  20,
+						RemainingBytes: 5,
+					}))
+				})
+			})
+		})
+
+		Describe("streaming in atomically", func() {
+			It("streams the file in atomically, as the user asked for, waits for completion, and succeeds", func() {
+				data := bytes.NewBufferString("chunk-1;chunk-2;chunk-3;")
+
+				fakeContainer.StreamInStub = func(spec api.StreamInSpec) error {
+					Ω(spec.Path).Should(Equal("/dst/path"))
+					Ω(spec.User).Should(Equal("some-user"))
+					Ω(spec.Atomic).Should(BeTrue())
+					Ω(ioutil.ReadAll(spec.TarStream)).Should(Equal([]byte("chunk-1;chunk-2;chunk-3;")))
+					return nil
+				}
+
+				err := container.StreamIn(api.StreamInSpec{
+					Path:      "/dst/path",
+					TarStream: data,
+					User:      "some-user",
+					Atomic:    true,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.StreamInCallCount()).Should(Equal(1))
+			})
+
+			It("decompresses the stream", func() {
+				compressed := new(bytes.Buffer)
+
+				gzipWriter := gzip.NewWriter(compressed)
+				_, err := gzipWriter.Write([]byte("chunk-1;"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(gzipWriter.Close()).Should(Succeed())
+
+				fakeContainer.StreamInStub = func(spec api.StreamInSpec) error {
+					Ω(ioutil.ReadAll(spec.TarStream)).Should(Equal([]byte("chunk-1;")))
+					return nil
+				}
+
+				err = container.StreamIn(api.StreamInSpec{
+					Path:        "/dst/path",
+					TarStream:   compressed,
+					Compression: api.CompressionGzip,
+					Atomic:      true,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.StreamIn(api.StreamInSpec{Path: "/dst/path", Atomic: true})
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when copying in to the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamInReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.StreamIn(api.StreamInSpec{Path: "/dst/path", Atomic: true})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("verifying a stream in", func() {
+			var archive *bytes.Buffer
+
+			BeforeEach(func() {
+				archive = new(bytes.Buffer)
+
+				tarWriter := tar.NewWriter(archive)
+
+				for _, name := range []string{"some-file", "../escaped"} {
+					err := tarWriter.WriteHeader(&tar.Header{
+						Name:     name,
+						Typeflag: tar.TypeReg,
+						Mode:     0644,
+						Size:     5,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, err = tarWriter.Write([]byte("hello"))
+					Ω(err).ShouldNot(HaveOccurred())
+				}
+
+				tarWriter.Close()
+			})
+
+			It("reports on the archive without streaming it in", func() {
+				report, err := container.VerifyStreamIn("/dst/path", archive)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(report).Should(Equal(api.StreamInReport{
+					Entries: 2,
+					Bytes:   10,
+					Problems: []api.StreamInProblem{
+						{Path: "../escaped", Reason: "path escapes the destination"},
+					},
+				}))
+
+				Ω(fakeContainer.StreamInCallCount()).Should(Equal(0))
+				Ω(fakeContainer.VerifyStreamInCallCount()).Should(Equal(0))
+			})
+
+			Context("when the container has a disk quota", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{ByteHard: 100}, nil)
+					fakeContainer.InfoReturns(api.ContainerInfo{
+						DiskStat: api.ContainerDiskStat{BytesUsed: 95},
+					}, nil)
+				})
+
+				It("reports whether the archive fits", func() {
+					report, err := container.VerifyStreamIn("/dst/path", archive)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(report.Problems).Should(ContainElement(api.StreamInProblem{
+						Reason: "archive needs 10 bytes, but only 5 remain in the disk quota",
+					}))
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.VerifyStreamIn("/dst/path", archive)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when getting the disk limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.VerifyStreamIn("/dst/path", archive)
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				_, err := container.VerifyStreamIn("/dst/path", archive)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Describe("streaming out", func() {
+			var streamOut io.ReadCloser
+
+			BeforeEach(func() {
+				streamOut = ioutil.NopCloser(bytes.NewBuffer([]byte("hello-world!")))
+			})
+
+			JustBeforeEach(func() {
+				fakeContainer.StreamOutReturns(streamOut, nil)
+			})
+
+			It("streams the bits out and succeeds", func() {
+				reader, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(reader).ShouldNot(BeZero())
+
+				streamedContent, err := ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(string(streamedContent)).Should(Equal("hello-world!"))
+
+				Ω(fakeContainer.StreamOutArgsForCall(0).Path).Should(Equal("/src/path"))
+			})
+
+			It("closes the backend's stream once it has been streamed", func() {
+				closer := &closeChecker{}
+				fakeContainer.StreamOutReturns(closingReader{bytes.NewBufferString("hello-world!"), closer}, nil)
+
+				reader, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(closer.Closed).Should(BeTrue())
+			})
+
+			It("cancels the backend's stream once the transfer has ended", func() {
+				reader, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(reader.Close()).Should(Succeed())
+
+				Eventually(fakeContainer.StreamOutArgsForCall(0).Cancel).Should(BeClosed())
+			})
+
+			It("reads the files as the given user", func() {
+				reader, err := container.StreamOut(api.StreamOutSpec{
+					Path: "/src/path",
+					User: "some-user",
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.StreamOutArgsForCall(0).User).Should(Equal("some-user"))
+			})
+
+			Context("when asked for a gzipped stream", func() {
+				It("compresses the backend's stream", func() {
+					reader, err := container.StreamOut(api.StreamOutSpec{
+						Path:        "/src/path",
+						Compression: api.CompressionGzip,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					gzipReader, err := gzip.NewReader(reader)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					streamedContent, err := ioutil.ReadAll(gzipReader)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(string(streamedContent)).Should(Equal("hello-world!"))
+
+					Ω(fakeContainer.StreamOutArgsForCall(0).Compression).Should(Equal(api.CompressionNone))
+				})
+			})
+
+			Context("when resuming the stream from an offset", func() {
+				It("skips that much of the backend's stream, which it asks for whole", func() {
+					reader, err := container.StreamOut(api.StreamOutSpec{
+						Path:   "/src/path",
+						Offset: 6,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					streamedContent, err := ioutil.ReadAll(reader)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(string(streamedContent)).Should(Equal("world!"))
+
+					Ω(fakeContainer.StreamOutArgsForCall(0).Offset).Should(BeZero())
+				})
+
+				Context("when the stream is gzipped", func() {
+					It("fails with a validation error", func() {
+						_, err := container.StreamOut(api.StreamOutSpec{
+							Path:        "/src/path",
+							Compression: api.CompressionGzip,
+							Offset:      6,
+						})
+						Ω(err).Should(Equal(api.InvalidRequestError{
+							Violations: []api.ValidationError{{
+								Field:  "offset",
+								Reason: "must not be given for gzipped streams",
+							}},
+						}))
+					})
+				})
+
+				Context("when the offset is past the end of the stream", func() {
+					It("fails with a validation error", func() {
+						_, err := container.StreamOut(api.StreamOutSpec{
+							Path:   "/src/path",
+							Offset: 100,
+						})
+						Ω(err).Should(Equal(api.InvalidRequestError{
+							Violations: []api.ValidationError{{
+								Field:  "offset",
+								Reason: "must not be past the end of the archive",
+							}},
+						}))
+					})
+				})
+			})
+
+			Context("when the backend's stream fails part way through", func() {
+				BeforeEach(func() {
+					streamReader, streamWriter := io.Pipe()
+
+					streamOut = streamReader
+
+					go func() {
+						streamWriter.Write([]byte("hello"))
+						streamWriter.CloseWithError(errors.New("backend exploded"))
+					}()
+				})
+
+				It("fails the stream once read, with the offset to resume it from", func() {
+					reader, err := container.StreamOut(api.StreamOutSpec{
+						Path:   "/src/path",
+						Offset: 2,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					streamedContent, err := ioutil.ReadAll(reader)
+					Ω(string(streamedContent)).Should(Equal("llo"))
+					Ω(err).Should(Equal(api.StreamOutInterruptedError{
+						Offset:  5,
+						Message: io.ErrUnexpectedEOF.Error(),
+					}))
+				})
+			})
+
+			Context("when the backend's stream fails before any of it is sent", func() {
+				BeforeEach(func() {
+					streamReader, streamWriter := io.Pipe()
+					streamWriter.CloseWithError(errors.New("backend exploded"))
+
+					streamOut = streamReader
+				})
+
+				It("fails with the error, even when gzipping the stream", func() {
+					_, err := container.StreamOut(api.StreamOutSpec{
+						Path:        "/src/path",
+						Compression: api.CompressionGzip,
+					})
+					Ω(err).Should(MatchError("backend exploded"))
+				})
+			})
+
+			Context("when the connection dies as we're streaming", func() {
+				var closer *closeChecker
+
+				BeforeEach(func() {
+					closer = &closeChecker{}
+
+					streamOut = closer
+				})
+
+				It("closes the backend's stream", func() {
+					reader, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = reader.Close()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(closer.Closed).Should(BeTrue())
+				})
+			})
+
+			Context("when the connection dies while the backend is producing the stream", func() {
+				var streamWriter *io.PipeWriter
+
+				BeforeEach(func() {
+					var streamReader *io.PipeReader
+					streamReader, streamWriter = io.Pipe()
+
+					streamOut = streamReader
+
+					// enough to have the response's headers sent, after which
+					// the backend stalls
+					go streamWriter.Write(bytes.Repeat([]byte("x"), 64*1024))
+				})
+
+				AfterEach(func() {
+					streamWriter.Close()
+				})
+
+				It("cancels the backend's stream without waiting for it to be read", func() {
+					reader, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(reader.Close()).Should(Succeed())
+
+					Eventually(func() <-chan struct{} {
+						return fakeContainer.StreamOutArgsForCall(0).Cancel
+					}).Should(BeClosed())
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				reader, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(reader).ShouldNot(BeZero())
+
+				_, err = ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when streaming out of the container fails", func() {
+				JustBeforeEach(func() {
+					fakeContainer.StreamOutReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					_, err := container.StreamOut(api.StreamOutSpec{Path: "/src/path"})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("limiting bandwidth", func() {
+			It("sets the container's bandwidth limits", func() {
+				setLimits := api.BandwidthLimits{
+					RateInBytesPerSecond:      123,
+					BurstRateInBytesPerSecond: 456,
+				}
+
+				err := container.LimitBandwidth(setLimits)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.LimitBandwidthArgsForCall(0)).Should(Equal(setLimits))
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				err := container.LimitBandwidth(api.BandwidthLimits{
+					RateInBytesPerSecond:      123,
+					BurstRateInBytesPerSecond: 456,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.LimitBandwidth(api.BandwidthLimits{
+					RateInBytesPerSecond:      123,
+					BurstRateInBytesPerSecond: 456,
+				})
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when limiting the bandwidth fails", func() {
+				BeforeEach(func() {
+					fakeContainer.LimitBandwidthReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.LimitBandwidth(api.BandwidthLimits{
+						RateInBytesPerSecond:      123,
+						BurstRateInBytesPerSecond: 456,
+					})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("getting the current bandwidth limits", func() {
+			It("returns the limits returned by the backend", func() {
+				effectiveLimits := api.BandwidthLimits{
+					RateInBytesPerSecond:      1230,
+					BurstRateInBytesPerSecond: 4560,
+				}
+
+				fakeContainer.CurrentBandwidthLimitsReturns(effectiveLimits, nil)
+
+				limits, err := container.CurrentBandwidthLimits()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(limits).Should(Equal(effectiveLimits))
+			})
+
+			Context("when getting the current limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentBandwidthLimitsReturns(api.BandwidthLimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.CurrentBandwidthLimits()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("limiting memory", func() {
+			setLimits := api.MemoryLimits{1024}
+
+			It("sets the container's memory limits", func() {
+				err := container.LimitMemory(setLimits)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.LimitMemoryArgsForCall(0)).Should(Equal(setLimits))
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				err := container.LimitMemory(setLimits)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.LimitMemory(api.MemoryLimits{123})
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when limiting the memory fails", func() {
+				BeforeEach(func() {
+					fakeContainer.LimitMemoryReturns(errors.New("oh no!"))
+				})
+
+				It("fail", func() {
+					err := container.LimitMemory(api.MemoryLimits{123})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("getting memory limits", func() {
+			It("obtains the current limits", func() {
+				effectiveLimits := api.MemoryLimits{2048}
+				fakeContainer.CurrentMemoryLimitsReturns(effectiveLimits, nil)
+
+				limits, err := container.CurrentMemoryLimits()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(limits).ShouldNot(BeZero())
+
+				Ω(limits).Should(Equal(effectiveLimits))
+			})
+
+			It("does not change the memory limit", func() {
+				_, err := container.CurrentMemoryLimits()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.LimitMemoryCallCount()).Should(BeZero())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.CurrentMemoryLimits()
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when getting the current memory limits fails", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentMemoryLimitsReturns(api.MemoryLimits{}, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.CurrentMemoryLimits()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("limiting disk", func() {
+			setLimits := api.DiskLimits{
+				BlockSoft: 111,
+				BlockHard: 222,
+
+				InodeSoft: 333,
+				InodeHard: 444,
+
+				ByteSoft: 555,
+				ByteHard: 666,
+			}
+
+			It("sets the container's disk limits and returns the current limits", func() {
+				err := container.LimitDisk(setLimits)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.LimitDiskArgsForCall(0)).Should(Equal(setLimits))
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				err := container.LimitDisk(setLimits)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.LimitDisk(api.DiskLimits{})
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when limiting the disk fails", func() {
+				BeforeEach(func() {
+					fakeContainer.LimitDiskReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.LimitDisk(api.DiskLimits{})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("getting the current disk limits", func() {
+			currentLimits := api.DiskLimits{
+				BlockSoft: 1111,
+				BlockHard: 2222,
+
+				InodeSoft: 3333,
+				InodeHard: 4444,
+
+				ByteSoft: 5555,
+				ByteHard: 6666,
+			}
+
+			It("returns the limits returned by the backend", func() {
+				fakeContainer.CurrentDiskLimitsReturns(currentLimits, nil)
+
+				limits, err := container.CurrentDiskLimits()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(limits).Should(Equal(currentLimits))
+			})
+
+			It("does not change the disk limit", func() {
+				_, err := container.CurrentDiskLimits()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.LimitDiskCallCount()).Should(BeZero())
+			})
+
 			itFailsWhenTheContainerIsNotFound(func() {
-				err := container.Stop(true)
+				_, err := container.CurrentDiskLimits()
 				Ω(err).Should(HaveOccurred())
 			})
 
-			Context("when stopping the container fails", func() {
+			Context("when getting the current disk limits fails", func() {
 				BeforeEach(func() {
-					fakeContainer.StopReturns(errors.New("oh no!"))
+					fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{}, errors.New("oh no!"))
 				})
 
-				It("returns an error", func() {
-					err := container.Stop(true)
+				It("fails", func() {
+					_, err := container.CurrentDiskLimits()
 					Ω(err).Should(HaveOccurred())
 				})
 			})
-
-			itResetsGraceTimeWhenHandling(
-				func() {
-					err := container.Stop(false)
-					Ω(err).ShouldNot(HaveOccurred())
-				},
-			)
 		})
 
-		Describe("properties", func() {
-			Describe("getting", func() {
-				Context("when getting the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.GetPropertyReturns("some-property-value", nil)
-					})
+		Describe("getting the disk usage", func() {
+			usage := api.ContainerDiskUsage{
+				RootFSLayers: []api.DiskUsageEntry{
+					{Name: "layer-a", BytesUsed: 1111, InodesUsed: 11},
+					{Name: "layer-b", BytesUsed: 2222, InodesUsed: 22},
+				},
+				Scratch: api.DiskUsageEntry{BytesUsed: 3333, InodesUsed: 33},
+				BindMounts: []api.DiskUsageEntry{
+					{Name: "/var/vcap/data", BytesUsed: 4444, InodesUsed: 44},
+				},
+			}
 
-					It("returns the property from the container", func() {
-						value, err := container.GetProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
+			It("returns the usage returned by the backend", func() {
+				fakeContainer.DiskUsageReturns(usage, nil)
 
-						Ω(value).Should(Equal("some-property-value"))
+				returnedUsage, err := container.DiskUsage()
+				Ω(err).ShouldNot(HaveOccurred())
 
-						Ω(fakeContainer.GetPropertyCallCount()).Should(Equal(1))
+				Ω(returnedUsage).Should(Equal(usage))
+			})
 
-						name := fakeContainer.GetPropertyArgsForCall(0)
-						Ω(name).Should(Equal("some-property"))
-					})
+			itResetsGraceTimeWhenHandling(func() {
+				_, err := container.DiskUsage()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
 
-					itResetsGraceTimeWhenHandling(func() {
-						_, err := container.GetProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
-					})
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.DiskUsage()
+				Ω(err).Should(HaveOccurred())
+			})
 
-					itFailsWhenTheContainerIsNotFound(func() {
-						_, err := container.GetProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-					})
+			Context("when getting the disk usage fails", func() {
+				BeforeEach(func() {
+					fakeContainer.DiskUsageReturns(api.ContainerDiskUsage{}, errors.New("oh no!"))
 				})
 
-				Context("when getting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.GetPropertyReturns("", errors.New("oh no!"))
-					})
-
-					It("returns an error", func() {
-						value, err := container.GetProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-						Ω(value).Should(BeZero())
-					})
+				It("fails", func() {
+					_, err := container.DiskUsage()
+					Ω(err).Should(HaveOccurred())
 				})
 			})
+		})
 
-			Describe("setting", func() {
-				Context("when setting the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.SetPropertyReturns(nil)
-					})
-
-					It("sets the property on the container", func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).ShouldNot(HaveOccurred())
+		Describe("getting the environment", func() {
+			Context("when the container has an environment", func() {
+				BeforeEach(func() {
+					containerSpec.Env = []string{"FLAVOR=vanilla", "CONE=waffle=large"}
+				})
 
-						Ω(fakeContainer.SetPropertyCallCount()).Should(Equal(1))
+				It("returns it", func() {
+					env, err := container.Env()
+					Ω(err).ShouldNot(HaveOccurred())
 
-						name, value := fakeContainer.SetPropertyArgsForCall(0)
-						Ω(name).Should(Equal("some-property"))
-						Ω(value).Should(Equal("some-value"))
-					})
+					Ω(env).Should(Equal([]string{"FLAVOR=vanilla", "CONE=waffle=large"}))
+				})
+			})
 
-					itResetsGraceTimeWhenHandling(func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).ShouldNot(HaveOccurred())
-					})
+			Context("when the container has no environment", func() {
+				It("returns an empty environment", func() {
+					env, err := container.Env()
+					Ω(err).ShouldNot(HaveOccurred())
 
-					itFailsWhenTheContainerIsNotFound(func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).Should(HaveOccurred())
-					})
+					Ω(env).Should(BeEmpty())
 				})
+			})
 
-				Context("when setting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.SetPropertyReturns(errors.New("oh no!"))
-					})
+			itResetsGraceTimeWhenHandling(func() {
+				_, err := container.Env()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
 
-					It("returns an error", func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).Should(HaveOccurred())
-					})
-				})
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.Env()
+				Ω(err).Should(HaveOccurred())
 			})
+		})
 
-			Describe("removing", func() {
-				Context("when removing the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.RemovePropertyReturns(nil)
-					})
+		Describe("getting a process's info", func() {
+			info := api.ProcessInfo{
+				ID:         42,
+				State:      api.ProcessStateRunning,
+				CPUTime:    1500 * time.Millisecond,
+				RSSInBytes: 4096,
+				StartedAt:  time.Unix(1234567890, 0),
+			}
 
-					It("returns the property from the container", func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
+			It("returns the info returned by the backend", func() {
+				fakeContainer.ProcessInfoReturns(info, nil)
 
-						Ω(fakeContainer.RemovePropertyCallCount()).Should(Equal(1))
+				returnedInfo, err := container.ProcessInfo(42)
+				Ω(err).ShouldNot(HaveOccurred())
 
-						name := fakeContainer.RemovePropertyArgsForCall(0)
-						Ω(name).Should(Equal("some-property"))
-					})
+				Ω(returnedInfo).Should(Equal(info))
 
-					itResetsGraceTimeWhenHandling(func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
-					})
+				Ω(fakeContainer.ProcessInfoArgsForCall(0)).Should(Equal(uint32(42)))
+			})
 
-					itFailsWhenTheContainerIsNotFound(func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-					})
-				})
+			Context("when the backend does not know when the process started", func() {
+				It("returns a zero start time", func() {
+					fakeContainer.ProcessInfoReturns(api.ProcessInfo{ID: 42}, nil)
 
-				Context("when setting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.RemovePropertyReturns(errors.New("oh no!"))
-					})
+					returnedInfo, err := container.ProcessInfo(42)
+					Ω(err).ShouldNot(HaveOccurred())
 
-					It("returns an error", func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-					})
+					Ω(returnedInfo.StartedAt.IsZero()).Should(BeTrue())
 				})
 			})
-		})
-
-		Describe("streaming in", func() {
-			It("streams the file in, waits for completion, and succeeds", func() {
-				data := bytes.NewBufferString("chunk-1;chunk-2;chunk-3;")
-
-				fakeContainer.StreamInStub = func(dest string, stream io.Reader) error {
-					Ω(dest).Should(Equal("/dst/path"))
-					Ω(ioutil.ReadAll(stream)).Should(Equal([]byte("chunk-1;chunk-2;chunk-3;")))
-					return nil
-				}
 
-				err := container.StreamIn("/dst/path", data)
+			itResetsGraceTimeWhenHandling(func() {
+				_, err := container.ProcessInfo(42)
 				Ω(err).ShouldNot(HaveOccurred())
-
-				Ω(fakeContainer.StreamInCallCount()).Should(Equal(1))
 			})
 
 			itFailsWhenTheContainerIsNotFound(func() {
-				err := container.StreamIn("/dst/path", nil)
+				_, err := container.ProcessInfo(42)
 				Ω(err).Should(HaveOccurred())
 			})
 
-			Context("when copying in to the container fails", func() {
+			Context("when the container has no such process", func() {
 				BeforeEach(func() {
-					fakeContainer.StreamInReturns(errors.New("oh no!"))
+					fakeContainer.ProcessInfoReturns(api.ProcessInfo{}, api.ErrProcessNotFound)
 				})
 
-				It("fails", func() {
-					err := container.StreamIn("/dst/path", nil)
-					Ω(err).Should(HaveOccurred())
+				It("fails with api.ErrProcessNotFound", func() {
+					_, err := container.ProcessInfo(42)
+					Ω(err).Should(Equal(api.ErrProcessNotFound))
 				})
 			})
 		})
 
-		Describe("streaming out", func() {
-			var streamOut io.ReadCloser
-
+		Describe("finding processes by their labels", func() {
 			BeforeEach(func() {
-				streamOut = ioutil.NopCloser(bytes.NewBuffer([]byte("hello-world!")))
+				fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+					process := new(fakes.FakeProcess)
+
+					switch spec.Labels["role"] {
+					case "health-check":
+						process.IDReturns(1)
+					default:
+						process.IDReturns(2)
+					}
+
+					return process, nil
+				}
+
+				fakeContainer.InfoFieldsReturns(api.ContainerInfo{ProcessIDs: []uint32{1, 2, 3}}, nil)
 			})
 
 			JustBeforeEach(func() {
-				fakeContainer.StreamOutReturns(streamOut, nil)
+				for _, role := range []string{"health-check", "app"} {
+					process, err := container.Run(api.ProcessSpec{
+						Path:   "/some/script",
+						Labels: map[string]string{"role": role, "team": "some-team"},
+					}, api.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+				}
 			})
 
-			It("streams the bits out and succeeds", func() {
-				reader, err := container.StreamOut("/src/path")
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(reader).ShouldNot(BeZero())
+			It("passes the labels to the backend", func() {
+				spec, _ := fakeContainer.RunArgsForCall(0)
+				Ω(spec.Labels).Should(Equal(map[string]string{"role": "health-check", "team": "some-team"}))
+			})
 
-				streamedContent, err := ioutil.ReadAll(reader)
+			It("returns the running processes having every label", func() {
+				processIDs, err := container.FindProcesses(map[string]string{"role": "health-check"})
 				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processIDs).Should(Equal([]uint32{1}))
 
-				Ω(string(streamedContent)).Should(Equal("hello-world!"))
+				processIDs, err = container.FindProcesses(map[string]string{"team": "some-team"})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processIDs).Should(Equal([]uint32{1, 2}))
 
-				Ω(fakeContainer.StreamOutArgsForCall(0)).Should(Equal("/src/path"))
+				processIDs, err = container.FindProcesses(map[string]string{"role": "app", "team": "other-team"})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processIDs).Should(BeEmpty())
 			})
 
-			Context("when the connection dies as we're streaming", func() {
-				var closer *closeChecker
-
-				BeforeEach(func() {
-					closer = &closeChecker{}
-
-					streamOut = closer
-				})
+			Context("when a process is no longer running", func() {
+				It("is not returned", func() {
+					fakeContainer.InfoFieldsReturns(api.ContainerInfo{ProcessIDs: []uint32{2}}, nil)
 
-				It("closes the backend's stream", func() {
-					reader, err := container.StreamOut("/src/path")
+					processIDs, err := container.FindProcesses(map[string]string{"team": "some-team"})
 					Ω(err).ShouldNot(HaveOccurred())
+					Ω(processIDs).Should(Equal([]uint32{2}))
 
-					err = reader.Close()
-					Ω(err).ShouldNot(HaveOccurred())
+					// even once a process with its ID is running again
+					fakeContainer.InfoFieldsReturns(api.ContainerInfo{ProcessIDs: []uint32{1, 2}}, nil)
 
-					Eventually(closer.Closed).Should(BeTrue())
+					processIDs, err = container.FindProcesses(map[string]string{"team": "some-team"})
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(processIDs).Should(Equal([]uint32{2}))
 				})
 			})
 
-			itResetsGraceTimeWhenHandling(func() {
-				reader, err := container.StreamOut("/src/path")
-				Ω(err).ShouldNot(HaveOccurred())
-				Ω(reader).ShouldNot(BeZero())
-
-				_, err = ioutil.ReadAll(reader)
-				Ω(err).ShouldNot(HaveOccurred())
-			})
-
 			itFailsWhenTheContainerIsNotFound(func() {
-				_, err := container.StreamOut("/src/path")
+				_, err := container.FindProcesses(map[string]string{"role": "health-check"})
 				Ω(err).Should(HaveOccurred())
 			})
 
-			Context("when streaming out of the container fails", func() {
-				JustBeforeEach(func() {
-					fakeContainer.StreamOutReturns(nil, errors.New("oh no!"))
-				})
+			Context("when listing the container's processes fails", func() {
+				It("fails", func() {
+					fakeContainer.InfoFieldsReturns(api.ContainerInfo{}, errors.New("oh no!"))
 
-				It("returns an error", func() {
-					_, err := container.StreamOut("/src/path")
+					_, err := container.FindProcesses(map[string]string{"role": "health-check"})
 					Ω(err).Should(HaveOccurred())
 				})
 			})
 		})
 
-		Describe("limiting bandwidth", func() {
-			It("sets the container's bandwidth limits", func() {
-				setLimits := api.BandwidthLimits{
-					RateInBytesPerSecond:      123,
-					BurstRateInBytesPerSecond: 456,
-				}
+		Describe("waiting for a process", func() {
+			var running []uint32
+			var runningL sync.Mutex
 
-				err := container.LimitBandwidth(setLimits)
-				Ω(err).ShouldNot(HaveOccurred())
+			setRunning := func(ids ...uint32) {
+				runningL.Lock()
+				running = ids
+				runningL.Unlock()
+			}
 
-				Ω(fakeContainer.LimitBandwidthArgsForCall(0)).Should(Equal(setLimits))
-			})
+			BeforeEach(func() {
+				setRunning()
 
-			itResetsGraceTimeWhenHandling(func() {
-				err := container.LimitBandwidth(api.BandwidthLimits{
-					RateInBytesPerSecond:      123,
-					BurstRateInBytesPerSecond: 456,
-				})
-				Ω(err).ShouldNot(HaveOccurred())
+				fakeContainer.InfoFieldsStub = func([]api.InfoField) (api.ContainerInfo, error) {
+					runningL.Lock()
+					defer runningL.Unlock()
+
+					return api.ContainerInfo{ProcessIDs: running}, nil
+				}
 			})
 
-			itFailsWhenTheContainerIsNotFound(func() {
-				err := container.LimitBandwidth(api.BandwidthLimits{
-					RateInBytesPerSecond:      123,
-					BurstRateInBytesPerSecond: 456,
-				})
-				Ω(err).Should(HaveOccurred())
+			It("returns a matching process which is already running", func() {
+				setRunning(6, 7)
+
+				processID, err := container.WaitForProcess(api.ProcessMatcher{ID: 7}, time.Second)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processID).Should(Equal(uint32(7)))
 			})
 
-			Context("when limiting the bandwidth fails", func() {
-				BeforeEach(func() {
-					fakeContainer.LimitBandwidthReturns(errors.New("oh no!"))
-				})
+			It("waits for a process started without the server, e.g. by an init script", func() {
+				go func() {
+					time.Sleep(200 * time.Millisecond)
+					setRunning(9)
+				}()
 
-				It("fails", func() {
-					err := container.LimitBandwidth(api.BandwidthLimits{
-						RateInBytesPerSecond:      123,
-						BurstRateInBytesPerSecond: 456,
-					})
-					Ω(err).Should(HaveOccurred())
-				})
+				processID, err := container.WaitForProcess(api.ProcessMatcher{}, 5*time.Second)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(processID).Should(Equal(uint32(9)))
 			})
-		})
 
-		Describe("getting the current bandwidth limits", func() {
-			It("returns the limits returned by the backend", func() {
-				effectiveLimits := api.BandwidthLimits{
-					RateInBytesPerSecond:      1230,
-					BurstRateInBytesPerSecond: 4560,
+			It("waits for a process run through the server with the labels", func() {
+				fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+					setRunning(3, 5)
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns(5)
+					return process, nil
 				}
 
-				fakeContainer.CurrentBandwidthLimitsReturns(effectiveLimits, nil)
+				setRunning(3)
+
+				go func() {
+					defer GinkgoRecover()
+
+					time.Sleep(200 * time.Millisecond)
+
+					_, err := container.Run(api.ProcessSpec{
+						Path:   "/some/daemon",
+						Labels: map[string]string{"role": "daemon"},
+					}, api.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+				}()
 
-				limits, err := container.CurrentBandwidthLimits()
+				processID, err := container.WaitForProcess(api.ProcessMatcher{
+					Labels: map[string]string{"role": "daemon"},
+				}, 5*time.Second)
 				Ω(err).ShouldNot(HaveOccurred())
-
-				Ω(limits).Should(Equal(effectiveLimits))
+				Ω(processID).Should(Equal(uint32(5)))
 			})
 
-			Context("when getting the current limits fails", func() {
-				BeforeEach(func() {
-					fakeContainer.CurrentBandwidthLimitsReturns(api.BandwidthLimits{}, errors.New("oh no!"))
-				})
+			Context("when no process matches in time", func() {
+				It("fails with ErrProcessNotFound", func() {
+					setRunning(6)
 
-				It("fails", func() {
-					_, err := container.CurrentBandwidthLimits()
-					Ω(err).Should(HaveOccurred())
+					_, err := container.WaitForProcess(api.ProcessMatcher{ID: 7}, 200*time.Millisecond)
+					Ω(err).Should(Equal(api.ErrProcessNotFound))
 				})
 			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.WaitForProcess(api.ProcessMatcher{}, time.Second)
+				Ω(err).Should(HaveOccurred())
+			})
 		})
 
-		Describe("limiting memory", func() {
-			setLimits := api.MemoryLimits{1024}
+		Describe("set the cpu limit", func() {
+			setLimits := api.CPULimits{
+				LimitInShares: 123,
+				Quota:         50000,
+				Period:        100000,
+				Cpuset:        "0-2,4",
+			}
 
-			It("sets the container's memory limits", func() {
-				err := container.LimitMemory(setLimits)
+			It("sets the container's CPU shares, quota and cores", func() {
+				err := container.LimitCPU(setLimits)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(fakeContainer.LimitMemoryArgsForCall(0)).Should(Equal(setLimits))
+				Ω(fakeContainer.LimitCPUArgsForCall(0)).Should(Equal(setLimits))
 			})
 
 			itResetsGraceTimeWhenHandling(func() {
-				err := container.LimitMemory(setLimits)
+				err := container.LimitCPU(setLimits)
 				Ω(err).ShouldNot(HaveOccurred())
 			})
 
 			itFailsWhenTheContainerIsNotFound(func() {
-				err := container.LimitMemory(api.MemoryLimits{123})
+				err := container.LimitCPU(setLimits)
 				Ω(err).Should(HaveOccurred())
 			})
 
-			Context("when limiting the memory fails", func() {
+			It("returns the container's resulting limits", func() {
+				fakeContainer.CurrentCPULimitsReturns(setLimits, nil)
+
+				err := container.LimitCPU(setLimits)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.CurrentCPULimitsCallCount()).Should(Equal(1))
+			})
+
+			Context("when limiting the CPU fails", func() {
 				BeforeEach(func() {
-					fakeContainer.LimitMemoryReturns(errors.New("oh no!"))
+					fakeContainer.LimitCPUReturns(errors.New("oh no!"))
 				})
 
-				It("fail", func() {
-					err := container.LimitMemory(api.MemoryLimits{123})
+				It("fails", func() {
+					err := container.LimitCPU(setLimits)
 					Ω(err).Should(HaveOccurred())
 				})
 			})
 		})
 
-		Describe("getting memory limits", func() {
-			It("obtains the current limits", func() {
-				effectiveLimits := api.MemoryLimits{2048}
-				fakeContainer.CurrentMemoryLimitsReturns(effectiveLimits, nil)
+		Describe("get the current cpu limits", func() {
+			effectiveLimits := api.CPULimits{
+				LimitInShares: 456,
+				Quota:         25000,
+				Period:        100000,
+				Cpuset:        "1",
+			}
 
-				limits, err := container.CurrentMemoryLimits()
+			It("gets the current limits", func() {
+				fakeContainer.CurrentCPULimitsReturns(effectiveLimits, nil)
+
+				limits, err := container.CurrentCPULimits()
 				Ω(err).ShouldNot(HaveOccurred())
-				Ω(limits).ShouldNot(BeZero())
 
 				Ω(limits).Should(Equal(effectiveLimits))
 			})
 
-			It("does not change the memory limit", func() {
-				_, err := container.CurrentMemoryLimits()
+			It("does not change the cpu limits", func() {
+				_, err := container.CurrentCPULimits()
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(fakeContainer.LimitMemoryCallCount()).Should(BeZero())
+				Ω(fakeContainer.LimitCPUCallCount()).Should(BeZero())
 			})
 
 			itFailsWhenTheContainerIsNotFound(func() {
-				_, err := container.CurrentMemoryLimits()
+				_, err := container.CurrentCPULimits()
 				Ω(err).Should(HaveOccurred())
 			})
 
-			Context("when getting the current memory limits fails", func() {
+			Context("when getting the current CPU limits fails", func() {
 				BeforeEach(func() {
-					fakeContainer.CurrentMemoryLimitsReturns(api.MemoryLimits{}, errors.New("oh no!"))
+					fakeContainer.CurrentCPULimitsReturns(api.CPULimits{}, errors.New("oh no!"))
 				})
 
 				It("fails", func() {
-					_, err := container.CurrentMemoryLimits()
+					_, err := container.CurrentCPULimits()
 					Ω(err).Should(HaveOccurred())
 				})
 			})
 		})
 
-		Describe("limiting disk", func() {
-			setLimits := api.DiskLimits{
-				BlockSoft: 111,
-				BlockHard: 222,
+		Describe("decoding requests", func() {
+			sendStopAccepting := func(contentType string, accept string) *http.Response {
+				conn, err := net.Dial("unix", socketPath)
+				Ω(err).ShouldNot(HaveOccurred())
 
-				InodeSoft: 333,
-				InodeHard: 444,
+				defer conn.Close()
 
-				ByteSoft: 555,
-				ByteHard: 666,
-			}
+				request, err := http.NewRequest(
+					"PUT",
+					"http://api/containers/some-handle/stop",
+					bytes.NewBufferString(`{"handle":"some-handle","kill":true}`),
+				)
+				Ω(err).ShouldNot(HaveOccurred())
 
-			It("sets the container's disk limits and returns the current limits", func() {
-				err := container.LimitDisk(setLimits)
+				request.Header.Set("Content-Type", contentType)
+
+				if accept != "" {
+					request.Header.Set("Accept", accept)
+				}
+
+				err = request.Write(conn)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(fakeContainer.LimitDiskArgsForCall(0)).Should(Equal(setLimits))
+				response, err := http.ReadResponse(bufio.NewReader(conn), request)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				return response
+			}
+
+			sendStop := func(contentType string) *http.Response {
+				return sendStopAccepting(contentType, "")
+			}
+
+			It("decodes requests with the codec registered for their content type", func() {
+				transport.RegisterCodec(relabelledCodec{transport.JSON, "application/x-garden-test"})
+
+				response := sendStop("application/x-garden-test")
+				Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+				Ω(fakeContainer.StopArgsForCall(0)).Should(BeTrue())
 			})
 
-			itResetsGraceTimeWhenHandling(func() {
-				err := container.LimitDisk(setLimits)
-				Ω(err).ShouldNot(HaveOccurred())
+			It("encodes responses with the codec the request was encoded with", func() {
+				transport.RegisterCodec(relabelledCodec{transport.JSON, "application/x-garden-test"})
+
+				response := sendStop("application/x-garden-test")
+				Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+				Ω(response.Header.Get("Content-Type")).Should(Equal("application/x-garden-test"))
 			})
 
-			itFailsWhenTheContainerIsNotFound(func() {
-				err := container.LimitDisk(api.DiskLimits{})
-				Ω(err).Should(HaveOccurred())
+			It("encodes responses with the first codec the request accepts which is registered", func() {
+				transport.RegisterCodec(relabelledCodec{transport.JSON, "application/x-garden-test"})
+
+				response := sendStopAccepting("application/json", "application/x-unregistered, application/x-garden-test;q=0.9")
+				Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+				Ω(response.Header.Get("Content-Type")).Should(Equal("application/x-garden-test"))
 			})
 
-			Context("when limiting the disk fails", func() {
+			Context("when no codec is registered for the content type", func() {
+				It("rejects the request without handling it", func() {
+					response := sendStop("application/x-unregistered")
+					Ω(response.StatusCode).Should(Equal(http.StatusInternalServerError))
+
+					var errResponse apitypes.ErrorResponse
+					err := json.NewDecoder(response.Body).Decode(&errResponse)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(errResponse.GetMessage()).Should(Equal("content-type must be application/json"))
+
+					Ω(fakeContainer.StopCallCount()).Should(Equal(0))
+				})
+			})
+
+			Context("when the request is encoded as protocol buffers", func() {
+				It("decodes it, and encodes the response likewise", func() {
+					body := new(bytes.Buffer)
+					err := transport.Protobuf.Encode(body, &apitypes.StopRequest{
+						Handle: apitypes.String("some-handle"),
+						Kill:   apitypes.Bool(true),
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					conn, err := net.Dial("unix", socketPath)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					defer conn.Close()
+
+					request, err := http.NewRequest("PUT", "http://api/containers/some-handle/stop", body)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					request.Header.Set("Content-Type", "application/x-protobuf")
+
+					err = request.Write(conn)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					response, err := http.ReadResponse(bufio.NewReader(conn), request)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(response.StatusCode).Should(Equal(http.StatusOK))
+					Ω(response.Header.Get("Content-Type")).Should(Equal("application/x-protobuf"))
+
+					Ω(fakeContainer.StopArgsForCall(0)).Should(BeTrue())
+				})
+			})
+
+			Context("when the client is configured to speak protocol buffers", func() {
 				BeforeEach(func() {
-					fakeContainer.LimitDiskReturns(errors.New("oh no!"))
+					apiClient = client.New(connection.NewWithConfig("unix", socketPath, connection.Config{
+						Codec: transport.Protobuf,
+					}))
 				})
 
-				It("fails", func() {
-					err := container.LimitDisk(api.DiskLimits{})
-					Ω(err).Should(HaveOccurred())
+				It("round-trips requests and responses", func() {
+					err := container.Stop(true)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(fakeContainer.StopArgsForCall(0)).Should(BeTrue())
+
+					fakeContainer.InfoReturns(api.ContainerInfo{
+						State:       "active",
+						ContainerIP: "10.0.0.2",
+						ProcessIDs:  []uint32{1, 2},
+					}, nil)
+
+					info, err := container.Info()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(info.State).Should(Equal("active"))
+					Ω(info.ContainerIP).Should(Equal("10.0.0.2"))
+					Ω(info.ProcessIDs).Should(Equal([]uint32{1, 2}))
+				})
+
+				It("decodes errors", func() {
+					fakeContainer.StopReturns(errors.New("oh no!"))
+
+					err := container.Stop(true)
+					Ω(err).Should(MatchError("oh no!"))
+				})
+			})
+
+			Context("when the request is bigger than the transport's maximum message size", func() {
+				It("rejects it with a MessageTooLargeError, without handling it", func() {
+					conn, err := net.Dial("unix", socketPath)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					defer conn.Close()
+
+					body := `{"handle":"` + strings.Repeat("x", transport.MaxMessageSize) + `","kill":true}`
+
+					request, err := http.NewRequest("PUT", "http://api/containers/some-handle/stop", bytes.NewBufferString(body))
+					Ω(err).ShouldNot(HaveOccurred())
+
+					request.Header.Set("Content-Type", "application/json")
+
+					// the server responds without reading the rest of the body
+					go request.Write(conn)
+
+					response, err := http.ReadResponse(bufio.NewReader(conn), request)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(response.StatusCode).Should(Equal(http.StatusRequestEntityTooLarge))
+
+					var errResponse apitypes.ErrorResponse
+					err = json.NewDecoder(response.Body).Decode(&errResponse)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(errResponse.GetType()).Should(Equal(apitypes.ErrorTypeMessageTooLarge))
+					Ω(errResponse.GetMessageSizeLimit()).Should(BeNumerically("==", transport.MaxMessageSize))
+
+					Ω(fakeContainer.StopCallCount()).Should(Equal(0))
 				})
 			})
+
+			Context("when the request fails", func() {
+				for err, status := range map[error]int{
+					api.ErrContainerNotFound:                                     http.StatusNotFound,
+					api.ErrUnsupportedOperation:                                  http.StatusNotImplemented,
+					api.ErrCapacityExceeded:                                      http.StatusConflict,
+					api.ErrRateLimited:                                           transport.StatusTooManyRequests,
+					api.Classify(api.ErrorClassRetryable, errors.New("busy")):    http.StatusServiceUnavailable,
+					api.Classify(api.ErrorClassQuota, errors.New("no inodes")):   http.StatusConflict,
+					api.Classify(api.ErrorClass("bogus"), errors.New("strange")): http.StatusInternalServerError,
+					errors.New("oh no!"):                                         http.StatusInternalServerError,
+				} {
+					err, status := err, status
+
+					It("responds with the status of its class, and the class, for "+err.Error(), func() {
+						fakeContainer.StopReturns(err)
+
+						response := sendStop("application/json")
+						Ω(response.StatusCode).Should(Equal(status))
+
+						var errResponse apitypes.ErrorResponse
+						decodeErr := json.NewDecoder(response.Body).Decode(&errResponse)
+						Ω(decodeErr).ShouldNot(HaveOccurred())
+
+						Ω(errResponse.GetClass()).Should(Equal(string(api.ClassOf(err))))
+					})
+				}
+			})
 		})
 
-		Describe("getting the current disk limits", func() {
-			currentLimits := api.DiskLimits{
-				BlockSoft: 1111,
-				BlockHard: 2222,
+		Describe("validating requests", func() {
+			sendRequest := func(method, path, body string) (*http.Response, apitypes.ErrorResponse) {
+				conn, err := net.Dial("unix", socketPath)
+				Ω(err).ShouldNot(HaveOccurred())
 
-				InodeSoft: 3333,
-				InodeHard: 4444,
+				defer conn.Close()
 
-				ByteSoft: 5555,
-				ByteHard: 6666,
-			}
+				request, err := http.NewRequest(method, "http://api"+path, bytes.NewBufferString(body))
+				Ω(err).ShouldNot(HaveOccurred())
 
-			It("returns the limits returned by the backend", func() {
-				fakeContainer.CurrentDiskLimitsReturns(currentLimits, nil)
+				request.Header.Set("Content-Type", "application/json")
 
-				limits, err := container.CurrentDiskLimits()
+				err = request.Write(conn)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(limits).Should(Equal(currentLimits))
-			})
+				response, err := http.ReadResponse(bufio.NewReader(conn), request)
+				Ω(err).ShouldNot(HaveOccurred())
 
-			It("does not change the disk limit", func() {
-				_, err := container.CurrentDiskLimits()
+				var errResponse apitypes.ErrorResponse
+				err = json.NewDecoder(response.Body).Decode(&errResponse)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(fakeContainer.LimitDiskCallCount()).Should(BeZero())
-			})
+				return response, errResponse
+			}
 
-			itFailsWhenTheContainerIsNotFound(func() {
-				_, err := container.CurrentDiskLimits()
-				Ω(err).Should(HaveOccurred())
-			})
+			Context("when a port is out of range", func() {
+				It("returns an InvalidRequestError listing each port, without mapping them", func() {
+					_, _, err := container.NetIn(70000, 80000)
+					Ω(err).Should(Equal(api.InvalidRequestError{
+						Violations: []api.ValidationError{
+							{Field: "host_port", Reason: "must be between 1 and 65535"},
+							{Field: "container_port", Reason: "must be between 1 and 65535"},
+						},
+					}))
 
-			Context("when getting the current disk limits fails", func() {
-				BeforeEach(func() {
-					fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{}, errors.New("oh no!"))
+					Ω(fakeContainer.NetInSpecCallCount()).Should(BeZero())
 				})
+			})
 
-				It("fails", func() {
-					_, err := container.CurrentDiskLimits()
-					Ω(err).Should(HaveOccurred())
+			Context("when a host IP is not an IP", func() {
+				It("responds with a 422 naming the field", func() {
+					response, errResponse := sendRequest(
+						"POST",
+						"/containers/some-handle/net/in",
+						`{"handle":"some-handle","host_port":123,"host_ip":"banana"}`,
+					)
+
+					Ω(response.StatusCode).Should(Equal(transport.StatusUnprocessableEntity))
+					Ω(errResponse.GetViolations()).Should(Equal([]*apitypes.ErrorResponse_Validation{
+						{
+							Field:  apitypes.String("host_ip"),
+							Reason: apitypes.String("must be an IP address"),
+						},
+					}))
+
+					Ω(fakeContainer.NetInSpecCallCount()).Should(BeZero())
 				})
 			})
-		})
 
-		Describe("set the cpu limit", func() {
-			setLimits := api.CPULimits{123}
+			Context("when a bind mount is malformed", func() {
+				It("responds with a 422 naming each field, without creating the container", func() {
+					response, errResponse := sendRequest(
+						"POST",
+						"/containers",
+						`{"handle":"other-handle","bind_mounts":[{"src_path":"relative/src","dst_path":"/dst/../etc","mode":7,"origin":7}]}`,
+					)
+
+					Ω(response.StatusCode).Should(Equal(transport.StatusUnprocessableEntity))
+					Ω(errResponse.GetViolations()).Should(Equal([]*apitypes.ErrorResponse_Validation{
+						{
+							Field:  apitypes.String("bind_mounts.src_path"),
+							Reason: apitypes.String("must be an absolute path"),
+						},
+						{
+							Field:  apitypes.String("bind_mounts.dst_path"),
+							Reason: apitypes.String("must not contain .."),
+						},
+						{
+							Field:  apitypes.String("bind_mounts.mode"),
+							Reason: apitypes.String("must be RO or RW"),
+						},
+						{
+							Field:  apitypes.String("bind_mounts.origin"),
+							Reason: apitypes.String("must be Host or Container"),
+						},
+					}))
 
-			It("sets the container's CPU shares", func() {
-				err := container.LimitCPU(setLimits)
-				Ω(err).ShouldNot(HaveOccurred())
+					// only the container created before each test
+					Ω(serverBackend.CreateCallCount()).Should(Equal(1))
+				})
+			})
 
-				Ω(fakeContainer.LimitCPUArgsForCall(0)).Should(Equal(setLimits))
+			Context("when an environment variable is malformed", func() {
+				It("responds with a 422 naming each violation, without creating the container", func() {
+					response, errResponse := sendRequest(
+						"POST",
+						"/containers",
+						`{"handle":"other-handle","env":[{"Value":"no-key"},{"Key":"A=B","Value":"c"},{"Key":"NUL","Value":"a\u0000b"}]}`,
+					)
+
+					Ω(response.StatusCode).Should(Equal(transport.StatusUnprocessableEntity))
+					Ω(errResponse.GetViolations()).Should(Equal([]*apitypes.ErrorResponse_Validation{
+						{
+							Field:  apitypes.String("env"),
+							Reason: apitypes.String("must not have empty keys"),
+						},
+						{
+							Field:  apitypes.String("env"),
+							Reason: apitypes.String("must not have keys containing ="),
+						},
+						{
+							Field:  apitypes.String("env"),
+							Reason: apitypes.String("must not contain NUL bytes"),
+						},
+					}))
+
+					// only the container created before each test
+					Ω(serverBackend.CreateCallCount()).Should(Equal(1))
+				})
 			})
 
-			itResetsGraceTimeWhenHandling(func() {
-				err := container.LimitCPU(setLimits)
-				Ω(err).ShouldNot(HaveOccurred())
+			Context("when a limit is negative", func() {
+				It("responds with a 422 naming the field", func() {
+					response, errResponse := sendRequest(
+						"PUT",
+						"/containers/some-handle/limits/memory",
+						`{"handle":"some-handle","limit_in_bytes":-1}`,
+					)
+
+					Ω(response.StatusCode).Should(Equal(transport.StatusUnprocessableEntity))
+					Ω(errResponse.GetType()).Should(Equal(apitypes.ErrorTypeInvalidRequest))
+					Ω(errResponse.GetViolations()).Should(Equal([]*apitypes.ErrorResponse_Validation{
+						{
+							Field:  apitypes.String("limit_in_bytes"),
+							Reason: apitypes.String("must be a non-negative integer"),
+						},
+					}))
+
+					Ω(fakeContainer.LimitMemoryCallCount()).Should(BeZero())
+				})
 			})
 
-			itFailsWhenTheContainerIsNotFound(func() {
-				err := container.LimitCPU(setLimits)
-				Ω(err).Should(HaveOccurred())
+			Context("when the body has fields the server does not know", func() {
+				It("ignores them", func() {
+					response, _ := sendRequest(
+						"PUT",
+						"/containers/some-handle/limits/memory",
+						`{"handle":"some-handle","limit_in_bytes":1024,"swap_limit_in_bytes":2048}`,
+					)
+
+					Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+					Ω(fakeContainer.LimitMemoryArgsForCall(0)).Should(Equal(api.MemoryLimits{
+						LimitInBytes: 1024,
+					}))
+				})
 			})
 
-			Context("when limiting the CPU fails", func() {
-				BeforeEach(func() {
-					fakeContainer.LimitCPUReturns(errors.New("oh no!"))
+			Context("when the body names a different handle to the URL", func() {
+				It("responds with a 422 naming the handle", func() {
+					response, errResponse := sendRequest(
+						"PUT",
+						"/containers/some-handle/stop",
+						`{"handle":"other-handle"}`,
+					)
+
+					Ω(response.StatusCode).Should(Equal(transport.StatusUnprocessableEntity))
+					Ω(errResponse.GetViolations()).Should(HaveLen(1))
+					Ω(errResponse.GetViolations()[0].GetField()).Should(Equal("handle"))
+
+					Ω(fakeContainer.StopCallCount()).Should(BeZero())
 				})
+			})
 
-				It("fails", func() {
-					err := container.LimitCPU(setLimits)
-					Ω(err).Should(HaveOccurred())
+			Context("when the body omits the handle", func() {
+				It("acts on the container in the URL", func() {
+					response, _ := sendRequest(
+						"PUT",
+						"/containers/some-handle/stop",
+						`{"kill":true}`,
+					)
+
+					Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+					Ω(fakeContainer.StopArgsForCall(0)).Should(BeTrue())
 				})
 			})
 		})
 
-		Describe("get the current cpu limits", func() {
-			effectiveLimits := api.CPULimits{456}
+		Describe("net in", func() {
+			It("maps the TCP ports on all addresses and returns them", func() {
+				fakeContainer.NetInSpecReturns(111, 222, nil)
 
-			It("gets the current limits", func() {
-				fakeContainer.CurrentCPULimitsReturns(effectiveLimits, nil)
+				hostPort, containerPort, err := container.NetIn(123, 456)
+				Ω(err).ShouldNot(HaveOccurred())
 
-				limits, err := container.CurrentCPULimits()
+				Ω(fakeContainer.NetInSpecArgsForCall(0)).Should(Equal(api.NetInSpec{
+					HostPort:      123,
+					ContainerPort: 456,
+					Protocol:      api.ProtocolTCP,
+				}))
+
+				Ω(hostPort).Should(Equal(uint32(111)))
+				Ω(containerPort).Should(Equal(uint32(222)))
+			})
+
+			It("maps UDP ports on the given host address", func() {
+				fakeContainer.NetInSpecReturns(111, 222, nil)
+
+				hostPort, containerPort, err := container.NetInSpec(api.NetInSpec{
+					HostPort:      123,
+					ContainerPort: 456,
+					Protocol:      api.ProtocolUDP,
+					HostIP:        net.ParseIP("10.0.0.1"),
+				})
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Ω(limits).Should(Equal(effectiveLimits))
+				Ω(fakeContainer.NetInSpecArgsForCall(0)).Should(Equal(api.NetInSpec{
+					HostPort:      123,
+					ContainerPort: 456,
+					Protocol:      api.ProtocolUDP,
+					HostIP:        net.IP{10, 0, 0, 1},
+				}))
+
+				Ω(hostPort).Should(Equal(uint32(111)))
+				Ω(containerPort).Should(Equal(uint32(222)))
 			})
 
-			It("does not change the cpu limits", func() {
-				_, err := container.CurrentCPULimits()
+			itResetsGraceTimeWhenHandling(func() {
+				_, _, err := container.NetIn(123, 456)
 				Ω(err).ShouldNot(HaveOccurred())
-
-				Ω(fakeContainer.LimitCPUCallCount()).Should(BeZero())
 			})
 
 			itFailsWhenTheContainerIsNotFound(func() {
-				_, err := container.CurrentCPULimits()
+				_, _, err := container.NetIn(123, 456)
 				Ω(err).Should(HaveOccurred())
 			})
 
-			Context("when getting the current CPU limits fails", func() {
+			Context("when mapping the port fails", func() {
 				BeforeEach(func() {
-					fakeContainer.CurrentCPULimitsReturns(api.CPULimits{}, errors.New("oh no!"))
+					fakeContainer.NetInSpecReturns(0, 0, errors.New("oh no!"))
 				})
 
 				It("fails", func() {
-					_, err := container.CurrentCPULimits()
+					_, _, err := container.NetIn(123, 456)
 					Ω(err).Should(HaveOccurred())
 				})
 			})
 		})
 
-		Describe("net in", func() {
-			It("maps the ports and returns them", func() {
-				fakeContainer.NetInReturns(111, 222, nil)
+		Describe("getting the mapped ports", func() {
+			JustBeforeEach(func() {
+				// a container just created answers from its creation info, so
+				// look it up to ask the server
+				serverBackend.ContainersReturns([]api.Container{fakeContainer}, nil)
 
-				hostPort, containerPort, err := container.NetIn(123, 456)
+				found, err := apiClient.Lookup("some-handle")
 				Ω(err).ShouldNot(HaveOccurred())
 
-				hp, cp := fakeContainer.NetInArgsForCall(0)
-				Ω(hp).Should(Equal(uint32(123)))
-				Ω(cp).Should(Equal(uint32(456)))
+				container = found.(client.Container)
+			})
 
-				Ω(hostPort).Should(Equal(uint32(111)))
-				Ω(containerPort).Should(Equal(uint32(222)))
+			It("returns the container's port mappings", func() {
+				mappings := []api.PortMapping{
+					{HostPort: 111, ContainerPort: 222, Protocol: api.ProtocolTCP},
+					{HostPort: 333, ContainerPort: 444, Protocol: api.ProtocolUDP, HostIP: net.ParseIP("10.0.0.1")},
+				}
+
+				fakeContainer.MappedPortsReturns(mappings, nil)
+
+				mappedPorts, err := container.MappedPorts()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(mappedPorts).Should(Equal(mappings))
+			})
+
+			It("does not fetch the container's info", func() {
+				infoCalls := fakeContainer.InfoCallCount()
+
+				_, err := container.MappedPorts()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.InfoCallCount()).Should(Equal(infoCalls))
 			})
 
 			itResetsGraceTimeWhenHandling(func() {
-				_, _, err := container.NetIn(123, 456)
+				_, err := container.MappedPorts()
 				Ω(err).ShouldNot(HaveOccurred())
 			})
 
 			itFailsWhenTheContainerIsNotFound(func() {
-				_, _, err := container.NetIn(123, 456)
+				_, err := container.MappedPorts()
 				Ω(err).Should(HaveOccurred())
 			})
 
-			Context("when mapping the port fails", func() {
+			Context("when getting the mapped ports fails", func() {
 				BeforeEach(func() {
-					fakeContainer.NetInReturns(0, 0, errors.New("oh no!"))
+					fakeContainer.MappedPortsReturns(nil, errors.New("oh no!"))
 				})
 
 				It("fails", func() {
-					_, _, err := container.NetIn(123, 456)
+					_, err := container.MappedPorts()
 					Ω(err).Should(HaveOccurred())
 				})
 			})
 		})
 
 		Describe("net out", func() {
-			It("permits traffic outside of the container with port specified", func() {
+			It("permits traffic outside of the container with port specified, as a rule", func() {
 				err := container.NetOut("1.2.3.4/22", 456, "", api.ProtocolAll)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				cidr, port, portRange, protoc := fakeContainer.NetOutArgsForCall(0)
-				Ω(cidr).Should(Equal("1.2.3.4/22"))
-				Ω(port).Should(Equal(uint32(456)))
-				Ω(portRange).Should(Equal(""))
-				Ω(protoc).Should(Equal(api.ProtocolAll))
+				Ω(fakeContainer.NetOutRuleArgsForCall(0)).Should(Equal(api.NetOutRule{
+					Protocol: api.ProtocolAll,
+					Networks: []api.IPRange{
+						{Start: net.IPv4(1, 2, 0, 0).To4(), End: net.IPv4(1, 2, 3, 255).To4()},
+					},
+					Ports: []api.PortRange{
+						{Start: 456, End: 456},
+					},
+				}))
+			})
+
+			It("permits traffic outside of the container with port range specified, as a rule", func() {
+				err := container.NetOut("1.2.3.4/22", 0, "80:81", api.ProtocolTCP)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.NetOutRuleArgsForCall(0)).Should(Equal(api.NetOutRule{
+					Protocol: api.ProtocolTCP,
+					Networks: []api.IPRange{
+						{Start: net.IPv4(1, 2, 0, 0).To4(), End: net.IPv4(1, 2, 3, 255).To4()},
+					},
+					Ports: []api.PortRange{
+						{Start: 80, End: 81},
+					},
+				}))
+			})
+
+			It("translates a single IP or a range of IPs into a network", func() {
+				err := container.NetOut("1.2.3.4", 0, "", api.ProtocolAll)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = container.NetOut("1.2.3.4-1.2.3.9", 0, "", api.ProtocolAll)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.NetOutRuleArgsForCall(0).Networks).Should(Equal([]api.IPRange{
+					{Start: net.IPv4(1, 2, 3, 4).To4(), End: net.IPv4(1, 2, 3, 4).To4()},
+				}))
+
+				Ω(fakeContainer.NetOutRuleArgsForCall(1).Networks).Should(Equal([]api.IPRange{
+					{Start: net.IPv4(1, 2, 3, 4).To4(), End: net.IPv4(1, 2, 3, 9).To4()},
+				}))
 			})
 
-			It("permits traffic outside of the container with port range specified", func() {
-				err := container.NetOut("1.2.3.4/22", 0, "80:81", api.ProtocolAll)
+			It("allows any network when none is given", func() {
+				err := container.NetOut("", 456, "", api.ProtocolAll)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				cidr, port, portRange, protoc := fakeContainer.NetOutArgsForCall(0)
-				Ω(cidr).Should(Equal("1.2.3.4/22"))
-				Ω(port).Should(Equal(uint32(0)))
-				Ω(portRange).Should(Equal("80:81"))
-				Ω(protoc).Should(Equal(api.ProtocolAll))
+				Ω(fakeContainer.NetOutRuleArgsForCall(0).Networks).Should(BeEmpty())
+			})
+
+			Context("with an invalid network", func() {
+				It("returns an error without permitting traffic", func() {
+					err := container.NetOut("foo-network", 456, "", api.ProtocolAll)
+					Ω(err).Should(MatchError(`invalid network: "foo-network"`))
+
+					Ω(fakeContainer.NetOutRuleCallCount()).Should(BeZero())
+				})
 			})
 
 			Context("with an invalid port range", func() {
@@ -1120,7 +4050,7 @@ var _ = Describe("When a client connects", func() {
 
 			Context("when permitting traffic fails", func() {
 				BeforeEach(func() {
-					fakeContainer.NetOutReturns(errors.New("oh no!"))
+					fakeContainer.NetOutRuleReturns(errors.New("oh no!"))
 				})
 
 				It("fails", func() {
@@ -1130,6 +4060,61 @@ var _ = Describe("When a client connects", func() {
 			})
 		})
 
+		Describe("net out rules", func() {
+			rule := api.NetOutRule{
+				Protocol: api.ProtocolUDP,
+				Networks: []api.IPRange{
+					{Start: net.IPv4(10, 0, 0, 1).To4(), End: net.IPv4(10, 0, 0, 9).To4()},
+					api.IPRangeFromIP(net.ParseIP("2001:db8::1")),
+				},
+				Ports: []api.PortRange{
+					api.PortRangeFromPort(53),
+					{Start: 8080, End: 8081},
+				},
+				Log: true,
+			}
+
+			It("permits traffic outside of the container matching the rule", func() {
+				err := container.NetOutRule(rule)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.NetOutRuleArgsForCall(0)).Should(Equal(rule))
+			})
+
+			itResetsGraceTimeWhenHandling(func() {
+				err := container.NetOutRule(rule)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.NetOutRule(rule)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when a port range ends before it starts", func() {
+				It("returns an InvalidRequestError without permitting traffic", func() {
+					err := container.NetOutRule(api.NetOutRule{
+						Protocol: api.ProtocolTCP,
+						Ports:    []api.PortRange{{Start: 81, End: 80}},
+					})
+					Ω(err).Should(BeAssignableToTypeOf(api.InvalidRequestError{}))
+
+					Ω(fakeContainer.NetOutRuleCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when permitting traffic fails", func() {
+				BeforeEach(func() {
+					fakeContainer.NetOutRuleReturns(errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					err := container.NetOutRule(rule)
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
 		Describe("info", func() {
 			containerInfo := api.ContainerInfo{
 				State:         "active",
@@ -1189,20 +4174,171 @@ var _ = Describe("When a client connects", func() {
 					OutBurst: 4,
 				},
 				MappedPorts: []api.PortMapping{
-					{HostPort: 1234, ContainerPort: 5678},
-					{HostPort: 1235, ContainerPort: 5679},
+					{HostPort: 1234, ContainerPort: 5678, Protocol: api.ProtocolTCP},
+					{HostPort: 1235, ContainerPort: 5679, Protocol: api.ProtocolTCP},
+				},
+				ExternalInterface: "eth1",
+				Limits: api.ContainerLimits{
+					Bandwidth: &api.BandwidthLimits{RateInBytesPerSecond: 1, BurstRateInBytesPerSecond: 2},
+					CPU:       &api.CPULimits{LimitInShares: 3, Quota: 4, Period: 5, Cpuset: "0-1"},
+					Disk:      &api.DiskLimits{BlockSoft: 6, BlockHard: 7, InodeSoft: 8, InodeHard: 9, ByteSoft: 10, ByteHard: 11},
+					Memory:    &api.MemoryLimits{LimitInBytes: 12},
 				},
 			}
 
+			BeforeEach(func() {
+				fakeContainer.CurrentBandwidthLimitsReturns(*containerInfo.Limits.Bandwidth, nil)
+				fakeContainer.CurrentCPULimitsReturns(*containerInfo.Limits.CPU, nil)
+				fakeContainer.CurrentDiskLimitsReturns(*containerInfo.Limits.Disk, nil)
+				fakeContainer.CurrentMemoryLimitsReturns(*containerInfo.Limits.Memory, nil)
+			})
+
 			It("reports information about the container", func() {
 				fakeContainer.InfoReturns(containerInfo, nil)
 
 				info, err := container.Info()
 				Ω(err).ShouldNot(HaveOccurred())
 
+				// the server's own, rather than the backend's
+				info.LastActivityAt = time.Time{}
+
 				Ω(info).Should(Equal(containerInfo))
 			})
 
+			It("reports the limits applied to the container", func() {
+				info, err := container.Info()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(info.Limits).Should(Equal(containerInfo.Limits))
+			})
+
+			Context("when the backend cannot report a limit", func() {
+				BeforeEach(func() {
+					fakeContainer.CurrentDiskLimitsReturns(api.DiskLimits{}, api.ErrUnsupportedOperation)
+				})
+
+				It("leaves it out, reporting the others", func() {
+					info, err := container.Info()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(info.Limits.Disk).Should(BeNil())
+					Ω(info.Limits.Memory).Should(Equal(containerInfo.Limits.Memory))
+				})
+			})
+
+			It("reports when the container was last used", func() {
+				before := time.Now().Add(-time.Second)
+
+				info, err := container.Info()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(info.LastActivityAt).Should(BeTemporally(">=", before))
+			})
+
+			It("reports no time to the expiry of containers without a grace time", func() {
+				info, err := container.Info()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(info.TimeToGraceExpiry).Should(BeZero())
+			})
+
+			Context("when the container has a grace time", func() {
+				BeforeEach(func() {
+					serverBackend.GraceTimeReturns(time.Minute)
+				})
+
+				It("reports how long was left of it", func() {
+					time.Sleep(100 * time.Millisecond)
+
+					info, err := container.Info()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(info.TimeToGraceExpiry).Should(BeNumerically("<=", time.Minute-100*time.Millisecond))
+					Ω(info.TimeToGraceExpiry).Should(BeNumerically(">", time.Minute-10*time.Second))
+				})
+			})
+
+			It("reports when the container was created, if the backend knows", func() {
+				createdAt := time.Unix(1234567890, 0)
+
+				fakeContainer.InfoReturns(api.ContainerInfo{CreatedAt: createdAt}, nil)
+
+				info, err := container.Info()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(info.CreatedAt.Equal(createdAt)).Should(BeTrue())
+			})
+
+			Context("when only some fields are asked for", func() {
+				fields := []api.InfoField{api.InfoFieldState, api.InfoFieldMappedPorts}
+
+				BeforeEach(func() {
+					fakeContainer.InfoFieldsReturns(containerInfo, nil)
+				})
+
+				It("asks the backend for only those fields", func() {
+					infos := fakeContainer.InfoCallCount()
+
+					_, err := container.InfoFields(fields)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(fakeContainer.InfoFieldsArgsForCall(0)).Should(Equal(fields))
+					Ω(fakeContainer.InfoCallCount()).Should(Equal(infos))
+				})
+
+				It("responds with only those fields", func() {
+					info, err := container.InfoFields(fields)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(info.State).Should(Equal("active"))
+					Ω(info.MappedPorts).Should(Equal(containerInfo.MappedPorts))
+
+					Ω(info.HostIP).Should(BeEmpty())
+					Ω(info.Properties).Should(BeEmpty())
+					Ω(info.MemoryStat).Should(BeZero())
+				})
+
+				It("does not ask the backend for the container's limits", func() {
+					_, err := container.InfoFields(fields)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(fakeContainer.CurrentMemoryLimitsCallCount()).Should(BeZero())
+				})
+
+				Context("when the limits are asked for", func() {
+					It("reports them", func() {
+						info, err := container.InfoFields([]api.InfoField{api.InfoFieldLimits})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(info.Limits).Should(Equal(containerInfo.Limits))
+					})
+				})
+
+				Context("when a field is unknown", func() {
+					It("returns an InvalidRequestError without asking the backend", func() {
+						_, err := container.InfoFields([]api.InfoField{api.InfoFieldState, "secrets"})
+						Ω(err).Should(Equal(api.InvalidRequestError{
+							Violations: []api.ValidationError{
+								{Field: "fields", Reason: "unknown field secrets"},
+							},
+						}))
+
+						Ω(fakeContainer.InfoFieldsCallCount()).Should(BeZero())
+					})
+				})
+
+				Context("when getting the fields fails", func() {
+					BeforeEach(func() {
+						fakeContainer.InfoFieldsReturns(api.ContainerInfo{}, errors.New("oh no!"))
+					})
+
+					It("fails", func() {
+						_, err := container.InfoFields(fields)
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+			})
+
 			itResetsGraceTimeWhenHandling(func() {
 				_, err := container.Info()
 				Ω(err).ShouldNot(HaveOccurred())
@@ -1287,6 +4423,24 @@ var _ = Describe("When a client connects", func() {
 					Ω(status).Should(Equal(123))
 				})
 
+				It("does not stream output the client has no writer for", func() {
+					stdout := gbytes.NewBuffer()
+
+					process, err := container.Attach(42, api.ProcessIO{
+						Stdin:  bytes.NewBufferString("stdin data"),
+						Stdout: stdout,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(stdout).Should(gbytes.Say("stdout data"))
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, attachedIO := fakeContainer.AttachArgsForCall(0)
+					Ω(attachedIO.Stderr).Should(Equal(ioutil.Discard))
+				})
+
 				itResetsGraceTimeWhenHandling(func() {
 					process, err := container.Attach(42, api.ProcessIO{
 						Stdin: bytes.NewBufferString("hello"),
@@ -1348,6 +4502,118 @@ var _ = Describe("When a client connects", func() {
 			})
 		})
 
+		Describe("attaching to all processes", func() {
+			Context("when attaching succeeds", func() {
+				BeforeEach(func() {
+					fakeContainer.AttachAllStub = func(processIO func(uint32) api.ProcessIO) ([]api.Process, error) {
+						processes := []api.Process{}
+
+						for _, id := range []uint32{42, 43} {
+							io := processIO(id)
+
+							writing := new(sync.WaitGroup)
+							writing.Add(1)
+
+							go func(id uint32) {
+								defer writing.Done()
+								defer GinkgoRecover()
+
+								_, err := fmt.Fprintf(io.Stdout, "stdout data %d", id)
+								Ω(err).ShouldNot(HaveOccurred())
+
+								in, err := ioutil.ReadAll(io.Stdin)
+								Ω(err).ShouldNot(HaveOccurred())
+
+								_, err = fmt.Fprintf(io.Stdout, "mirrored %s", string(in))
+								Ω(err).ShouldNot(HaveOccurred())
+
+								_, err = fmt.Fprintf(io.Stderr, "stderr data %d", id)
+								Ω(err).ShouldNot(HaveOccurred())
+							}(id)
+
+							process := new(fakes.FakeProcess)
+
+							process.IDReturns(id)
+
+							exitStatus := int(id) + 100
+							process.WaitStub = func() (int, error) {
+								writing.Wait()
+								return exitStatus, nil
+							}
+
+							processes = append(processes, process)
+						}
+
+						return processes, nil
+					}
+				})
+
+				It("multiplexes a ProcessPayload for every chunk of every process", func() {
+					stdouts := map[uint32]*gbytes.Buffer{}
+					stderrs := map[uint32]*gbytes.Buffer{}
+
+					processes, err := container.AttachAll(func(id uint32) api.ProcessIO {
+						stdouts[id] = gbytes.NewBuffer()
+						stderrs[id] = gbytes.NewBuffer()
+
+						return api.ProcessIO{
+							Stdin:  bytes.NewBufferString(fmt.Sprintf("stdin data %d", id)),
+							Stdout: stdouts[id],
+							Stderr: stderrs[id],
+						}
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(processes).Should(HaveLen(2))
+
+					for _, process := range processes {
+						id := process.ID()
+
+						Eventually(stdouts[id]).Should(gbytes.Say(fmt.Sprintf("stdout data %d", id)))
+						Eventually(stdouts[id]).Should(gbytes.Say(fmt.Sprintf("mirrored stdin data %d", id)))
+						Eventually(stderrs[id]).Should(gbytes.Say(fmt.Sprintf("stderr data %d", id)))
+
+						status, err := process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(status).Should(Equal(int(id) + 100))
+					}
+				})
+
+				itResetsGraceTimeWhenHandling(func() {
+					processes, err := container.AttachAll(func(uint32) api.ProcessIO {
+						return api.ProcessIO{
+							Stdin: bytes.NewBufferString("hello"),
+						}
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					for _, process := range processes {
+						_, err := process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+					}
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.AttachAll(func(uint32) api.ProcessIO {
+					return api.ProcessIO{}
+				})
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when attaching fails", func() {
+				BeforeEach(func() {
+					fakeContainer.AttachAllReturns(nil, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.AttachAll(func(uint32) api.ProcessIO {
+						return api.ProcessIO{}
+					})
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
 		Describe("running", func() {
 			processSpec := api.ProcessSpec{
 				Path: "/some/script",
@@ -1383,6 +4649,99 @@ var _ = Describe("When a client connects", func() {
 				},
 			}
 
+			Context("when the container has process defaults", func() {
+				BeforeEach(func() {
+					containerSpec.DefaultProcessUser = "default-user"
+					containerSpec.Env = []string{"FLAVOR=vanilla", "CONE=waffle"}
+
+					fakeContainer.RunReturns(new(fakes.FakeProcess), nil)
+				})
+
+				It("runs the process with environment values intact, however they are written", func() {
+					spec := processSpec
+					spec.Env = []string{"EMPTY=", "EQUATION=a=b+c", "LINES=one\ntwo\n"}
+
+					process, err := container.Run(spec, api.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					ranSpec, _ := fakeContainer.RunArgsForCall(0)
+					Ω(ranSpec.Env).Should(Equal([]string{
+						"FLAVOR=vanilla",
+						"CONE=waffle",
+						"EMPTY=",
+						"EQUATION=a=b+c",
+						"LINES=one\ntwo\n",
+					}))
+				})
+
+				It("fails without asking the server when an environment variable has no key", func() {
+					spec := processSpec
+					spec.Env = []string{"=chocolate"}
+
+					_, err := container.Run(spec, api.ProcessIO{})
+					Ω(err).Should(Equal(api.ValidationError{
+						Field:  api.SpecFieldEnv,
+						Reason: "must not have empty keys",
+					}))
+
+					Ω(fakeContainer.RunCallCount()).Should(BeZero())
+				})
+
+				It("runs the process as the default user, beneath the container's environment", func() {
+					process, err := container.Run(processSpec, api.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					ranSpec, _ := fakeContainer.RunArgsForCall(0)
+					Ω(ranSpec.User).Should(Equal("default-user"))
+					Ω(ranSpec.Env).Should(Equal([]string{
+						"CONE=waffle",
+						"FLAVOR=chocolate",
+						"TOPPINGS=sprinkles",
+					}))
+				})
+
+				Context("when the process names a user", func() {
+					It("runs the process as that user", func() {
+						spec := processSpec
+						spec.User = "some-user"
+
+						process, err := container.Run(spec, api.ProcessIO{})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						_, err = process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						ranSpec, _ := fakeContainer.RunArgsForCall(0)
+						Ω(ranSpec.User).Should(Equal("some-user"))
+					})
+				})
+
+				Context("when the process names a uid and gid", func() {
+					It("runs the process as them, rather than the default user", func() {
+						spec := processSpec
+						spec.UID = uint32ptr(1000)
+						spec.GID = uint32ptr(2000)
+
+						process, err := container.Run(spec, api.ProcessIO{})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						_, err = process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						ranSpec, _ := fakeContainer.RunArgsForCall(0)
+						Ω(ranSpec.User).Should(BeEmpty())
+						Ω(ranSpec.UID).Should(Equal(uint32ptr(1000)))
+						Ω(ranSpec.GID).Should(Equal(uint32ptr(2000)))
+					})
+				})
+			})
+
 			Context("when running succeeds", func() {
 				BeforeEach(func() {
 					fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
@@ -1449,6 +4808,67 @@ var _ = Describe("When a client connects", func() {
 					close(done)
 				})
 
+				It("has the process read and write the files the client passes directly", func() {
+					stdinR, stdinW, err := os.Pipe()
+					Ω(err).ShouldNot(HaveOccurred())
+					defer stdinR.Close()
+
+					stdoutR, stdoutW, err := os.Pipe()
+					Ω(err).ShouldNot(HaveOccurred())
+					defer stdoutR.Close()
+
+					stdout := gbytes.NewBuffer()
+					go io.Copy(stdout, stdoutR)
+
+					stderr := gbytes.NewBuffer()
+
+					process, err := container.Run(processSpec, api.ProcessIO{
+						Stdin:     stdinR,
+						Stdout:    stdoutW,
+						Stderr:    stderr,
+						PassFiles: true,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					// the server has copies of its own
+					stdoutW.Close()
+
+					_, err = stdinW.Write([]byte("stdin data"))
+					Ω(err).ShouldNot(HaveOccurred())
+					stdinW.Close()
+
+					Eventually(stdout).Should(gbytes.Say("stdout data"))
+					Eventually(stdout).Should(gbytes.Say("mirrored stdin data"))
+					Eventually(stderr).Should(gbytes.Say("stderr data"))
+
+					status, err := process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(status).Should(Equal(123))
+
+					_, ranIO := fakeContainer.RunArgsForCall(0)
+					Ω(ranIO.Stdin).Should(BeAssignableToTypeOf(&os.File{}))
+					Ω(ranIO.Stdout).Should(BeAssignableToTypeOf(&os.File{}))
+					Ω(ranIO.Stderr).ShouldNot(BeAssignableToTypeOf(&os.File{}))
+				})
+
+				It("does not stream output the client has no writer for", func() {
+					stderr := gbytes.NewBuffer()
+
+					process, err := container.Run(processSpec, api.ProcessIO{
+						Stdin:  bytes.NewBufferString("stdin data"),
+						Stderr: stderr,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(stderr).Should(gbytes.Say("stderr data"))
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, ranIO := fakeContainer.RunArgsForCall(0)
+					Ω(ranIO.Stdout).Should(Equal(ioutil.Discard))
+				})
+
 				itResetsGraceTimeWhenHandling(func() {
 					process, err := container.Run(processSpec, api.ProcessIO{
 						Stdin: bytes.NewBufferString("hello"),
@@ -1461,6 +4881,137 @@ var _ = Describe("When a client connects", func() {
 				})
 			})
 
+			Context("when the process writes binary output", func() {
+				binaryData := string([]byte{0xff, 0x00, 0xfe, 0x80})
+
+				BeforeEach(func() {
+					fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+						writing := new(sync.WaitGroup)
+						writing.Add(1)
+
+						go func() {
+							defer writing.Done()
+							fmt.Fprint(io.Stdout, binaryData)
+						}()
+
+						process := new(fakes.FakeProcess)
+						process.IDReturns(42)
+						process.WaitStub = func() (int, error) {
+							writing.Wait()
+							return 0, nil
+						}
+
+						return process, nil
+					}
+				})
+
+				It("streams it to the client intact", func() {
+					stdout := gbytes.NewBuffer()
+
+					process, err := container.Run(processSpec, api.ProcessIO{
+						Stdout: stdout,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(stdout.Contents()).Should(Equal([]byte(binaryData)))
+				})
+
+				Context("when the client does not ask for binary framing", func() {
+					It("streams JSON payloads", func() {
+						conn, err := net.Dial("unix", socketPath)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						request, err := http.NewRequest(
+							"POST",
+							"http://api/containers/some-handle/processes",
+							bytes.NewBufferString(`{"handle":"some-handle","path":"/some/script"}`),
+						)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						request.Header.Set("Content-Type", "application/json")
+
+						err = request.Write(conn)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						br := bufio.NewReader(conn)
+
+						response, err := http.ReadResponse(br, request)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(response.Header.Get(transport.ProcessStreamFramingHeader)).Should(BeEmpty())
+
+						decoder := json.NewDecoder(br)
+
+						var payload apitypes.ProcessPayload
+						err = decoder.Decode(&payload)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(payload.GetProcessId()).Should(Equal(uint32(42)))
+						Ω(payload.GetStdinWindow()).Should(Equal(uint32(server.StdinWindow)))
+
+						err = decoder.Decode(&payload)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(payload.GetSource()).Should(Equal(apitypes.ProcessPayload_stdout))
+					})
+				})
+			})
+
+			Context("when the process does not consume its stdin", func() {
+				var processStdin chan io.Reader
+
+				BeforeEach(func() {
+					processStdin = make(chan io.Reader, 1)
+
+					fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+						processStdin <- io.Stdin
+
+						process := &fakes.FakeProcess{
+							WaitStub: func() (int, error) {
+								select {}
+								return 0, nil
+							},
+						}
+						process.IDReturns(42)
+
+						return process, nil
+					}
+				})
+
+				It("stops the client from sending more than the stdin window", func() {
+					pipeR, pipeW := io.Pipe()
+
+					_, err := container.Run(processSpec, api.ProcessIO{Stdin: pipeR})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					chunks := server.StdinWindow * 4
+
+					written := make(chan struct{}, chunks)
+					go func() {
+						for i := 0; i < chunks; i++ {
+							pipeW.Write([]byte("x"))
+							written <- struct{}{}
+						}
+					}()
+
+					Eventually(func() int { return len(written) }).Should(BeNumerically(">=", server.StdinWindow))
+					Consistently(func() int { return len(written) }).Should(BeNumerically("<=", server.StdinWindow+2))
+
+					var stdin io.Reader
+					Eventually(processStdin).Should(Receive(&stdin))
+
+					_, err = io.ReadFull(stdin, make([]byte, chunks))
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(func() int { return len(written) }).Should(Equal(chunks))
+				})
+			})
+
 			Describe("when the server is shut down while there is a process running", func() {
 				BeforeEach(func() {
 					process := &fakes.FakeProcess{
@@ -1529,6 +5080,122 @@ var _ = Describe("When a client connects", func() {
 				})
 			})
 
+			Context("when the process's resource limits are changed", func() {
+				var fakeProcess *fakes.FakeProcess
+
+				BeforeEach(func() {
+					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.IDReturns(42)
+					fakeProcess.WaitStub = func() (int, error) {
+						select {}
+						return 0, nil
+					}
+
+					fakeContainer.RunReturns(fakeProcess, nil)
+				})
+
+				It("is eventually set in the backend", func() {
+					process, err := container.Run(processSpec, api.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					nofile := uint64(4096)
+
+					err = process.SetRlimits(api.ResourceLimits{Nofile: &nofile})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(fakeProcess.SetRlimitsCallCount).Should(Equal(1))
+
+					Ω(fakeProcess.SetRlimitsArgsForCall(0)).Should(Equal(api.ResourceLimits{Nofile: &nofile}))
+				})
+
+				Context("when the backend cannot change them", func() {
+					BeforeEach(func() {
+						fakeProcess.SetRlimitsReturns(errors.New("not supported"))
+					})
+
+					It("keeps streaming to the process", func() {
+						stdin := gbytes.NewBuffer()
+						fakeContainer.RunStub = func(spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+							go io.Copy(stdin, processIO.Stdin)
+							return fakeProcess, nil
+						}
+
+						stdinR, stdinW := io.Pipe()
+
+						process, err := container.Run(processSpec, api.ProcessIO{Stdin: stdinR})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						err = process.SetRlimits(api.ResourceLimits{})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Eventually(fakeProcess.SetRlimitsCallCount).Should(Equal(1))
+
+						stdinW.Write([]byte("still here"))
+
+						Eventually(stdin).Should(gbytes.Say("still here"))
+					})
+				})
+			})
+
+			Context("when the process is given a TTY", func() {
+				var fakeProcess *fakes.FakeProcess
+
+				BeforeEach(func() {
+					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.IDReturns(42)
+					fakeProcess.WaitStub = func() (int, error) {
+						select {}
+						return 0, nil
+					}
+
+					fakeContainer.RunReturns(fakeProcess, nil)
+				})
+
+				It("allocates it in the backend before returning", func() {
+					process, err := container.Run(processSpec, api.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					ttySpec := api.TTYSpec{
+						WindowSize: &api.WindowSize{
+							Columns: 80,
+							Rows:    24,
+						},
+					}
+
+					err = process.AllocateTTY(ttySpec)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(fakeProcess.AllocateTTYCallCount()).Should(Equal(1))
+					Ω(fakeProcess.AllocateTTYArgsForCall(0)).Should(Equal(ttySpec))
+				})
+
+				Context("when the backend cannot allocate one", func() {
+					BeforeEach(func() {
+						fakeProcess.AllocateTTYReturns(api.ErrUnsupportedOperation)
+					})
+
+					It("returns ErrUnsupportedOperation, and keeps streaming to the process", func() {
+						stdin := gbytes.NewBuffer()
+						fakeContainer.RunStub = func(spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+							go io.Copy(stdin, processIO.Stdin)
+							return fakeProcess, nil
+						}
+
+						stdinR, stdinW := io.Pipe()
+
+						process, err := container.Run(processSpec, api.ProcessIO{Stdin: stdinR})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						err = process.AllocateTTY(api.TTYSpec{})
+						Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+						stdinW.Write([]byte("still here"))
+
+						Eventually(stdin).Should(gbytes.Say("still here"))
+					})
+				})
+			})
+
 			Context("when waiting on the process fails server-side", func() {
 				BeforeEach(func() {
 					fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
@@ -1556,6 +5223,46 @@ var _ = Describe("When a client connects", func() {
 				Ω(err).Should(HaveOccurred())
 			})
 
+			Context("when the process spec is invalid", func() {
+				itRejects := func(field api.SpecField, mutate func(*api.ProcessSpec)) {
+					It("returns a ValidationError for the field without running the process", func() {
+						spec := processSpec
+						mutate(&spec)
+
+						_, err := container.Run(spec, api.ProcessIO{})
+						Ω(err).Should(BeAssignableToTypeOf(api.ValidationError{}))
+						Ω(err.(api.ValidationError).Field).Should(Equal(field))
+
+						Ω(fakeContainer.RunCallCount()).Should(BeZero())
+					})
+				}
+
+				Context("because the path is empty", func() {
+					itRejects(api.SpecFieldPath, func(spec *api.ProcessSpec) {
+						spec.Path = ""
+					})
+				})
+
+				Context("because the dir is not clean", func() {
+					itRejects(api.SpecFieldDir, func(spec *api.ProcessSpec) {
+						spec.Dir = "/some/../dir/"
+					})
+				})
+
+				Context("because the user is malformed", func() {
+					itRejects(api.SpecFieldUser, func(spec *api.ProcessSpec) {
+						spec.User = "bad user:name"
+					})
+				})
+
+				Context("because both a user and a uid are given", func() {
+					itRejects(api.SpecFieldUID, func(spec *api.ProcessSpec) {
+						spec.User = "some-user"
+						spec.UID = uint32ptr(1000)
+					})
+				})
+			})
+
 			Context("when running fails", func() {
 				BeforeEach(func() {
 					fakeContainer.RunReturns(nil, errors.New("oh no!"))
@@ -1592,3 +5299,38 @@ func (checker *closeChecker) Closed() bool {
 	defer checker.Unlock()
 	return checker.closed
 }
+
+// closingReader records being closed with its closeChecker.
+type closingReader struct {
+	io.Reader
+
+	closer *closeChecker
+}
+
+func (r closingReader) Close() error {
+	return r.closer.Close()
+}
+
+type relabelledCodec struct {
+	transport.Codec
+
+	contentType string
+}
+
+func (c relabelledCodec) ContentType() string {
+	return c.contentType
+}
+
+// uncomparableError cannot be compared with ==, nor used as a map key
+type uncomparableError []string
+
+func (e uncomparableError) Error() string {
+	return strings.Join(e, " ")
+}
+
+// capableBackend is a backend with every optional capability.
+type capableBackend struct {
+	*fakes.FakeBackend
+	*fakes.FakeRenamer
+	*fakes.FakeSnapshotter
+}
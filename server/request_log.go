@@ -0,0 +1,138 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// LogFields returns fields to log with every line about a request, e.g. the
+// tenant its credential belongs to.
+type LogFields func(*http.Request) lager.Data
+
+// SetLogFields registers a hook adding fields to those logged with every
+// line about a request. Fields named like the server's own, e.g. "route" or
+// "handle", are ignored. It must be called before Start.
+func (s *GardenServer) SetLogFields(fields LogFields) {
+	s.logFields = fields
+}
+
+// requestLogs keeps the fields logged with every line about each request
+// being handled.
+type requestLogs struct {
+	requests map[*http.Request]lager.Data
+	mu       sync.Mutex
+}
+
+func newRequestLogs() *requestLogs {
+	return &requestLogs{
+		requests: map[*http.Request]lager.Data{},
+	}
+}
+
+func (l *requestLogs) set(r *http.Request, data lager.Data) {
+	l.mu.Lock()
+	l.requests[r] = data
+	l.mu.Unlock()
+}
+
+func (l *requestLogs) get(r *http.Request) (lager.Data, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, found := l.requests[r]
+	return data, found
+}
+
+func (l *requestLogs) forget(r *http.Request) {
+	l.mu.Lock()
+	delete(l.requests, r)
+	l.mu.Unlock()
+}
+
+// logged tags the request with the fields logged with every line about it, so
+// that the lines logged while handling one request can be told apart from
+// those of others:
+//
+//   - route: the request's route, e.g. routes.Create
+//   - request: an ID the server gives the request
+//   - remote_addr: the address of the client which made it
+//   - requester: the client, as the audit sink's requester id hook
+//     identifies it, if the server has one
+//   - handle: the container's, for requests made of an existing container
+//   - trace: the ID of the trace the request is part of, if it gave one
+func (s *GardenServer) logged(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := lager.Data{
+			"route":       route,
+			"request":     newRequestID(),
+			"remote_addr": r.RemoteAddr,
+		}
+
+		// rata passes the route's parameters in the query
+		if handle := r.URL.Query().Get(":handle"); handle != "" {
+			data["handle"] = handle
+		}
+
+		if id := traceID(r); id != "" {
+			data["trace"] = id
+		}
+
+		if s.auditRequesterID != nil {
+			if requester := s.auditRequesterID(r); requester != "" {
+				data["requester"] = requester
+			}
+		}
+
+		if s.logFields != nil {
+			for k, v := range s.logFields(r) {
+				if _, taken := data[k]; !taken {
+					data[k] = v
+				}
+			}
+		}
+
+		s.requestLogs.set(r, data)
+		defer s.requestLogs.forget(r)
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// session starts the logger's session for handling the request, logging the
+// fields it was tagged with, or just its trace ID for requests which were
+// not routed, with each of its lines. The data given is logged too.
+func (s *GardenServer) session(r *http.Request, task string, data ...lager.Data) lager.Logger {
+	sessionData := lager.Data{}
+
+	if requestData, ok := s.requestLogs.get(r); ok {
+		for k, v := range requestData {
+			sessionData[k] = v
+		}
+	} else if id := traceID(r); id != "" {
+		sessionData["trace"] = id
+	}
+
+	for _, d := range data {
+		for k, v := range d {
+			sessionData[k] = v
+		}
+	}
+
+	return s.logger.Session(task, sessionData)
+}
+
+// newRequestID returns 16 random hex digits, or "" if none could be read.
+func newRequestID() string {
+	id := make([]byte, 8)
+
+	_, err := rand.Read(id)
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+const maxPort = 65535
+
+type handleRequest interface {
+	GetHandle() string
+}
+
+// requestViolations checks a decoded request against the constraints that its
+// field types cannot express, returning every field that breaks them.
+func requestViolations(request interface{}, handle string) []api.ValidationError {
+	var violations []api.ValidationError
+
+	// the handle in the URL is the one acted on; refuse a body naming another
+	if req, ok := request.(handleRequest); ok && handle != "" {
+		if req.GetHandle() != "" && req.GetHandle() != handle {
+			violations = append(violations, api.ValidationError{
+				Field:  "handle",
+				Reason: "must match the handle in the URL",
+			})
+		}
+	}
+
+	switch req := request.(type) {
+	case *apitypes.NetInRequest:
+		violations = append(violations, portViolations("host_port", req.GetHostPort())...)
+		violations = append(violations, portViolations("container_port", req.GetContainerPort())...)
+
+		if req.HostIp != nil {
+			violations = append(violations, ipViolations("host_ip", req.GetHostIp())...)
+		}
+
+		if _, known := apitypes.NetInRequest_Protocol_name[int32(req.GetProtocol())]; !known {
+			violations = append(violations, api.ValidationError{
+				Field:  "protocol",
+				Reason: "must be TCP or UDP",
+			})
+		}
+
+	case *apitypes.CreateRequest:
+		if req.ExternalIp != nil {
+			violations = append(violations, ipViolations("external_ip", req.GetExternalIp())...)
+		}
+
+		if req.ExternalInterface != nil && !validInterfaceName(req.GetExternalInterface()) {
+			violations = append(violations, api.ValidationError{
+				Field:  "external_interface",
+				Reason: "must be a network interface's name",
+			})
+		}
+
+		for _, mount := range req.GetBindMounts() {
+			violations = append(violations, bindMountViolations(mount)...)
+		}
+
+		violations = append(violations, envViolations(req.GetEnv())...)
+
+	case *apitypes.NetOutRequest:
+		violations = append(violations, portViolations("port", req.GetPort())...)
+
+	case *apitypes.RenameRequest:
+		if req.GetNewHandle() == "" {
+			violations = append(violations, api.ValidationError{
+				Field:  "new_handle",
+				Reason: "must not be empty",
+			})
+		}
+
+	case *apitypes.NetOutRuleRequest:
+		for _, network := range req.GetNetworks() {
+			violations = append(violations, ipViolations("networks.start", network.GetStart())...)
+			violations = append(violations, ipViolations("networks.end", network.GetEnd())...)
+		}
+
+		for _, ports := range req.GetPorts() {
+			violations = append(violations, portViolations("ports.start", ports.GetStart())...)
+			violations = append(violations, portViolations("ports.end", ports.GetEnd())...)
+
+			if ports.GetStart() > ports.GetEnd() {
+				violations = append(violations, api.ValidationError{
+					Field:  "ports",
+					Reason: "start must not be after end",
+				})
+			}
+		}
+
+	case *apitypes.RunRequest:
+		passed := map[apitypes.ProcessPayload_Source]bool{}
+		for _, source := range req.GetPassedFiles() {
+			if _, known := apitypes.ProcessPayload_Source_name[int32(source)]; !known || passed[source] {
+				violations = append(violations, api.ValidationError{
+					Field:  "passed_files",
+					Reason: "must each be a distinct stdin, stdout or stderr",
+				})
+				break
+			}
+
+			passed[source] = true
+		}
+
+		violations = append(violations, envViolations(req.GetEnv())...)
+	}
+
+	return violations
+}
+
+// a port of zero is left for the backend to choose
+func portViolations(field api.SpecField, port uint32) []api.ValidationError {
+	if port > maxPort {
+		return []api.ValidationError{{
+			Field:  field,
+			Reason: "must be between 1 and 65535",
+		}}
+	}
+
+	return nil
+}
+
+func ipViolations(field api.SpecField, ip string) []api.ValidationError {
+	if parseIP(ip) == nil {
+		return []api.ValidationError{{
+			Field:  field,
+			Reason: "must be an IP address",
+		}}
+	}
+
+	return nil
+}
+
+// envViolations checks that environment variables have keys which, joined
+// to their values with =, split back into the same pair, and that neither
+// contains NUL bytes, which processes' environments cannot. Values may be
+// empty, and may contain = or newlines. Each kind of violation is reported
+// once, however many variables have it.
+func envViolations(env []*apitypes.EnvironmentVariable) []api.ValidationError {
+	var violations []api.ValidationError
+	var emptyKey, eqKey, nul bool
+
+	for _, variable := range env {
+		emptyKey = emptyKey || variable.GetKey() == ""
+		eqKey = eqKey || strings.Contains(variable.GetKey(), "=")
+		nul = nul || strings.ContainsRune(variable.GetKey()+variable.GetValue(), 0)
+	}
+
+	if emptyKey {
+		violations = append(violations, api.ValidationError{
+			Field:  api.SpecFieldEnv,
+			Reason: "must not have empty keys",
+		})
+	}
+
+	if eqKey {
+		violations = append(violations, api.ValidationError{
+			Field:  api.SpecFieldEnv,
+			Reason: "must not have keys containing =",
+		})
+	}
+
+	if nul {
+		violations = append(violations, api.ValidationError{
+			Field:  api.SpecFieldEnv,
+			Reason: "must not contain NUL bytes",
+		})
+	}
+
+	return violations
+}
+
+// bindMountViolations checks a bind mount's paths and mode, which backends
+// would otherwise fail on with errors of their own, or worse, follow out of
+// the directories they were meant to mount.
+func bindMountViolations(mount *apitypes.CreateRequest_BindMount) []api.ValidationError {
+	var violations []api.ValidationError
+
+	violations = append(violations, mountPathViolations(api.SpecFieldBindMountSrcPath, mount.GetSrcPath())...)
+	violations = append(violations, mountPathViolations(api.SpecFieldBindMountDstPath, mount.GetDstPath())...)
+
+	// an omitted mode is RO
+	if _, known := apitypes.CreateRequest_BindMount_Mode_name[int32(mount.GetMode())]; !known {
+		violations = append(violations, api.ValidationError{
+			Field:  api.SpecFieldBindMountMode,
+			Reason: "must be RO or RW",
+		})
+	}
+
+	if _, known := apitypes.CreateRequest_BindMount_Origin_name[int32(mount.GetOrigin())]; !known {
+		violations = append(violations, api.ValidationError{
+			Field:  api.SpecFieldBindMountOrigin,
+			Reason: "must be Host or Container",
+		})
+	}
+
+	return violations
+}
+
+func mountPathViolations(field api.SpecField, mountPath string) []api.ValidationError {
+	if !path.IsAbs(mountPath) {
+		return []api.ValidationError{{
+			Field:  field,
+			Reason: "must be an absolute path",
+		}}
+	}
+
+	for _, element := range strings.Split(mountPath, "/") {
+		if element == ".." {
+			return []api.ValidationError{{
+				Field:  field,
+				Reason: "must not contain ..",
+			}}
+		}
+	}
+
+	return nil
+}
+
+// maxInterfaceNameLength is the longest name Linux gives network interfaces.
+const maxInterfaceNameLength = 15
+
+func validInterfaceName(name string) bool {
+	if name == "" || name == "." || name == ".." || len(name) > maxInterfaceNameLength {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		if name[i] <= ' ' || name[i] > '~' || name[i] == '/' || name[i] == ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// typeViolation describes a field whose JSON value could not be decoded into
+// its type, e.g. a negative limit.
+func typeViolation(err *json.UnmarshalTypeError) api.ValidationError {
+	reason := "must be a " + err.Type.String()
+
+	switch err.Type.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		reason = "must be a non-negative integer"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		reason = "must be an integer"
+	}
+
+	return api.ValidationError{
+		Field:  api.SpecField(err.Field),
+		Reason: reason,
+	}
+}
+
+func validationResponse(err api.ValidationError) *apitypes.ErrorResponse_Validation {
+	return &apitypes.ErrorResponse_Validation{
+		Field:  apitypes.String(string(err.Field)),
+		Reason: apitypes.String(err.Reason),
+	}
+}
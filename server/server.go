@@ -11,11 +11,15 @@ import (
 	"github.com/cloudfoundry-incubator/garden/api"
 	"github.com/cloudfoundry-incubator/garden/routes"
 	"github.com/cloudfoundry-incubator/garden/server/bomberman"
-	"github.com/gogo/protobuf/proto"
+	"github.com/cloudfoundry-incubator/garden/transport"
 	"github.com/pivotal-golang/lager"
 	"github.com/tedsuo/rata"
 )
 
+// proxyHeaderTimeout bounds how long a connection may take to send its PROXY
+// protocol header, when the server expects one.
+const proxyHeaderTimeout = 5 * time.Second
+
 type GardenServer struct {
 	logger lager.Logger
 
@@ -26,35 +30,152 @@ type GardenServer struct {
 	containerGraceTime time.Duration
 	backend            api.Backend
 
-	listener net.Listener
-	handling *sync.WaitGroup
+	// defaultsL guards the defaults which may be changed while the server
+	// runs, with SetDefaults: containerGraceTime and rateLimiter
+	defaultsL sync.RWMutex
+
+	maxGraceTime    time.Duration
+	graceTimePolicy GraceTimePolicy
+
+	errorAnnotationKeys []string
+
+	authenticator      Authenticator
+	adminAuthenticator Authenticator
+	userChecker        UserChecker
+	privilegePolicy    PrivilegePolicy
+	metricsReporter    MetricsReporter
+	rateLimiter        *rateLimiter
+
+	auditSink        AuditSink
+	auditRequesterID func(*http.Request) string
+
+	logFields   LogFields
+	requestLogs *requestLogs
+
+	writeTimeout time.Duration
+
+	reconnectKey      []byte
+	reconnectTTL      time.Duration
+	reconnectInstance string
+
+	// stateL guards all of the state the server keeps by container handle,
+	// so that renaming or forgetting a container moves or drops all of it at
+	// once
+	stateL          *sync.Mutex
+	stdinAccounting *stdinAccounting
+	streamInLimits  StreamInLimits
+	processLimit    *processLimit
+	processLabels   *processLabels
+	processWaits    *processWaits
+	processLogs     *processLogs
+	processDefaults *processDefaults
+	handles         *handles
+
+	containerHooks ContainerHooks
+	containerSpecs *containerSpecs
+
+	// defaultProcessEnv is the environment beneath every container's own
+	defaultProcessEnv []string
+
+	strictRequests bool
+	serverTiming   bool
+	proxyProtocol  bool
+
+	// codec encodes every response other than process streams
+	codec transport.Codec
+
+	// confirmDestroys requires destroys to name their container in a
+	// transport.DestroyConfirmationHeader
+	confirmDestroys bool
+
+	enforceCapacity bool
+	creating        int
+	creatingL       *sync.Mutex
+
+	capacityCache *capacityCache
+
+	workerPools map[WorkerPool]*workerPool
+
+	listener  net.Listener
+	accepting *handOffListener
+	handling  *sync.WaitGroup
 
-	started  bool
-	stopping chan bool
+	// streaming counts the process streams being served, which outlive their
+	// requests' connections being counted as handled
+	streaming *sync.WaitGroup
+
+	started   bool
+	startedAt time.Time
+	stopping  chan bool
+
+	// detaching is closed to end process streams without waiting for their
+	// processes to exit
+	detaching chan struct{}
 
 	bomberman *bomberman.Bomberman
 
-	conns map[net.Conn]net.Conn
-	mu    sync.Mutex
+	conns       map[net.Conn]net.Conn
+	muxSessions map[*transport.MuxSession]struct{}
+	mu          sync.Mutex
+
+	// fileConns are the unix socket connections being served, by their
+	// remote addresses, for handlers to take the files passed over them
+	fileConns map[string]*transport.FileConn
+
+	// openConns and hijackedConns count the connections being served, and
+	// those taken over by handlers, e.g. for process streams; s.mu guards
+	// them
+	openConns     int
+	hijackedConns int
+
+	// handedOff is set once the server has handed off to another, which
+	// reaps idle containers from then on
+	handedOff bool
 
-	destroys  map[string]struct{}
-	destroysL *sync.Mutex
+	destroyOperations *destroyOperations
 }
 
 type UnhandledRequestError struct {
-	Request proto.Message
+	Request interface{}
 }
 
 func (e UnhandledRequestError) Error() string {
 	return fmt.Sprintf("unhandled request type: %T", e.Request)
 }
 
+// Authenticator validates a request before it is dispatched to its handler.
+// A non-nil error rejects the request with 401 Unauthorized.
+type Authenticator interface {
+	Authenticate(*http.Request) error
+}
+
+type AuthenticatorFunc func(*http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(request *http.Request) error {
+	return f(request)
+}
+
+// UserChecker checks that the user a process is to run as exists in its
+// container, before the process is run. It should return api.ErrUserNotFound
+// if not, which fails the Run request before its stream is hijacked.
+type UserChecker interface {
+	CheckUser(container api.Container, spec api.ProcessSpec) error
+}
+
+type UserCheckerFunc func(api.Container, api.ProcessSpec) error
+
+func (f UserCheckerFunc) CheckUser(container api.Container, spec api.ProcessSpec) error {
+	return f(container, spec)
+}
+
 func New(
 	listenNetwork, listenAddr string,
 	containerGraceTime time.Duration,
 	backend api.Backend,
 	logger lager.Logger,
 ) *GardenServer {
+	stateL := new(sync.Mutex)
+
 	s := &GardenServer{
 		logger: logger.Session("garden-server"),
 
@@ -64,40 +185,113 @@ func New(
 		containerGraceTime: containerGraceTime,
 		backend:            backend,
 
+		codec: transport.JSON,
+
+		stateL:          stateL,
+		stdinAccounting: newStdinAccounting(stateL),
+		processLimit:    newProcessLimit(stateL),
+		processLabels:   newProcessLabels(stateL),
+		processWaits:    newProcessWaits(stateL),
+		processLogs:     newProcessLogs(stateL),
+		processDefaults: newProcessDefaults(stateL),
+		handles:         newHandles(),
+
+		containerSpecs: newContainerSpecs(stateL),
+
 		stopping: make(chan bool),
 
+		detaching: make(chan struct{}),
+		streaming: new(sync.WaitGroup),
+
 		handling: new(sync.WaitGroup),
 		conns:    make(map[net.Conn]net.Conn),
 
-		destroys:  make(map[string]struct{}),
-		destroysL: new(sync.Mutex),
+		fileConns: make(map[string]*transport.FileConn),
+
+		muxSessions: make(map[*transport.MuxSession]struct{}),
+
+		destroyOperations: newDestroyOperations(),
+
+		creatingL: new(sync.Mutex),
+
+		capacityCache: newCapacityCache(backend),
+
+		requestLogs: newRequestLogs(),
+
+		workerPools: map[WorkerPool]*workerPool{
+			ControlPool:   newWorkerPool(0),
+			StreamingPool: newWorkerPool(0),
+		},
 	}
 
 	handlers := map[string]http.Handler{
 		routes.Ping:                   http.HandlerFunc(s.handlePing),
 		routes.Capacity:               http.HandlerFunc(s.handleCapacity),
+		routes.Version:                http.HandlerFunc(s.handleVersion),
+		routes.HealthCheck:            http.HandlerFunc(s.handleHealthCheck),
+		routes.GetDefaults:            http.HandlerFunc(s.handleGetDefaults),
+		routes.SetDefaults:            http.HandlerFunc(s.handleSetDefaults),
+		routes.GetDiagnostics:         http.HandlerFunc(s.handleGetDiagnostics),
+		routes.Multiplex:              http.HandlerFunc(s.handleMultiplex),
 		routes.Create:                 http.HandlerFunc(s.handleCreate),
 		routes.Destroy:                http.HandlerFunc(s.handleDestroy),
+		routes.DestroyOperation:       http.HandlerFunc(s.handleDestroyOperation),
+		routes.Rename:                 http.HandlerFunc(s.handleRename),
 		routes.List:                   http.HandlerFunc(s.handleList),
+		routes.LookupBy:               http.HandlerFunc(s.handleLookupBy),
 		routes.Stop:                   http.HandlerFunc(s.handleStop),
+		routes.SignalAll:              http.HandlerFunc(s.handleSignalAll),
+		routes.Pause:                  http.HandlerFunc(s.handlePause),
+		routes.Resume:                 http.HandlerFunc(s.handleResume),
+		routes.ScheduleDestroy:        http.HandlerFunc(s.handleScheduleDestroy),
+		routes.CancelScheduledDestroy: http.HandlerFunc(s.handleCancelScheduledDestroy),
+		routes.SetHold:                http.HandlerFunc(s.handleSetHold),
+		routes.SetGraceTime:           http.HandlerFunc(s.handleSetGraceTime),
+		routes.Snapshot:               http.HandlerFunc(s.handleSnapshot),
+		routes.Restore:                http.HandlerFunc(s.handleRestore),
 		routes.StreamIn:               http.HandlerFunc(s.handleStreamIn),
+		routes.StreamInAtomically:     http.HandlerFunc(s.handleStreamInAtomically),
 		routes.StreamOut:              http.HandlerFunc(s.handleStreamOut),
+		routes.VerifyStreamIn:         http.HandlerFunc(s.handleVerifyStreamIn),
 		routes.LimitBandwidth:         http.HandlerFunc(s.handleLimitBandwidth),
 		routes.CurrentBandwidthLimits: http.HandlerFunc(s.handleCurrentBandwidthLimits),
 		routes.LimitCPU:               http.HandlerFunc(s.handleLimitCPU),
 		routes.CurrentCPULimits:       http.HandlerFunc(s.handleCurrentCPULimits),
 		routes.LimitDisk:              http.HandlerFunc(s.handleLimitDisk),
 		routes.CurrentDiskLimits:      http.HandlerFunc(s.handleCurrentDiskLimits),
+		routes.DiskUsage:              http.HandlerFunc(s.handleDiskUsage),
 		routes.LimitMemory:            http.HandlerFunc(s.handleLimitMemory),
 		routes.CurrentMemoryLimits:    http.HandlerFunc(s.handleCurrentMemoryLimits),
 		routes.NetIn:                  http.HandlerFunc(s.handleNetIn),
+		routes.MappedPorts:            http.HandlerFunc(s.handleMappedPorts),
 		routes.NetOut:                 http.HandlerFunc(s.handleNetOut),
+		routes.NetOutRule:             http.HandlerFunc(s.handleNetOutRule),
 		routes.Info:                   http.HandlerFunc(s.handleInfo),
+		routes.ProcessInfo:            http.HandlerFunc(s.handleProcessInfo),
+		routes.FindProcesses:          http.HandlerFunc(s.handleFindProcesses),
+		routes.WaitForProcess:         http.HandlerFunc(s.handleWaitForProcess),
+		routes.Logs:                   http.HandlerFunc(s.handleLogs),
+		routes.Env:                    http.HandlerFunc(s.handleEnv),
 		routes.Run:                    http.HandlerFunc(s.handleRun),
 		routes.Attach:                 http.HandlerFunc(s.handleAttach),
+		routes.AttachAll:              http.HandlerFunc(s.handleAttachAll),
 		routes.GetProperty:            http.HandlerFunc(s.handleGetProperty),
 		routes.SetProperty:            http.HandlerFunc(s.handleSetProperty),
+		routes.SetProperties:          http.HandlerFunc(s.handleSetProperties),
+		routes.CompareAndSwapProperty: http.HandlerFunc(s.handleCompareAndSwapProperty),
 		routes.RemoveProperty:         http.HandlerFunc(s.handleRemoveProperty),
+		routes.BulkProperties:         http.HandlerFunc(s.handleBulkProperties),
+	}
+
+	for route, handler := range handlers {
+		// timed innermost, so that handlers can tell it what they are doing
+		handler = s.negotiated(s.timed(handler))
+
+		if !unpooledRoutes[route] {
+			handler = s.pooled(workerPoolFor(route), handler)
+		}
+
+		handlers[route] = s.logged(route, s.audited(route, s.metered(route, s.limited(route, handler))))
 	}
 
 	mux, err := rata.NewRouter(routes.Routes, handlers)
@@ -108,15 +302,25 @@ func New(
 	conLogger := logger.Session("connection")
 
 	s.server = http.Server{
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Handler: traced(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.authenticate(w, r) {
+				return
+			}
+
 			mux.ServeHTTP(w, r)
-		}),
+		})),
 
 		ConnState: func(conn net.Conn, state http.ConnState) {
 			switch state {
 			case http.StateNew:
 				conLogger.Debug("open", lager.Data{"local_addr": conn.LocalAddr(), "remote_addr": conn.RemoteAddr()})
 				s.handling.Add(1)
+				s.mu.Lock()
+				s.openConns++
+				if fileConn, ok := conn.(*transport.FileConn); ok {
+					s.fileConns[fileConn.RemoteAddr().String()] = fileConn
+				}
+				s.mu.Unlock()
 			case http.StateActive:
 				s.mu.Lock()
 				delete(s.conns, conn)
@@ -133,6 +337,10 @@ func New(
 			case http.StateHijacked, http.StateClosed:
 				s.mu.Lock()
 				delete(s.conns, conn)
+				if fileConn, ok := conn.(*transport.FileConn); ok {
+					delete(s.fileConns, fileConn.RemoteAddr().String())
+				}
+				s.openConns--
 				s.mu.Unlock()
 				conLogger.Debug("closed", lager.Data{"local_addr": conn.LocalAddr(), "remote_addr": conn.RemoteAddr()})
 				s.handling.Done()
@@ -143,30 +351,195 @@ func New(
 	return s
 }
 
-func (s *GardenServer) Start() error {
-	s.started = true
+// SetErrorAnnotationKeys has errors about a container report its properties
+// with the keys, e.g. to say which team owns it. It must be called before
+// Start.
+func (s *GardenServer) SetErrorAnnotationKeys(keys []string) {
+	s.errorAnnotationKeys = keys
+}
 
-	err := s.removeExistingSocket()
-	if err != nil {
-		return err
+// SetAuthenticator registers a hook that every request, including those for
+// hijacked process streams, must pass before being handled. It must be called
+// before Start.
+func (s *GardenServer) SetAuthenticator(authenticator Authenticator) {
+	s.authenticator = authenticator
+}
+
+// SetAdminAuthenticator registers a hook that requests of the admin routes,
+// e.g. to change the server's defaults, must pass besides any authenticator
+// of every request. Without one, admin requests are refused with
+// api.ErrPermissionDenied. It must be called before Start.
+func (s *GardenServer) SetAdminAuthenticator(authenticator Authenticator) {
+	s.adminAuthenticator = authenticator
+}
+
+// SetUserChecker registers a hook that checks the user of every process
+// before it is run. It must be called before Start.
+func (s *GardenServer) SetUserChecker(checker UserChecker) {
+	s.userChecker = checker
+}
+
+// SetPrivilegePolicy registers a policy deciding which clients may create
+// privileged containers and run privileged processes; without one, any may.
+// It must be called before Start.
+func (s *GardenServer) SetPrivilegePolicy(policy PrivilegePolicy) {
+	s.privilegePolicy = policy
+}
+
+// SetMaxGraceTime limits the grace times clients may give containers, so that
+// none can opt out of being destroyed once idle. Requests for longer grace
+// times, or for none, are clamped or rejected per the policy. It does not
+// limit the server's default grace time. It must be called before Start.
+func (s *GardenServer) SetMaxGraceTime(max time.Duration, policy GraceTimePolicy) {
+	s.maxGraceTime = max
+	s.graceTimePolicy = policy
+}
+
+// SetAuditSink registers a sink recording every request which changes
+// containers, with its arguments and outcome. requesterID, if given,
+// identifies the client making each request, e.g. by the principal it
+// authenticated as. It must be called before Start.
+func (s *GardenServer) SetAuditSink(sink AuditSink, requesterID func(*http.Request) string) {
+	s.auditSink = sink
+	s.auditRequesterID = requesterID
+}
+
+// SetMetricsReporter registers a reporter for metrics about each route's
+// requests. It must be called before Start.
+func (s *GardenServer) SetMetricsReporter(reporter MetricsReporter) {
+	s.metricsReporter = reporter
+}
+
+// SetRateLimits bounds how many requests, and how many streams at once, each
+// client may make. It must be called before Start.
+func (s *GardenServer) SetRateLimits(limits RateLimits) {
+	s.defaultsL.Lock()
+	s.rateLimiter = newRateLimiter(limits)
+	s.defaultsL.Unlock()
+}
+
+// SetStdinLimit limits the bytes that may be written to each process's stdin,
+// over all of the streams attached to it. Once a stream would exceed it, its
+// stdin is cut off and the client is sent api.StdinLimitExceededError. A
+// limit of 0, the default, is no limit. It must be called before Start.
+func (s *GardenServer) SetStdinLimit(bytes uint64) {
+	s.stdinAccounting.setLimit(bytes)
+}
+
+// SetMaxProcessesPerContainer limits how many processes each container may run
+// at once. Run fails with api.ErrProcessLimitExceeded once a container runs
+// that many. A limit of 0, the default, is no limit. It must be called before
+// Start.
+func (s *GardenServer) SetMaxProcessesPerContainer(max uint32) {
+	s.processLimit.setMax(max)
+}
+
+// SetDefaultProcessEnv sets an environment, as "KEY=value" strings, that
+// every container's processes inherit beneath the container's own environment
+// and their own. It must be called before Start.
+func (s *GardenServer) SetDefaultProcessEnv(env []string) {
+	s.defaultProcessEnv = env
+}
+
+// SetStrictRequests makes the server reject requests with fields it does not
+// know, rather than ignoring them, with an api.InvalidRequestError naming
+// each. Clients newer than the server then learn that it did not apply them.
+// Only JSON requests are checked. It must be called before Start.
+func (s *GardenServer) SetStrictRequests(strict bool) {
+	s.strictRequests = strict
+}
+
+// SetProxyProtocol makes the server expect each connection to begin with a
+// PROXY protocol header, as sent by load balancers such as HAProxy or an AWS
+// NLB, and take the client's address from it, for logging, auditing, rate
+// limiting and authentication, including of hijacked streams. Connections
+// without a valid header are closed. It must be called before Start.
+func (s *GardenServer) SetProxyProtocol(enabled bool) {
+	s.proxyProtocol = enabled
+}
+
+// SetHandleGenerator replaces the generator of handles for containers created
+// without one, RandomHandles by default. It must be called before Start.
+func (s *GardenServer) SetHandleGenerator(generator HandleGenerator) {
+	s.handles.generator = generator
+}
+
+// SetEnforceCapacity makes Create fail with api.ErrCapacityExceeded when the
+// backend already has as many containers as its capacity's MaxContainers. It
+// must be called before Start.
+func (s *GardenServer) SetEnforceCapacity(enforce bool) {
+	s.enforceCapacity = enforce
+}
+
+// SetCapacityCacheTTL makes the server reuse the backend's capacity for up to
+// ttl when reporting it, rather than asking the backend on every request. A
+// ttl of 0 disables caching, which is the default. It must be called before
+// Start.
+func (s *GardenServer) SetCapacityCacheTTL(ttl time.Duration) {
+	s.capacityCache.setTTL(ttl)
+}
+
+// SetWorkerPools bounds how many control and streaming requests are handled
+// at once; requests beyond a pool's size wait for one of its workers. A size
+// of 0 leaves the pool unbounded, which is the default. It must be called
+// before Start.
+func (s *GardenServer) SetWorkerPools(controlWorkers, streamingWorkers int) {
+	s.workerPools = map[WorkerPool]*workerPool{
+		ControlPool:   newWorkerPool(controlWorkers),
+		StreamingPool: newWorkerPool(streamingWorkers),
 	}
+}
 
-	err = s.backend.Start()
+// WorkerPoolStats reports the current load of each worker pool.
+func (s *GardenServer) WorkerPoolStats() map[WorkerPool]WorkerPoolStats {
+	stats := map[WorkerPool]WorkerPoolStats{}
+
+	for name, pool := range s.workerPools {
+		stats[name] = pool.stats()
+	}
+
+	return stats
+}
+
+func (s *GardenServer) pooled(pool WorkerPool, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.workerPools[pool].serve(handler, w, r)
+	})
+}
+
+func (s *GardenServer) Start() error {
+	err := s.removeExistingSocket()
 	if err != nil {
 		return err
 	}
 
-	listener, err := net.Listen(s.listenNetwork, s.listenAddr)
+	return s.start(nil, handOffState{})
+}
+
+// start starts the backend and serves the listener, or else listens anew,
+// taking on the state handed off by another server, if any.
+func (s *GardenServer) start(listener net.Listener, state handOffState) error {
+	s.started = true
+	s.startedAt = time.Now()
+
+	err := s.backend.Start()
 	if err != nil {
 		return err
 	}
 
-	s.listener = listener
+	if listener == nil {
+		listener, err = transport.Listen(s.listenNetwork, s.listenAddr)
+		if err != nil {
+			return err
+		}
 
-	if s.listenNetwork == "unix" {
-		os.Chmod(s.listenAddr, 0777)
+		if s.listenNetwork == "unix" {
+			os.Chmod(s.listenAddr, 0777)
+		}
 	}
 
+	s.listener = listener
+
 	containers, err := s.backend.Containers(nil)
 	if err != nil {
 		return err
@@ -178,25 +551,65 @@ func (s *GardenServer) Start() error {
 		s.bomberman.Strap(container)
 	}
 
-	go s.server.Serve(listener)
+	s.takeOver(containers, state)
+
+	s.accepting = newHandOffListener(listener)
+
+	// the listener is handed off as it is, for the new server to wrap as it
+	// is configured to
+	var served net.Listener = s.accepting
+	if s.proxyProtocol {
+		served = transport.ProxyProtocolListener(served, proxyHeaderTimeout)
+	}
+
+	// clients connected over unix sockets may pass files to processes
+	go s.server.Serve(transport.FileListener(served))
 
 	return nil
 }
 
 func (s *GardenServer) Stop() {
-	if !s.started {
+	// a server which has handed off has stopped already, leaving its backend
+	// to the new server
+	if !s.started || s.isHandedOff() {
 		return
 	}
 
+	close(s.detaching)
+
+	s.stop()
+
+	s.logger.Info("stopping-backend")
+	s.backend.Stop()
+
+	s.logger.Info("stopped")
+}
+
+// stop stops accepting requests and waits for those in flight, including
+// process streams, and for destroys to finish.
+func (s *GardenServer) stop() {
+
 	close(s.stopping)
 
-	s.listener.Close()
+	if s.isHandedOff() {
+		s.accepting.stopAccepting()
+	} else {
+		s.listener.Close()
+	}
 
 	s.mu.Lock()
 	conns := s.conns
 	s.conns = make(map[net.Conn]net.Conn)
+	muxSessions := s.muxSessions
+	s.muxSessions = make(map[*transport.MuxSession]struct{})
 	s.mu.Unlock()
 
+	// multiplexed sessions stop accepting streams like the listener, but
+	// leave the streams they have open, e.g. for processes, to finish
+	for session := range muxSessions {
+		session.GoAway()
+	}
+
 	for _, c := range conns {
 		s.logger.Debug("closing-idle", lager.Data{
 			"addr": c.RemoteAddr(),
@@ -208,10 +621,8 @@ func (s *GardenServer) Stop() {
 	s.logger.Info("waiting-for-connections-to-close")
 	s.handling.Wait()
 
-	s.logger.Info("stopping-backend")
-	s.backend.Stop()
-
-	s.logger.Info("stopped")
+	s.logger.Info("waiting-for-destroys")
+	s.destroyOperations.wait()
 }
 
 func (s *GardenServer) removeExistingSocket() error {
@@ -233,10 +644,23 @@ func (s *GardenServer) removeExistingSocket() error {
 }
 
 func (s *GardenServer) reapContainer(container api.Container) {
+	if s.isHandedOff() {
+		s.logger.Info("not-reaping-handed-off", lager.Data{
+			"handle": container.Handle(),
+		})
+
+		return
+	}
+
 	s.logger.Info("reaping", lager.Data{
 		"handle":     container.Handle(),
 		"grace-time": s.backend.GraceTime(container).String(),
 	})
 
-	s.backend.Destroy(container.Handle())
+	err := s.backend.Destroy(container.Handle())
+	s.forgetContainerState(container.Handle())
+
+	if err == nil {
+		s.containerDestroyed(container.Handle())
+	}
 }
@@ -1,22 +1,38 @@
 package server_test
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
 
 	"github.com/cloudfoundry-incubator/garden/api"
 	"github.com/cloudfoundry-incubator/garden/api/fakes"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
 	"github.com/cloudfoundry-incubator/garden/client"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/routes"
 	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
 var _ = Describe("The Garden server", func() {
@@ -84,86 +100,3293 @@ var _ = Describe("The Garden server", func() {
 		})
 	})
 
+	Context("when passed a registered network", func() {
+		It("listens on it, for clients dialing it", func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath := path.Join(tmpdir, "api.sock")
+
+			// stands in for e.g. named pipes, which are only on windows
+			transport.RegisterNetwork("fake-pipe", transport.Network{
+				Listen: func(string) (net.Listener, error) {
+					return net.Listen("unix", socketPath)
+				},
+				Dial: func(address string, timeout time.Duration) (net.Conn, error) {
+					return net.DialTimeout("unix", socketPath, timeout)
+				},
+			})
+
+			apiServer := server.New("fake-pipe", `\\.\pipe\garden`, 0, new(fakes.FakeBackend), logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer apiServer.Stop()
+
+			Eventually(connection.New("fake-pipe", `\\.\pipe\garden`).Ping).ShouldNot(HaveOccurred())
+		})
+	})
+
 	It("starts the backend", func() {
 		var err error
 		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
 		Ω(err).ShouldNot(HaveOccurred())
 
-		socketPath := path.Join(tmpdir, "api.sock")
+		socketPath := path.Join(tmpdir, "api.sock")
+
+		fakeBackend := new(fakes.FakeBackend)
+
+		apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+
+		err = apiServer.Start()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(fakeBackend.StartCallCount()).Should(Equal(1))
+	})
+
+	It("destroys containers that have been idle for their grace time", func() {
+		var err error
+		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		socketPath := path.Join(tmpdir, "api.sock")
+
+		fakeBackend := new(fakes.FakeBackend)
+
+		doomedContainer := new(fakes.FakeContainer)
+
+		fakeBackend.ContainersReturns([]api.Container{doomedContainer}, nil)
+		fakeBackend.GraceTimeReturns(100 * time.Millisecond)
+
+		apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+
+		before := time.Now()
+
+		err = apiServer.Start()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(fakeBackend.DestroyCallCount()).Should(Equal(0))
+		Eventually(fakeBackend.DestroyCallCount).Should(Equal(1))
+
+		Ω(time.Since(before)).Should(BeNumerically(">", 100*time.Millisecond))
+	})
+
+	Context("when starting the backend fails", func() {
+		disaster := errors.New("oh no!")
+
+		It("fails to start", func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath := path.Join(tmpdir, "api.sock")
+
+			fakeBackend := new(fakes.FakeBackend)
+			fakeBackend.StartReturns(disaster)
+
+			apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).Should(Equal(disaster))
+		})
+	})
+
+	Context("when listening on the socket fails", func() {
+		It("fails to start", func() {
+			tmpfile, err := ioutil.TempFile(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			apiServer := server.New(
+				"unix",
+				// weird scenario: /foo/X/api.sock with X being a file
+				path.Join(tmpfile.Name(), "api.sock"),
+				0,
+				new(fakes.FakeBackend),
+				logger,
+			)
+
+			err = apiServer.Start()
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("authentication", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+
+		var authenticatedRequests chan *http.Request
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			authenticatedRequests = make(chan *http.Request, 10)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			apiServer.SetAuthenticator(server.AuthenticatorFunc(func(request *http.Request) error {
+				authenticatedRequests <- request
+
+				if request.Header.Get("Authorization") != "Bearer some-token" {
+					return errors.New("bad token")
+				}
+
+				return nil
+			}))
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		Context("when the request is authenticated", func() {
+			var apiClient api.Client
+
+			BeforeEach(func() {
+				apiClient = client.New(connection.NewWithHeader("unix", socketPath, http.Header{
+					"Authorization": []string{"Bearer some-token"},
+				}))
+			})
+
+			It("dispatches the request", func() {
+				err := apiClient.Ping()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeBackend.PingCallCount()).Should(Equal(1))
+
+				var request *http.Request
+				Eventually(authenticatedRequests).Should(Receive(&request))
+				Ω(request.URL.Path).Should(Equal("/ping"))
+			})
+
+			It("authenticates hijacked process requests", func() {
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.RunReturns(new(fakes.FakeProcess), nil)
+				fakeBackend.LookupReturns(fakeContainer, nil)
+
+				conn := connection.NewWithHeader("unix", socketPath, http.Header{
+					"Authorization": []string{"Bearer some-token"},
+				})
+
+				_, err := conn.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(fakeContainer.RunCallCount).Should(Equal(1))
+
+				var request *http.Request
+				Eventually(authenticatedRequests).Should(Receive(&request))
+				Ω(request.URL.Path).Should(Equal("/containers/some-handle/processes"))
+			})
+		})
+
+		Context("when the request is not authenticated", func() {
+			var apiClient api.Client
+
+			BeforeEach(func() {
+				apiClient = client.New(connection.New("unix", socketPath))
+			})
+
+			It("rejects the request without dispatching it", func() {
+				err := apiClient.Ping()
+				Ω(err).Should(MatchError("bad token"))
+
+				Ω(fakeBackend.PingCallCount()).Should(Equal(0))
+			})
+
+			It("rejects hijacked process requests", func() {
+				fakeContainer := new(fakes.FakeContainer)
+				fakeBackend.LookupReturns(fakeContainer, nil)
+
+				conn := connection.New("unix", socketPath)
+
+				_, err := conn.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+				Ω(err).Should(MatchError("bad token"))
+
+				Ω(fakeContainer.RunCallCount()).Should(Equal(0))
+			})
+		})
+	})
+
+	Describe("enforcing capacity", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var fakeSnapshotter *fakes.FakeSnapshotter
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+			fakeSnapshotter = new(fakes.FakeSnapshotter)
+
+			fakeBackend.CapacityReturns(api.Capacity{MaxContainers: 2}, nil)
+			fakeBackend.CreateReturns(new(fakes.FakeContainer), nil)
+			fakeSnapshotter.RestoreReturns(new(fakes.FakeContainer), nil)
+
+			apiServer = server.New("unix", socketPath, 0, capableBackend{fakeBackend, new(fakes.FakeRenamer), fakeSnapshotter}, logger)
+			apiServer.SetEnforceCapacity(true)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		Context("when the backend has room for another container", func() {
+			BeforeEach(func() {
+				fakeBackend.ContainersReturns([]api.Container{new(fakes.FakeContainer)}, nil)
+			})
+
+			It("creates the container", func() {
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeBackend.CreateCallCount()).Should(Equal(1))
+			})
+		})
+
+		Context("when the backend is at capacity", func() {
+			BeforeEach(func() {
+				fakeBackend.ContainersReturns([]api.Container{
+					new(fakes.FakeContainer),
+					new(fakes.FakeContainer),
+				}, nil)
+			})
+
+			It("fails with ErrCapacityExceeded without creating the container", func() {
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).Should(Equal(api.ErrCapacityExceeded))
+
+				Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+			})
+
+			It("fails to restore a container likewise", func() {
+				_, err := apiClient.Restore(bytes.NewBufferString("some-snapshot"))
+				Ω(err).Should(Equal(api.ErrCapacityExceeded))
+
+				Ω(fakeSnapshotter.RestoreCallCount()).Should(BeZero())
+			})
+		})
+
+		Context("when another create is in flight", func() {
+			var creating chan struct{}
+			var finishCreating chan struct{}
+
+			BeforeEach(func() {
+				fakeBackend.ContainersReturns([]api.Container{new(fakes.FakeContainer)}, nil)
+
+				creating = make(chan struct{})
+				finishCreating = make(chan struct{})
+
+				fakeBackend.CreateStub = func(api.ContainerSpec) (api.Container, error) {
+					close(creating)
+					<-finishCreating
+					return new(fakes.FakeContainer), nil
+				}
+			})
+
+			It("counts it towards the capacity", func() {
+				defer close(finishCreating)
+
+				go apiClient.Create(api.ContainerSpec{})
+
+				Eventually(creating).Should(BeClosed())
+
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).Should(Equal(api.ErrCapacityExceeded))
+			})
+		})
+
+		Context("when the backend does not report a maximum", func() {
+			BeforeEach(func() {
+				fakeBackend.CapacityReturns(api.Capacity{}, nil)
+			})
+
+			It("creates the container", func() {
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeBackend.CreateCallCount()).Should(Equal(1))
+			})
+		})
+
+		Context("when getting the capacity fails", func() {
+			BeforeEach(func() {
+				fakeBackend.CapacityReturns(api.Capacity{}, errors.New("oh no!"))
+			})
+
+			It("fails without creating the container", func() {
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).Should(HaveOccurred())
+
+				Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+			})
+		})
+	})
+
+	Describe("confirming destroys", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeBackend.LookupReturns(new(fakes.FakeContainer), nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetDestroyConfirmation(true)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		Context("when the client confirms its destroys", func() {
+			var apiClient api.Client
+
+			BeforeEach(func() {
+				apiClient = client.New(connection.NewWithConfig("unix", socketPath, connection.Config{
+					ConfirmDestroys: true,
+				}))
+			})
+
+			It("destroys the container", func() {
+				err := apiClient.Destroy("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeBackend.DestroyCallCount()).Should(Equal(1))
+				Ω(fakeBackend.DestroyArgsForCall(0)).Should(Equal("some-handle"))
+			})
+		})
+
+		Context("when the client does not confirm its destroys", func() {
+			var apiConnection connection.Connection
+
+			BeforeEach(func() {
+				apiConnection = connection.New("unix", socketPath)
+			})
+
+			It("fails with ErrDestroyNotConfirmed without destroying the container", func() {
+				err := apiConnection.Destroy("some-handle")
+				Ω(err).Should(Equal(api.ErrDestroyNotConfirmed))
+
+				Ω(fakeBackend.DestroyCallCount()).Should(BeZero())
+			})
+
+			It("fails to schedule the destroy with ErrDestroyNotConfirmed", func() {
+				err := apiConnection.DestroyAfter("some-handle", time.Hour)
+				Ω(err).Should(Equal(api.ErrDestroyNotConfirmed))
+			})
+		})
+
+		Context("when the confirmation names another container", func() {
+			It("fails with ErrDestroyNotConfirmed without destroying the container", func() {
+				apiConnection := connection.NewWithHeader("unix", socketPath, http.Header{
+					transport.DestroyConfirmationHeader: []string{"another-handle"},
+				})
+
+				err := apiConnection.Destroy("some-handle")
+				Ω(err).Should(Equal(api.ErrDestroyNotConfirmed))
+
+				Ω(fakeBackend.DestroyCallCount()).Should(BeZero())
+			})
+		})
+	})
+
+	Describe("backends without optional capabilities", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var apiConnection connection.Connection
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeBackend.LookupReturns(new(fakes.FakeContainer), nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiConnection = connection.New("unix", socketPath)
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("fails renames with ErrUnsupportedOperation", func() {
+			err := apiConnection.Rename("some-handle", "new-handle")
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+		})
+
+		It("does not list the rename capability", func() {
+			version, err := apiConnection.ServerVersion()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(version.Capabilities).ShouldNot(ContainElement(api.CapabilityRename))
+			Ω(version.Capabilities).Should(ContainElement(api.CapabilityPauseResume))
+		})
+
+		It("fails snapshots and restores with ErrUnsupportedOperation", func() {
+			_, err := apiConnection.Snapshot("some-handle")
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+
+			_, err = apiConnection.Restore(bytes.NewBufferString("some-snapshot"))
+			Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+		})
+	})
+
+	Describe("caching capacity", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeBackend.CapacityReturns(api.Capacity{MaxContainers: 1}, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetCapacityCacheTTL(500 * time.Millisecond)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("reuses the backend's capacity until the ttl passes", func() {
+			capacity, err := apiClient.Capacity()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity.MaxContainers).Should(Equal(uint64(1)))
+
+			fakeBackend.CapacityReturns(api.Capacity{MaxContainers: 2}, nil)
+
+			capacity, err = apiClient.Capacity()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity.MaxContainers).Should(Equal(uint64(1)))
+			Ω(fakeBackend.CapacityCallCount()).Should(Equal(1))
+
+			Eventually(func() uint64 {
+				capacity, err := apiClient.Capacity()
+				Ω(err).ShouldNot(HaveOccurred())
+				return capacity.MaxContainers
+			}).Should(Equal(uint64(2)))
+		})
+
+		It("asks the backend when fresh capacity is requested", func() {
+			_, err := apiClient.Capacity()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			fakeBackend.CapacityReturns(api.Capacity{MaxContainers: 2}, nil)
+
+			capacity, err := apiClient.FreshCapacity()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity.MaxContainers).Should(Equal(uint64(2)))
+
+			capacity, err = apiClient.Capacity()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity.MaxContainers).Should(Equal(uint64(2)))
+			Ω(fakeBackend.CapacityCallCount()).Should(Equal(2))
+		})
+
+		Context("when the backend fails to report its capacity", func() {
+			BeforeEach(func() {
+				fakeBackend.CapacityReturns(api.Capacity{}, errors.New("oh no!"))
+			})
+
+			It("does not cache the failure", func() {
+				_, err := apiClient.Capacity()
+				Ω(err).Should(HaveOccurred())
+
+				fakeBackend.CapacityReturns(api.Capacity{MaxContainers: 2}, nil)
+
+				capacity, err := apiClient.Capacity()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(capacity.MaxContainers).Should(Equal(uint64(2)))
+			})
+		})
+	})
+
+	Describe("multiplexing", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var apiConnection connection.Connection
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiConnection = connection.NewWithConfig("unix", socketPath, connection.Config{
+				Multiplex: true,
+			})
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		sessionsServed := func() int {
+			served := 0
+
+			for _, log := range logger.Logs() {
+				if log.Message == "test.garden-server.multiplex.serving" {
+					served++
+				}
+			}
+
+			return served
+		}
+
+		It("sends requests and process streams over a single session", func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+				io.Stdout.Write([]byte("hello"))
+
+				process := new(fakes.FakeProcess)
+				process.IDReturns(42)
+				return process, nil
+			}
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			for i := 0; i < 3; i++ {
+				Ω(apiConnection.Ping()).Should(Succeed())
+			}
+
+			stdout := gbytes.NewBuffer()
+
+			processes := []api.Process{}
+			for i := 0; i < 3; i++ {
+				process, err := apiConnection.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				processes = append(processes, process)
+			}
+
+			for _, process := range processes {
+				_, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			Eventually(stdout).Should(gbytes.Say("hellohellohello"))
+
+			Ω(sessionsServed()).Should(Equal(1))
+		})
+
+		Context("when the server is restarted", func() {
+			BeforeEach(func() {
+				Ω(apiConnection.Ping()).Should(Succeed())
+
+				apiServer.Stop()
+
+				apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+				err := apiServer.Start()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+			})
+
+			It("starts a new session", func() {
+				Eventually(apiConnection.Ping).Should(Succeed())
+
+				Ω(sessionsServed()).Should(Equal(2))
+			})
+		})
+	})
+
+	Describe("reporting metrics", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var apiClient api.Client
+		var reporter *recordingReporter
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			reporter = &recordingReporter{durations: map[string][]time.Duration{}, counters: map[string]int{}}
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetMetricsReporter(reporter)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("counts and times each route's requests", func() {
+			fakeBackend.PingStub = func() error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			}
+
+			Ω(apiClient.Ping()).Should(Succeed())
+			Ω(apiClient.Ping()).Should(Succeed())
+
+			Ω(reporter.counter("requests.Ping")).Should(Equal(2))
+
+			// the response is sent before the request is done being handled
+			Eventually(func() []time.Duration {
+				return reporter.durationsOf("request_duration.Ping")
+			}).Should(HaveLen(2))
+
+			Ω(reporter.durationsOf("request_duration.Ping")[0]).Should(BeNumerically(">=", 10*time.Millisecond))
+			Ω(reporter.counter("request_failures.Ping")).Should(BeZero())
+		})
+
+		It("counts failed requests", func() {
+			fakeBackend.PingReturns(errors.New("oh no!"))
+
+			Ω(apiClient.Ping()).ShouldNot(Succeed())
+
+			Ω(reporter.counter("requests.Ping")).Should(Equal(1))
+
+			Eventually(func() int {
+				return reporter.counter("request_failures.Ping")
+			}).Should(Equal(1))
+		})
+
+		It("reports process streams once they end", func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.RunReturns(new(fakes.FakeProcess), nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			conn := connection.New("unix", socketPath)
+
+			process, err := conn.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(func() []time.Duration {
+				return reporter.durationsOf("request_duration.Run")
+			}).Should(HaveLen(1))
+
+			Ω(reporter.counter("request_failures.Run")).Should(BeZero())
+		})
+	})
+
+	Describe("auditing", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var apiClient api.Client
+		var sink *recordingAuditSink
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			sink = &recordingAuditSink{}
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetAuditSink(sink, func(request *http.Request) string {
+				return request.Header.Get("Authorization")
+			})
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.NewWithHeader("unix", socketPath, http.Header{
+				"Authorization": []string{"some-user"},
+			}))
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("records requests which change containers, with their requester and arguments", func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeBackend.CreateReturns(fakeContainer, nil)
+
+			before := time.Now()
+
+			_, err := apiClient.Create(api.ContainerSpec{Handle: "some-handle"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			events := sink.recorded()
+			Ω(events).Should(HaveLen(1))
+
+			event := events[0]
+			Ω(event.Route).Should(Equal("Create"))
+			Ω(event.Requester).Should(Equal("some-user"))
+			Ω(event.Status).Should(Equal(http.StatusOK))
+			Ω(event.Time.Before(before)).Should(BeFalse())
+
+			var request apitypes.CreateRequest
+			err = json.Unmarshal(event.Arguments, &request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(request.GetHandle()).Should(Equal("some-handle"))
+		})
+
+		It("redacts passwords from the arguments", func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeBackend.CreateReturns(fakeContainer, nil)
+
+			_, err := apiClient.Create(api.ContainerSpec{
+				Image: api.ImageRef{
+					URI:      "docker:///busybox",
+					Username: "some-user",
+					Password: "some-password",
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			events := sink.recorded()
+			Ω(events).Should(HaveLen(1))
+
+			var request apitypes.CreateRequest
+			err = json.Unmarshal(events[0].Arguments, &request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(request.GetImage().GetUri()).Should(Equal("docker:///busybox"))
+			Ω(request.GetImage().GetUsername()).Should(Equal("some-user"))
+			Ω(request.GetImage().GetPassword()).Should(Equal("[REDACTED]"))
+
+			Ω(fakeBackend.CreateArgsForCall(0).Image.Password).Should(Equal("some-password"))
+		})
+
+		It("records the container and query of requests made of one", func() {
+			fakeBackend.LookupReturns(new(fakes.FakeContainer), nil)
+
+			conn := connection.New("unix", socketPath)
+
+			err := conn.ForceDestroy("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			events := sink.recorded()
+			Ω(events).Should(HaveLen(1))
+			Ω(events[0].Route).Should(Equal("Destroy"))
+			Ω(events[0].Handle).Should(Equal("some-handle"))
+			Ω(events[0].Query).Should(Equal(url.Values{"force": []string{"true"}}))
+		})
+
+		It("records requests which fail", func() {
+			fakeBackend.LookupReturns(nil, api.ErrContainerNotFound)
+
+			_, err := connection.New("unix", socketPath).LimitMemory("some-handle", api.MemoryLimits{LimitInBytes: 1024})
+			Ω(err).Should(HaveOccurred())
+
+			events := sink.recorded()
+			Ω(events).Should(HaveLen(1))
+			Ω(events[0].Route).Should(Equal("LimitMemory"))
+			Ω(events[0].Status).Should(BeNumerically(">=", 400))
+		})
+
+		It("records processes as they start, rather than once they exit", func() {
+			exit := make(chan struct{})
+			defer close(exit)
+
+			fakeProcess := new(fakes.FakeProcess)
+			fakeProcess.WaitStub = func() (int, error) {
+				<-exit
+				return 0, nil
+			}
+
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.RunReturns(fakeProcess, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			_, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			events := sink.recorded()
+			Ω(events).Should(HaveLen(1))
+			Ω(events[0].Route).Should(Equal("Run"))
+			Ω(events[0].Status).Should(Equal(http.StatusCreated))
+			Ω(string(events[0].Arguments)).Should(ContainSubstring("some-path"))
+		})
+
+		It("does not record requests which change nothing", func() {
+			fakeBackend.LookupReturns(new(fakes.FakeContainer), nil)
+
+			Ω(apiClient.Ping()).Should(Succeed())
+
+			_, err := apiClient.Containers(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = connection.New("unix", socketPath).Info("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(sink.recorded()).Should(BeEmpty())
+		})
+
+		Describe("writing events", func() {
+			It("writes each as a line of JSON", func() {
+				buffer := new(bytes.Buffer)
+
+				writerSink := server.NewWriterAuditSink(buffer)
+				writerSink.Record(server.AuditEvent{Route: "Create", Arguments: json.RawMessage(`{"handle":"a"}`)})
+				writerSink.Record(server.AuditEvent{Route: "Destroy", Handle: "a"})
+
+				decoder := json.NewDecoder(buffer)
+
+				var event server.AuditEvent
+				Ω(decoder.Decode(&event)).Should(Succeed())
+				Ω(event.Route).Should(Equal("Create"))
+				Ω(string(event.Arguments)).Should(Equal(`{"handle":"a"}`))
+
+				event = server.AuditEvent{}
+				Ω(decoder.Decode(&event)).Should(Succeed())
+				Ω(event.Route).Should(Equal("Destroy"))
+				Ω(event.Handle).Should(Equal("a"))
+			})
+
+			It("logs each with the lager sink", func() {
+				server.NewLagerAuditSink(logger).Record(server.AuditEvent{Route: "Create"})
+
+				Ω(logger.LogMessages()).Should(ContainElement("test.audit"))
+			})
+		})
+	})
+
+	Describe("behind a PROXY protocol load balancer", func() {
+		var apiServer *server.GardenServer
+
+		var authenticatedRequests chan *http.Request
+
+		BeforeEach(func() {
+			authenticatedRequests = make(chan *http.Request, 10)
+
+			apiServer = server.New("tcp", "127.0.0.1:60125", 0, new(fakes.FakeBackend), logger)
+			apiServer.SetProxyProtocol(true)
+
+			apiServer.SetAuthenticator(server.AuthenticatorFunc(func(request *http.Request) error {
+				authenticatedRequests <- request
+				return nil
+			}))
+
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("tcp", "127.0.0.1:60125")).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		ping := func(header string) (*http.Response, error) {
+			conn, err := net.Dial("tcp", "127.0.0.1:60125")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer conn.Close()
+
+			_, err = conn.Write([]byte(header + "GET /ping HTTP/1.1\r\nHost: api\r\n\r\n"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			return http.ReadResponse(bufio.NewReader(conn), nil)
+		}
+
+		It("takes the client's address from each connection's header", func() {
+			response, err := ping("PROXY TCP4 192.0.2.1 192.0.2.2 56324 7777\r\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+			var request *http.Request
+			Eventually(authenticatedRequests).Should(Receive(&request))
+
+			Ω(request.RemoteAddr).Should(Equal("192.0.2.1:56324"))
+		})
+
+		Context("when a connection has no header", func() {
+			It("closes it without handling its requests", func() {
+				_, err := ping("")
+				Ω(err).Should(HaveOccurred())
+
+				Consistently(authenticatedRequests).ShouldNot(Receive())
+			})
+		})
+	})
+
+	Describe("limiting clients", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var limits server.RateLimits
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			limits = server.RateLimits{}
+		})
+
+		JustBeforeEach(func() {
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetRateLimits(limits)
+
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		Context("with a request rate", func() {
+			BeforeEach(func() {
+				limits.RequestsPerSecond = 5
+				limits.Burst = 2
+			})
+
+			It("rejects requests beyond it with ErrRateLimited", func() {
+				apiClient := client.New(connection.New("unix", socketPath))
+
+				Ω(apiClient.Ping()).Should(Succeed())
+				Ω(apiClient.Ping()).Should(Succeed())
+				Ω(apiClient.Ping()).Should(Equal(api.ErrRateLimited))
+
+				Ω(fakeBackend.PingCallCount()).Should(Equal(2))
+
+				Eventually(apiClient.Ping).Should(Succeed())
+			})
+
+			Context("when clients are identified by their requests", func() {
+				BeforeEach(func() {
+					limits.ClientID = func(request *http.Request) string {
+						return request.Header.Get("Authorization")
+					}
+				})
+
+				It("limits each client separately", func() {
+					clientA := client.New(connection.NewWithHeader("unix", socketPath, http.Header{
+						"Authorization": []string{"a"},
+					}))
+
+					clientB := client.New(connection.NewWithHeader("unix", socketPath, http.Header{
+						"Authorization": []string{"b"},
+					}))
+
+					Ω(clientA.Ping()).Should(Succeed())
+					Ω(clientA.Ping()).Should(Succeed())
+					Ω(clientA.Ping()).Should(Equal(api.ErrRateLimited))
+
+					Ω(clientB.Ping()).Should(Succeed())
+				})
+			})
+		})
+
+		Context("with a maximum number of streams", func() {
+			var exit chan int
+
+			BeforeEach(func() {
+				limits.MaxStreams = 1
+
+				exit = make(chan int)
+
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.RunStub = func(api.ProcessSpec, api.ProcessIO) (api.Process, error) {
+					process := new(fakes.FakeProcess)
+					process.WaitStub = func() (int, error) {
+						return <-exit, nil
+					}
+
+					return process, nil
+				}
+
+				fakeBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			It("rejects streams beyond it until one ends", func() {
+				conn := connection.New("unix", socketPath)
+
+				process, err := conn.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = conn.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+				Ω(err).Should(Equal(api.ErrRateLimited))
+
+				Ω(conn.Ping()).Should(Succeed())
+
+				exit <- 0
+
+				_, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(func() error {
+					_, err := conn.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+					return err
+				}).ShouldNot(HaveOccurred())
+
+				close(exit)
+			})
+		})
+	})
+
+	Describe("changing defaults", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeBackend.CreateReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, time.Minute, fakeBackend, logger)
+		})
+
+		JustBeforeEach(func() {
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("reports the defaults it was configured with", func() {
+			Ω(apiServer.Defaults()).Should(Equal(server.Defaults{GraceTime: time.Minute}))
+		})
+
+		It("gives containers created afterwards the new grace time", func() {
+			_, err := apiClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			apiServer.SetDefaults(server.Defaults{GraceTime: 5 * time.Minute})
+
+			_, err = apiClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeBackend.CreateArgsForCall(0).GraceTime).Should(Equal(time.Minute))
+			Ω(fakeBackend.CreateArgsForCall(1).GraceTime).Should(Equal(5 * time.Minute))
+		})
+
+		It("limits clients' requests while running", func() {
+			apiServer.SetDefaults(server.Defaults{RequestsPerSecond: 0.01, Burst: 1})
+
+			Ω(apiClient.Ping()).Should(Succeed())
+			Ω(apiClient.Ping()).Should(Equal(api.ErrRateLimited))
+
+			apiServer.SetDefaults(server.Defaults{})
+
+			Ω(apiClient.Ping()).Should(Succeed())
+		})
+
+		Describe("over the admin route", func() {
+			var httpClient *http.Client
+
+			BeforeEach(func() {
+				httpClient = &http.Client{
+					Transport: &http.Transport{
+						Dial: func(string, string) (net.Conn, error) {
+							return net.Dial("unix", socketPath)
+						},
+					},
+				}
+			})
+
+			adminRequest := func(method string, body string, admin string) *http.Response {
+				request, err := http.NewRequest(method, "http://api/admin/defaults", strings.NewReader(body))
+				Ω(err).ShouldNot(HaveOccurred())
+
+				request.Header.Set("Content-Type", "application/json")
+				request.Header.Set("X-Caller", admin)
+
+				response, err := httpClient.Do(request)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				return response
+			}
+
+			Context("with an admin authenticator", func() {
+				BeforeEach(func() {
+					apiServer.SetAdminAuthenticator(server.AuthenticatorFunc(func(request *http.Request) error {
+						if request.Header.Get("X-Caller") != "admin" {
+							return errors.New("not an admin")
+						}
+
+						return nil
+					}))
+
+					apiServer.SetDefaults(server.Defaults{
+						GraceTime:  time.Minute,
+						StdinLimit: 1024,
+					})
+				})
+
+				It("reports the defaults", func() {
+					response := adminRequest("GET", "", "admin")
+					defer response.Body.Close()
+
+					Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+					var defaults apitypes.DefaultsResponse
+					err := json.NewDecoder(response.Body).Decode(&defaults)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(defaults.GetGraceTime()).Should(Equal(uint32(60)))
+					Ω(defaults.GetStdinLimit()).Should(Equal(uint64(1024)))
+					Ω(defaults.GetMaxProcessesPerContainer()).Should(BeZero())
+				})
+
+				It("changes only the defaults given", func() {
+					response := adminRequest("PUT", `{"grace_time":30,"max_processes_per_container":4}`, "admin")
+					response.Body.Close()
+
+					Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+					Ω(apiServer.Defaults()).Should(Equal(server.Defaults{
+						GraceTime:                30 * time.Second,
+						StdinLimit:               1024,
+						MaxProcessesPerContainer: 4,
+					}))
+				})
+
+				It("refuses requests which the authenticator rejects", func() {
+					response := adminRequest("PUT", `{"grace_time":30}`, "someone")
+					response.Body.Close()
+
+					Ω(response.StatusCode).Should(Equal(http.StatusForbidden))
+
+					Ω(apiServer.Defaults().GraceTime).Should(Equal(time.Minute))
+				})
+			})
+
+			Context("without an admin authenticator", func() {
+				It("refuses every request", func() {
+					response := adminRequest("GET", "", "admin")
+					response.Body.Close()
+
+					Ω(response.StatusCode).Should(Equal(http.StatusForbidden))
+
+					response = adminRequest("PUT", `{"grace_time":30}`, "admin")
+					response.Body.Close()
+
+					Ω(response.StatusCode).Should(Equal(http.StatusForbidden))
+
+					Ω(apiServer.Defaults().GraceTime).Should(Equal(time.Minute))
+				})
+			})
+		})
+	})
+
+	Describe("reporting diagnostics", func() {
+		var socketPath string
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		var exit chan struct{}
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend := new(fakes.FakeBackend)
+
+			exit = make(chan struct{})
+
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.RunStub = func(api.ProcessSpec, api.ProcessIO) (api.Process, error) {
+				process := new(fakes.FakeProcess)
+				process.IDReturns(42)
+				process.WaitStub = func() (int, error) {
+					<-exit
+					return 0, nil
+				}
+
+				return process, nil
+			}
+
+			fakeBackend.ContainersReturns([]api.Container{fakeContainer}, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, time.Minute, fakeBackend, logger)
+
+			apiServer.SetAdminAuthenticator(server.AuthenticatorFunc(func(*http.Request) error {
+				return nil
+			}))
+		})
+
+		JustBeforeEach(func() {
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		AfterEach(func() {
+			close(exit)
+			apiServer.Stop()
+		})
+
+		It("counts the connections hijacked for process streams until they are closed", func() {
+			container, err := apiClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			process, err := container.Run(api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(func() int {
+				return apiServer.Diagnostics().HijackedConnections
+			}).Should(Equal(1))
+
+			exit <- struct{}{}
+
+			_, err = process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(func() int {
+				return apiServer.Diagnostics().HijackedConnections
+			}).Should(BeZero())
+		})
+
+		It("reports them over the admin route", func() {
+			httpClient := &http.Client{
+				Transport: &http.Transport{
+					Dial: func(string, string) (net.Conn, error) {
+						return net.Dial("unix", socketPath)
+					},
+				},
+			}
+
+			response, err := httpClient.Get("http://api/admin/diagnostics")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer response.Body.Close()
+
+			Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+			var diagnostics apitypes.DiagnosticsResponse
+			err = json.NewDecoder(response.Body).Decode(&diagnostics)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(diagnostics.GetGoroutines()).ShouldNot(BeZero())
+			Ω(diagnostics.GetOpenConnections()).Should(BeNumerically(">=", 1))
+			Ω(diagnostics.GetHijackedConnections()).Should(BeZero())
+			Ω(diagnostics.GetHeapBytes()).ShouldNot(BeZero())
+		})
+	})
+
+	Describe("reporting server timing", func() {
+		var socketPath string
+		var apiServer *server.GardenServer
+		var httpClient *http.Client
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend := new(fakes.FakeBackend)
+
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			httpClient = &http.Client{
+				Transport: &http.Transport{
+					Dial: func(string, string) (net.Conn, error) {
+						return net.Dial("unix", socketPath)
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		setMemoryLimit := func() *http.Response {
+			request, err := http.NewRequest(
+				"PUT",
+				"http://api/containers/some-handle/limits/memory",
+				strings.NewReader(`{"handle":"some-handle","limit_in_bytes":1024}`),
+			)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			request.Header.Set("Content-Type", "application/json")
+
+			response, err := httpClient.Do(request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			response.Body.Close()
+
+			return response
+		}
+
+		Context("when enabled", func() {
+			BeforeEach(func() {
+				apiServer.SetServerTiming(true)
+			})
+
+			It("breaks down how long each request took to decode, handle and encode", func() {
+				response := setMemoryLimit()
+
+				Ω(response.StatusCode).Should(Equal(http.StatusOK))
+				Ω(response.Header.Get(server.ServerTimingHeader)).Should(MatchRegexp(
+					`^decode;dur=\d+\.\d\d, backend;dur=\d+\.\d\d, encode;dur=\d+\.\d\d$`,
+				))
+			})
+		})
+
+		Context("when not enabled", func() {
+			It("does not report timings", func() {
+				response := setMemoryLimit()
+
+				Ω(response.StatusCode).Should(Equal(http.StatusOK))
+				Ω(response.Header.Get(server.ServerTimingHeader)).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("tracing requests", func() {
+		var socketPath string
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("echoes the request's trace ID in its response", func() {
+			httpClient := &http.Client{
+				Transport: &http.Transport{
+					Dial: func(string, string) (net.Conn, error) {
+						return net.Dial("unix", socketPath)
+					},
+				},
+			}
+
+			request, err := http.NewRequest("GET", "http://api/ping", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			request.Header.Set(transport.TraceHeader, "some-trace")
+
+			response, err := httpClient.Do(request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			response.Body.Close()
+
+			Ω(response.StatusCode).Should(Equal(http.StatusOK))
+			Ω(response.Header.Get(transport.TraceHeader)).Should(Equal("some-trace"))
+		})
+
+		Context("when the request fails", func() {
+			BeforeEach(func() {
+				fakeBackend.LookupReturns(nil, errors.New("oh no!"))
+			})
+
+			It("gives the trace ID in the error, and logs it", func() {
+				conn := connection.NewWithHeader("unix", socketPath, http.Header{
+					transport.TraceHeader: []string{"some-trace"},
+				})
+
+				_, err := conn.Info("some-handle")
+				Ω(err).Should(BeAssignableToTypeOf(&connection.GardenError{}))
+				Ω(err.(*connection.GardenError).TraceID).Should(Equal("some-trace"))
+
+				logs, err := json.Marshal(logger.Logs())
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(string(logs)).Should(ContainSubstring(`"trace":"some-trace"`))
+			})
+		})
+
+		Context("when the trace ID is not printable", func() {
+			It("is not echoed", func() {
+				conn := connection.NewWithHeader("unix", socketPath, http.Header{
+					transport.TraceHeader: []string{"some trace"},
+				})
+
+				fakeBackend.LookupReturns(nil, errors.New("oh no!"))
+
+				_, err := conn.Info("some-handle")
+				Ω(err).Should(BeAssignableToTypeOf(&connection.GardenError{}))
+				Ω(err.(*connection.GardenError).TraceID).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("logging requests", func() {
+		var socketPath string
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+			fakeBackend.LookupReturns(nil, errors.New("oh no!"))
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetAuditSink(new(recordingAuditSink), func(request *http.Request) string {
+				return request.Header.Get("X-Caller")
+			})
+			apiServer.SetLogFields(func(request *http.Request) lager.Data {
+				return lager.Data{
+					"tenant": request.Header.Get("X-Tenant"),
+					"route":  "overridden",
+				}
+			})
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		infoLogs := func() []lager.LogFormat {
+			infoLogs := []lager.LogFormat{}
+
+			for _, log := range logger.Logs() {
+				if strings.HasPrefix(log.Message, "test.garden-server.info.") {
+					infoLogs = append(infoLogs, log)
+				}
+			}
+
+			return infoLogs
+		}
+
+		It("tags every line about a request with its route, handle, ID and client", func() {
+			conn := connection.NewWithHeader("unix", socketPath, http.Header{
+				"X-Caller":            []string{"some-caller"},
+				"X-Tenant":            []string{"some-tenant"},
+				transport.TraceHeader: []string{"some-trace"},
+			})
+
+			_, err := conn.Info("some-handle")
+			Ω(err).Should(HaveOccurred())
+
+			logs := infoLogs()
+			Ω(logs).ShouldNot(BeEmpty())
+
+			for _, log := range logs {
+				Ω(log.Data).Should(HaveKeyWithValue("route", routes.Info))
+				Ω(log.Data).Should(HaveKeyWithValue("handle", "some-handle"))
+				Ω(log.Data).Should(HaveKeyWithValue("requester", "some-caller"))
+				Ω(log.Data).Should(HaveKeyWithValue("tenant", "some-tenant"))
+				Ω(log.Data).Should(HaveKeyWithValue("trace", "some-trace"))
+				Ω(log.Data).Should(HaveKey("remote_addr"))
+				Ω(log.Data["request"]).Should(MatchRegexp(`^[0-9a-f]{16}$`))
+			}
+		})
+
+		It("gives each request its own ID", func() {
+			conn := connection.New("unix", socketPath)
+
+			_, err := conn.Info("some-handle")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = conn.Info("some-handle")
+			Ω(err).Should(HaveOccurred())
+
+			ids := map[interface{}]bool{}
+			for _, log := range infoLogs() {
+				ids[log.Data["request"]] = true
+			}
+
+			Ω(ids).Should(HaveLen(2))
+		})
+	})
+
+	Describe("timing out writes", func() {
+		var socketPath string
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+
+		var wroteOutput chan struct{}
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			wroteOutput = make(chan struct{})
+
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.RunStub = func(spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+				go func() {
+					defer close(wroteOutput)
+
+					// far more than the socket buffers
+					chunk := bytes.Repeat([]byte("x"), 64*1024)
+					for i := 0; i < 64; i++ {
+						processIO.Stdout.Write(chunk)
+					}
+				}()
+
+				process := new(fakes.FakeProcess)
+				process.IDReturns(42)
+				process.WaitStub = func() (int, error) {
+					<-wroteOutput
+					return 0, nil
+				}
+
+				return process, nil
+			}
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetWriteTimeout(100 * time.Millisecond)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("closes the streams of clients which stop reading, letting their processes' output drain", func() {
+			conn, err := net.Dial("unix", socketPath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer conn.Close()
+
+			_, err = fmt.Fprintf(
+				conn,
+				"POST /containers/some-handle/processes HTTP/1.1\r\nHost: api\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s",
+				len(`{"handle":"some-handle","path":"yes"}`),
+				`{"handle":"some-handle","path":"yes"}`,
+			)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			// the client reads nothing
+			Eventually(wroteOutput, 5*time.Second).Should(BeClosed())
+		})
+	})
+
+	Describe("generating handles", func() {
+		var socketPath string
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeBackend.CreateStub = func(spec api.ContainerSpec) (api.Container, error) {
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns(spec.Handle)
+				return fakeContainer, nil
+			}
+
+			fakeBackend.LookupStub = func(handle string) (api.Container, error) {
+				if handle != "taken-handle" {
+					return nil, api.ErrContainerNotFound
+				}
+
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns(handle)
+				return fakeContainer, nil
+			}
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		JustBeforeEach(func() {
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("generates a random handle for containers created without one", func() {
+			container, err := apiClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.Handle()).Should(MatchRegexp(`^[0-9a-f]{16}$`))
+			Ω(fakeBackend.CreateArgsForCall(0).Handle).Should(Equal(container.Handle()))
+		})
+
+		It("leaves the handles clients give alone", func() {
+			container, err := apiClient.Create(api.ContainerSpec{Handle: "some-handle"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.Handle()).Should(Equal("some-handle"))
+		})
+
+		Context("with a generator", func() {
+			var generated []string
+
+			BeforeEach(func() {
+				generated = []string{"taken-handle", "taken-handle", "handle-1", "handle-2"}
+
+				apiServer.SetHandleGenerator(func() (string, error) {
+					handle := generated[0]
+					generated = generated[1:]
+					return handle, nil
+				})
+			})
+
+			It("generates handles with it, until one is not taken", func() {
+				container, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(container.Handle()).Should(Equal("handle-1"))
+
+				container, err = apiClient.Create(api.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(container.Handle()).Should(Equal("handle-2"))
+			})
+		})
+
+		Context("when the generator keeps generating taken handles", func() {
+			BeforeEach(func() {
+				apiServer.SetHandleGenerator(func() (string, error) {
+					return "taken-handle", nil
+				})
+			})
+
+			It("gives up with a retryable error", func() {
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).Should(HaveOccurred())
+				Ω(api.ClassOf(err)).Should(Equal(api.ErrorClassRetryable))
+
+				Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+			})
+		})
+
+		Context("when the generator fails", func() {
+			BeforeEach(func() {
+				apiServer.SetHandleGenerator(func() (string, error) {
+					return "", errors.New("oh no!")
+				})
+			})
+
+			It("fails to create the container", func() {
+				_, err := apiClient.Create(api.ContainerSpec{})
+				Ω(err).Should(MatchError("oh no!"))
+
+				Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+			})
+		})
+	})
+
+	Describe("retaining process output", func() {
+		var socketPath string
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			fakeBackend.CreateReturns(fakeContainer, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			var lastProcessID uint32
+
+			fakeContainer.RunStub = func(spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+				for _, arg := range spec.Args {
+					if strings.HasPrefix(arg, "err:") {
+						processIO.Stderr.Write([]byte(strings.TrimPrefix(arg, "err:")))
+					} else {
+						processIO.Stdout.Write([]byte(arg))
+					}
+				}
+
+				process := new(fakes.FakeProcess)
+				process.IDReturns(atomic.AddUint32(&lastProcessID, 1))
+				return process, nil
+			}
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetLogRetention(16)
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		JustBeforeEach(func() {
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		create := func() client.Container {
+			container, err := apiClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			return container.(client.Container)
+		}
+
+		run := func(container api.Container, output ...string) {
+			process, err := container.Run(api.ProcessSpec{Path: "echo", Args: output}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		readAll := func(stream api.LogStream) []api.LogEntry {
+			entries := []api.LogEntry{}
+
+			for {
+				entry, err := stream.Next()
+				if err == io.EOF {
+					return entries
+				}
+
+				Ω(err).ShouldNot(HaveOccurred())
+
+				entries = append(entries, entry)
+			}
+		}
+
+		It("replays the output of the processes run in the container", func() {
+			container := create()
+
+			run(container, "hello", "err:oops")
+			run(container, "again")
+
+			stream, err := container.Logs(0, false)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer stream.Close()
+
+			entries := readAll(stream)
+			Ω(entries).Should(HaveLen(3))
+
+			Ω(entries[0].ProcessID).Should(Equal(uint32(1)))
+			Ω(entries[0].Source).Should(Equal(api.LogSourceStdout))
+			Ω(string(entries[0].Data)).Should(Equal("hello"))
+
+			Ω(entries[1].ProcessID).Should(Equal(uint32(1)))
+			Ω(entries[1].Source).Should(Equal(api.LogSourceStderr))
+			Ω(string(entries[1].Data)).Should(Equal("oops"))
+
+			Ω(entries[2].ProcessID).Should(Equal(uint32(2)))
+			Ω(string(entries[2].Data)).Should(Equal("again"))
+			Ω(entries[2].Time).Should(BeTemporally("~", time.Now(), time.Minute))
+		})
+
+		It("replays only the tail asked for", func() {
+			container := create()
+
+			run(container, "one", "two", "three")
+
+			stream, err := container.Logs(2, false)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer stream.Close()
+
+			entries := readAll(stream)
+			Ω(entries).Should(HaveLen(2))
+			Ω(string(entries[0].Data)).Should(Equal("two"))
+			Ω(string(entries[1].Data)).Should(Equal("three"))
+		})
+
+		It("drops the oldest output beyond the retention", func() {
+			container := create()
+
+			run(container, "0123456789", "abcdefghij")
+
+			stream, err := container.Logs(0, false)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer stream.Close()
+
+			entries := readAll(stream)
+			Ω(entries).Should(HaveLen(1))
+			Ω(string(entries[0].Data)).Should(Equal("abcdefghij"))
+		})
+
+		Context("when following", func() {
+			It("streams output as it is written, until the container is destroyed", func() {
+				container := create()
+
+				run(container, "before")
+
+				stream, err := container.Logs(0, true)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				defer stream.Close()
+
+				entry, err := stream.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(entry.Data)).Should(Equal("before"))
+
+				run(container, "after")
+
+				entry, err = stream.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(entry.Data)).Should(Equal("after"))
+
+				err = apiClient.Destroy(container.Handle())
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = stream.Next()
+				Ω(err).Should(Equal(io.EOF))
+			})
+
+			Context("when requests are metered and audited", func() {
+				BeforeEach(func() {
+					apiServer.SetMetricsReporter(&recordingReporter{durations: map[string][]time.Duration{}, counters: map[string]int{}})
+					apiServer.SetAuditSink(server.AuditSinkFunc(func(server.AuditEvent) {}), nil)
+				})
+
+				It("still streams output as it is written", func() {
+					container := create()
+
+					run(container, "before")
+
+					// unflushed, not even the response's headers would arrive
+					entries := make(chan api.LogEntry, 1)
+					go func() {
+						defer GinkgoRecover()
+
+						stream, err := container.Logs(0, true)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer stream.Close()
+
+						entry, err := stream.Next()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						entries <- entry
+					}()
+
+					var entry api.LogEntry
+					Eventually(entries).Should(Receive(&entry))
+					Ω(string(entry.Data)).Should(Equal("before"))
+				})
+			})
+		})
+
+		Context("when retention is off", func() {
+			BeforeEach(func() {
+				apiServer.SetLogRetention(0)
+			})
+
+			It("fails with ErrUnsupportedOperation", func() {
+				container := create()
+
+				_, err := container.Logs(0, false)
+				Ω(err).Should(Equal(api.ErrUnsupportedOperation))
+			})
+		})
+	})
+
+	Describe("reconnecting to processes", func() {
+		var socketPath string
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var fakeProcess *fakes.FakeProcess
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		var exit chan struct{}
+		var runIO api.ProcessIO
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			fakeBackend.CreateReturns(fakeContainer, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			exit = make(chan struct{})
+
+			fakeProcess = new(fakes.FakeProcess)
+			fakeProcess.IDReturns(42)
+			fakeProcess.WaitStub = func() (int, error) {
+				<-exit
+				return 0, nil
+			}
+
+			fakeContainer.RunStub = func(spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+				runIO = processIO
+
+				processIO.Stdout.Write([]byte("hello"))
+				processIO.Stderr.Write([]byte("oops"))
+
+				return fakeProcess, nil
+			}
+
+			fakeContainer.AttachReturns(fakeProcess, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetLogRetention(1024)
+			apiServer.SetReconnectTokens([]byte("some-key"), time.Minute)
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		JustBeforeEach(func() {
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			select {
+			case <-exit:
+			default:
+				close(exit)
+			}
+
+			apiServer.Stop()
+		})
+
+		run := func() (api.Process, api.ReconnectSpec, bool) {
+			container, err := apiClient.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			stdout := gbytes.NewBuffer()
+			stderr := gbytes.NewBuffer()
+
+			process, err := container.Run(api.ProcessSpec{Path: "echo"}, api.ProcessIO{
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(stdout).Should(gbytes.Say("hello"))
+			Eventually(stderr).Should(gbytes.Say("oops"))
+
+			spec, ok := process.(connection.Reconnectable).ReconnectSpec()
+			return process, spec, ok
+		}
+
+		It("gives out a token with each process, with the output received so far", func() {
+			_, spec, ok := run()
+			Ω(ok).Should(BeTrue())
+
+			Ω(spec.Token).ShouldNot(BeEmpty())
+			Ω(spec.StdoutOffset).Should(Equal(uint64(len("hello"))))
+			Ω(spec.StderrOffset).Should(Equal(uint64(len("oops"))))
+		})
+
+		It("replays the output not yet received, then streams the rest until the process exits", func() {
+			process, spec, _ := run()
+
+			// as if the stream were lost after the first two bytes of stdout
+			spec.StdoutOffset = 2
+			spec.StderrOffset = 0
+
+			stdout := gbytes.NewBuffer()
+			stderr := gbytes.NewBuffer()
+
+			resumed, err := apiClient.Reconnect("some-handle", process.ID(), spec, api.ProcessIO{
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(stdout).Should(gbytes.Say("llo"))
+			Eventually(stderr).Should(gbytes.Say("oops"))
+
+			runIO.Stdout.Write([]byte("bye"))
+			close(exit)
+
+			status, err := resumed.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status).Should(Equal(0))
+
+			Ω(string(stdout.Contents())).Should(Equal("llobye"))
+			Ω(string(stderr.Contents())).Should(Equal("oops"))
+
+			resumedSpec, ok := resumed.(connection.Reconnectable).ReconnectSpec()
+			Ω(ok).Should(BeTrue())
+			Ω(resumedSpec.StdoutOffset).Should(Equal(uint64(len("hellobye"))))
+		})
+
+		It("rejects forged tokens with ErrPermissionDenied", func() {
+			process, spec, _ := run()
+
+			spec.Token = "forged." + strings.SplitN(spec.Token, ".", 2)[1]
+
+			_, err := apiClient.Reconnect("some-handle", process.ID(), spec, api.ProcessIO{})
+			Ω(err).Should(Equal(api.ErrPermissionDenied))
+
+			Ω(fakeContainer.AttachCallCount()).Should(BeZero())
+		})
+
+		It("rejects tokens for other processes with ErrPermissionDenied", func() {
+			_, spec, _ := run()
+
+			_, err := apiClient.Reconnect("some-handle", 43, spec, api.ProcessIO{})
+			Ω(err).Should(Equal(api.ErrPermissionDenied))
+		})
+
+		Context("when the output is not retained", func() {
+			BeforeEach(func() {
+				apiServer.SetLogRetention(0)
+
+				fakeContainer.AttachStub = func(processID uint32, processIO api.ProcessIO) (api.Process, error) {
+					processIO.Stdout.Write([]byte("live"))
+					return fakeProcess, nil
+				}
+			})
+
+			It("streams the output from now on", func() {
+				process, spec, _ := run()
+
+				stdout := gbytes.NewBuffer()
+
+				_, err := apiClient.Reconnect("some-handle", process.ID(), spec, api.ProcessIO{
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(stdout).Should(gbytes.Say("live"))
+			})
+		})
+
+		Context("when the server gives out no tokens", func() {
+			BeforeEach(func() {
+				apiServer.SetReconnectTokens(nil, 0)
+			})
+
+			It("gives the process none", func() {
+				_, _, ok := run()
+				Ω(ok).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("container hooks", func() {
+		type hookCall struct {
+			handle string
+			spec   api.ContainerSpec
+		}
+
+		var socketPath string
+		var fakeBackend *fakes.FakeBackend
+		var fakeSnapshotter *fakes.FakeSnapshotter
+		var apiServer *server.GardenServer
+		var apiClient client.Client
+
+		var created chan hookCall
+		var destroyed chan hookCall
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+			fakeSnapshotter = new(fakes.FakeSnapshotter)
+
+			fakeBackend.CreateStub = func(spec api.ContainerSpec) (api.Container, error) {
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns(spec.Handle)
+				return fakeContainer, nil
+			}
+
+			created = make(chan hookCall, 10)
+			destroyed = make(chan hookCall, 10)
+
+			apiServer = server.New("unix", socketPath, 0, capableBackend{fakeBackend, new(fakes.FakeRenamer), fakeSnapshotter}, logger)
+
+			apiServer.SetContainerHooks(server.ContainerHooks{
+				OnContainerCreated: func(handle string, spec api.ContainerSpec) {
+					created <- hookCall{handle, spec}
+				},
+				OnContainerDestroyed: func(handle string, spec api.ContainerSpec) {
+					destroyed <- hookCall{handle, spec}
+				},
+			})
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		JustBeforeEach(func() {
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("calls the created hook with each container created, and its spec", func() {
+			_, err := apiClient.Create(api.ContainerSpec{
+				Handle:     "some-handle",
+				Properties: api.Properties{"foo": "bar"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var call hookCall
+			Ω(created).Should(Receive(&call))
+			Ω(call.handle).Should(Equal("some-handle"))
+			Ω(call.spec.Handle).Should(Equal("some-handle"))
+			Ω(call.spec.Properties).Should(HaveKeyWithValue("foo", "bar"))
+		})
+
+		It("calls the destroyed hook with each container destroyed, and the spec it was created with", func() {
+			_, err := apiClient.Create(api.ContainerSpec{
+				Handle:     "some-handle",
+				Properties: api.Properties{"foo": "bar"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(destroyed).ShouldNot(Receive())
+
+			err = apiClient.Destroy("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var call hookCall
+			Ω(destroyed).Should(Receive(&call))
+			Ω(call.handle).Should(Equal("some-handle"))
+			Ω(call.spec.Properties).Should(HaveKeyWithValue("foo", "bar"))
+		})
+
+		It("calls the destroyed hook with only the handle of containers it did not create", func() {
+			err := apiClient.Destroy("other-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var call hookCall
+			Ω(destroyed).Should(Receive(&call))
+			Ω(call.handle).Should(Equal("other-handle"))
+			Ω(call.spec).Should(Equal(api.ContainerSpec{Handle: "other-handle"}))
+		})
+
+		Context("when a container is restored", func() {
+			BeforeEach(func() {
+				restored := new(fakes.FakeContainer)
+				restored.HandleReturns("restored-handle")
+				restored.PropertiesReturns(api.Properties{"foo": "bar"}, nil)
+
+				fakeSnapshotter.RestoreReturns(restored, nil)
+				fakeBackend.GraceTimeReturns(time.Hour)
+			})
+
+			It("calls the hooks as for created containers, with what the backend restored", func() {
+				_, err := apiClient.Restore(bytes.NewBufferString("some-snapshot"))
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var call hookCall
+				Ω(created).Should(Receive(&call))
+				Ω(call.handle).Should(Equal("restored-handle"))
+				Ω(call.spec).Should(Equal(api.ContainerSpec{
+					Handle:     "restored-handle",
+					GraceTime:  time.Hour,
+					Properties: api.Properties{"foo": "bar"},
+				}))
+
+				err = apiClient.Destroy("restored-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(destroyed).Should(Receive(&call))
+				Ω(call.spec.Properties).Should(HaveKeyWithValue("foo", "bar"))
+			})
+		})
+
+		Context("when destroying the container fails", func() {
+			BeforeEach(func() {
+				fakeBackend.DestroyReturns(errors.New("oh no!"))
+			})
+
+			It("does not call the destroyed hook", func() {
+				err := apiClient.Destroy("some-handle")
+				Ω(err).Should(HaveOccurred())
+
+				Ω(destroyed).ShouldNot(Receive())
+			})
+		})
+
+		Context("when a container's grace time runs out", func() {
+			BeforeEach(func() {
+				fakeBackend.GraceTimeReturns(100 * time.Millisecond)
+			})
+
+			It("calls the destroyed hook once it is reaped", func() {
+				_, err := apiClient.Create(api.ContainerSpec{Handle: "some-handle"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var call hookCall
+				Eventually(destroyed).Should(Receive(&call))
+				Ω(call.handle).Should(Equal("some-handle"))
+				Ω(call.spec.Handle).Should(Equal("some-handle"))
+			})
+		})
+	})
+
+	Describe("limiting streams in", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+		var conn connection.Connection
+
+		var streamedIn chan []byte
+
+		archive := func(files int) *bytes.Buffer {
+			archive := new(bytes.Buffer)
+
+			tarWriter := tar.NewWriter(archive)
+
+			for i := 0; i < files; i++ {
+				err := tarWriter.WriteHeader(&tar.Header{
+					Name:     fmt.Sprintf("some-file-%d", i),
+					Typeflag: tar.TypeReg,
+					Mode:     0644,
+					Size:     5,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = tarWriter.Write([]byte("hello"))
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			tarWriter.Close()
+
+			return archive
+		}
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			streamedIn = make(chan []byte, 1)
+
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.StreamInStub = func(spec api.StreamInSpec) error {
+				received, err := ioutil.ReadAll(spec.TarStream)
+				streamedIn <- received
+				return err
+			}
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetStreamInLimits(server.StreamInLimits{
+				MaxBytes:   uint64(archive(4).Len()),
+				MaxEntries: 4,
+			})
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			conn = connection.New("unix", socketPath)
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("streams in archives within the limits whole", func() {
+			err := conn.StreamIn("some-handle", api.StreamInSpec{
+				Path:      "/dst",
+				TarStream: archive(4),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(<-streamedIn).Should(Equal(archive(4).Bytes()))
+		})
+
+		It("aborts archives with too many entries, failing with StreamInLimitExceededError", func() {
+			err := conn.StreamIn("some-handle", api.StreamInSpec{
+				Path:      "/dst",
+				TarStream: archive(5),
+			})
+			Ω(err).Should(Equal(api.StreamInLimitExceededError{LimitInEntries: 4}))
+
+			Ω(len(<-streamedIn)).Should(BeNumerically("<", archive(5).Len()))
+		})
+
+		It("aborts archives of too many bytes, failing with StreamInLimitExceededError", func() {
+			tooBig := archive(4)
+			tooBig.Write(make([]byte, 512))
+
+			err := conn.StreamIn("some-handle", api.StreamInSpec{
+				Path:      "/dst",
+				TarStream: tooBig,
+			})
+			Ω(err).Should(Equal(api.StreamInLimitExceededError{
+				LimitInBytes: uint64(archive(4).Len()),
+			}))
+		})
+	})
+
+	Describe("limiting process stdin", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var apiServer *server.GardenServer
+
+		var stdinReceived chan string
+		var stdinErr chan error
+		var exit chan int
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			stdinReceived = make(chan string, 1)
+			stdinErr = make(chan error, 1)
+			exit = make(chan int, 1)
+
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.RunStub = func(spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+				go func() {
+					received, err := ioutil.ReadAll(processIO.Stdin)
+					stdinReceived <- string(received)
+					stdinErr <- err
+				}()
+
+				process := new(fakes.FakeProcess)
+				process.WaitStub = func() (int, error) {
+					return <-exit, nil
+				}
+
+				return process, nil
+			}
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetStdinLimit(8)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("cuts off stdin that would exceed the limit, failing the process's Wait with StdinLimitExceededError", func() {
+			conn := connection.New("unix", socketPath)
+
+			process, err := conn.Run("some-handle", api.ProcessSpec{Path: "cat"}, api.ProcessIO{
+				Stdin: io.MultiReader(strings.NewReader("hello"), strings.NewReader("world")),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(stdinReceived).Should(Receive(Equal("hello")))
+			Eventually(stdinErr).Should(Receive(Equal(api.StdinLimitExceededError{LimitInBytes: 8})))
+
+			exit <- 3
+
+			exitStatus, err := process.Wait()
+			Ω(exitStatus).Should(Equal(3))
+			Ω(err).Should(Equal(api.StdinLimitExceededError{LimitInBytes: 8}))
+		})
+
+		It("lets stdin within the limit through", func() {
+			conn := connection.New("unix", socketPath)
+
+			process, err := conn.Run("some-handle", api.ProcessSpec{Path: "cat"}, api.ProcessIO{
+				Stdin: strings.NewReader("hello"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(stdinReceived).Should(Receive(Equal("hello")))
+			Eventually(stdinErr).Should(Receive(BeNil()))
+
+			exit <- 0
+
+			Ω(process.Wait()).Should(Equal(0))
+		})
+	})
+
+	Describe("default process environment", func() {
+		var socketPath string
+
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend := new(fakes.FakeBackend)
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			process := new(fakes.FakeProcess)
+			fakeContainer.RunReturns(process, nil)
+
+			fakeBackend.CreateReturns(fakeContainer, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetDefaultProcessEnv([]string{"PATH=/bin", "FLAVOR=plain"})
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			_, _, err = connection.New("unix", socketPath).Create(api.ContainerSpec{
+				Handle: "some-handle",
+				Env:    []string{"FLAVOR=vanilla", "CONE=waffle"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("reports it beneath the container's environment", func() {
+			conn := connection.New("unix", socketPath)
+
+			Ω(conn.Env("some-handle")).Should(Equal([]string{
+				"PATH=/bin",
+				"FLAVOR=vanilla",
+				"CONE=waffle",
+			}))
+		})
+
+		It("runs processes beneath the container's environment and their own", func() {
+			conn := connection.New("unix", socketPath)
+
+			process, err := conn.Run("some-handle", api.ProcessSpec{
+				Path: "ls",
+				Env:  []string{"CONE=sugar"},
+			}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			ranSpec, _ := fakeContainer.RunArgsForCall(0)
+			Ω(ranSpec.Env).Should(Equal([]string{
+				"PATH=/bin",
+				"FLAVOR=vanilla",
+				"CONE=sugar",
+			}))
+		})
+	})
+
+	Describe("limiting processes per container", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+
+		var runningIDs []uint32
+		var running sync.Mutex
+
+		var exit chan struct{}
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			runningIDs = []uint32{}
+			exit = make(chan struct{})
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.InfoFieldsStub = func([]api.InfoField) (api.ContainerInfo, error) {
+				running.Lock()
+				defer running.Unlock()
+
+				return api.ContainerInfo{ProcessIDs: runningIDs}, nil
+			}
+
+			// processes may outlive the test, so hold on to its channel
+			exited := exit
+
+			fakeContainer.RunStub = func(api.ProcessSpec, api.ProcessIO) (api.Process, error) {
+				process := new(fakes.FakeProcess)
+				process.IDReturns(42)
+				process.WaitStub = func() (int, error) {
+					<-exited
+					return 0, nil
+				}
+
+				return process, nil
+			}
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetMaxProcessesPerContainer(2)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			close(exit)
+			apiServer.Stop()
+		})
+
+		It("runs processes while the container runs fewer than the limit", func() {
+			running.Lock()
+			runningIDs = []uint32{1}
+			running.Unlock()
+
+			_, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeContainer.RunCallCount()).Should(Equal(1))
+		})
+
+		It("fails to run processes once the container runs as many as the limit, without running them", func() {
+			running.Lock()
+			runningIDs = []uint32{1, 2}
+			running.Unlock()
+
+			_, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+			Ω(err).Should(Equal(api.ErrProcessLimitExceeded))
+
+			Ω(fakeContainer.RunCallCount()).Should(BeZero())
+		})
+
+		It("counts processes which are still starting", func() {
+			running.Lock()
+			runningIDs = []uint32{1}
+			running.Unlock()
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			defer close(release)
+
+			var runs int32
+
+			runStub := fakeContainer.RunStub
+			fakeContainer.RunStub = func(spec api.ProcessSpec, processIO api.ProcessIO) (api.Process, error) {
+				// only the first process is slow to start
+				if atomic.AddInt32(&runs, 1) == 1 {
+					close(started)
+					<-release
+				}
+
+				return runStub(spec, processIO)
+			}
+
+			go connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+
+			<-started
+
+			_, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+			Ω(err).Should(Equal(api.ErrProcessLimitExceeded))
+		})
+
+		It("does not hold up processes started in other containers while asking the backend", func() {
+			asked := make(chan struct{})
+			release := make(chan struct{})
+			defer close(release)
+
+			slowContainer := new(fakes.FakeContainer)
+			slowContainer.HandleReturns("slow-handle")
+			slowContainer.InfoFieldsStub = func([]api.InfoField) (api.ContainerInfo, error) {
+				close(asked)
+				<-release
+				return api.ContainerInfo{}, nil
+			}
+
+			fakeBackend.LookupStub = func(handle string) (api.Container, error) {
+				if handle == "slow-handle" {
+					return slowContainer, nil
+				}
+
+				return fakeContainer, nil
+			}
+
+			go connection.New("unix", socketPath).Run("slow-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+
+			<-asked
+
+			_, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "ls"}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeContainer.RunCallCount()).Should(Equal(1))
+		})
+	})
+
+	Describe("strict requests", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+
+		sendRequest := func(method, path, body string) (*http.Response, apitypes.ErrorResponse) {
+			conn, err := net.Dial("unix", socketPath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer conn.Close()
+
+			request, err := http.NewRequest(method, "http://api"+path, bytes.NewBufferString(body))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			request.Header.Set("Content-Type", "application/json")
+
+			err = request.Write(conn)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			response, err := http.ReadResponse(bufio.NewReader(conn), request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var errResponse apitypes.ErrorResponse
+			json.NewDecoder(response.Body).Decode(&errResponse)
+
+			return response, errResponse
+		}
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			fakeBackend.CreateReturns(fakeContainer, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetStrictRequests(true)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("rejects requests with fields the server does not know, with a 422 naming each", func() {
+			response, errResponse := sendRequest(
+				"PUT",
+				"/containers/some-handle/limits/memory",
+				`{"handle":"some-handle","limit_in_bytes":1024,"swap_limit_in_bytes":2048,"oom_score":1}`,
+			)
+
+			Ω(response.StatusCode).Should(Equal(transport.StatusUnprocessableEntity))
+			Ω(errResponse.GetType()).Should(Equal(apitypes.ErrorTypeInvalidRequest))
+			Ω(errResponse.GetViolations()).Should(Equal([]*apitypes.ErrorResponse_Validation{
+				{
+					Field:  apitypes.String("oom_score"),
+					Reason: apitypes.String("is not known to this server"),
+				},
+				{
+					Field:  apitypes.String("swap_limit_in_bytes"),
+					Reason: apitypes.String("is not known to this server"),
+				},
+			}))
+
+			Ω(fakeContainer.LimitMemoryCallCount()).Should(BeZero())
+		})
+
+		It("names unknown fields of nested messages by their path", func() {
+			response, errResponse := sendRequest(
+				"POST",
+				"/containers",
+				`{"handle":"some-handle","bind_mounts":[{"src_path":"/a","dst_path":"/b","propagation":"shared"}]}`,
+			)
+
+			Ω(response.StatusCode).Should(Equal(transport.StatusUnprocessableEntity))
+			Ω(errResponse.GetViolations()).Should(HaveLen(1))
+			Ω(errResponse.GetViolations()[0].GetField()).Should(Equal("bind_mounts.propagation"))
+
+			Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+		})
+
+		It("accepts the requests the client sends", func() {
+			apiClient := client.New(connection.New("unix", socketPath))
+
+			container, err := apiClient.Create(api.ContainerSpec{
+				Handle: "some-handle",
+				BindMounts: []api.BindMount{
+					{SrcPath: "/a", DstPath: "/b", Mode: api.BindMountModeRW},
+				},
+				Properties: api.Properties{"owner": "some-team"},
+				Env:        []string{"FLAVOR=vanilla"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.LimitMemory(api.MemoryLimits{LimitInBytes: 1024})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeContainer.LimitMemoryCallCount()).Should(Equal(1))
+		})
+	})
+
+	Describe("checking process users", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+		var checkedSpecs chan api.ProcessSpec
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.RunReturns(new(fakes.FakeProcess), nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			checkedSpecs = make(chan api.ProcessSpec, 10)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			apiServer.SetUserChecker(server.UserCheckerFunc(func(container api.Container, spec api.ProcessSpec) error {
+				checkedSpecs <- spec
+
+				if spec.User == "nobody-here" {
+					return api.ErrUserNotFound
+				}
+
+				return nil
+			}))
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		Context("when the user exists", func() {
+			It("runs the process", func() {
+				conn := connection.New("unix", socketPath)
+
+				_, err := conn.Run("some-handle", api.ProcessSpec{Path: "some-path", User: "some-user"}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(fakeContainer.RunCallCount).Should(Equal(1))
+
+				var spec api.ProcessSpec
+				Eventually(checkedSpecs).Should(Receive(&spec))
+				Ω(spec.User).Should(Equal("some-user"))
+			})
+		})
+
+		Context("when the user does not exist", func() {
+			It("fails with ErrUserNotFound without running the process", func() {
+				conn := connection.New("unix", socketPath)
+
+				_, err := conn.Run("some-handle", api.ProcessSpec{Path: "some-path", User: "nobody-here"}, api.ProcessIO{})
+				Ω(err).Should(Equal(api.ErrUserNotFound))
+
+				Ω(fakeContainer.RunCallCount()).Should(BeZero())
+			})
+		})
+	})
+
+	Describe("maximum grace time", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+		var policy server.GraceTimePolicy
+
+		var warnings chan string
+		var conn connection.Connection
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeBackend.CreateReturns(fakeContainer, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			policy = server.GraceTimeClamp
+
+			warnings = make(chan string, 10)
+			conn = connection.NewWithConfig("unix", socketPath, connection.Config{
+				OnWarning: func(warning string) {
+					warnings <- warning
+				},
+			})
+		})
+
+		JustBeforeEach(func() {
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetMaxGraceTime(time.Hour, policy)
+
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("gives containers grace times up to the maximum, without warning", func() {
+			_, _, err := conn.Create(api.ContainerSpec{GraceTime: time.Hour})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeBackend.CreateArgsForCall(0).GraceTime).Should(Equal(time.Hour))
+			Ω(warnings).ShouldNot(Receive())
+		})
+
+		It("gives containers created without a grace time the server's default", func() {
+			_, _, err := conn.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeBackend.CreateArgsForCall(0).GraceTime).Should(BeZero())
+			Ω(warnings).ShouldNot(Receive())
+		})
+
+		Context("when clamping", func() {
+			It("gives containers created with longer grace times the maximum, with a warning", func() {
+				_, _, err := conn.Create(api.ContainerSpec{GraceTime: 2 * time.Hour})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeBackend.CreateArgsForCall(0).GraceTime).Should(Equal(time.Hour))
+				Ω(warnings).Should(Receive(ContainSubstring("clamped")))
+			})
+
+			It("clamps grace times which never expire", func() {
+				err := conn.SetGraceTime("some-handle", 0)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.SetGraceTimeArgsForCall(0)).Should(Equal(time.Hour))
+				Ω(warnings).Should(Receive(ContainSubstring("clamped")))
+			})
+		})
+
+		Context("when rejecting", func() {
+			BeforeEach(func() {
+				policy = server.GraceTimeReject
+			})
 
-		fakeBackend := new(fakes.FakeBackend)
+			It("fails to create containers with longer grace times", func() {
+				_, _, err := conn.Create(api.ContainerSpec{GraceTime: 2 * time.Hour})
+				Ω(err).Should(Equal(api.ValidationError{
+					Field:  api.SpecFieldGraceTime,
+					Reason: "must be at most 1h0m0s",
+				}))
 
-		apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+				Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+			})
 
-		err = apiServer.Start()
-		Ω(err).ShouldNot(HaveOccurred())
+			It("fails to give containers longer grace times", func() {
+				err := conn.SetGraceTime("some-handle", 0)
+				Ω(err).Should(BeAssignableToTypeOf(api.ValidationError{}))
 
-		Ω(fakeBackend.StartCallCount()).Should(Equal(1))
+				Ω(fakeContainer.SetGraceTimeCallCount()).Should(BeZero())
+			})
+		})
 	})
 
-	It("destroys containers that have been idle for their grace time", func() {
-		var err error
-		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
-		Ω(err).ShouldNot(HaveOccurred())
+	Describe("privilege policies", func() {
+		var socketPath string
 
-		socketPath := path.Join(tmpdir, "api.sock")
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+		var policy server.PrivilegePolicy
 
-		fakeBackend := new(fakes.FakeBackend)
+		var admin, user connection.Connection
 
-		doomedContainer := new(fakes.FakeContainer)
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
 
-		fakeBackend.ContainersReturns([]api.Container{doomedContainer}, nil)
-		fakeBackend.GraceTimeReturns(100 * time.Millisecond)
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
 
-		apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.RunReturns(new(fakes.FakeProcess), nil)
+			fakeBackend.CreateReturns(fakeContainer, nil)
+			fakeBackend.LookupReturns(fakeContainer, nil)
 
-		before := time.Now()
+			policy = callerPrivilegePolicy{admin: "some-admin"}
 
-		err = apiServer.Start()
-		Ω(err).ShouldNot(HaveOccurred())
+			admin = connection.NewWithHeader("unix", socketPath, http.Header{"X-Caller": []string{"some-admin"}})
+			user = connection.NewWithHeader("unix", socketPath, http.Header{"X-Caller": []string{"some-user"}})
+		})
 
-		Ω(fakeBackend.DestroyCallCount()).Should(Equal(0))
-		Eventually(fakeBackend.DestroyCallCount).Should(Equal(1))
+		JustBeforeEach(func() {
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetPrivilegePolicy(policy)
 
-		Ω(time.Since(before)).Should(BeNumerically(">", 100*time.Millisecond))
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			apiServer.Stop()
+		})
+
+		It("creates privileged containers for callers the policy allows", func() {
+			_, _, err := admin.Create(api.ContainerSpec{Privileged: true})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeBackend.CreateCallCount()).Should(Equal(1))
+		})
+
+		It("refuses privileged containers to callers the policy denies, without creating them", func() {
+			_, _, err := user.Create(api.ContainerSpec{Privileged: true})
+			Ω(err).Should(Equal(api.ErrPermissionDenied))
+
+			Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+		})
+
+		It("creates unprivileged containers for any caller", func() {
+			_, _, err := user.Create(api.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeBackend.CreateCallCount()).Should(Equal(1))
+		})
+
+		It("refuses privileged processes to callers the policy denies, without running them", func() {
+			_, err := user.Run("some-handle", api.ProcessSpec{Path: "some-path", Privileged: true}, api.ProcessIO{})
+			Ω(err).Should(Equal(api.ErrPermissionDenied))
+
+			_, err = user.Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = admin.Run("some-handle", api.ProcessSpec{Path: "some-path", Privileged: true}, api.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(fakeContainer.RunCallCount).Should(Equal(2))
+			Consistently(fakeContainer.RunCallCount).Should(Equal(2))
+		})
+
+		Context("when denying all privileges", func() {
+			BeforeEach(func() {
+				policy = server.DenyPrivileged
+			})
+
+			It("refuses privileged containers and processes to every caller", func() {
+				_, _, err := admin.Create(api.ContainerSpec{Privileged: true})
+				Ω(err).Should(Equal(api.ErrPermissionDenied))
+
+				_, err = admin.Run("some-handle", api.ProcessSpec{Path: "some-path", Privileged: true}, api.ProcessIO{})
+				Ω(err).Should(Equal(api.ErrPermissionDenied))
+
+				Ω(fakeBackend.CreateCallCount()).Should(BeZero())
+				Ω(fakeContainer.RunCallCount()).Should(BeZero())
+			})
+		})
 	})
 
-	Context("when starting the backend fails", func() {
-		disaster := errors.New("oh no!")
+	Describe("worker pools", func() {
+		var socketPath string
 
-		It("fails to start", func() {
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var apiServer *server.GardenServer
+		var apiClient api.Client
+
+		var streaming chan struct{}
+		var finishStreaming chan struct{}
+
+		BeforeEach(func() {
 			var err error
 			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
 			Ω(err).ShouldNot(HaveOccurred())
 
-			socketPath := path.Join(tmpdir, "api.sock")
+			socketPath = path.Join(tmpdir, "api.sock")
+			fakeBackend = new(fakes.FakeBackend)
 
-			fakeBackend := new(fakes.FakeBackend)
-			fakeBackend.StartReturns(disaster)
+			streaming = make(chan struct{}, 2)
+			finishStreaming = make(chan struct{})
 
-			apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.StreamInStub = func(api.StreamInSpec) error {
+				streaming <- struct{}{}
+				<-finishStreaming
+				return nil
+			}
+
+			fakeBackend.LookupReturns(fakeContainer, nil)
+			fakeBackend.ContainersReturns([]api.Container{fakeContainer}, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+			apiServer.SetWorkerPools(2, 1)
 
 			err = apiServer.Start()
-			Ω(err).Should(Equal(disaster))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.New("unix", socketPath))
 		})
-	})
 
-	Context("when listening on the socket fails", func() {
-		It("fails to start", func() {
-			tmpfile, err := ioutil.TempFile(os.TempDir(), "api-server-test")
+		AfterEach(func() {
+			close(finishStreaming)
+			apiServer.Stop()
+		})
+
+		streamIn := func() {
+			container, err := apiClient.Lookup("some-handle")
 			Ω(err).ShouldNot(HaveOccurred())
 
-			apiServer := server.New(
-				"unix",
-				// weird scenario: /foo/X/api.sock with X being a file
-				path.Join(tmpfile.Name(), "api.sock"),
-				0,
-				new(fakes.FakeBackend),
-				logger,
-			)
+			go container.StreamIn(api.StreamInSpec{
+				Path:      "/some/path",
+				TarStream: strings.NewReader("some-tar"),
+			})
+		}
 
-			err = apiServer.Start()
-			Ω(err).Should(HaveOccurred())
+		It("queues streaming requests beyond the streaming pool's size", func() {
+			streamIn()
+			Eventually(streaming).Should(Receive())
+
+			streamIn()
+			Eventually(func() int {
+				return apiServer.WorkerPoolStats()[server.StreamingPool].Queued
+			}).Should(Equal(1))
+
+			Consistently(streaming).ShouldNot(Receive())
+
+			Ω(apiServer.WorkerPoolStats()[server.StreamingPool]).Should(Equal(server.WorkerPoolStats{
+				Size:   1,
+				Active: 1,
+				Queued: 1,
+			}))
+		})
+
+		It("serves control requests while the streaming pool is full", func() {
+			streamIn()
+			streamIn()
+
+			Eventually(func() int {
+				return apiServer.WorkerPoolStats()[server.StreamingPool].Queued
+			}).Should(Equal(1))
+
+			err := apiClient.Ping()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(apiServer.WorkerPoolStats()[server.ControlPool]).Should(Equal(server.WorkerPoolStats{
+				Size:   2,
+				Active: 0,
+				Queued: 0,
+			}))
 		})
 	})
 
@@ -312,4 +3535,300 @@ var _ = Describe("The Garden server", func() {
 			})
 		})
 	})
+	Describe("handing off to a new server", func() {
+		var socketPath string
+		var handOffPath string
+
+		var oldBackend *fakes.FakeBackend
+		var newBackend *fakes.FakeBackend
+
+		var oldServer *server.GardenServer
+		var newServer *server.GardenServer
+
+		var takenOver chan error
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+			handOffPath = path.Join(tmpdir, "handoff.sock")
+
+			oldBackend = new(fakes.FakeBackend)
+			newBackend = new(fakes.FakeBackend)
+
+			oldServer = server.New("unix", socketPath, 0, oldBackend, logger)
+			newServer = server.New("unix", socketPath, 0, newBackend, logger)
+
+			takenOver = make(chan error, 1)
+		})
+
+		JustBeforeEach(func() {
+			err := oldServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+			go func() {
+				takenOver <- newServer.StartFromHandOff(handOffPath)
+			}()
+
+			Eventually(ErrorDialing("unix", handOffPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			oldServer.Stop()
+			newServer.Stop()
+		})
+
+		It("serves requests on the same socket with the new server", func() {
+			Ω(oldServer.HandOff(handOffPath)).Should(Succeed())
+			Eventually(takenOver).Should(Receive(BeNil()))
+
+			Ω(connection.New("unix", socketPath).Ping()).Should(Succeed())
+
+			Ω(newBackend.PingCallCount()).Should(Equal(1))
+			Ω(oldBackend.PingCallCount()).Should(Equal(0))
+		})
+
+		It("leaves the socket accepting connections once handed off", func() {
+			Ω(oldServer.HandOff(handOffPath)).Should(Succeed())
+
+			_, err := os.Stat(socketPath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(takenOver).Should(Receive(BeNil()))
+
+			for i := 0; i < 3; i++ {
+				Ω(connection.New("unix", socketPath).Ping()).Should(Succeed())
+			}
+
+			Ω(newBackend.PingCallCount()).Should(Equal(3))
+			Ω(oldBackend.PingCallCount()).Should(Equal(0))
+		})
+
+		It("starts the new server's backend, without stopping the old one's", func() {
+			Ω(oldServer.HandOff(handOffPath)).Should(Succeed())
+			Eventually(takenOver).Should(Receive(BeNil()))
+
+			Ω(newBackend.StartCallCount()).Should(Equal(1))
+			Ω(oldBackend.StopCallCount()).Should(Equal(0))
+		})
+
+		Context("when a process stream is open on the old server", func() {
+			var exit chan int
+
+			BeforeEach(func() {
+				exit = make(chan int, 1)
+
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.RunStub = func(spec api.ProcessSpec, io api.ProcessIO) (api.Process, error) {
+					io.Stdout.Write([]byte("hello"))
+
+					process := new(fakes.FakeProcess)
+					process.WaitStub = func() (int, error) {
+						return <-exit, nil
+					}
+
+					return process, nil
+				}
+
+				oldBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			It("lets it run to the end, while the new server serves requests", func() {
+				stdout := gbytes.NewBuffer()
+
+				process, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{Path: "some-path"}, api.ProcessIO{
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(stdout).Should(gbytes.Say("hello"))
+
+				handedOff := make(chan error, 1)
+				go func() {
+					handedOff <- oldServer.HandOff(handOffPath)
+				}()
+
+				Eventually(takenOver).Should(Receive(BeNil()))
+
+				Ω(connection.New("unix", socketPath).Ping()).Should(Succeed())
+				Ω(newBackend.PingCallCount()).Should(Equal(1))
+
+				Consistently(handedOff).ShouldNot(Receive())
+
+				exit <- 42
+
+				Ω(process.Wait()).Should(Equal(42))
+
+				Eventually(handedOff).Should(Receive(BeNil()))
+			})
+		})
+
+		Context("when containers are held or scheduled to be destroyed", func() {
+			var held, doomed *fakes.FakeContainer
+
+			BeforeEach(func() {
+				held = new(fakes.FakeContainer)
+				held.HandleReturns("held")
+
+				doomed = new(fakes.FakeContainer)
+				doomed.HandleReturns("doomed")
+
+				for _, backend := range []*fakes.FakeBackend{oldBackend, newBackend} {
+					backend.ContainersReturns([]api.Container{held, doomed}, nil)
+					backend.GraceTimeStub = func(container api.Container) time.Duration {
+						if container.Handle() == "held" {
+							return 100 * time.Millisecond
+						}
+
+						return 0
+					}
+				}
+
+				oldBackend.LookupStub = func(handle string) (api.Container, error) {
+					if handle == "held" {
+						return held, nil
+					}
+
+					return doomed, nil
+				}
+			})
+
+			JustBeforeEach(func() {
+				apiConnection := connection.New("unix", socketPath)
+
+				Ω(apiConnection.SetHold("held", true)).Should(Succeed())
+				Ω(apiConnection.DestroyAfter("doomed", time.Second)).Should(Succeed())
+			})
+
+			It("keeps holding them, and destroys them when scheduled, with the new server", func() {
+				Ω(oldServer.HandOff(handOffPath)).Should(Succeed())
+				Eventually(takenOver).Should(Receive(BeNil()))
+
+				Eventually(newBackend.DestroyCallCount, 2*time.Second).Should(Equal(1))
+				Ω(newBackend.DestroyArgsForCall(0)).Should(Equal("doomed"))
+
+				Consistently(newBackend.DestroyCallCount).Should(Equal(1))
+				Ω(oldBackend.DestroyCallCount()).Should(Equal(0))
+			})
+		})
+
+		Context("when a container was created with process defaults", func() {
+			var fakeContainer *fakes.FakeContainer
+
+			BeforeEach(func() {
+				fakeContainer = new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+				fakeContainer.RunReturns(new(fakes.FakeProcess), nil)
+
+				oldBackend.CreateReturns(fakeContainer, nil)
+
+				newBackend.ContainersReturns([]api.Container{fakeContainer}, nil)
+				newBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			JustBeforeEach(func() {
+				_, _, err := connection.New("unix", socketPath).Create(api.ContainerSpec{
+					Handle:             "some-handle",
+					Env:                []string{"FLAVOR=vanilla"},
+					DefaultProcessUser: "default-user",
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("runs the container's processes with them on the new server", func() {
+				Ω(oldServer.HandOff(handOffPath)).Should(Succeed())
+				Eventually(takenOver).Should(Receive(BeNil()))
+
+				_, err := connection.New("unix", socketPath).Run("some-handle", api.ProcessSpec{
+					Path: "ls",
+				}, api.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(fakeContainer.RunCallCount).Should(Equal(1))
+
+				ranSpec, _ := fakeContainer.RunArgsForCall(0)
+				Ω(ranSpec.User).Should(Equal("default-user"))
+				Ω(ranSpec.Env).Should(Equal([]string{"FLAVOR=vanilla"}))
+			})
+		})
+	})
 })
+
+type recordingAuditSink struct {
+	events []server.AuditEvent
+	sync.Mutex
+}
+
+func (s *recordingAuditSink) Record(event server.AuditEvent) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.events = append(s.events, event)
+}
+
+func (s *recordingAuditSink) recorded() []server.AuditEvent {
+	s.Lock()
+	defer s.Unlock()
+
+	return append([]server.AuditEvent{}, s.events...)
+}
+
+type recordingReporter struct {
+	counters  map[string]int
+	durations map[string][]time.Duration
+	sync.Mutex
+}
+
+func (r *recordingReporter) IncrementCounter(name string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.counters[name]++
+}
+
+func (r *recordingReporter) SendDuration(name string, duration time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.durations[name] = append(r.durations[name], duration)
+}
+
+func (r *recordingReporter) counter(name string) int {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.counters[name]
+}
+
+func (r *recordingReporter) durationsOf(name string) []time.Duration {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]time.Duration{}, r.durations[name]...)
+}
+
+// callerPrivilegePolicy allows privileges only to the caller named by the
+// X-Caller header as admin
+type callerPrivilegePolicy struct {
+	admin string
+}
+
+func (p callerPrivilegePolicy) AllowPrivilegedContainer(request *http.Request, spec api.ContainerSpec) error {
+	return p.allow(request)
+}
+
+func (p callerPrivilegePolicy) AllowPrivilegedProcess(request *http.Request, container api.Container, spec api.ProcessSpec) error {
+	return p.allow(request)
+}
+
+func (p callerPrivilegePolicy) allow(request *http.Request) error {
+	if request.Header.Get("X-Caller") != p.admin {
+		return api.ErrPermissionDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServerTimingHeader is the header of responses breaking down how long the
+// server took to produce them, when it is configured to with
+// SetServerTiming.
+const ServerTimingHeader = "Server-Timing"
+
+// SetServerTiming has every response report how long the server took to
+// decode the request, to handle it with the backend, and to encode the
+// response, in a Server-Timing header, e.g.
+//
+//	Server-Timing: decode;dur=0.05, backend;dur=12.30, encode;dur=0.02
+//
+// with each duration in milliseconds. The backend's time runs from the
+// request being decoded to its response beginning, so includes the little
+// the server does besides; streams of files and processes which follow are
+// not timed. It must be called before Start.
+func (s *GardenServer) SetServerTiming(enabled bool) {
+	s.serverTiming = enabled
+}
+
+func (s *GardenServer) timed(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.serverTiming {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		handler.ServeHTTP(&serverTiming{
+			ResponseWriter: w,
+			started:        time.Now(),
+		}, r)
+	})
+}
+
+// serverTiming times the phases of handling a request, reporting them as
+// its response begins.
+type serverTiming struct {
+	http.ResponseWriter
+
+	started time.Time
+
+	decodedAt time.Time
+	decode    time.Duration
+
+	encodedAt time.Time
+	encode    time.Duration
+
+	wroteHeader bool
+}
+
+// decoded records that the request, whose decoding began at the time, has
+// been decoded.
+func (t *serverTiming) decoded(began time.Time) {
+	t.decodedAt = time.Now()
+	t.decode = t.decodedAt.Sub(began)
+}
+
+// encoded records that the response, whose encoding began at the time, has
+// been encoded, ending the backend's handling of the request.
+func (t *serverTiming) encoded(began time.Time) {
+	t.encodedAt = began
+	t.encode = time.Since(began)
+}
+
+func (t *serverTiming) WriteHeader(status int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		t.Header().Set(ServerTimingHeader, t.String())
+	}
+
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *serverTiming) Write(data []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+
+	return t.ResponseWriter.Write(data)
+}
+
+// Flush sends what has been written of a streamed response, e.g. a
+// followed container's logs.
+func (t *serverTiming) Flush() {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (t *serverTiming) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return t.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (t *serverTiming) CloseNotify() <-chan bool {
+	return closeNotify(t.ResponseWriter)
+}
+
+func (t *serverTiming) String() string {
+	handled := t.started
+	if !t.decodedAt.IsZero() {
+		handled = t.decodedAt
+	}
+
+	responded := t.encodedAt
+	if responded.IsZero() {
+		responded = time.Now()
+	}
+
+	metrics := []string{}
+
+	if !t.decodedAt.IsZero() {
+		metrics = append(metrics, timingMetric("decode", t.decode))
+	}
+
+	metrics = append(metrics, timingMetric("backend", responded.Sub(handled)))
+
+	if !t.encodedAt.IsZero() {
+		metrics = append(metrics, timingMetric("encode", t.encode))
+	}
+
+	return strings.Join(metrics, ", ")
+}
+
+func timingMetric(name string, duration time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.2f", name, float64(duration)/float64(time.Millisecond))
+}
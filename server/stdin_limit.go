@@ -0,0 +1,196 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/transport"
+	"github.com/pivotal-golang/lager"
+)
+
+// stdinAccounting counts the bytes written to each process's stdin, over all
+// of the streams attached to it, so that they can be limited. A process is
+// counted from when a stream is first attached to it until the last detaches.
+// rename expects the container state lock to be held.
+type stdinAccounting struct {
+	// limit is the most bytes each process's stdin may be written, or 0 for
+	// no limit
+	limit uint64
+
+	// accounts are keyed by handle and process ID
+	accounts map[string]*stdinAccount
+	mu       *sync.Mutex
+}
+
+type stdinAccount struct {
+	key     string
+	written uint64
+	streams int
+}
+
+func newStdinAccounting(mu *sync.Mutex) *stdinAccounting {
+	return &stdinAccounting{
+		accounts: map[string]*stdinAccount{},
+		mu:       mu,
+	}
+}
+
+func (a *stdinAccounting) setLimit(limit uint64) {
+	a.mu.Lock()
+	a.limit = limit
+	a.mu.Unlock()
+}
+
+func (a *stdinAccounting) getLimit() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.limit
+}
+
+// snapshot returns the bytes written to the stdin of each process which has
+// streams attached, by handle and process ID.
+func (a *stdinAccounting) snapshot() map[string]uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	written := map[string]uint64{}
+	for key, account := range a.accounts {
+		written[key] = account.written
+	}
+
+	return written
+}
+
+// inherit counts the bytes as already written to the processes' stdin, e.g.
+// by another server whose streams are still attached to them. Each process's
+// count is kept until a stream attaches to it here and then detaches.
+func (a *stdinAccounting) inherit(written map[string]uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, bytes := range written {
+		a.accounts[key] = &stdinAccount{key: key, written: bytes}
+	}
+}
+
+// rename moves the accounts of the container's processes to its new handle,
+// so that streams attached under it are counted with those attached before.
+func (a *stdinAccounting) rename(handle, newHandle string) {
+	prefix := handle + "/"
+
+	for key, account := range a.accounts {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		account.key = newHandle + "/" + strings.TrimPrefix(key, prefix)
+		a.accounts[account.key] = account
+		delete(a.accounts, key)
+	}
+}
+
+// meter returns the meter for a stream attached to the process, which must be
+// released once the stream ends.
+func (a *stdinAccounting) meter(handle string, processID uint32) *stdinMeter {
+	key := fmt.Sprintf("%s/%d", handle, processID)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	account, found := a.accounts[key]
+	if !found {
+		account = &stdinAccount{key: key}
+		a.accounts[key] = account
+	}
+
+	account.streams++
+
+	return &stdinMeter{
+		accounting: a,
+		account:    account,
+	}
+}
+
+// stdinMeter counts the stdin a stream writes to its process.
+type stdinMeter struct {
+	accounting *stdinAccounting
+	account    *stdinAccount
+
+	// exceeded is set once the stream's stdin has been cut off
+	exceeded bool
+}
+
+// write writes the data to the process's stdin, unless that would exceed the
+// limit, in which case it cuts off the stdin and tells the client why. Once
+// cut off, stdin is discarded.
+func (m *stdinMeter) write(in *io.PipeWriter, out transport.MessageWriter, processID uint32, data []byte) error {
+	if m.exceeded {
+		return nil
+	}
+
+	a := m.accounting
+
+	a.mu.Lock()
+
+	if a.limit != 0 && m.account.written+uint64(len(data)) > a.limit {
+		a.mu.Unlock()
+
+		m.exceeded = true
+
+		err := api.StdinLimitExceededError{LimitInBytes: a.limit}
+
+		in.CloseWithError(err)
+
+		res, _ := errorResponse(err, nil)
+
+		return out.WriteMessage(&apitypes.ProcessPayload{
+			ProcessId:  apitypes.Uint32(processID),
+			StdinError: res,
+		})
+	}
+
+	m.account.written += uint64(len(data))
+
+	a.mu.Unlock()
+
+	_, err := in.Write(data)
+	if err != nil {
+		return err
+	}
+
+	ackStdin(out, processID)
+
+	return nil
+}
+
+func (m *stdinMeter) written() uint64 {
+	m.accounting.mu.Lock()
+	defer m.accounting.mu.Unlock()
+
+	return m.account.written
+}
+
+// release detaches the stream from the process, logging how much stdin the
+// process has been written.
+func (m *stdinMeter) release(logger lager.Logger, processID uint32) {
+	logger.Debug("stdin-written", lager.Data{
+		"id":    processID,
+		"bytes": m.written(),
+	})
+
+	a := m.accounting
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m.account.streams--
+
+	if m.account.streams == 0 && a.accounts[m.account.key] == m.account {
+		delete(a.accounts, m.account.key)
+	}
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/pivotal-golang/lager"
+)
+
+// StopReasonProperty is the property in which the server records why a
+// container was last stopped, if the client said.
+const StopReasonProperty = "garden.stop-reason"
+
+// recordStopReason keeps the reason with the container. The container is
+// stopped either way, so failing to record it is only logged.
+func recordStopReason(container api.Container, reason string, logger lager.Logger) {
+	err := container.SetProperty(StopReasonProperty, reason)
+	if err != nil {
+		logger.Error("failed-to-record-reason", err)
+	}
+}
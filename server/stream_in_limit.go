@@ -0,0 +1,153 @@
+package server
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// StreamInLimits bound each stream of files in to a container, before any
+// disk quota the container has applies. Zero limits are unlimited.
+type StreamInLimits struct {
+	// MaxBytes bounds the bytes of each stream's tar archive, once any
+	// compression is undone.
+	MaxBytes uint64
+
+	// MaxEntries bounds the files, directories, links and so on in each
+	// stream's tar archive.
+	MaxEntries uint64
+}
+
+// SetStreamInLimits bounds each stream of files in to a container. Streams
+// exceeding a limit are aborted, and fail with
+// api.StreamInLimitExceededError. It must be called before Start.
+func (s *GardenServer) SetStreamInLimits(limits StreamInLimits) {
+	s.streamInLimits = limits
+}
+
+// limitedStreamIn is a tar stream which fails once it exceeds the limits,
+// remembering that it did, so that the client is told why the stream was
+// aborted whatever error the backend makes of it.
+type limitedStreamIn struct {
+	io.Reader
+
+	// closed stops the archive being read into a pipe nobody reads
+	closed func()
+
+	exceeded error
+	mu       sync.Mutex
+}
+
+func limitStreamIn(tarStream io.Reader, limits StreamInLimits) *limitedStreamIn {
+	limited := &limitedStreamIn{
+		Reader: tarStream,
+		closed: func() {},
+	}
+
+	if limits.MaxBytes != 0 {
+		limited.Reader = &byteLimitedReader{
+			Reader:  limited.Reader,
+			limited: limited,
+			limit:   limits.MaxBytes,
+		}
+	}
+
+	if limits.MaxEntries != 0 {
+		archive := limited.Reader
+
+		pipeR, pipeW := io.Pipe()
+
+		limited.Reader = pipeR
+		limited.closed = func() { pipeR.Close() }
+
+		go limited.countEntries(archive, pipeW, limits.MaxEntries)
+	}
+
+	return limited
+}
+
+// countEntries copies the archive into the pipe as its entries are read,
+// failing the pipe once there are more than the limit.
+func (l *limitedStreamIn) countEntries(archive io.Reader, pipeW *io.PipeWriter, limit uint64) {
+	tarReader := tar.NewReader(io.TeeReader(archive, pipeW))
+
+	var entries uint64
+
+	for {
+		_, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			pipeW.CloseWithError(err)
+			return
+		}
+
+		entries++
+
+		if entries > limit {
+			pipeW.CloseWithError(l.exceed(api.StreamInLimitExceededError{LimitInEntries: limit}))
+			return
+		}
+
+		_, err = io.Copy(ioutil.Discard, tarReader)
+		if err != nil {
+			pipeW.CloseWithError(err)
+			return
+		}
+	}
+
+	// copy any trailing padding, so the backend is given the whole archive
+	_, err := io.Copy(pipeW, archive)
+	pipeW.CloseWithError(err)
+}
+
+func (l *limitedStreamIn) exceed(err error) error {
+	l.mu.Lock()
+	l.exceeded = err
+	l.mu.Unlock()
+
+	return err
+}
+
+// exceededLimit returns the error the stream failed with for exceeding a
+// limit, if it did.
+func (l *limitedStreamIn) exceededLimit() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.exceeded
+}
+
+func (l *limitedStreamIn) Close() error {
+	l.closed()
+	return nil
+}
+
+type byteLimitedReader struct {
+	io.Reader
+
+	limited *limitedStreamIn
+	limit   uint64
+	read    uint64
+}
+
+func (r *byteLimitedReader) Read(p []byte) (int, error) {
+	if remaining := r.limit - r.read; uint64(len(p)) > remaining+1 {
+		// read a byte past the limit, to tell if the stream exceeds it
+		p = p[:remaining+1]
+	}
+
+	n, err := r.Reader.Read(p)
+	r.read += uint64(n)
+
+	if r.read > r.limit {
+		return 0, r.limited.exceed(api.StreamInLimitExceededError{LimitInBytes: r.limit})
+	}
+
+	return n, err
+}
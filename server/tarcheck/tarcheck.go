@@ -0,0 +1,141 @@
+package tarcheck
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// Quota is the disk quota an archive will be extracted into. Zero limits are
+// unlimited.
+type Quota struct {
+	ByteLimit uint64
+	BytesUsed uint64
+
+	InodeLimit uint64
+	InodesUsed uint64
+}
+
+// FitsBytes reports whether the given number of bytes fit in the quota.
+func (q Quota) FitsBytes(bytes uint64) bool {
+	return q.ByteLimit == 0 || q.BytesUsed+bytes <= q.ByteLimit
+}
+
+// FitsInodes reports whether the given number of inodes fit in the quota.
+func (q Quota) FitsInodes(inodes uint64) bool {
+	return q.InodeLimit == 0 || q.InodesUsed+inodes <= q.InodeLimit
+}
+
+func (q Quota) RemainingBytes() uint64 {
+	return remaining(q.ByteLimit, q.BytesUsed)
+}
+
+func (q Quota) RemainingInodes() uint64 {
+	return remaining(q.InodeLimit, q.InodesUsed)
+}
+
+// Check reads the whole of a tar archive without extracting it, and reports
+// any entries which could not safely be streamed in, or whether the archive
+// would exceed the quota.
+func Check(archive io.Reader, quota Quota) api.StreamInReport {
+	report := api.StreamInReport{}
+
+	// read through any trailing padding so the whole upload is consumed
+	defer io.Copy(ioutil.Discard, archive)
+
+	tarReader := tar.NewReader(archive)
+
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			report.Problems = append(report.Problems, api.StreamInProblem{
+				Reason: "invalid archive: " + err.Error(),
+			})
+
+			return report
+		}
+
+		report.Entries++
+
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+			report.Bytes += uint64(hdr.Size)
+		}
+
+		reason := checkEntry(hdr)
+		if reason != "" {
+			report.Problems = append(report.Problems, api.StreamInProblem{
+				Path:   hdr.Name,
+				Reason: reason,
+			})
+		}
+	}
+
+	if !quota.FitsBytes(report.Bytes) {
+		report.Problems = append(report.Problems, api.StreamInProblem{
+			Reason: fmt.Sprintf(
+				"archive needs %d bytes, but only %d remain in the disk quota",
+				report.Bytes,
+				quota.RemainingBytes(),
+			),
+		})
+	}
+
+	if !quota.FitsInodes(report.Entries) {
+		report.Problems = append(report.Problems, api.StreamInProblem{
+			Reason: fmt.Sprintf(
+				"archive needs %d inodes, but only %d remain in the disk quota",
+				report.Entries,
+				quota.RemainingInodes(),
+			),
+		})
+	}
+
+	return report
+}
+
+func checkEntry(hdr *tar.Header) string {
+	if path.IsAbs(hdr.Name) {
+		return "absolute paths are not permitted"
+	}
+
+	if escapes(hdr.Name) {
+		return "path escapes the destination"
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeChar, tar.TypeBlock:
+		return "device files are not permitted"
+
+	case tar.TypeFifo:
+		return "named pipes are not permitted"
+
+	case tar.TypeLink:
+		if path.IsAbs(hdr.Linkname) || escapes(hdr.Linkname) {
+			return "hard link target escapes the destination"
+		}
+	}
+
+	return ""
+}
+
+func escapes(name string) bool {
+	cleaned := path.Clean(name)
+	return cleaned == ".." || strings.HasPrefix(cleaned, "../")
+}
+
+func remaining(limit, used uint64) uint64 {
+	if used > limit {
+		return 0
+	}
+
+	return limit - used
+}
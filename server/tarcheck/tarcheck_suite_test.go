@@ -0,0 +1,13 @@
+package tarcheck_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTarCheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TarCheck Suite")
+}
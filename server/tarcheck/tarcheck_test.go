@@ -0,0 +1,147 @@
+package tarcheck_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/server/tarcheck"
+)
+
+var _ = Describe("Checking an archive", func() {
+	var archive *bytes.Buffer
+	var tarWriter *tar.Writer
+
+	BeforeEach(func() {
+		archive = new(bytes.Buffer)
+		tarWriter = tar.NewWriter(archive)
+	})
+
+	writeEntry := func(hdr *tar.Header, contents string) {
+		hdr.Size = int64(len(contents))
+
+		err := tarWriter.WriteHeader(hdr)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = tarWriter.Write([]byte(contents))
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	Context("with a well-formed archive", func() {
+		BeforeEach(func() {
+			writeEntry(&tar.Header{Name: "./some-dir/", Typeflag: tar.TypeDir, Mode: 0755}, "")
+			writeEntry(&tar.Header{Name: "./some-dir/some-file", Typeflag: tar.TypeReg, Mode: 0644}, "hello")
+			writeEntry(&tar.Header{Name: "./some-dir/other-file", Typeflag: tar.TypeReg, Mode: 0644}, "goodbye")
+			writeEntry(&tar.Header{Name: "./some-dir/link", Typeflag: tar.TypeSymlink, Linkname: "../elsewhere"}, "")
+			tarWriter.Close()
+		})
+
+		It("reports its entries and size", func() {
+			report := tarcheck.Check(archive, tarcheck.Quota{})
+			Ω(report).Should(Equal(api.StreamInReport{
+				Entries: 4,
+				Bytes:   12,
+			}))
+
+			Ω(report.Valid()).Should(BeTrue())
+		})
+
+		It("reads the whole archive", func() {
+			archive.Write([]byte("trailing padding"))
+
+			tarcheck.Check(archive, tarcheck.Quota{})
+
+			remaining, err := ioutil.ReadAll(archive)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(remaining).Should(BeEmpty())
+		})
+
+		Context("when it fits in the disk quota", func() {
+			It("is valid", func() {
+				report := tarcheck.Check(archive, tarcheck.Quota{
+					ByteLimit:  100,
+					BytesUsed:  88,
+					InodeLimit: 10,
+					InodesUsed: 6,
+				})
+
+				Ω(report.Valid()).Should(BeTrue())
+			})
+		})
+
+		Context("when it would exceed the byte quota", func() {
+			It("reports the problem", func() {
+				report := tarcheck.Check(archive, tarcheck.Quota{
+					ByteLimit: 100,
+					BytesUsed: 89,
+				})
+
+				Ω(report.Problems).Should(Equal([]api.StreamInProblem{
+					{Reason: "archive needs 12 bytes, but only 11 remain in the disk quota"},
+				}))
+			})
+		})
+
+		Context("when it would exceed the inode quota", func() {
+			It("reports the problem", func() {
+				report := tarcheck.Check(archive, tarcheck.Quota{
+					InodeLimit: 10,
+					InodesUsed: 12,
+				})
+
+				Ω(report.Problems).Should(Equal([]api.StreamInProblem{
+					{Reason: "archive needs 4 inodes, but only 0 remain in the disk quota"},
+				}))
+			})
+		})
+	})
+
+	Context("with entries that cannot be streamed in", func() {
+		BeforeEach(func() {
+			writeEntry(&tar.Header{Name: "/etc/passwd", Typeflag: tar.TypeReg, Mode: 0644}, "root")
+			writeEntry(&tar.Header{Name: "some-dir/../../outside", Typeflag: tar.TypeReg, Mode: 0644}, "")
+			writeEntry(&tar.Header{Name: "null", Typeflag: tar.TypeChar, Mode: 0666}, "")
+			writeEntry(&tar.Header{Name: "pipe", Typeflag: tar.TypeFifo, Mode: 0666}, "")
+			writeEntry(&tar.Header{Name: "hardlink", Typeflag: tar.TypeLink, Linkname: "../../etc/shadow"}, "")
+			writeEntry(&tar.Header{Name: "fine", Typeflag: tar.TypeReg, Mode: 0644}, "ok")
+			tarWriter.Close()
+		})
+
+		It("reports each of them", func() {
+			report := tarcheck.Check(archive, tarcheck.Quota{})
+
+			Ω(report.Entries).Should(Equal(uint64(6)))
+			Ω(report.Valid()).Should(BeFalse())
+
+			Ω(report.Problems).Should(Equal([]api.StreamInProblem{
+				{Path: "/etc/passwd", Reason: "absolute paths are not permitted"},
+				{Path: "some-dir/../../outside", Reason: "path escapes the destination"},
+				{Path: "null", Reason: "device files are not permitted"},
+				{Path: "pipe", Reason: "named pipes are not permitted"},
+				{Path: "hardlink", Reason: "hard link target escapes the destination"},
+			}))
+		})
+	})
+
+	Context("with a malformed archive", func() {
+		BeforeEach(func() {
+			writeEntry(&tar.Header{Name: "some-file", Typeflag: tar.TypeReg, Mode: 0644}, "hello")
+			tarWriter.Flush()
+
+			// cut the archive off part way through the file's contents
+			archive.Truncate(512 + 2)
+		})
+
+		It("reports that the archive is invalid", func() {
+			report := tarcheck.Check(archive, tarcheck.Quota{})
+
+			Ω(report.Valid()).Should(BeFalse())
+			Ω(report.Problems).Should(HaveLen(1))
+			Ω(report.Problems[0].Reason).Should(ContainSubstring("invalid archive"))
+		})
+	})
+})
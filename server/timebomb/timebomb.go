@@ -13,6 +13,22 @@ type TimeBomb struct {
 	defused bool
 	timer   *time.Timer
 	lock    *sync.Mutex
+
+	// armedAt is when the countdown last started
+	armedAt time.Time
+
+	// lastActivity is when the bomb was last strapped, paused or unpaused
+	lastActivity time.Time
+}
+
+// Status is how far a bomb's countdown has got.
+type Status struct {
+	// Remaining is how long is left of the countdown, or zero if the bomb is
+	// not counting down: it is paused, defused, or has no countdown.
+	Remaining time.Duration
+
+	// LastActivity is when the bomb was last strapped, paused or unpaused.
+	LastActivity time.Time
 }
 
 func New(countdown time.Duration, detonate func()) *TimeBomb {
@@ -24,9 +40,12 @@ func New(countdown time.Duration, detonate func()) *TimeBomb {
 	}
 }
 
+// Strap starts the countdown. A bomb with a countdown of zero never
+// detonates.
 func (b *TimeBomb) Strap() {
 	b.lock.Lock()
-	b.timer = time.AfterFunc(b.countdown, b.detonate)
+	b.lastActivity = time.Now()
+	b.arm()
 	b.lock.Unlock()
 }
 
@@ -38,6 +57,7 @@ func (b *TimeBomb) Pause() bool {
 	b.timer = nil
 
 	b.pauses++
+	b.lastActivity = time.Now()
 
 	if timer == nil {
 		return true
@@ -71,8 +91,55 @@ func (b *TimeBomb) Unpause() {
 	defer b.lock.Unlock()
 
 	b.pauses--
+	b.lastActivity = time.Now()
+
+	if !b.defused && b.pauses == 0 {
+		b.arm()
+	}
+}
+
+// SetCountdown changes the countdown and, unless the bomb is paused or
+// defused, starts it again from the beginning.
+func (b *TimeBomb) SetCountdown(countdown time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.countdown = countdown
+
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
 
 	if !b.defused && b.pauses == 0 {
-		b.timer = time.AfterFunc(b.countdown, b.detonate)
+		b.arm()
 	}
 }
+
+// Status returns how far the countdown has got.
+func (b *TimeBomb) Status() Status {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	status := Status{LastActivity: b.lastActivity}
+
+	if b.timer != nil {
+		status.Remaining = b.countdown - time.Since(b.armedAt)
+
+		// detonating
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+	}
+
+	return status
+}
+
+func (b *TimeBomb) arm() {
+	if b.countdown == 0 {
+		return
+	}
+
+	b.armedAt = time.Now()
+	b.timer = time.AfterFunc(b.countdown, b.detonate)
+}
@@ -283,4 +283,146 @@ var _ = Describe("THE TIMEBOMB", func() {
 			})
 		})
 	})
+
+	Context("WITH A COUNTDOWN OF ZERO", func() {
+		It("NEVER DETONATES", func() {
+			detonated := make(chan time.Time)
+
+			bomb := timebomb.New(
+				0,
+				func() {
+					detonated <- time.Now()
+				},
+			)
+
+			bomb.Strap()
+
+			select {
+			case <-detonated:
+				Fail("DETONATED!")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	})
+
+	Context("WHEN THE COUNTDOWN IS CHANGED", func() {
+		It("DETONATES AFTER THE NEW COUNTDOWN, FROM THE TIME OF THE CHANGE", func() {
+			detonated := make(chan time.Time)
+
+			bomb := timebomb.New(
+				100*time.Millisecond,
+				func() {
+					detonated <- time.Now()
+				},
+			)
+
+			bomb.Strap()
+
+			time.Sleep(50 * time.Millisecond)
+
+			countdown := 200 * time.Millisecond
+
+			before := time.Now()
+
+			bomb.SetCountdown(countdown)
+
+			Ω((<-detonated).Sub(before)).Should(BeNumerically(">=", countdown))
+		})
+
+		Context("TO ZERO", func() {
+			It("DOES NOT DETONATE", func() {
+				detonated := make(chan time.Time)
+
+				countdown := 100 * time.Millisecond
+
+				bomb := timebomb.New(
+					countdown,
+					func() {
+						detonated <- time.Now()
+					},
+				)
+
+				bomb.Strap()
+				bomb.SetCountdown(0)
+
+				select {
+				case <-detonated:
+					Fail("DETONATED!")
+				case <-time.After(countdown + 50*time.Millisecond):
+				}
+			})
+		})
+
+		Context("WHILE PAUSED", func() {
+			It("DETONATES AFTER THE NEW COUNTDOWN ONCE UNPAUSED", func() {
+				detonated := make(chan time.Time)
+
+				bomb := timebomb.New(
+					0,
+					func() {
+						detonated <- time.Now()
+					},
+				)
+
+				bomb.Strap()
+				bomb.Pause()
+
+				countdown := 100 * time.Millisecond
+
+				bomb.SetCountdown(countdown)
+
+				select {
+				case <-detonated:
+					Fail("DETONATED!")
+				case <-time.After(countdown + 50*time.Millisecond):
+				}
+
+				before := time.Now()
+
+				bomb.Unpause()
+
+				Ω((<-detonated).Sub(before)).Should(BeNumerically(">=", countdown))
+			})
+		})
+	})
+
+	Context("WHEN ASKED FOR ITS STATUS", func() {
+		It("SAYS HOW LONG IS LEFT OF THE COUNTDOWN", func() {
+			bomb := timebomb.New(time.Minute, func() {})
+
+			Ω(bomb.Status().Remaining).Should(BeZero())
+
+			bomb.Strap()
+
+			time.Sleep(100 * time.Millisecond)
+
+			Ω(bomb.Status().Remaining).Should(BeNumerically("<=", time.Minute-100*time.Millisecond))
+			Ω(bomb.Status().Remaining).Should(BeNumerically(">", time.Minute-time.Second))
+		})
+
+		It("SAYS WHEN IT WAS LAST PAUSED OR UNPAUSED", func() {
+			bomb := timebomb.New(time.Minute, func() {})
+
+			bomb.Strap()
+
+			time.Sleep(100 * time.Millisecond)
+
+			before := time.Now()
+
+			bomb.Pause()
+
+			Ω(bomb.Status().LastActivity).Should(BeTemporally(">=", before))
+		})
+
+		Context("WHILE PAUSED", func() {
+			It("SAYS IT IS NOT COUNTING DOWN", func() {
+				bomb := timebomb.New(time.Minute, func() {})
+
+				bomb.Strap()
+				bomb.Pause()
+
+				Ω(bomb.Status().Remaining).Should(BeZero())
+			})
+		})
+	})
 })
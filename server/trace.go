@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// maxTraceIDLength bounds the trace IDs the server logs and echoes.
+const maxTraceIDLength = 128
+
+// traceID returns the ID of the trace the request is part of, or "" if it
+// gave none, or one which is too long or not printable ASCII.
+func traceID(r *http.Request) string {
+	id := r.Header.Get(transport.TraceHeader)
+	if len(id) > maxTraceIDLength {
+		return ""
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return ""
+		}
+	}
+
+	return id
+}
+
+// traced echoes the request's trace ID in its response, so that it is given
+// even by responses the handler does not write, e.g. those to requests which
+// fail to authenticate.
+func traced(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := traceID(r); id != "" {
+			w.Header().Set(transport.TraceHeader, id)
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
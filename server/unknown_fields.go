@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFieldViolations lists the fields of a JSON request which its message
+// has no field for. Without strict requests they are silently ignored, so a
+// client newer than the server would believe they had been applied.
+func unknownFieldViolations(data []byte, msg interface{}) []api.ValidationError {
+	var violations []api.ValidationError
+
+	seen := map[string]bool{}
+
+	for _, field := range unknownFields(data, reflect.TypeOf(msg), "") {
+		if seen[field] {
+			continue
+		}
+
+		seen[field] = true
+
+		violations = append(violations, api.ValidationError{
+			Field:  api.SpecField(field),
+			Reason: "is not known to this server",
+		})
+	}
+
+	return violations
+}
+
+// unknownFields returns the dotted paths, as encoding/json names fields in
+// its errors, of the fields in the JSON value which values of type t would
+// not decode.
+func unknownFields(data []byte, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// the type decodes itself, e.g. an enum
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return nil
+		}
+
+		names := []string{}
+		for name := range object {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+
+			field, found := jsonField(t, name)
+			if !found {
+				unknown = append(unknown, fieldPath)
+				continue
+			}
+
+			unknown = append(unknown, unknownFields(object[name], field.Type, fieldPath)...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		var elements []json.RawMessage
+		if json.Unmarshal(data, &elements) != nil {
+			return nil
+		}
+
+		for _, element := range elements {
+			unknown = append(unknown, unknownFields(element, t.Elem(), path)...)
+		}
+
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if json.Unmarshal(data, &values) != nil {
+			return nil
+		}
+
+		for _, value := range values {
+			unknown = append(unknown, unknownFields(value, t.Elem(), path)...)
+		}
+	}
+
+	return unknown
+}
+
+// jsonField returns the field of the struct type which encoding/json decodes
+// the named field of an object into. Like encoding/json, it prefers an exact
+// match of the name, but accepts one differing in case.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	var folded *reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fieldName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if fieldName == "-" {
+			continue
+		}
+
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+
+		if fieldName == name {
+			return field, true
+		}
+
+		if folded == nil && strings.EqualFold(fieldName, name) {
+			folded = &field
+		}
+	}
+
+	if folded != nil {
+		return *folded, true
+	}
+
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/routes"
+)
+
+// WorkerPool names a class of requests that is served by its own bounded
+// pool of workers, so that a flood of one class cannot starve the other.
+type WorkerPool string
+
+const (
+	// ControlPool serves the cheap requests which manage containers, e.g.
+	// Ping, Create, Info and limits.
+	ControlPool WorkerPool = "control"
+
+	// StreamingPool serves requests which stream files or snapshots in or out
+	// of a container.
+	StreamingPool WorkerPool = "streaming"
+)
+
+// Process requests are never pooled, as their hijacked streams last as long
+// as the processes do. Nor are diagnostics, which are most wanted when the
+// pools are exhausted.
+var unpooledRoutes = map[string]bool{
+	routes.Run:            true,
+	routes.Attach:         true,
+	routes.AttachAll:      true,
+	routes.Multiplex:      true,
+	routes.GetDiagnostics: true,
+	routes.WaitForProcess: true,
+	routes.Logs:           true,
+}
+
+var streamingRoutes = map[string]bool{
+	routes.StreamIn:           true,
+	routes.StreamInAtomically: true,
+	routes.StreamOut:          true,
+	routes.VerifyStreamIn:     true,
+	routes.Snapshot:           true,
+	routes.Restore:            true,
+}
+
+func workerPoolFor(route string) WorkerPool {
+	if streamingRoutes[route] {
+		return StreamingPool
+	}
+
+	return ControlPool
+}
+
+// WorkerPoolStats is a snapshot of a worker pool's load.
+type WorkerPoolStats struct {
+	// Size is the number of workers, or 0 if the pool is unbounded.
+	Size int
+
+	// Active is the number of requests being handled.
+	Active int
+
+	// Queued is the number of requests waiting for a worker.
+	Queued int
+}
+
+type workerPool struct {
+	size    int
+	workers chan struct{}
+
+	active int
+	queued int
+	statsL sync.Mutex
+}
+
+func newWorkerPool(size int) *workerPool {
+	pool := &workerPool{size: size}
+
+	if size > 0 {
+		pool.workers = make(chan struct{}, size)
+	}
+
+	return pool
+}
+
+func (p *workerPool) serve(handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	p.statsL.Lock()
+	p.queued++
+	p.statsL.Unlock()
+
+	if p.workers != nil {
+		p.workers <- struct{}{}
+	}
+
+	p.statsL.Lock()
+	p.queued--
+	p.active++
+	p.statsL.Unlock()
+
+	defer func() {
+		p.statsL.Lock()
+		p.active--
+		p.statsL.Unlock()
+
+		if p.workers != nil {
+			<-p.workers
+		}
+	}()
+
+	handler.ServeHTTP(w, r)
+}
+
+func (p *workerPool) stats() WorkerPoolStats {
+	p.statsL.Lock()
+	defer p.statsL.Unlock()
+
+	return WorkerPoolStats{
+		Size:   p.size,
+		Active: p.active,
+		Queued: p.queued,
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// SetWriteTimeout bounds how long each write to a process stream may take,
+// so that a client which stops reading cannot hold up the streaming of its
+// process's output indefinitely. Streams whose writes time out are closed;
+// their processes run on, and clients may attach to them again. 0, the
+// default, leaves writes unbounded. It must be called before Start.
+func (s *GardenServer) SetWriteTimeout(timeout time.Duration) {
+	s.writeTimeout = timeout
+}
+
+// timedWriter bounds each write to the hijacked connection by the server's
+// write timeout, closing the connection once one times out.
+func (s *GardenServer) timedWriter(conn net.Conn) io.Writer {
+	if s.writeTimeout == 0 {
+		return conn
+	}
+
+	return &closingWriter{
+		w:    transport.NewTimeoutWriter(conn, s.writeTimeout),
+		conn: conn,
+	}
+}
+
+type closingWriter struct {
+	w    io.Writer
+	conn net.Conn
+}
+
+func (w *closingWriter) Write(data []byte) (int, error) {
+	n, err := w.w.Write(data)
+	if _, timedOut := err.(transport.WriteTimeoutError); timedOut {
+		w.conn.Close()
+	}
+
+	return n, err
+}
@@ -0,0 +1,242 @@
+package servertest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// Any matches any argument.
+var Any = anyArg{}
+
+type anyArg struct{}
+
+func (anyArg) String() string {
+	return "<any>"
+}
+
+// An Expectation is a call which the backend, or one of its containers, is
+// expected to receive, and how to respond to it.
+type Expectation struct {
+	// Handle is the container the call is made of, or empty for calls of
+	// the backend.
+	Handle string
+
+	// Method is the name of the api.Backend or api.Container method called,
+	// e.g. "Create".
+	Method string
+
+	// Args are the arguments expected, each compared as reflect.DeepEqual
+	// does, except that nil and empty slices and maps are equal, as they are
+	// once sent to the server, unless it is Any. If nil, any arguments match.
+	Args []interface{}
+
+	// Returns are the method's results; nils, and any left off the end, are
+	// zero values. If nil, the call is only verified, and is made of the
+	// fake as if nothing were expected of it.
+	Returns []interface{}
+
+	// Times is how many calls are expected; 0 means one.
+	Times int
+}
+
+func (e Expectation) String() string {
+	call := Call{Handle: e.Handle, Method: e.Method, Args: e.Args}
+
+	if method, found := e.method(); found && e.Args == nil {
+		for i := 0; i < method.Type.NumIn(); i++ {
+			call.Args = append(call.Args, Any)
+		}
+	}
+
+	return call.String()
+}
+
+func (e Expectation) receiver() reflect.Type {
+	if e.Handle != "" {
+		return reflect.TypeOf((*api.Container)(nil)).Elem()
+	}
+
+	return reflect.TypeOf((*api.Backend)(nil)).Elem()
+}
+
+func (e Expectation) method() (reflect.Method, bool) {
+	return e.receiver().MethodByName(e.Method)
+}
+
+func (e Expectation) times() int {
+	if e.Times == 0 {
+		return 1
+	}
+
+	return e.Times
+}
+
+func (e Expectation) matches(call Call) bool {
+	if e.Handle != call.Handle || e.Method != call.Method {
+		return false
+	}
+
+	if e.Args == nil {
+		return true
+	}
+
+	if len(e.Args) != len(call.Args) {
+		return false
+	}
+
+	for i, arg := range e.Args {
+		if arg == Any {
+			continue
+		}
+
+		if !equivalent(reflect.ValueOf(arg), reflect.ValueOf(call.Args[i])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// check panics if the expectation could never be met, e.g. as the method
+// does not exist, as Expect is called by tests which are then wrong.
+func (e Expectation) check() {
+	method, found := e.method()
+	if !found {
+		panic(fmt.Sprintf("servertest: %s has no method %s", e.receiver(), e.Method))
+	}
+
+	if e.Args != nil && len(e.Args) != method.Type.NumIn() {
+		panic(fmt.Sprintf("servertest: %s expects %d arguments, not %d", e, method.Type.NumIn(), len(e.Args)))
+	}
+
+	if len(e.Returns) > method.Type.NumOut() {
+		panic(fmt.Sprintf("servertest: %s returns %d results, not %d", e, method.Type.NumOut(), len(e.Returns)))
+	}
+
+	for i, result := range e.Returns {
+		if result != nil && !reflect.TypeOf(result).AssignableTo(method.Type.Out(i)) {
+			panic(fmt.Sprintf("servertest: %s cannot return %T as %s", e, result, method.Type.Out(i)))
+		}
+	}
+}
+
+// equivalent is reflect.DeepEqual, except that nil and empty slices and maps
+// are equivalent.
+func equivalent(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		for i := 0; i < a.Len(); i++ {
+			if !equivalent(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+
+		return true
+
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		for _, key := range a.MapKeys() {
+			value := b.MapIndex(key)
+			if !value.IsValid() || !equivalent(a.MapIndex(key), value) {
+				return false
+			}
+		}
+
+		return true
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equivalent(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+
+		return true
+
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+
+		return equivalent(a.Elem(), b.Elem())
+
+	// the rest are compared by kind, as unexported fields cannot be compared
+	// through Interface
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+
+	case reflect.String:
+		return a.String() == b.String()
+	}
+
+	// functions and channels are equal only if both are nil, as with
+	// reflect.DeepEqual
+	return a.IsNil() && b.IsNil()
+}
+
+// A Call is a call of the backend or a container.
+type Call struct {
+	// Handle is the container the call was made of, or empty for calls of
+	// the backend.
+	Handle string
+
+	Method string
+	Args   []interface{}
+}
+
+func (c Call) String() string {
+	receiver := "backend"
+	if c.Handle != "" {
+		receiver = fmt.Sprintf("container %q", c.Handle)
+	}
+
+	args := ""
+	for i, arg := range c.Args {
+		if i > 0 {
+			args += ", "
+		}
+
+		args += fmt.Sprintf("%+v", arg)
+	}
+
+	return fmt.Sprintf("%s.%s(%s)", receiver, c.Method, args)
+}
+
+// UnexpectedCallError is returned by calls of methods with expectations which
+// none of them match.
+type UnexpectedCallError struct {
+	Call Call
+}
+
+func (e UnexpectedCallError) Error() string {
+	return fmt.Sprintf("servertest: unexpected call: %s", e.Call)
+}
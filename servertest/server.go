@@ -0,0 +1,262 @@
+// Package servertest runs a GardenServer against a fake backend scripted
+// with expectations, for contract tests of code talking to Garden: each
+// states a call which the code should cause the backend, or one of its
+// containers, to receive, and how to respond to it:
+//
+//	server, err := servertest.Start(gardentest.Config{})
+//	defer server.Stop()
+//
+//	server.Expect(servertest.Expectation{
+//		Method:  "Create",
+//		Args:    []interface{}{api.ContainerSpec{Handle: "some-handle"}},
+//		Returns: []interface{}{nil, api.ErrCapacityExceeded},
+//	})
+//
+//	... exercise the code with server.Client ...
+//
+//	err = server.Verify()
+//
+// The server is a gardentest.Harness, which keeps the containers created
+// and destroyed through it unless that is expected otherwise.
+package servertest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/api/fakes"
+	"github.com/cloudfoundry-incubator/garden/gardentest"
+)
+
+type Server struct {
+	*gardentest.Harness
+
+	expectations []*expectation
+	unexpected   []Call
+
+	// installed records the methods of each fake which check expectations
+	installed map[installation]bool
+
+	mu sync.Mutex
+}
+
+type expectation struct {
+	Expectation
+	calls int
+}
+
+type installation struct {
+	fake   interface{}
+	method string
+}
+
+// Start starts a server against a fake backend, returning once it accepts
+// connections.
+func Start(config gardentest.Config) (*Server, error) {
+	harness, err := gardentest.Start(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Harness:   harness,
+		installed: map[installation]bool{},
+	}
+
+	// containers are scripted as the server is given them, from before
+	// anything is expected of them
+	s.mu.Lock()
+	for _, method := range []string{"Create", "Lookup", "Containers"} {
+		s.install("", s.Backend, method)
+	}
+	s.mu.Unlock()
+
+	return s, nil
+}
+
+// Expect adds expectations, which calls match in the order they were added.
+// Once a method has an expectation, calls of it which none match fail with
+// UnexpectedCallError. It panics if an expectation could never be met, and
+// must not be called while requests it affects are being handled.
+func (s *Server) Expect(expectations ...Expectation) {
+	for _, e := range expectations {
+		e.check()
+
+		var fake interface{} = s.Backend
+		if e.Handle != "" {
+			if container := s.Container(e.Handle); container != nil {
+				fake = container
+			} else {
+				// the container is scripted once the server is given it
+				fake = nil
+			}
+		}
+
+		s.mu.Lock()
+
+		s.expectations = append(s.expectations, &expectation{Expectation: e})
+
+		if fake != nil {
+			s.install(e.Handle, fake, e.Method)
+		}
+
+		s.mu.Unlock()
+	}
+}
+
+// Verify returns an error describing the expectations which have not been
+// met and the calls which none matched, or nil if there are none.
+func (s *Server) Verify() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	problems := []string{}
+
+	for _, e := range s.expectations {
+		if e.calls < e.times() {
+			problems = append(problems, fmt.Sprintf("expected %s %d times, got %d", e.Expectation, e.times(), e.calls))
+		}
+	}
+
+	for _, call := range s.unexpected {
+		problems = append(problems, fmt.Sprintf("unexpected %s", call))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("servertest: %s", strings.Join(problems, "; "))
+}
+
+// install replaces the fake's stub of the method with one which checks the
+// expectations, falling back on the stub it replaces. s.mu must be held.
+func (s *Server) install(handle string, fake interface{}, method string) {
+	key := installation{fake, method}
+	if s.installed[key] {
+		return
+	}
+
+	s.installed[key] = true
+
+	stub := reflect.ValueOf(fake).Elem().FieldByName(method + "Stub")
+	previous := reflect.ValueOf(stub.Interface())
+
+	stub.Set(reflect.MakeFunc(stub.Type(), func(args []reflect.Value) []reflect.Value {
+		return s.call(handle, method, stub.Type(), previous, args)
+	}))
+}
+
+func (s *Server) call(handle string, method string, stubType reflect.Type, previous reflect.Value, args []reflect.Value) []reflect.Value {
+	call := Call{Handle: handle, Method: method}
+	for _, arg := range args {
+		call.Args = append(call.Args, arg.Interface())
+	}
+
+	returns, err := s.match(call)
+
+	var results []reflect.Value
+
+	switch {
+	case err != nil:
+		results = zeroResults(stubType)
+
+		last := stubType.NumOut() - 1
+		if last >= 0 && stubType.Out(last) == errorType {
+			results[last] = reflect.ValueOf(&err).Elem()
+		}
+
+	case returns != nil:
+		results = zeroResults(stubType)
+
+		for i, result := range returns {
+			if result != nil {
+				results[i] = reflect.New(stubType.Out(i)).Elem()
+				results[i].Set(reflect.ValueOf(result))
+			}
+		}
+
+	case !previous.IsNil():
+		results = previous.Call(args)
+
+	default:
+		results = zeroResults(stubType)
+	}
+
+	for _, result := range results {
+		s.script(result.Interface())
+	}
+
+	return results
+}
+
+// match finds the first expectation the call matches which is still
+// expected, returning its results, or an error if the method has
+// expectations and none match.
+func (s *Server) match(call Call) ([]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expected := false
+
+	for _, e := range s.expectations {
+		if e.Handle != call.Handle || e.Method != call.Method {
+			continue
+		}
+
+		expected = true
+
+		if e.calls < e.times() && e.matches(call) {
+			e.calls++
+			return e.Returns, nil
+		}
+	}
+
+	if !expected {
+		return nil, nil
+	}
+
+	s.unexpected = append(s.unexpected, call)
+
+	return nil, UnexpectedCallError{Call: call}
+}
+
+// script installs the expectations of containers the backend returns.
+func (s *Server) script(result interface{}) {
+	switch r := result.(type) {
+	case *fakes.FakeContainer:
+		if r == nil {
+			return
+		}
+
+		handle := r.Handle()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for _, e := range s.expectations {
+			if e.Handle == handle {
+				s.install(handle, r, e.Method)
+			}
+		}
+
+	case []api.Container:
+		for _, container := range r {
+			s.script(container)
+		}
+	}
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func zeroResults(stubType reflect.Type) []reflect.Value {
+	results := make([]reflect.Value, stubType.NumOut())
+	for i := range results {
+		results[i] = reflect.Zero(stubType.Out(i))
+	}
+
+	return results
+}
@@ -0,0 +1,175 @@
+package servertest_test
+
+import (
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/gardentest"
+	"github.com/cloudfoundry-incubator/garden/servertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	var server *servertest.Server
+
+	BeforeEach(func() {
+		var err error
+
+		server, err = servertest.Start(gardentest.Config{})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	It("responds to expected calls as they say", func() {
+		server.Expect(servertest.Expectation{
+			Method:  "Create",
+			Args:    []interface{}{api.ContainerSpec{Handle: "some-handle"}},
+			Returns: []interface{}{nil, api.ErrCapacityExceeded},
+		})
+
+		_, err := server.Client.Create(api.ContainerSpec{Handle: "some-handle"})
+		Ω(err).Should(Equal(api.ErrCapacityExceeded))
+
+		Ω(server.Verify()).Should(Succeed())
+	})
+
+	It("lets calls which are only verified through to the backend", func() {
+		server.Expect(servertest.Expectation{
+			Method: "Create",
+			Args:   []interface{}{api.ContainerSpec{Handle: "some-handle"}},
+		})
+
+		container, err := server.Client.Create(api.ContainerSpec{Handle: "some-handle"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(container.Handle()).Should(Equal("some-handle"))
+
+		Ω(server.Container("some-handle")).ShouldNot(BeNil())
+
+		Ω(server.Verify()).Should(Succeed())
+	})
+
+	It("matches any arguments given Any, or none", func() {
+		server.Expect(
+			servertest.Expectation{
+				Method: "Destroy",
+				Args:   []interface{}{servertest.Any},
+			},
+			servertest.Expectation{
+				Method: "Destroy",
+			},
+		)
+
+		server.NewContainer("some-handle")
+		server.NewContainer("some-other-handle")
+
+		Ω(server.Client.Destroy("some-handle")).Should(Succeed())
+		Ω(server.Client.Destroy("some-other-handle")).Should(Succeed())
+
+		Ω(server.Verify()).Should(Succeed())
+	})
+
+	It("expects calls as many times as it is told", func() {
+		server.Expect(servertest.Expectation{
+			Method: "Ping",
+			Times:  2,
+		})
+
+		Ω(server.Client.Ping()).Should(Succeed())
+
+		err := server.Verify()
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("expected backend.Ping() 2 times, got 1"))
+
+		Ω(server.Client.Ping()).Should(Succeed())
+
+		Ω(server.Verify()).Should(Succeed())
+
+		Ω(server.Client.Ping()).ShouldNot(Succeed())
+	})
+
+	It("fails calls of expected methods which no expectation matches", func() {
+		server.Expect(servertest.Expectation{
+			Method: "Create",
+			Args:   []interface{}{api.ContainerSpec{Handle: "some-handle"}},
+		})
+
+		_, err := server.Client.Create(api.ContainerSpec{Handle: "some-other-handle"})
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("unexpected call"))
+
+		err = server.Verify()
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(`expected backend.Create({Handle:some-handle`))
+		Ω(err.Error()).Should(ContainSubstring(`unexpected backend.Create({Handle:some-other-handle`))
+	})
+
+	It("leaves methods without expectations to the fakes", func() {
+		server.Backend.CapacityReturns(api.Capacity{MaxContainers: 42}, nil)
+
+		capacity, err := server.Client.Capacity()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(capacity.MaxContainers).Should(Equal(uint64(42)))
+
+		Ω(server.Verify()).Should(Succeed())
+	})
+
+	Describe("expecting calls of containers", func() {
+		It("responds to them for containers the backend has", func() {
+			server.NewContainer("some-handle")
+
+			server.Expect(servertest.Expectation{
+				Handle:  "some-handle",
+				Method:  "Info",
+				Returns: []interface{}{api.ContainerInfo{State: "active"}},
+			})
+
+			container, err := server.Client.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			info, err := container.Info()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(info.State).Should(Equal("active"))
+
+			Ω(server.Verify()).Should(Succeed())
+		})
+
+		It("responds to them for containers created later", func() {
+			server.Expect(servertest.Expectation{
+				Handle:  "some-handle",
+				Method:  "Stop",
+				Args:    []interface{}{true},
+				Returns: []interface{}{api.ErrUnsupportedOperation},
+			})
+
+			container, err := server.Client.Create(api.ContainerSpec{Handle: "some-handle"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(container.Stop(true)).Should(Equal(api.ErrUnsupportedOperation))
+
+			Ω(server.Verify()).Should(Succeed())
+		})
+	})
+
+	It("panics given expectations which could never be met", func() {
+		Ω(func() {
+			server.Expect(servertest.Expectation{Method: "Bogus"})
+		}).Should(Panic())
+
+		Ω(func() {
+			server.Expect(servertest.Expectation{
+				Method: "Destroy",
+				Args:   []interface{}{"some-handle", "some-other-handle"},
+			})
+		}).Should(Panic())
+
+		Ω(func() {
+			server.Expect(servertest.Expectation{
+				Method:  "Ping",
+				Returns: []interface{}{"not an error"},
+			})
+		}).Should(Panic())
+	})
+})
@@ -0,0 +1,13 @@
+package servertest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestServertest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Servertest Suite")
+}
@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"sync"
+)
+
+// ContentTypeJSON is the content type of JSON-encoded messages, which every
+// client and server understands.
+const ContentTypeJSON = "application/json"
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// A Codec encodes and decodes request and response messages in one wire
+// format, identified by the content type the messages are sent with.
+type Codec interface {
+	ContentType() string
+
+	Encode(w io.Writer, msg interface{}) error
+	Decode(r io.Reader, msg interface{}) error
+}
+
+// JSON encodes messages as JSON, using the field names of their apitypes.
+var JSON Codec = jsonCodec{}
+
+var (
+	codecs = map[string]Codec{
+		ContentTypeJSON:     JSON,
+		ContentTypeProtobuf: Protobuf,
+	}
+	codecsL sync.RWMutex
+)
+
+// RegisterCodec makes a codec available to clients and servers for messages
+// sent with its content type, replacing any codec already registered for it.
+func RegisterCodec(codec Codec) {
+	codecsL.Lock()
+	codecs[codec.ContentType()] = codec
+	codecsL.Unlock()
+}
+
+// CodecFor returns the codec registered for a content type. Parameters of
+// the content type, e.g. a charset, are ignored.
+func CodecFor(contentType string) (Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedContentType
+	}
+
+	codecsL.RLock()
+	codec, found := codecs[mediaType]
+	codecsL.RUnlock()
+
+	if !found {
+		return nil, ErrUnsupportedContentType
+	}
+
+	return codec, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Encode(w io.Writer, msg interface{}) error {
+	return WriteMessage(w, msg)
+}
+
+func (jsonCodec) Decode(r io.Reader, msg interface{}) error {
+	return json.NewDecoder(LimitMessage(r)).Decode(msg)
+}
@@ -0,0 +1,217 @@
+package transport_test
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type relabelledCodec struct {
+	transport.Codec
+
+	contentType string
+}
+
+func (c relabelledCodec) ContentType() string {
+	return c.contentType
+}
+
+var _ = Describe("Codecs", func() {
+	Describe("JSON", func() {
+		It("round-trips messages", func() {
+			buffer := new(bytes.Buffer)
+
+			err := transport.JSON.Encode(buffer, &apitypes.CreateRequest{
+				Handle: apitypes.String("some-handle"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var request apitypes.CreateRequest
+			err = transport.JSON.Decode(buffer, &request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(request.GetHandle()).Should(Equal("some-handle"))
+		})
+
+		Context("when a message is bigger than MaxMessageSize", func() {
+			It("fails with a MessageTooLargeError", func() {
+				buffer := new(bytes.Buffer)
+
+				err := transport.JSON.Encode(buffer, &apitypes.CreateRequest{
+					Handle: apitypes.String(strings.Repeat("x", transport.MaxMessageSize)),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var request apitypes.CreateRequest
+				err = transport.JSON.Decode(buffer, &request)
+				Ω(err).Should(Equal(api.MessageTooLargeError{LimitInBytes: transport.MaxMessageSize}))
+			})
+		})
+
+		It("is registered for application/json", func() {
+			codec, err := transport.CodecFor("application/json")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(codec).Should(Equal(transport.JSON))
+		})
+
+		It("ignores content type parameters", func() {
+			codec, err := transport.CodecFor("application/json; charset=utf-8")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(codec).Should(Equal(transport.JSON))
+		})
+	})
+
+	Describe("Protobuf", func() {
+		It("round-trips messages", func() {
+			request := &apitypes.RunRequest{
+				Handle:     apitypes.String("some-handle"),
+				Path:       apitypes.String("some-script"),
+				Privileged: apitypes.Bool(true),
+				Uid:        apitypes.Uint32(1000),
+				Rlimits: &apitypes.ResourceLimits{
+					Nofile: apitypes.Uint64(1 << 40),
+				},
+				Env: []*apitypes.EnvironmentVariable{
+					{Key: apitypes.String("FOO"), Value: apitypes.String("bar")},
+					{Key: apitypes.String("BAZ"), Value: apitypes.String("")},
+				},
+				Args:        []string{"arg1", "", "arg3"},
+				PassedFiles: []apitypes.ProcessPayload_Source{apitypes.ProcessPayload_stdout, apitypes.ProcessPayload_stdin},
+			}
+
+			buffer := new(bytes.Buffer)
+
+			err := transport.Protobuf.Encode(buffer, request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var decoded apitypes.RunRequest
+			err = transport.Protobuf.Decode(buffer, &decoded)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(&decoded).Should(Equal(request))
+		})
+
+		It("round-trips negative and floating point numbers", func() {
+			buffer := new(bytes.Buffer)
+
+			err := transport.Protobuf.Encode(buffer, &apitypes.ScheduleDestroyRequest{
+				At: apitypes.Int64(-42),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var scheduled apitypes.ScheduleDestroyRequest
+			err = transport.Protobuf.Decode(buffer, &scheduled)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(scheduled.GetAt()).Should(Equal(int64(-42)))
+
+			err = transport.Protobuf.Encode(buffer, &apitypes.SetDefaultsRequest{
+				RequestsPerSecond: apitypes.Float64(2.5),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var defaults apitypes.SetDefaultsRequest
+			err = transport.Protobuf.Decode(buffer, &defaults)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(defaults.GetRequestsPerSecond()).Should(Equal(2.5))
+		})
+
+		It("numbers fields as their protobuf tags do", func() {
+			buffer := new(bytes.Buffer)
+
+			err := transport.Protobuf.Encode(buffer, &apitypes.CreateRequest{
+				Handle:     apitypes.String("h"),
+				Privileged: apitypes.Bool(true),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(buffer.Bytes()).Should(Equal([]byte{
+				3<<3 | 2, 1, 'h',
+				8 << 3, 1,
+			}))
+		})
+
+		It("skips fields it does not know", func() {
+			var request apitypes.CreateRequest
+			err := transport.Protobuf.Decode(bytes.NewReader([]byte{
+				0xc2, 0x3e, 1, 'x', // field 1000, which CreateRequest lacks
+				3<<3 | 2, 1, 'h',
+			}), &request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(request.GetHandle()).Should(Equal("h"))
+		})
+
+		It("decodes packed repeated fields", func() {
+			var request apitypes.FindProcessesResponse
+			err := transport.Protobuf.Decode(bytes.NewReader([]byte{
+				1<<3 | 2, 3, 1, 2, 3,
+			}), &request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(request.ProcessIds).Should(Equal([]uint32{1, 2, 3}))
+		})
+
+		Context("when a message is truncated", func() {
+			It("fails with ErrMalformedProtobuf", func() {
+				var request apitypes.CreateRequest
+				err := transport.Protobuf.Decode(bytes.NewReader([]byte{
+					3<<3 | 2, 5, 'h',
+				}), &request)
+				Ω(err).Should(Equal(transport.ErrMalformedProtobuf))
+			})
+		})
+
+		Context("when a message is bigger than MaxMessageSize", func() {
+			It("fails with a MessageTooLargeError", func() {
+				buffer := new(bytes.Buffer)
+
+				err := transport.Protobuf.Encode(buffer, &apitypes.CreateRequest{
+					Handle: apitypes.String(strings.Repeat("x", transport.MaxMessageSize)),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var request apitypes.CreateRequest
+				err = transport.Protobuf.Decode(buffer, &request)
+				Ω(err).Should(Equal(api.MessageTooLargeError{LimitInBytes: transport.MaxMessageSize}))
+			})
+		})
+
+		It("is registered for application/x-protobuf", func() {
+			codec, err := transport.CodecFor("application/x-protobuf")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(codec).Should(Equal(transport.Protobuf))
+		})
+	})
+
+	Context("when no codec is registered for the content type", func() {
+		It("returns ErrUnsupportedContentType", func() {
+			_, err := transport.CodecFor("application/x-unregistered")
+			Ω(err).Should(Equal(transport.ErrUnsupportedContentType))
+		})
+	})
+
+	Context("when the content type is missing", func() {
+		It("returns ErrUnsupportedContentType", func() {
+			_, err := transport.CodecFor("")
+			Ω(err).Should(Equal(transport.ErrUnsupportedContentType))
+		})
+	})
+
+	Describe("registering a codec", func() {
+		It("selects it for its content type", func() {
+			codec := relabelledCodec{transport.JSON, "application/x-garden-test"}
+
+			transport.RegisterCodec(codec)
+
+			registered, err := transport.CodecFor("application/x-garden-test")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(registered).Should(Equal(codec))
+		})
+	})
+})
@@ -0,0 +1,6 @@
+package transport
+
+// DestroyConfirmationHeader confirms a request to destroy a container, or to
+// schedule its destruction, by naming the container's handle. Servers may be
+// configured to refuse destroys which are not confirmed.
+const DestroyConfirmationHeader = "X-Garden-Confirm-Destroy"
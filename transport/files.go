@@ -0,0 +1,176 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrFilePassingUnsupported is returned for files which cannot be passed to
+// the process at the other end of a connection, e.g. over named pipes, and
+// on platforms without unix sockets to pass them over.
+var ErrFilePassingUnsupported = errors.New("file passing unsupported")
+
+// maxPassedFiles bounds how many files may be received with each read.
+const maxPassedFiles = 16
+
+// FileSender is a unix socket connection which passes files to the process at
+// the other end along with the first bytes written to it, for a FileConn to
+// receive.
+type FileSender struct {
+	*net.UnixConn
+
+	files []*os.File
+	mu    sync.Mutex
+}
+
+func NewFileSender(conn *net.UnixConn, files []*os.File) *FileSender {
+	return &FileSender{
+		UnixConn: conn,
+		files:    files,
+	}
+}
+
+func (c *FileSender) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	files := c.files
+	if len(b) > 0 {
+		c.files = nil
+	}
+	c.mu.Unlock()
+
+	if len(files) == 0 || len(b) == 0 {
+		return c.UnixConn.Write(b)
+	}
+
+	oob, err := unixRights(files)
+	if err != nil {
+		return 0, err
+	}
+
+	n, _, err := c.UnixConn.WriteMsgUnix(b, oob, nil)
+	if err != nil || n == len(b) {
+		return n, err
+	}
+
+	// the files went with the first of the bytes; the rest may follow plainly
+	rest, err := c.UnixConn.Write(b[n:])
+	return n + rest, err
+}
+
+// FileConn is a unix socket connection which keeps the files passed to it
+// along with what is read from it, until they are taken with Files.
+type FileConn struct {
+	*net.UnixConn
+
+	addr fileConnAddr
+
+	// oob receives the rights passing the files, and is reused by each read
+	oob    []byte
+	readMu sync.Mutex
+
+	files []*os.File
+	mu    sync.Mutex
+}
+
+// fileConnIDs numbers the FileConns made, for their remote addresses
+var fileConnIDs uint64
+
+func NewFileConn(conn *net.UnixConn) *FileConn {
+	peer := ""
+	if addr := conn.RemoteAddr(); addr != nil {
+		peer = addr.String()
+	}
+
+	return &FileConn{
+		UnixConn: conn,
+		addr:     fileConnAddr(net.JoinHostPort(peer, strconv.FormatUint(atomic.AddUint64(&fileConnIDs, 1), 10))),
+		oob:      make([]byte, rightsSpace(maxPassedFiles)),
+	}
+}
+
+// RemoteAddr returns the address of the process at the other end, with a
+// port numbering the connection. Unix sockets' clients are usually unnamed,
+// so this is what tells a server which connection a request was read from.
+func (c *FileConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+type fileConnAddr string
+
+func (a fileConnAddr) Network() string {
+	return "unix"
+}
+
+func (a fileConnAddr) String() string {
+	return string(a)
+}
+
+func (c *FileConn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	n, oobn, _, _, err := c.UnixConn.ReadMsgUnix(b, c.oob)
+
+	// ReadMsgUnix reports -1 bytes on errors, e.g. once closed or past the
+	// read deadline, which readers such as bufio's panic on
+	if n < 0 {
+		n = 0
+	}
+
+	if oobn > 0 {
+		files, parseErr := parseUnixRights(c.oob[:oobn])
+
+		c.mu.Lock()
+		c.files = append(c.files, files...)
+		c.mu.Unlock()
+
+		if err == nil {
+			err = parseErr
+		}
+	}
+
+	return n, err
+}
+
+// Files returns the files passed to the connection so far. They are no
+// longer kept by it, and are for the caller to close.
+func (c *FileConn) Files() []*os.File {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files := c.files
+	c.files = nil
+
+	return files
+}
+
+// Close closes the connection along with any files passed to it which were
+// not taken.
+func (c *FileConn) Close() error {
+	for _, file := range c.Files() {
+		file.Close()
+	}
+
+	return c.UnixConn.Close()
+}
+
+type fileListener struct {
+	net.Listener
+}
+
+func (l fileListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if unixConn, ok := conn.(*net.UnixConn); ok {
+		return NewFileConn(unixConn), nil
+	}
+
+	return conn, nil
+}
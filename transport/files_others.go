@@ -0,0 +1,50 @@
+// +build !windows
+
+package transport
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// FileListener returns a listener whose unix socket connections are
+// FileConns, for clients to pass files to.
+func FileListener(listener net.Listener) net.Listener {
+	return fileListener{listener}
+}
+
+func rightsSpace(files int) int {
+	return syscall.CmsgSpace(files * 4)
+}
+
+func unixRights(files []*os.File) ([]byte, error) {
+	fds := make([]int, len(files))
+	for i, file := range files {
+		fds[i] = int(file.Fd())
+	}
+
+	return syscall.UnixRights(fds...), nil
+}
+
+func parseUnixRights(oob []byte) ([]*os.File, error) {
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*os.File
+
+	for _, message := range messages {
+		fds, err := syscall.ParseUnixRights(&message)
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			files = append(files, os.NewFile(uintptr(fd), "passed"))
+		}
+	}
+
+	return files, nil
+}
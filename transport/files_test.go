@@ -0,0 +1,116 @@
+package transport_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"runtime"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Passing files", func() {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	var tmpdir string
+
+	var sender *transport.FileSender
+	var receiver net.Conn
+
+	var pipeR, pipeW *os.File
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "files")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		listener, err := transport.Listen("unix", path.Join(tmpdir, "files.sock"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer listener.Close()
+
+		pipeR, pipeW, err = os.Pipe()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		conn, err := net.Dial("unix", listener.Addr().String())
+		Ω(err).ShouldNot(HaveOccurred())
+
+		sender = transport.NewFileSender(conn.(*net.UnixConn), []*os.File{pipeW})
+
+		receiver, err = transport.FileListener(listener).Accept()
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		sender.Close()
+		receiver.Close()
+		pipeR.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	It("passes the files along with the first bytes written, for the receiver to take", func() {
+		_, err := sender.Write([]byte("hello"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = sender.Write([]byte(" world"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		pipeW.Close()
+
+		Ω(receiver).Should(BeAssignableToTypeOf(&transport.FileConn{}))
+		fileConn := receiver.(*transport.FileConn)
+
+		buf := make([]byte, 11)
+		n, err := fileConn.Read(buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(buf[:n])).Should(HavePrefix("hello"))
+
+		files := fileConn.Files()
+		Ω(files).Should(HaveLen(1))
+
+		_, err = files[0].Write([]byte("through the pipe"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		files[0].Close()
+
+		Ω(ioutil.ReadAll(pipeR)).Should(Equal([]byte("through the pipe")))
+
+		Ω(fileConn.Files()).Should(BeEmpty())
+	})
+
+	It("numbers each connection in the port of its remote address", func() {
+		host, port, err := net.SplitHostPort(receiver.RemoteAddr().String())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(host).Should(Equal(sender.LocalAddr().String()))
+		Ω(port).ShouldNot(BeEmpty())
+
+		another := transport.NewFileConn(receiver.(*transport.FileConn).UnixConn)
+		Ω(another.RemoteAddr().String()).ShouldNot(Equal(receiver.RemoteAddr().String()))
+	})
+
+	It("reads no bytes, rather than a negative count, past the read deadline", func() {
+		err := receiver.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		n, err := receiver.Read(make([]byte, 8))
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(net.Error).Timeout()).Should(BeTrue())
+		Ω(n).Should(BeZero())
+	})
+
+	It("reads no bytes, rather than a negative count, once closed", func() {
+		err := receiver.Close()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		n, err := receiver.Read(make([]byte, 8))
+		Ω(err).Should(HaveOccurred())
+		Ω(n).Should(BeZero())
+	})
+})
@@ -0,0 +1,26 @@
+// +build windows
+
+package transport
+
+import (
+	"net"
+	"os"
+)
+
+// files cannot be passed between processes over unix sockets on Windows
+
+func FileListener(listener net.Listener) net.Listener {
+	return listener
+}
+
+func rightsSpace(int) int {
+	return 0
+}
+
+func unixRights([]*os.File) ([]byte, error) {
+	return nil, ErrFilePassingUnsupported
+}
+
+func parseUnixRights([]byte) ([]*os.File, error) {
+	return nil, ErrFilePassingUnsupported
+}
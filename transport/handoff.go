@@ -0,0 +1,8 @@
+package transport
+
+import "errors"
+
+// ErrHandOffUnsupported is returned for listeners which cannot be handed off
+// to another process, e.g. named pipes, and on platforms without unix
+// sockets to hand them off over.
+var ErrHandOffUnsupported = errors.New("listener hand-off unsupported")
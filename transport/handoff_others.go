@@ -0,0 +1,69 @@
+// +build !windows
+
+package transport
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// SendListener passes a duplicate of the listener's socket to the process at
+// the other end of the connection, which receives it with ReceiveListener.
+//
+// The listener should be left open afterwards, only no longer accepted on, as
+// closing a unix listener removes its socket file, which the other process is
+// still listening on.
+func SendListener(conn *net.UnixConn, listener net.Listener) error {
+	filer, ok := listener.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return ErrHandOffUnsupported
+	}
+
+	file, err := filer.File()
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, _, err = conn.WriteMsgUnix([]byte{0}, syscall.UnixRights(int(file.Fd())), nil)
+	return err
+}
+
+// ReceiveListener receives a listener sent over the connection with
+// SendListener.
+func ReceiveListener(conn *net.UnixConn) (net.Listener, error) {
+	oob := make([]byte, syscall.CmsgSpace(4))
+
+	_, oobn, _, _, err := conn.ReadMsgUnix(make([]byte, 1), oob)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(messages) != 1 {
+		return nil, fmt.Errorf("expected a listener, got %d messages", len(messages))
+	}
+
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fds) != 1 {
+		return nil, fmt.Errorf("expected a listener, got %d descriptors", len(fds))
+	}
+
+	file := os.NewFile(uintptr(fds[0]), "listener")
+	defer file.Close()
+
+	return net.FileListener(file)
+}
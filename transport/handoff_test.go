@@ -0,0 +1,90 @@
+package transport_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"runtime"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handing off listeners", func() {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	var tmpdir string
+
+	var sender, receiver *net.UnixConn
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "handoff")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		handOffListener, err := net.ListenUnix("unix", &net.UnixAddr{
+			Name: path.Join(tmpdir, "handoff.sock"),
+			Net:  "unix",
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer handOffListener.Close()
+
+		sender, err = net.DialUnix("unix", nil, handOffListener.Addr().(*net.UnixAddr))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		receiver, err = handOffListener.AcceptUnix()
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		sender.Close()
+		receiver.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	It("passes the listener to the receiver, which accepts connections on the same socket file", func() {
+		socketPath := path.Join(tmpdir, "some.sock")
+
+		listener, err := transport.Listen("unix", socketPath)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer listener.Close()
+
+		err = transport.SendListener(sender, listener)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		received, err := transport.ReceiveListener(receiver)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer received.Close()
+
+		_, err = os.Stat(socketPath)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		conn, err := transport.Dial("unix", socketPath, time.Second)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer conn.Close()
+
+		accepted, err := received.Accept()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		accepted.Close()
+	})
+
+	It("fails to send listeners without a socket to pass", func() {
+		err := transport.SendListener(sender, new(fakeListener))
+		Ω(err).Should(Equal(transport.ErrHandOffUnsupported))
+	})
+})
+
+type fakeListener struct {
+	net.Listener
+}
@@ -0,0 +1,15 @@
+// +build windows
+
+package transport
+
+import "net"
+
+// listeners cannot be passed between processes over unix sockets on Windows
+
+func SendListener(*net.UnixConn, net.Listener) error {
+	return ErrHandOffUnsupported
+}
+
+func ReceiveListener(*net.UnixConn) (net.Listener, error) {
+	return nil, ErrHandOffUnsupported
+}
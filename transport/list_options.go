@@ -0,0 +1,17 @@
+package transport
+
+// Listing options are sent as headers rather than in the query string, where
+// every key filters by a container property of the same name. Servers which
+// predate an option ignore it.
+const (
+	// ListVerboseHeader set to "true" has the listing include each
+	// container's state and properties.
+	ListVerboseHeader = "X-Garden-List-Verbose"
+
+	// ListSortHeader chooses the order of the listing.
+	ListSortHeader = "X-Garden-List-Sort"
+
+	// ListStreamHeader set to "true" has the listing streamed as a JSON
+	// message per container.
+	ListStreamHeader = "X-Garden-List-Stream"
+)
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"io"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// MaxMessageSize bounds each request, response or process stream message
+// decoded, so that a corrupt or huge message fails with
+// api.MessageTooLargeError rather than being read into memory whole.
+const MaxMessageSize = 16 * 1024 * 1024
+
+// LimitMessage returns a reader of a single message which fails with
+// api.MessageTooLargeError once more than MaxMessageSize bytes are read.
+func LimitMessage(r io.Reader) io.Reader {
+	return &messageLimitedReader{r: r, limit: MaxMessageSize}
+}
+
+type messageLimitedReader struct {
+	r     io.Reader
+	limit uint64
+	read  uint64
+}
+
+// Read fails only once the limit is reached and more is wanted, as a message
+// of exactly the limit is decoded without reading past its end.
+func (r *messageLimitedReader) Read(p []byte) (int, error) {
+	remaining := r.limit - r.read
+	if remaining == 0 {
+		return 0, api.MessageTooLargeError{LimitInBytes: r.limit}
+	}
+
+	if uint64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := r.r.Read(p)
+	r.read += uint64(n)
+
+	return n, err
+}
+
+// next starts counting towards the limit afresh, for a stream's next message.
+func (r *messageLimitedReader) next() {
+	r.read = 0
+}
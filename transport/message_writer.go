@@ -3,10 +3,8 @@ package transport
 import (
 	"encoding/json"
 	"io"
-
-	"github.com/gogo/protobuf/proto"
 )
 
-func WriteMessage(writer io.Writer, req proto.Message) error {
+func WriteMessage(writer io.Writer, req interface{}) error {
 	return json.NewEncoder(writer).Encode(req)
 }
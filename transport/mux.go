@@ -0,0 +1,618 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// MuxWindow is how much data may be sent on a mux stream before the other
+// end has read it.
+const MuxWindow = 256 * 1024
+
+// muxMaxFrame bounds the data carried by a single mux frame, so that one
+// stream cannot hold up the others for long.
+const muxMaxFrame = 32 * 1024
+
+var ErrMuxSessionClosed = errors.New("mux session closed")
+var ErrMuxGoAway = errors.New("mux session going away")
+var ErrMuxStreamClosed = errors.New("mux stream closed")
+
+// Mux frames are a one byte kind, a four byte stream id, and a four byte
+// length (both big-endian). Data frames are followed by length bytes of
+// data; window frames grant length more bytes of window to the stream.
+const (
+	muxFrameOpen byte = iota
+	muxFrameData
+	muxFrameWindow
+	muxFrameClose
+	muxFrameGoAway
+)
+
+const muxHeaderSize = 9
+
+// MuxSession multiplexes streams over a single connection, each of which is a
+// net.Conn of its own. Clients Open streams and servers Accept them, so that
+// a server's session can be served like a net.Listener.
+type MuxSession struct {
+	conn   net.Conn
+	reader io.Reader
+
+	client bool
+	nextID uint32
+
+	writeL sync.Mutex
+
+	streams         map[uint32]*muxStream
+	goingAway       bool
+	remoteGoingAway bool
+	streamsL        sync.Mutex
+
+	accepts  chan *muxStream
+	goneAway chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMuxClient starts a session which opens streams over conn. br, if not
+// nil, is a reader over conn which may have buffered some of its data.
+func NewMuxClient(conn net.Conn, br *bufio.Reader) *MuxSession {
+	return newMuxSession(conn, br, true)
+}
+
+// NewMuxServer starts a session which accepts streams over conn. br, if not
+// nil, is a reader over conn which may have buffered some of its data.
+func NewMuxServer(conn net.Conn, br *bufio.Reader) *MuxSession {
+	return newMuxSession(conn, br, false)
+}
+
+func newMuxSession(conn net.Conn, br *bufio.Reader, client bool) *MuxSession {
+	var reader io.Reader = conn
+	if br != nil {
+		reader = br
+	}
+
+	// clients use odd stream ids, leaving even ones free for servers
+	nextID := uint32(2)
+	if client {
+		nextID = 1
+	}
+
+	session := &MuxSession{
+		conn:   conn,
+		reader: reader,
+
+		client: client,
+		nextID: nextID,
+
+		streams: make(map[uint32]*muxStream),
+
+		accepts:  make(chan *muxStream, 64),
+		goneAway: make(chan struct{}),
+
+		closed: make(chan struct{}),
+	}
+
+	go session.receive()
+
+	return session
+}
+
+// Open starts a new stream. It fails with ErrMuxGoAway once either end of the
+// session has gone away, after which a new session should be started.
+func (s *MuxSession) Open() (net.Conn, error) {
+	s.streamsL.Lock()
+
+	if s.isClosed() {
+		s.streamsL.Unlock()
+		return nil, ErrMuxSessionClosed
+	}
+
+	if s.goingAway || s.remoteGoingAway {
+		s.streamsL.Unlock()
+		return nil, ErrMuxGoAway
+	}
+
+	id := s.nextID
+	s.nextID += 2
+
+	stream := newMuxStream(s, id)
+	s.streams[id] = stream
+
+	s.streamsL.Unlock()
+
+	err := s.writeFrame(muxFrameOpen, id, 0, nil)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// Accept waits for the other end to open a stream.
+func (s *MuxSession) Accept() (net.Conn, error) {
+	select {
+	case <-s.goneAway:
+		return nil, ErrMuxGoAway
+	case <-s.closed:
+		return nil, ErrMuxSessionClosed
+	default:
+	}
+
+	select {
+	case stream := <-s.accepts:
+		return stream, nil
+	case <-s.goneAway:
+		return nil, ErrMuxGoAway
+	case <-s.closed:
+		return nil, ErrMuxSessionClosed
+	}
+}
+
+// GoAway stops the session accepting new streams, and tells the other end not
+// to open any. Streams which are already open are unaffected; the session is
+// closed once they all are.
+func (s *MuxSession) GoAway() error {
+	s.streamsL.Lock()
+
+	if s.goingAway {
+		s.streamsL.Unlock()
+		return nil
+	}
+
+	s.goingAway = true
+	close(s.goneAway)
+
+	s.streamsL.Unlock()
+
+	s.rejectAccepts()
+
+	err := s.writeFrame(muxFrameGoAway, 0, 0, nil)
+
+	s.closeIfIdle()
+
+	return err
+}
+
+// Close closes the session's connection, and with it all of its streams.
+func (s *MuxSession) Close() error {
+	var err error
+
+	s.closeOnce.Do(func() {
+		close(s.closed)
+
+		err = s.conn.Close()
+
+		s.streamsL.Lock()
+		streams := s.streams
+		s.streams = make(map[uint32]*muxStream)
+		s.streamsL.Unlock()
+
+		for _, stream := range streams {
+			stream.remoteClosed()
+		}
+	})
+
+	return err
+}
+
+// Closed is closed once the session is.
+func (s *MuxSession) Closed() <-chan struct{} {
+	return s.closed
+}
+
+func (s *MuxSession) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s *MuxSession) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *MuxSession) receive() {
+	defer s.Close()
+
+	header := make([]byte, muxHeaderSize)
+
+	for {
+		_, err := io.ReadFull(s.reader, header)
+		if err != nil {
+			return
+		}
+
+		kind := header[0]
+		id := binary.BigEndian.Uint32(header[1:5])
+		length := binary.BigEndian.Uint32(header[5:9])
+
+		switch kind {
+		case muxFrameOpen:
+			s.accept(id)
+
+		case muxFrameData:
+			if length > muxMaxFrame {
+				return
+			}
+
+			data := make([]byte, length)
+
+			_, err := io.ReadFull(s.reader, data)
+			if err != nil {
+				return
+			}
+
+			if stream := s.stream(id); stream != nil {
+				stream.received(data)
+			}
+
+		case muxFrameWindow:
+			if stream := s.stream(id); stream != nil {
+				stream.credited(length)
+			}
+
+		case muxFrameClose:
+			if stream := s.stream(id); stream != nil {
+				stream.remoteClosed()
+			}
+
+		case muxFrameGoAway:
+			s.streamsL.Lock()
+			s.remoteGoingAway = true
+			s.streamsL.Unlock()
+
+			s.closeIfIdle()
+
+		default:
+			return
+		}
+	}
+}
+
+func (s *MuxSession) accept(id uint32) {
+	s.streamsL.Lock()
+
+	// clients never accept streams
+	if s.client || s.goingAway {
+		s.streamsL.Unlock()
+		s.writeFrame(muxFrameClose, id, 0, nil)
+		return
+	}
+
+	stream := newMuxStream(s, id)
+	s.streams[id] = stream
+
+	s.streamsL.Unlock()
+
+	select {
+	case s.accepts <- stream:
+	case <-s.closed:
+		return
+	}
+
+	// the session may have gone away while the stream was being queued
+	s.streamsL.Lock()
+	goingAway := s.goingAway
+	s.streamsL.Unlock()
+
+	if goingAway {
+		s.rejectAccepts()
+	}
+}
+
+// rejectAccepts closes streams which were opened but never accepted
+func (s *MuxSession) rejectAccepts() {
+	for {
+		select {
+		case stream := <-s.accepts:
+			stream.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (s *MuxSession) stream(id uint32) *muxStream {
+	s.streamsL.Lock()
+	defer s.streamsL.Unlock()
+
+	return s.streams[id]
+}
+
+func (s *MuxSession) removeStream(id uint32) {
+	s.streamsL.Lock()
+	delete(s.streams, id)
+	s.streamsL.Unlock()
+
+	s.closeIfIdle()
+}
+
+func (s *MuxSession) closeIfIdle() {
+	s.streamsL.Lock()
+	idle := (s.goingAway || s.remoteGoingAway) && len(s.streams) == 0
+	s.streamsL.Unlock()
+
+	if idle {
+		s.Close()
+	}
+}
+
+func (s *MuxSession) writeFrame(kind byte, id uint32, length uint32, data []byte) error {
+	if s.isClosed() {
+		return ErrMuxSessionClosed
+	}
+
+	frame := make([]byte, muxHeaderSize+len(data))
+	frame[0] = kind
+	binary.BigEndian.PutUint32(frame[1:5], id)
+	binary.BigEndian.PutUint32(frame[5:9], length)
+	copy(frame[muxHeaderSize:], data)
+
+	s.writeL.Lock()
+	_, err := s.conn.Write(frame)
+	s.writeL.Unlock()
+
+	if err != nil {
+		s.Close()
+		return ErrMuxSessionClosed
+	}
+
+	return nil
+}
+
+type muxStream struct {
+	id      uint32
+	session *MuxSession
+
+	buf bytes.Buffer
+
+	// unacked is how much has been read without granting the window back
+	unacked    uint32
+	sendWindow uint32
+
+	localClosed   bool
+	remoteEnded   bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	l sync.Mutex
+
+	readReady  chan struct{}
+	writeReady chan struct{}
+}
+
+func newMuxStream(session *MuxSession, id uint32) *muxStream {
+	return &muxStream{
+		id:      id,
+		session: session,
+
+		sendWindow: MuxWindow,
+
+		readReady:  make(chan struct{}, 1),
+		writeReady: make(chan struct{}, 1),
+	}
+}
+
+func (s *muxStream) Read(p []byte) (int, error) {
+	for {
+		s.l.Lock()
+
+		if s.localClosed {
+			s.l.Unlock()
+			return 0, ErrMuxStreamClosed
+		}
+
+		if s.buf.Len() > 0 {
+			n, _ := s.buf.Read(p)
+
+			s.unacked += uint32(n)
+
+			var credit uint32
+			if s.unacked >= MuxWindow/4 {
+				credit = s.unacked
+				s.unacked = 0
+			}
+
+			ended := s.remoteEnded
+
+			s.l.Unlock()
+
+			if credit > 0 && !ended {
+				s.session.writeFrame(muxFrameWindow, s.id, credit, nil)
+			}
+
+			return n, nil
+		}
+
+		if s.remoteEnded {
+			s.l.Unlock()
+			return 0, io.EOF
+		}
+
+		deadline := s.readDeadline
+
+		s.l.Unlock()
+
+		err := waitForMux(s.readReady, deadline)
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (s *muxStream) Write(p []byte) (int, error) {
+	written := 0
+
+	for len(p) > 0 {
+		s.l.Lock()
+
+		if s.localClosed || s.remoteEnded {
+			s.l.Unlock()
+			return written, ErrMuxStreamClosed
+		}
+
+		if s.sendWindow == 0 {
+			deadline := s.writeDeadline
+
+			s.l.Unlock()
+
+			err := waitForMux(s.writeReady, deadline)
+			if err != nil {
+				return written, err
+			}
+
+			continue
+		}
+
+		n := len(p)
+		if n > int(s.sendWindow) {
+			n = int(s.sendWindow)
+		}
+
+		if n > muxMaxFrame {
+			n = muxMaxFrame
+		}
+
+		s.sendWindow -= uint32(n)
+
+		s.l.Unlock()
+
+		err := s.session.writeFrame(muxFrameData, s.id, uint32(n), p[:n])
+		if err != nil {
+			return written, err
+		}
+
+		written += n
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+func (s *muxStream) Close() error {
+	s.l.Lock()
+
+	if s.localClosed {
+		s.l.Unlock()
+		return nil
+	}
+
+	s.localClosed = true
+
+	s.l.Unlock()
+
+	notifyMux(s.readReady)
+	notifyMux(s.writeReady)
+
+	s.session.writeFrame(muxFrameClose, s.id, 0, nil)
+	s.session.removeStream(s.id)
+
+	return nil
+}
+
+func (s *muxStream) LocalAddr() net.Addr {
+	return s.session.conn.LocalAddr()
+}
+
+func (s *muxStream) RemoteAddr() net.Addr {
+	return s.session.conn.RemoteAddr()
+}
+
+func (s *muxStream) SetDeadline(t time.Time) error {
+	s.SetReadDeadline(t)
+	s.SetWriteDeadline(t)
+	return nil
+}
+
+func (s *muxStream) SetReadDeadline(t time.Time) error {
+	s.l.Lock()
+	s.readDeadline = t
+	s.l.Unlock()
+
+	// wake any reader to wait for the new deadline instead
+	notifyMux(s.readReady)
+
+	return nil
+}
+
+func (s *muxStream) SetWriteDeadline(t time.Time) error {
+	s.l.Lock()
+	s.writeDeadline = t
+	s.l.Unlock()
+
+	notifyMux(s.writeReady)
+
+	return nil
+}
+
+func (s *muxStream) received(data []byte) {
+	s.l.Lock()
+	s.buf.Write(data)
+	s.l.Unlock()
+
+	notifyMux(s.readReady)
+}
+
+func (s *muxStream) credited(n uint32) {
+	s.l.Lock()
+	s.sendWindow += n
+	s.l.Unlock()
+
+	notifyMux(s.writeReady)
+}
+
+func (s *muxStream) remoteClosed() {
+	s.l.Lock()
+	s.remoteEnded = true
+	s.l.Unlock()
+
+	notifyMux(s.readReady)
+	notifyMux(s.writeReady)
+}
+
+func notifyMux(ready chan<- struct{}) {
+	select {
+	case ready <- struct{}{}:
+	default:
+	}
+}
+
+func waitForMux(ready <-chan struct{}, deadline time.Time) error {
+	if deadline.IsZero() {
+		<-ready
+		return nil
+	}
+
+	timeout := deadline.Sub(time.Now())
+	if timeout <= 0 {
+		return muxTimeoutError{}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return nil
+	case <-timer.C:
+		return muxTimeoutError{}
+	}
+}
+
+// muxTimeoutError is returned by stream reads and writes which pass their
+// deadline, and is a net.Error so that callers recognise it as a timeout.
+type muxTimeoutError struct{}
+
+func (muxTimeoutError) Error() string   { return "mux stream i/o timeout" }
+func (muxTimeoutError) Timeout() bool   { return true }
+func (muxTimeoutError) Temporary() bool { return true }
@@ -0,0 +1,194 @@
+package transport_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multiplexed sessions", func() {
+	var client *transport.MuxSession
+	var server *transport.MuxSession
+
+	BeforeEach(func() {
+		clientConn, serverConn := net.Pipe()
+
+		client = transport.NewMuxClient(clientConn, nil)
+		server = transport.NewMuxServer(serverConn, nil)
+	})
+
+	AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	openStreams := func() (net.Conn, net.Conn) {
+		clientStream, err := client.Open()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		serverStream, err := server.Accept()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return clientStream, serverStream
+	}
+
+	It("carries data both ways over each stream independently", func() {
+		clientA, serverA := openStreams()
+		clientB, serverB := openStreams()
+
+		_, err := clientA.Write([]byte("to a"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = clientB.Write([]byte("to b"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = serverB.Write([]byte("from b"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		buf := make([]byte, 64)
+
+		n, err := serverA.Read(buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(buf[:n])).Should(Equal("to a"))
+
+		n, err = serverB.Read(buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(buf[:n])).Should(Equal("to b"))
+
+		n, err = clientB.Read(buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(buf[:n])).Should(Equal("from b"))
+	})
+
+	It("carries more data than the window once it is read", func() {
+		clientStream, serverStream := openStreams()
+
+		data := bytes.Repeat([]byte("x"), 4*transport.MuxWindow)
+
+		go func() {
+			defer GinkgoRecover()
+
+			_, err := clientStream.Write(data)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			clientStream.Close()
+		}()
+
+		received, err := ioutil.ReadAll(serverStream)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(received).Should(Equal(data))
+	})
+
+	It("does not let a stream whose data is not read hold up the others", func() {
+		clientA, _ := openStreams()
+		clientB, serverB := openStreams()
+
+		written := make(chan struct{})
+
+		go func() {
+			clientA.Write(bytes.Repeat([]byte("x"), 2*transport.MuxWindow))
+			close(written)
+		}()
+
+		Consistently(written).ShouldNot(BeClosed())
+
+		_, err := clientB.Write([]byte("hello"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(serverB, buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(buf)).Should(Equal("hello"))
+	})
+
+	It("ends a stream's data once the other end closes it", func() {
+		clientStream, serverStream := openStreams()
+
+		_, err := serverStream.Write([]byte("goodbye"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		err = serverStream.Close()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		received, err := ioutil.ReadAll(clientStream)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(received)).Should(Equal("goodbye"))
+
+		_, err = clientStream.Write([]byte("hello?"))
+		Ω(err).Should(Equal(transport.ErrMuxStreamClosed))
+	})
+
+	It("times out reads which pass their deadline", func() {
+		clientStream, _ := openStreams()
+
+		err := clientStream.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = clientStream.Read(make([]byte, 1))
+		Ω(err).Should(HaveOccurred())
+
+		netErr, ok := err.(net.Error)
+		Ω(ok).Should(BeTrue())
+		Ω(netErr.Timeout()).Should(BeTrue())
+	})
+
+	Context("when the server goes away", func() {
+		It("keeps open streams, but refuses new ones", func() {
+			clientStream, serverStream := openStreams()
+
+			err := server.GoAway()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = server.Accept()
+			Ω(err).Should(Equal(transport.ErrMuxGoAway))
+
+			Eventually(func() error {
+				_, err := client.Open()
+				return err
+			}).Should(Equal(transport.ErrMuxGoAway))
+
+			_, err = clientStream.Write([]byte("still here"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			buf := make([]byte, 10)
+			_, err = io.ReadFull(serverStream, buf)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(buf)).Should(Equal("still here"))
+		})
+
+		It("closes the session once its streams are closed", func() {
+			clientStream, _ := openStreams()
+
+			err := server.GoAway()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Consistently(server.Closed()).ShouldNot(BeClosed())
+
+			clientStream.Close()
+
+			Eventually(server.Closed()).Should(BeClosed())
+			Eventually(client.Closed()).Should(BeClosed())
+		})
+	})
+
+	Context("when the session is closed", func() {
+		It("ends its streams", func() {
+			clientStream, _ := openStreams()
+
+			server.Close()
+
+			_, err := ioutil.ReadAll(clientStream)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = client.Open()
+			Ω(err).Should(Equal(transport.ErrMuxSessionClosed))
+		})
+	})
+})
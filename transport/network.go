@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// A Network listens and dials on a kind of address which the net package
+// does not, e.g. a Windows named pipe, which Windows backends may register
+// as others listen on unix sockets.
+type Network struct {
+	Listen func(address string) (net.Listener, error)
+	Dial   func(address string, timeout time.Duration) (net.Conn, error)
+}
+
+var (
+	networks  = map[string]Network{}
+	networksL sync.RWMutex
+)
+
+// RegisterNetwork makes a network available to clients and servers by name,
+// replacing any network already registered with it.
+func RegisterNetwork(name string, network Network) {
+	networksL.Lock()
+	networks[name] = network
+	networksL.Unlock()
+}
+
+// Listen listens on the address with the network registered by its name, or
+// else with the net package, e.g. for "tcp" or "unix".
+func Listen(network, address string) (net.Listener, error) {
+	registered, found := lookupNetwork(network)
+	if !found {
+		return net.Listen(network, address)
+	}
+
+	return registered.Listen(address)
+}
+
+// Dial connects to the address with the network registered by its name, or
+// else with the net package, giving up after the timeout.
+func Dial(network, address string, timeout time.Duration) (net.Conn, error) {
+	registered, found := lookupNetwork(network)
+	if !found {
+		return net.DialTimeout(network, address, timeout)
+	}
+
+	return registered.Dial(address, timeout)
+}
+
+func lookupNetwork(name string) (Network, bool) {
+	networksL.RLock()
+	network, found := networks[name]
+	networksL.RUnlock()
+
+	return network, found
+}
@@ -0,0 +1,75 @@
+package transport_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Networks", func() {
+	var tmpdir string
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "networks")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	It("listens and dials with the net package for networks it knows", func() {
+		socketPath := path.Join(tmpdir, "some.sock")
+
+		listener, err := transport.Listen("unix", socketPath)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer listener.Close()
+
+		conn, err := transport.Dial("unix", socketPath, time.Second)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		conn.Close()
+	})
+
+	It("listens and dials with registered networks", func() {
+		socketPath := path.Join(tmpdir, "some.sock")
+
+		var listened, dialed string
+		var dialTimeout time.Duration
+
+		transport.RegisterNetwork("some-network", transport.Network{
+			Listen: func(address string) (net.Listener, error) {
+				listened = address
+				return net.Listen("unix", socketPath)
+			},
+			Dial: func(address string, timeout time.Duration) (net.Conn, error) {
+				dialed = address
+				dialTimeout = timeout
+				return net.Dial("unix", socketPath)
+			},
+		})
+
+		listener, err := transport.Listen("some-network", "some-address")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer listener.Close()
+
+		conn, err := transport.Dial("some-network", "some-other-address", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		conn.Close()
+
+		Ω(listened).Should(Equal("some-address"))
+		Ω(dialed).Should(Equal("some-other-address"))
+		Ω(dialTimeout).Should(Equal(time.Minute))
+	})
+})
@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+)
+
+// ProcessStreamFramingHeader is set by clients on requests for process
+// streams to ask for binary framing, and echoed by servers which agree to it.
+// Without it both ends fall back to newline-delimited JSON messages.
+const ProcessStreamFramingHeader = "X-Garden-Process-Stream-Framing"
+
+const FramingBinary = "binary"
+
+// MaxFrameSize bounds the body of a single binary frame.
+const MaxFrameSize = 16 * 1024 * 1024
+
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// Binary frames are a one byte kind, a four byte process id, and a four byte
+// body length (both big-endian), followed by the body. Stream data is carried
+// as raw bytes; every other message is a JSON-encoded control frame.
+const (
+	frameControl byte = iota
+	frameStdin
+	frameStdout
+	frameStderr
+)
+
+const frameHeaderSize = 9
+
+type MessageReader interface {
+	ReadMessage(msg interface{}) error
+}
+
+type MessageWriter interface {
+	WriteMessage(msg interface{}) error
+}
+
+// NewJSONReader reads newline-delimited JSON messages, failing with
+// api.MessageTooLargeError on any message bigger than MaxMessageSize.
+func NewJSONReader(r io.Reader) MessageReader {
+	limited := &messageLimitedReader{r: r, limit: MaxMessageSize}
+	return jsonReader{json.NewDecoder(limited), limited}
+}
+
+func NewJSONWriter(w io.Writer) MessageWriter {
+	return jsonWriter{w}
+}
+
+func NewFramedReader(r io.Reader) MessageReader {
+	return &framedReader{r: r}
+}
+
+func NewFramedWriter(w io.Writer) MessageWriter {
+	return framedWriter{w}
+}
+
+type jsonReader struct {
+	decoder *json.Decoder
+	limited *messageLimitedReader
+}
+
+func (r jsonReader) ReadMessage(msg interface{}) error {
+	// the decoder reads ahead, so each message is counted from wherever the
+	// last one finished reading, rather than exactly from its own start
+	r.limited.next()
+	return r.decoder.Decode(msg)
+}
+
+type jsonWriter struct {
+	w io.Writer
+}
+
+func (w jsonWriter) WriteMessage(msg interface{}) error {
+	return WriteMessage(w.w, msg)
+}
+
+type framedWriter struct {
+	w io.Writer
+}
+
+func (w framedWriter) WriteMessage(msg interface{}) error {
+	var kind byte
+	var processID uint32
+	var body []byte
+
+	if payload, ok := msg.(*apitypes.ProcessPayload); ok && isStreamData(payload) {
+		kind = frameStdin + byte(payload.GetSource())
+		processID = payload.GetProcessId()
+		body = []byte(payload.GetData())
+	} else {
+		var err error
+		body, err = json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(body) > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	// a single write keeps frames intact on connections shared by processes
+	frame := make([]byte, frameHeaderSize+len(body))
+	frame[0] = kind
+	binary.BigEndian.PutUint32(frame[1:5], processID)
+	binary.BigEndian.PutUint32(frame[5:9], uint32(len(body)))
+	copy(frame[frameHeaderSize:], body)
+
+	_, err := w.w.Write(frame)
+	return err
+}
+
+type framedReader struct {
+	r      io.Reader
+	header [frameHeaderSize]byte
+}
+
+func (r *framedReader) ReadMessage(msg interface{}) error {
+	_, err := io.ReadFull(r.r, r.header[:])
+	if err != nil {
+		return err
+	}
+
+	kind := r.header[0]
+	processID := binary.BigEndian.Uint32(r.header[1:5])
+	length := binary.BigEndian.Uint32(r.header[5:9])
+
+	if length > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	body := make([]byte, length)
+
+	_, err = io.ReadFull(r.r, body)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case frameControl:
+		return json.Unmarshal(body, msg)
+
+	case frameStdin, frameStdout, frameStderr:
+		payload, ok := msg.(*apitypes.ProcessPayload)
+		if !ok {
+			return fmt.Errorf("unexpected stream data frame for %T", msg)
+		}
+
+		source := apitypes.ProcessPayload_Source(kind - frameStdin)
+
+		*payload = apitypes.ProcessPayload{
+			ProcessId: apitypes.Uint32(processID),
+			Source:    &source,
+			Data:      apitypes.String(string(body)),
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("unknown frame kind: %d", kind)
+	}
+}
+
+// isStreamData reports whether the payload carries nothing but stream data,
+// and so can be sent as a raw data frame.
+func isStreamData(payload *apitypes.ProcessPayload) bool {
+	return payload.Data != nil &&
+		payload.ExitStatus == nil &&
+		payload.Error == nil &&
+		payload.Tty == nil &&
+		payload.StdinWindow == nil &&
+		payload.StdinAck == nil &&
+		payload.Rlimits == nil
+}
@@ -0,0 +1,200 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Process streams", func() {
+	var buffer *bytes.Buffer
+
+	stdout := apitypes.ProcessPayload_stdout
+	stderr := apitypes.ProcessPayload_stderr
+
+	BeforeEach(func() {
+		buffer = new(bytes.Buffer)
+	})
+
+	Describe("binary framing", func() {
+		var writer transport.MessageWriter
+		var reader transport.MessageReader
+
+		BeforeEach(func() {
+			writer = transport.NewFramedWriter(buffer)
+			reader = transport.NewFramedReader(buffer)
+		})
+
+		It("round-trips binary stream data intact", func() {
+			data := string([]byte{0xff, 0x00, 0xfe, '\n', 0x80})
+
+			err := writer.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(42),
+				Source:    &stderr,
+				Data:      apitypes.String(data),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var payload apitypes.ProcessPayload
+			err = reader.ReadMessage(&payload)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(payload).Should(Equal(apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(42),
+				Source:    &stderr,
+				Data:      apitypes.String(data),
+			}))
+		})
+
+		It("sends stream data without encoding it", func() {
+			err := writer.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(42),
+				Source:    &stdout,
+				Data:      apitypes.String("hello"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(buffer.Bytes()).Should(Equal([]byte{2, 0, 0, 0, 42, 0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o'}))
+		})
+
+		It("round-trips control messages", func() {
+			err := writer.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId:  apitypes.Uint32(42),
+				ExitStatus: apitypes.Uint32(3),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = writer.WriteMessage(&apitypes.AttachAllResponse{
+				ProcessIds: []uint32{1, 2},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var payload apitypes.ProcessPayload
+			err = reader.ReadMessage(&payload)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(payload).Should(Equal(apitypes.ProcessPayload{
+				ProcessId:  apitypes.Uint32(42),
+				ExitStatus: apitypes.Uint32(3),
+			}))
+
+			var attached apitypes.AttachAllResponse
+			err = reader.ReadMessage(&attached)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(attached.GetProcessIds()).Should(Equal([]uint32{1, 2}))
+		})
+
+		It("returns EOF once the stream ends", func() {
+			var payload apitypes.ProcessPayload
+			err := reader.ReadMessage(&payload)
+			Ω(err).Should(Equal(io.EOF))
+		})
+
+		Context("when a frame is truncated", func() {
+			It("returns an unexpected EOF", func() {
+				buffer.Write([]byte{2, 0, 0, 0, 42, 0, 0, 0, 5, 'h', 'e'})
+
+				var payload apitypes.ProcessPayload
+				err := reader.ReadMessage(&payload)
+				Ω(err).Should(Equal(io.ErrUnexpectedEOF))
+			})
+		})
+
+		Context("when a frame is too large", func() {
+			It("refuses to read it", func() {
+				header := []byte{2, 0, 0, 0, 42, 0, 0, 0, 0}
+				binary.BigEndian.PutUint32(header[5:], transport.MaxFrameSize+1)
+				buffer.Write(header)
+
+				var payload apitypes.ProcessPayload
+				err := reader.ReadMessage(&payload)
+				Ω(err).Should(Equal(transport.ErrFrameTooLarge))
+			})
+		})
+
+		Context("when a stream data frame is read into another type", func() {
+			It("returns an error", func() {
+				err := writer.WriteMessage(&apitypes.ProcessPayload{
+					ProcessId: apitypes.Uint32(42),
+					Source:    &stdout,
+					Data:      apitypes.String("hello"),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var attached apitypes.AttachAllResponse
+				err = reader.ReadMessage(&attached)
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("JSON messages", func() {
+		It("writes newline-delimited JSON", func() {
+			writer := transport.NewJSONWriter(buffer)
+
+			err := writer.WriteMessage(&apitypes.ProcessPayload{
+				ProcessId: apitypes.Uint32(42),
+				Source:    &stdout,
+				Data:      apitypes.String("hello"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(buffer.String()).Should(Equal(`{"process_id":42,"source":1,"data":"hello"}` + "\n"))
+
+			var payload apitypes.ProcessPayload
+			err = transport.NewJSONReader(buffer).ReadMessage(&payload)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(payload.GetData()).Should(Equal("hello"))
+		})
+
+		It("reads any number of messages, each within MaxMessageSize", func() {
+			writer := transport.NewJSONWriter(buffer)
+
+			data := strings.Repeat("x", transport.MaxMessageSize/2)
+
+			for i := 0; i < 3; i++ {
+				err := writer.WriteMessage(&apitypes.ProcessPayload{
+					Source: &stdout,
+					Data:   apitypes.String(data),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			reader := transport.NewJSONReader(buffer)
+
+			for i := 0; i < 3; i++ {
+				var payload apitypes.ProcessPayload
+				err := reader.ReadMessage(&payload)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(payload.GetData()).Should(Equal(data))
+			}
+		})
+
+		Context("when a message is bigger than MaxMessageSize", func() {
+			It("fails with a MessageTooLargeError", func() {
+				writer := transport.NewJSONWriter(buffer)
+
+				err := writer.WriteMessage(&apitypes.ProcessPayload{
+					Source: &stdout,
+					Data:   apitypes.String(strings.Repeat("x", transport.MaxMessageSize)),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var payload apitypes.ProcessPayload
+				err = transport.NewJSONReader(buffer).ReadMessage(&payload)
+				Ω(err).Should(Equal(api.MessageTooLargeError{LimitInBytes: transport.MaxMessageSize}))
+			})
+		})
+	})
+})
@@ -0,0 +1,447 @@
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden/api"
+)
+
+// ContentTypeProtobuf is the content type of messages encoded as protocol
+// buffers, which are more compact than JSON.
+const ContentTypeProtobuf = "application/x-protobuf"
+
+var ErrMalformedProtobuf = errors.New("malformed protocol buffer")
+
+// Protobuf encodes messages as protocol buffers, numbering their fields as
+// the protobuf tags of their apitypes do. Fields it does not know, e.g. from
+// a newer peer, are skipped when decoding.
+var Protobuf Codec = protobufCodec{}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) Encode(w io.Writer, msg interface{}) error {
+	value := reflect.ValueOf(msg)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot encode %T as a protocol buffer", msg)
+	}
+
+	buf, err := appendMessage(nil, value.Elem())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// Decode reads the rest of the reader as a single message, as protocol
+// buffers do not delimit themselves.
+func (protobufCodec) Decode(r io.Reader, msg interface{}) error {
+	value := reflect.ValueOf(msg)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode a protocol buffer into %T", msg)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, MaxMessageSize+1))
+	if err != nil {
+		return err
+	}
+
+	if len(data) > MaxMessageSize {
+		return api.MessageTooLargeError{LimitInBytes: MaxMessageSize}
+	}
+
+	return decodeMessage(data, value.Elem())
+}
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+type protobufField struct {
+	index    int
+	number   uint64
+	wireType uint64
+}
+
+// protobufFields reads the numbers and wire types of a struct's fields from
+// their tags, e.g. `protobuf:"bytes,1,opt,name=handle"`.
+func protobufFields(structType reflect.Type) ([]protobufField, error) {
+	var fields []protobufField
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("protobuf"), ",")
+		if len(tag) < 2 {
+			return nil, fmt.Errorf("field %s of %s has no protobuf tag", field.Name, structType)
+		}
+
+		number, err := strconv.ParseUint(tag[1], 10, 29)
+		if err != nil || number == 0 {
+			return nil, fmt.Errorf("field %s of %s has an invalid protobuf number", field.Name, structType)
+		}
+
+		var wireType uint64
+		switch tag[0] {
+		case "varint":
+			wireType = wireVarint
+		case "fixed64":
+			wireType = wireFixed64
+		case "bytes":
+			wireType = wireBytes
+		case "fixed32":
+			wireType = wireFixed32
+		default:
+			return nil, fmt.Errorf("field %s of %s has unsupported protobuf type %q", field.Name, structType, tag[0])
+		}
+
+		fields = append(fields, protobufField{
+			index:    i,
+			number:   number,
+			wireType: wireType,
+		})
+	}
+
+	return fields, nil
+}
+
+func appendMessage(buf []byte, message reflect.Value) ([]byte, error) {
+	fields, err := protobufFields(message.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		value := message.Field(field.index)
+
+		switch {
+		case value.Kind() == reflect.Ptr:
+			if value.IsNil() {
+				continue
+			}
+
+			buf, err = appendField(buf, field, value.Elem())
+
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8:
+			// repeated fields are not packed, as proto2 has them by default
+			for i := 0; i < value.Len(); i++ {
+				element := value.Index(i)
+				if element.Kind() == reflect.Ptr {
+					if element.IsNil() {
+						continue
+					}
+
+					element = element.Elem()
+				}
+
+				buf, err = appendField(buf, field, element)
+				if err != nil {
+					break
+				}
+			}
+
+		default:
+			if isZero(value) {
+				continue
+			}
+
+			buf, err = appendField(buf, field, value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf, nil
+}
+
+func appendField(buf []byte, field protobufField, value reflect.Value) ([]byte, error) {
+	buf = appendVarint(buf, field.number<<3|field.wireType)
+
+	switch field.wireType {
+	case wireVarint:
+		switch value.Kind() {
+		case reflect.Bool:
+			if value.Bool() {
+				return appendVarint(buf, 1), nil
+			}
+
+			return appendVarint(buf, 0), nil
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return appendVarint(buf, uint64(value.Int())), nil
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return appendVarint(buf, value.Uint()), nil
+		}
+
+	case wireFixed64:
+		if value.Kind() == reflect.Float64 {
+			var fixed [8]byte
+			binary.LittleEndian.PutUint64(fixed[:], math.Float64bits(value.Float()))
+			return append(buf, fixed[:]...), nil
+		}
+
+	case wireFixed32:
+		if value.Kind() == reflect.Float32 {
+			var fixed [4]byte
+			binary.LittleEndian.PutUint32(fixed[:], math.Float32bits(float32(value.Float())))
+			return append(buf, fixed[:]...), nil
+		}
+
+	case wireBytes:
+		switch value.Kind() {
+		case reflect.String:
+			buf = appendVarint(buf, uint64(value.Len()))
+			return append(buf, value.String()...), nil
+
+		case reflect.Slice:
+			buf = appendVarint(buf, uint64(value.Len()))
+			return append(buf, value.Bytes()...), nil
+
+		case reflect.Struct:
+			message, err := appendMessage(nil, value)
+			if err != nil {
+				return nil, err
+			}
+
+			buf = appendVarint(buf, uint64(len(message)))
+			return append(buf, message...), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot encode %s as a protobuf field %d", value.Type(), field.number)
+}
+
+func appendVarint(buf []byte, x uint64) []byte {
+	for x >= 0x80 {
+		buf = append(buf, byte(x)|0x80)
+		x >>= 7
+	}
+
+	return append(buf, byte(x))
+}
+
+func isZero(value reflect.Value) bool {
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
+
+func decodeMessage(data []byte, message reflect.Value) error {
+	fields, err := protobufFields(message.Type())
+	if err != nil {
+		return err
+	}
+
+	byNumber := map[uint64]protobufField{}
+	for _, field := range fields {
+		byNumber[field.number] = field
+	}
+
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrMalformedProtobuf
+		}
+
+		data = data[n:]
+
+		number, wireType := key>>3, key&7
+
+		var raw []byte
+		var x uint64
+
+		switch wireType {
+		case wireVarint:
+			x, n = binary.Uvarint(data)
+			if n <= 0 {
+				return ErrMalformedProtobuf
+			}
+
+		case wireFixed64:
+			if len(data) < 8 {
+				return ErrMalformedProtobuf
+			}
+
+			x, n = binary.LittleEndian.Uint64(data), 8
+
+		case wireFixed32:
+			if len(data) < 4 {
+				return ErrMalformedProtobuf
+			}
+
+			x, n = uint64(binary.LittleEndian.Uint32(data)), 4
+
+		case wireBytes:
+			length, lengthN := binary.Uvarint(data)
+			if lengthN <= 0 || length > uint64(len(data)-lengthN) {
+				return ErrMalformedProtobuf
+			}
+
+			raw = data[lengthN : lengthN+int(length)]
+			n = lengthN + int(length)
+
+		default:
+			return ErrMalformedProtobuf
+		}
+
+		data = data[n:]
+
+		field, found := byNumber[number]
+		if !found {
+			continue
+		}
+
+		value := message.Field(field.index)
+
+		switch {
+		case value.Kind() == reflect.Ptr:
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+
+			err = decodeField(value.Elem(), field, wireType, x, raw)
+
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8:
+			err = decodeRepeated(value, field, wireType, x, raw)
+
+		default:
+			err = decodeField(value, field, wireType, x, raw)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeRepeated appends an element to a repeated field, or each of a packed
+// field's elements, as peers packing them may send.
+func decodeRepeated(value reflect.Value, field protobufField, wireType, x uint64, raw []byte) error {
+	elementType := value.Type().Elem()
+
+	if wireType == wireBytes && field.wireType != wireBytes {
+		packed := protobufField{number: field.number, wireType: field.wireType}
+
+		for len(raw) > 0 {
+			var n int
+
+			switch field.wireType {
+			case wireVarint:
+				x, n = binary.Uvarint(raw)
+			case wireFixed64:
+				if len(raw) >= 8 {
+					x, n = binary.LittleEndian.Uint64(raw), 8
+				}
+			case wireFixed32:
+				if len(raw) >= 4 {
+					x, n = uint64(binary.LittleEndian.Uint32(raw)), 4
+				}
+			}
+
+			if n <= 0 {
+				return ErrMalformedProtobuf
+			}
+
+			raw = raw[n:]
+
+			err := decodeRepeated(value, packed, field.wireType, x, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	element := reflect.New(elementType).Elem()
+	if elementType.Kind() == reflect.Ptr {
+		element.Set(reflect.New(elementType.Elem()))
+
+		err := decodeField(element.Elem(), field, wireType, x, raw)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := decodeField(element, field, wireType, x, raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	value.Set(reflect.Append(value, element))
+
+	return nil
+}
+
+func decodeField(value reflect.Value, field protobufField, wireType, x uint64, raw []byte) error {
+	if wireType != field.wireType {
+		return ErrMalformedProtobuf
+	}
+
+	switch wireType {
+	case wireVarint:
+		switch value.Kind() {
+		case reflect.Bool:
+			value.SetBool(x != 0)
+			return nil
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			value.SetInt(int64(x))
+			return nil
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			value.SetUint(x)
+			return nil
+		}
+
+	case wireFixed64:
+		if value.Kind() == reflect.Float64 {
+			value.SetFloat(math.Float64frombits(x))
+			return nil
+		}
+
+	case wireFixed32:
+		if value.Kind() == reflect.Float32 {
+			value.SetFloat(float64(math.Float32frombits(uint32(x))))
+			return nil
+		}
+
+	case wireBytes:
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString(string(raw))
+			return nil
+
+		case reflect.Slice:
+			value.SetBytes(append([]byte{}, raw...))
+			return nil
+
+		case reflect.Struct:
+			return decodeMessage(raw, value)
+		}
+	}
+
+	return fmt.Errorf("cannot decode protobuf field %d into %s", field.number, value.Type())
+}
@@ -0,0 +1,301 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+
+// proxyV2Signature begins every version 2 PROXY protocol header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// maxProxyV1HeaderSize bounds a version 1 header, including its CRLF.
+const maxProxyV1HeaderSize = 107
+
+// ProxyProtocolListener returns a listener whose connections each begin with
+// a PROXY protocol header, version 1 or 2, as sent by load balancers such as
+// HAProxy, and which report the addresses the header gives as their own.
+// Connections whose header is missing, invalid, or not sent within the
+// timeout are closed without being accepted. Headers are read apart from
+// Accept, so that slow connections do not hold up others.
+func ProxyProtocolListener(listener net.Listener, timeout time.Duration) net.Listener {
+	l := &proxyListener{
+		Listener: listener,
+		timeout:  timeout,
+		accepted: make(chan acceptResult),
+		closed:   make(chan struct{}),
+	}
+
+	go l.acceptLoop()
+
+	return l
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+type proxyListener struct {
+	net.Listener
+
+	timeout  time.Duration
+	accepted chan acceptResult
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	select {
+	case result := <-l.accepted:
+		return result.conn, result.err
+	case <-l.closed:
+		return nil, errors.New("use of closed network connection")
+	}
+}
+
+func (l *proxyListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
+
+func (l *proxyListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			if !l.deliver(acceptResult{err: err}) {
+				return
+			}
+
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				continue
+			}
+
+			return
+		}
+
+		go l.readHeader(conn)
+	}
+}
+
+func (l *proxyListener) readHeader(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(l.timeout))
+
+	proxied, err := readProxyHeader(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	conn.SetReadDeadline(time.Time{})
+
+	if !l.deliver(acceptResult{conn: proxied}) {
+		conn.Close()
+	}
+}
+
+// deliver hands a result to Accept, returning false if the listener was
+// closed first.
+func (l *proxyListener) deliver(result acceptResult) bool {
+	select {
+	case l.accepted <- result:
+		return true
+	case <-l.closed:
+		return false
+	}
+}
+
+// proxyConn is a connection which reports the addresses its PROXY protocol
+// header gave, reading on past the header through the buffer it was read
+// with.
+type proxyConn struct {
+	net.Conn
+
+	r      *bufio.Reader
+	remote net.Addr
+	local  net.Addr
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *proxyConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func readProxyHeader(conn net.Conn) (net.Conn, error) {
+	proxied := &proxyConn{
+		Conn:   conn,
+		r:      bufio.NewReader(conn),
+		remote: conn.RemoteAddr(),
+		local:  conn.LocalAddr(),
+	}
+
+	// every header is longer than the version 2 signature
+	start, err := proxied.r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(start, proxyV2Signature) {
+		err = proxied.readV2Header()
+	} else if bytes.HasPrefix(start, []byte("PROXY ")) {
+		err = proxied.readV1Header()
+	} else {
+		err = ErrInvalidProxyHeader
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return proxied, nil
+}
+
+// readV1Header reads a header such as
+// "PROXY TCP4 192.0.2.1 192.0.2.2 56324 7777\r\n". The addresses of
+// "PROXY UNKNOWN" headers, e.g. for a load balancer's health checks, are
+// the connection's own.
+func (c *proxyConn) readV1Header() error {
+	var line []byte
+
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		line = append(line, b)
+
+		if b == '\n' {
+			break
+		}
+
+		if len(line) >= maxProxyV1HeaderSize {
+			return ErrInvalidProxyHeader
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return ErrInvalidProxyHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return ErrInvalidProxyHeader
+	}
+
+	remote, err := parseProxyAddr(fields[2], fields[4])
+	if err != nil {
+		return err
+	}
+
+	local, err := parseProxyAddr(fields[3], fields[5])
+	if err != nil {
+		return err
+	}
+
+	c.remote = remote
+	c.local = local
+
+	return nil
+}
+
+func parseProxyAddr(host, port string) (net.Addr, error) {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	parsedPort, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(parsedPort)}, nil
+}
+
+// readV2Header reads a binary header: the signature, a version and command
+// byte, an address family and protocol byte, and a big-endian length of the
+// addresses and any TLVs which follow. The addresses of LOCAL commands, and
+// of families other than TCP over IPv4 or IPv6, are the connection's own.
+func (c *proxyConn) readV2Header() error {
+	header := make([]byte, len(proxyV2Signature)+4)
+
+	_, err := io.ReadFull(c.r, header)
+	if err != nil {
+		return err
+	}
+
+	versionCommand := header[12]
+	family := header[13]
+	length := binary.BigEndian.Uint16(header[14:16])
+
+	if versionCommand>>4 != 2 {
+		return ErrInvalidProxyHeader
+	}
+
+	body := make([]byte, length)
+
+	_, err = io.ReadFull(c.r, body)
+	if err != nil {
+		return err
+	}
+
+	switch versionCommand & 0x0f {
+	case 0x0:
+		// LOCAL
+		return nil
+	case 0x1:
+		// PROXY
+	default:
+		return ErrInvalidProxyHeader
+	}
+
+	var ipSize int
+
+	switch family {
+	case 0x11:
+		ipSize = net.IPv4len
+	case 0x21:
+		ipSize = net.IPv6len
+	default:
+		return nil
+	}
+
+	if len(body) < 2*ipSize+4 {
+		return ErrInvalidProxyHeader
+	}
+
+	c.remote = &net.TCPAddr{
+		IP:   net.IP(body[:ipSize]),
+		Port: int(binary.BigEndian.Uint16(body[2*ipSize:])),
+	}
+
+	c.local = &net.TCPAddr{
+		IP:   net.IP(body[ipSize : 2*ipSize]),
+		Port: int(binary.BigEndian.Uint16(body[2*ipSize+2:])),
+	}
+
+	return nil
+}
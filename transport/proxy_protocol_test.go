@@ -0,0 +1,141 @@
+package transport_test
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PROXY protocol listeners", func() {
+	var listener net.Listener
+
+	BeforeEach(func() {
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		listener = transport.ProxyProtocolListener(tcpListener, time.Second)
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	send := func(header []byte) net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = conn.Write(append(header, []byte("hello")...))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return conn
+	}
+
+	accept := func() net.Conn {
+		conn, err := listener.Accept()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return conn
+	}
+
+	itReadsOnPastTheHeader := func(header []byte) {
+		It("reads on past the header", func() {
+			client := send(header)
+			defer client.Close()
+
+			conn := accept()
+			defer conn.Close()
+
+			hello := make([]byte, 5)
+			_, err := conn.Read(hello)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(string(hello)).Should(Equal("hello"))
+		})
+	}
+
+	Context("with a version 1 header", func() {
+		header := []byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 7777\r\n")
+
+		It("reports the addresses it gives", func() {
+			client := send(header)
+			defer client.Close()
+
+			conn := accept()
+			defer conn.Close()
+
+			Ω(conn.RemoteAddr().String()).Should(Equal("192.0.2.1:56324"))
+			Ω(conn.LocalAddr().String()).Should(Equal("192.0.2.2:7777"))
+		})
+
+		itReadsOnPastTheHeader(header)
+	})
+
+	Context("with a version 1 UNKNOWN header", func() {
+		It("reports the connection's own addresses", func() {
+			client := send([]byte("PROXY UNKNOWN\r\n"))
+			defer client.Close()
+
+			conn := accept()
+			defer conn.Close()
+
+			Ω(conn.RemoteAddr().String()).Should(Equal(client.LocalAddr().String()))
+		})
+	})
+
+	Context("with a version 2 header", func() {
+		header := []byte("\r\n\r\n\x00\r\nQUIT\n")
+		header = append(header, 0x21, 0x21, 0, 36)
+		header = append(header, net.ParseIP("2001:db8::1")...)
+		header = append(header, net.ParseIP("2001:db8::2")...)
+		header = append(header, 0, 0, 0, 0)
+		binary.BigEndian.PutUint16(header[len(header)-4:], 56324)
+		binary.BigEndian.PutUint16(header[len(header)-2:], 7777)
+
+		It("reports the addresses it gives", func() {
+			client := send(header)
+			defer client.Close()
+
+			conn := accept()
+			defer conn.Close()
+
+			Ω(conn.RemoteAddr().String()).Should(Equal("[2001:db8::1]:56324"))
+			Ω(conn.LocalAddr().String()).Should(Equal("[2001:db8::2]:7777"))
+		})
+
+		itReadsOnPastTheHeader(header)
+	})
+
+	Context("without a header", func() {
+		It("closes the connection without accepting it", func() {
+			client := send([]byte("GET / HTTP/1.1\r\n\r\n"))
+			defer client.Close()
+
+			// closed with the request unread, the connection may be reset
+			// rather than ended
+			_, err := client.Read(make([]byte, 1))
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("while a connection has yet to send its header", func() {
+		It("accepts others", func() {
+			slow, err := net.Dial("tcp", listener.Addr().String())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer slow.Close()
+
+			client := send([]byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 7777\r\n"))
+			defer client.Close()
+
+			conn := accept()
+			defer conn.Close()
+
+			Ω(conn.RemoteAddr().String()).Should(Equal("192.0.2.1:56324"))
+		})
+	})
+})
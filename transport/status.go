@@ -0,0 +1,10 @@
+package transport
+
+// StatusUnprocessableEntity is the status of responses to requests which
+// were well-formed but invalid, e.g. with a field out of range. net/http
+// only names it from Go 1.7.
+const StatusUnprocessableEntity = 422
+
+// StatusTooManyRequests is the status of responses to requests beyond the
+// server's rate limits. net/http only names it from Go 1.6.
+const StatusTooManyRequests = 429
@@ -0,0 +1,11 @@
+package transport
+
+// ExpectedSizeHeader may be set on requests to stream files in to a
+// container, giving the number of bytes the files will occupy so the server
+// can check the container's disk quota before extracting anything.
+const ExpectedSizeHeader = "X-Garden-Expected-Size"
+
+// CompressionGzip is the value of the compression query parameter on
+// requests to stream files in or out of a container whose tar streams are
+// gzipped.
+const CompressionGzip = "gzip"
@@ -0,0 +1,6 @@
+package transport
+
+// TraceHeader carries the ID of the trace a request is part of, e.g. of the
+// upstream request it was made for. Servers log it with everything they do for
+// the request, and echo it in their response.
+const TraceHeader = "X-Garden-Trace-Id"
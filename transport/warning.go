@@ -0,0 +1,6 @@
+package transport
+
+// WarningHeader is set by servers on responses to requests they carried out
+// other than as asked, e.g. with a grace time clamped to the server's
+// maximum. It may be given more than once.
+const WarningHeader = "X-Garden-Warning"
@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// WriteTimeoutError is returned by writes which did not finish within their
+// timeout, e.g. to a peer which stopped reading. The message may have been
+// partly written, leaving the stream unusable, so it should be closed.
+type WriteTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e WriteTimeoutError) Error() string {
+	return fmt.Sprintf("write timed out after %s", e.Timeout)
+}
+
+// A DeadlineWriter is a writer whose writes can be given a deadline, e.g. a
+// net.Conn.
+type DeadlineWriter interface {
+	io.Writer
+	SetWriteDeadline(time.Time) error
+}
+
+// NewTimeoutWriter returns a writer each of whose writes must finish within
+// the timeout, failing with a WriteTimeoutError otherwise. A timeout of 0
+// leaves writes unbounded.
+func NewTimeoutWriter(w DeadlineWriter, timeout time.Duration) io.Writer {
+	return &timeoutWriter{w: w, timeout: timeout}
+}
+
+type timeoutWriter struct {
+	w       DeadlineWriter
+	timeout time.Duration
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	if w.timeout == 0 {
+		return w.w.Write(data)
+	}
+
+	err := w.w.SetWriteDeadline(time.Now().Add(w.timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.w.Write(data)
+	if isTimeout(err) {
+		return n, WriteTimeoutError{Timeout: w.timeout}
+	}
+
+	return n, err
+}
+
+// ErrWriteCancelled is returned by writes given up on as they were
+// cancelled. As with WriteTimeoutError, the message may have been partly
+// written.
+var ErrWriteCancelled = errors.New("write cancelled")
+
+// WriteMessageWithin is like WriteMessage, but gives up once the timeout
+// passes, failing with a WriteTimeoutError, or once cancel is closed, failing
+// with ErrWriteCancelled. A timeout of 0 or a nil cancel leaves it unbounded
+// by them.
+func WriteMessageWithin(w DeadlineWriter, msg interface{}, timeout time.Duration, cancel <-chan struct{}) error {
+	var deadline time.Time
+	if timeout != 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	err := w.SetWriteDeadline(deadline)
+	if err != nil {
+		return err
+	}
+
+	written := make(chan struct{})
+	watched := make(chan struct{})
+
+	cancelled := false
+
+	go func() {
+		defer close(watched)
+
+		select {
+		case <-cancel:
+			cancelled = true
+
+			// a deadline in the past fails the write in progress
+			w.SetWriteDeadline(time.Unix(1, 0))
+		case <-written:
+		}
+	}()
+
+	err = WriteMessage(w, msg)
+
+	close(written)
+	<-watched
+
+	if cancelled {
+		// the write may have finished before it was failed
+		if err == nil {
+			w.SetWriteDeadline(time.Time{})
+			return nil
+		}
+
+		return ErrWriteCancelled
+	}
+
+	if isTimeout(err) {
+		return WriteTimeoutError{Timeout: timeout}
+	}
+
+	w.SetWriteDeadline(time.Time{})
+
+	return err
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
@@ -0,0 +1,68 @@
+package transport_test
+
+import (
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden/apitypes"
+	"github.com/cloudfoundry-incubator/garden/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Writing with deadlines", func() {
+	var conn net.Conn
+	var peer net.Conn
+
+	BeforeEach(func() {
+		// writes to pipes block until they are read
+		conn, peer = net.Pipe()
+	})
+
+	AfterEach(func() {
+		conn.Close()
+		peer.Close()
+	})
+
+	Describe("NewTimeoutWriter", func() {
+		It("writes to peers which read", func() {
+			go ioutil.ReadAll(peer)
+
+			_, err := transport.NewTimeoutWriter(conn, time.Second).Write([]byte("hello"))
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("fails with a WriteTimeoutError once a write takes longer than the timeout", func() {
+			_, err := transport.NewTimeoutWriter(conn, 100*time.Millisecond).Write([]byte("hello"))
+			Ω(err).Should(Equal(transport.WriteTimeoutError{Timeout: 100 * time.Millisecond}))
+		})
+	})
+
+	Describe("WriteMessageWithin", func() {
+		It("writes to peers which read", func() {
+			go ioutil.ReadAll(peer)
+
+			err := transport.WriteMessageWithin(conn, &apitypes.PingResponse{}, time.Second, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("gives up once cancelled, failing with ErrWriteCancelled", func() {
+			cancel := make(chan struct{})
+
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				close(cancel)
+			}()
+
+			err := transport.WriteMessageWithin(conn, &apitypes.PingResponse{}, 0, cancel)
+			Ω(err).Should(Equal(transport.ErrWriteCancelled))
+		})
+
+		It("gives up once the timeout passes, failing with a WriteTimeoutError", func() {
+			err := transport.WriteMessageWithin(conn, &apitypes.PingResponse{}, 100*time.Millisecond, nil)
+			Ω(err).Should(Equal(transport.WriteTimeoutError{Timeout: 100 * time.Millisecond}))
+		})
+	})
+})